@@ -0,0 +1,151 @@
+package logreg
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+)
+
+// EncryptedGradient evaluates the update of the weights of an iteration of the gradient ascent on an encrypted
+// mini-batch, i.e. LearningRate/BatchSize * sum_i (y_i - g(x_i . w)) x_i. The linear transforms and the
+// approximation of the link function are precomputed once, so that an EncryptedGradient can be reused for any number
+// of iterations, mini-batches and models sharing the same Parameters, with any Evaluator holding the relinearization
+// key and the rotation keys listed by Rotations.
+type EncryptedGradient struct {
+	params    ckks.Parameters
+	p         Parameters
+	inner     *ckks.LinearTransformPrecomputed
+	aggregate *ckks.LinearTransformPrecomputed
+	link      *ckks.ChebyshevInterpolation
+}
+
+// NewEncryptedGradient creates a new EncryptedGradient. The ckks.Parameters must have at least p.Depth() levels,
+// and should have moduli close to their default scale.
+// Returns an error if the Parameters are invalid.
+func NewEncryptedGradient(params ckks.Parameters, encoder ckks.Encoder, p Parameters) (*EncryptedGradient, error) {
+
+	if err := p.Validate(params); err != nil {
+		return nil, fmt.Errorf("cannot NewEncryptedGradient: %w", err)
+	}
+
+	if params.MaxLevel() < p.Depth() {
+		return nil, fmt.Errorf("cannot NewEncryptedGradient: the parameters have %d levels but the gradient consumes %d levels", params.MaxLevel(), p.Depth())
+	}
+
+	slots := params.Slots()
+	d, b := p.Features, p.BatchSize
+
+	// The diagonals are indexed modulo the number of slots, so that the diagonals which are equal modulo the
+	// number of slots are merged.
+	diagonal := func(diags map[int][]complex128, k int) []complex128 {
+		k = ((k % slots) + slots) % slots
+		if _, ok := diags[k]; !ok {
+			diags[k] = make([]complex128, slots)
+		}
+		return diags[k]
+	}
+
+	// Sums the slots of each sample and replicates the sum on them, with the change of variable of the Chebyshev
+	// approximation: out[i*d+j] = sum_j' in[i*d+j'] / LinkInterval
+	inner := make(map[int][]complex128)
+	for k := -(d - 1); k < d; k++ {
+		diag := diagonal(inner, k)
+		for i := 0; i < b; i++ {
+			for j := 0; j < d; j++ {
+				if j+k >= 0 && j+k < d {
+					diag[i*d+j] += complex(1/p.LinkInterval, 0)
+				}
+			}
+		}
+	}
+
+	// Sums the slots of each feature over the samples, scaled by the learning rate, and replicates the sum on the
+	// slots of each sample: out[i*d+j] = LearningRate/BatchSize * sum_i' in[i'*d+j]
+	aggregate := make(map[int][]complex128)
+	for k := -(b - 1); k < b; k++ {
+		diag := diagonal(aggregate, k*d)
+		for i := 0; i < b; i++ {
+			if i+k >= 0 && i+k < b {
+				for j := 0; j < d; j++ {
+					diag[i*d+j] += complex(p.LearningRate/float64(b), 0)
+				}
+			}
+		}
+	}
+
+	// The inner transform is evaluated after the first product, and the aggregation after the second product,
+	// which follows the evaluation of the link function.
+	linkDepth := p.Depth() - 4
+
+	return &EncryptedGradient{
+		params:    params,
+		p:         p,
+		inner:     ckks.NewLinearTransformPrecomputed(params, encoder, inner, p.Depth()-1, params.MaxLevel()-1, params.LogSlots()),
+		aggregate: ckks.NewLinearTransformPrecomputed(params, encoder, aggregate, 1, params.MaxLevel()-linkDepth-3, params.LogSlots()),
+		link: ckks.Approximate(func(x complex128) complex128 {
+			return complex(p.Link(real(x)), 0)
+		}, complex(-p.LinkInterval, 0), complex(p.LinkInterval, 0), p.LinkDegree),
+	}, nil
+}
+
+// Parameters returns the Parameters of the EncryptedGradient.
+func (g *EncryptedGradient) Parameters() Parameters {
+	return g.p
+}
+
+// Rotations returns the rotations needed to evaluate the EncryptedGradient.
+func (g *EncryptedGradient) Rotations() (rotations []int) {
+
+	seen := make(map[int]bool)
+	for _, lt := range []*ckks.LinearTransformPrecomputed{g.inner, g.aggregate} {
+		for _, k := range lt.Rotations(g.params) {
+			if !seen[k] {
+				seen[k] = true
+				rotations = append(rotations, k)
+			}
+		}
+	}
+
+	return
+}
+
+// Compute returns the update of the weights w on the mini-batch, which consumes Parameters.Depth() levels of w. The
+// update has the layout of the weights, so that it can be added to them.
+// Returns an error if w does not have enough levels.
+func (g *EncryptedGradient) Compute(eval ckks.Evaluator, batch *EncryptedBatch, w *ckks.Ciphertext) (update *ckks.Ciphertext, err error) {
+
+	if w.Level() < g.p.Depth() {
+		return nil, fmt.Errorf("cannot Compute: weights level %d < depth %d", w.Level(), g.p.Depth())
+	}
+
+	// Inner products u_i = x_i . w / LinkInterval, replicated on the slots of each sample
+	ct := eval.MulRelinNew(batch.X, w)
+	if err = eval.Rescale(ct, g.params.Scale(), ct); err != nil {
+		return nil, fmt.Errorf("cannot Compute: %w", err)
+	}
+
+	ct = eval.LinearTransform(ct, g.inner)[0]
+	if err = eval.Rescale(ct, g.params.Scale(), ct); err != nil {
+		return nil, fmt.Errorf("cannot Compute: %w", err)
+	}
+
+	// Residuals y_i - g(x_i . w)
+	if ct, err = eval.EvaluateCheby(ct, g.link, ct.Scale()); err != nil {
+		return nil, fmt.Errorf("cannot Compute: %w", err)
+	}
+
+	eval.Sub(batch.Y, ct, ct)
+
+	// LearningRate/BatchSize * sum_i (y_i - g(x_i . w)) x_i
+	eval.MulRelin(ct, batch.X, ct)
+	if err = eval.Rescale(ct, g.params.Scale(), ct); err != nil {
+		return nil, fmt.Errorf("cannot Compute: %w", err)
+	}
+
+	update = eval.LinearTransform(ct, g.aggregate)[0]
+	if err = eval.Rescale(update, g.params.Scale(), update); err != nil {
+		return nil, fmt.Errorf("cannot Compute: %w", err)
+	}
+
+	return update, nil
+}
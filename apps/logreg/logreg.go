@@ -0,0 +1,154 @@
+// Package logreg implements the training of logistic regression models, and more generally of generalized linear
+// models (GLM), by mini-batch gradient descent on encrypted data with the CKKS scheme.
+//
+// A mini-batch of BatchSize samples with Features features (including the constant feature of the intercept, if
+// any) is packed in the slots of a ciphertext sample by sample: the j-th feature of the i-th sample is stored in the
+// slot i*Features + j. The labels are packed in a second ciphertext, each label being replicated on the Features
+// slots of its sample, and the weights of the model are replicated on the Features slots of each sample, so that
+// the slot-wise product of the weights and of a mini-batch gives the terms of the inner products of the samples
+// with the weights.
+//
+// The gradient of the log-likelihood of a GLM with inverse link function g is sum_i (y_i - g(x_i . w)) x_i, and
+// gradient ascent updates the weights with w = w + LearningRate/BatchSize * gradient. The inner products and the
+// sum over the samples of the mini-batch are evaluated with plaintext linear transforms, and g (e.g. the sigmoid of
+// the logistic regression) is evaluated with a Chebyshev approximation over an interval [-LinkInterval,
+// LinkInterval] containing the inner products.
+package logreg
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+)
+
+// Parameters are the parameters of the gradient of a GLM.
+type Parameters struct {
+	Features     int                   // Number of features of each sample, including the intercept
+	BatchSize    int                   // Number of samples of a mini-batch
+	LearningRate float64               // Learning rate of the gradient ascent
+	Link         func(float64) float64 // Inverse link function, e.g. Sigmoid for the logistic regression
+	LinkInterval float64               // The inner products of the samples with the weights must lie in [-LinkInterval, LinkInterval]
+	LinkDegree   int                   // Degree of the Chebyshev approximation of Link
+}
+
+// Sigmoid is the inverse link function of the logistic regression, 1/(1+e^-x).
+func Sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// Validate returns an error if the Parameters are invalid or if a mini-batch does not fit in the slots of a
+// ciphertext of the ckks.Parameters.
+func (p Parameters) Validate(params ckks.Parameters) error {
+	switch {
+	case p.Features < 1 || p.BatchSize < 1:
+		return fmt.Errorf("invalid mini-batch dimensions %dx%d", p.BatchSize, p.Features)
+	case p.Features*p.BatchSize > params.Slots():
+		return fmt.Errorf("a mini-batch of %d values does not fit in %d slots", p.Features*p.BatchSize, params.Slots())
+	case p.Link == nil:
+		return fmt.Errorf("the link function is nil")
+	case p.LinkInterval <= 0 || p.LinkDegree < 1:
+		return fmt.Errorf("invalid link approximation")
+	}
+	return nil
+}
+
+// Depth returns the number of levels consumed by an iteration of the gradient ascent: one level by each of the two
+// slot-wise products with the mini-batch, one level by each of the two linear transforms and the levels consumed by
+// the evaluation of the approximation of the link function.
+func (p Parameters) Depth() int {
+	return 4 + int(math.Ceil(math.Log2(float64(p.LinkDegree+1))))
+}
+
+// PackFeatures returns the slots of the mini-batch of samples X, which must have at most BatchSize samples of
+// Features features. The slots of the missing samples are set to zero, so that they do not contribute to the
+// gradient.
+func (p Parameters) PackFeatures(params ckks.Parameters, X [][]float64) (values []complex128) {
+
+	if len(X) > p.BatchSize {
+		panic(fmt.Sprintf("cannot PackFeatures: the number of samples (%d) is larger than the batch size (%d)", len(X), p.BatchSize))
+	}
+
+	values = make([]complex128, params.Slots())
+	for i, x := range X {
+		if len(x) != p.Features {
+			panic(fmt.Sprintf("cannot PackFeatures: sample %d has %d features instead of %d", i, len(x), p.Features))
+		}
+		for j, v := range x {
+			values[i*p.Features+j] = complex(v, 0)
+		}
+	}
+
+	return
+}
+
+// PackLabels returns the slots of the labels y of a mini-batch, each label being replicated on the slots of its
+// sample.
+func (p Parameters) PackLabels(params ckks.Parameters, y []float64) (values []complex128) {
+
+	if len(y) > p.BatchSize {
+		panic(fmt.Sprintf("cannot PackLabels: the number of labels (%d) is larger than the batch size (%d)", len(y), p.BatchSize))
+	}
+
+	values = make([]complex128, params.Slots())
+	for i, v := range y {
+		for j := 0; j < p.Features; j++ {
+			values[i*p.Features+j] = complex(v, 0)
+		}
+	}
+
+	return
+}
+
+// PackWeights returns the slots of the weights w, which are replicated on the slots of each sample.
+func (p Parameters) PackWeights(params ckks.Parameters, w []float64) (values []complex128) {
+
+	if len(w) != p.Features {
+		panic(fmt.Sprintf("cannot PackWeights: the number of weights (%d) is not the number of features (%d)", len(w), p.Features))
+	}
+
+	values = make([]complex128, params.Slots())
+	for i := 0; i < p.BatchSize; i++ {
+		for j, v := range w {
+			values[i*p.Features+j] = complex(v, 0)
+		}
+	}
+
+	return
+}
+
+// UnpackWeights returns the weights stored in the slots of the first sample of values, e.g. the decoding of the
+// weights returned by a Trainer.
+func (p Parameters) UnpackWeights(values []complex128) (w []float64) {
+	w = make([]float64, p.Features)
+	for j := range w {
+		w[j] = real(values[j])
+	}
+	return
+}
+
+// EncryptedBatch is an encrypted mini-batch of samples and of their labels.
+type EncryptedBatch struct {
+	X *ckks.Ciphertext // Features of the samples (see Parameters.PackFeatures)
+	Y *ckks.Ciphertext // Labels of the samples (see Parameters.PackLabels)
+}
+
+// EncryptBatch encodes and encrypts the mini-batch of samples X and of labels y at the maximum level and with the
+// default scale of the parameters.
+func EncryptBatch(params ckks.Parameters, p Parameters, encoder ckks.Encoder, encryptor ckks.Encryptor, X [][]float64, y []float64) *EncryptedBatch {
+
+	if len(X) != len(y) {
+		panic(fmt.Sprintf("cannot EncryptBatch: %d samples but %d labels", len(X), len(y)))
+	}
+
+	encrypt := func(values []complex128) *ckks.Ciphertext {
+		pt := ckks.NewPlaintext(params, params.MaxLevel(), params.Scale())
+		encoder.EncodeNTT(pt, values, params.LogSlots())
+		return encryptor.EncryptNew(pt)
+	}
+
+	return &EncryptedBatch{
+		X: encrypt(p.PackFeatures(params, X)),
+		Y: encrypt(p.PackLabels(params, y)),
+	}
+}
@@ -0,0 +1,194 @@
+package logreg
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogReg(t *testing.T) {
+
+	p := Parameters{
+		Features:     3,
+		BatchSize:    16,
+		LearningRate: 2,
+		Link:         Sigmoid,
+		LinkInterval: 8,
+		LinkDegree:   7,
+	}
+
+	// Two gradient iterations per refresh
+	logQ := []int{55}
+	for i := 0; i < 2*p.Depth(); i++ {
+		logQ = append(logQ, 40)
+	}
+
+	params, err := ckks.NewParametersFromLiteral(ckks.ParametersLiteral{
+		LogN:     10,
+		LogQ:     logQ,
+		LogP:     []int{61},
+		Sigma:    rlwe.DefaultSigma,
+		LogSlots: 9,
+		Scale:    1 << 40,
+	})
+	require.NoError(t, err)
+
+	kgen := ckks.NewKeyGenerator(params)
+	sk := kgen.GenSecretKey()
+	encoder := ckks.NewEncoder(params)
+	encryptor := ckks.NewEncryptorFromSk(params, sk)
+	decryptor := ckks.NewDecryptor(params, sk)
+
+	gradient, err := NewEncryptedGradient(params, encoder, p)
+	require.NoError(t, err)
+
+	eval := ckks.NewEvaluator(params, rlwe.EvaluationKey{
+		Rlk:  kgen.GenRelinearizationKey(sk),
+		Rtks: kgen.GenRotationKeysForRotations(gradient.Rotations(), false, sk),
+	})
+
+	// Linearly separable samples (x1, x2, 1) labelled by x1 - 2*x2 + 0.25 > 0
+	prng := rand.New(rand.NewSource(0))
+	X, y := make([][]float64, 4*p.BatchSize), make([]float64, 4*p.BatchSize)
+	for i := range X {
+		x1, x2 := 2*prng.Float64()-1, 2*prng.Float64()-1
+		X[i] = []float64{x1, x2, 1}
+		if x1-2*x2+0.25 > 0 {
+			y[i] = 1
+		}
+	}
+
+	batches := make([]*EncryptedBatch, len(X)/p.BatchSize)
+	for i := range batches {
+		batches[i] = EncryptBatch(params, p, encoder, encryptor, X[i*p.BatchSize:(i+1)*p.BatchSize], y[i*p.BatchSize:(i+1)*p.BatchSize])
+	}
+
+	encryptWeights := func(w []float64) *ckks.Ciphertext {
+		pt := ckks.NewPlaintext(params, params.MaxLevel(), params.Scale())
+		encoder.EncodeNTT(pt, p.PackWeights(params, w), params.LogSlots())
+		return encryptor.EncryptNew(pt)
+	}
+
+	decryptWeights := func(ct *ckks.Ciphertext) []float64 {
+		return p.UnpackWeights(encoder.Decode(decryptor.DecryptNew(ct), params.LogSlots()))
+	}
+
+	// The refresh re-encrypts the weights, in place of a bootstrapping
+	refresh := func(ct *ckks.Ciphertext) *ckks.Ciphertext {
+		return encryptWeights(decryptWeights(ct))
+	}
+
+	// train runs the gradient ascent in cleartext with the exact link function
+	train := func(w []float64, iterations int) []float64 {
+		w = append([]float64{}, w...)
+		for it := 0; it < iterations; it++ {
+			update := make([]float64, p.Features)
+			for i := it % len(batches) * p.BatchSize; i < (it%len(batches)+1)*p.BatchSize; i++ {
+				var u float64
+				for j := range w {
+					u += X[i][j] * w[j]
+				}
+				for j := range update {
+					update[j] += (y[i] - p.Link(u)) * X[i][j]
+				}
+			}
+			for j := range w {
+				w[j] += p.LearningRate / float64(p.BatchSize) * update[j]
+			}
+		}
+		return w
+	}
+
+	accuracy := func(w []float64) float64 {
+		var correct int
+		for i := range X {
+			var u float64
+			for j := range w {
+				u += X[i][j] * w[j]
+			}
+			if (u > 0) == (y[i] == 1) {
+				correct++
+			}
+		}
+		return float64(correct) / float64(len(X))
+	}
+
+	t.Run("Parameters", func(t *testing.T) {
+
+		invalid := p
+		invalid.BatchSize = params.Slots()
+		_, err := NewEncryptedGradient(params, encoder, invalid)
+		require.Error(t, err)
+
+		invalid = p
+		invalid.Link = nil
+		_, err = NewEncryptedGradient(params, encoder, invalid)
+		require.Error(t, err)
+
+		invalid = p
+		invalid.LinkDegree = 1 << 10
+		_, err = NewEncryptedGradient(params, encoder, invalid)
+		require.Error(t, err)
+	})
+
+	t.Run("Gradient", func(t *testing.T) {
+
+		w := []float64{0.5, -0.5, 0.1}
+
+		update, err := gradient.Compute(eval, batches[0], encryptWeights(w))
+		require.NoError(t, err)
+		require.Equal(t, params.MaxLevel()-p.Depth(), update.Level())
+
+		want := train(w, 1)
+		for j, v := range decryptWeights(update) {
+			require.InDelta(t, want[j]-w[j], v, 1e-2)
+		}
+
+		// The weights have levels for a single more iteration
+		update, err = gradient.Compute(eval, batches[0], update)
+		require.NoError(t, err)
+		_, err = gradient.Compute(eval, batches[0], update)
+		require.Error(t, err)
+	})
+
+	t.Run("Train", func(t *testing.T) {
+
+		iterations := 8
+
+		trainer := NewTrainer(gradient, eval, refresh, 0)
+
+		w, err := trainer.Train(encryptWeights(make([]float64, p.Features)), batches, iterations)
+		require.NoError(t, err)
+
+		// The weights are refreshed every two iterations when they run out of levels
+		require.Equal(t, (iterations-1)/2, trainer.Refreshes())
+
+		have, want := decryptWeights(w), train(make([]float64, p.Features), iterations)
+		for j := range have {
+			require.InDelta(t, want[j], have[j], 5e-2)
+		}
+
+		require.Greater(t, accuracy(have), 0.9)
+	})
+
+	t.Run("Cadence", func(t *testing.T) {
+
+		trainer := NewTrainer(gradient, eval, refresh, 1)
+
+		_, err := trainer.Train(encryptWeights(make([]float64, p.Features)), batches, 3)
+		require.NoError(t, err)
+		require.Equal(t, 2, trainer.Refreshes())
+	})
+
+	t.Run("NoRefresh", func(t *testing.T) {
+
+		trainer := NewTrainer(gradient, eval, nil, 0)
+
+		_, err := trainer.Train(encryptWeights(make([]float64, p.Features)), batches, 3)
+		require.Error(t, err)
+		require.Zero(t, trainer.Refreshes())
+	})
+}
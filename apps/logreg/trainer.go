@@ -0,0 +1,77 @@
+package logreg
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+)
+
+// Trainer trains the encrypted weights of a model by gradient ascent over a sequence of encrypted mini-batches, and
+// refreshes the weights when they do not have enough levels left for an iteration, or every Cadence iterations
+// if Cadence is positive.
+//
+// The weights are refreshed by a function returning a copy of its input with more levels, e.g. the Bootstrap method
+// of a ckks.AutoBootstrapEvaluator, or the re-encryption of the weights by their owner in an interactive protocol.
+// A Trainer is not safe for concurrent use.
+type Trainer struct {
+	gradient  *EncryptedGradient
+	eval      ckks.Evaluator
+	refresh   func(ct *ckks.Ciphertext) *ckks.Ciphertext
+	cadence   int
+	refreshes int
+}
+
+// NewTrainer creates a new Trainer evaluating gradient with eval, which must hold the relinearization key and the
+// rotation keys of the gradient (see EncryptedGradient.Rotations). The weights are refreshed with refresh, every
+// cadence iterations if cadence is positive and else only when needed. If refresh is nil, the number of iterations
+// is limited by the levels of the weights.
+func NewTrainer(gradient *EncryptedGradient, eval ckks.Evaluator, refresh func(ct *ckks.Ciphertext) *ckks.Ciphertext, cadence int) *Trainer {
+	return &Trainer{gradient: gradient, eval: eval, refresh: refresh, cadence: cadence}
+}
+
+// Refreshes returns the number of refreshes of the weights performed so far by the Trainer.
+func (t *Trainer) Refreshes() int {
+	return t.refreshes
+}
+
+// Train runs the given number of iterations of gradient ascent on the weights w, using the mini-batches in a
+// round-robin order, and returns the updated weights in a new ciphertext.
+// Returns an error if the weights do not have enough levels for an iteration and cannot be refreshed.
+func (t *Trainer) Train(w *ckks.Ciphertext, batches []*EncryptedBatch, iterations int) (*ckks.Ciphertext, error) {
+
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("cannot Train: no mini-batch")
+	}
+
+	depth := t.gradient.Parameters().Depth()
+
+	w = w.CopyNew()
+
+	for it := 0; it < iterations; it++ {
+
+		if w.Level() < depth || (t.cadence > 0 && it > 0 && it%t.cadence == 0) {
+
+			if t.refresh == nil {
+				if w.Level() < depth {
+					return nil, fmt.Errorf("cannot Train: weights level %d < depth %d at iteration %d and no refresh function", w.Level(), depth, it)
+				}
+			} else {
+				w = t.refresh(w)
+				t.refreshes++
+				if w.Level() < depth {
+					return nil, fmt.Errorf("cannot Train: refreshed weights level %d < depth %d", w.Level(), depth)
+				}
+			}
+		}
+
+		update, err := t.gradient.Compute(t.eval, batches[it%len(batches)], w)
+		if err != nil {
+			return nil, fmt.Errorf("cannot Train: iteration %d: %w", it, err)
+		}
+
+		t.eval.Add(w, update, update)
+		w = update
+	}
+
+	return w, nil
+}
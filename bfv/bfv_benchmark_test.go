@@ -2,6 +2,7 @@ package bfv
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/ldsec/lattigo/v2/rlwe"
@@ -35,6 +36,7 @@ func BenchmarkBFV(b *testing.B) {
 		benchEncrypt(testctx, b)
 		benchDecrypt(testctx, b)
 		benchEvaluator(testctx, b)
+		benchGadget(testctx, b)
 	}
 }
 
@@ -258,3 +260,35 @@ func benchEvaluator(testctx *testContext, b *testing.B) {
 		}
 	})
 }
+
+// benchGadget benchmarks the key-switch with the gadget decompositions of the rlwe package, and reports the size of
+// their switching keys, to compare the tradeoffs of the bases of the bit decomposition.
+func benchGadget(testctx *testContext, b *testing.B) {
+
+	if testctx.params.PCount() == 0 {
+		return
+	}
+
+	params := testctx.params.Parameters
+	skOut := testctx.kgen.GenSecretKey()
+	ciphertext := NewCiphertextRandom(testctx.prng, testctx.params, 1)
+
+	names, gadgets := []string{"RNS"}, []rlwe.Gadget{rlwe.NewRNSGadget(params)}
+	for _, logBase := range []int{8, 16, 30} {
+		names = append(names, fmt.Sprintf("Bit/logBase=%d", logBase), fmt.Sprintf("Hybrid/logBase=%d", logBase))
+		gadgets = append(gadgets, rlwe.NewBitGadget(params, logBase), rlwe.NewHybridGadget(params, logBase))
+	}
+
+	for i, gadget := range gadgets {
+
+		swk := rlwe.GenSwitchingKeyWithGadget(params, gadget, testctx.sk, skOut)
+		reEncryptor := rlwe.NewReEncryptorWithGadget(params, gadget, swk)
+
+		b.Run(testString("KeySwitch/Gadget="+names[i]+"/", testctx.params), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				reEncryptor.ReEncrypt(ciphertext.El(), ciphertext.El())
+			}
+			b.ReportMetric(float64(swk.GetDataLen(true)), "key-bytes")
+		})
+	}
+}
@@ -2,13 +2,17 @@ package bfv
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"math"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ldsec/lattigo/v2/internal/prngtest"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -39,6 +43,10 @@ type testContext struct {
 	evaluator   Evaluator
 }
 
+func TestMain(m *testing.M) {
+	prngtest.Main(m)
+}
+
 func TestBFV(t *testing.T) {
 
 	defaultParams := DefaultParams // the default test runs for ring degree N=2^12, 2^13, 2^14, 2^15
@@ -67,10 +75,15 @@ func TestBFV(t *testing.T) {
 
 		testParameters(testctx, t)
 		testEncoder(testctx, t)
+		testEncoderCoeff(testctx, t)
+		testBytes(testctx, t)
 		testEncryptor(testctx, t)
 		testEvaluator(testctx, t)
 		testEvaluatorKeySwitch(testctx, t)
 		testEvaluatorRotate(testctx, t)
+		testEvaluatorLevels(testctx, t)
+		testSanitizer(testctx, t)
+		testCleartextEvaluator(testctx, t)
 		testMarshaller(testctx, t)
 	}
 
@@ -96,7 +109,11 @@ func genTestParams(params Parameters) (testctx *testContext, err error) {
 		testctx.rlk = testctx.kgen.GenRelinearizationKey(testctx.sk, 1)
 	}
 
-	testctx.encoder = NewEncoder(testctx.params)
+	if params.AllowsBatching() {
+		testctx.encoder = NewEncoderBatch(testctx.params)
+	} else {
+		testctx.encoder = NewEncoderCoeff(testctx.params)
+	}
 	testctx.encryptorPk = NewEncryptorFromPk(testctx.params, testctx.pk)
 	testctx.encryptorSk = NewEncryptorFromSk(testctx.params, testctx.sk)
 	testctx.decryptor = NewDecryptor(testctx.params, testctx.sk)
@@ -243,6 +260,286 @@ func testEncoder(testctx *testContext, t *testing.T) {
 	})
 }
 
+func testEncoderCoeff(testctx *testContext, t *testing.T) {
+
+	for _, T := range []uint64{1 << 16, 3 * 5 * 7 * 11 * 13 * 17} {
+
+		params, err := NewParameters(testctx.params.Parameters, T)
+		require.NoError(t, err)
+		require.False(t, params.AllowsBatching())
+
+		require.Panics(t, func() { NewEncoderBatch(params) })
+
+		tc, err := genTestParams(params)
+		require.NoError(t, err)
+
+		t.Run(testString(fmt.Sprintf("EncoderCoeff/T=%d/Encode&Decode/RingQ/Uint/", T), params), func(t *testing.T) {
+			values, plaintext, _ := newTestVectorsRingQ(tc, nil, t)
+			verifyTestVectors(tc, nil, values, plaintext, t)
+		})
+
+		t.Run(testString(fmt.Sprintf("EncoderCoeff/T=%d/Encode&Decode/PlaintextMul/", T), params), func(t *testing.T) {
+			values, plaintext := newTestVectorsMul(tc, t)
+			verifyTestVectors(tc, nil, values, plaintext, t)
+		})
+
+		t.Run(testString(fmt.Sprintf("EncoderCoeff/T=%d/Evaluator/Add/", T), params), func(t *testing.T) {
+
+			values1, _, ciphertext1 := newTestVectorsRingQ(tc, tc.encryptorPk, t)
+			values2, _, ciphertext2 := newTestVectorsRingQ(tc, tc.encryptorPk, t)
+
+			tc.evaluator.Add(ciphertext1, ciphertext2, ciphertext1)
+			tc.ringT.Add(values1, values2, values1)
+
+			verifyTestVectors(tc, tc.decryptor, values1, ciphertext1, t)
+		})
+
+		t.Run(testString(fmt.Sprintf("EncoderCoeff/T=%d/Evaluator/Mul/", T), params), func(t *testing.T) {
+
+			if params.PCount() == 0 {
+				t.Skip("#Pi is empty")
+			}
+
+			values1, _, ciphertext1 := newTestVectorsRingQ(tc, tc.encryptorPk, t)
+			values2, _, ciphertext2 := newTestVectorsRingQ(tc, tc.encryptorPk, t)
+
+			receiver := NewCiphertext(params, 2)
+			tc.evaluator.Mul(ciphertext1, ciphertext2, receiver)
+			receiver = tc.evaluator.RelinearizeNew(receiver)
+
+			// Negacyclic convolution of the coefficients modulo T
+			N := params.N()
+			want := tc.ringT.NewPoly()
+			a, b, c := values1.Coeffs[0], values2.Coeffs[0], want.Coeffs[0]
+			for i := 0; i < N; i++ {
+				for j := 0; j < N; j++ {
+					v := (a[i] * b[j]) % T
+					if k := i + j; k < N {
+						c[k] = (c[k] + v) % T
+					} else {
+						c[k-N] = (c[k-N] + T - v) % T
+					}
+				}
+			}
+
+			verifyTestVectors(tc, tc.decryptor, want, receiver, t)
+		})
+	}
+}
+
+func testBytes(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Encoder/Bytes/", testctx.params), func(t *testing.T) {
+
+		data := []byte("lattigo\x00\x00")
+
+		plaintext := NewPlaintext(testctx.params)
+		testctx.encoder.EncodeBytes(data, plaintext)
+
+		dataTest, err := testctx.encoder.DecodeBytes(plaintext)
+		require.NoError(t, err)
+		require.Equal(t, data, dataTest)
+
+		dataTest, err = testctx.encoder.DecodeBytes(testctx.decryptor.DecryptNew(testctx.encryptorSk.EncryptNew(plaintext)))
+		require.NoError(t, err)
+		require.Equal(t, data, dataTest)
+
+		values := make([]uint64, testctx.params.N())
+		values[0] = uint64(testctx.params.N())
+		testctx.encoder.EncodeUint(values, plaintext)
+		_, err = testctx.encoder.DecodeBytes(plaintext)
+		require.Error(t, err)
+
+		require.Panics(t, func() { testctx.encoder.EncodeBytes(make([]byte, testctx.params.N()), plaintext) })
+	})
+
+	t.Run(testString("Evaluator/Equal/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.LogN() > 12 {
+			t.Skip("skipped for LogN > 12")
+		}
+
+		// The equality test consumes a depth of log2(t-1) = 16, hence a larger modulus than the default parameters.
+		// The Qi are kept small enough for the extended basis used in the tensoring to be sufficiently larger than Q.
+		logQ := make([]int, 14)
+		for i := range logQ {
+			logQ[i] = 50
+		}
+		params, err := NewParametersFromLiteral(ParametersLiteral{LogN: testctx.params.LogN(), LogQ: logQ, LogP: []int{61}, Sigma: rlwe.DefaultSigma, T: testctx.params.T()})
+		require.NoError(t, err)
+
+		tc, err := genTestParams(params)
+		require.NoError(t, err)
+
+		encode := func(s string) *Plaintext {
+			pt := NewPlaintext(params)
+			tc.encoder.EncodeBytes([]byte(s), pt)
+			return pt
+		}
+
+		cleartextEncryptor := NewCleartextEncryptor(params)
+		cleartextDecryptor := NewCleartextDecryptor(params)
+		cleartextEvaluator := NewCleartextEvaluator(params)
+
+		pt0 := encode("lattigo")
+
+		for _, tv := range []struct {
+			pt1   *Plaintext
+			equal bool
+		}{
+			{encode("lattigo"), true},
+			{encode("lattice"), false},
+			{encode("lattigo!"), false},
+		} {
+			res := tc.encoder.DecodeUintNew(tc.decryptor.DecryptNew(tc.evaluator.EqualNew(tc.encryptorSk.EncryptNew(pt0), tc.encryptorSk.EncryptNew(tv.pt1))))
+
+			allOnes := true
+			for _, v := range res {
+				allOnes = allOnes && v == 1
+			}
+			require.Equal(t, tv.equal, allOnes)
+
+			cleartext := cleartextEvaluator.EqualNew(cleartextEncryptor.EncryptNew(pt0), cleartextEncryptor.EncryptNew(tv.pt1))
+			require.Equal(t, tc.encoder.DecodeUintNew(cleartextDecryptor.DecryptNew(cleartext)), res)
+
+			// Equality with a plaintext operand
+			res = tc.encoder.DecodeUintNew(tc.decryptor.DecryptNew(tc.evaluator.EqualNew(tc.encryptorSk.EncryptNew(pt0), tv.pt1)))
+			require.Equal(t, tc.encoder.DecodeUintNew(cleartextDecryptor.DecryptNew(cleartext)), res)
+		}
+	})
+
+	t.Run(testString("Evaluator/EqualConst/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.LogN() > 12 {
+			t.Skip("skipped for LogN > 12")
+		}
+
+		// Same depth as Equal
+		logQ := make([]int, 14)
+		for i := range logQ {
+			logQ[i] = 50
+		}
+		params, err := NewParametersFromLiteral(ParametersLiteral{LogN: testctx.params.LogN(), LogQ: logQ, LogP: []int{61}, Sigma: rlwe.DefaultSigma, T: testctx.params.T()})
+		require.NoError(t, err)
+		require.Equal(t, 16, params.DepthEqual())
+
+		tc, err := genTestParams(params)
+		require.NoError(t, err)
+
+		values := make([]uint64, params.N())
+		for i := range values {
+			values[i] = uint64(i % 4)
+		}
+
+		pt := NewPlaintext(params)
+		tc.encoder.EncodeUint(values, pt)
+
+		res := tc.encoder.DecodeUintNew(tc.decryptor.DecryptNew(tc.evaluator.EqualConstNew(tc.encryptorSk.EncryptNew(pt), 2)))
+
+		for i, v := range values {
+			if v == 2 {
+				require.Equal(t, uint64(1), res[i])
+			} else {
+				require.Equal(t, uint64(0), res[i])
+			}
+		}
+	})
+
+	t.Run(testString("Evaluator/LessThan/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.LogN() > 12 {
+			t.Skip("skipped for LogN > 12")
+		}
+
+		bound := uint64(16)
+
+		logQ := make([]int, 6)
+		for i := range logQ {
+			logQ[i] = 50
+		}
+		params, err := NewParametersFromLiteral(ParametersLiteral{LogN: testctx.params.LogN(), LogQ: logQ, LogP: []int{61}, Sigma: rlwe.DefaultSigma, T: testctx.params.T()})
+		require.NoError(t, err)
+		require.Equal(t, 5, params.DepthLessThan(bound))
+
+		tc, err := genTestParams(params)
+		require.NoError(t, err)
+
+		values0 := make([]uint64, params.N())
+		values1 := make([]uint64, params.N())
+		for i := range values0 {
+			values0[i] = uint64(i) % bound
+			values1[i] = uint64(i/int(bound)) % bound
+		}
+
+		pt0, pt1 := NewPlaintext(params), NewPlaintext(params)
+		tc.encoder.EncodeUint(values0, pt0)
+		tc.encoder.EncodeUint(values1, pt1)
+
+		ct0 := tc.encryptorSk.EncryptNew(pt0)
+
+		cleartextEncryptor := NewCleartextEncryptor(params)
+		cleartext := NewCleartextEvaluator(params).LessThanNew(cleartextEncryptor.EncryptNew(pt0), cleartextEncryptor.EncryptNew(pt1), bound)
+		want := tc.encoder.DecodeUintNew(NewCleartextDecryptor(params).DecryptNew(cleartext))
+
+		for i := range want {
+			if values0[i] < values1[i] {
+				require.Equal(t, uint64(1), want[i])
+			} else {
+				require.Equal(t, uint64(0), want[i])
+			}
+		}
+
+		res := tc.encoder.DecodeUintNew(tc.decryptor.DecryptNew(tc.evaluator.LessThanNew(ct0, tc.encryptorSk.EncryptNew(pt1), bound)))
+		require.Equal(t, want, res)
+
+		// Comparison with a plaintext operand
+		res = tc.encoder.DecodeUintNew(tc.decryptor.DecryptNew(tc.evaluator.LessThanNew(ct0, pt1, bound)))
+		require.Equal(t, want, res)
+
+		require.Panics(t, func() { tc.evaluator.LessThanNew(ct0, pt1, params.T()) })
+	})
+
+	t.Run(testString("Evaluator/EvaluatePoly/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.LogN() > 12 {
+			t.Skip("skipped for LogN > 12")
+		}
+
+		coeffs := make([]uint64, 32)
+		for i := range coeffs {
+			coeffs[i] = utils.RandUint64() % testctx.params.T()
+		}
+		pol := NewPoly(coeffs)
+		require.Equal(t, 31, pol.Degree())
+		require.Equal(t, 5, testctx.params.DepthPoly(pol.Degree()))
+		require.Equal(t, 0, NewPoly([]uint64{1, 0, 0}).Degree())
+
+		// Modulus Q sized from the estimate
+		logQ := make([]int, (testctx.params.EstimateLogQPoly(pol.Degree())+49)/50)
+		for i := range logQ {
+			logQ[i] = 50
+		}
+		params, err := NewParametersFromLiteral(ParametersLiteral{LogN: testctx.params.LogN(), LogQ: logQ, LogP: []int{61}, Sigma: rlwe.DefaultSigma, T: testctx.params.T()})
+		require.NoError(t, err)
+
+		tc, err := genTestParams(params)
+		require.NoError(t, err)
+
+		pt := NewPlaintext(params)
+		tc.encoder.EncodeUint(tc.uSampler.ReadNew().Coeffs[0], pt)
+
+		cleartextEncryptor := NewCleartextEncryptor(params)
+		cleartextDecryptor := NewCleartextDecryptor(params)
+		cleartextEvaluator := NewCleartextEvaluator(params)
+
+		for _, pol := range []*Poly{pol, NewPoly(coeffs[:2]), NewPoly(coeffs[:17]), NewPoly([]uint64{3})} {
+			want := tc.encoder.DecodeUintNew(cleartextDecryptor.DecryptNew(cleartextEvaluator.EvaluatePolyNew(cleartextEncryptor.EncryptNew(pt), pol)))
+			res := tc.encoder.DecodeUintNew(tc.decryptor.DecryptNew(tc.evaluator.EvaluatePolyNew(tc.encryptorSk.EncryptNew(pt), pol)))
+			require.Equal(t, want, res)
+		}
+	})
+}
+
 func testEncryptor(testctx *testContext, t *testing.T) {
 
 	coeffs := testctx.uSampler.ReadNew()
@@ -274,6 +571,35 @@ func testEncryptor(testctx *testContext, t *testing.T) {
 		samplerQP := ring.NewUniformSampler(testctx.prng, testctx.ringQP)
 		verifyTestVectors(testctx, testctx.decryptor, coeffs, testctx.encryptorSk.EncryptFromCRPNew(plaintext, samplerQP.ReadNew()), t)
 	})
+
+	t.Run(testString("Encryptor/WithPRNG/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		newPRNG := func() utils.PRNG {
+			prng, err := utils.NewKeyedPRNG([]byte{'l', 'a', 't', 't', 'i', 'g', 'o'})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return prng
+		}
+
+		for _, newEncryptor := range []func() Encryptor{
+			func() Encryptor { return NewEncryptorFromPkWithPRNG(testctx.params, testctx.pk, newPRNG()) },
+			func() Encryptor { return NewEncryptorFromSkWithPRNG(testctx.params, testctx.sk, newPRNG()) },
+		} {
+			ciphertext0 := newEncryptor().EncryptNew(plaintext)
+			ciphertext1 := newEncryptor().EncryptNew(plaintext)
+
+			for i := range ciphertext0.Value {
+				require.True(t, ciphertext0.Value[i].Equals(ciphertext1.Value[i]))
+			}
+
+			verifyTestVectors(testctx, testctx.decryptor, coeffs, ciphertext0, t)
+		}
+	})
 }
 
 func testEvaluator(testctx *testContext, t *testing.T) {
@@ -493,6 +819,40 @@ func testEvaluator(testctx *testContext, t *testing.T) {
 		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
 	})
 
+	t.Run(testString("Evaluator/MulScalarBigint/", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		scalar := new(big.Int).Lsh(big.NewInt(1), 200)
+		scalar.Neg(scalar.Add(scalar, big.NewInt(37)))
+
+		testctx.evaluator.MulScalarBigint(ciphertext1, scalar, ciphertext1)
+		testctx.ringT.MulScalarBigint(values1, scalar, values1)
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/DivByConst/", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		testctx.evaluator.MulScalar(ciphertext1, 37, ciphertext1)
+		require.NoError(t, testctx.evaluator.DivByConst(ciphertext1, 37, ciphertext1))
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+
+		ciphertext2, err := testctx.evaluator.DivByConstNew(ciphertext1, 5)
+		require.NoError(t, err)
+		testctx.evaluator.MulScalar(ciphertext2, 5, ciphertext2)
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext2, t)
+
+		require.Error(t, testctx.evaluator.DivByConst(ciphertext1, 0, ciphertext1))
+		require.Error(t, testctx.evaluator.DivByConst(ciphertext1, 3*testctx.params.T(), ciphertext1))
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
 	t.Run(testString("Evaluator/Mul/op1=Ciphertext/op2=Ciphertext/", testctx.params), func(t *testing.T) {
 
 		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
@@ -570,6 +930,48 @@ func testEvaluator(testctx *testContext, t *testing.T) {
 		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
 	})
 
+	t.Run(testString("Evaluator/AddConstVector/", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2 := testctx.uSampler.ReadNew()
+
+		// The vector is shorter than the number of slots
+		short := values2.Coeffs[0][:testctx.params.N()/2]
+		for i := len(short); i < testctx.params.N(); i++ {
+			values2.Coeffs[0][i] = 0
+		}
+
+		ciphertext2, err := testctx.evaluator.AddConstVectorNew(ciphertext1, short)
+		require.NoError(t, err)
+		testctx.ringT.Add(values1, values2, values1)
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext2, t)
+
+		_, err = testctx.evaluator.AddConstVectorNew(ciphertext1, make([]uint64, testctx.params.N()+1))
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Evaluator/MulConstVectorThenAdd/", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values3 := testctx.uSampler.ReadNew()
+
+		eval := testctx.evaluator.ShallowCopy()
+		cached := len(eval.(*evaluator).constVectors.plaintexts)
+
+		// Accumulates twice with the same constants, the second time with the cached plaintext
+		for i := 0; i < 2; i++ {
+			require.NoError(t, eval.MulConstVectorThenAdd(ciphertext1, values3.Coeffs[0], ciphertext2))
+			tmp := testctx.ringT.NewPoly()
+			testctx.ringT.MulCoeffs(values1, values3, tmp)
+			testctx.ringT.Add(values2, tmp, values2)
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, values2, ciphertext2, t)
+		require.Len(t, eval.(*evaluator).constVectors.plaintexts, cached+1)
+	})
+
 	t.Run(testString("Evaluator/Mul/Relinearize/", testctx.params), func(t *testing.T) {
 
 		if testctx.params.PCount() == 0 {
@@ -589,6 +991,50 @@ func testEvaluator(testctx *testContext, t *testing.T) {
 		testctx.evaluator.Relinearize(receiver, receiver)
 		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
 	})
+
+	t.Run(testString("Evaluator/Mul/AutoRelinearize/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		eval := testctx.evaluator.WithRelinearization(true).ShallowCopy()
+
+		// The products are chained on ciphertexts of degree 1
+		ciphertext3 := eval.MulNew(ciphertext1, ciphertext2)
+		require.Equal(t, 1, ciphertext3.Degree())
+		eval.Mul(ciphertext3, ciphertext1, ciphertext3)
+		require.Equal(t, 1, ciphertext3.Degree())
+
+		// A receiver of larger degree is resized to degree 1
+		receiver := NewCiphertext(testctx.params, 2)
+		eval.Mul(ciphertext1, ciphertext2, receiver)
+		require.Equal(t, 1, receiver.Degree())
+
+		values3 := testctx.ringT.NewPoly()
+		testctx.ringT.MulCoeffs(values1, values2, values3)
+		verifyTestVectors(testctx, testctx.decryptor, values3, receiver, t)
+
+		// The parameters with LogN < 13 do not support a multiplicative depth of two
+		if testctx.params.LogN() >= 13 {
+			testctx.ringT.MulCoeffs(values3, values1, values3)
+			verifyTestVectors(testctx, testctx.decryptor, values3, ciphertext3, t)
+		}
+
+		// The products by a plaintext are not relinearized, and the policy can be disabled
+		require.Equal(t, 1, eval.MulNew(ciphertext1, NewPlaintext(testctx.params)).Degree())
+		require.Equal(t, 2, eval.WithRelinearization(false).MulNew(ciphertext1, ciphertext2).Degree())
+
+		// Without a relinearization key, the products are not relinearized
+		require.Equal(t, 2, eval.WithKey(rlwe.EvaluationKey{}).MulNew(ciphertext1, ciphertext2).Degree())
+
+		// The cleartext evaluator tracks the degrees in the same way
+		cleartext := NewCleartextEvaluator(testctx.params).WithRelinearization(true).ShallowCopy()
+		require.Equal(t, 1, cleartext.MulNew(ciphertext1, ciphertext2).Degree())
+	})
 }
 
 func testEvaluatorKeySwitch(testctx *testContext, t *testing.T) {
@@ -612,6 +1058,167 @@ func testEvaluatorKeySwitch(testctx *testContext, t *testing.T) {
 		ciphertext = testctx.evaluator.SwitchKeysNew(ciphertext, switchKey)
 		verifyTestVectors(testctx, decryptorSk2, values, ciphertext, t)
 	})
+
+	t.Run(testString("Evaluator/KeySwitch/Gadget/", testctx.params), func(t *testing.T) {
+
+		params := testctx.params.Parameters
+
+		for _, gadget := range []rlwe.Gadget{
+			rlwe.NewRNSGadget(params),
+			rlwe.NewBitGadget(params, 16),
+			rlwe.NewHybridGadget(params, 30),
+		} {
+			// The relinearization and the rotations of an Evaluator with the gadget
+			galEl := testctx.params.GaloisElementForColumnRotationBy(1)
+			eval := NewEvaluatorWithGadget(testctx.params, gadget, rlwe.EvaluationKey{
+				Rlk:  rlwe.GenRelinearizationKeyWithGadget(params, gadget, testctx.sk, 1),
+				Rtks: rlwe.GenRotationKeysWithGadget(params, gadget, []uint64{galEl}, testctx.sk),
+			})
+
+			values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+			values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+			receiver := eval.RelinearizeNew(eval.MulNew(ciphertext1, ciphertext2))
+			testctx.ringT.MulCoeffs(values1, values2, values1)
+			verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+
+			valuesWant := utils.RotateUint64Slots(values1.Coeffs[0], 1)
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, eval.ShallowCopy().RotateColumnsNew(receiver, 1), t)
+		}
+
+		// The relinearization of an Evaluator with the bit decomposition, which does not need the modulus P
+		paramsNoP, err := rlwe.NewParameters(params.LogN(), params.Q(), nil, params.Sigma())
+		require.NoError(t, err)
+		bfvParamsNoP, err := NewParameters(paramsNoP, testctx.params.T())
+		require.NoError(t, err)
+
+		sk := NewKeyGenerator(bfvParamsNoP).GenSecretKey()
+
+		gadget := rlwe.NewBitGadget(paramsNoP, 8)
+
+		encoder := NewEncoder(bfvParamsNoP)
+		coeffs := testctx.uSampler.ReadNew()
+		plaintext := NewPlaintext(bfvParamsNoP)
+		encoder.EncodeUint(coeffs.Coeffs[0], plaintext)
+		ciphertext := NewEncryptorFromSk(bfvParamsNoP, sk).EncryptNew(plaintext)
+
+		eval := NewEvaluatorWithGadget(bfvParamsNoP, gadget, rlwe.EvaluationKey{Rlk: rlwe.GenRelinearizationKeyWithGadget(paramsNoP, gadget, sk, 1)})
+		ringT := bfvParamsNoP.RingT()
+		ringT.MulCoeffs(coeffs, coeffs, coeffs)
+		ciphertext = eval.RelinearizeNew(eval.MulNew(ciphertext, ciphertext))
+		require.Equal(t, 1, ciphertext.Degree())
+		require.Equal(t, coeffs.Coeffs[0], encoder.DecodeUintNew(NewDecryptor(bfvParamsNoP, sk).DecryptNew(ciphertext)))
+	})
+
+	t.Run(testString("Evaluator/RGSW/", testctx.params), func(t *testing.T) {
+
+		params := testctx.params
+		ringQ, ringT := params.RingQ(), params.RingT()
+
+		rgswEncryptor := rlwe.NewRGSWEncryptor(params.Parameters, testctx.sk)
+		rgswEvaluator := rlwe.NewRGSWEvaluator(params.Parameters)
+
+		// The external product with an encryption of X^k multiplies the plaintext polynomial by X^k
+		k := 5
+		monomial := ringQ.NewPoly()
+		for i := range monomial.Coeffs {
+			monomial.Coeffs[i][k] = 1
+		}
+		rgsw := rgswEncryptor.EncryptNew(monomial)
+
+		data, err := rgsw.MarshalBinary()
+		require.NoError(t, err)
+		rgswNew := new(rlwe.RGSWCiphertext)
+		require.NoError(t, rgswNew.UnmarshalBinary(data))
+		require.True(t, rgsw.Equals(rgswNew))
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		ptRt := NewPlaintextRingT(params)
+		testctx.encoder.EncodeUintRingT(values.Coeffs[0], ptRt)
+		ringT.MultByMonomial(ptRt.value, k, ptRt.value)
+
+		rgswEvaluator.ExternalProduct(ciphertext.El(), rgswNew, ciphertext.El())
+		require.Equal(t, testctx.encoder.DecodeUintNew(ptRt), testctx.encoder.DecodeUintNew(testctx.decryptor.DecryptNew(ciphertext)))
+
+		// CMux selects the first input for an encryption of 0 and the second one for an encryption of 1
+		values0, _, ciphertext0 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		for bit, values := range []*ring.Poly{values0, values1} {
+			m := ringQ.NewPoly()
+			for i := range m.Coeffs {
+				m.Coeffs[i][0] = uint64(bit)
+			}
+			ciphertext := NewCiphertext(params, 1)
+			rgswEvaluator.CMux(rgswEncryptor.EncryptNew(m), ciphertext0.El(), ciphertext1.El(), ciphertext.El())
+			verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+		}
+	})
+
+	t.Run(testString("Evaluator/KeySwitch/RingSwitcher/", testctx.params), func(t *testing.T) {
+
+		large := testctx.params
+
+		small, err := NewParametersFromLiteral(ParametersLiteral{
+			LogN:  large.LogN() - 1,
+			Q:     large.Q()[:utils.MaxInt(1, large.QCount()-1)],
+			P:     large.P(),
+			Sigma: large.Sigma(),
+			T:     large.T(),
+		})
+		require.NoError(t, err)
+
+		skSmall := NewKeyGenerator(small).GenSecretKey()
+		rs := NewRingSwitcher(large, small, GenRingSwitchingKeys(large, small, testctx.sk, skSmall))
+
+		// Plaintext of the subring m(X^2)
+		valuesSmall := make([]uint64, small.N())
+		valuesLarge := make([]uint64, large.N())
+		for i := range valuesSmall {
+			valuesSmall[i] = utils.RandUint64() % large.T()
+			valuesLarge[2*i] = valuesSmall[i]
+		}
+
+		encoderLarge, encoderSmall := NewEncoderCoeff(large), NewEncoderCoeff(small)
+
+		pt := NewPlaintext(large)
+		encoderLarge.EncodeUint(valuesLarge, pt)
+		ctLarge := testctx.encryptorSk.EncryptNew(pt)
+
+		ctSmall := rs.ToSmallNew(ctLarge)
+		require.Equal(t, small.N(), ctSmall.Value[0].Degree())
+		require.Equal(t, rs.MaxLevel(), ctSmall.Level())
+		require.Equal(t, valuesSmall, encoderSmall.DecodeUintNew(NewDecryptor(small, skSmall).DecryptNew(ctSmall)))
+
+		ctLarge = rs.ToLargeNew(ctSmall)
+		require.Equal(t, large.N(), ctLarge.Value[0].Degree())
+		require.Equal(t, valuesLarge, encoderLarge.DecodeUintNew(testctx.decryptor.DecryptNew(ctLarge)))
+
+		params, err := NewParameters(small.Parameters, large.T()+2)
+		require.NoError(t, err)
+		require.Panics(t, func() { NewRingSwitcher(large, params, GenRingSwitchingKeys(large, params, testctx.sk, skSmall)) })
+	})
+
+	t.Run(testString("Evaluator/LowMemory/", testctx.params), func(t *testing.T) {
+
+		evalLowMem := NewEvaluatorWithOptions(testctx.params, rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: testctx.kgen.GenRotationKeysForRotations([]int{1}, false, testctx.sk)}, rlwe.Options{LowMemory: true})
+
+		for _, eval := range []Evaluator{evalLowMem, evalLowMem.ShallowCopy()} {
+
+			// The pools are allocated on the first use.
+			require.Nil(t, eval.(*evaluator).poolQ)
+			require.Nil(t, eval.(*evaluator).poolQKS[0])
+
+			values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+			values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+			receiver := eval.RelinearizeNew(eval.MulNew(ciphertext1, ciphertext2))
+			testctx.ringT.MulCoeffs(values1, values2, values1)
+			verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+
+			eval.RotateColumns(receiver, 1, receiver)
+			valuesWant := utils.RotateUint64Slots(values1.Coeffs[0], 1)
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, receiver, t)
+		}
+	})
 }
 
 func testEvaluatorRotate(testctx *testContext, t *testing.T) {
@@ -631,6 +1238,22 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
 	})
 
+	t.Run(testString("Evaluator/RotateRows/ConjugationKey/", testctx.params), func(t *testing.T) {
+
+		require.True(t, evaluator.HasConjugationKey())
+		require.False(t, testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk}).HasConjugationKey())
+
+		rotKey := testctx.kgen.GenConjugationKey(testctx.sk)
+		require.Len(t, rotKey.Keys, 1)
+		evalConj := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotKey})
+		require.True(t, evalConj.HasConjugationKey())
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		evalConj.RotateRows(ciphertext, ciphertext)
+		values.Coeffs[0] = append(values.Coeffs[0][testctx.params.N()>>1:], values.Coeffs[0][:testctx.params.N()>>1]...)
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
 	t.Run(testString("Evaluator/RotateRowsNew/", testctx.params), func(t *testing.T) {
 		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
 		ciphertext = evaluator.RotateRowsNew(ciphertext)
@@ -665,6 +1288,104 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 		}
 	})
 
+	t.Run(testString("Evaluator/RotateBy/", testctx.params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		for _, rot := range []rlwe.SlotRotation{rlwe.RotateLeft(4), rlwe.RotateRight(63)} {
+			valuesWant := utils.RotateUint64Slots(values.Coeffs[0], int(rot))
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, evaluator.RotateByNew(ciphertext, rot), t)
+		}
+
+		// X^N = -1
+		receiver := evaluator.RotateByNew(ciphertext, rlwe.CoeffShift(testctx.params.N()))
+		valuesWant := make([]uint64, len(values.Coeffs[0]))
+		for i, v := range values.Coeffs[0] {
+			valuesWant[i] = (testctx.params.T() - v) % testctx.params.T()
+		}
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, receiver, t)
+
+		evaluator.RotateBy(ciphertext, rlwe.CoeffShift(-7), receiver)
+		evaluator.RotateBy(receiver, rlwe.CoeffShift(7), receiver)
+		verifyTestVectors(testctx, testctx.decryptor, values, receiver, t)
+	})
+
+	t.Run(testString("Evaluator/RotationKeyProvider/", testctx.params), func(t *testing.T) {
+
+		// Generates the rotation keys on demand and caches them
+		cache := make(map[uint64]*rlwe.SwitchingKey)
+		provider := rlwe.RotationKeyProviderFunc(func(galEl uint64) (*rlwe.SwitchingKey, bool) {
+			if _, inCache := cache[galEl]; !inCache {
+				cache[galEl] = testctx.kgen.GenSwitchingKeyForGalois(galEl, testctx.sk)
+			}
+			return cache[galEl], true
+		})
+
+		evaluator := testctx.evaluator.WithRotationKeyProvider(provider)
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		for _, n := range []int{5, 5} {
+			receiver := evaluator.RotateColumnsNew(ciphertext, n)
+			valuesWant := utils.RotateUint64Slots(values.Coeffs[0], n)
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, receiver, t)
+		}
+
+		require.Len(t, cache, 1)
+	})
+
+	t.Run(testString("Evaluator/RotateMatrix/", testctx.params), func(t *testing.T) {
+
+		slots := testctx.params.N() >> 1
+
+		for _, matrix := range []SlotMatrix{{Rows: 4, Cols: 8}, {Rows: 16, Cols: slots / 16}} {
+
+			require.NoError(t, matrix.Validate(testctx.params))
+
+			rowShifts, colShifts := []int{0, 1, -1, 3}, []int{1, 5, -2}
+
+			rotations := []int{}
+			for _, k := range rowShifts {
+				rotations = append(rotations, matrix.RotationsForRows(testctx.params, k)...)
+			}
+			for _, k := range colShifts {
+				rotations = append(rotations, matrix.RotationsForCols(testctx.params, k)...)
+			}
+
+			rtks := testctx.kgen.GenRotationKeysForRotations(rotations, false, testctx.sk)
+			eval := NewMatrixEvaluator(testctx.params, testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rtks}), matrix)
+
+			values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+			// want returns the values whose entry (i, j) of both matrices is the entry src(i, j) of the input
+			want := func(src func(i, j int) (int, int)) *ring.Poly {
+				valuesWant := make([]uint64, testctx.params.N())
+				for i := 0; i < matrix.Rows; i++ {
+					for j := 0; j < matrix.Cols; j++ {
+						i0, j0 := src(i, j)
+						valuesWant[matrix.Slot(i, j)] = values.Coeffs[0][matrix.Slot(i0, j0)]
+						valuesWant[matrix.Slot(i, j)+slots] = values.Coeffs[0][matrix.Slot(i0, j0)+slots]
+					}
+				}
+				return &ring.Poly{Coeffs: [][]uint64{valuesWant}}
+			}
+
+			for _, k := range rowShifts {
+				valuesWant := want(func(i, j int) (int, int) { return ((i+k)%matrix.Rows + matrix.Rows) % matrix.Rows, j })
+				verifyTestVectors(testctx, testctx.decryptor, valuesWant, eval.RotateMatrixRowsNew(ciphertext, k), t)
+			}
+
+			receiver := NewCiphertext(testctx.params, 1)
+			for _, k := range colShifts {
+				valuesWant := want(func(i, j int) (int, int) { return i, ((j+k)%matrix.Cols + matrix.Cols) % matrix.Cols })
+				eval.RotateMatrixCols(ciphertext, k, receiver)
+				verifyTestVectors(testctx, testctx.decryptor, valuesWant, receiver, t)
+			}
+		}
+
+		require.Error(t, SlotMatrix{Rows: 2, Cols: slots}.Validate(testctx.params))
+	})
+
 	rotkey = testctx.kgen.GenRotationKeysForInnerSum(testctx.sk)
 	evaluator = evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
 
@@ -685,6 +1406,303 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 		}
 		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
 	})
+
+	t.Run(testString("Evaluator/Rotate/InnerSumLog/", testctx.params), func(t *testing.T) {
+
+		batch, n := 2, 5
+
+		rotkey := testctx.kgen.GenRotationKeysForRotations(testctx.params.RotationsForInnerSumLog(batch, n), false, testctx.sk)
+		evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		evaluator.InnerSumLog(ciphertext, batch, n, ciphertext)
+
+		// Each slot is the sum of the n sub-vectors of its row starting at its position
+		half := testctx.params.N() >> 1
+		want := make([]uint64, len(values.Coeffs[0]))
+		for i := range want {
+			row := i - i%half
+			for k := 0; k < n; k++ {
+				want[i] += values.Coeffs[0][row+(i+k*batch)%half]
+			}
+			want[i] %= testctx.params.T()
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/Rotate/BloomFilter/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.LogN() < 13 {
+			t.Skip("not enough levels for the membership circuit")
+		}
+
+		bf := NewBloomFilter(64, 3)
+		bf.Insert([]byte("alice"))
+		bf.Insert([]byte("bob"))
+
+		require.True(t, bf.Contains([]byte("alice")))
+
+		nonMember := []byte("eve")
+		for i := 0; bf.Contains(nonMember); i++ {
+			nonMember = []byte(fmt.Sprintf("eve%d", i))
+		}
+
+		filter := bf.EncodeNew(testctx.encoder, testctx.params)
+
+		for _, testCase := range []struct {
+			element  []byte
+			expected uint64
+		}{{[]byte("alice"), 1}, {nonMember, 0}} {
+
+			query := EncryptBloomFilterQuery(testCase.element, bf.Size(), bf.HashCount(), testctx.params, testctx.encoder, testctx.encryptorPk)
+			require.Len(t, query, bf.HashCount())
+
+			indicator := EvaluateBloomFilterMembership(evaluator, testctx.params, filter, query)
+
+			values := testctx.ringT.NewPoly()
+			for i := range values.Coeffs[0] {
+				values.Coeffs[0][i] = testCase.expected
+			}
+			verifyTestVectors(testctx, testctx.decryptor, values, indicator, t)
+		}
+	})
+
+	t.Run(testString("Evaluator/Rotate/Expand/", testctx.params), func(t *testing.T) {
+
+		params := testctx.params
+		encoderCoeff := NewEncoderCoeff(params)
+		T := params.T()
+
+		for _, logN := range []int{0, 3} {
+
+			evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rtks: testctx.kgen.GenRotationKeysForExpand(logN, testctx.sk)})
+
+			coeffs := make([]uint64, 1<<logN)
+			for i := range coeffs {
+				coeffs[i] = utils.RandUint64() % T
+			}
+
+			plaintext := NewPlaintext(params)
+			encoderCoeff.EncodeUint(coeffs, plaintext)
+
+			cleartexts := NewCleartextEvaluator(params).Expand(NewCleartextEncryptor(params).EncryptNew(plaintext), logN)
+			ciphertexts := evaluator.Expand(testctx.encryptorPk.EncryptNew(plaintext), logN)
+			require.Len(t, ciphertexts, 1<<logN)
+			require.Len(t, cleartexts, 1<<logN)
+
+			// ciphertexts[j] encrypts the constant 2^logN * coeffs[j]
+			for j := range ciphertexts {
+				want := make([]uint64, params.N())
+				want[0] = (coeffs[j] << logN) % T
+				require.Equal(t, want, encoderCoeff.DecodeUintNew(testctx.decryptor.DecryptNew(ciphertexts[j])))
+				require.Equal(t, want, encoderCoeff.DecodeUintNew(NewCleartextDecryptor(params).DecryptNew(cleartexts[j])))
+			}
+		}
+	})
+}
+
+func testSanitizer(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Sanitizer/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		sigma := float64(1 << 20)
+
+		sanitizer, err := NewSanitizer(testctx.params, testctx.pk, sigma)
+		require.NoError(t, err)
+
+		values, plaintext, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		ctOut := sanitizer.SanitizeNew(ciphertext)
+
+		verifyTestVectors(testctx, testctx.decryptor, values, ctOut, t)
+
+		// The second polynomial is re-randomized
+		require.False(t, testctx.ringQ.Equal(ciphertext.Value[1], ctOut.Value[1]))
+
+		// The noise has the standard deviation of the flooding noise
+		ringQ := testctx.ringQ
+		noise := testctx.decryptor.DecryptNew(ctOut).value
+		ringQ.Sub(noise, plaintext.value, noise)
+
+		q0 := ringQ.Modulus[0]
+		var variance float64
+		for _, c := range noise.Coeffs[0] {
+			e := float64(c)
+			if c > q0>>1 {
+				e = -float64(q0 - c)
+			}
+			variance += e * e
+		}
+		std := math.Sqrt(variance / float64(ringQ.N))
+
+		require.InDelta(t, sigma, std, sigma/10)
+
+		// Invalid flooding sigma
+		_, err = NewSanitizer(testctx.params, testctx.pk, 1)
+		require.Error(t, err)
+		_, err = NewSanitizer(testctx.params, testctx.pk, MaxFloodingSigma*2)
+		require.Error(t, err)
+		require.Error(t, testctx.params.CheckFloodingSigma(sigma, -1))
+
+		// Flooding noise beyond the precision of a float64
+		if largeSigma := float64(1 << 56); testctx.params.CheckFloodingSigma(largeSigma, testctx.params.MaxLevel()) == nil {
+			sanitizer, err := NewSanitizer(testctx.params, testctx.pk, largeSigma)
+			require.NoError(t, err)
+			verifyTestVectors(testctx, testctx.decryptor, values, sanitizer.SanitizeNew(ciphertext), t)
+		}
+
+		require.Panics(t, func() { sanitizer.SanitizeNew(NewCiphertext(testctx.params, 2)) })
+	})
+}
+
+func testEvaluatorLevels(testctx *testContext, t *testing.T) {
+
+	if testctx.params.MaxLevel() == 0 {
+		t.Skip("#Qi is 1")
+	}
+
+	t.Run(testString("Evaluator/SwitchModulus/", testctx.params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		for ciphertext.Level() > 0 {
+			level := ciphertext.Level()
+			ciphertext = testctx.evaluator.SwitchModulusNew(ciphertext)
+			require.Equal(t, level-1, ciphertext.Level())
+			verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+		}
+	})
+
+	t.Run(testString("Evaluator/SwitchModulus/InPlace/", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		testctx.evaluator.SwitchModulus(ciphertext, ciphertext)
+		require.Equal(t, testctx.params.MaxLevel()-1, ciphertext.Level())
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/Add/MixedLevels/", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, plaintext2, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values3, plaintext3 := newTestVectorsRingT(testctx, t)
+
+		testctx.evaluator.SwitchModulus(ciphertext1, ciphertext1)
+
+		receiver := testctx.evaluator.AddNew(ciphertext1, ciphertext2)
+		require.Equal(t, ciphertext1.Level(), receiver.Level())
+		testctx.ringT.Add(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+
+		testctx.evaluator.Sub(receiver, plaintext2, receiver)
+		testctx.ringT.Sub(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+
+		testctx.evaluator.Add(receiver, plaintext3, receiver)
+		testctx.ringT.Add(values1, values3, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+	})
+
+	t.Run(testString("Evaluator/Mul/SwitchModulus/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values3, plaintext3 := newTestVectorsMul(testctx, t)
+
+		receiver := testctx.evaluator.RelinearizeNew(testctx.evaluator.MulNew(ciphertext1, ciphertext2))
+		testctx.evaluator.SwitchModulus(receiver, receiver)
+		testctx.ringT.MulCoeffs(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+
+		// The remaining modulus must leave room for a second multiplication
+		if testctx.params.MaxLevel() < 2 {
+			t.Skip("#Qi is smaller than 3")
+		}
+
+		// The multiplication is performed at the level of the operands
+		receiver = testctx.evaluator.MulNew(receiver, ciphertext2)
+		require.Equal(t, testctx.params.MaxLevel()-1, receiver.Level())
+		testctx.evaluator.Relinearize(receiver, receiver)
+		testctx.ringT.MulCoeffs(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+
+		testctx.evaluator.Mul(receiver, plaintext3, receiver)
+		testctx.ringT.MulCoeffs(values1, values3, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+	})
+
+	t.Run(testString("Evaluator/RotateColumns/SwitchModulus/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		rotkey := testctx.kgen.GenRotationKeysForRotations([]int{1}, true, testctx.sk)
+		evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		ciphertext = evaluator.SwitchModulusNew(ciphertext)
+
+		receiver := evaluator.RotateColumnsNew(ciphertext, 1)
+		require.Equal(t, ciphertext.Level(), receiver.Level())
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{utils.RotateUint64Slots(values.Coeffs[0], 1)}}, receiver, t)
+
+		evaluator.RotateRows(receiver, receiver)
+		values.Coeffs[0] = utils.RotateUint64Slots(values.Coeffs[0], 1)
+		values.Coeffs[0] = append(values.Coeffs[0][testctx.params.N()>>1:], values.Coeffs[0][:testctx.params.N()>>1]...)
+		verifyTestVectors(testctx, testctx.decryptor, values, receiver, t)
+	})
+}
+
+func testCleartextEvaluator(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Cleartext/Circuit/", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		rotkey := testctx.kgen.GenRotationKeysForRotations([]int{3}, true, testctx.sk)
+		evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+		// The same circuit is evaluated with both evaluators and compared after decryption.
+		circuit := func(eval Evaluator, ct0, ct1 *Ciphertext, pt *PlaintextRingT) *Ciphertext {
+			ct := eval.MulNew(ct0, ct1)
+			eval.Relinearize(ct, ct)
+			eval.Add(ct, pt, ct)
+			eval.RotateColumns(ct, 3, ct)
+			eval.RotateRows(ct, ct)
+			eval.RotateBy(ct, rlwe.CoeffShift(5), ct)
+			eval.Sub(ct, ct0, ct)
+			eval.MulScalar(ct, 7, ct)
+			return ct
+		}
+
+		_, plaintext0, ciphertext0 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		_, plaintext1, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		_, plaintextRingT := newTestVectorsRingT(testctx, t)
+
+		encryptor := NewCleartextEncryptor(testctx.params)
+		decryptor := NewCleartextDecryptor(testctx.params)
+
+		ciphertext := circuit(evaluator, ciphertext0, ciphertext1, plaintextRingT)
+		cleartext := circuit(NewCleartextEvaluator(testctx.params), encryptor.EncryptNew(plaintext0), encryptor.EncryptNew(plaintext1), plaintextRingT)
+
+		require.Equal(t, ciphertext.Degree(), cleartext.Degree())
+
+		values := testctx.ringT.NewPoly()
+		testctx.encoder.DecodeUint(testctx.decryptor.DecryptNew(ciphertext), values.Coeffs[0])
+		verifyTestVectors(testctx, decryptor, values, cleartext, t)
+	})
 }
 
 func testMarshaller(testctx *testContext, t *testing.T) {
@@ -741,6 +1759,34 @@ func testMarshalParameters(testctx *testContext, t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, 2, paramsWithLogModuliNoP.QCount())
 		assert.Equal(t, 0, paramsWithLogModuliNoP.PCount())
+
+		// checks that bfv.Parameters can be unmarshalled from the name of a default parameter set
+		var paramsNamed Parameters
+		err = json.Unmarshal([]byte(`"PN12QP109"`), &paramsNamed)
+		assert.Nil(t, err)
+		paramsWant, err := NewParametersFromLiteral(PN12QP109)
+		assert.Nil(t, err)
+		assert.True(t, paramsWant.Equals(paramsNamed))
+
+		// checks that the fields of a default parameter set can be overridden
+		var paramsPreset Parameters
+		err = json.Unmarshal([]byte(`{"Preset":"PN13QP218","T":786433}`), &paramsPreset)
+		assert.Nil(t, err)
+		assert.Equal(t, 13, paramsPreset.LogN())
+		assert.Equal(t, uint64(786433), paramsPreset.T())
+		assert.Equal(t, PN13QP218.Q, paramsPreset.Q())
+		assert.Equal(t, PN13QP218.P, paramsPreset.P())
+
+		// checks that invalid representations are rejected
+		for _, data := range []string{
+			`"PN12QP0"`,
+			`{"Preset":"PN12QP109","Scale":1024}`,
+			`{"Preset":"PN12QP109","T":1}`,
+			`{"Preset":"PN12QP109","Sigma":-3.2}`,
+			`{"Preset":"PN12QP109","LogQ":"50"}`,
+		} {
+			assert.NotNil(t, json.Unmarshal([]byte(data), new(Parameters)), data)
+		}
 	})
 }
 
@@ -761,6 +1807,92 @@ func testMarshalCiphertext(testctx *testContext, t *testing.T) {
 			require.True(t, testctx.ringQ.Equal(ciphertextWant.Value[i], ciphertextTest.Value[i]))
 		}
 	})
+
+	t.Run(testString("Marshaller/Ciphertext/Versioned/", testctx.params), func(t *testing.T) {
+
+		ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 2)
+
+		data, err := MarshalVersioned(testctx.params, ciphertextWant)
+		require.NoError(t, err)
+
+		header, err := rlwe.Peek(data)
+		require.NoError(t, err)
+		require.Equal(t, rlwe.SchemeBFV, header.Scheme)
+		require.Equal(t, rlwe.ObjectCiphertext, header.Type)
+		require.Equal(t, ciphertextWant.Level(), header.Level)
+		require.Equal(t, 2, header.Degree)
+
+		ciphertextTest := new(Ciphertext)
+		_, err = UnmarshalVersioned(testctx.params, data, ciphertextTest)
+		require.NoError(t, err)
+		for i := range ciphertextWant.Value {
+			require.True(t, testctx.ringQ.Equal(ciphertextWant.Value[i], ciphertextTest.Value[i]))
+		}
+
+		// Another plaintext modulus gives other parameters
+		otherParams, err := NewParameters(testctx.params.Parameters, testctx.params.T()+2)
+		require.NoError(t, err)
+		_, err = UnmarshalVersioned(otherParams, data, new(Ciphertext))
+		require.True(t, errors.Is(err, rlwe.ErrIncompatible))
+
+		_, err = UnmarshalVersioned(testctx.params, data, new(rlwe.SecretKey))
+		require.True(t, errors.Is(err, rlwe.ErrIncompatible))
+
+		_, err = UnmarshalVersioned(testctx.params, data[:rlwe.HeaderSize+1], new(Ciphertext))
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Marshaller/Ciphertext/RLWE/", testctx.params), func(t *testing.T) {
+
+		coeffs, plaintext, ciphertextWant := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		data, err := ciphertextWant.RLWE().MarshalBinary()
+		require.NoError(t, err)
+
+		ctRLWE := new(rlwe.Ciphertext)
+		require.NoError(t, ctRLWE.UnmarshalBinary(data))
+		require.Equal(t, rlwe.SchemeBFV, ctRLWE.Scheme)
+		require.Equal(t, ciphertextWant.Degree(), ctRLWE.Degree())
+
+		ciphertextTest, err := NewCiphertextFromRLWE(ctRLWE)
+		require.NoError(t, err)
+		verifyTestVectors(testctx, testctx.decryptor, coeffs, ciphertextTest, t)
+
+		ptRLWE := plaintext.RLWE()
+		require.True(t, ptRLWE.Value[0] == plaintext.Value[0])
+		plaintextTest, err := NewPlaintextFromRLWE(ptRLWE)
+		require.NoError(t, err)
+		verifyTestVectors(testctx, testctx.decryptor, coeffs, plaintextTest, t)
+
+		ctRLWE.Scheme = rlwe.SchemeCKKS
+		_, err = NewCiphertextFromRLWE(ctRLWE)
+		require.Error(t, err)
+
+		ciphertextTest = NewCiphertextFromElement(ciphertextWant.El())
+		require.True(t, ciphertextTest.Value[0] == ciphertextWant.Value[0])
+		require.Equal(t, rlwe.SchemeBFV, ciphertextTest.Scheme)
+		verifyTestVectors(testctx, testctx.decryptor, coeffs, ciphertextTest, t)
+
+		require.True(t, ciphertextWant.RLWE() == ciphertextWant.Ciphertext)
+		require.Equal(t, rlwe.SchemeBFV, ciphertextWant.Scheme)
+
+		// Truncated or corrupted data are rejected without panicking
+		for _, n := range []int{0, 11, 12, 13, 14, len(data) / 2, len(data) - 1} {
+			require.Error(t, new(rlwe.Ciphertext).UnmarshalBinary(data[:n]), n)
+		}
+
+		for _, corrupt := range []func(data []byte){
+			func(data []byte) { data[11] = 0 },   // degree+1
+			func(data []byte) { data[11]++ },     // degree+1
+			func(data []byte) { data[12] = 255 }, // logN of the first polynomial
+			func(data []byte) { data[13]++ },     // number of moduli of the first polynomial
+			func(data []byte) { data[13] = 0 },   // number of moduli of the first polynomial
+		} {
+			dataCorrupt := append([]byte{}, data...)
+			corrupt(dataCorrupt)
+			require.Error(t, new(rlwe.Ciphertext).UnmarshalBinary(dataCorrupt))
+		}
+	})
 }
 
 func testMarshalSK(testctx *testContext, t *testing.T) {
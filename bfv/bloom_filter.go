@@ -0,0 +1,151 @@
+package bfv
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// BloomFilter is a Bloom filter of a given size with hashCount hash functions, which can be encoded in the slots
+// of a BFV plaintext to answer membership queries on encrypted elements (see EncryptBloomFilterQuery and
+// EvaluateBloomFilterMembership). The filter is held in clear by its owner: only the queried element is hidden.
+type BloomFilter struct {
+	bits      []bool
+	hashCount int
+}
+
+// NewBloomFilter creates a new empty BloomFilter of size bits with hashCount hash functions.
+func NewBloomFilter(size, hashCount int) *BloomFilter {
+
+	if size < 1 || hashCount < 1 {
+		panic("cannot NewBloomFilter: size and hashCount must be positive")
+	}
+
+	return &BloomFilter{bits: make([]bool, size), hashCount: hashCount}
+}
+
+// Size returns the number of bits of the filter.
+func (bf *BloomFilter) Size() int {
+	return len(bf.bits)
+}
+
+// HashCount returns the number of hash functions of the filter.
+func (bf *BloomFilter) HashCount() int {
+	return bf.hashCount
+}
+
+// Insert adds element to the filter.
+func (bf *BloomFilter) Insert(element []byte) {
+	for _, j := range BloomFilterPositions(element, len(bf.bits), bf.hashCount) {
+		bf.bits[j] = true
+	}
+}
+
+// Contains returns true if element may be in the filter and false if it is definitely not.
+func (bf *BloomFilter) Contains(element []byte) bool {
+	for _, j := range BloomFilterPositions(element, len(bf.bits), bf.hashCount) {
+		if !bf.bits[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode encodes the bits of the filter in the first slots of pt. The filter must not be larger than the number
+// of slots of the parameters.
+func (bf *BloomFilter) Encode(encoder Encoder, params Parameters, pt *PlaintextMul) {
+
+	if len(bf.bits) > params.N() {
+		panic(fmt.Sprintf("cannot Encode: the filter size (%d) is larger than the number of slots (%d)", len(bf.bits), params.N()))
+	}
+
+	coeffs := make([]uint64, params.N())
+	for j, b := range bf.bits {
+		if b {
+			coeffs[j] = 1
+		}
+	}
+
+	encoder.EncodeUintMul(coeffs, pt)
+}
+
+// EncodeNew encodes the bits of the filter in the first slots of a new plaintext (see Encode).
+func (bf *BloomFilter) EncodeNew(encoder Encoder, params Parameters) (pt *PlaintextMul) {
+	pt = NewPlaintextMul(params)
+	bf.Encode(encoder, params, pt)
+	return
+}
+
+// BloomFilterPositions returns the hashCount positions of element in a Bloom filter of the given size.
+// The positions are derived by double hashing from the BLAKE2b digest of element.
+func BloomFilterPositions(element []byte, size, hashCount int) (positions []int) {
+
+	digest := blake2b.Sum256(element)
+	h1 := binary.LittleEndian.Uint64(digest[0:8])
+	h2 := binary.LittleEndian.Uint64(digest[8:16]) | 1
+
+	positions = make([]int, hashCount)
+	for i := range positions {
+		positions[i] = int((h1 + uint64(i)*h2) % uint64(size))
+	}
+
+	return
+}
+
+// EncryptBloomFilterQuery encrypts a membership query for element in a Bloom filter of the given size with
+// hashCount hash functions. The query consists of one encrypted one-hot vector per hash function, selecting
+// the corresponding position of element.
+func EncryptBloomFilterQuery(element []byte, size, hashCount int, params Parameters, encoder Encoder, encryptor Encryptor) (query []*Ciphertext) {
+
+	if size > params.N() {
+		panic(fmt.Sprintf("cannot EncryptBloomFilterQuery: the filter size (%d) is larger than the number of slots (%d)", size, params.N()))
+	}
+
+	pt := NewPlaintext(params)
+	coeffs := make([]uint64, params.N())
+
+	query = make([]*Ciphertext, hashCount)
+	for i, j := range BloomFilterPositions(element, size, hashCount) {
+		coeffs[j] = 1
+		encoder.EncodeUint(coeffs, pt)
+		query[i] = encryptor.EncryptNew(pt)
+		coeffs[j] = 0
+	}
+
+	return
+}
+
+// EvaluateBloomFilterMembership evaluates an encrypted membership query (see EncryptBloomFilterQuery) on the
+// encoded filter and returns an encrypted indicator, whose slots are all 1 if the queried element may be in the
+// filter and 0 otherwise. Each bit selected by the query is extracted with a plaintext multiplication followed by
+// an InnerSum, and the bits are multiplied together. The evaluator must hold the rotation keys for InnerSum and,
+// if the query has more than one ciphertext, a relinearization key. The circuit consumes a multiplicative depth
+// of ceil(log2(len(query))) on top of the plaintext multiplication, whose noise growth is proportional to t*N:
+// parameters with logN >= 13 (e.g. PN13QP218) are required in practice.
+func EvaluateBloomFilterMembership(eval Evaluator, params Parameters, filter *PlaintextMul, query []*Ciphertext) (ctOut *Ciphertext) {
+
+	if len(query) == 0 {
+		panic("cannot EvaluateBloomFilterMembership: empty query")
+	}
+
+	bits := make([]*Ciphertext, len(query))
+	for i := range query {
+		bits[i] = NewCiphertext(params, 1)
+		eval.Mul(query[i], filter, bits[i])
+		eval.InnerSum(bits[i], bits[i])
+	}
+
+	// Product tree of the selected bits
+	for len(bits) > 1 {
+		for i := 0; i+1 < len(bits); i += 2 {
+			bits[i>>1] = eval.RelinearizeNew(eval.MulNew(bits[i], bits[i+1]))
+		}
+		if len(bits)&1 == 1 {
+			bits[len(bits)>>1] = bits[len(bits)-1]
+		}
+		bits = bits[:(len(bits)+1)>>1]
+	}
+
+	return bits[0]
+}
@@ -1,28 +1,67 @@
 package bfv
 
 import (
+	"fmt"
+
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 )
 
-// Ciphertext is a *ring.Poly array representing a polynomial of degree > 0 with coefficients in R_Q.
+// Ciphertext is a *ring.Poly array representing a polynomial of degree > 0 with coefficients in R_Q. It embeds an
+// rlwe.Ciphertext of the scheme rlwe.SchemeBFV, but keeps its own binary encoding (see MarshalBinary).
 type Ciphertext struct {
-	*rlwe.Element
+	*rlwe.Ciphertext
 }
 
 // NewCiphertext creates a new ciphertext parameterized by degree, level and scale.
 func NewCiphertext(params Parameters, degree int) (ciphertext *Ciphertext) {
-	return &Ciphertext{rlwe.NewElement(params.Parameters, degree)}
+	return NewCiphertextFromElement(rlwe.NewElement(params.Parameters, degree))
+}
+
+// NewCiphertextLvl creates a new ciphertext of the given degree at the given level.
+func NewCiphertextLvl(params Parameters, degree, level int) (ciphertext *Ciphertext) {
+	return NewCiphertextFromElement(rlwe.NewElementAtLevel(params.Parameters, degree, level))
+}
+
+// newCiphertextFromPool creates a new ciphertext of the given degree whose polynomials are drawn from ring.DefaultPolyPool.
+func newCiphertextFromPool(params Parameters, degree int) (ciphertext *Ciphertext) {
+	return newCiphertextAtLevelFromPool(params, degree, params.MaxLevel())
+}
+
+// newCiphertextAtLevelFromPool creates a new ciphertext of the given degree and level whose polynomials are drawn from
+// ring.DefaultPolyPool.
+func newCiphertextAtLevelFromPool(params Parameters, degree, level int) (ciphertext *Ciphertext) {
+	return NewCiphertextFromElement(rlwe.NewElementAtLevelFromPool(params.Parameters, degree, level))
+}
+
+// NewCiphertextFromElement returns the element el as a Ciphertext of the scheme rlwe.SchemeBFV. The two share their
+// polynomials. It replaces the composite literal &Ciphertext{el} of the previous versions.
+func NewCiphertextFromElement(el *rlwe.Element) *Ciphertext {
+	return &Ciphertext{&rlwe.Ciphertext{Element: el, MetaData: rlwe.MetaData{Scheme: rlwe.SchemeBFV}}}
 }
 
 // NewCiphertextRandom generates a new uniformly distributed ciphertext of degree, level and scale.
 func NewCiphertextRandom(prng utils.PRNG, params Parameters, degree int) (ciphertext *Ciphertext) {
-	ciphertext = &Ciphertext{rlwe.NewElement(params.Parameters, degree)}
+	ciphertext = NewCiphertext(params, degree)
 	rlwe.PopulateElementRandom(prng, params.Parameters, (*rlwe.Element)(ciphertext.Element))
 	return
 }
 
 // CopyNew creates a deep copy of the receiver ciphertext and returns it.
 func (ct *Ciphertext) CopyNew() *Ciphertext {
-	return &Ciphertext{ct.Element.CopyNew()}
+	return &Ciphertext{ct.Ciphertext.CopyNew()}
+}
+
+// RLWE returns the embedded rlwe.Ciphertext of the receiver ciphertext.
+func (ct *Ciphertext) RLWE() *rlwe.Ciphertext {
+	return ct.Ciphertext
+}
+
+// NewCiphertextFromRLWE returns the rlwe.Ciphertext ct as a Ciphertext. The two ciphertexts share their polynomials.
+// Returns an error if ct is not a ciphertext of the scheme rlwe.SchemeBFV.
+func NewCiphertextFromRLWE(ct *rlwe.Ciphertext) (*Ciphertext, error) {
+	if ct.Scheme != rlwe.SchemeBFV {
+		return nil, fmt.Errorf("cannot NewCiphertextFromRLWE: scheme %s is not BFV", ct.Scheme)
+	}
+	return &Ciphertext{ct}, nil
 }
@@ -0,0 +1,536 @@
+package bfv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// The cleartext Encryptor, Decryptor and Evaluator execute a circuit in the clear, for debugging and differential
+// testing, without any change to the code of the circuit. A cleartext Ciphertext is a regular Ciphertext that stores
+// the N plaintext slots (mod T) in the coefficients of its first polynomial, and zero everywhere else: it must only be
+// manipulated by the cleartext Encryptor, Decryptor and Evaluator. The degree of the cleartext ciphertexts is tracked
+// as for the encrypted ones, but the keys are ignored.
+
+// cleartextEncryptor is an Encryptor storing the decoded plaintext in the ciphertext.
+type cleartextEncryptor struct {
+	params  Parameters
+	encoder Encoder
+}
+
+// NewCleartextEncryptor instantiates a new Encryptor producing cleartext ciphertexts.
+func NewCleartextEncryptor(params Parameters) Encryptor {
+	return &cleartextEncryptor{params: params, encoder: NewEncoder(params)}
+}
+
+func (enc *cleartextEncryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
+	ciphertext := NewCiphertext(enc.params, 1)
+	enc.Encrypt(plaintext, ciphertext)
+	return ciphertext
+}
+
+func (enc *cleartextEncryptor) Encrypt(plaintext *Plaintext, ciphertext *Ciphertext) {
+	setCleartext(ciphertext, enc.encoder.DecodeUintNew(plaintext))
+}
+
+func (enc *cleartextEncryptor) EncryptFastNew(plaintext *Plaintext) *Ciphertext {
+	return enc.EncryptNew(plaintext)
+}
+
+func (enc *cleartextEncryptor) EncryptFast(plaintext *Plaintext, ciphertext *Ciphertext) {
+	enc.Encrypt(plaintext, ciphertext)
+}
+
+func (enc *cleartextEncryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext {
+	return enc.EncryptNew(plaintext)
+}
+
+func (enc *cleartextEncryptor) EncryptFromCRP(plaintext *Plaintext, ciphertext *Ciphertext, crp *ring.Poly) {
+	enc.Encrypt(plaintext, ciphertext)
+}
+
+func (enc *cleartextEncryptor) EncryptFromCRPFastNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext {
+	return enc.EncryptNew(plaintext)
+}
+
+func (enc *cleartextEncryptor) EncryptFromCRPFast(plaintext *Plaintext, ciphertext *Ciphertext, crp *ring.Poly) {
+	enc.Encrypt(plaintext, ciphertext)
+}
+
+// cleartextDecryptor is a Decryptor encoding the slots stored in the ciphertext.
+type cleartextDecryptor struct {
+	params  Parameters
+	encoder Encoder
+}
+
+// NewCleartextDecryptor instantiates a new Decryptor for cleartext ciphertexts.
+func NewCleartextDecryptor(params Parameters) Decryptor {
+	return &cleartextDecryptor{params: params, encoder: NewEncoder(params)}
+}
+
+func (dec *cleartextDecryptor) DecryptNew(ciphertext *Ciphertext) *Plaintext {
+	plaintext := NewPlaintext(dec.params)
+	dec.Decrypt(ciphertext, plaintext)
+	return plaintext
+}
+
+func (dec *cleartextDecryptor) Decrypt(ciphertext *Ciphertext, plaintext *Plaintext) {
+	dec.encoder.EncodeUint(getCleartext(ciphertext.Element, dec.params.N()), plaintext)
+}
+
+// cleartextEvaluator is an Evaluator operating on the slots stored in cleartext ciphertexts.
+type cleartextEvaluator struct {
+	params       Parameters
+	encoder      Encoder
+	ringT        *ring.Ring
+	constVectors *constVectorCache
+	autoRelin    bool
+}
+
+// NewCleartextEvaluator instantiates a new Evaluator operating on cleartext ciphertexts. Plaintext operands
+// of any type are decoded before the evaluation.
+func NewCleartextEvaluator(params Parameters) Evaluator {
+	return &cleartextEvaluator{params: params, encoder: NewEncoder(params), ringT: params.RingT(), constVectors: newConstVectorCache(params)}
+}
+
+// setCleartext writes the slots in the ciphertext.
+func setCleartext(ct *Ciphertext, values []uint64) {
+	for i := range ct.Value {
+		for j := range ct.Value[i].Coeffs {
+			for k := range ct.Value[i].Coeffs[j] {
+				ct.Value[i].Coeffs[j][k] = 0
+			}
+		}
+	}
+	copy(ct.Value[0].Coeffs[0], values)
+}
+
+// getCleartext returns a copy of the slots stored in the element.
+func getCleartext(el *rlwe.Element, N int) (values []uint64) {
+	values = make([]uint64, N)
+	copy(values, el.Value[0].Coeffs[0])
+	return
+}
+
+// values returns the slots of the operand, decoding it if it is a plaintext.
+func (eval *cleartextEvaluator) values(op Operand) []uint64 {
+	switch op := op.(type) {
+	case *Ciphertext:
+		return getCleartext(op.Element, eval.params.N())
+	default:
+		return eval.encoder.DecodeUintNew(op)
+	}
+}
+
+// setOutput resizes ctOut to the given degree and writes the slots.
+func (eval *cleartextEvaluator) setOutput(ctOut *Ciphertext, degree int, values []uint64) {
+	ctOut.Resize(eval.params.Parameters, degree)
+	setCleartext(ctOut, values)
+}
+
+func (eval *cleartextEvaluator) binary(op0, op1 Operand, ctOut *Ciphertext, f func(a, b uint64) uint64) {
+	v0, v1 := eval.values(op0), eval.values(op1)
+	for i := range v0 {
+		v0[i] = f(v0[i], v1[i])
+	}
+	degree := op0.Degree()
+	if op1.Degree() > degree {
+		degree = op1.Degree()
+	}
+	eval.setOutput(ctOut, degree, v0)
+}
+
+func (eval *cleartextEvaluator) newCiphertextBinary(op0, op1 Operand) *Ciphertext {
+	degree := op0.Degree()
+	if op1.Degree() > degree {
+		degree = op1.Degree()
+	}
+	return NewCiphertext(eval.params, degree)
+}
+
+func (eval *cleartextEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	t := eval.params.T()
+	eval.binary(op0, op1, ctOut, func(a, b uint64) uint64 { return (a + b) % t })
+}
+
+func (eval *cleartextEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Add(op0, op1, ctOut)
+}
+
+func (eval *cleartextEvaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.AddNew(op0, op1)
+}
+
+func (eval *cleartextEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	t := eval.params.T()
+	eval.binary(op0, op1, ctOut, func(a, b uint64) uint64 { return (a + t - b) % t })
+}
+
+func (eval *cleartextEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.Sub(op0, op1, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Sub(op0, op1, ctOut)
+}
+
+func (eval *cleartextEvaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.SubNew(op0, op1)
+}
+
+func (eval *cleartextEvaluator) Neg(op Operand, ctOut *Ciphertext) {
+	t := eval.params.T()
+	values := eval.values(op)
+	for i := range values {
+		values[i] = (t - values[i]) % t
+	}
+	eval.setOutput(ctOut, op.Degree(), values)
+}
+
+func (eval *cleartextEvaluator) NegNew(op Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op.Degree())
+	eval.Neg(op, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) Reduce(op Operand, ctOut *Ciphertext) {
+	eval.setOutput(ctOut, op.Degree(), eval.values(op))
+}
+
+func (eval *cleartextEvaluator) ReduceNew(op Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op.Degree())
+	eval.Reduce(op, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) AddConstVector(ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error) {
+	return evalAddConstVector(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+func (eval *cleartextEvaluator) AddConstVectorNew(ctIn *Ciphertext, values []uint64) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree())
+	return ctOut, eval.AddConstVector(ctIn, values, ctOut)
+}
+
+func (eval *cleartextEvaluator) MulConstVectorThenAdd(ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error) {
+	return evalMulConstVectorThenAdd(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+func (eval *cleartextEvaluator) MulScalar(op Operand, scalar uint64, ctOut *Ciphertext) {
+	t := eval.params.T()
+	bredParams := ring.BRedParams(t)
+	values := eval.values(op)
+	for i := range values {
+		values[i] = ring.BRed(values[i], scalar%t, t, bredParams)
+	}
+	eval.setOutput(ctOut, op.Degree(), values)
+}
+
+func (eval *cleartextEvaluator) MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op.Degree())
+	eval.MulScalar(op, scalar, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) MulScalarBigint(op Operand, scalar *big.Int, ctOut *Ciphertext) {
+	eval.MulScalar(op, new(big.Int).Mod(scalar, ring.NewUint(eval.params.T())).Uint64(), ctOut)
+}
+
+func (eval *cleartextEvaluator) MulScalarBigintNew(op Operand, scalar *big.Int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op.Degree())
+	eval.MulScalarBigint(op, scalar, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) DivByConst(op Operand, scalar uint64, ctOut *Ciphertext) (err error) {
+
+	inv := new(big.Int).ModInverse(ring.NewUint(scalar), ring.NewUint(eval.params.T()))
+	if inv == nil {
+		return fmt.Errorf("cannot DivByConst: %d is not invertible modulo t=%d", scalar, eval.params.T())
+	}
+
+	eval.MulScalar(op, inv.Uint64(), ctOut)
+
+	return nil
+}
+
+func (eval *cleartextEvaluator) DivByConstNew(op Operand, scalar uint64) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, op.Degree())
+	if err = eval.DivByConst(op, scalar, ctOut); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (eval *cleartextEvaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+	t := eval.params.T()
+	bredParams := ring.BRedParams(t)
+	v0, v1 := eval.values(op0), eval.values(op1)
+	for i := range v0 {
+		v0[i] = ring.BRed(v0[i], v1[i], t, bredParams)
+	}
+	eval.setOutput(ctOut, eval.mulDegree(op0, op1), v0)
+}
+
+// mulDegree returns the degree of the product of op0 and op1, which is relinearized to degree 1 if the automatic
+// relinearization is enabled.
+func (eval *cleartextEvaluator) mulDegree(op0, op1 Operand) int {
+	degree := op0.Degree() + op1.Degree()
+	if eval.autoRelin && degree > 1 {
+		return 1
+	}
+	return degree
+}
+
+func (eval *cleartextEvaluator) MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, eval.mulDegree(op0, op1))
+	eval.Mul(op0, op1, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) SwitchModulus(ct0 *Ciphertext, ctOut *Ciphertext) {
+	eval.setOutput(ctOut, ct0.Degree(), eval.values(ct0))
+}
+
+func (eval *cleartextEvaluator) SwitchModulusNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree())
+	eval.SwitchModulus(ct0, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) Relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
+	eval.setOutput(ctOut, 1, eval.values(ct0))
+}
+
+func (eval *cleartextEvaluator) RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.Relinearize(ct0, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext) {
+	eval.setOutput(ctOut, 1, eval.values(ct0))
+}
+
+func (eval *cleartextEvaluator) SwitchKeysNew(ct0 *Ciphertext, switchkey *rlwe.SwitchingKey) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.SwitchKeys(ct0, switchkey, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
+	values := eval.values(ct0)
+	rotated := make([]uint64, len(values))
+	half := len(values) >> 1
+	for i := 0; i < half; i++ {
+		j := (i + k) & (half - 1)
+		rotated[i] = values[j]
+		rotated[i+half] = values[j+half]
+	}
+	eval.setOutput(ctOut, ct0.Degree(), rotated)
+}
+
+func (eval *cleartextEvaluator) RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.RotateColumns(ct0, k, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) RotateRows(ct0 *Ciphertext, ctOut *Ciphertext) {
+	values := eval.values(ct0)
+	half := len(values) >> 1
+	eval.setOutput(ctOut, ct0.Degree(), append(values[half:], values[:half]...))
+}
+
+func (eval *cleartextEvaluator) RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.RotateRows(ct0, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) HasConjugationKey() bool {
+	return true
+}
+
+func (eval *cleartextEvaluator) RotateBy(ct0 *Ciphertext, rot rlwe.Rotation, ctOut *Ciphertext) {
+	switch rot := rot.(type) {
+	case rlwe.SlotRotation:
+		eval.RotateColumns(ct0, int(rot), ctOut)
+	case rlwe.CoeffShift:
+		// The multiplication by X^k is done on the coefficients of the plaintext polynomial in R_t.
+		ptRt := NewPlaintextRingT(eval.params)
+		eval.encoder.EncodeUintRingT(eval.values(ct0), ptRt)
+		eval.ringT.MultByMonomial(ptRt.value, rot.MonomialDegree(eval.params.Parameters), ptRt.value)
+		eval.ringT.Reduce(ptRt.value, ptRt.value)
+		eval.setOutput(ctOut, ct0.Degree(), eval.encoder.DecodeUintNew(ptRt))
+	default:
+		panic(fmt.Sprintf("cannot RotateBy: unsupported rotation type %T", rot))
+	}
+}
+
+func (eval *cleartextEvaluator) RotateByNew(ct0 *Ciphertext, rot rlwe.Rotation) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree())
+	eval.RotateBy(ct0, rot, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
+	t := eval.params.T()
+	values := eval.values(ct0)
+	var sum uint64
+	for _, v := range values {
+		sum = (sum + v) % t
+	}
+	for i := range values {
+		values[i] = sum
+	}
+	eval.setOutput(ctOut, 1, values)
+}
+
+func (eval *cleartextEvaluator) InnerSumLog(ct0 *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
+	t := eval.params.T()
+	values := eval.values(ct0)
+	half := len(values) >> 1
+	sums := make([]uint64, len(values))
+	for i := 0; i < half; i++ {
+		for k := 0; k < n; k++ {
+			j := (i + k*batchSize) & (half - 1)
+			sums[i] = (sums[i] + values[j]) % t
+			sums[i+half] = (sums[i+half] + values[j+half]) % t
+		}
+	}
+	eval.setOutput(ctOut, 1, sums)
+}
+
+func (eval *cleartextEvaluator) Expand(ct0 *Ciphertext, logN int) (ctOut []*Ciphertext) {
+
+	if logN < 0 || logN > eval.params.LogN() {
+		panic("cannot Expand: logN must be in [0, params.LogN()]")
+	}
+
+	// The expansion is done on the coefficients of the plaintext polynomial in R_t.
+	ringT := eval.ringT
+	N := ringT.N
+
+	polys := make([]*ring.Poly, 1<<logN)
+	ptRt := NewPlaintextRingT(eval.params)
+	eval.encoder.EncodeUintRingT(eval.values(ct0), ptRt)
+	polys[0] = ptRt.value.CopyNew()
+
+	sub := ringT.NewPoly()
+	for i, galEl := range eval.params.GaloisElementsForExpand(logN) {
+		for j := 0; j < 1<<i; j++ {
+			ringT.Permute(polys[j], galEl, sub)
+			polys[j+(1<<i)] = ringT.NewPoly()
+			ringT.Sub(polys[j], sub, polys[j+(1<<i)])
+			ringT.MultByMonomial(polys[j+(1<<i)], 2*N-(1<<i), polys[j+(1<<i)])
+			ringT.Reduce(polys[j+(1<<i)], polys[j+(1<<i)])
+			ringT.Add(polys[j], sub, polys[j])
+		}
+	}
+
+	ctOut = make([]*Ciphertext, len(polys))
+	for j, poly := range polys {
+		ringT.Copy(poly, ptRt.value)
+		ctOut[j] = NewCiphertext(eval.params, 1)
+		eval.setOutput(ctOut[j], 1, eval.encoder.DecodeUintNew(ptRt))
+	}
+
+	return
+}
+
+func (eval *cleartextEvaluator) Equal(ct0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+	v0, v1 := eval.values(ct0), eval.values(op1)
+	for i := range v0 {
+		if v0[i] == v1[i] {
+			v0[i] = 1
+		} else {
+			v0[i] = 0
+		}
+	}
+	eval.setOutput(ctOut, 1, v0)
+}
+
+func (eval *cleartextEvaluator) EqualNew(ct0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.Equal(ct0, op1, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) EqualConst(ct0 *Ciphertext, constant uint64, ctOut *Ciphertext) {
+	eval.Equal(ct0, constantPlaintext(eval.params, constant), ctOut)
+}
+
+func (eval *cleartextEvaluator) EqualConstNew(ct0 *Ciphertext, constant uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.EqualConst(ct0, constant, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) LessThan(ct0 *Ciphertext, op1 Operand, bound uint64, ctOut *Ciphertext) {
+	v0, v1 := eval.values(ct0), eval.values(op1)
+	for i := range v0 {
+		if v0[i] < v1[i] {
+			v0[i] = 1
+		} else {
+			v0[i] = 0
+		}
+	}
+	eval.setOutput(ctOut, 1, v0)
+}
+
+func (eval *cleartextEvaluator) LessThanNew(ct0 *Ciphertext, op1 Operand, bound uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.LessThan(ct0, op1, bound, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) EvaluatePoly(ct0 *Ciphertext, pol *Poly, ctOut *Ciphertext) {
+	t := eval.params.T()
+	bredParams := ring.BRedParams(t)
+	coeffs := pol.Coeffs()
+	values := eval.values(ct0)
+	for i, x := range values {
+		// Horner evaluation
+		var y uint64
+		for k := len(coeffs) - 1; k >= 0; k-- {
+			y = (ring.BRed(y, x, t, bredParams) + coeffs[k]%t) % t
+		}
+		values[i] = y
+	}
+	eval.setOutput(ctOut, 1, values)
+}
+
+func (eval *cleartextEvaluator) EvaluatePolyNew(ct0 *Ciphertext, pol *Poly) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.EvaluatePoly(ct0, pol, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) ShallowCopy() Evaluator {
+	return eval.WithRelinearization(eval.autoRelin)
+}
+
+func (eval *cleartextEvaluator) WithKey(rlwe.EvaluationKey) Evaluator {
+	return eval.ShallowCopy()
+}
+
+func (eval *cleartextEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	return eval.ShallowCopy()
+}
+
+func (eval *cleartextEvaluator) WithRelinearization(auto bool) Evaluator {
+	ev := NewCleartextEvaluator(eval.params).(*cleartextEvaluator)
+	ev.autoRelin = auto
+	return ev
+}
@@ -0,0 +1,143 @@
+package bfv
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// EqualConst evaluates the slot-wise equality test between ct0 and the constant and returns the result in ctOut:
+// each slot of ctOut is 1 if the corresponding slot of ct0 is equal to the constant and 0 otherwise (see Equal).
+// It consumes a multiplicative depth of Parameters.DepthEqual().
+func (eval *evaluator) EqualConst(ct0 *Ciphertext, constant uint64, ctOut *Ciphertext) {
+	eval.Equal(ct0, constantPlaintext(eval.params, constant), ctOut)
+}
+
+// EqualConstNew evaluates the slot-wise equality test between ct0 and the constant and returns the result in a
+// new ciphertext (see EqualConst).
+func (eval *evaluator) EqualConstNew(ct0 *Ciphertext, constant uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
+	eval.EqualConst(ct0, constant, ctOut)
+	return
+}
+
+// LessThan evaluates the slot-wise comparison between ct0 and op1 and returns the result in ctOut: each slot of
+// ctOut is 1 if the slot of ct0 is smaller than the corresponding slot of op1 and 0 otherwise. The slots of both
+// operands must be in [0, bound), with 1 <= bound <= (t+1)/2, otherwise the result is undefined.
+//
+// The circuit evaluates on the difference d = ct0 - op1 the polynomial of degree 2*bound-2 over Z_t that is 1 on
+// the negative differences -bound < d < 0 and 0 on the others, obtained by Lagrange interpolation. It requires the
+// parameters to allow batching (see Parameters.AllowsBatching), a relinearization key, and consumes a
+// multiplicative depth of Parameters.DepthLessThan(bound). The number of ciphertext multiplications is 2*bound-3,
+// so that the operation is only practical for small bounds.
+func (eval *evaluator) LessThan(ct0 *Ciphertext, op1 Operand, bound uint64, ctOut *Ciphertext) {
+
+	eval.checkWordSizedT("LessThan")
+
+	if !eval.params.AllowsBatching() {
+		panic("cannot LessThan: the parameters do not allow batching")
+	}
+
+	if ctOut.Degree() < 1 {
+		panic("cannot LessThan: output ciphertext must be at least of degree 1")
+	}
+
+	coeffs := lessThanCoefficients(eval.params.T(), bound)
+
+	diff := eval.SubNew(ct0, op1)
+	if diff.Degree() > 1 {
+		diff = eval.RelinearizeNew(diff)
+	}
+
+	// powers[k] = diff^k, with a balanced product tree to minimize the depth
+	degree := len(coeffs) - 1
+	powers := make([]*Ciphertext, degree+1)
+	if degree > 0 {
+		powers[1] = diff
+	}
+	for k := 2; k <= degree; k++ {
+		powers[k] = eval.RelinearizeNew(eval.MulNew(powers[k/2], powers[k-k/2]))
+	}
+
+	acc := NewCiphertextLvl(eval.params, 1, diff.Level())
+	tmp := NewCiphertextLvl(eval.params, 1, diff.Level())
+	for k := 1; k <= degree; k++ {
+		if coeffs[k] != 0 {
+			eval.MulScalar(powers[k], coeffs[k], tmp)
+			eval.Add(acc, tmp, acc)
+		}
+	}
+
+	eval.Add(acc, constantPlaintext(eval.params, coeffs[0]), ctOut)
+}
+
+// LessThanNew evaluates the slot-wise comparison between ct0 and op1 and returns the result in a new ciphertext
+// (see LessThan).
+func (eval *evaluator) LessThanNew(ct0 *Ciphertext, op1 Operand, bound uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, 1, eval.minLevel(ct0, op1))
+	eval.LessThan(ct0, op1, bound, ctOut)
+	return
+}
+
+// constantPlaintext returns a plaintext whose slots are all equal to constant.
+func constantPlaintext(params Parameters, constant uint64) *PlaintextRingT {
+	pt := NewPlaintextRingT(params)
+	pt.value.Coeffs[0][0] = constant % params.T()
+	return pt
+}
+
+// lessThanCoefficients returns the coefficients, in Z_t, of the polynomial of degree 2*bound-2 that is 1 on
+// {-bound+1, ..., -1} and 0 on {0, ..., bound-1}.
+func lessThanCoefficients(t, bound uint64) (coeffs []uint64) {
+
+	if bound < 1 || 2*bound-1 > t {
+		panic(fmt.Sprintf("cannot LessThan: bound must be in [1, (t+1)/2] but is %d", bound))
+	}
+
+	bredParams := ring.BRedParams(t)
+
+	// Interpolation points -bound+1, ..., bound-1
+	points := make([]uint64, 2*bound-1)
+	for j := range points {
+		points[j] = (t + uint64(j) + 1 - bound) % t
+	}
+
+	n := len(points)
+
+	// master = prod_j (X - x_j), of degree n
+	master := make([]uint64, n+1)
+	master[0] = 1
+	for j, x := range points {
+		for k := j + 1; k > 0; k-- {
+			master[k] = (master[k-1] + ring.BRed(master[k], t-x, t, bredParams)) % t
+		}
+		master[0] = ring.BRed(master[0], t-x, t, bredParams)
+	}
+
+	coeffs = make([]uint64, n)
+	quotient := make([]uint64, n)
+
+	// Only the negative points, which are the first bound-1 points, have a non-zero value.
+	for _, x := range points[:bound-1] {
+
+		// quotient = master / (X - x) by synthetic division
+		quotient[n-1] = master[n]
+		for k := n - 1; k > 0; k-- {
+			quotient[k-1] = (master[k] + ring.BRed(quotient[k], x, t, bredParams)) % t
+		}
+
+		// w = quotient(x) = prod_{j != x} (x - x_j)
+		var w uint64
+		for k := n - 1; k >= 0; k-- {
+			w = (ring.BRed(w, x, t, bredParams) + quotient[k]) % t
+		}
+
+		wInv := ring.ModExp(w, int(t-2), t)
+
+		for k := range coeffs {
+			coeffs[k] = (coeffs[k] + ring.BRed(quotient[k], wInv, t, bredParams)) % t
+		}
+	}
+
+	return
+}
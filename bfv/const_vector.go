@@ -0,0 +1,139 @@
+package bfv
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// constVectorCacheSize is the maximum number of encoded constant vectors cached by an evaluator.
+const constVectorCacheSize = 64
+
+type constVectorKey struct {
+	digest [sha256.Size]byte
+	mul    bool
+}
+
+// constVectorCache caches the plaintexts of the constant vectors of AddConstVector and MulConstVectorThenAdd, so
+// that the constants used repeatedly (e.g., the weights of a model applied to many ciphertexts) are encoded once.
+// The oldest plaintext is evicted when the cache is full. It is shared by the shallow copies of an evaluator and is
+// safe for concurrent use.
+type constVectorCache struct {
+	sync.Mutex
+	params     Parameters
+	encoder    Encoder
+	plaintexts map[constVectorKey]Operand
+	keys       []constVectorKey // from the oldest to the most recent plaintext
+}
+
+func newConstVectorCache(params Parameters) *constVectorCache {
+	return &constVectorCache{params: params, plaintexts: make(map[constVectorKey]Operand)}
+}
+
+// get returns the encoding of values on a *PlaintextMul if mul is true, and on a *Plaintext otherwise. The
+// returned plaintext must not be modified.
+func (c *constVectorCache) get(values []uint64, mul bool) (pt Operand, err error) {
+
+	params := c.params
+
+	if len(values) > params.N() {
+		return nil, fmt.Errorf("the vector has %d values but the parameters have %d slots", len(values), params.N())
+	}
+
+	h := sha256.New()
+	var buf [8]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+
+	key := constVectorKey{mul: mul}
+	copy(key.digest[:], h.Sum(nil))
+
+	c.Lock()
+	defer c.Unlock()
+
+	if pt, ok := c.plaintexts[key]; ok {
+		return pt, nil
+	}
+
+	if c.encoder == nil {
+		c.encoder = NewEncoder(params)
+	}
+
+	slots := make([]uint64, params.N())
+	copy(slots, values)
+
+	if mul {
+		ptMul := NewPlaintextMul(params)
+		c.encoder.EncodeUintMul(slots, ptMul)
+		pt = ptMul
+	} else {
+		ptAdd := NewPlaintext(params)
+		c.encoder.EncodeUint(slots, ptAdd)
+		pt = ptAdd
+	}
+
+	if len(c.keys) == constVectorCacheSize {
+		delete(c.plaintexts, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+
+	c.plaintexts[key] = pt
+	c.keys = append(c.keys, key)
+
+	return pt, nil
+}
+
+// AddConstVector adds the vector of constants values to the slots of ctIn and returns the result in ctOut. The
+// slots beyond the length of the vector are left unchanged. The encodings of the last vectors are cached by the
+// evaluator and its shallow copies. Returns an error if the vector is longer than the number of slots.
+func (eval *evaluator) AddConstVector(ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error) {
+	eval.checkWordSizedT("AddConstVector")
+	return evalAddConstVector(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+// AddConstVectorNew adds the vector of constants values to the slots of ctIn and returns the result in a newly
+// created element. See AddConstVector.
+func (eval *evaluator) AddConstVectorNew(ctIn *Ciphertext, values []uint64) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree())
+	return ctOut, eval.AddConstVector(ctIn, values, ctOut)
+}
+
+// MulConstVectorThenAdd multiplies the slots of ctIn by the vector of constants values and adds the result to
+// ctOut, i.e. it evaluates ctOut += ctIn * values. The slots beyond the length of the vector are multiplied by
+// zero. The encodings of the last vectors are cached by the evaluator and its shallow copies. Returns an error if
+// the vector is longer than the number of slots.
+func (eval *evaluator) MulConstVectorThenAdd(ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error) {
+	eval.checkWordSizedT("MulConstVectorThenAdd")
+	return evalMulConstVectorThenAdd(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+// evalAddConstVector implements AddConstVector on top of the Evaluator interface, so that it is shared by the
+// evaluator and the cleartext evaluator.
+func evalAddConstVector(eval Evaluator, cache *constVectorCache, ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error) {
+
+	var pt Operand
+	if pt, err = cache.get(values, false); err != nil {
+		return fmt.Errorf("cannot AddConstVector: %w", err)
+	}
+
+	eval.Add(ctIn, pt, ctOut)
+
+	return nil
+}
+
+// evalMulConstVectorThenAdd implements MulConstVectorThenAdd on top of the Evaluator interface, so that it is
+// shared by the evaluator and the cleartext evaluator.
+func evalMulConstVectorThenAdd(eval Evaluator, cache *constVectorCache, ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error) {
+
+	var pt Operand
+	if pt, err = cache.get(values, true); err != nil {
+		return fmt.Errorf("cannot MulConstVectorThenAdd: %w", err)
+	}
+
+	eval.Add(ctOut, eval.MulNew(ctIn, pt), ctOut)
+
+	return nil
+}
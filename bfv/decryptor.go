@@ -39,7 +39,7 @@ func NewDecryptor(params Parameters, sk *rlwe.SecretKey) Decryptor {
 }
 
 func (decryptor *decryptor) DecryptNew(ciphertext *Ciphertext) *Plaintext {
-	p := NewPlaintext(decryptor.params)
+	p := newPlaintextFromPool(decryptor.params)
 	decryptor.Decrypt(ciphertext, p)
 	return p
 }
@@ -49,21 +49,27 @@ func (decryptor *decryptor) Decrypt(ciphertext *Ciphertext, p *Plaintext) {
 	ringQ := decryptor.ringQ
 	tmp := decryptor.polypool
 
-	ringQ.NTTLazy(ciphertext.Value[ciphertext.Degree()], p.value)
+	level := ciphertext.Level()
+
+	ringQ.NTTLazyLvl(level, ciphertext.Value[ciphertext.Degree()], p.value)
 
 	for i := ciphertext.Degree(); i > 0; i-- {
-		ringQ.MulCoeffsMontgomery(p.value, decryptor.sk.Value, p.value)
-		ringQ.NTTLazy(ciphertext.Value[i-1], tmp)
-		ringQ.Add(p.value, tmp, p.value)
+		ringQ.MulCoeffsMontgomeryLvl(level, p.value, decryptor.sk.Value, p.value)
+		ringQ.NTTLazyLvl(level, ciphertext.Value[i-1], tmp)
+		ringQ.AddLvl(level, p.value, tmp, p.value)
 
 		if i&3 == 3 {
-			ringQ.Reduce(p.value, p.value)
+			ringQ.ReduceLvl(level, p.value, p.value)
 		}
 	}
 
 	if (ciphertext.Degree())&3 != 3 {
-		ringQ.Reduce(p.value, p.value)
+		ringQ.ReduceLvl(level, p.value, p.value)
 	}
 
-	ringQ.InvNTT(p.value, p.value)
+	ringQ.InvNTTLvl(level, p.value, p.value)
+
+	if level < decryptor.params.MaxLevel() {
+		scaleUpLevel(ringQ, level, p.value)
+	}
 }
@@ -16,6 +16,9 @@ const GaloisGen uint64 = 5
 
 // Encoder is an interface for plaintext encoding and decoding operations. It provides methods to embed []uint64 and []int64 types into
 // the various plaintext types and the inverse operations. It also provides methodes to convert between the different plaintext types.
+// Two implementations are provided: the batch encoder (see NewEncoderBatch), which packs the values in the slots of R_t and
+// requires t to be a prime congruent to 1 mod 2N, and the coefficient encoder (see NewEncoderCoeff), which packs the values
+// in the coefficients of R_t and supports any t.
 // The different plaintext types represent different embeddings of the message in the polynomial space. This relation is illustrated in
 // The figure below:
 //
@@ -54,27 +57,109 @@ type Encoder interface {
 	DecodeInt(pt interface{}, coeffs []int64)
 	DecodeUintNew(pt interface{}) (coeffs []uint64)
 	DecodeIntNew(pt interface{}) (coeffs []int64)
+
+	EncodeBytes(data []byte, pt *Plaintext)
+	DecodeBytes(pt interface{}) (data []byte, err error)
 }
 
-// Encoder is a structure that stores the parameters to encode values on a plaintext in a SIMD (Single-Instruction Multiple-Data) fashion.
-type encoder struct {
+// bytesToSlots maps a byte string onto a vector of N values: the first value stores the length of the string,
+// the next ones store its bytes, and the remaining ones are padded with zeros. Two byte strings are thus equal
+// if and only if their vectors are equal.
+func bytesToSlots(data []byte, N int, t uint64) (values []uint64) {
+
+	if t < 256 {
+		panic(fmt.Errorf("cannot EncodeBytes: plaintext modulus t=%d is smaller than 256", t))
+	}
+
+	if len(data) > N-1 || uint64(len(data)) >= t {
+		panic(fmt.Errorf("cannot EncodeBytes: len(data)=%d exceeds the capacity of the plaintext (%d bytes)", len(data), utils.MinInt(N-1, int(t-1))))
+	}
+
+	values = make([]uint64, N)
+	values[0] = uint64(len(data))
+	for i, b := range data {
+		values[i+1] = uint64(b)
+	}
+
+	return
+}
+
+// slotsToBytes is the inverse of bytesToSlots. It returns an error if the length metadata or the bytes are invalid.
+func slotsToBytes(values []uint64) (data []byte, err error) {
+
+	if values[0] > uint64(len(values)-1) {
+		return nil, fmt.Errorf("cannot DecodeBytes: invalid length metadata (%d)", values[0])
+	}
+
+	data = make([]byte, values[0])
+	for i := range data {
+		if values[i+1] > 255 {
+			return nil, fmt.Errorf("cannot DecodeBytes: invalid byte value (%d) at index %d", values[i+1], i)
+		}
+		data[i] = byte(values[i+1])
+	}
+
+	return
+}
+
+// encoderBase stores the parameters and methods shared by all the encoders, i.e., the conversions
+// between the different plaintext types, which do not depend on how the values are packed in R_t.
+type encoderBase struct {
 	params Parameters
 
 	ringQ *ring.Ring
+
+	scaler    ring.Scaler
+	deltaMont []uint64
+
+	tmpPtRt *PlaintextRingT
+}
+
+func newEncoderBase(params Parameters) encoderBase {
+	ringQ := params.RingQ()
+	return encoderBase{
+		params:    params,
+		ringQ:     ringQ,
+		deltaMont: GenLiftParams(ringQ, params.T()),
+		scaler:    newScaler(params.T(), ringQ),
+		tmpPtRt:   NewPlaintextRingT(params),
+	}
+}
+
+// newScaler returns the RNSScaler if t is an odd prime, and falls back on the
+// (slower) SimpleScaler, which supports any t, otherwise.
+func newScaler(t uint64, ringQ *ring.Ring) ring.Scaler {
+	if t&1 == 1 && ring.IsPrime(t) {
+		return ring.NewRNSScaler(t, ringQ)
+	}
+	return ring.NewSimpleScaler(t, ringQ)
+}
+
+// Encoder is a structure that stores the parameters to encode values on a plaintext in a SIMD (Single-Instruction Multiple-Data) fashion.
+type encoder struct {
+	encoderBase
+
 	ringT *ring.Ring
 
 	indexMatrix []uint64
-	scaler      ring.Scaler
-	deltaMont   []uint64
 
 	tmpPoly *ring.Poly
-	tmpPtRt *PlaintextRingT
 }
 
-// NewEncoder creates a new encoder from the provided parameters.
+// NewEncoder creates a new encoder from the provided parameters. It is equivalent to NewEncoderBatch.
 func NewEncoder(params Parameters) Encoder {
+	return NewEncoderBatch(params)
+}
+
+// NewEncoderBatch creates a new Encoder that packs the values in the N slots of the plaintext space R_t, so that
+// the homomorphic additions and multiplications act slot-wise on the encoded vectors. It requires the plaintext
+// modulus t to be a prime congruent to 1 mod 2N and panics otherwise (see Parameters.AllowsBatching).
+func NewEncoderBatch(params Parameters) Encoder {
+
+	if !params.AllowsBatching() {
+		panic(fmt.Errorf("cannot NewEncoderBatch: t=%d is not a prime congruent to 1 mod 2N (use NewEncoderCoeff instead)", params.T()))
+	}
 
-	ringQ := params.RingQ()
 	ringT := params.RingT()
 
 	var m, pos, index1, index2 uint64
@@ -102,14 +187,10 @@ func NewEncoder(params Parameters) Encoder {
 	}
 
 	return &encoder{
-		params:      params,
-		ringQ:       ringQ,
+		encoderBase: newEncoderBase(params),
 		ringT:       ringT,
 		indexMatrix: indexMatrix,
-		deltaMont:   GenLiftParams(ringQ, params.T()),
-		scaler:      ring.NewRNSScaler(params.T(), ringQ),
 		tmpPoly:     ringT.NewPoly(),
-		tmpPtRt:     NewPlaintextRingT(params),
 	}
 }
 
@@ -222,7 +303,7 @@ func (encoder *encoder) EncodeIntMul(coeffs []int64, p *PlaintextMul) {
 }
 
 // ScaleUp transforms a PlaintextRingT (R_t) into a Plaintext (R_q) by scaling up the coefficient by Q/t.
-func (encoder *encoder) ScaleUp(ptRt *PlaintextRingT, pt *Plaintext) {
+func (encoder *encoderBase) ScaleUp(ptRt *PlaintextRingT, pt *Plaintext) {
 	scaleUp(encoder.ringQ, encoder.deltaMont, ptRt.value, pt.value)
 }
 
@@ -252,14 +333,33 @@ func scaleUp(ringQ *ring.Ring, deltaMont []uint64, pIn, pOut *ring.Poly) {
 	}
 }
 
+// scaleUpLevel maps the polynomial p of R_{Q_level} to R_Q by multiplying it by Q/Q_level, where Q_level is the product
+// of the first level+1 moduli of Q. This maps the decryption of a ciphertext at the given level to the decryption of
+// the same message at the maximum level.
+func scaleUpLevel(ringQ *ring.Ring, level int, p *ring.Poly) {
+
+	qOverQLevel := ring.NewUint(1)
+	for _, qi := range ringQ.Modulus[level+1:] {
+		qOverQLevel.Mul(qOverQLevel, ring.NewUint(qi))
+	}
+
+	ringQ.MulScalarBigintLvl(level, p, qOverQLevel, p)
+
+	for i := level + 1; i < len(ringQ.Modulus); i++ {
+		for j := range p.Coeffs[i] {
+			p.Coeffs[i][j] = 0
+		}
+	}
+}
+
 // ScaleDown transforms a Plaintext (R_q) into a PlaintextRingT (R_t) by scaling down the coefficient by t/Q and rounding.
-func (encoder *encoder) ScaleDown(pt *Plaintext, ptRt *PlaintextRingT) {
+func (encoder *encoderBase) ScaleDown(pt *Plaintext, ptRt *PlaintextRingT) {
 	encoder.scaler.DivByQOverTRounded(pt.value, ptRt.value)
 }
 
 // RingTToMul transforms a PlaintextRingT into a PlaintextMul by operating the NTT transform
 // of R_q and putting the coefficients in Montgomery form.
-func (encoder *encoder) RingTToMul(ptRt *PlaintextRingT, ptMul *PlaintextMul) {
+func (encoder *encoderBase) RingTToMul(ptRt *PlaintextRingT, ptMul *PlaintextMul) {
 	if ptRt.value != ptMul.value {
 		copy(ptMul.value.Coeffs[0], ptRt.value.Coeffs[0])
 	}
@@ -273,13 +373,13 @@ func (encoder *encoder) RingTToMul(ptRt *PlaintextRingT, ptMul *PlaintextMul) {
 
 // MulToRingT transforms a PlaintextMul into PlaintextRingT by operating the inverse NTT transform of R_q and
 // putting the coefficients out of the Montgomery form.
-func (encoder *encoder) MulToRingT(pt *PlaintextMul, ptRt *PlaintextRingT) {
+func (encoder *encoderBase) MulToRingT(pt *PlaintextMul, ptRt *PlaintextRingT) {
 	encoder.ringQ.InvNTTLvl(0, pt.value, ptRt.value)
 	encoder.ringQ.InvMFormLvl(0, ptRt.value, ptRt.value)
 }
 
 // DecodeRingT decodes any plaintext type into a PlaintextRingT. It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
-func (encoder *encoder) DecodeRingT(p interface{}, ptRt *PlaintextRingT) {
+func (encoder *encoderBase) DecodeRingT(p interface{}, ptRt *PlaintextRingT) {
 	switch pt := p.(type) {
 	case *Plaintext:
 		encoder.ScaleDown(pt, ptRt)
@@ -345,3 +445,151 @@ func (encoder *encoder) DecodeIntNew(p interface{}) (coeffs []int64) {
 	encoder.DecodeInt(p, coeffs)
 	return
 }
+
+// EncodeBytes encodes a byte string of at most N-1 bytes on a plaintext, one byte per slot. The first slot stores the length
+// of the string and the unused slots are padded with zeros, so that the slot-wise equality (see Evaluator.Equal) of two
+// encoded strings is all ones if and only if the strings are equal. It panics if t is smaller than 256.
+func (encoder *encoder) EncodeBytes(data []byte, p *Plaintext) {
+	encoder.EncodeUint(bytesToSlots(data, encoder.ringQ.N, encoder.params.T()), p)
+}
+
+// DecodeBytes decodes any plaintext type generated by EncodeBytes and returns the byte string. It returns an error if
+// the plaintext does not store a valid encoding. It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (encoder *encoder) DecodeBytes(p interface{}) (data []byte, err error) {
+	return slotsToBytes(encoder.DecodeUintNew(p))
+}
+
+// encoderCoeff is an Encoder that packs the values directly in the coefficients of the plaintext polynomial.
+type encoderCoeff struct {
+	encoderBase
+}
+
+// NewEncoderCoeff creates a new Encoder that packs the values in the N coefficients of the plaintext polynomial in R_t.
+// It supports any plaintext modulus t (e.g., a power of two or a composite number), but the homomorphic multiplications
+// then act as negacyclic convolutions of the encoded vectors (i.e., products in Z_t[X]/(X^N+1)) instead of slot-wise products.
+func NewEncoderCoeff(params Parameters) Encoder {
+	return &encoderCoeff{newEncoderBase(params)}
+}
+
+// EncodeUintRingT encodes a slice of uint64 into the coefficients of a Plaintext in R_t.
+func (encoder *encoderCoeff) EncodeUintRingT(coeffs []uint64, p *PlaintextRingT) {
+
+	if len(coeffs) > encoder.ringQ.N {
+		panic("invalid input to encode: number of coefficients must be smaller or equal to the ring degree")
+	}
+
+	if len(p.value.Coeffs[0]) != encoder.ringQ.N {
+		panic("invalid plaintext to receive encoding: number of coefficients does not match the ring degree")
+	}
+
+	copy(p.value.Coeffs[0], coeffs)
+
+	for i := len(coeffs); i < encoder.ringQ.N; i++ {
+		p.value.Coeffs[0][i] = 0
+	}
+}
+
+// EncodeUint encodes an uint64 slice of size at most N on a plaintext.
+func (encoder *encoderCoeff) EncodeUint(coeffs []uint64, p *Plaintext) {
+	ptRt := &PlaintextRingT{p.Element, p.Element.Value[0]}
+	encoder.EncodeUintRingT(coeffs, ptRt)
+	encoder.ScaleUp(ptRt, p)
+}
+
+// EncodeUintMul encodes an uint64 slice of size at most N on a plaintext in the NTT+Montgomery domains of R_q.
+func (encoder *encoderCoeff) EncodeUintMul(coeffs []uint64, p *PlaintextMul) {
+	ptRt := &PlaintextRingT{p.Element, p.Element.Value[0]}
+	encoder.EncodeUintRingT(coeffs, ptRt)
+	encoder.RingTToMul(ptRt, p)
+}
+
+// EncodeIntRingT encodes an int64 slice into the coefficients of a Plaintext in R_t. It also encodes the sign of the given
+// integer (as its inverse modulo the plaintext modulus).
+func (encoder *encoderCoeff) EncodeIntRingT(coeffs []int64, p *PlaintextRingT) {
+
+	if len(coeffs) > encoder.ringQ.N {
+		panic("invalid input to encode: number of coefficients must be smaller or equal to the ring degree")
+	}
+
+	if len(p.value.Coeffs[0]) != encoder.ringQ.N {
+		panic("invalid plaintext to receive encoding: number of coefficients does not match the ring degree")
+	}
+
+	for i := 0; i < len(coeffs); i++ {
+		if coeffs[i] < 0 {
+			p.value.Coeffs[0][i] = uint64(int64(encoder.params.T()) + coeffs[i])
+		} else {
+			p.value.Coeffs[0][i] = uint64(coeffs[i])
+		}
+	}
+
+	for i := len(coeffs); i < encoder.ringQ.N; i++ {
+		p.value.Coeffs[0][i] = 0
+	}
+}
+
+// EncodeInt encodes an int64 slice of size at most N on a plaintext.
+func (encoder *encoderCoeff) EncodeInt(coeffs []int64, p *Plaintext) {
+	ptRt := &PlaintextRingT{p.Element, p.value}
+	encoder.EncodeIntRingT(coeffs, ptRt)
+	encoder.ScaleUp(ptRt, p)
+}
+
+// EncodeIntMul encodes an int64 slice of size at most N on a plaintext in the NTT+Montgomery domains of R_q.
+func (encoder *encoderCoeff) EncodeIntMul(coeffs []int64, p *PlaintextMul) {
+	ptRt := &PlaintextRingT{p.Element, p.value}
+	encoder.EncodeIntRingT(coeffs, ptRt)
+	encoder.RingTToMul(ptRt, p)
+}
+
+// DecodeUint decodes any plaintext type and writes its coefficients in coeffs. It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (encoder *encoderCoeff) DecodeUint(p interface{}, coeffs []uint64) {
+	encoder.DecodeRingT(p, encoder.tmpPtRt)
+	copy(coeffs, encoder.tmpPtRt.value.Coeffs[0])
+}
+
+// DecodeUintNew decodes any plaintext type and returns its coefficients in a new []uint64.
+// It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (encoder *encoderCoeff) DecodeUintNew(p interface{}) (coeffs []uint64) {
+	coeffs = make([]uint64, encoder.ringQ.N)
+	encoder.DecodeUint(p, coeffs)
+	return
+}
+
+// DecodeInt decodes any plaintext type and writes its coefficients in coeffs. It also decodes the sign
+// modulus (by centering the values around the plaintext). It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (encoder *encoderCoeff) DecodeInt(p interface{}, coeffs []int64) {
+
+	encoder.DecodeRingT(p, encoder.tmpPtRt)
+
+	modulus := int64(encoder.params.T())
+	modulusHalf := modulus >> 1
+	var value int64
+	for i := 0; i < encoder.ringQ.N; i++ {
+		value = int64(encoder.tmpPtRt.value.Coeffs[0][i])
+		coeffs[i] = value
+		if value >= modulusHalf {
+			coeffs[i] -= modulus
+		}
+	}
+}
+
+// DecodeIntNew decodes any plaintext type and returns its coefficients in a new []int64. It also decodes the sign
+// modulus (by centering the values around the plaintext). It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (encoder *encoderCoeff) DecodeIntNew(p interface{}) (coeffs []int64) {
+	coeffs = make([]int64, encoder.ringQ.N)
+	encoder.DecodeInt(p, coeffs)
+	return
+}
+
+// EncodeBytes encodes a byte string of at most N-1 bytes on a plaintext, one byte per coefficient. The first coefficient stores
+// the length of the string and the unused coefficients are padded with zeros. It panics if t is smaller than 256.
+func (encoder *encoderCoeff) EncodeBytes(data []byte, p *Plaintext) {
+	encoder.EncodeUint(bytesToSlots(data, encoder.ringQ.N, encoder.params.T()), p)
+}
+
+// DecodeBytes decodes any plaintext type generated by EncodeBytes and returns the byte string. It returns an error if
+// the plaintext does not store a valid encoding. It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (encoder *encoderCoeff) DecodeBytes(p interface{}) (data []byte, err error) {
+	return slotsToBytes(encoder.DecodeUintNew(p))
+}
@@ -85,16 +85,38 @@ type skEncryptor struct {
 // NewEncryptorFromPk creates a new Encryptor with the provided public-key.
 // This encryptor can be used to encrypt plaintexts, using the stored key.
 func NewEncryptorFromPk(params Parameters, pk *rlwe.PublicKey) Encryptor {
-	return &pkEncryptor{newEncryptor(params), pk}
+	return &pkEncryptor{newEncryptor(params, newEncryptorPRNG()), pk}
 }
 
 // NewEncryptorFromSk creates a new Encryptor with the provided secret-key.
 // This encryptor can be used to encrypt plaintexts, using the stored key.
 func NewEncryptorFromSk(params Parameters, sk *rlwe.SecretKey) Encryptor {
-	return &skEncryptor{newEncryptor(params), sk}
+	return &skEncryptor{newEncryptor(params, newEncryptorPRNG()), sk}
 }
 
-func newEncryptor(params Parameters) encryptor {
+// NewEncryptorFromPkWithPRNG creates a new Encryptor with the provided public-key, which draws all the
+// randomness of the encryption from the provided PRNG. Two such encryptors with PRNGs in the same state
+// produce the same ciphertexts for the same sequence of calls, and the encryption randomness of a
+// ciphertext can be reproduced by anyone knowing the state of the PRNG (e.g. the key of a KeyedPRNG).
+func NewEncryptorFromPkWithPRNG(params Parameters, pk *rlwe.PublicKey, prng utils.PRNG) Encryptor {
+	return &pkEncryptor{newEncryptor(params, prng), pk}
+}
+
+// NewEncryptorFromSkWithPRNG creates a new Encryptor with the provided secret-key, which draws all the
+// randomness of the encryption from the provided PRNG (see NewEncryptorFromPkWithPRNG).
+func NewEncryptorFromSkWithPRNG(params Parameters, sk *rlwe.SecretKey, prng utils.PRNG) Encryptor {
+	return &skEncryptor{newEncryptor(params, prng), sk}
+}
+
+func newEncryptorPRNG() utils.PRNG {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return prng
+}
+
+func newEncryptor(params Parameters, prng utils.PRNG) encryptor {
 
 	ringQ := params.RingQ()
 	ringQP := params.RingQP()
@@ -104,16 +126,10 @@ func newEncryptor(params Parameters) encryptor {
 		baseconverter = ring.NewFastBasisExtender(ringQ, params.RingP())
 	}
 
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
-
 	return encryptor{
 		params:                     params,
 		ringQ:                      ringQ,
 		ringQP:                     ringQP,
-		polypool:                   [3]*ring.Poly{ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly()},
 		baseconverter:              baseconverter,
 		gaussianSamplerQP:          ring.NewGaussianSampler(prng, ringQP, params.Sigma(), int(6*params.Sigma())),
 		gaussianSamplerQ:           ring.NewGaussianSampler(prng, ringQ, params.Sigma(), int(6*params.Sigma())),
@@ -124,8 +140,16 @@ func newEncryptor(params Parameters) encryptor {
 	}
 }
 
+// allocatePools allocates, if not already done, the memory pools of the encryption, which are allocated on their
+// first use rather than by the constructors (see rlwe.Options).
+func (encryptor *encryptor) allocatePools() {
+	if encryptor.polypool[0] == nil {
+		encryptor.polypool = [3]*ring.Poly{encryptor.ringQP.NewPoly(), encryptor.ringQP.NewPoly(), encryptor.ringQP.NewPoly()}
+	}
+}
+
 func (encryptor *pkEncryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
-	ciphertext := NewCiphertext(encryptor.params, 1)
+	ciphertext := newCiphertextFromPool(encryptor.params, 1)
 	encryptor.encrypt(plaintext, ciphertext, false)
 	return ciphertext
 }
@@ -140,7 +164,7 @@ func (encryptor *pkEncryptor) Encrypt(plaintext *Plaintext, ciphertext *Cipherte
 }
 
 func (encryptor *pkEncryptor) EncryptFastNew(plaintext *Plaintext) *Ciphertext {
-	ciphertext := NewCiphertext(encryptor.params, 1)
+	ciphertext := newCiphertextFromPool(encryptor.params, 1)
 	encryptor.encrypt(plaintext, ciphertext, true)
 
 	return ciphertext
@@ -168,6 +192,8 @@ func (encryptor *pkEncryptor) EncryptFromCRPFastNew(plaintext *Plaintext, crp *r
 
 func (encryptor *pkEncryptor) encrypt(p *Plaintext, ciphertext *Ciphertext, fast bool) {
 
+	encryptor.allocatePools()
+
 	ringQ := encryptor.ringQ
 
 	if fast {
@@ -219,7 +245,7 @@ func (encryptor *pkEncryptor) encrypt(p *Plaintext, ciphertext *Ciphertext, fast
 }
 
 func (encryptor *skEncryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
-	ciphertext := NewCiphertext(encryptor.params, 1)
+	ciphertext := newCiphertextFromPool(encryptor.params, 1)
 	encryptor.Encrypt(plaintext, ciphertext)
 	return ciphertext
 }
@@ -237,7 +263,7 @@ func (encryptor *skEncryptor) EncryptFast(plaintext *Plaintext, ciphertext *Ciph
 }
 
 func (encryptor *skEncryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext {
-	ciphertext := NewCiphertext(encryptor.params, 1)
+	ciphertext := newCiphertextFromPool(encryptor.params, 1)
 	encryptor.EncryptFromCRP(plaintext, ciphertext, crp)
 	return ciphertext
 }
@@ -255,11 +281,13 @@ func (encryptor *skEncryptor) EncryptFromCRPFast(plaintext *Plaintext, ciphertex
 }
 
 func (encryptor *skEncryptor) encryptSample(plaintext *Plaintext, ciphertext *Ciphertext) {
+	encryptor.allocatePools()
 	encryptor.uniformSamplerQ.Read(encryptor.polypool[1])
 	encryptor.encrypt(plaintext, ciphertext, encryptor.polypool[1])
 }
 
 func (encryptor *skEncryptor) encryptFromCRP(plaintext *Plaintext, ciphertext *Ciphertext, crp *ring.Poly) {
+	encryptor.allocatePools()
 	encryptor.ringQ.Copy(crp, encryptor.polypool[1])
 	encryptor.encrypt(plaintext, ciphertext, encryptor.polypool[1])
 }
@@ -2,6 +2,7 @@ package bfv
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/ldsec/lattigo/v2/ring"
@@ -11,11 +12,8 @@ import (
 	"unsafe"
 )
 
-// Operand is a common interface for Ciphertext and Plaintext.
-type Operand interface {
-	El() *rlwe.Element
-	Degree() int
-}
+// Operand is a common interface for Ciphertext and Plaintext. It is an alias of rlwe.Operand, kept for compatibility.
+type Operand = rlwe.Operand
 
 // Evaluator is an interface implementing the public methodes of the eval.
 type Evaluator interface {
@@ -31,10 +29,19 @@ type Evaluator interface {
 	NegNew(op Operand) (ctOut *Ciphertext)
 	Reduce(op Operand, ctOut *Ciphertext)
 	ReduceNew(op Operand) (ctOut *Ciphertext)
+	AddConstVector(ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error)
+	AddConstVectorNew(ctIn *Ciphertext, values []uint64) (ctOut *Ciphertext, err error)
+	MulConstVectorThenAdd(ctIn *Ciphertext, values []uint64, ctOut *Ciphertext) (err error)
 	MulScalar(op Operand, scalar uint64, ctOut *Ciphertext)
 	MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext)
+	MulScalarBigint(op Operand, scalar *big.Int, ctOut *Ciphertext)
+	MulScalarBigintNew(op Operand, scalar *big.Int) (ctOut *Ciphertext)
+	DivByConst(op Operand, scalar uint64, ctOut *Ciphertext) (err error)
+	DivByConstNew(op Operand, scalar uint64) (ctOut *Ciphertext, err error)
 	Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext)
 	MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext)
+	SwitchModulus(ct0 *Ciphertext, ctOut *Ciphertext)
+	SwitchModulusNew(ct0 *Ciphertext) (ctOut *Ciphertext)
 	Relinearize(ct0 *Ciphertext, ctOut *Ciphertext)
 	RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext)
 	SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext)
@@ -43,9 +50,24 @@ type Evaluator interface {
 	RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext)
 	RotateRows(ct0 *Ciphertext, ctOut *Ciphertext)
 	RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext)
+	HasConjugationKey() bool
+	RotateBy(ct0 *Ciphertext, rot rlwe.Rotation, ctOut *Ciphertext)
+	RotateByNew(ct0 *Ciphertext, rot rlwe.Rotation) (ctOut *Ciphertext)
 	InnerSum(ct0 *Ciphertext, ctOut *Ciphertext)
+	InnerSumLog(ct0 *Ciphertext, batchSize, n int, ctOut *Ciphertext)
+	Expand(ct0 *Ciphertext, logN int) (ctOut []*Ciphertext)
+	Equal(ct0 *Ciphertext, op1 Operand, ctOut *Ciphertext)
+	EqualNew(ct0 *Ciphertext, op1 Operand) (ctOut *Ciphertext)
+	EqualConst(ct0 *Ciphertext, constant uint64, ctOut *Ciphertext)
+	EqualConstNew(ct0 *Ciphertext, constant uint64) (ctOut *Ciphertext)
+	LessThan(ct0 *Ciphertext, op1 Operand, bound uint64, ctOut *Ciphertext)
+	LessThanNew(ct0 *Ciphertext, op1 Operand, bound uint64) (ctOut *Ciphertext)
+	EvaluatePoly(ct0 *Ciphertext, pol *Poly, ctOut *Ciphertext)
+	EvaluatePolyNew(ct0 *Ciphertext, pol *Poly) (ctOut *Ciphertext)
 	ShallowCopy() Evaluator
 	WithKey(rlwe.EvaluationKey) Evaluator
+	WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator
+	WithRelinearization(auto bool) Evaluator
 }
 
 // evaluator is a struct that holds the necessary elements to perform the homomorphic operations between ciphertexts and/or plaintexts.
@@ -55,10 +77,14 @@ type evaluator struct {
 	*evaluatorBuffers
 
 	rlk  *rlwe.RelinearizationKey
-	rtks *rlwe.RotationKeySet
+	rtks rlwe.RotationKeyProvider
+
+	autoRelin bool
 
 	baseconverterQ1Q2 *ring.FastBasisExtender
 	baseconverterQ1P  *ring.FastBasisExtender
+
+	ks *rlwe.KeySwitcher // the key-switch of the gadget of NewEvaluatorWithGadget, nil for the RNS decomposition
 }
 
 type evaluatorBase struct {
@@ -70,9 +96,14 @@ type evaluatorBase struct {
 	decomposer *ring.Decomposer
 
 	t     uint64
-	pHalf *big.Int
+	tBig  *big.Int // the plaintext modulus of the multiplications if it is larger than a machine word
+	pHalf []*big.Int
 
 	deltaMont []uint64
+
+	constVectors *constVectorCache
+
+	lowMemory bool // see rlwe.Options
 }
 
 func newEvaluatorPrecomp(params Parameters) *evaluatorBase {
@@ -90,8 +121,15 @@ func newEvaluatorPrecomp(params Parameters) *evaluatorBase {
 		panic(err)
 	}
 
-	ev.pHalf = new(big.Int).Rsh(ev.ringQMul.ModulusBigint, 1)
+	// pHalf[i] = (QMul_i-1)/2 where QMul_i is the product of the first i+1 moduli of QMul
+	ev.pHalf = make([]*big.Int, len(qiMul))
+	QMul := ring.NewUint(1)
+	for i, qi := range qiMul {
+		QMul.Mul(QMul, ring.NewUint(qi))
+		ev.pHalf[i] = new(big.Int).Rsh(QMul, 1)
+	}
 	ev.deltaMont = GenLiftParams(ev.ringQ, params.T())
+	ev.constVectors = newConstVectorCache(params)
 
 	if params.PCount() != 0 {
 		ev.ringP = params.RingP()
@@ -107,11 +145,34 @@ type evaluatorBuffers struct {
 	poolQKS [4]*ring.Poly
 	poolPKS [3]*ring.Poly
 
+	poolQSwitch *ring.Poly
+
 	tmpPt *Plaintext
 }
 
 func newEvaluatorBuffer(eval *evaluatorBase) *evaluatorBuffers {
 	evb := new(evaluatorBuffers)
+
+	// With rlwe.Options.LowMemory, the pools of the tensoring and of the key-switching are allocated on their first use.
+	if !eval.lowMemory {
+		evb.allocateTensoringPools(eval)
+		evb.allocateKeySwitchingPools(eval)
+	}
+
+	evb.poolQSwitch = eval.ringQ.NewPoly()
+
+	evb.tmpPt = NewPlaintext(eval.params)
+
+	return evb
+}
+
+// allocateTensoringPools allocates, if not already done, the memory pools of the tensoring of ciphertexts.
+func (evb *evaluatorBuffers) allocateTensoringPools(eval *evaluatorBase) {
+
+	if evb.poolQ != nil {
+		return
+	}
+
 	evb.poolQ = make([][]*ring.Poly, 4)
 	evb.poolQmul = make([][]*ring.Poly, 4)
 	for i := 0; i < 4; i++ {
@@ -122,22 +183,31 @@ func newEvaluatorBuffer(eval *evaluatorBase) *evaluatorBuffers {
 			evb.poolQmul[i][j] = eval.ringQMul.NewPoly()
 		}
 	}
-	if eval.ringP != nil {
+}
+
+// allocateKeySwitchingPools allocates, if not already done, the memory pools of the key-switching.
+func (evb *evaluatorBuffers) allocateKeySwitchingPools(eval *evaluatorBase) {
+	if evb.poolQKS[0] == nil {
 		evb.poolQKS = [4]*ring.Poly{eval.ringQ.NewPoly(), eval.ringQ.NewPoly(), eval.ringQ.NewPoly(), eval.ringQ.NewPoly()}
+	}
+	if eval.ringP != nil && evb.poolPKS[0] == nil {
 		evb.poolPKS = [3]*ring.Poly{eval.ringP.NewPoly(), eval.ringP.NewPoly(), eval.ringP.NewPoly()}
 	}
-
-	evb.tmpPt = NewPlaintext(eval.params)
-
-	return evb
 }
 
 // NewEvaluator creates a new Evaluator, that can be used to do homomorphic
 // operations on ciphertexts and/or plaintexts. It stores a small pool of polynomials
 // and ciphertexts that will be used for intermediate values.
 func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator {
+	return NewEvaluatorWithOptions(params, evaluationKey, rlwe.DefaultOptions())
+}
+
+// NewEvaluatorWithOptions creates a new Evaluator with the options opts, e.g. to allocate its memory pools on their
+// first use with opts.LowMemory. The options are kept by ShallowCopy and WithKey.
+func NewEvaluatorWithOptions(params Parameters, evaluationKey rlwe.EvaluationKey, opts rlwe.Options) Evaluator {
 	ev := new(evaluator)
 	ev.evaluatorBase = newEvaluatorPrecomp(params)
+	ev.evaluatorBase.lowMemory = opts.LowMemory
 	ev.evaluatorBuffers = newEvaluatorBuffer(ev.evaluatorBase)
 	ev.baseconverterQ1Q2 = ring.NewFastBasisExtender(ev.ringQ, ev.ringQMul)
 	if params.PCount() != 0 {
@@ -148,6 +218,32 @@ func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator
 	return ev
 }
 
+// NewEvaluatorWithGadget creates a new Evaluator whose key-switches (relinearization, SwitchKeys and rotations)
+// decompose the ciphertexts with the gadget decomposition gadget instead of the RNS decomposition. The keys of
+// evaluationKey must be generated for the gadget (see rlwe.GenRelinearizationKeyWithGadget and
+// rlwe.GenRotationKeysWithGadget). A gadget without the special modulus P, such as the rlwe.NewBitGadget, supports
+// parameters without P.
+func NewEvaluatorWithGadget(params Parameters, gadget rlwe.Gadget, evaluationKey rlwe.EvaluationKey) Evaluator {
+	ev := NewEvaluator(params, evaluationKey).(*evaluator)
+	ev.ks = rlwe.NewKeySwitcher(params.Parameters, gadget)
+	ev.allocateKeySwitchingPools(ev.evaluatorBase)
+	return ev
+}
+
+// NewEvaluatorBigT creates a new Evaluator for the plaintext modulus t, which can be larger than a machine word and
+// replaces the plaintext modulus of params (e.g., a product of word-sized primes encoded in RNS or a 128-bit prime,
+// see package bigbfv). Only the operations that do not depend on the plaintext modulus are supported with such an
+// Evaluator: the additions, subtractions and negations, the multiplications (Mul) between ciphertexts and plaintexts
+// of type *Plaintext, the relinearization, the key-switching and the rotations. The other operations, and the
+// operands of type *PlaintextRingT and *PlaintextMul, which are encoded modulo the plaintext modulus of params,
+// panic. The plaintexts must be encoded in R_Q with the scaling factor Q/t, and the modulus Q must be larger than t^2
+// by the noise budget of the circuit.
+func NewEvaluatorBigT(params Parameters, t *big.Int, evaluationKey rlwe.EvaluationKey) Evaluator {
+	ev := NewEvaluator(params, evaluationKey).(*evaluator)
+	ev.evaluatorBase.tBig = new(big.Int).Set(t)
+	return ev
+}
+
 // NewEvaluators creates n evaluators sharing the same read-only data-structures.
 func NewEvaluators(params Parameters, evaluationKey rlwe.EvaluationKey, n int) []Evaluator {
 	if n <= 0 {
@@ -172,8 +268,10 @@ func (eval *evaluator) ShallowCopy() Evaluator {
 		evaluatorBuffers:  newEvaluatorBuffer(eval.evaluatorBase),
 		baseconverterQ1Q2: eval.baseconverterQ1Q2.ShallowCopy(),
 		baseconverterQ1P:  eval.baseconverterQ1P.ShallowCopy(),
+		ks:                eval.ks.ShallowCopy(),
 		rlk:               eval.rlk,
 		rtks:              eval.rtks,
+		autoRelin:         eval.autoRelin,
 	}
 }
 
@@ -185,20 +283,58 @@ func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
 		evaluatorBuffers:  eval.evaluatorBuffers,
 		baseconverterQ1Q2: eval.baseconverterQ1Q2,
 		baseconverterQ1P:  eval.baseconverterQ1P,
+		ks:                eval.ks,
 		rlk:               evaluationKey.Rlk,
 		rtks:              evaluationKey.Rtks,
+		autoRelin:         eval.autoRelin,
+	}
+}
+
+// WithRotationKeyProvider creates a shallow copy of the receiver Evaluator for which the rotation keys are queried from
+// rtkp and where the temporary buffers are shared. The receiver and the returned Evaluators cannot be used concurrently.
+func (eval *evaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	if rtkp == nil {
+		rtkp = (*rlwe.RotationKeySet)(nil)
+	}
+	return &evaluator{
+		evaluatorBase:     eval.evaluatorBase,
+		evaluatorBuffers:  eval.evaluatorBuffers,
+		baseconverterQ1Q2: eval.baseconverterQ1Q2,
+		baseconverterQ1P:  eval.baseconverterQ1P,
+		ks:                eval.ks,
+		rlk:               eval.rlk,
+		rtks:              rtkp,
+		autoRelin:         eval.autoRelin,
+	}
+}
+
+// WithRelinearization creates a shallow copy of the receiver Evaluator, where the temporary buffers are shared, which
+// relinearizes the result of Mul to degree 1 if auto is true and if a relinearization key is attached. The degree of
+// the results is tracked accordingly: the receivers of Mul then only need to be of degree 1 and MulNew returns
+// ciphertexts of degree 1, so that the products can be chained without exceeding the degree supported by the
+// relinearization key. The receiver and the returned Evaluators cannot be used concurrently.
+func (eval *evaluator) WithRelinearization(auto bool) Evaluator {
+	return &evaluator{
+		evaluatorBase:     eval.evaluatorBase,
+		evaluatorBuffers:  eval.evaluatorBuffers,
+		baseconverterQ1Q2: eval.baseconverterQ1Q2,
+		baseconverterQ1P:  eval.baseconverterQ1P,
+		ks:                eval.ks,
+		rlk:               eval.rlk,
+		rtks:              eval.rtks,
+		autoRelin:         auto,
 	}
 }
 
 // Add adds op0 to op1 and returns the result in ctOut.
 func (eval *evaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
-	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.Add)
+	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.AddLvl)
 }
 
 // AddNew adds op0 to op1 and creates a new element ctOut to store the result.
 func (eval *evaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()))
+	ctOut = NewCiphertextLvl(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), eval.minLevel(op0, op1))
 	eval.Add(op0, op1, ctOut)
 	return
 }
@@ -206,12 +342,12 @@ func (eval *evaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
 // AddNoMod adds op0 to op1 without modular reduction, and returns the result in cOut.
 func (eval *evaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
-	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.AddNoMod)
+	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.AddNoModLvl)
 }
 
 // AddNoModNew adds op0 to op1 without modular reduction and creates a new element ctOut to store the result.
 func (eval *evaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()))
+	ctOut = NewCiphertextLvl(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), eval.minLevel(op0, op1))
 	eval.AddNoMod(op0, op1, ctOut)
 	return
 }
@@ -219,18 +355,18 @@ func (eval *evaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
 // Sub subtracts op1 from op0 and returns the result in cOut.
 func (eval *evaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
-	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.Sub)
+	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.SubLvl)
 
 	if el0.Degree() < el1.Degree() {
 		for i := el0.Degree() + 1; i < el1.Degree()+1; i++ {
-			eval.ringQ.Neg(ctOut.Value[i], ctOut.Value[i])
+			eval.ringQ.NegLvl(elOut.Level(), ctOut.Value[i], ctOut.Value[i])
 		}
 	}
 }
 
 // SubNew subtracts op1 from op0 and creates a new element ctOut to store the result.
 func (eval *evaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()))
+	ctOut = NewCiphertextLvl(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), eval.minLevel(op0, op1))
 	eval.Sub(op0, op1, ctOut)
 	return
 }
@@ -239,18 +375,18 @@ func (eval *evaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
 func (eval *evaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
 
-	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.SubNoMod)
+	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.SubNoModLvl)
 
 	if el0.Degree() < el1.Degree() {
 		for i := el0.Degree() + 1; i < el1.Degree()+1; i++ {
-			eval.ringQ.Neg(ctOut.Value[i], ctOut.Value[i])
+			eval.ringQ.NegLvl(elOut.Level(), ctOut.Value[i], ctOut.Value[i])
 		}
 	}
 }
 
 // SubNoModNew subtracts op1 from op0 without modular reduction and creates a new element ctOut to store the result.
 func (eval *evaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()))
+	ctOut = NewCiphertextLvl(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), eval.minLevel(op0, op1))
 	eval.SubNoMod(op0, op1, ctOut)
 	return
 }
@@ -258,12 +394,12 @@ func (eval *evaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
 // Neg negates op and returns the result in ctOut.
 func (eval *evaluator) Neg(op Operand, ctOut *Ciphertext) {
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
-	evaluateInPlaceUnary(el0, elOut, eval.ringQ.Neg)
+	evaluateInPlaceUnary(el0, elOut, eval.ringQ.NegLvl)
 }
 
 // NegNew negates op and creates a new element to store the result.
 func (eval *evaluator) NegNew(op Operand) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, op.Degree())
+	ctOut = NewCiphertextLvl(eval.params, op.Degree(), op.El().Level())
 	eval.Neg(op, ctOut)
 	return ctOut
 }
@@ -271,33 +407,91 @@ func (eval *evaluator) NegNew(op Operand) (ctOut *Ciphertext) {
 // Reduce applies a modular reduction to op and returns the result in ctOut.
 func (eval *evaluator) Reduce(op Operand, ctOut *Ciphertext) {
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
-	evaluateInPlaceUnary(el0, elOut, eval.ringQ.Reduce)
+	evaluateInPlaceUnary(el0, elOut, eval.ringQ.ReduceLvl)
 }
 
 // ReduceNew applies a modular reduction to op and creates a new element ctOut to store the result.
 func (eval *evaluator) ReduceNew(op Operand) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, op.Degree())
+	ctOut = NewCiphertextLvl(eval.params, op.Degree(), op.El().Level())
 	eval.Reduce(op, ctOut)
 	return ctOut
 }
 
 // MulScalar multiplies op by a uint64 scalar and returns the result in ctOut.
 func (eval *evaluator) MulScalar(op Operand, scalar uint64, ctOut *Ciphertext) {
+	eval.checkWordSizedT("MulScalar")
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
-	fun := func(el, elOut *ring.Poly) { eval.ringQ.MulScalar(el, scalar, elOut) }
+	fun := func(level int, el, elOut *ring.Poly) { eval.ringQ.MulScalarLvl(level, el, scalar, elOut) }
 	evaluateInPlaceUnary(el0, elOut, fun)
 }
 
 // MulScalarNew multiplies op by a uint64 scalar and creates a new element ctOut to store the result.
 func (eval *evaluator) MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, op.Degree())
+	ctOut = NewCiphertextLvl(eval.params, op.Degree(), op.El().Level())
 	eval.MulScalar(op, scalar, ctOut)
 	return
 }
 
-// tensorAndRescale computes (ct0 x ct1) * (t/Q) and stores the result in ctOut.
+// MulScalarBigint multiplies op by a big.Int scalar and returns the result in ctOut.
+// The scalar can be negative or larger than the moduli: it is first reduced to its centered representative
+// modulo t, which gives the same plaintext result with the smallest noise growth.
+func (eval *evaluator) MulScalarBigint(op Operand, scalar *big.Int, ctOut *Ciphertext) {
+	eval.checkWordSizedT("MulScalarBigint")
+	eval.mulScalarCentered(op, new(big.Int).Mod(scalar, ring.NewUint(eval.t)).Uint64(), ctOut)
+}
+
+// MulScalarBigintNew multiplies op by a big.Int scalar and creates a new element ctOut to store the result.
+func (eval *evaluator) MulScalarBigintNew(op Operand, scalar *big.Int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, op.Degree(), op.El().Level())
+	eval.MulScalarBigint(op, scalar, ctOut)
+	return
+}
+
+// DivByConst divides op by a uint64 scalar, i.e. multiplies it by the inverse of the scalar modulo t, and returns
+// the result in ctOut. The division is exact in the plaintext space Z_t: it is the inverse of MulScalar.
+// Returns an error if the scalar is not invertible modulo t, in which case ctOut is not modified.
+func (eval *evaluator) DivByConst(op Operand, scalar uint64, ctOut *Ciphertext) (err error) {
+
+	eval.checkWordSizedT("DivByConst")
+
+	inv := new(big.Int).ModInverse(ring.NewUint(scalar), ring.NewUint(eval.t))
+	if inv == nil {
+		return fmt.Errorf("cannot DivByConst: %d is not invertible modulo t=%d", scalar, eval.t)
+	}
+
+	eval.mulScalarCentered(op, inv.Uint64(), ctOut)
+
+	return nil
+}
+
+// DivByConstNew divides op by a uint64 scalar and creates a new element ctOut to store the result.
+// Returns an error if the scalar is not invertible modulo t.
+func (eval *evaluator) DivByConstNew(op Operand, scalar uint64) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertextLvl(eval.params, op.Degree(), op.El().Level())
+	if err = eval.DivByConst(op, scalar, ctOut); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// mulScalarCentered multiplies op by scalar in [0, t) using its centered representative in [-t/2, t/2).
+func (eval *evaluator) mulScalarCentered(op Operand, scalar uint64, ctOut *Ciphertext) {
+	if scalar > eval.t>>1 {
+		eval.MulScalar(op, eval.t-scalar, ctOut)
+		eval.Neg(ctOut, ctOut)
+	} else {
+		eval.MulScalar(op, scalar, ctOut)
+	}
+}
+
+// tensorAndRescale computes (ct0 x ct1) * (t/Q) and stores the result in ctOut. The operands and the receiver must be
+// at the same level, and Q is the product of the moduli at that level.
 func (eval *evaluator) tensorAndRescale(ct0, ct1, ctOut *rlwe.Element) {
 
+	eval.allocateTensoringPools(eval.evaluatorBase)
+
+	level := ctOut.Level()
+
 	c0Q1 := eval.poolQ[0]
 	c0Q2 := eval.poolQmul[0]
 
@@ -306,10 +500,10 @@ func (eval *evaluator) tensorAndRescale(ct0, ct1, ctOut *rlwe.Element) {
 
 	// Prepares the ciphertexts for the Tensoring by extending their
 	// basis from Q to QP and transforming them to NTT form
-	eval.modUpAndNTT(ct0, c0Q1, c0Q2)
+	eval.modUpAndNTT(level, ct0, c0Q1, c0Q2)
 
 	if ct0 != ct1 {
-		eval.modUpAndNTT(ct1, c1Q1, c1Q2)
+		eval.modUpAndNTT(level, ct1, c1Q1, c1Q2)
 	}
 
 	// Tensoring: multiplies each elements of the ciphertexts together
@@ -318,25 +512,26 @@ func (eval *evaluator) tensorAndRescale(ct0, ct1, ctOut *rlwe.Element) {
 
 	// Case where both Elements are of degree 1
 	if ct0.Degree() == 1 && ct1.Degree() == 1 {
-		eval.tensoreLowDeg(ct0, ct1)
+		eval.tensoreLowDeg(level, ct0, ct1)
 		// Case where at least one element is not of degree 1
 	} else {
-		eval.tensortLargeDeg(ct0, ct1)
+		eval.tensortLargeDeg(level, ct0, ct1)
 	}
 
-	eval.quantize(ctOut)
+	eval.quantize(level, ctOut)
 }
 
-func (eval *evaluator) modUpAndNTT(ct *rlwe.Element, cQ, cQMul []*ring.Poly) {
-	levelQ := len(eval.ringQ.Modulus) - 1
+func (eval *evaluator) modUpAndNTT(level int, ct *rlwe.Element, cQ, cQMul []*ring.Poly) {
+	eval.baseconverterQ1Q2.ModUpSplitQPMany(level, level, ct.Value, cQMul[:len(ct.Value)])
 	for i := range ct.Value {
-		eval.baseconverterQ1Q2.ModUpSplitQP(levelQ, ct.Value[i], cQMul[i])
-		eval.ringQ.NTTLazy(ct.Value[i], cQ[i])
-		eval.ringQMul.NTTLazy(cQMul[i], cQMul[i])
+		eval.ringQ.NTTLazyLvl(level, ct.Value[i], cQ[i])
+		eval.ringQMul.NTTLazyLvl(level, cQMul[i], cQMul[i])
 	}
 }
 
-func (eval *evaluator) tensoreLowDeg(ct0, ct1 *rlwe.Element) {
+func (eval *evaluator) tensoreLowDeg(level int, ct0, ct1 *rlwe.Element) {
+
+	ringQ, ringQMul := eval.ringQ, eval.ringQMul
 
 	c0Q1 := eval.poolQ[0]
 	c0Q2 := eval.poolQmul[0]
@@ -352,51 +547,53 @@ func (eval *evaluator) tensoreLowDeg(ct0, ct1 *rlwe.Element) {
 	c01Q := eval.poolQ[3][1]
 	c01P := eval.poolQmul[3][1]
 
-	eval.ringQ.MForm(c0Q1[0], c00Q)
-	eval.ringQMul.MForm(c0Q2[0], c00Q2)
+	ringQ.MFormLvl(level, c0Q1[0], c00Q)
+	ringQMul.MFormLvl(level, c0Q2[0], c00Q2)
 
-	eval.ringQ.MForm(c0Q1[1], c01Q)
-	eval.ringQMul.MForm(c0Q2[1], c01P)
+	ringQ.MFormLvl(level, c0Q1[1], c01Q)
+	ringQMul.MFormLvl(level, c0Q2[1], c01P)
 
 	// Squaring case
 	if ct0 == ct1 {
 
 		// c0 = c0[0]*c0[0]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c0Q1[0], c2Q1[0])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c0Q2[0], c2Q2[0])
+		ringQ.MulCoeffsMontgomeryLvl(level, c00Q, c0Q1[0], c2Q1[0])
+		ringQMul.MulCoeffsMontgomeryLvl(level, c00Q2, c0Q2[0], c2Q2[0])
 
 		// c1 = 2*c0[0]*c0[1]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c0Q1[1], c2Q1[1])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c0Q2[1], c2Q2[1])
+		ringQ.MulCoeffsMontgomeryLvl(level, c00Q, c0Q1[1], c2Q1[1])
+		ringQMul.MulCoeffsMontgomeryLvl(level, c00Q2, c0Q2[1], c2Q2[1])
 
-		eval.ringQ.AddNoMod(c2Q1[1], c2Q1[1], c2Q1[1])
-		eval.ringQMul.AddNoMod(c2Q2[1], c2Q2[1], c2Q2[1])
+		ringQ.AddNoModLvl(level, c2Q1[1], c2Q1[1], c2Q1[1])
+		ringQMul.AddNoModLvl(level, c2Q2[1], c2Q2[1], c2Q2[1])
 
 		// c2 = c0[1]*c0[1]
-		eval.ringQ.MulCoeffsMontgomery(c01Q, c0Q1[1], c2Q1[2])
-		eval.ringQMul.MulCoeffsMontgomery(c01P, c0Q2[1], c2Q2[2])
+		ringQ.MulCoeffsMontgomeryLvl(level, c01Q, c0Q1[1], c2Q1[2])
+		ringQMul.MulCoeffsMontgomeryLvl(level, c01P, c0Q2[1], c2Q2[2])
 
 		// Normal case
 	} else {
 
 		// c0 = c0[0]*c1[0]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c1Q1[0], c2Q1[0])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c1Q2[0], c2Q2[0])
+		ringQ.MulCoeffsMontgomeryLvl(level, c00Q, c1Q1[0], c2Q1[0])
+		ringQMul.MulCoeffsMontgomeryLvl(level, c00Q2, c1Q2[0], c2Q2[0])
 
 		// c1 = c0[0]*c1[1] + c0[1]*c1[0]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c1Q1[1], c2Q1[1])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c1Q2[1], c2Q2[1])
+		ringQ.MulCoeffsMontgomeryLvl(level, c00Q, c1Q1[1], c2Q1[1])
+		ringQMul.MulCoeffsMontgomeryLvl(level, c00Q2, c1Q2[1], c2Q2[1])
 
-		eval.ringQ.MulCoeffsMontgomeryAndAddNoMod(c01Q, c1Q1[0], c2Q1[1])
-		eval.ringQMul.MulCoeffsMontgomeryAndAddNoMod(c01P, c1Q2[0], c2Q2[1])
+		ringQ.MulCoeffsMontgomeryAndAddNoModLvl(level, c01Q, c1Q1[0], c2Q1[1])
+		ringQMul.MulCoeffsMontgomeryAndAddNoModLvl(level, c01P, c1Q2[0], c2Q2[1])
 
 		// c2 = c0[1]*c1[1]
-		eval.ringQ.MulCoeffsMontgomery(c01Q, c1Q1[1], c2Q1[2])
-		eval.ringQMul.MulCoeffsMontgomery(c01P, c1Q2[1], c2Q2[2])
+		ringQ.MulCoeffsMontgomeryLvl(level, c01Q, c1Q1[1], c2Q1[2])
+		ringQMul.MulCoeffsMontgomeryLvl(level, c01P, c1Q2[1], c2Q2[2])
 	}
 }
 
-func (eval *evaluator) tensortLargeDeg(ct0, ct1 *rlwe.Element) {
+func (eval *evaluator) tensortLargeDeg(level int, ct0, ct1 *rlwe.Element) {
+
+	ringQ, ringQMul := eval.ringQ, eval.ringQMul
 
 	c0Q1 := eval.poolQ[0]
 	c0Q2 := eval.poolQmul[0]
@@ -419,42 +616,41 @@ func (eval *evaluator) tensortLargeDeg(ct0, ct1 *rlwe.Element) {
 		c00Q2 := eval.poolQmul[3]
 
 		for i := range ct0.Value {
-			eval.ringQ.MForm(c0Q1[i], c00Q1[i])
-			eval.ringQMul.MForm(c0Q2[i], c00Q2[i])
+			ringQ.MFormLvl(level, c0Q1[i], c00Q1[i])
+			ringQMul.MFormLvl(level, c0Q2[i], c00Q2[i])
 		}
 
 		for i := 0; i < ct0.Degree()+1; i++ {
 			for j := i + 1; j < ct0.Degree()+1; j++ {
-				eval.ringQ.MulCoeffsMontgomery(c00Q1[i], c0Q1[j], c2Q1[i+j])
-				eval.ringQMul.MulCoeffsMontgomery(c00Q2[i], c0Q2[j], c2Q2[i+j])
+				ringQ.MulCoeffsMontgomeryLvl(level, c00Q1[i], c0Q1[j], c2Q1[i+j])
+				ringQMul.MulCoeffsMontgomeryLvl(level, c00Q2[i], c0Q2[j], c2Q2[i+j])
 
-				eval.ringQ.Add(c2Q1[i+j], c2Q1[i+j], c2Q1[i+j])
-				eval.ringQMul.Add(c2Q2[i+j], c2Q2[i+j], c2Q2[i+j])
+				ringQ.AddLvl(level, c2Q1[i+j], c2Q1[i+j], c2Q1[i+j])
+				ringQMul.AddLvl(level, c2Q2[i+j], c2Q2[i+j], c2Q2[i+j])
 			}
 		}
 
 		for i := 0; i < ct0.Degree()+1; i++ {
-			eval.ringQ.MulCoeffsMontgomeryAndAdd(c00Q1[i], c0Q1[i], c2Q1[i<<1])
-			eval.ringQMul.MulCoeffsMontgomeryAndAdd(c00Q2[i], c0Q2[i], c2Q2[i<<1])
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00Q1[i], c0Q1[i], c2Q1[i<<1])
+			ringQMul.MulCoeffsMontgomeryAndAddLvl(level, c00Q2[i], c0Q2[i], c2Q2[i<<1])
 		}
 
 		// Normal case
 	} else {
 		for i := range ct0.Value {
-			eval.ringQ.MForm(c0Q1[i], c0Q1[i])
-			eval.ringQMul.MForm(c0Q2[i], c0Q2[i])
+			ringQ.MFormLvl(level, c0Q1[i], c0Q1[i])
+			ringQMul.MFormLvl(level, c0Q2[i], c0Q2[i])
 			for j := range ct1.Value {
-				eval.ringQ.MulCoeffsMontgomeryAndAdd(c0Q1[i], c1Q1[j], c2Q1[i+j])
-				eval.ringQMul.MulCoeffsMontgomeryAndAdd(c0Q2[i], c1Q2[j], c2Q2[i+j])
+				ringQ.MulCoeffsMontgomeryAndAddLvl(level, c0Q1[i], c1Q1[j], c2Q1[i+j])
+				ringQMul.MulCoeffsMontgomeryAndAddLvl(level, c0Q2[i], c1Q2[j], c2Q2[i+j])
 			}
 		}
 	}
 }
 
-func (eval *evaluator) quantize(ctOut *rlwe.Element) {
+func (eval *evaluator) quantize(level int, ctOut *rlwe.Element) {
 
-	levelQ := len(eval.ringQ.Modulus) - 1
-	levelQMul := len(eval.ringQMul.Modulus) - 1
+	baseconverter := eval.baseconverterQ1Q2
 
 	c2Q1 := eval.poolQ[2]
 	c2Q2 := eval.poolQmul[2]
@@ -462,30 +658,56 @@ func (eval *evaluator) quantize(ctOut *rlwe.Element) {
 	// Applies the inverse NTT to the ciphertext, scales down the ciphertext
 	// by t/q and reduces its basis from QP to Q
 	for i := range ctOut.Value {
-		eval.ringQ.InvNTTLazy(c2Q1[i], c2Q1[i])
-		eval.ringQMul.InvNTTLazy(c2Q2[i], c2Q2[i])
+		eval.ringQ.InvNTTLazyLvl(level, c2Q1[i], c2Q1[i])
+		eval.ringQMul.InvNTTLazyLvl(level, c2Q2[i], c2Q2[i])
 
 		// Extends the basis Q of ct(x) to the basis P and Divides (ct(x)Q -> P) by Q
-		eval.baseconverterQ1Q2.ModDownSplitQP(levelQ, levelQMul, c2Q1[i], c2Q2[i], c2Q2[i])
+		baseconverter.ModDownSplitQP(level, level, c2Q1[i], c2Q2[i], c2Q2[i])
 
 		// Centers (ct(x)Q -> P)/Q by (P-1)/2 and extends ((ct(x)Q -> P)/Q) to the basis Q
-		eval.ringQMul.AddScalarBigint(c2Q2[i], eval.pHalf, c2Q2[i])
-		eval.baseconverterQ1Q2.ModUpSplitPQ(levelQMul, c2Q2[i], ctOut.Value[i])
-		eval.ringQ.SubScalarBigint(ctOut.Value[i], eval.pHalf, ctOut.Value[i])
+		eval.ringQMul.AddScalarBigintLvl(level, c2Q2[i], eval.pHalf[level], c2Q2[i])
+		baseconverter.ModUpSplitPQLvl(level, level, c2Q2[i], ctOut.Value[i])
+		eval.ringQ.SubScalarBigintLvl(level, ctOut.Value[i], eval.pHalf[level], ctOut.Value[i])
 
 		// Option (2) (ct(x)/Q)*T, doing so only requires that Q*P > Q*Q, faster but adds error ~|T|
-		eval.ringQ.MulScalar(ctOut.Value[i], eval.t, ctOut.Value[i])
+		if eval.tBig != nil {
+			eval.ringQ.MulScalarBigintLvl(level, ctOut.Value[i], eval.tBig, ctOut.Value[i])
+		} else {
+			eval.ringQ.MulScalarLvl(level, ctOut.Value[i], eval.t, ctOut.Value[i])
+		}
 	}
 }
 
 // Mul multiplies op0 by op1 and returns the result in ctOut.
+// The result is at the smallest level among the operands and the receiver. If the automatic relinearization is
+// enabled (see WithRelinearization), a result of degree larger than one is relinearized to degree 1.
 func (eval *evaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+
+	if degree := op0.Degree() + op1.Degree(); degree > 1 && eval.autoRelinearize() {
+		tmp := newCiphertextAtLevelFromPool(eval.params, degree, utils.MinInt(eval.minLevel(op0, op1), ctOut.Level()))
+		eval.mul(op0, op1, tmp)
+		eval.Relinearize(tmp, ctOut)
+		tmp.Release()
+		return
+	}
+
+	eval.mul(op0, op1, ctOut)
+}
+
+// autoRelinearize returns true if the results of Mul must be relinearized.
+func (eval *evaluator) autoRelinearize() bool {
+	return eval.autoRelin && eval.rlk != nil
+}
+
+func (eval *evaluator) mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, op0.Degree()+op1.Degree(), false)
 	switch op1 := op1.(type) {
 	case *PlaintextMul:
-		eval.mulPlaintextMul(op0, op1, ctOut)
+		eval.checkWordSizedT("Mul by a PlaintextMul")
+		eval.mulPlaintextMul(el0, op1, elOut)
 	case *PlaintextRingT:
-		eval.mulPlaintextRingT(op0, op1, ctOut)
+		eval.checkWordSizedT("Mul by a PlaintextRingT")
+		eval.mulPlaintextRingT(el0, op1, elOut)
 	case *Plaintext, *Ciphertext:
 		eval.tensorAndRescale(el0, el1, elOut)
 	default:
@@ -494,30 +716,36 @@ func (eval *evaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
 
 }
 
-func (eval *evaluator) mulPlaintextMul(ct0 *Ciphertext, ptRt *PlaintextMul, ctOut *Ciphertext) {
+func (eval *evaluator) mulPlaintextMul(ct0 *rlwe.Element, ptRt *PlaintextMul, ctOut *rlwe.Element) {
+	level := ctOut.Level()
 	for i := range ct0.Value {
-		eval.ringQ.NTTLazy(ct0.Value[i], ctOut.Value[i])
-		eval.ringQ.MulCoeffsMontgomeryConstant(ctOut.Value[i], ptRt.value, ctOut.Value[i])
-		eval.ringQ.InvNTT(ctOut.Value[i], ctOut.Value[i])
+		eval.ringQ.NTTLazyLvl(level, ct0.Value[i], ctOut.Value[i])
+		eval.ringQ.MulCoeffsMontgomeryConstantLvl(level, ctOut.Value[i], ptRt.value, ctOut.Value[i])
+		eval.ringQ.InvNTTLvl(level, ctOut.Value[i], ctOut.Value[i])
 	}
 }
 
-func (eval *evaluator) mulPlaintextRingT(ct0 *Ciphertext, ptRt *PlaintextRingT, ctOut *Ciphertext) {
+func (eval *evaluator) mulPlaintextRingT(ct0 *rlwe.Element, ptRt *PlaintextRingT, ctOut *rlwe.Element) {
+
+	eval.allocateTensoringPools(eval.evaluatorBase)
+
 	ringQ := eval.ringQ
 
+	level := ctOut.Level()
+
 	coeffs := ptRt.value.Coeffs[0]
 	coeffsNTT := eval.poolQ[0][0].Coeffs[0]
 
 	for i := range ct0.Value {
 
 		// Copies the inputCT on the outputCT and switches to the NTT domain
-		eval.ringQ.NTTLazy(ct0.Value[i], ctOut.Value[i])
+		eval.ringQ.NTTLazyLvl(level, ct0.Value[i], ctOut.Value[i])
 
 		// Switches the outputCT in the Montgomery domain
-		eval.ringQ.MForm(ctOut.Value[i], ctOut.Value[i])
+		eval.ringQ.MFormLvl(level, ctOut.Value[i], ctOut.Value[i])
 
 		// For each qi in Q
-		for j := range ringQ.Modulus {
+		for j := range ringQ.Modulus[:level+1] {
 
 			tmp := ctOut.Value[i].Coeffs[j]
 			qi := ringQ.Modulus[j]
@@ -546,29 +774,91 @@ func (eval *evaluator) mulPlaintextRingT(ct0 *Ciphertext, ptRt *PlaintextRingT,
 		}
 
 		// Switches the ciphertext out of the NTT domain
-		eval.ringQ.InvNTT(ctOut.Value[i], ctOut.Value[i])
+		eval.ringQ.InvNTTLvl(level, ctOut.Value[i], ctOut.Value[i])
 	}
 }
 
 // MulNew multiplies op0 by op1 and creates a new element ctOut to store the result.
 func (eval *evaluator) MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, op0.Degree()+op1.Degree())
+	degree := op0.Degree() + op1.Degree()
+	if eval.autoRelinearize() {
+		degree = utils.MinInt(degree, 1)
+	}
+	ctOut = NewCiphertextLvl(eval.params, degree, eval.minLevel(op0, op1))
 	eval.Mul(op0, op1, ctOut)
 	return
 }
 
+// SwitchModulus divides ct0 by the last modulus of its level (with rounding), which decreases its level by one,
+// and returns the result in ctOut. The ratio between the noise and the modulus, and therefore the decryption
+// failure probability, is essentially unchanged, but the following operations are performed on one modulus less.
+// It is typically applied after a multiplication. It panics if ct0 is at level 0 or if ctOut is at a level smaller
+// than the level of ct0 minus one.
+func (eval *evaluator) SwitchModulus(ct0 *Ciphertext, ctOut *Ciphertext) {
+
+	if ct0.Level() == 0 {
+		panic("cannot SwitchModulus: input ciphertext is already at level 0")
+	}
+
+	if ctOut.Level() < ct0.Level()-1 {
+		panic("cannot SwitchModulus: receiver ciphertext level is too small")
+	}
+
+	if ctOut.Degree() < ct0.Degree() {
+		panic("cannot SwitchModulus: receiver ciphertext degree is too small")
+	}
+
+	eval.switchLevel(ct0.Level()-1, ct0.El(), ctOut.El())
+	ctOut.SetValue(ctOut.Value[:ct0.Degree()+1])
+}
+
+// SwitchModulusNew divides ct0 by the last modulus of its level (with rounding) and returns the result, at the level
+// of ct0 minus one, in a new ciphertext (see SwitchModulus).
+func (eval *evaluator) SwitchModulusNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	if ct0.Level() == 0 {
+		panic("cannot SwitchModulusNew: input ciphertext is already at level 0")
+	}
+	ctOut = NewCiphertextLvl(eval.params, ct0.Degree(), ct0.Level()-1)
+	eval.SwitchModulus(ct0, ctOut)
+	return
+}
+
+// switchLevel divides el by the moduli of its level above the given level (with rounding), and returns the result,
+// at the given level, in elOut. The input element is not modified unless it is also the receiver.
+func (eval *evaluator) switchLevel(level int, el, elOut *rlwe.Element) {
+
+	ringQ := eval.ringQ
+
+	levelIn := el.Level()
+
+	for i := range el.Value {
+
+		// The division by the last modulus modifies its input: it is performed on a copy
+		tmp := &ring.Poly{Coeffs: eval.poolQSwitch.Coeffs[:levelIn+1]}
+		ringQ.CopyLvl(levelIn, el.Value[i], tmp)
+		ringQ.DivRoundByLastModulusMany(tmp, tmp, levelIn-level)
+
+		elOut.Value[i].Coeffs = elOut.Value[i].Coeffs[:level+1]
+		ringQ.CopyLvl(level, tmp, elOut.Value[i])
+	}
+}
+
 // relinearize is a method common to Relinearize and RelinearizeNew. It switches ct0 to the NTT domain, applies the keyswitch, and returns the result out of the NTT domain.
 func (eval *evaluator) relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 
+	eval.allocateKeySwitchingPools(eval.evaluatorBase)
+
+	level := ctOut.Level()
+
 	if ctOut != ct0 {
-		eval.ringQ.Copy(ct0.Value[0], ctOut.Value[0])
-		eval.ringQ.Copy(ct0.Value[1], ctOut.Value[1])
+		eval.ringQ.CopyLvl(level, ct0.Value[0], ctOut.Value[0])
+		eval.ringQ.CopyLvl(level, ct0.Value[1], ctOut.Value[1])
 	}
 
 	for deg := uint64(ct0.Degree()); deg > 1; deg-- {
-		eval.switchKeysInPlace(ct0.Value[deg], eval.rlk.Keys[deg-2], eval.poolQKS[1], eval.poolQKS[2])
-		eval.ringQ.Add(ctOut.Value[0], eval.poolQKS[1], ctOut.Value[0])
-		eval.ringQ.Add(ctOut.Value[1], eval.poolQKS[2], ctOut.Value[1])
+		eval.switchKeysInPlace(level, ct0.Value[deg], eval.rlk.Keys[deg-2], eval.poolQKS[1], eval.poolQKS[2])
+		eval.ringQ.AddLvl(level, ctOut.Value[0], eval.poolQKS[1], ctOut.Value[0])
+		eval.ringQ.AddLvl(level, ctOut.Value[1], eval.poolQKS[2], ctOut.Value[1])
 	}
 
 	ctOut.SetValue(ctOut.Value[:2])
@@ -592,6 +882,8 @@ func (eval *evaluator) Relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 		panic("input ciphertext degree is too large to allow relinearization with the evluator's relinearization key")
 	}
 
+	ct0 = eval.matchLevel(ct0, ctOut)
+
 	if ct0.Degree() < 2 {
 		if ct0 != ctOut {
 			ctOut.Copy(ct0.El())
@@ -610,7 +902,7 @@ func (eval *evaluator) Relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 // - it must be of degree high enough to relinearize the input ciphertext to degree 1 (e.g., a ciphertext
 // of degree 3 will require that the evaluation key stores the keys for both degree 3 and degree 2 ciphertexts).
 func (eval *evaluator) RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, 1)
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
 	eval.Relinearize(ct0, ctOut)
 	return
 }
@@ -623,16 +915,21 @@ func (eval *evaluator) SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey,
 		panic("cannot SwitchKeys: input and output must be of degree 1 to allow key switching")
 	}
 
-	eval.switchKeysInPlace(ct0.Value[1], switchKey, eval.poolQKS[1], eval.poolQKS[2])
+	eval.allocateKeySwitchingPools(eval.evaluatorBase)
 
-	eval.ringQ.Add(ct0.Value[0], eval.poolQKS[1], ctOut.Value[0])
-	eval.ringQ.Copy(eval.poolQKS[2], ctOut.Value[1])
+	ct0 = eval.matchLevel(ct0, ctOut)
+	level := ctOut.Level()
+
+	eval.switchKeysInPlace(level, ct0.Value[1], switchKey, eval.poolQKS[1], eval.poolQKS[2])
+
+	eval.ringQ.AddLvl(level, ct0.Value[0], eval.poolQKS[1], ctOut.Value[0])
+	eval.ringQ.CopyLvl(level, eval.poolQKS[2], ctOut.Value[1])
 }
 
 // SwitchKeysNew applies the key-switching procedure to the ciphertext ct0 and creates a new ciphertext to store the result. It requires as an additional input a valid switching-key:
 // it must encrypt the target key under the public key under which ct0 is currently encrypted.
 func (eval *evaluator) SwitchKeysNew(ct0 *Ciphertext, switchkey *rlwe.SwitchingKey) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, 1)
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
 	eval.SwitchKeys(ct0, switchkey, ctOut)
 	return
 }
@@ -649,6 +946,8 @@ func (eval *evaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext)
 		panic("cannot RotateColumns: input and or output must be of degree 1")
 	}
 
+	ct0 = eval.matchLevel(ct0, ctOut)
+
 	if k == 0 {
 
 		ctOut.Copy(ct0.El())
@@ -667,9 +966,35 @@ func (eval *evaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext)
 	}
 }
 
+// RotateBy applies the rotation rot to ct0 and returns the result in ctOut. A rlwe.SlotRotation rotates the columns
+// (see RotateColumns) and a rlwe.CoeffShift multiplies the plaintext by a monomial. See rlwe.Rotation for the conventions.
+func (eval *evaluator) RotateBy(ct0 *Ciphertext, rot rlwe.Rotation, ctOut *Ciphertext) {
+	switch rot := rot.(type) {
+	case rlwe.SlotRotation:
+		eval.RotateColumns(ct0, int(rot), ctOut)
+	case rlwe.CoeffShift:
+		if ct0.Degree() != ctOut.Degree() {
+			panic("cannot RotateBy: input and output must be of the same degree")
+		}
+		ct0 = eval.matchLevel(ct0, ctOut)
+		for i := range ct0.Value {
+			eval.ringQ.MultByMonomialLvl(ctOut.Level(), ct0.Value[i], rot.MonomialDegree(eval.params.Parameters), ctOut.Value[i])
+		}
+	default:
+		panic(fmt.Sprintf("cannot RotateBy: unsupported rotation type %T", rot))
+	}
+}
+
+// RotateByNew applies RotateBy and returns the result in a new Ciphertext.
+func (eval *evaluator) RotateByNew(ct0 *Ciphertext, rot rlwe.Rotation) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, ct0.Degree(), ct0.Level())
+	eval.RotateBy(ct0, rot, ctOut)
+	return
+}
+
 // RotateColumnsNew applies RotateColumns and returns the result in a new Ciphertext.
 func (eval *evaluator) RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, 1)
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
 	eval.RotateColumns(ct0, k, ctOut)
 	return
 }
@@ -681,6 +1006,8 @@ func (eval *evaluator) RotateRows(ct0 *Ciphertext, ctOut *Ciphertext) {
 		panic("cannot RotateRows: input and/or output must be of degree 1")
 	}
 
+	ct0 = eval.matchLevel(ct0, ctOut)
+
 	galEl := eval.params.GaloisElementForRowRotation()
 
 	if key, inSet := eval.rtks.GetRotationKey(galEl); inSet {
@@ -690,9 +1017,15 @@ func (eval *evaluator) RotateRows(ct0 *Ciphertext, ctOut *Ciphertext) {
 	}
 }
 
+// HasConjugationKey returns true if the evaluator has the rotation key for the swap of the rows, i.e. if
+// RotateRows can be called (see KeyGenerator.GenConjugationKey).
+func (eval *evaluator) HasConjugationKey() bool {
+	return rlwe.HasRotationKeys(eval.rtks, eval.params.GaloisElementForRowRotation())
+}
+
 // RotateRowsNew rotates the rows of ct0 and returns the result a new Ciphertext.
 func (eval *evaluator) RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
-	ctOut = NewCiphertext(eval.params, 1)
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
 	eval.RotateRows(ct0, ctOut)
 	return
 }
@@ -705,7 +1038,10 @@ func (eval *evaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
 		panic("cannot InnerSum: input and output must be of degree 1")
 	}
 
-	cTmp := NewCiphertext(eval.params, 1)
+	ct0 = eval.matchLevel(ct0, ctOut)
+
+	cTmp := newCiphertextAtLevelFromPool(eval.params, 1, ctOut.Level())
+	defer cTmp.Release()
 
 	ctOut.Copy(ct0.El())
 
@@ -718,19 +1054,227 @@ func (eval *evaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
 	eval.Add(ctOut, cTmp, ctOut)
 }
 
+// InnerSumLog computes partial sums over the rows of ct0 with a log-depth tree of column rotations
+// (log2(n) + HW(n) rotations) and returns the result in ctOut. The operation assumes that each row of ct0
+// encrypts N/(2*batchSize) sub-vectors of size batchSize, which it adds together (in parallel) by groups of n:
+// the "leftmost" sub-vector of each group of ctOut is equal to the sum of the group, e.g. with batchSize=1,
+// the first slot of each group of n adjacent slots is the sum of the group. It requires the rotation keys
+// given by Parameters.RotationsForInnerSumLog.
+func (eval *evaluator) InnerSumLog(ct0 *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot InnerSumLog: input and output must be of degree 1")
+	}
+
+	if batchSize < 1 || n < 1 {
+		panic("cannot InnerSumLog: batchSize and n must be positive")
+	}
+
+	ct0 = eval.matchLevel(ct0, ctOut)
+	level := ctOut.Level()
+
+	if n == 1 {
+		if ct0 != ctOut {
+			ctOut.Copy(ct0.El())
+		}
+		return
+	}
+
+	// cur = sum_{t < 2^i} Rotate(ct0, t*batchSize)
+	cur := newCiphertextAtLevelFromPool(eval.params, 1, level)
+	defer cur.Release()
+
+	rot := newCiphertextAtLevelFromPool(eval.params, 1, level)
+	defer rot.Release()
+
+	acc := newCiphertextAtLevelFromPool(eval.params, 1, level)
+	defer acc.Release()
+
+	cur.Copy(ct0.El())
+
+	empty := true
+	// Binary reading of n: the bit i contributes the 2^i sub-vectors following the higher bits of n
+	for i, j := 0, n; j > 0; i, j = i+1, j>>1 {
+
+		if j&1 == 1 {
+
+			k := (n - (n & ((2 << i) - 1))) * batchSize
+
+			if k != 0 {
+				eval.RotateColumns(cur, k, rot)
+			} else {
+				rot.Copy(cur.El())
+			}
+
+			if empty {
+				acc.Copy(rot.El())
+				empty = false
+			} else {
+				eval.Add(acc, rot, acc)
+			}
+		}
+
+		if j > 1 {
+			eval.RotateColumns(cur, (1<<i)*batchSize, rot)
+			eval.Add(cur, rot, cur)
+		}
+	}
+
+	ctOut.Copy(acc.El())
+}
+
+// Expand obliviously expands ct0 into 2^logN ciphertexts, with the query expansion of Angel et al. ("PIR with
+// Compressed Queries and Amortized Query Processing", S&P 2018, a.k.a. SealPIR). If ct0 encrypts the plaintext
+// polynomial m = sum_i m_i X^i of R_t whose coefficients m_i are zero for i >= 2^logN, e.g. the coefficient encoding
+// (see NewEncoderCoeff) of a unit vector of 2^logN values, ctOut[j] encrypts the constant polynomial 2^logN * m_j.
+// The factor 2^logN can be cancelled by the client by encoding 2^-logN * m, which requires an odd t. Each of the logN
+// steps doubles the number of ciphertexts with a substitution X -> X^(N/2^i+1), which requires the rotation keys
+// given by Parameters.GaloisElementsForExpand (see KeyGenerator.GenRotationKeysForExpand).
+func (eval *evaluator) Expand(ct0 *Ciphertext, logN int) (ctOut []*Ciphertext) {
+
+	if ct0.Degree() != 1 {
+		panic("cannot Expand: input must be of degree 1")
+	}
+
+	if logN < 0 || logN > eval.params.LogN() {
+		panic("cannot Expand: logN must be in [0, params.LogN()]")
+	}
+
+	ringQ := eval.ringQ
+	N := ringQ.N
+
+	level := ct0.Level()
+
+	ctOut = make([]*Ciphertext, 1<<logN)
+	ctOut[0] = ct0.CopyNew()
+
+	sub := newCiphertextAtLevelFromPool(eval.params, 1, level)
+	defer sub.Release()
+
+	galEls := eval.params.GaloisElementsForExpand(logN)
+
+	for i, galEl := range galEls {
+
+		swk, inSet := eval.rtks.GetRotationKey(galEl)
+		if !inSet {
+			panic(fmt.Errorf("evaluator has no rotation key for Galois element %d", galEl))
+		}
+
+		// The ciphertexts of step i encrypt polynomials supported on the multiples of 2^i, on which the
+		// substitution X -> X^(N/2^i+1) negates the odd multiples of 2^i and leaves the even ones unchanged.
+		for j := 0; j < 1<<i; j++ {
+
+			ct := ctOut[j]
+
+			eval.permute(ct, galEl, swk, sub)
+
+			ctOut[j+(1<<i)] = NewCiphertextLvl(eval.params, 1, level)
+
+			// (ct - sub) * X^(-2^i)
+			for k := range ct.Value {
+				ringQ.SubLvl(level, ct.Value[k], sub.Value[k], ctOut[j+(1<<i)].Value[k])
+				ringQ.MultByMonomialLvl(level, ctOut[j+(1<<i)].Value[k], 2*N-(1<<i), ctOut[j+(1<<i)].Value[k])
+				ringQ.ReduceLvl(level, ctOut[j+(1<<i)].Value[k], ctOut[j+(1<<i)].Value[k])
+			}
+
+			// ct + sub
+			for k := range ct.Value {
+				ringQ.AddLvl(level, ct.Value[k], sub.Value[k], ct.Value[k])
+			}
+		}
+	}
+
+	return
+}
+
+// Equal evaluates the slot-wise equality test between ct0 and op1 and returns the result in ctOut: each slot of ctOut
+// is 1 if the corresponding slots of ct0 and op1 are equal and 0 otherwise. The circuit evaluates 1 - (ct0 - op1)^(t-1),
+// which is exact by Fermat's little theorem. It requires the parameters to allow batching (see Parameters.AllowsBatching),
+// a relinearization key, and consumes a multiplicative depth of ceil(log2(t-1)) (e.g., 16 for t = 65537).
+func (eval *evaluator) Equal(ct0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+
+	eval.checkWordSizedT("Equal")
+
+	if !eval.params.AllowsBatching() {
+		panic("cannot Equal: the parameters do not allow batching")
+	}
+
+	if ctOut.Degree() < 1 {
+		panic("cannot Equal: output ciphertext must be at least of degree 1")
+	}
+
+	diff := eval.SubNew(ct0, op1)
+	if diff.Degree() > 1 {
+		diff = eval.RelinearizeNew(diff)
+	}
+
+	tmp := newCiphertextAtLevelFromPool(eval.params, 2, diff.Level())
+	defer tmp.Release()
+
+	// Square-and-multiply computation of diff^(t-1)
+	var acc *Ciphertext
+	for e := eval.params.T() - 1; ; {
+
+		if e&1 == 1 {
+			if acc == nil {
+				acc = diff.CopyNew()
+			} else {
+				eval.Mul(acc, diff, tmp)
+				eval.Relinearize(tmp, acc)
+			}
+		}
+
+		if e >>= 1; e == 0 {
+			break
+		}
+
+		eval.Mul(diff, diff, tmp)
+		eval.Relinearize(tmp, diff)
+	}
+
+	// 1 - diff^(t-1)
+	one := NewPlaintextRingT(eval.params)
+	one.value.Coeffs[0][0] = 1
+
+	eval.Neg(acc, ctOut)
+	eval.Add(ctOut, one, ctOut)
+}
+
+// EqualNew evaluates the slot-wise equality test between ct0 and op1 and returns the result in a new ciphertext (see Equal).
+func (eval *evaluator) EqualNew(ct0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, 1, eval.minLevel(ct0, op1))
+	eval.Equal(ct0, op1, ctOut)
+	return
+}
+
 // permute performs a column rotation on ct0 and returns the result in ctOut
 func (eval *evaluator) permute(ct0 *Ciphertext, generator uint64, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext) {
 
-	eval.switchKeysInPlace(ct0.Value[1], switchKey, eval.poolQKS[1], eval.poolQKS[2])
+	eval.allocateKeySwitchingPools(eval.evaluatorBase)
 
-	eval.ringQ.Add(eval.poolQKS[1], ct0.Value[0], eval.poolQKS[1])
+	level := ct0.Level()
 
-	eval.ringQ.Permute(eval.poolQKS[1], generator, ctOut.Value[0])
-	eval.ringQ.Permute(eval.poolQKS[2], generator, ctOut.Value[1])
+	eval.switchKeysInPlace(level, ct0.Value[1], switchKey, eval.poolQKS[1], eval.poolQKS[2])
+
+	eval.ringQ.AddLvl(level, eval.poolQKS[1], ct0.Value[0], eval.poolQKS[1])
+
+	eval.ringQ.PermuteLvl(level, eval.poolQKS[1], generator, ctOut.Value[0])
+	eval.ringQ.PermuteLvl(level, eval.poolQKS[2], generator, ctOut.Value[1])
 }
 
 // switchKeys applies the general key-switching procedure of the form [c0 + cx*evakey[0], c1 + cx*evakey[1]]
-func (eval *evaluator) switchKeysInPlace(cx *ring.Poly, evakey *rlwe.SwitchingKey, pool2Q, pool3Q *ring.Poly) {
+func (eval *evaluator) switchKeysInPlace(level int, cx *ring.Poly, evakey *rlwe.SwitchingKey, pool2Q, pool3Q *ring.Poly) {
+
+	if eval.ks != nil {
+		// The key-switch is counted by the KeySwitcher (see ring.Ring.CountKeySwitch)
+		eval.ringQ.NTTLvl(level, cx, eval.poolQKS[3])
+		eval.ks.SwitchKeys(level, eval.poolQKS[3], cx, evakey, pool2Q, pool3Q)
+		eval.ringQ.InvNTTLvl(level, pool2Q, pool2Q)
+		eval.ringQ.InvNTTLvl(level, pool3Q, pool3Q)
+		return
+	}
+
+	eval.ringQ.CountKeySwitch()
 
 	ringQ := eval.ringQ
 	ringP := eval.ringP
@@ -738,8 +1282,6 @@ func (eval *evaluator) switchKeysInPlace(cx *ring.Poly, evakey *rlwe.SwitchingKe
 	pool2P := eval.poolPKS[1]
 	pool3P := eval.poolPKS[2]
 
-	level := len(ringQ.Modulus) - 1
-
 	c2QiQ := eval.poolQKS[0]
 	c2QiP := eval.poolPKS[0]
 	c2 := eval.poolQKS[3]
@@ -750,62 +1292,58 @@ func (eval *evaluator) switchKeysInPlace(cx *ring.Poly, evakey *rlwe.SwitchingKe
 	evakey1P := new(ring.Poly)
 
 	// We switch the element on which the key-switching operation will be conducted out of the NTT domain
-	ringQ.NTTLazy(cx, c2)
+	ringQ.NTTLazyLvl(level, cx, c2)
 
-	var reduce int
+	alpha := eval.params.PCount()
+	beta := int(math.Ceil(float64(level+1) / float64(alpha)))
 
-	// Key switching with CRT decomposition for the Qi
-	for i := 0; i < eval.params.Beta(); i++ {
+	// Key switching with CRT decomposition for the Qi. The products are accumulated in the lazy representation,
+	// which is the input representation of InvNTTLazy: no Reduce is needed.
+	for i := 0; i < beta; i++ {
 
 		eval.decomposeAndSplitNTT(level, i, c2, cx, c2QiQ, c2QiP)
 
 		evakey0Q.Coeffs = evakey.Value[i][0].Coeffs[:level+1]
 		evakey1Q.Coeffs = evakey.Value[i][1].Coeffs[:level+1]
-		evakey0P.Coeffs = evakey.Value[i][0].Coeffs[level+1:]
-		evakey1P.Coeffs = evakey.Value[i][1].Coeffs[level+1:]
+		evakey0P.Coeffs = evakey.Value[i][0].Coeffs[len(ringQ.Modulus):]
+		evakey1P.Coeffs = evakey.Value[i][1].Coeffs[len(ringQ.Modulus):]
 
 		if i == 0 {
-			ringQ.MulCoeffsMontgomeryLvl(level, evakey0Q, c2QiQ, pool2Q)
-			ringQ.MulCoeffsMontgomeryLvl(level, evakey1Q, c2QiQ, pool3Q)
-			ringP.MulCoeffsMontgomery(evakey0P, c2QiP, pool2P)
-			ringP.MulCoeffsMontgomery(evakey1P, c2QiP, pool3P)
+			ringQ.MulCoeffsMontgomeryLazyLvl(level, evakey0Q, c2QiQ, pool2Q)
+			ringQ.MulCoeffsMontgomeryLazyLvl(level, evakey1Q, c2QiQ, pool3Q)
+			ringP.MulCoeffsMontgomeryLazy(evakey0P, c2QiP, pool2P)
+			ringP.MulCoeffsMontgomeryLazy(evakey1P, c2QiP, pool3P)
 		} else {
-			ringQ.MulCoeffsMontgomeryAndAddNoModLvl(level, evakey0Q, c2QiQ, pool2Q)
-			ringQ.MulCoeffsMontgomeryAndAddNoModLvl(level, evakey1Q, c2QiQ, pool3Q)
-			ringP.MulCoeffsMontgomeryAndAddNoMod(evakey0P, c2QiP, pool2P)
-			ringP.MulCoeffsMontgomeryAndAddNoMod(evakey1P, c2QiP, pool3P)
-		}
-
-		if reduce&3 == 3 {
-			ringQ.ReduceLvl(level, pool2Q, pool2Q)
-			ringQ.ReduceLvl(level, pool3Q, pool3Q)
-			ringP.Reduce(pool2P, pool2P)
-			ringP.Reduce(pool3P, pool3P)
+			ringQ.MulCoeffsMontgomeryAndAddLazyLvl(level, evakey0Q, c2QiQ, pool2Q)
+			ringQ.MulCoeffsMontgomeryAndAddLazyLvl(level, evakey1Q, c2QiQ, pool3Q)
+			ringP.MulCoeffsMontgomeryAndAddLazy(evakey0P, c2QiP, pool2P)
+			ringP.MulCoeffsMontgomeryAndAddLazy(evakey1P, c2QiP, pool3P)
 		}
-
-		reduce++
 	}
 
-	if (reduce-1)&3 != 3 {
-		ringQ.ReduceLvl(level, pool2Q, pool2Q)
-		ringQ.ReduceLvl(level, pool3Q, pool3Q)
-		ringP.Reduce(pool2P, pool2P)
-		ringP.Reduce(pool3P, pool3P)
-	}
-
-	ringQ.InvNTTLazy(pool2Q, pool2Q)
-	ringQ.InvNTTLazy(pool3Q, pool3Q)
+	ringQ.InvNTTLazyLvl(level, pool2Q, pool2Q)
+	ringQ.InvNTTLazyLvl(level, pool3Q, pool3Q)
 	ringP.InvNTTLazy(pool2P, pool2P)
 	ringP.InvNTTLazy(pool3P, pool3P)
 
 	eval.baseconverterQ1P.ModDownSplitPQ(level, pool2Q, pool2P, pool2Q)
 	eval.baseconverterQ1P.ModDownSplitPQ(level, pool3Q, pool3P, pool3Q)
 }
+
+// checkWordSizedT panics if the evaluator was created by NewEvaluatorBigT, whose plaintext modulus does not fit the
+// operations that depend on it.
+func (eval *evaluator) checkWordSizedT(op string) {
+	if eval.tBig != nil {
+		panic(fmt.Sprintf("cannot %s: not supported with a plaintext modulus larger than a machine word (see NewEvaluatorBigT)", op))
+	}
+}
+
 func (eval *evaluator) getRingQElem(op Operand) *rlwe.Element {
 	switch o := op.(type) {
 	case *Ciphertext, *Plaintext:
 		return o.El()
 	case *PlaintextRingT:
+		eval.checkWordSizedT("evaluate a PlaintextRingT operand")
 		scaleUp(eval.ringQ, eval.deltaMont, o.value, eval.tmpPt.value)
 		return eval.tmpPt.Element
 	default:
@@ -813,7 +1351,60 @@ func (eval *evaluator) getRingQElem(op Operand) *rlwe.Element {
 	}
 }
 
+// isScaledUp returns true if op is an element of R_q scaled up by Q/t, i.e. a Ciphertext or a Plaintext, as opposed to
+// the plaintexts PlaintextRingT and PlaintextMul which can be used at any level.
+func isScaledUp(op Operand) bool {
+	switch op.(type) {
+	case *PlaintextRingT, *PlaintextMul:
+		return false
+	default:
+		return true
+	}
+}
+
+// levelOf returns the level of op, where the plaintexts that are not scaled up in R_q are considered at the maximum level.
+func (eval *evaluator) levelOf(op Operand) int {
+	if !isScaledUp(op) {
+		return eval.params.MaxLevel()
+	}
+	return op.El().Level()
+}
+
+// minLevel returns the smallest level between op0 and op1, i.e. the level of the result of a binary operation.
+func (eval *evaluator) minLevel(op0, op1 Operand) int {
+	return utils.MinInt(eval.levelOf(op0), eval.levelOf(op1))
+}
+
+// getElemAtLevel returns el if it is at the given level, and otherwise a new element storing el switched down
+// to the given level (see SwitchModulus).
+func (eval *evaluator) getElemAtLevel(level int, el *rlwe.Element) *rlwe.Element {
+	if el.Level() == level {
+		return el
+	}
+	elLvl := rlwe.NewElementAtLevel(eval.params.Parameters, el.Degree(), level)
+	eval.switchLevel(level, el, elLvl)
+	return elLvl
+}
+
+// matchLevel returns ct0 switched down to the level of ctOut if ct0 is at a larger level, and sets the level of
+// ctOut to the level of the result.
+func (eval *evaluator) matchLevel(ct0, ctOut *Ciphertext) *Ciphertext {
+	level := utils.MinInt(ct0.Level(), ctOut.Level())
+	ct0 = NewCiphertextFromElement(eval.getElemAtLevel(level, ct0.El()))
+	setLevel(level, ctOut.El())
+	return ct0
+}
+
+// setLevel drops the moduli of the polynomials of el above the given level.
+func setLevel(level int, el *rlwe.Element) {
+	for i := range el.Value {
+		el.Value[i].Coeffs = el.Value[i].Coeffs[:level+1]
+	}
+}
+
 // getElemAndCheckBinary unwraps the elements from the operands and checks that the receiver has sufficiently large degree.
+// The elements are returned at the smallest level among the operands and the receiver: the operands at a larger level are
+// switched down on new elements (see SwitchModulus), and the level of the receiver is set accordingly.
 func (eval *evaluator) getElemAndCheckBinary(op0, op1, opOut Operand, opOutMinDegree int, ensureRingQ bool) (el0, el1, elOut *rlwe.Element) {
 	if op0 == nil || op1 == nil || opOut == nil {
 		panic("operands cannot be nil")
@@ -827,13 +1418,31 @@ func (eval *evaluator) getElemAndCheckBinary(op0, op1, opOut Operand, opOutMinDe
 		panic("receiver operand degree is too small")
 	}
 
+	level := utils.MinInt(eval.minLevel(op0, op1), opOut.El().Level())
+
 	if ensureRingQ {
-		return eval.getRingQElem(op0), eval.getRingQElem(op1), opOut.El() // lifts from Rt to Rq if necessary
+		el0, el1 = eval.getRingQElem(op0), eval.getRingQElem(op1) // lifts from Rt to Rq if necessary
+	} else {
+		el0, el1 = op0.El(), op1.El()
+	}
+
+	// The plaintexts that are not scaled up in Rq are used as is
+	if ensureRingQ || isScaledUp(op0) {
+		el0 = eval.getElemAtLevel(level, el0)
 	}
 
-	return op0.El(), op1.El(), opOut.El()
+	if ensureRingQ || isScaledUp(op1) {
+		el1 = eval.getElemAtLevel(level, el1)
+	}
+
+	elOut = opOut.El()
+	setLevel(level, elOut)
+
+	return
 }
 
+// getElemAndCheckUnary unwraps the elements from the operands and checks that the receiver has sufficiently large degree.
+// The elements are returned at the smallest level between the operand and the receiver (see getElemAndCheckBinary).
 func (eval *evaluator) getElemAndCheckUnary(op0, opOut Operand, opOutMinDegree int) (el0, elOut *rlwe.Element) {
 	if op0 == nil || opOut == nil {
 		panic("operand cannot be nil")
@@ -846,31 +1455,40 @@ func (eval *evaluator) getElemAndCheckUnary(op0, opOut Operand, opOutMinDegree i
 	if opOut.Degree() < opOutMinDegree {
 		panic("receiver operand degree is too small")
 	}
-	el0, elOut = op0.El(), opOut.El()
+
+	level := utils.MinInt(op0.El().Level(), opOut.El().Level())
+
+	el0, elOut = eval.getElemAtLevel(level, op0.El()), opOut.El()
+	setLevel(level, elOut)
+
 	return
 }
 
 // evaluateInPlaceBinary applies the provided function in place on el0 and el1 and returns the result in elOut.
-func (eval *evaluator) evaluateInPlaceBinary(el0, el1, elOut *rlwe.Element, evaluate func(*ring.Poly, *ring.Poly, *ring.Poly)) {
+// The elements must be at the same level.
+func (eval *evaluator) evaluateInPlaceBinary(el0, el1, elOut *rlwe.Element, evaluate func(int, *ring.Poly, *ring.Poly, *ring.Poly)) {
+
+	level := elOut.Level()
 
 	smallest, largest, _ := rlwe.GetSmallestLargest(el0, el1)
 
 	for i := 0; i < smallest.Degree()+1; i++ {
-		evaluate(el0.Value[i], el1.Value[i], elOut.Value[i])
+		evaluate(level, el0.Value[i], el1.Value[i], elOut.Value[i])
 	}
 
 	// If the inputs degrees differ, it copies the remaining degree on the receiver.
 	if largest != nil && largest != elOut { // checks to avoid unnecessary work.
 		for i := smallest.Degree() + 1; i < largest.Degree()+1; i++ {
-			elOut.Value[i].Copy(largest.Value[i])
+			eval.ringQ.CopyLvl(level, largest.Value[i], elOut.Value[i])
 		}
 	}
 }
 
 // evaluateInPlaceUnary applies the provided function in place on el0 and returns the result in elOut.
-func evaluateInPlaceUnary(el0, elOut *rlwe.Element, evaluate func(*ring.Poly, *ring.Poly)) {
+// The elements must be at the same level.
+func evaluateInPlaceUnary(el0, elOut *rlwe.Element, evaluate func(int, *ring.Poly, *ring.Poly)) {
 	for i := range el0.Value {
-		evaluate(el0.Value[i], elOut.Value[i])
+		evaluate(elOut.Level(), el0.Value[i], elOut.Value[i])
 	}
 }
 
@@ -20,6 +20,8 @@ type KeyGenerator interface {
 	GenRotationKeys(galEls []uint64, sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
 	GenRotationKeysForRotations(ks []int, includeSwapRow bool, sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
 	GenRotationKeysForInnerSum(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
+	GenConjugationKey(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
+	GenRotationKeysForExpand(logN int, sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
 }
 
 // keyGenerator is a structure that stores the elements required to create new keys,
@@ -182,11 +184,23 @@ func (keygen *keyGenerator) GenRotationKeysForRotations(ks []int, includeConjuga
 	return keygen.GenRotationKeys(galEls, sk)
 }
 
+// GenConjugationKey generates a RotationKeySet containing only the key for the swap of the rows of the plaintext
+// matrix, as required by Evaluator.RotateRows.
+func (keygen *keyGenerator) GenConjugationKey(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet) {
+	return keygen.GenRotationKeys([]uint64{keygen.params.GaloisElementForRowRotation()}, sk)
+}
+
 // GenRotationKeysForInnerSum generates a RotationKeySet supporting the InnerSum operation of the Evaluator
 func (keygen *keyGenerator) GenRotationKeysForInnerSum(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet) {
 	return keygen.GenRotationKeys(keygen.params.GaloisElementsForRowInnerSum(), sk)
 }
 
+// GenRotationKeysForExpand generates a RotationKeySet supporting the Expand operation of the Evaluator with
+// parameter logN (see Parameters.GaloisElementsForExpand).
+func (keygen *keyGenerator) GenRotationKeysForExpand(logN int, sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet) {
+	return keygen.GenRotationKeys(keygen.params.GaloisElementsForExpand(logN), sk)
+}
+
 func (keygen *keyGenerator) genrotKey(sk *ring.Poly, gen uint64, swkOut *rlwe.SwitchingKey) {
 
 	skIn := sk
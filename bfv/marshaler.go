@@ -1,6 +1,9 @@
 package bfv
 
 import (
+	"encoding"
+	"errors"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 )
@@ -31,7 +34,11 @@ func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes a previously marshaled Ciphertext in the target Ciphertext.
 func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
 
-	ciphertext.Element = new(rlwe.Element)
+	if len(data) < 1 {
+		return errors.New("too small bytearray")
+	}
+
+	ciphertext.Ciphertext = &rlwe.Ciphertext{Element: new(rlwe.Element), MetaData: rlwe.MetaData{Scheme: rlwe.SchemeBFV}}
 
 	ciphertext.Value = make([]*ring.Poly, uint8(data[0]))
 
@@ -64,3 +71,47 @@ func (ciphertext *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 
 	return dataLen
 }
+
+// MarshalVersioned encodes obj, a Ciphertext or a key of the parameters params, on a slice of bytes prefixed by its
+// rlwe.Header, whose content can be inspected with rlwe.Peek.
+func MarshalVersioned(params Parameters, obj encoding.BinaryMarshaler) (data []byte, err error) {
+
+	var h rlwe.Header
+	if h, err = newHeader(params, obj); err != nil {
+		return nil, err
+	}
+
+	return rlwe.MarshalWithHeader(h, obj)
+}
+
+// UnmarshalVersioned decodes data, previously marshaled with MarshalVersioned, on obj, a Ciphertext or a key of the
+// parameters params, and returns its rlwe.Header. Returns an error, without decoding the object, if data were
+// marshaled with an unsupported version, or for another scheme, type of object or parameters.
+func UnmarshalVersioned(params Parameters, data []byte, obj encoding.BinaryUnmarshaler) (h rlwe.Header, err error) {
+
+	var want rlwe.Header
+	if want, err = newHeader(params, obj); err != nil {
+		return want, err
+	}
+
+	return rlwe.UnmarshalWithHeader(data, want, obj)
+}
+
+// newHeader returns the rlwe.Header of obj, a Ciphertext or a key of the parameters params.
+func newHeader(params Parameters, obj interface{}) (h rlwe.Header, err error) {
+
+	var hash [8]byte
+	if hash, err = rlwe.ParametersHash(params); err != nil {
+		return h, err
+	}
+
+	if ciphertext, ok := obj.(*Ciphertext); ok {
+		el := new(rlwe.Element)
+		if ciphertext.Ciphertext != nil && ciphertext.Element != nil {
+			el = ciphertext.Element
+		}
+		return rlwe.NewHeader(rlwe.SchemeBFV, hash, el)
+	}
+
+	return rlwe.NewHeader(rlwe.SchemeBFV, hash, obj)
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/bits"
+	"strings"
 
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
 var (
@@ -108,6 +112,19 @@ type ParametersLiteral struct {
 	T     uint64  // Plaintext modulus
 }
 
+// NamedParams are the default parameter sets indexed by their name, which can be referenced in the JSON
+// representation of the parameters (see Parameters.UnmarshalJSON). Applications can add their own parameter sets.
+var NamedParams = map[string]ParametersLiteral{
+	"PN12QP109":   PN12QP109,
+	"PN13QP218":   PN13QP218,
+	"PN14QP438":   PN14QP438,
+	"PN15QP880":   PN15QP880,
+	"PN12QP101pq": PN12QP101pq,
+	"PN13QP202pq": PN13QP202pq,
+	"PN14QP411pq": PN14QP411pq,
+	"PN15QP827pq": PN15QP827pq,
+}
+
 // Parameters represents a parameter set for the BFV cryptosystem. Its fields are private and
 // immutable. See ParametersLiteral for user-specified parameters.
 type Parameters struct {
@@ -121,6 +138,9 @@ func NewParameters(rlweParams rlwe.Parameters, t uint64) (p Parameters, err erro
 	if rlweParams.Equals(rlwe.Parameters{}) {
 		return Parameters{}, fmt.Errorf("provided RLWE parameters are invalid")
 	}
+	if t < 2 {
+		return Parameters{}, fmt.Errorf("t=%d must be at least 2", t)
+	}
 	if t > rlweParams.Q()[0] {
 		return Parameters{}, fmt.Errorf("t=%d is larger than Q[0]=%d", t, rlweParams.Q()[0])
 	}
@@ -142,13 +162,99 @@ func (p Parameters) T() uint64 {
 	return p.t
 }
 
+// MaxLevel returns the maximum ciphertext level. A ciphertext at level l has its coefficients modulo the
+// first l+1 moduli of Q; the level of a ciphertext is decreased with Evaluator.SwitchModulus.
+func (p Parameters) MaxLevel() int {
+	return p.QCount() - 1
+}
+
+// AllowsBatching returns true if the plaintext modulus t is a prime congruent to 1 mod 2N, in which case R_t splits
+// into N slots and the parameters can be used with the batch encoder (see NewEncoderBatch). For any other t, only the
+// coefficient encoder can be used (see NewEncoderCoeff).
+func (p Parameters) AllowsBatching() bool {
+	return ring.IsPrime(p.t) && p.t&uint64(2*p.N()-1) == 1
+}
+
 // RingT instantiates a new ring.Ring corresponding to the plaintext space ring R_t.
+// If the parameters do not allow batching, the returned ring does not support the NTT.
 func (p Parameters) RingT() *ring.Ring {
-	ringQP, err := ring.NewRing(p.N(), []uint64{p.t})
-	if err != nil {
+	ringT, err := ring.NewRing(p.N(), []uint64{p.t})
+	if err != nil && (ringT == nil || p.AllowsBatching()) {
 		panic(err) // Parameter type invariant
 	}
-	return ringQP
+	return ringT
+}
+
+// RotationsForInnerSumLog generates the column rotations that will be performed by the
+// `Evaluator.InnerSumLog` operation when performed with parameters `batch` and `n`.
+func (p Parameters) RotationsForInnerSumLog(batch, n int) (rotations []int) {
+
+	rotations = []int{}
+	var k int
+	for i := 1; i < n; i <<= 1 {
+
+		k = i
+		k *= batch
+
+		if !utils.IsInSliceInt(k, rotations) && k != 0 {
+			rotations = append(rotations, k)
+		}
+
+		k = n - (n & ((i << 1) - 1))
+		k *= batch
+
+		if !utils.IsInSliceInt(k, rotations) && k != 0 {
+			rotations = append(rotations, k)
+		}
+	}
+
+	return
+}
+
+// GaloisElementsForExpand returns the Galois elements X -> X^(N/2^i+1), for 0 <= i < logN, of the substitutions
+// performed by the `Evaluator.Expand` operation when performed with parameter `logN`.
+func (p Parameters) GaloisElementsForExpand(logN int) (galEls []uint64) {
+	galEls = make([]uint64, logN)
+	for i := range galEls {
+		galEls[i] = uint64(p.N()>>i) + 1
+	}
+	return
+}
+
+// DepthEqual returns the multiplicative depth consumed by the `Evaluator.Equal` and `Evaluator.EqualConst`
+// operations, which is ceil(log2(t-1)).
+func (p Parameters) DepthEqual() int {
+	return bits.Len64(p.t - 2)
+}
+
+// DepthLessThan returns the multiplicative depth consumed by the `Evaluator.LessThan` operation when performed with
+// parameter `bound`, which is ceil(log2(2*bound-2)).
+func (p Parameters) DepthLessThan(bound uint64) int {
+	if bound < 2 {
+		return 0
+	}
+	return bits.Len64(2*bound - 3)
+}
+
+// DepthPoly returns the maximum multiplicative depth consumed by the `Evaluator.EvaluatePoly` operation for a
+// polynomial of the given degree, which is ceil(log2(degree+1)).
+func (p Parameters) DepthPoly(degree int) int {
+	if degree < 1 {
+		return 0
+	}
+	return bits.Len64(uint64(degree))
+}
+
+// EstimateLogQPoly returns a heuristic estimate of the number of bits of the modulus Q needed to decrypt
+// correctly the result of the `Evaluator.EvaluatePoly` operation for a polynomial of the given degree on a
+// fresh ciphertext. The estimate is the sum of the bits of t and of the fresh noise, plus, for each level of
+// DepthPoly(degree), the growth of the noise in a multiplication by a plaintext coefficient and in a
+// relinearized ciphertext multiplication, log2(t) + log2(t*N) + 4 bits. It is meant to size the parameters,
+// not as a security or correctness guarantee.
+func (p Parameters) EstimateLogQPoly(degree int) int {
+	logT := bits.Len64(p.t)
+	logFresh := int(math.Ceil(math.Log2(6 * p.Sigma() * float64(p.N()))))
+	return logT + 1 + logFresh + p.DepthPoly(degree)*(2*logT+p.LogN()+4)
 }
 
 // Equals compares two sets of parameters for equality.
@@ -158,6 +264,20 @@ func (p Parameters) Equals(other Parameters) bool {
 	return res
 }
 
+// String returns a single-line summary of the parameters.
+func (p Parameters) String() string {
+	return fmt.Sprintf("BFV(%s, t=%d)", p.Parameters.String(), p.t)
+}
+
+// Describe returns a multi-line human-readable description of the parameters, including the plaintext modulus.
+func (p Parameters) Describe() string {
+	var sb strings.Builder
+	sb.WriteString("BFV parameters:\n")
+	sb.WriteString(p.Parameters.Describe())
+	fmt.Fprintf(&sb, "%-10s: %d (%d bits)\n", "t", p.t, bits.Len64(p.t))
+	return sb.String()
+}
+
 // CopyNew makes a deep copy of the receiver and returns it.
 func (p Parameters) CopyNew() Parameters {
 	p.Parameters = p.Parameters.CopyNew()
@@ -204,9 +324,96 @@ func (p Parameters) MarshalJSON() ([]byte, error) {
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
+// The representation is either the name of a parameter set of NamedParams (e.g., "PN14QP438"), or a ParametersLiteral
+// object, which can start from a parameter set of NamedParams given by the field "Preset" and override some of its
+// fields (e.g., {"Preset": "PN14QP438", "T": 65537}). The decoding of an object with a "Preset" is strict: it returns an error
+// if the object has unknown fields, references an unknown parameter set, or has a non-positive Sigma. An object
+// without "Preset" is decoded as by the previous versions, ignoring its unknown fields.
 func (p *Parameters) UnmarshalJSON(data []byte) (err error) {
 	var params ParametersLiteral
-	json.Unmarshal(data, &params)
+	if params, err = parametersLiteralFromJSON(data); err != nil {
+		return err
+	}
 	*p, err = NewParametersFromLiteral(params)
 	return
 }
+
+// parametersLiteralFromJSON decodes the JSON representation of the parameters described in Parameters.UnmarshalJSON.
+func parametersLiteralFromJSON(data []byte) (pl ParametersLiteral, err error) {
+
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		return namedParametersLiteral(name)
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return ParametersLiteral{}, err
+	}
+
+	raw, ok := jsonField(fields, "Preset")
+	if !ok {
+		// A ParametersLiteral object is decoded as by the previous versions, which ignored its unknown fields
+		err = json.Unmarshal(data, &pl)
+		return pl, err
+	}
+
+	if err = json.Unmarshal(raw, &name); err != nil {
+		return ParametersLiteral{}, fmt.Errorf("invalid parameters: Preset must be a string")
+	}
+
+	if pl, err = namedParametersLiteral(name); err != nil {
+		return ParametersLiteral{}, err
+	}
+
+	// The moduli given either by their values or by their sizes replace the ones of the preset.
+	if _, ok := jsonField(fields, "Q"); ok {
+		pl.LogQ = nil
+	} else if _, ok := jsonField(fields, "LogQ"); ok {
+		pl.Q = nil
+	}
+
+	if _, ok := jsonField(fields, "P"); ok {
+		pl.LogP = nil
+	} else if _, ok := jsonField(fields, "LogP"); ok {
+		pl.P = nil
+	}
+
+	literal := struct {
+		Preset string
+		*ParametersLiteral
+	}{ParametersLiteral: &pl}
+
+	if err = utils.UnmarshalJSONStrict(data, &literal); err != nil {
+		return ParametersLiteral{}, err
+	}
+
+	if pl.Sigma <= 0 {
+		return ParametersLiteral{}, fmt.Errorf("invalid parameters: Sigma=%v must be positive", pl.Sigma)
+	}
+
+	return pl, nil
+}
+
+// namedParametersLiteral returns a copy of the parameter set of NamedParams with the given name.
+func namedParametersLiteral(name string) (ParametersLiteral, error) {
+	pl, ok := NamedParams[name]
+	if !ok {
+		return ParametersLiteral{}, fmt.Errorf("invalid parameters: unknown parameter set %q", name)
+	}
+	pl.Q = append([]uint64(nil), pl.Q...)
+	pl.P = append([]uint64(nil), pl.P...)
+	pl.LogQ = append([]int(nil), pl.LogQ...)
+	pl.LogP = append([]int(nil), pl.LogP...)
+	return pl, nil
+}
+
+// jsonField returns the raw value of the field of fields matching key, case-insensitively like encoding/json.
+func jsonField(fields map[string]json.RawMessage, key string) (raw json.RawMessage, ok bool) {
+	for k, v := range fields {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
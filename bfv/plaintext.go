@@ -1,6 +1,8 @@
 package bfv
 
 import (
+	"fmt"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 )
@@ -34,6 +36,13 @@ func NewPlaintext(params Parameters) *Plaintext {
 	return plaintext
 }
 
+// newPlaintextFromPool creates a new plaintext in RingQ whose polynomial is drawn from ring.DefaultPolyPool.
+func newPlaintextFromPool(params Parameters) *Plaintext {
+	plaintext := &Plaintext{rlwe.NewElementAtLevelFromPool(params.Parameters, 0, params.QCount()-1), nil}
+	plaintext.value = plaintext.Element.Value[0]
+	return plaintext
+}
+
 // NewPlaintextRingT creates and allocates a new plaintext in RingT (single modulus T).
 // The plaintext will be in RingT.
 func NewPlaintextRingT(params Parameters) *PlaintextRingT {
@@ -49,3 +58,18 @@ func NewPlaintextMul(params Parameters) *PlaintextMul {
 	plaintext.value = plaintext.Element.Value[0]
 	return plaintext
 }
+
+// RLWE returns the receiver plaintext as an rlwe.Plaintext of the scheme rlwe.SchemeBFV. The two plaintexts share
+// their polynomial.
+func (pt *Plaintext) RLWE() *rlwe.Plaintext {
+	return &rlwe.Plaintext{Element: pt.Element, MetaData: rlwe.MetaData{Scheme: rlwe.SchemeBFV}}
+}
+
+// NewPlaintextFromRLWE returns the rlwe.Plaintext pt as a Plaintext. The two plaintexts share their polynomial.
+// Returns an error if pt is not a plaintext of the scheme rlwe.SchemeBFV.
+func NewPlaintextFromRLWE(pt *rlwe.Plaintext) (*Plaintext, error) {
+	if pt.Scheme != rlwe.SchemeBFV {
+		return nil, fmt.Errorf("cannot NewPlaintextFromRLWE: scheme %s is not BFV", pt.Scheme)
+	}
+	return &Plaintext{pt.Element, pt.Value[0]}, nil
+}
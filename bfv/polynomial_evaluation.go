@@ -0,0 +1,169 @@
+package bfv
+
+import (
+	"math/bits"
+)
+
+// Poly is a polynomial over Z_t, in the standard basis, that can be evaluated slot-wise on a ciphertext
+// (see Evaluator.EvaluatePoly).
+type Poly struct {
+	coeffs []uint64
+}
+
+// NewPoly creates a new Poly from its coefficients, given by increasing degree. The coefficients are reduced
+// modulo t at the evaluation.
+func NewPoly(coeffs []uint64) (p *Poly) {
+
+	degree := len(coeffs) - 1
+	for degree > 0 && coeffs[degree] == 0 {
+		degree--
+	}
+
+	p = new(Poly)
+	p.coeffs = make([]uint64, degree+1)
+	copy(p.coeffs, coeffs)
+
+	return
+}
+
+// Degree returns the degree of the polynomial.
+func (p *Poly) Degree() int {
+	return len(p.coeffs) - 1
+}
+
+// Coeffs returns a copy of the coefficients of the polynomial.
+func (p *Poly) Coeffs() (coeffs []uint64) {
+	coeffs = make([]uint64, len(p.coeffs))
+	copy(coeffs, p.coeffs)
+	return
+}
+
+// EvaluatePoly evaluates the polynomial pol slot-wise on ct0 and returns the result in ctOut, with the
+// Paterson-Stockmeyer algorithm: the polynomial is split recursively on the powers ct0^(2^i), and the leaves, of
+// degree smaller than 2^l, are evaluated with the baby-step powers ct0, ct0^2, ..., ct0^(2^l-1), where l
+// minimizes the number of ciphertext multiplications. Each ciphertext multiplication is followed by a
+// relinearization.
+//
+// It requires the parameters to allow batching (see Parameters.AllowsBatching) and, unless pol has degree at
+// most one, a relinearization key. It consumes a multiplicative depth of at most Parameters.DepthPoly(pol.Degree())
+// (see also Parameters.EstimateLogQPoly).
+func (eval *evaluator) EvaluatePoly(ct0 *Ciphertext, pol *Poly, ctOut *Ciphertext) {
+
+	eval.checkWordSizedT("EvaluatePoly")
+
+	if !eval.params.AllowsBatching() {
+		panic("cannot EvaluatePoly: the parameters do not allow batching")
+	}
+
+	if ctOut.Degree() < 1 {
+		panic("cannot EvaluatePoly: output ciphertext must be at least of degree 1")
+	}
+
+	t := eval.params.T()
+	coeffs := pol.Coeffs()
+	for i := range coeffs {
+		coeffs[i] %= t
+	}
+
+	x := ct0
+	if x.Degree() > 1 {
+		x = eval.RelinearizeNew(x)
+	}
+
+	logDegree := bits.Len64(uint64(pol.Degree()))
+	logSplit := optimalSplit(logDegree)
+
+	powers := map[int]*Ciphertext{1: x}
+
+	for i := 2; i < (1 << logSplit); i++ {
+		eval.computePower(i, powers)
+	}
+
+	for i := logSplit; i < logDegree; i++ {
+		eval.computePower(1<<i, powers)
+	}
+
+	eval.evaluatePolyFromPowers(logSplit, coeffs, powers, ctOut)
+}
+
+// EvaluatePolyNew evaluates the polynomial pol slot-wise on ct0 and returns the result in a new ciphertext
+// (see EvaluatePoly).
+func (eval *evaluator) EvaluatePolyNew(ct0 *Ciphertext, pol *Poly) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
+	eval.EvaluatePoly(ct0, pol, ctOut)
+	return
+}
+
+// optimalSplit returns the log2 of the baby-step giant-step split minimizing the number of
+// non-scalar multiplications 2^logSplit + 2^(logDegree-logSplit) for a polynomial of degree < 2^logDegree.
+func optimalSplit(logDegree int) (logSplit int) {
+	logSplit = 1
+	for i := 2; i < logDegree; i++ {
+		if (1<<i)+(1<<(logDegree-i)) < (1<<logSplit)+(1<<(logDegree-logSplit)) {
+			logSplit = i
+		}
+	}
+	return
+}
+
+// computePower computes powers[n] = powers[ceil(n/2)] * powers[floor(n/2)] and the powers it depends on, with a
+// multiplicative depth of ceil(log2(n)).
+func (eval *evaluator) computePower(n int, powers map[int]*Ciphertext) {
+
+	if powers[n] != nil {
+		return
+	}
+
+	a, b := (n+1)>>1, n>>1
+
+	eval.computePower(a, powers)
+	eval.computePower(b, powers)
+
+	powers[n] = eval.RelinearizeNew(eval.MulNew(powers[a], powers[b]))
+}
+
+// evaluatePolyFromPowers evaluates the polynomial of the given coefficients, reduced modulo t, on the powers and
+// returns the result in ctOut. The polynomial is split as coeffs = lo + X^(2^i) * hi, until its degree is smaller
+// than 2^logSplit.
+func (eval *evaluator) evaluatePolyFromPowers(logSplit int, coeffs []uint64, powers map[int]*Ciphertext, ctOut *Ciphertext) {
+
+	level := powers[1].Level()
+	degree := len(coeffs) - 1
+
+	// Leaf, evaluated as a linear combination of the baby-step powers
+	if degree < (1 << logSplit) {
+
+		acc := NewCiphertextLvl(eval.params, 1, level)
+		tmp := NewCiphertextLvl(eval.params, 1, level)
+		for k := 1; k <= degree; k++ {
+			if coeffs[k] != 0 {
+				eval.MulScalar(powers[k], coeffs[k], tmp)
+				eval.Add(acc, tmp, acc)
+			}
+		}
+
+		eval.Add(acc, constantPlaintext(eval.params, coeffs[0]), ctOut)
+		return
+	}
+
+	nextPower := 1 << logSplit
+	for nextPower < (degree>>1)+1 {
+		nextPower <<= 1
+	}
+
+	lo, hi := coeffs[:nextPower], coeffs[nextPower:]
+
+	var res *Ciphertext
+	if len(hi) == 1 {
+		res = eval.MulScalarNew(powers[nextPower], hi[0])
+	} else {
+		res = NewCiphertextLvl(eval.params, 1, level)
+		eval.evaluatePolyFromPowers(logSplit, hi, powers, res)
+		res = eval.RelinearizeNew(eval.MulNew(res, powers[nextPower]))
+	}
+
+	tmp := NewCiphertextLvl(eval.params, 1, level)
+	eval.evaluatePolyFromPowers(logSplit, lo, powers, tmp)
+
+	eval.Add(res, tmp, ctOut)
+}
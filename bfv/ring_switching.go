@@ -0,0 +1,66 @@
+package bfv
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RingSwitcher moves ciphertexts between a large ring degree N and a small ring degree n < N, for example to ship
+// the results of a computation in much smaller ciphertexts. The small parameters must have the same plaintext
+// modulus as the large parameters, and their moduli Q must be the first moduli Q of the large parameters.
+//
+// Moving a ciphertext to the small ring keeps the coefficients of its plaintext of index multiple of N/n: the
+// plaintexts m(X^(N/n)) of the large ring, which are for example obtained by encoding with NewEncoderCoeff only
+// the coefficients of index multiple of N/n, are moved to m(Y) in the small ring, and conversely.
+// A RingSwitcher is not safe for concurrent use.
+type RingSwitcher struct {
+	*rlwe.RingSwitcher
+	large Parameters
+	small Parameters
+
+	evalLarge *evaluator
+}
+
+// GenRingSwitchingKeys generates the key bridges between the secret key skLarge of the large parameters and the
+// secret key skSmall of the small parameters.
+func GenRingSwitchingKeys(large, small Parameters, skLarge, skSmall *rlwe.SecretKey) *rlwe.RingSwitchingKeys {
+	return rlwe.GenRingSwitchingKeys(large.Parameters, small.Parameters, skLarge, skSmall)
+}
+
+// NewRingSwitcher creates a new RingSwitcher between the large and small parameters with the key bridges keys.
+// It panics if the ciphertexts cannot be switched between the two rings (see rlwe.CheckRingSwitching) or if the
+// plaintext moduli differ.
+func NewRingSwitcher(large, small Parameters, keys *rlwe.RingSwitchingKeys) *RingSwitcher {
+
+	if large.T() != small.T() {
+		panic(fmt.Sprintf("cannot NewRingSwitcher: small t=%d does not match large t=%d", small.T(), large.T()))
+	}
+
+	return &RingSwitcher{
+		RingSwitcher: rlwe.NewRingSwitcher(large.Parameters, small.Parameters, keys),
+		large:        large,
+		small:        small,
+		evalLarge:    NewEvaluator(large, rlwe.EvaluationKey{}).(*evaluator),
+	}
+}
+
+// ToSmallNew moves ctLarge, a ciphertext of the large parameters, to the small ring and returns the result in a new
+// ciphertext of the small parameters, at the level of ctLarge or at the maximum level of the small parameters if
+// lower, in which case ctLarge is first switched down to this level (see Evaluator.SwitchModulus).
+// ctLarge must be of degree 1.
+func (rs *RingSwitcher) ToSmallNew(ctLarge *Ciphertext) (ctSmall *Ciphertext) {
+	level := utils.MinInt(ctLarge.Level(), rs.MaxLevel())
+	ctSmall = NewCiphertextLvl(rs.small, 1, level)
+	rs.SwitchDown(rs.evalLarge.getElemAtLevel(level, ctLarge.Element), ctSmall.Element)
+	return
+}
+
+// ToLargeNew moves ctSmall, a ciphertext of the small parameters, to the large ring and returns the result in a new
+// ciphertext of the large parameters, at the level of ctSmall. ctSmall must be of degree 1.
+func (rs *RingSwitcher) ToLargeNew(ctSmall *Ciphertext) (ctLarge *Ciphertext) {
+	ctLarge = NewCiphertextLvl(rs.large, 1, ctSmall.Level())
+	rs.SwitchUp(ctSmall.Element, ctLarge.Element)
+	return
+}
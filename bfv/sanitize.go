@@ -0,0 +1,138 @@
+package bfv
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// MaxFloodingSigma is the maximum standard deviation of the flooding noise of a Sanitizer.
+const MaxFloodingSigma = float64(1 << 59)
+
+// FloodingSigma returns the standard deviation of the flooding noise such that the statistical distance between
+// the noises of two sanitized ciphertexts, whose noises before the sanitization have coefficients bounded by
+// noiseBound in absolute value, is at most 2^-statisticalSecurity: by the smudging lemma, the statistical distance
+// between N(0, sigma^2) and N(c, sigma^2) is at most |c|/(sigma*sqrt(2*pi)) on each of the N coefficients.
+func (p Parameters) FloodingSigma(noiseBound float64, statisticalSecurity int) float64 {
+	return float64(p.N()) * noiseBound * math.Exp2(float64(statisticalSecurity)) / math.Sqrt(2*math.Pi)
+}
+
+// CheckFloodingSigma returns an error if sigma is not a valid standard deviation for the flooding noise of the
+// ciphertexts at the given level: it must be at least the standard deviation of the encryption noise and at most
+// MaxFloodingSigma, and 6*sigma must be at most half of the noise budget Q_level/(2t) of the level, so that the
+// flooding noise only exceeds the budget with a sample beyond 12*sigma.
+func (p Parameters) CheckFloodingSigma(sigma float64, level int) error {
+
+	if math.IsNaN(sigma) || sigma < p.Sigma() || sigma > MaxFloodingSigma {
+		return fmt.Errorf("flooding sigma=%v must be in [%v, 2^59]", sigma, p.Sigma())
+	}
+
+	if level < 0 || level > p.MaxLevel() {
+		return fmt.Errorf("invalid level %d", level)
+	}
+
+	qLvl := ring.NewUint(1)
+	for _, qi := range p.Q()[:level+1] {
+		qLvl.Mul(qLvl, ring.NewUint(qi))
+	}
+
+	budget, _ := new(big.Float).Quo(new(big.Float).SetInt(qLvl), big.NewFloat(float64(4*p.T()))).Float64()
+
+	if 6*sigma >= budget {
+		return fmt.Errorf("flooding sigma=2^%.2f exceeds half of the noise budget 2^%.2f at level %d", math.Log2(sigma), math.Log2(budget), level)
+	}
+
+	return nil
+}
+
+// Sanitizer re-randomizes ciphertexts for circuit privacy: it adds to a ciphertext a fresh encryption of zero under
+// the public key, which makes its second polynomial independent of the evaluated circuit, and a flooding discrete
+// Gaussian noise of large standard deviation on its first polynomial (see ring.DiscreteGaussianSampler), which
+// statistically hides its noise (see Parameters.FloodingSigma), so that a server can return the result of a
+// computation without leaking anything about the circuit but the decrypted result. The flooding noise consumes a part of the noise budget of the
+// ciphertext, which must still be decryptable once sanitized.
+// A Sanitizer is not safe for concurrent use.
+type Sanitizer struct {
+	params Parameters
+	sigma  float64
+
+	encryptor Encryptor
+	eval      *evaluator
+	ptZero    *Plaintext
+	ctZero    *Ciphertext
+
+	gaussianSampler *ring.DiscreteGaussianSampler
+}
+
+// NewSanitizer creates a new Sanitizer re-randomizing the ciphertexts with encryptions of zero under the public key
+// pk and a flooding noise of standard deviation sigma. Returns an error if sigma is not valid at the maximum level
+// (see Parameters.CheckFloodingSigma).
+func NewSanitizer(params Parameters, pk *rlwe.PublicKey, sigma float64) (*Sanitizer, error) {
+
+	if err := params.CheckFloodingSigma(sigma, params.MaxLevel()); err != nil {
+		return nil, fmt.Errorf("cannot NewSanitizer: %w", err)
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sanitizer{
+		params:          params,
+		sigma:           sigma,
+		encryptor:       NewEncryptorFromPk(params, pk),
+		eval:            NewEvaluator(params, rlwe.EvaluationKey{}).(*evaluator),
+		ptZero:          NewPlaintext(params),
+		ctZero:          NewCiphertext(params, 1),
+		gaussianSampler: ring.NewDiscreteGaussianSampler(prng, params.RingQ(), sigma, true),
+	}, nil
+}
+
+// FloodingSigma returns the standard deviation of the flooding noise of the Sanitizer.
+func (s *Sanitizer) FloodingSigma() float64 {
+	return s.sigma
+}
+
+// Sanitize re-randomizes ctIn and returns the result in ctOut, at the level of ctIn. ctIn must be of degree 1, i.e.
+// relinearized. It panics if the flooding noise is too large for the level of ctIn (see
+// Parameters.CheckFloodingSigma).
+func (s *Sanitizer) Sanitize(ctIn, ctOut *Ciphertext) {
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot Sanitize: input and output must be of degree 1")
+	}
+
+	level := ctIn.Level()
+
+	if ctOut.Level() < level {
+		panic("cannot Sanitize: output level is smaller than the input level")
+	}
+
+	if err := s.params.CheckFloodingSigma(s.sigma, level); err != nil {
+		panic(fmt.Sprintf("cannot Sanitize: %s", err))
+	}
+
+	s.encryptor.Encrypt(s.ptZero, s.ctZero)
+
+	zero := s.eval.getElemAtLevel(level, s.ctZero.Element)
+
+	setLevel(level, ctOut.Element)
+
+	ringQ := s.eval.ringQ
+	ringQ.AddLvl(level, ctIn.Value[0], zero.Value[0], ctOut.Value[0])
+	ringQ.AddLvl(level, ctIn.Value[1], zero.Value[1], ctOut.Value[1])
+
+	s.gaussianSampler.ReadAndAddLvl(level, ctOut.Value[0])
+}
+
+// SanitizeNew re-randomizes ctIn and returns the result in a new ciphertext (see Sanitize).
+func (s *Sanitizer) SanitizeNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(s.params, 1, ctIn.Level())
+	s.Sanitize(ctIn, ctOut)
+	return
+}
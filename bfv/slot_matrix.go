@@ -0,0 +1,162 @@
+package bfv
+
+import (
+	"fmt"
+)
+
+// SlotMatrix is a Rows x Cols matrix stored row by row in the first Rows*Cols slots of each of the two rows of the
+// 2 x N/2 matrix of the slots, i.e. the entry (i, j) of the matrices of the two rows are stored in the slots i*Cols+j
+// and N/2+i*Cols+j. The rotations of the rows and of the columns of a SlotMatrix are evaluated by a MatrixEvaluator.
+type SlotMatrix struct {
+	Rows int
+	Cols int
+}
+
+// Validate returns an error if the SlotMatrix does not fit in a row of the slots of the parameters.
+func (m SlotMatrix) Validate(params Parameters) error {
+	if m.Rows < 1 || m.Cols < 1 || m.Rows*m.Cols > params.N()>>1 {
+		return fmt.Errorf("invalid %dx%d SlotMatrix for %d slots per row", m.Rows, m.Cols, params.N()>>1)
+	}
+	return nil
+}
+
+// Slot returns the index of the slot storing the entry (i, j) of the matrix of the first row of the slots. The entry
+// of the matrix of the second row is stored at the index Slot(i, j) + N/2.
+func (m SlotMatrix) Slot(i, j int) int {
+	return i*m.Cols + j
+}
+
+// matrixRotation returns the two column rotations of the circuit rotating the matrix by k positions along a
+// dimension of size n whose consecutive elements are stride slots apart: the elements of the first n-k positions
+// are moved by the rotation by k*stride and the elements of the last k positions by the rotation by (k-n)*stride.
+// If n*stride is the number of slots of a row, a single rotation is needed and the second rotation is zero.
+func matrixRotation(params Parameters, k, n, stride int) (k0, k1 int) {
+	slots := params.N() >> 1
+	k = ((k % n) + n) % n
+	k0 = (k * stride) % slots
+	if k != 0 && n*stride != slots {
+		k1 = ((k-n)*stride%slots + slots) % slots
+	}
+	return
+}
+
+// RotationsForRows returns the column rotations needed by MatrixEvaluator.RotateMatrixRows to rotate the rows of the
+// matrix by k positions.
+func (m SlotMatrix) RotationsForRows(params Parameters, k int) (rotations []int) {
+	k0, k1 := matrixRotation(params, k, m.Rows, m.Cols)
+	return nonZeroRotations(k0, k1)
+}
+
+// RotationsForCols returns the column rotations needed by MatrixEvaluator.RotateMatrixCols to rotate the columns of
+// the matrix by k positions.
+func (m SlotMatrix) RotationsForCols(params Parameters, k int) (rotations []int) {
+	k0, k1 := matrixRotation(params, k, m.Cols, 1)
+	return nonZeroRotations(k0, k1)
+}
+
+func nonZeroRotations(ks ...int) (rotations []int) {
+	rotations = []int{}
+	for _, k := range ks {
+		if k != 0 {
+			rotations = append(rotations, k)
+		}
+	}
+	return
+}
+
+// MatrixEvaluator rotates the rows and the columns of the SlotMatrix stored in the slots of ciphertexts with an
+// underlying Evaluator. The rotations which do not map the matrix onto itself with a single rotation of the slots
+// are evaluated with two rotations whose results are masked by multiplications by plaintexts, which add the noise
+// of a multiplication by a plaintext. The masks are cached by the underlying Evaluator (see
+// Evaluator.MulConstVectorThenAdd).
+type MatrixEvaluator struct {
+	params Parameters
+	eval   Evaluator
+	matrix SlotMatrix
+}
+
+// NewMatrixEvaluator creates a new MatrixEvaluator for the SlotMatrix matrix operating with eval, which must hold the
+// rotation keys given by SlotMatrix.RotationsForRows and SlotMatrix.RotationsForCols for the rotations to evaluate.
+func NewMatrixEvaluator(params Parameters, eval Evaluator, matrix SlotMatrix) *MatrixEvaluator {
+	if err := matrix.Validate(params); err != nil {
+		panic(fmt.Errorf("cannot NewMatrixEvaluator: %w", err))
+	}
+	return &MatrixEvaluator{params: params, eval: eval, matrix: matrix}
+}
+
+// RotateMatrixRows rotates the rows of the matrix of ct0 by k positions upward, i.e. the entry (i, j) of the result is
+// the entry ((i+k) mod Rows, j) of ct0, and returns the result in ctOut. The slots outside the matrix are set to zero.
+func (eval *MatrixEvaluator) RotateMatrixRows(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
+	m := eval.matrix
+	eval.rotate(ct0, k, m.Rows, m.Cols, func(i, j int) int { return i }, ctOut)
+}
+
+// RotateMatrixRowsNew applies RotateMatrixRows and returns the result in a new Ciphertext.
+func (eval *MatrixEvaluator) RotateMatrixRowsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
+	eval.RotateMatrixRows(ct0, k, ctOut)
+	return
+}
+
+// RotateMatrixCols rotates the columns of the matrix of ct0 by k positions to the left, i.e. the entry (i, j) of the
+// result is the entry (i, (j+k) mod Cols) of ct0, and returns the result in ctOut. The slots outside the matrix are
+// set to zero.
+func (eval *MatrixEvaluator) RotateMatrixCols(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
+	m := eval.matrix
+	eval.rotate(ct0, k, m.Cols, 1, func(i, j int) int { return j }, ctOut)
+}
+
+// RotateMatrixColsNew applies RotateMatrixCols and returns the result in a new Ciphertext.
+func (eval *MatrixEvaluator) RotateMatrixColsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertextLvl(eval.params, 1, ct0.Level())
+	eval.RotateMatrixCols(ct0, k, ctOut)
+	return
+}
+
+// rotate rotates the matrix by k positions along the dimension of size n and stride stride, where position returns
+// the position of the entry (i, j) along this dimension.
+func (eval *MatrixEvaluator) rotate(ct0 *Ciphertext, k, n, stride int, position func(i, j int) int, ctOut *Ciphertext) {
+
+	k0, k1 := matrixRotation(eval.params, k, n, stride)
+
+	slots := eval.params.N() >> 1
+
+	// The matrix fills the slots, so that the rotation maps it onto itself
+	if k1 == 0 && eval.matrix.Rows*eval.matrix.Cols == slots {
+		eval.eval.RotateColumns(ct0, k0, ctOut)
+		return
+	}
+
+	// The masks of the entries moved by each of the two rotations
+	k = ((k % n) + n) % n
+	mask0, mask1 := make([]uint64, eval.params.N()), make([]uint64, eval.params.N())
+	for i := 0; i < eval.matrix.Rows; i++ {
+		for j := 0; j < eval.matrix.Cols; j++ {
+			mask := mask0
+			if position(i, j) >= n-k {
+				mask = mask1
+			}
+			mask[eval.matrix.Slot(i, j)] = 1
+			mask[eval.matrix.Slot(i, j)+slots] = 1
+		}
+	}
+
+	rotations := []struct {
+		k    int
+		mask []uint64
+	}{{k0, mask0}, {k1, mask1}}
+
+	// The identity only clears the slots outside the matrix
+	if k == 0 {
+		rotations = rotations[:1]
+	}
+
+	res := NewCiphertextLvl(eval.params, 1, ct0.Level())
+	for _, rot := range rotations {
+		if err := eval.eval.MulConstVectorThenAdd(eval.eval.RotateColumnsNew(ct0, rot.k), rot.mask, res); err != nil {
+			panic(err)
+		}
+	}
+
+	ctOut.Copy(res.El())
+}
@@ -0,0 +1,218 @@
+package bigbfv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Operand is the interface of the operands of the Evaluator: the *Plaintext and the *Ciphertext.
+type Operand interface {
+	instance(i int) bfv.Operand
+}
+
+// Plaintext is a plaintext of each BFV instance, which together encode a vector of big integers.
+type Plaintext struct {
+	Value []*bfv.Plaintext
+}
+
+// Ciphertext is a ciphertext of each BFV instance, which together encrypt a vector of big integers.
+type Ciphertext struct {
+	Value []*bfv.Ciphertext
+}
+
+// NewPlaintext allocates a new Plaintext.
+func NewPlaintext(params Parameters) *Plaintext {
+	pt := &Plaintext{Value: make([]*bfv.Plaintext, params.Instances())}
+	for i := range pt.Value {
+		pt.Value[i] = bfv.NewPlaintext(params.Instance(i))
+	}
+	return pt
+}
+
+// NewCiphertext allocates a new Ciphertext of the given degree.
+func NewCiphertext(params Parameters, degree int) *Ciphertext {
+	ct := &Ciphertext{Value: make([]*bfv.Ciphertext, params.Instances())}
+	for i := range ct.Value {
+		ct.Value[i] = bfv.NewCiphertext(params.Instance(i), degree)
+	}
+	return ct
+}
+
+// Degree returns the degree of the ciphertext.
+func (ct *Ciphertext) Degree() int {
+	return ct.Value[0].Degree()
+}
+
+func (pt *Plaintext) instance(i int) bfv.Operand {
+	return pt.Value[i]
+}
+
+func (ct *Ciphertext) instance(i int) bfv.Operand {
+	return ct.Value[i]
+}
+
+// NewKeyGenerator creates a new KeyGenerator, which generates the keys shared by all the BFV instances.
+func NewKeyGenerator(params Parameters) bfv.KeyGenerator {
+	return bfv.NewKeyGenerator(params.Instance(0))
+}
+
+// Encoder encodes vectors of big integers on the slots of the plaintexts, and decodes them with the Chinese
+// Remainder Theorem.
+type Encoder struct {
+	params   Parameters
+	encoders []bfv.Encoder
+	moduli   []*big.Int
+	buff     []uint64
+}
+
+// NewEncoder creates a new Encoder.
+func NewEncoder(params Parameters) *Encoder {
+	enc := &Encoder{
+		params:   params,
+		encoders: make([]bfv.Encoder, params.Instances()),
+		moduli:   make([]*big.Int, params.Instances()),
+		buff:     make([]uint64, params.N()),
+	}
+	for i := range enc.encoders {
+		enc.encoders[i] = bfv.NewEncoder(params.Instance(i))
+		enc.moduli[i] = new(big.Int).SetUint64(params.Instance(i).T())
+	}
+	return enc
+}
+
+// Encode encodes the values, which can be negative, on the slots of pt. The values are encoded modulo T, and the
+// slots beyond the values are set to zero.
+func (enc *Encoder) Encode(values []*big.Int, pt *Plaintext) {
+
+	if len(values) > enc.params.N() {
+		panic(fmt.Sprintf("cannot Encode: the number of values (%d) is larger than the number of slots (%d)", len(values), enc.params.N()))
+	}
+
+	tmp := new(big.Int)
+	for i, encoder := range enc.encoders {
+		for j := range enc.buff {
+			enc.buff[j] = 0
+		}
+		for j, v := range values {
+			enc.buff[j] = tmp.Mod(v, enc.moduli[i]).Uint64()
+		}
+		encoder.EncodeUint(enc.buff, pt.Value[i])
+	}
+}
+
+// EncodeNew encodes the values on a new Plaintext (see Encode).
+func (enc *Encoder) EncodeNew(values []*big.Int) (pt *Plaintext) {
+	pt = NewPlaintext(enc.params)
+	enc.Encode(values, pt)
+	return
+}
+
+// Decode decodes the slots of pt and returns their values in [0, T).
+func (enc *Encoder) Decode(pt *Plaintext) (values []*big.Int) {
+
+	params := enc.params
+
+	values = make([]*big.Int, params.N())
+	for j := range values {
+		values[j] = new(big.Int)
+	}
+
+	tmp := new(big.Int)
+	for i, encoder := range enc.encoders {
+		encoder.DecodeUint(pt.Value[i], enc.buff)
+		for j, v := range enc.buff {
+			values[j].Add(values[j], tmp.Mul(tmp.SetUint64(v), params.crt[i]))
+		}
+	}
+
+	for j := range values {
+		values[j].Mod(values[j], params.t)
+	}
+
+	return
+}
+
+// DecodeSigned decodes the slots of pt and returns their values in [-T/2, T/2).
+func (enc *Encoder) DecodeSigned(pt *Plaintext) (values []*big.Int) {
+
+	values = enc.Decode(pt)
+
+	half := new(big.Int).Rsh(enc.params.t, 1)
+	for _, v := range values {
+		if v.Cmp(half) >= 0 {
+			v.Sub(v, enc.params.t)
+		}
+	}
+
+	return
+}
+
+// Encryptor encrypts the plaintexts of all the BFV instances.
+type Encryptor struct {
+	encryptors []bfv.Encryptor
+	params     Parameters
+}
+
+// NewEncryptorFromPk creates a new Encryptor encrypting with the public key pk.
+func NewEncryptorFromPk(params Parameters, pk *rlwe.PublicKey) *Encryptor {
+	enc := &Encryptor{encryptors: make([]bfv.Encryptor, params.Instances()), params: params}
+	for i := range enc.encryptors {
+		enc.encryptors[i] = bfv.NewEncryptorFromPk(params.Instance(i), pk)
+	}
+	return enc
+}
+
+// NewEncryptorFromSk creates a new Encryptor encrypting with the secret key sk.
+func NewEncryptorFromSk(params Parameters, sk *rlwe.SecretKey) *Encryptor {
+	enc := &Encryptor{encryptors: make([]bfv.Encryptor, params.Instances()), params: params}
+	for i := range enc.encryptors {
+		enc.encryptors[i] = bfv.NewEncryptorFromSk(params.Instance(i), sk)
+	}
+	return enc
+}
+
+// Encrypt encrypts pt on ct.
+func (enc *Encryptor) Encrypt(pt *Plaintext, ct *Ciphertext) {
+	for i, encryptor := range enc.encryptors {
+		encryptor.Encrypt(pt.Value[i], ct.Value[i])
+	}
+}
+
+// EncryptNew encrypts pt on a new Ciphertext.
+func (enc *Encryptor) EncryptNew(pt *Plaintext) (ct *Ciphertext) {
+	ct = NewCiphertext(enc.params, 1)
+	enc.Encrypt(pt, ct)
+	return
+}
+
+// Decryptor decrypts the ciphertexts of all the BFV instances.
+type Decryptor struct {
+	decryptors []bfv.Decryptor
+	params     Parameters
+}
+
+// NewDecryptor creates a new Decryptor decrypting with the secret key sk.
+func NewDecryptor(params Parameters, sk *rlwe.SecretKey) *Decryptor {
+	dec := &Decryptor{decryptors: make([]bfv.Decryptor, params.Instances()), params: params}
+	for i := range dec.decryptors {
+		dec.decryptors[i] = bfv.NewDecryptor(params.Instance(i), sk)
+	}
+	return dec
+}
+
+// Decrypt decrypts ct on pt.
+func (dec *Decryptor) Decrypt(ct *Ciphertext, pt *Plaintext) {
+	for i, decryptor := range dec.decryptors {
+		decryptor.Decrypt(ct.Value[i], pt.Value[i])
+	}
+}
+
+// DecryptNew decrypts ct on a new Plaintext.
+func (dec *Decryptor) DecryptNew(ct *Ciphertext) (pt *Plaintext) {
+	pt = NewPlaintext(dec.params)
+	dec.Decrypt(ct, pt)
+	return
+}
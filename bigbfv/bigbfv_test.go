@@ -0,0 +1,284 @@
+package bigbfv
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBigBFV(t *testing.T) {
+
+	lit := bfv.PN13QP218
+
+	moduli := GenPlaintextModuli(lit.LogN, 25, 6)
+
+	params, err := NewParametersFromLiteral(lit, moduli)
+	require.NoError(t, err)
+	require.Equal(t, 6, params.Instances())
+	require.GreaterOrEqual(t, params.LogT(), 145)
+
+	kgen := NewKeyGenerator(params)
+	sk, pk := kgen.GenKeyPair()
+	rlk := kgen.GenRelinearizationKey(sk, 1)
+	rtks := kgen.GenRotationKeysForRotations([]int{1}, false, sk)
+
+	encoder := NewEncoder(params)
+	encryptor := NewEncryptorFromPk(params, pk)
+	decryptor := NewDecryptor(params, sk)
+	eval := NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks})
+
+	// randInt returns a uniform integer in [-2^(bits-1), 2^(bits-1))
+	randInt := func(bits int) *big.Int {
+		bound := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		v := ring.RandInt(bound)
+		return v.Sub(v, new(big.Int).Rsh(bound, 1))
+	}
+
+	newValues := func(bits int) (values []*big.Int) {
+		values = make([]*big.Int, params.N())
+		for i := range values {
+			values[i] = randInt(bits)
+		}
+		return
+	}
+
+	t.Run("Parameters", func(t *testing.T) {
+		_, err := NewParametersFromLiteral(lit, nil)
+		require.Error(t, err)
+		_, err = NewParametersFromLiteral(lit, []uint64{moduli[0], moduli[0]})
+		require.Error(t, err)
+		// 65539 is prime but not congruent to 1 mod 2N
+		_, err = NewParametersFromLiteral(lit, []uint64{moduli[0], 65539})
+		require.Error(t, err)
+
+		other, err := NewParametersFromLiteral(lit, moduli[:5])
+		require.NoError(t, err)
+		require.False(t, params.Equals(other))
+		require.True(t, params.Equals(params))
+	})
+
+	t.Run("Encoder", func(t *testing.T) {
+		values := newValues(params.LogT() - 1)
+		pt := encoder.EncodeNew(values)
+		require.Equal(t, values, encoder.DecodeSigned(pt))
+
+		unsigned := encoder.Decode(pt)
+		for i, v := range values {
+			require.Equal(t, new(big.Int).Mod(v, params.T()), unsigned[i])
+		}
+	})
+
+	t.Run("Evaluator/128Bits", func(t *testing.T) {
+
+		a, b, c := newValues(64), newValues(64), newValues(120)
+		scalar := randInt(20)
+
+		ctA := encryptor.EncryptNew(encoder.EncodeNew(a))
+		ctB := encryptor.EncryptNew(encoder.EncodeNew(b))
+		ptC := encoder.EncodeNew(c)
+
+		// (a*b - c) * scalar + a, on integers of up to 129 + 20 bits
+		res := eval.RelinearizeNew(eval.MulNew(ctA, ctB))
+		require.Equal(t, 1, res.Degree())
+		eval.Sub(res, ptC, res)
+		eval.MulScalar(res, scalar, res)
+		eval.Add(res, ctA, res)
+
+		want := make([]*big.Int, params.N())
+		for i := range want {
+			want[i] = new(big.Int).Mul(a[i], b[i])
+			want[i].Sub(want[i], c[i])
+			want[i].Mul(want[i], scalar)
+			want[i].Add(want[i], a[i])
+		}
+
+		require.Equal(t, want, encoder.DecodeSigned(decryptor.DecryptNew(res)))
+
+		// Negation and rotation
+		res = eval.RotateColumnsNew(eval.NegNew(ctA), 1)
+		got := encoder.DecodeSigned(decryptor.DecryptNew(res))
+		half := params.N() >> 1
+		for i := 0; i < half; i++ {
+			require.Zero(t, new(big.Int).Neg(a[(i+1)%half]).Cmp(got[i]))
+			require.Zero(t, new(big.Int).Neg(a[half+(i+1)%half]).Cmp(got[half+i]))
+		}
+	})
+	t.Run("RNSPlaintext", func(t *testing.T) {
+
+		if testing.Short() {
+			t.Skip("skipped in -short mode")
+		}
+
+		// A single ciphertext requires Q > T^2 times the noise budget, hence a larger Q for a T of 75 bits
+		rnsLit := bfv.PN14QP438
+		rnsParams, err := NewParametersFromLiteral(rnsLit, GenPlaintextModuli(rnsLit.LogN, 25, 3))
+		require.NoError(t, err)
+		require.Greater(t, rnsParams.LogT(), 64)
+
+		rnsKgen := NewKeyGenerator(rnsParams)
+		rnsSk, rnsPk := rnsKgen.GenKeyPair()
+		rnsRlk := rnsKgen.GenRelinearizationKey(rnsSk, 1)
+		rnsRtks := rnsKgen.GenRotationKeysForRotations([]int{1}, false, rnsSk)
+
+		rnsEncoder := NewRNSEncoder(rnsParams)
+		rnsEncryptor := bfv.NewEncryptorFromPk(rnsParams.Instance(0), rnsPk)
+		rnsDecryptor := bfv.NewDecryptor(rnsParams.Instance(0), rnsSk)
+		rnsEval := NewRNSEvaluator(rnsParams, rlwe.EvaluationKey{Rlk: rnsRlk, Rtks: rnsRtks})
+
+		T := rnsParams.T()
+		newResidues := func() (values []*big.Int) {
+			values = make([]*big.Int, rnsParams.N())
+			for i := range values {
+				values[i] = ring.RandInt(T)
+			}
+			return
+		}
+
+		a, b, c := newResidues(), newResidues(), newResidues()
+
+		pt := rnsEncoder.EncodeNew(a)
+		require.Equal(t, a, rnsEncoder.Decode(pt))
+
+		ctA := rnsEncryptor.EncryptNew(pt)
+		ctB := rnsEncryptor.EncryptNew(rnsEncoder.EncodeNew(b))
+		require.Equal(t, a, rnsEncoder.Decode(rnsDecryptor.DecryptNew(ctA)))
+
+		// a*b - c + a
+		res := rnsEval.RelinearizeNew(rnsEval.MulNew(ctA, ctB))
+		require.Equal(t, 1, res.Degree())
+		rnsEval.Sub(res, rnsEncoder.EncodeNew(c), res)
+		rnsEval.Add(res, ctA, res)
+
+		want := make([]*big.Int, rnsParams.N())
+		for i := range want {
+			want[i] = new(big.Int).Mul(a[i], b[i])
+			want[i].Sub(want[i], c[i])
+			want[i].Add(want[i], a[i])
+			want[i].Mod(want[i], T)
+		}
+
+		require.Equal(t, want, rnsEncoder.Decode(rnsDecryptor.DecryptNew(res)))
+
+		// Negation and rotation
+		res = rnsEval.RotateColumnsNew(rnsEval.NegNew(ctA), 1)
+		got := rnsEncoder.Decode(rnsDecryptor.DecryptNew(res))
+		half := rnsParams.N() >> 1
+		neg := func(v *big.Int) *big.Int {
+			return new(big.Int).Mod(new(big.Int).Neg(v), T)
+		}
+		for i := 0; i < half; i++ {
+			require.Zero(t, neg(a[(i+1)%half]).Cmp(got[i]))
+			require.Zero(t, neg(a[half+(i+1)%half]).Cmp(got[half+i]))
+		}
+	})
+
+	t.Run("RNSPlaintext/Unsupported", func(t *testing.T) {
+
+		// The operations depending on the plaintext modulus of the instance are rejected with T
+		bigEval := NewRNSEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks}).(bfv.Evaluator)
+		instance := params.Instance(0)
+		ct := bfv.NewCiphertext(instance, 1)
+		ptRt := bfv.NewPlaintextRingT(instance)
+		ptMul := bfv.NewPlaintextMul(instance)
+
+		for _, tc := range []struct {
+			name string
+			f    func()
+		}{
+			{"MulScalar", func() { bigEval.MulScalar(ct, 3, ct) }},
+			{"MulScalarBigint", func() { bigEval.MulScalarBigint(ct, big.NewInt(3), ct) }},
+			{"DivByConst", func() { bigEval.DivByConst(ct, 3, ct) }},
+			{"Mul/PlaintextRingT", func() { bigEval.Mul(ct, ptRt, ct) }},
+			{"Mul/PlaintextMul", func() { bigEval.Mul(ct, ptMul, ct) }},
+			{"Add/PlaintextRingT", func() { bigEval.Add(ct, ptRt, ct) }},
+			{"AddConstVector", func() { bigEval.AddConstVector(ct, []uint64{1}, ct) }},
+			{"MulConstVectorThenAdd", func() { bigEval.MulConstVectorThenAdd(ct, []uint64{1}, ct) }},
+			{"Equal", func() { bigEval.Equal(ct, ct, ct) }},
+			{"LessThan", func() { bigEval.LessThan(ct, ct, 2, ct) }},
+			{"EvaluatePoly", func() { bigEval.EvaluatePoly(ct, bfv.NewPoly([]uint64{1, 1}), ct) }},
+		} {
+			require.Panics(t, tc.f, tc.name)
+		}
+	})
+
+	t.Run("CoeffPlaintext", func(t *testing.T) {
+
+		if testing.Short() {
+			t.Skip("skipped in -short mode")
+		}
+
+		// The 127-bit Mersenne prime, i.e. a prime field that cannot be batched
+		T := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+		require.True(t, T.ProbablyPrime(32))
+
+		coeffParams, err := bfv.NewParametersFromLiteral(bfv.PN14QP438)
+		require.NoError(t, err)
+
+		coeffKgen := bfv.NewKeyGenerator(coeffParams)
+		coeffSk, coeffPk := coeffKgen.GenKeyPair()
+		coeffRlk := coeffKgen.GenRelinearizationKey(coeffSk, 1)
+
+		coeffEncoder := NewCoeffEncoder(coeffParams, T)
+		coeffEncryptor := bfv.NewEncryptorFromPk(coeffParams, coeffPk)
+		coeffDecryptor := bfv.NewDecryptor(coeffParams, coeffSk)
+		coeffEval := NewCoeffEvaluator(coeffParams, T, rlwe.EvaluationKey{Rlk: coeffRlk})
+
+		// Short vectors, so that the negacyclic convolution is computed naively
+		n := 16
+		newResidues := func() (values []*big.Int) {
+			values = make([]*big.Int, n)
+			for i := range values {
+				values[i] = ring.RandInt(T)
+			}
+			return
+		}
+
+		a, b, c := newResidues(), newResidues(), newResidues()
+
+		pt := coeffEncoder.EncodeNew(a)
+		require.Equal(t, a, coeffEncoder.Decode(pt)[:n])
+
+		ctA := coeffEncryptor.EncryptNew(pt)
+		ctB := coeffEncryptor.EncryptNew(coeffEncoder.EncodeNew(b))
+
+		// a*b - c + a
+		res := coeffEval.RelinearizeNew(coeffEval.MulNew(ctA, ctB))
+		require.Equal(t, 1, res.Degree())
+		coeffEval.Sub(res, coeffEncoder.EncodeNew(c), res)
+		coeffEval.Add(res, ctA, res)
+
+		want := make([]*big.Int, coeffParams.N())
+		for i := range want {
+			want[i] = new(big.Int)
+		}
+		for i := range a {
+			for j := range b {
+				want[i+j].Add(want[i+j], new(big.Int).Mul(a[i], b[j]))
+			}
+		}
+		for i := range c {
+			want[i].Sub(want[i], c[i])
+			want[i].Add(want[i], a[i])
+		}
+		for i := range want {
+			want[i].Mod(want[i], T)
+		}
+
+		require.Equal(t, want, coeffEncoder.Decode(coeffDecryptor.DecryptNew(res)))
+
+		// The constant terms are multiplied in Z_T
+		ctX := coeffEncryptor.EncryptNew(coeffEncoder.EncodeNew(a[:1]))
+		ctY := coeffEncryptor.EncryptNew(coeffEncoder.EncodeNew(b[:1]))
+		got := coeffEncoder.DecodeSigned(coeffDecryptor.DecryptNew(coeffEval.NegNew(coeffEval.MulNew(ctX, ctY))))
+		prod := new(big.Int).Mul(a[0], b[0])
+		prod.Neg(prod).Mod(prod, T)
+		if prod.Cmp(new(big.Int).Rsh(T, 1)) >= 0 {
+			prod.Sub(prod, T)
+		}
+		require.Zero(t, prod.Cmp(got[0]))
+	})
+}
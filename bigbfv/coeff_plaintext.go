@@ -0,0 +1,124 @@
+package bigbfv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// The coefficient plaintext supports any plaintext modulus T larger than a machine word, e.g. the 128-bit prime of a
+// prime field, at the cost of the batching: a vector of N big integers modulo T is encoded on the coefficients of a
+// plaintext polynomial of Z_T[X]/(X^N+1) scaled up by Q/T, and is encrypted in a single bfv.Ciphertext. The additions
+// are coefficient-wise, whereas the multiplications are negacyclic convolutions of the vectors: a value encoded on
+// the first coefficient, i.e. the constant term, is thus multiplied as an element of Z_T. As for the RNS plaintext,
+// the modulus Q must be larger than T^2 by the noise budget of the circuit.
+
+// CoeffEvaluator is the interface of the homomorphic operations supported by the ciphertexts of the coefficient
+// plaintext. It is implemented by the bfv.Evaluator returned by NewCoeffEvaluator. The plaintext operands must be
+// of type *bfv.Plaintext, encoded by the CoeffEncoder.
+type CoeffEvaluator interface {
+	Add(op0, op1 bfv.Operand, ctOut *bfv.Ciphertext)
+	AddNew(op0, op1 bfv.Operand) (ctOut *bfv.Ciphertext)
+	Sub(op0, op1 bfv.Operand, ctOut *bfv.Ciphertext)
+	SubNew(op0, op1 bfv.Operand) (ctOut *bfv.Ciphertext)
+	Neg(op bfv.Operand, ctOut *bfv.Ciphertext)
+	NegNew(op bfv.Operand) (ctOut *bfv.Ciphertext)
+	Mul(op0 *bfv.Ciphertext, op1 bfv.Operand, ctOut *bfv.Ciphertext)
+	MulNew(op0 *bfv.Ciphertext, op1 bfv.Operand) (ctOut *bfv.Ciphertext)
+	Relinearize(ct0 *bfv.Ciphertext, ctOut *bfv.Ciphertext)
+	RelinearizeNew(ct0 *bfv.Ciphertext) (ctOut *bfv.Ciphertext)
+}
+
+// NewCoeffEvaluator creates a new CoeffEvaluator for the BFV parameters params, whose plaintext modulus is ignored,
+// the plaintext modulus t and the evaluation key evk (see bfv.NewEvaluatorBigT).
+func NewCoeffEvaluator(params bfv.Parameters, t *big.Int, evk rlwe.EvaluationKey) CoeffEvaluator {
+	return bfv.NewEvaluatorBigT(params, t, evk)
+}
+
+// CoeffEncoder encodes vectors of big integers modulo T on the coefficients of a single bfv.Plaintext, scaled up by
+// Q/T. A CoeffEncoder is not safe for concurrent use.
+type CoeffEncoder struct {
+	params bfv.Parameters
+	t      *big.Int
+	ringQ  *ring.Ring
+	coeffs []*big.Int
+}
+
+// NewCoeffEncoder creates a new CoeffEncoder for the BFV parameters params, whose plaintext modulus is ignored, and
+// the plaintext modulus t, which must be larger than one.
+func NewCoeffEncoder(params bfv.Parameters, t *big.Int) *CoeffEncoder {
+
+	if t.Cmp(big.NewInt(1)) <= 0 {
+		panic("cannot NewCoeffEncoder: the plaintext modulus must be larger than one")
+	}
+
+	enc := &CoeffEncoder{
+		params: params,
+		t:      new(big.Int).Set(t),
+		ringQ:  params.RingQ(),
+		coeffs: make([]*big.Int, params.N()),
+	}
+
+	for j := range enc.coeffs {
+		enc.coeffs[j] = new(big.Int)
+	}
+
+	return enc
+}
+
+// Encode encodes the values, which can be negative, on the coefficients of pt at its level. The values are encoded
+// modulo T, and the coefficients beyond the values are set to zero.
+func (enc *CoeffEncoder) Encode(values []*big.Int, pt *bfv.Plaintext) {
+
+	if len(values) > enc.params.N() {
+		panic(fmt.Sprintf("cannot Encode: the number of values (%d) is larger than the number of coefficients (%d)", len(values), enc.params.N()))
+	}
+
+	for j, c := range enc.coeffs {
+		if j < len(values) {
+			c.Mod(values[j], enc.t)
+		} else {
+			c.SetUint64(0)
+		}
+	}
+
+	scaleUpBigint(enc.ringQ, enc.t, enc.coeffs, pt)
+}
+
+// EncodeNew encodes the values on a new bfv.Plaintext (see Encode).
+func (enc *CoeffEncoder) EncodeNew(values []*big.Int) (pt *bfv.Plaintext) {
+	pt = bfv.NewPlaintext(enc.params)
+	enc.Encode(values, pt)
+	return
+}
+
+// Decode decodes the coefficients of pt, e.g. the decryption of a ciphertext, and returns their values in [0, T).
+func (enc *CoeffEncoder) Decode(pt *bfv.Plaintext) (values []*big.Int) {
+
+	scaleDownBigint(enc.ringQ, enc.t, pt, enc.coeffs)
+
+	values = make([]*big.Int, len(enc.coeffs))
+	for j, c := range enc.coeffs {
+		values[j] = new(big.Int).Set(c)
+	}
+
+	return
+}
+
+// DecodeSigned decodes the coefficients of pt and returns their values in [-T/2, T/2).
+func (enc *CoeffEncoder) DecodeSigned(pt *bfv.Plaintext) (values []*big.Int) {
+
+	values = enc.Decode(pt)
+
+	half := new(big.Int).Rsh(enc.t, 1)
+	for _, v := range values {
+		if v.Cmp(half) >= 0 {
+			v.Sub(v, enc.t)
+		}
+	}
+
+	return
+}
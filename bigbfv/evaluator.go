@@ -0,0 +1,180 @@
+package bigbfv
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Evaluator evaluates the homomorphic operations on the ciphertexts of all the BFV instances. The instances are
+// evaluated concurrently, each by its own bfv.Evaluator.
+// An Evaluator is not safe for concurrent use.
+type Evaluator struct {
+	params Parameters
+	evals  []bfv.Evaluator
+}
+
+// NewEvaluator creates a new Evaluator with the evaluation key evk, which is shared by all the BFV instances.
+func NewEvaluator(params Parameters, evk rlwe.EvaluationKey) *Evaluator {
+	eval := &Evaluator{params: params, evals: make([]bfv.Evaluator, params.Instances())}
+	for i := range eval.evals {
+		eval.evals[i] = bfv.NewEvaluator(params.Instance(i), evk)
+	}
+	return eval
+}
+
+// ShallowCopy creates a shallow copy of this Evaluator in which the read-only data-structures are shared with the
+// receiver and the temporary buffers are reallocated. The receiver and the returned Evaluators can be used
+// concurrently.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+	evals := make([]bfv.Evaluator, len(eval.evals))
+	for i := range evals {
+		evals[i] = eval.evals[i].ShallowCopy()
+	}
+	return &Evaluator{params: eval.params, evals: evals}
+}
+
+// forEach calls f for each instance, concurrently.
+func (eval *Evaluator) forEach(f func(i int, eval bfv.Evaluator)) {
+	var wg sync.WaitGroup
+	wg.Add(len(eval.evals))
+	for i := range eval.evals {
+		go func(i int) {
+			f(i, eval.evals[i])
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Add adds op0 to op1 and returns the result in ctOut.
+func (eval *Evaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ev.Add(op0.instance(i), op1.instance(i), ctOut.Value[i])
+	})
+}
+
+// AddNew adds op0 to op1 and returns the result in a new Ciphertext.
+func (eval *Evaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, maxDegree(op0, op1))
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut.
+func (eval *Evaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ev.Sub(op0.instance(i), op1.instance(i), ctOut.Value[i])
+	})
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a new Ciphertext.
+func (eval *Evaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, maxDegree(op0, op1))
+	eval.Sub(op0, op1, ctOut)
+	return
+}
+
+// Neg negates op and returns the result in ctOut.
+func (eval *Evaluator) Neg(op Operand, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ev.Neg(op.instance(i), ctOut.Value[i])
+	})
+}
+
+// NegNew negates op and returns the result in a new Ciphertext.
+func (eval *Evaluator) NegNew(op Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, maxDegree(op, op))
+	eval.Neg(op, ctOut)
+	return
+}
+
+// MulScalar multiplies op by the scalar, which can be negative, and returns the result in ctOut.
+func (eval *Evaluator) MulScalar(op Operand, scalar *big.Int, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ti := new(big.Int).SetUint64(eval.params.Instance(i).T())
+		ev.MulScalar(op.instance(i), new(big.Int).Mod(scalar, ti).Uint64(), ctOut.Value[i])
+	})
+}
+
+// MulScalarNew multiplies op by the scalar and returns the result in a new Ciphertext.
+func (eval *Evaluator) MulScalarNew(op Operand, scalar *big.Int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, maxDegree(op, op))
+	eval.MulScalar(op, scalar, ctOut)
+	return
+}
+
+// Mul multiplies ct0 by op1 and returns the result in ctOut, whose degree is the sum of the degrees of the operands
+// (see Relinearize).
+func (eval *Evaluator) Mul(ct0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ev.Mul(ct0.Value[i], op1.instance(i), ctOut.Value[i])
+	})
+}
+
+// MulNew multiplies ct0 by op1 and returns the result in a new Ciphertext.
+func (eval *Evaluator) MulNew(ct0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree()+degree(op1))
+	eval.Mul(ct0, op1, ctOut)
+	return
+}
+
+// Relinearize relinearizes ct0 to a ciphertext of degree 1 and returns the result in ctOut. It requires a
+// relinearization key.
+func (eval *Evaluator) Relinearize(ct0, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ev.Relinearize(ct0.Value[i], ctOut.Value[i])
+	})
+}
+
+// RelinearizeNew relinearizes ct0 and returns the result in a new Ciphertext.
+func (eval *Evaluator) RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.Relinearize(ct0, ctOut)
+	return
+}
+
+// RotateColumns rotates the columns of ct0 by k positions to the left and returns the result in ctOut (see
+// bfv.Evaluator.RotateColumns).
+func (eval *Evaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ev.RotateColumns(ct0.Value[i], k, ctOut.Value[i])
+	})
+}
+
+// RotateColumnsNew rotates the columns of ct0 by k positions to the left and returns the result in a new Ciphertext.
+func (eval *Evaluator) RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.RotateColumns(ct0, k, ctOut)
+	return
+}
+
+// RotateRows swaps the rows of ct0 and returns the result in ctOut (see bfv.Evaluator.RotateRows).
+func (eval *Evaluator) RotateRows(ct0, ctOut *Ciphertext) {
+	eval.forEach(func(i int, ev bfv.Evaluator) {
+		ev.RotateRows(ct0.Value[i], ctOut.Value[i])
+	})
+}
+
+// RotateRowsNew swaps the rows of ct0 and returns the result in a new Ciphertext.
+func (eval *Evaluator) RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.RotateRows(ct0, ctOut)
+	return
+}
+
+func degree(op Operand) int {
+	if ct, isCt := op.(*Ciphertext); isCt {
+		return ct.Degree()
+	}
+	return 0
+}
+
+func maxDegree(op0, op1 Operand) int {
+	if degree(op0) > degree(op1) {
+		return degree(op0)
+	}
+	return degree(op1)
+}
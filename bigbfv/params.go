@@ -0,0 +1,126 @@
+// Package bigbfv implements exact big-integer arithmetic on top of the BFV scheme by splitting the plaintext space
+// with the Chinese Remainder Theorem: a computation is evaluated in parallel by several BFV instances whose plaintext
+// moduli t_1, ..., t_k are distinct primes, and its results are reconstructed modulo T = t_1 * ... * t_k at
+// decryption. With enough plaintext moduli (see GenPlaintextModuli), the results of a circuit on 128-bit integers are
+// computed exactly, as long as all its intermediate values are smaller than T (or than T/2 in absolute value for
+// signed integers).
+//
+// The BFV instances share the ring degree N and the moduli Q and P, and thus their secret, public and evaluation
+// keys: the keys are generated once with the key generator of any instance (see NewKeyGenerator). Each instance
+// consumes its own noise budget, which is the one of a BFV instance with the largest of the plaintext moduli.
+package bigbfv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Parameters represents the parameters of a set of BFV instances that differ only by their plaintext modulus.
+type Parameters struct {
+	instances []bfv.Parameters
+	t         *big.Int
+	crt       []*big.Int // crt[i] = (T/t_i) * ((T/t_i)^-1 mod t_i) mod T
+}
+
+// GenPlaintextModuli returns k distinct NTT-friendly primes of logT bits for the ring degree 2^logN, which allow the
+// batching of the plaintexts (see bfv.Parameters.AllowsBatching).
+func GenPlaintextModuli(logN, logT, k int) []uint64 {
+	return ring.GenerateNTTPrimes(logT, 2<<logN, k)
+}
+
+// NewParameters creates the Parameters of the BFV instances with the RLWE parameters rlweParams and the plaintext
+// moduli plaintextModuli. Returns an error if the plaintext moduli are not distinct primes allowing the batching, or
+// if they are invalid BFV plaintext moduli for rlweParams.
+func NewParameters(rlweParams rlwe.Parameters, plaintextModuli []uint64) (p Parameters, err error) {
+
+	if len(plaintextModuli) == 0 {
+		return Parameters{}, fmt.Errorf("cannot NewParameters: at least one plaintext modulus is required")
+	}
+
+	p.instances = make([]bfv.Parameters, len(plaintextModuli))
+	p.t = big.NewInt(1)
+
+	for i, ti := range plaintextModuli {
+
+		for _, tj := range plaintextModuli[:i] {
+			if ti == tj {
+				return Parameters{}, fmt.Errorf("cannot NewParameters: duplicate plaintext modulus t=%d", ti)
+			}
+		}
+
+		if p.instances[i], err = bfv.NewParameters(rlweParams, ti); err != nil {
+			return Parameters{}, fmt.Errorf("cannot NewParameters: %w", err)
+		}
+
+		if !p.instances[i].AllowsBatching() {
+			return Parameters{}, fmt.Errorf("cannot NewParameters: t=%d is not a prime congruent to 1 mod 2N", ti)
+		}
+
+		p.t.Mul(p.t, new(big.Int).SetUint64(ti))
+	}
+
+	p.crt = make([]*big.Int, len(plaintextModuli))
+	for i, ti := range plaintextModuli {
+		bigTi := new(big.Int).SetUint64(ti)
+		quo := new(big.Int).Quo(p.t, bigTi)
+		p.crt[i] = new(big.Int).ModInverse(new(big.Int).Mod(quo, bigTi), bigTi)
+		p.crt[i].Mul(p.crt[i], quo)
+		p.crt[i].Mod(p.crt[i], p.t)
+	}
+
+	return p, nil
+}
+
+// NewParametersFromLiteral creates the Parameters of the BFV instances with the ring degree and moduli of the
+// literal, whose plaintext modulus is ignored, and the plaintext moduli plaintextModuli (see NewParameters).
+func NewParametersFromLiteral(pl bfv.ParametersLiteral, plaintextModuli []uint64) (p Parameters, err error) {
+
+	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, Sigma: pl.Sigma})
+	if err != nil {
+		return Parameters{}, err
+	}
+
+	return NewParameters(rlweParams, plaintextModuli)
+}
+
+// Instances returns the number of BFV instances.
+func (p Parameters) Instances() int {
+	return len(p.instances)
+}
+
+// Instance returns the parameters of the i-th BFV instance.
+func (p Parameters) Instance(i int) bfv.Parameters {
+	return p.instances[i]
+}
+
+// T returns a new big.Int storing the plaintext modulus T, i.e. the product of the plaintext moduli of the instances.
+func (p Parameters) T() *big.Int {
+	return new(big.Int).Set(p.t)
+}
+
+// LogT returns the size in bits of the plaintext modulus T.
+func (p Parameters) LogT() int {
+	return p.t.BitLen()
+}
+
+// N returns the ring degree, which is also the number of slots of the plaintexts.
+func (p Parameters) N() int {
+	return p.instances[0].N()
+}
+
+// Equals compares two sets of parameters for equality.
+func (p Parameters) Equals(other Parameters) bool {
+	if len(p.instances) != len(other.instances) {
+		return false
+	}
+	for i := range p.instances {
+		if !p.instances[i].Equals(other.instances[i]) {
+			return false
+		}
+	}
+	return true
+}
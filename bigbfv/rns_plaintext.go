@@ -0,0 +1,230 @@
+package bigbfv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// The RNS plaintext is the single-ciphertext alternative to the BFV instances: the plaintext modulus T is the
+// product of the plaintext moduli t_1, ..., t_k of the Parameters, whose residues are combined in RNS by the
+// RNSEncoder, and a vector of N big integers modulo T is encrypted in a single bfv.Ciphertext with the scaling factor
+// Q/T. The ciphertexts are encrypted and decrypted by the bfv.Encryptor and bfv.Decryptor of any instance, and the
+// homomorphic operations are evaluated by the RNSEvaluator, i.e. by one BFV evaluation instead of k. In exchange, the
+// modulus Q must be larger than T^2 by the noise budget of the circuit, whereas each BFV instance only requires a
+// modulus larger than t_i^2.
+//
+// T must be the product of distinct primes allowing the batching, since the slots are batched modulo each t_i. A
+// plaintext modulus that cannot be split this way, e.g. the 128-bit prime of a prime field, is supported without
+// batching by the coefficient encoding of the CoeffEncoder.
+
+// RNSEvaluator is the interface of the homomorphic operations supported by the ciphertexts of the RNS plaintext. It
+// is implemented by the bfv.Evaluator returned by NewRNSEvaluator. The plaintext operands must be of type
+// *bfv.Plaintext, encoded by the RNSEncoder.
+type RNSEvaluator interface {
+	Add(op0, op1 bfv.Operand, ctOut *bfv.Ciphertext)
+	AddNew(op0, op1 bfv.Operand) (ctOut *bfv.Ciphertext)
+	Sub(op0, op1 bfv.Operand, ctOut *bfv.Ciphertext)
+	SubNew(op0, op1 bfv.Operand) (ctOut *bfv.Ciphertext)
+	Neg(op bfv.Operand, ctOut *bfv.Ciphertext)
+	NegNew(op bfv.Operand) (ctOut *bfv.Ciphertext)
+	Mul(op0 *bfv.Ciphertext, op1 bfv.Operand, ctOut *bfv.Ciphertext)
+	MulNew(op0 *bfv.Ciphertext, op1 bfv.Operand) (ctOut *bfv.Ciphertext)
+	Relinearize(ct0 *bfv.Ciphertext, ctOut *bfv.Ciphertext)
+	RelinearizeNew(ct0 *bfv.Ciphertext) (ctOut *bfv.Ciphertext)
+	RotateColumns(ct0 *bfv.Ciphertext, k int, ctOut *bfv.Ciphertext)
+	RotateColumnsNew(ct0 *bfv.Ciphertext, k int) (ctOut *bfv.Ciphertext)
+	RotateRows(ct0 *bfv.Ciphertext, ctOut *bfv.Ciphertext)
+	RotateRowsNew(ct0 *bfv.Ciphertext) (ctOut *bfv.Ciphertext)
+}
+
+// NewRNSEvaluator creates a new RNSEvaluator with the evaluation key evk, whose multiplications are evaluated with
+// the plaintext modulus T (see bfv.NewEvaluatorBigT).
+func NewRNSEvaluator(params Parameters, evk rlwe.EvaluationKey) RNSEvaluator {
+	return bfv.NewEvaluatorBigT(params.Instance(0), params.t, evk)
+}
+
+// RNSEncoder encodes vectors of big integers modulo T on the slots of a single bfv.Plaintext: the values are
+// batched modulo each t_i by the encoder of the i-th instance, and the residues of the coefficients are combined
+// with the Chinese Remainder Theorem and scaled up by Q/T.
+// An RNSEncoder is not safe for concurrent use.
+type RNSEncoder struct {
+	params   Parameters
+	ringQ    *ring.Ring
+	encoders []bfv.Encoder
+	moduli   []*big.Int
+	ptRingT  []*bfv.PlaintextRingT
+	buff     []uint64
+	coeffs   []*big.Int
+}
+
+// NewRNSEncoder creates a new RNSEncoder.
+func NewRNSEncoder(params Parameters) *RNSEncoder {
+
+	enc := &RNSEncoder{
+		params:   params,
+		ringQ:    params.Instance(0).RingQ(),
+		encoders: make([]bfv.Encoder, params.Instances()),
+		moduli:   make([]*big.Int, params.Instances()),
+		ptRingT:  make([]*bfv.PlaintextRingT, params.Instances()),
+		buff:     make([]uint64, params.N()),
+		coeffs:   make([]*big.Int, params.N()),
+	}
+
+	for i := range enc.encoders {
+		enc.encoders[i] = bfv.NewEncoder(params.Instance(i))
+		enc.moduli[i] = new(big.Int).SetUint64(params.Instance(i).T())
+		enc.ptRingT[i] = bfv.NewPlaintextRingT(params.Instance(i))
+	}
+
+	for j := range enc.coeffs {
+		enc.coeffs[j] = new(big.Int)
+	}
+
+	return enc
+}
+
+// Encode encodes the values, which can be negative, on the slots of pt at its level. The values are encoded modulo
+// T, and the slots beyond the values are set to zero.
+func (enc *RNSEncoder) Encode(values []*big.Int, pt *bfv.Plaintext) {
+
+	params := enc.params
+
+	if len(values) > params.N() {
+		panic(fmt.Sprintf("cannot Encode: the number of values (%d) is larger than the number of slots (%d)", len(values), params.N()))
+	}
+
+	// Batches the residues modulo each t_i and combines the coefficients with the CRT
+	tmp := new(big.Int)
+	for j := range enc.coeffs {
+		enc.coeffs[j].SetUint64(0)
+	}
+
+	for i, encoder := range enc.encoders {
+
+		for j := range enc.buff {
+			enc.buff[j] = 0
+		}
+		for j, v := range values {
+			enc.buff[j] = tmp.Mod(v, enc.moduli[i]).Uint64()
+		}
+
+		encoder.EncodeUintRingT(enc.buff, enc.ptRingT[i])
+
+		for j, c := range enc.ptRingT[i].Value[0].Coeffs[0] {
+			enc.coeffs[j].Add(enc.coeffs[j], tmp.Mul(tmp.SetUint64(c), params.crt[i]))
+		}
+	}
+
+	for _, c := range enc.coeffs {
+		c.Mod(c, params.t)
+	}
+
+	scaleUpBigint(enc.ringQ, params.t, enc.coeffs, pt)
+}
+
+// EncodeNew encodes the values on a new bfv.Plaintext (see Encode).
+func (enc *RNSEncoder) EncodeNew(values []*big.Int) (pt *bfv.Plaintext) {
+	pt = bfv.NewPlaintext(enc.params.Instance(0))
+	enc.Encode(values, pt)
+	return
+}
+
+// Decode decodes the slots of pt, e.g. the decryption of a ciphertext, and returns their values in [0, T).
+func (enc *RNSEncoder) Decode(pt *bfv.Plaintext) (values []*big.Int) {
+
+	params := enc.params
+
+	scaleDownBigint(enc.ringQ, params.t, pt, enc.coeffs)
+
+	// Decodes the residues modulo each t_i and combines the slots with the CRT
+	values = make([]*big.Int, params.N())
+	for j := range values {
+		values[j] = new(big.Int)
+	}
+
+	tmp := new(big.Int)
+	for i, encoder := range enc.encoders {
+
+		coeffs := enc.ptRingT[i].Value[0].Coeffs[0]
+		for j, c := range enc.coeffs {
+			coeffs[j] = tmp.Mod(c, enc.moduli[i]).Uint64()
+		}
+
+		encoder.DecodeUint(enc.ptRingT[i], enc.buff)
+
+		for j, v := range enc.buff {
+			values[j].Add(values[j], tmp.Mul(tmp.SetUint64(v), params.crt[i]))
+		}
+	}
+
+	for j := range values {
+		values[j].Mod(values[j], params.t)
+	}
+
+	return
+}
+
+// DecodeSigned decodes the slots of pt and returns their values in [-T/2, T/2).
+func (enc *RNSEncoder) DecodeSigned(pt *bfv.Plaintext) (values []*big.Int) {
+
+	values = enc.Decode(pt)
+
+	half := new(big.Int).Rsh(enc.params.t, 1)
+	for _, v := range values {
+		if v.Cmp(half) >= 0 {
+			v.Sub(v, enc.params.t)
+		}
+	}
+
+	return
+}
+
+// modulusAtLevel returns the product of the moduli of ringQ up to the given level.
+func modulusAtLevel(ringQ *ring.Ring, level int) *big.Int {
+	Q := big.NewInt(1)
+	for _, qi := range ringQ.Modulus[:level+1] {
+		Q.Mul(Q, new(big.Int).SetUint64(qi))
+	}
+	return Q
+}
+
+// scaleUpBigint scales up the coefficients in [0, t) by Q/t with rounding, i.e. computes round(c * Q / t), and sets
+// them on pt at its level. The coefficients are modified.
+func scaleUpBigint(ringQ *ring.Ring, t *big.Int, coeffs []*big.Int, pt *bfv.Plaintext) {
+
+	level := pt.Level()
+	Q := modulusAtLevel(ringQ, level)
+	halfT := new(big.Int).Rsh(t, 1)
+
+	for _, c := range coeffs {
+		c.Mul(c, Q)
+		c.Add(c, halfT)
+		c.Quo(c, t)
+	}
+
+	ringQ.SetCoefficientsBigintLvl(level, coeffs, pt.Value[0])
+}
+
+// scaleDownBigint scales down the coefficients of pt by t/Q with rounding, i.e. computes round(c * t / Q) mod t, and
+// stores them in coeffs.
+func scaleDownBigint(ringQ *ring.Ring, t *big.Int, pt *bfv.Plaintext, coeffs []*big.Int) {
+
+	Q := modulusAtLevel(ringQ, pt.Level())
+	halfQ := new(big.Int).Rsh(Q, 1)
+
+	for _, c := range coeffs {
+		c.SetUint64(0)
+	}
+	ringQ.PolyToBigintNoAlloc(pt.Value[0], coeffs)
+
+	for _, c := range coeffs {
+		c.Mul(c, t)
+		c.Add(c, halfQ)
+		c.Quo(c, Q)
+		c.Mod(c, t)
+	}
+}
@@ -0,0 +1,388 @@
+// Package ceremony orchestrates the setup of the collective keys of n parties (the key ceremony): the collective
+// public key (CKG), the relinearization key (RKG, in two rounds) and a set of rotation keys (RTG), all generated
+// from common reference polynomials (CRS) expanded from a public seed.
+//
+// The ceremony is a small state machine advancing round by round, in which every message is a byte slice: in each
+// round, every Party generates its share with GenShare, a Coordinator aggregates the shares of all the parties
+// and returns the aggregated share, and every party advances to the next round with it. The parties and the
+// coordinator never communicate directly, so that the messages can be carried across air-gapped machines, and
+// their state can be marshaled at any point and resumed later.
+package ceremony
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Round is a round of the ceremony.
+type Round uint8
+
+const (
+	// RoundCKG is the single round of the collective public key generation.
+	RoundCKG Round = iota
+	// RoundRKG1 is the first round of the collective relinearization key generation.
+	RoundRKG1
+	// RoundRKG2 is the second round of the collective relinearization key generation.
+	RoundRKG2
+	// RoundRTG is the single round of the collective rotation keys generation.
+	RoundRTG
+	// RoundDone is reached once all the keys were generated.
+	RoundDone
+)
+
+// String returns the name of the round.
+func (r Round) String() string {
+	switch r {
+	case RoundCKG:
+		return "CKG"
+	case RoundRKG1:
+		return "RKG-1"
+	case RoundRKG2:
+		return "RKG-2"
+	case RoundRTG:
+		return "RTG"
+	case RoundDone:
+		return "Done"
+	}
+	return fmt.Sprintf("Round(%d)", uint8(r))
+}
+
+// Config is the public configuration of a ceremony, which must be the same for all the parties and the coordinator.
+type Config struct {
+	Params         rlwe.Parameters
+	Parties        []drlwe.PartyID
+	Seed           []byte   // Public seed from which the common reference polynomials are expanded
+	GaloisElements []uint64 // Galois elements of the rotation keys, the RTG round is skipped if empty
+	EphSkPr        float64  // Density of the ephemeral secrets of the RKG protocol (0 stands for 0.5)
+}
+
+// Validate returns an error if the configuration is not valid.
+func (cfg Config) Validate() error {
+
+	if len(cfg.Parties) == 0 {
+		return errors.New("invalid ceremony configuration: empty set of parties")
+	}
+
+	set := make(map[drlwe.PartyID]bool, len(cfg.Parties))
+	for _, id := range cfg.Parties {
+		if set[id] {
+			return fmt.Errorf("invalid ceremony configuration: duplicate party ID %q", id)
+		}
+		set[id] = true
+	}
+
+	if len(cfg.Seed) == 0 {
+		return errors.New("invalid ceremony configuration: empty seed")
+	}
+
+	if cfg.Params.PCount() == 0 {
+		return errors.New("invalid ceremony configuration: modulus P is empty")
+	}
+
+	if cfg.EphSkPr < 0 || cfg.EphSkPr > 1 {
+		return fmt.Errorf("invalid ceremony configuration: EphSkPr must be in [0, 1] but is %v", cfg.EphSkPr)
+	}
+
+	return nil
+}
+
+// session is the public state of a ceremony, shared by the parties and the coordinator: the current round and
+// the aggregated shares of the completed rounds.
+type session struct {
+	cfg Config
+
+	ckg *drlwe.CKGProtocol
+	rkg *drlwe.RKGProtocol
+	rtg *drlwe.RTGSetProtocol
+
+	crsCKG *ring.Poly
+	crsRKG []*ring.Poly
+	crsRTG map[uint64][]*ring.Poly
+
+	round   Round
+	outCKG  *drlwe.CKGShare
+	outRKG1 *drlwe.RKGShare
+	outRKG2 *drlwe.RKGShare
+	outRTG  *drlwe.RTGSetShare
+}
+
+func newSession(cfg Config) (s *session, err error) {
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	ephSkPr := cfg.EphSkPr
+	if ephSkPr == 0 {
+		ephSkPr = 0.5
+	}
+
+	s = &session{
+		cfg: cfg,
+		ckg: drlwe.NewCKGProtocol(cfg.Params),
+		rkg: drlwe.NewRKGProtocol(cfg.Params, ephSkPr),
+	}
+
+	ringQP := cfg.Params.RingQP()
+
+	var prng utils.PRNG
+	if prng, err = s.prng("CKG"); err != nil {
+		return nil, err
+	}
+	s.crsCKG = ring.NewUniformSampler(prng, ringQP).ReadNew()
+
+	if prng, err = s.prng("RKG"); err != nil {
+		return nil, err
+	}
+	sampler := ring.NewUniformSampler(prng, ringQP)
+	s.crsRKG = make([]*ring.Poly, cfg.Params.Beta())
+	for i := range s.crsRKG {
+		s.crsRKG[i] = sampler.ReadNew()
+	}
+
+	if len(cfg.GaloisElements) > 0 {
+		s.rtg = drlwe.NewRTGSetProtocol(cfg.Params, cfg.GaloisElements)
+		if prng, err = s.prng("RTG"); err != nil {
+			return nil, err
+		}
+		s.crsRTG = s.rtg.SampleCRP(prng)
+	}
+
+	return s, nil
+}
+
+// prng returns a PRNG keyed with the seed of the ceremony and the given label, so that the common reference
+// polynomials of each protocol are independent.
+func (s *session) prng(label string) (utils.PRNG, error) {
+	key := sha256.Sum256(append(append([]byte{}, s.cfg.Seed...), label...))
+	return utils.NewKeyedPRNG(key[:])
+}
+
+// Round returns the current round of the ceremony.
+func (s *session) Round() Round {
+	return s.round
+}
+
+// newShare allocates a share of the given round.
+func (s *session) newShare(round Round) drlwe.Share {
+	switch round {
+	case RoundCKG:
+		return s.ckg.AllocateShares()
+	case RoundRKG1, RoundRKG2:
+		_, share, _ := s.rkg.AllocateShares()
+		return share
+	case RoundRTG:
+		return s.rtg.AllocateShares()
+	}
+	panic(fmt.Sprintf("cannot allocate a share for round %s", round))
+}
+
+// aggregate aggregates the shares of the given round.
+func (s *session) aggregate(round Round) drlwe.AggregateFunc {
+	switch round {
+	case RoundCKG:
+		return func(share1, share2, shareOut drlwe.Share) {
+			s.ckg.AggregateShares(share1.(*drlwe.CKGShare), share2.(*drlwe.CKGShare), shareOut.(*drlwe.CKGShare))
+		}
+	case RoundRKG1, RoundRKG2:
+		return func(share1, share2, shareOut drlwe.Share) {
+			s.rkg.AggregateShares(share1.(*drlwe.RKGShare), share2.(*drlwe.RKGShare), shareOut.(*drlwe.RKGShare))
+		}
+	case RoundRTG:
+		return func(share1, share2, shareOut drlwe.Share) {
+			s.rtg.Aggregate(share1.(*drlwe.RTGSetShare), share2.(*drlwe.RTGSetShare), shareOut.(*drlwe.RTGSetShare))
+		}
+	}
+	panic(fmt.Sprintf("cannot aggregate the shares of round %s", round))
+}
+
+// decodeShare decodes a share of the given round and checks that data is its canonical encoding. The dimensions of
+// the encoding are checked against the ones of the round before the decoding, so that a malformed share (e.g.,
+// empty, truncated or of other parameters) is reported as an error and can be aggregated once decoded.
+func (s *session) decodeShare(round Round, data []byte) (share drlwe.Share, err error) {
+
+	if len(data) == 0 {
+		return nil, errors.New("empty share")
+	}
+
+	share = s.newShare(round)
+
+	if err = share.(encodingChecker).CheckEncoding(data); err != nil {
+		return nil, fmt.Errorf("malformed share: %v", err)
+	}
+
+	if err = share.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	var encoded []byte
+	if encoded, err = share.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(encoded, data) {
+		return nil, errors.New("non-canonical share encoding")
+	}
+
+	return share, nil
+}
+
+// encodingChecker is implemented by the shares of the rounds of a ceremony, which check that an encoding has their
+// dimensions.
+type encodingChecker interface {
+	CheckEncoding(data []byte) error
+}
+
+// advance records the aggregated share of the current round and moves to the next round.
+func (s *session) advance(data []byte) (err error) {
+
+	if s.round == RoundDone {
+		return errors.New("cannot advance: the ceremony is done")
+	}
+
+	var share drlwe.Share
+	if share, err = s.decodeShare(s.round, data); err != nil {
+		return fmt.Errorf("cannot advance: invalid aggregated share for round %s: %w", s.round, err)
+	}
+
+	switch s.round {
+	case RoundCKG:
+		s.outCKG = share.(*drlwe.CKGShare)
+	case RoundRKG1:
+		s.outRKG1 = share.(*drlwe.RKGShare)
+	case RoundRKG2:
+		s.outRKG2 = share.(*drlwe.RKGShare)
+	case RoundRTG:
+		s.outRTG = share.(*drlwe.RTGSetShare)
+	}
+
+	s.round++
+	if s.round == RoundRTG && s.rtg == nil {
+		s.round = RoundDone
+	}
+
+	return nil
+}
+
+// PublicKey returns the collective public key, once the CKG round is completed.
+func (s *session) PublicKey() (pk *rlwe.PublicKey, err error) {
+	if s.outCKG == nil {
+		return nil, errors.New("cannot PublicKey: the CKG round is not completed")
+	}
+	pk = rlwe.NewPublicKey(s.cfg.Params)
+	s.ckg.GenPublicKey(s.outCKG, s.crsCKG, pk)
+	return pk, nil
+}
+
+// RelinearizationKey returns the collective relinearization key, once the RKG rounds are completed.
+func (s *session) RelinearizationKey() (rlk *rlwe.RelinearizationKey, err error) {
+	if s.outRKG2 == nil {
+		return nil, errors.New("cannot RelinearizationKey: the RKG rounds are not completed")
+	}
+	rlk = rlwe.NewRelinKey(s.cfg.Params, 1)
+	s.rkg.GenRelinearizationKey(s.outRKG1, s.outRKG2, rlk)
+	return rlk, nil
+}
+
+// RotationKeys returns the collective rotation keys, once the RTG round is completed.
+func (s *session) RotationKeys() (rtks *rlwe.RotationKeySet, err error) {
+	if s.outRTG == nil {
+		return nil, errors.New("cannot RotationKeys: the RTG round is not completed or was skipped")
+	}
+	rtks = rlwe.NewRotationKeySet(s.cfg.Params, s.rtg.GaloisElements())
+	s.rtg.GenRotationKeySet(s.outRTG, s.crsRTG, rtks)
+	return rtks, nil
+}
+
+// marshal encodes the public state of the ceremony.
+func (s *session) marshal() (data []byte, err error) {
+
+	data = []byte{byte(s.round)}
+
+	for _, share := range []drlwe.Share{s.outCKG, s.outRKG1, s.outRKG2, s.outRTG} {
+
+		var b []byte
+		if !isNilShare(share) {
+			if b, err = share.MarshalBinary(); err != nil {
+				return nil, err
+			}
+		}
+
+		data = appendBytes(data, b)
+	}
+
+	return data, nil
+}
+
+// unmarshal decodes the public state of the ceremony and returns the remaining bytes.
+func (s *session) unmarshal(data []byte) (rest []byte, err error) {
+
+	if len(data) < 1 {
+		return nil, errors.New("too small bytearray")
+	}
+
+	round := Round(data[0])
+	if round > RoundDone {
+		return nil, fmt.Errorf("invalid round %d", round)
+	}
+
+	data = data[1:]
+
+	outs := make([][]byte, 4)
+	for i := range outs {
+		if outs[i], data, err = readBytes(data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Replays the completed rounds
+	s.round, s.outCKG, s.outRKG1, s.outRKG2, s.outRTG = RoundCKG, nil, nil, nil, nil
+	for s.round < round {
+		if err = s.advance(outs[s.round]); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.round != round {
+		return nil, fmt.Errorf("invalid round %s", round)
+	}
+
+	return data, nil
+}
+
+func isNilShare(share drlwe.Share) bool {
+	switch share := share.(type) {
+	case *drlwe.CKGShare:
+		return share == nil
+	case *drlwe.RKGShare:
+		return share == nil
+	case *drlwe.RTGSetShare:
+		return share == nil
+	}
+	return false
+}
+
+func appendBytes(data, b []byte) []byte {
+	var buff [4]byte
+	binary.BigEndian.PutUint32(buff[:], uint32(len(b)))
+	return append(append(data, buff[:]...), b...)
+}
+
+func readBytes(data []byte) (b, rest []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, errors.New("too small bytearray")
+	}
+	n := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4+n {
+		return nil, nil, errors.New("too small bytearray")
+	}
+	return data[4 : 4+n], data[4+n:], nil
+}
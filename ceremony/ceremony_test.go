@@ -0,0 +1,201 @@
+package ceremony
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCeremony(t *testing.T) {
+
+	params, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+
+	cfg := Config{
+		Params:         params.Parameters,
+		Parties:        []drlwe.PartyID{"alice", "bob", "charlie"},
+		Seed:           []byte("ceremony test seed"),
+		GaloisElements: []uint64{params.GaloisElementForColumnRotationBy(1)},
+	}
+
+	kgen := bfv.NewKeyGenerator(params)
+	ringQP := params.RingQP()
+
+	sks := make([]*rlwe.SecretKey, len(cfg.Parties))
+	skIdeal := rlwe.NewSecretKey(params.Parameters)
+	for i := range sks {
+		sks[i] = kgen.GenSecretKey()
+		ringQP.Add(skIdeal.Value, sks[i].Value, skIdeal.Value)
+	}
+
+	t.Run("Config/Validate", func(t *testing.T) {
+
+		require.NoError(t, cfg.Validate())
+
+		invalid := cfg
+		invalid.Parties = nil
+		require.Error(t, invalid.Validate())
+
+		invalid = cfg
+		invalid.Parties = []drlwe.PartyID{"alice", "alice"}
+		require.Error(t, invalid.Validate())
+
+		invalid = cfg
+		invalid.Seed = nil
+		require.Error(t, invalid.Validate())
+
+		invalid = cfg
+		invalid.EphSkPr = 2
+		require.Error(t, invalid.Validate())
+
+		_, err := NewParty(cfg, "eve", sks[0])
+		require.Error(t, err)
+	})
+
+	t.Run("Run", func(t *testing.T) {
+
+		parties := make([]*Party, len(cfg.Parties))
+		for i, id := range cfg.Parties {
+			parties[i], err = NewParty(cfg, id, sks[i])
+			require.NoError(t, err)
+		}
+
+		coord, err := NewCoordinator(cfg)
+		require.NoError(t, err)
+
+		_, err = coord.PublicKey()
+		require.Error(t, err)
+
+		for round := RoundCKG; round < RoundDone; round++ {
+
+			require.Equal(t, round, coord.Round())
+
+			for i, p := range parties {
+
+				require.Equal(t, round, p.Round())
+
+				share, err := p.GenShare()
+				require.NoError(t, err)
+
+				// Checkpoints the party after GenShare and resumes it from a fresh state
+				state, err := p.MarshalBinary()
+				require.NoError(t, err)
+				parties[i], err = NewParty(cfg, p.ID(), sks[i])
+				require.NoError(t, err)
+				require.NoError(t, parties[i].UnmarshalBinary(state))
+				require.Equal(t, round, parties[i].Round())
+
+				// Malformed shares are rejected
+				for _, malformed := range [][]byte{{}, share[:1], share[:len(share)/2], {4, 0, 0, 0, 1}, {63, 2}} {
+					_, err = coord.AddShare(p.ID(), malformed)
+					require.Error(t, err)
+				}
+
+				added, err := coord.AddShare(p.ID(), share)
+				require.NoError(t, err)
+				require.True(t, added)
+
+				added, err = coord.AddShare(p.ID(), share)
+				require.NoError(t, err)
+				require.False(t, added)
+
+				_, err = coord.AddShare("eve", share)
+				require.Error(t, err)
+
+				if i == 0 {
+					_, err = coord.Aggregated()
+					require.Error(t, err)
+					require.Equal(t, cfg.Parties[1:], coord.Missing())
+				}
+
+				// Checkpoints the coordinator and resumes it from a fresh state
+				state, err = coord.MarshalBinary()
+				require.NoError(t, err)
+				coord, err = NewCoordinator(cfg)
+				require.NoError(t, err)
+				require.NoError(t, coord.UnmarshalBinary(state))
+			}
+
+			require.True(t, coord.Complete())
+
+			aggregated, err := coord.Aggregated()
+			require.NoError(t, err)
+
+			for _, p := range parties {
+				require.Error(t, p.Advance([]byte{}))
+				require.NoError(t, p.Advance(aggregated))
+			}
+		}
+
+		require.Equal(t, RoundDone, coord.Round())
+		_, err = parties[0].GenShare()
+		require.Error(t, err)
+
+		pk, err := coord.PublicKey()
+		require.NoError(t, err)
+		rlk, err := coord.RelinearizationKey()
+		require.NoError(t, err)
+		rtks, err := coord.RotationKeys()
+		require.NoError(t, err)
+
+		// The parties obtain the same keys as the coordinator
+		for _, p := range parties {
+			pkParty, err := p.PublicKey()
+			require.NoError(t, err)
+			require.True(t, ringQP.Equal(pk.Value[0], pkParty.Value[0]))
+			require.True(t, ringQP.Equal(pk.Value[1], pkParty.Value[1]))
+		}
+
+		encoder := bfv.NewEncoder(params)
+		encryptor := bfv.NewEncryptorFromPk(params, pk)
+		decryptor := bfv.NewDecryptor(params, skIdeal)
+		eval := bfv.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks})
+
+		values := make([]uint64, params.N())
+		want := make([]uint64, params.N())
+		for i := range values {
+			values[i] = utils.RandUint64() % params.T()
+		}
+		for i := range want {
+			// Columns rotation by 1 on both rows of N/2 slots
+			row, col := i/(params.N()>>1), i%(params.N()>>1)
+			j := row*(params.N()>>1) + (col+1)%(params.N()>>1)
+			want[i] = (values[j] * values[j]) % params.T()
+		}
+
+		pt := bfv.NewPlaintext(params)
+		encoder.EncodeUint(values, pt)
+		ct := encryptor.EncryptNew(pt)
+
+		ct = eval.RelinearizeNew(eval.MulNew(ct, ct))
+		ct = eval.RotateColumnsNew(ct, 1)
+
+		require.Equal(t, want, encoder.DecodeUintNew(decryptor.DecryptNew(ct)))
+	})
+
+	t.Run("NoRotationKeys", func(t *testing.T) {
+
+		cfg := cfg
+		cfg.GaloisElements = nil
+
+		p, err := NewParty(cfg, cfg.Parties[0], sks[0])
+		require.NoError(t, err)
+
+		for _, round := range []Round{RoundCKG, RoundRKG1, RoundRKG2} {
+			require.Equal(t, round, p.Round())
+			share, err := p.GenShare()
+			require.NoError(t, err)
+			require.NoError(t, p.Advance(share))
+		}
+
+		require.Equal(t, RoundDone, p.Round())
+		_, err = p.RotationKeys()
+		require.Error(t, err)
+		require.Equal(t, "Done", fmt.Sprint(RoundDone))
+	})
+}
@@ -0,0 +1,127 @@
+package ceremony
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+)
+
+// Coordinator is the state of the coordinator of a ceremony, which aggregates the shares of the parties in each
+// round. The coordinator only handles public data and does not need to be trusted for the secrecy of the keys.
+type Coordinator struct {
+	*session
+	agg *drlwe.Aggregator
+}
+
+// NewCoordinator creates the state of the coordinator at the first round of the ceremony configured by cfg.
+func NewCoordinator(cfg Config) (c *Coordinator, err error) {
+
+	c = new(Coordinator)
+
+	if c.session, err = newSession(cfg); err != nil {
+		return nil, err
+	}
+
+	c.newAggregator()
+
+	return c, nil
+}
+
+func (c *Coordinator) newAggregator() {
+	c.agg = nil
+	if c.round != RoundDone {
+		c.agg = drlwe.NewAggregator(c.cfg.Parties, c.newShare(c.round), c.aggregate(c.round))
+	}
+}
+
+// AddShare aggregates the share of the party id for the current round. Returns false if the share of this party
+// was already aggregated, in which case the share is ignored, and an error if id is not one of the parties of the
+// ceremony or if the share is invalid.
+func (c *Coordinator) AddShare(id drlwe.PartyID, data []byte) (added bool, err error) {
+
+	if c.round == RoundDone {
+		return false, errors.New("cannot AddShare: the ceremony is done")
+	}
+
+	var share drlwe.Share
+	if share, err = c.decodeShare(c.round, data); err != nil {
+		return false, fmt.Errorf("cannot AddShare: invalid share of party %q for round %s: %w", id, c.round, err)
+	}
+
+	return c.agg.Add(id, share)
+}
+
+// Missing returns the sorted IDs of the parties whose share of the current round was not yet received.
+func (c *Coordinator) Missing() []drlwe.PartyID {
+	if c.round == RoundDone {
+		return []drlwe.PartyID{}
+	}
+	return c.agg.Missing()
+}
+
+// Complete returns true if the shares of all the parties were received for the current round.
+func (c *Coordinator) Complete() bool {
+	return c.round != RoundDone && c.agg.Complete()
+}
+
+// Aggregated completes the current round and returns the marshaled aggregated share, which must be sent to all
+// the parties, and moves the coordinator to the next round. Returns an error if some shares are missing.
+func (c *Coordinator) Aggregated() (data []byte, err error) {
+
+	if !c.Complete() {
+		return nil, fmt.Errorf("cannot Aggregated: the shares of %v are missing for round %s", c.Missing(), c.round)
+	}
+
+	if data, err = c.agg.Aggregated().MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	if err = c.advance(data); err != nil {
+		return nil, err
+	}
+
+	c.newAggregator()
+
+	return data, nil
+}
+
+// MarshalBinary encodes the state of the coordinator, including the shares received for the current round, on a
+// slice of bytes.
+func (c *Coordinator) MarshalBinary() (data []byte, err error) {
+
+	if data, err = c.marshal(); err != nil {
+		return nil, err
+	}
+
+	var agg []byte
+	if c.agg != nil {
+		if agg, err = c.agg.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	return appendBytes(data, agg), nil
+}
+
+// UnmarshalBinary decodes a previously marshaled state on the target coordinator, which must have been created
+// with the same configuration.
+func (c *Coordinator) UnmarshalBinary(data []byte) (err error) {
+
+	if data, err = c.unmarshal(data); err != nil {
+		return err
+	}
+
+	var agg []byte
+	if agg, _, err = readBytes(data); err != nil {
+		return err
+	}
+
+	c.newAggregator()
+
+	if c.agg != nil {
+		return c.agg.UnmarshalBinary(agg)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package ceremony
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Party is the state of a party in a ceremony. In each round, the party generates its share with GenShare, sends
+// it to the coordinator, and advances to the next round with the aggregated share returned by the coordinator.
+//
+// The state of a party contains its ephemeral secret of the RKG protocol between the two RKG rounds: it must be
+// marshaled after GenShare and before the share is sent, so that a resumed party completes the RKG protocol with
+// the same ephemeral secret, and the marshaled state must be protected as a secret key.
+type Party struct {
+	*session
+	id    drlwe.PartyID
+	sk    *rlwe.SecretKey
+	ephSk *rlwe.SecretKey
+}
+
+// NewParty creates the state of the party id, with the secret key share sk, at the first round of the ceremony
+// configured by cfg.
+func NewParty(cfg Config, id drlwe.PartyID, sk *rlwe.SecretKey) (p *Party, err error) {
+
+	p = &Party{id: id, sk: sk}
+
+	if p.session, err = newSession(cfg); err != nil {
+		return nil, err
+	}
+
+	for _, party := range cfg.Parties {
+		if party == id {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot NewParty: %q is not a party of the ceremony", id)
+}
+
+// ID returns the ID of the party.
+func (p *Party) ID() drlwe.PartyID {
+	return p.id
+}
+
+// GenShare generates the share of the party for the current round and returns it marshaled.
+func (p *Party) GenShare() (data []byte, err error) {
+
+	if p.round == RoundDone {
+		return nil, errors.New("cannot GenShare: the ceremony is done")
+	}
+
+	share := p.newShare(p.round)
+
+	switch p.round {
+	case RoundCKG:
+		p.ckg.GenShare(p.sk, p.crsCKG, share.(*drlwe.CKGShare))
+	case RoundRKG1:
+		ephSk, _, _ := p.rkg.AllocateShares()
+		p.rkg.GenShareRoundOne(p.sk, p.crsRKG, ephSk, share.(*drlwe.RKGShare))
+		p.ephSk = ephSk
+	case RoundRKG2:
+		p.rkg.GenShareRoundTwo(p.ephSk, p.sk, p.outRKG1, p.crsRKG, share.(*drlwe.RKGShare))
+	case RoundRTG:
+		p.rtg.GenShare(p.sk, p.crsRTG, share.(*drlwe.RTGSetShare))
+	}
+
+	return share.MarshalBinary()
+}
+
+// Advance records the aggregated share of the current round, returned by the coordinator, and moves the party to
+// the next round.
+func (p *Party) Advance(aggregated []byte) (err error) {
+
+	if err = p.advance(aggregated); err != nil {
+		return err
+	}
+
+	// The ephemeral secret is not needed anymore once the RKG protocol is completed
+	if p.round > RoundRKG2 {
+		p.ephSk = nil
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the state of the party, including its ephemeral secret of the RKG protocol but not its
+// secret key share, on a slice of bytes.
+func (p *Party) MarshalBinary() (data []byte, err error) {
+
+	if data, err = p.marshal(); err != nil {
+		return nil, err
+	}
+
+	var ephSk []byte
+	if p.ephSk != nil {
+		if ephSk, err = p.ephSk.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	return appendBytes(data, ephSk), nil
+}
+
+// UnmarshalBinary decodes a previously marshaled state on the target party, which must have been created with
+// the same configuration, ID and secret key share.
+func (p *Party) UnmarshalBinary(data []byte) (err error) {
+
+	if data, err = p.unmarshal(data); err != nil {
+		return err
+	}
+
+	var ephSk []byte
+	if ephSk, _, err = readBytes(data); err != nil {
+		return err
+	}
+
+	p.ephSk = nil
+	if len(ephSk) > 0 {
+		p.ephSk = new(rlwe.SecretKey)
+		if err = p.ephSk.UnmarshalBinary(ephSk); err != nil {
+			return err
+		}
+	}
+
+	if p.round == RoundRKG2 && p.ephSk == nil {
+		return errors.New("cannot UnmarshalBinary: missing ephemeral secret for round RKG-2")
+	}
+
+	return nil
+}
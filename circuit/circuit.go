@@ -0,0 +1,297 @@
+// Package circuit implements the recording of the operations of a CKKS evaluator into a directed acyclic graph
+// (a Circuit), which can be optimized, serialized and replayed on other input ciphertexts, sequentially or on
+// several goroutines. It is the first step toward a compiler of homomorphic circuits.
+//
+// A Circuit is obtained by evaluating a computation once with a Recorder (see NewRecorder) on inputs registered
+// with Recorder.Input, and by marking its results with Recorder.Output.
+package circuit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Operation is the name of the evaluator operation of a Node.
+type Operation string
+
+// Operations of a Circuit. Each operation corresponds to the method of the same name of ckks.Evaluator, except
+// OpInput, which is an input of the circuit.
+const (
+	OpInput       = Operation("Input")
+	OpAdd         = Operation("Add")
+	OpSub         = Operation("Sub")
+	OpNeg         = Operation("Neg")
+	OpAddConst    = Operation("AddConst")
+	OpMultByConst = Operation("MultByConst")
+	OpMul         = Operation("Mul")
+	OpMulRelin    = Operation("MulRelin")
+	OpRelinearize = Operation("Relinearize")
+	OpRescale     = Operation("Rescale")
+	OpRotate      = Operation("Rotate")
+	OpConjugate   = Operation("Conjugate")
+	OpDropLevel   = Operation("DropLevel")
+)
+
+// arity is the number of ciphertext operands of each operation.
+var arity = map[Operation]int{
+	OpInput:       0,
+	OpAdd:         2,
+	OpSub:         2,
+	OpNeg:         1,
+	OpAddConst:    1,
+	OpMultByConst: 1,
+	OpMul:         2,
+	OpMulRelin:    2,
+	OpRelinearize: 1,
+	OpRescale:     1,
+	OpRotate:      1,
+	OpConjugate:   1,
+	OpDropLevel:   1,
+}
+
+// Node is an operation of a Circuit.
+type Node struct {
+	// Op is the operation of the node.
+	Op Operation
+	// Args are the indexes, in the Circuit, of the nodes whose outputs are the operands of the operation.
+	Args []int `json:",omitempty"`
+
+	// ConstReal and ConstImag are the real and imaginary parts of the constant of OpAddConst and OpMultByConst.
+	ConstReal float64 `json:",omitempty"`
+	ConstImag float64 `json:",omitempty"`
+	// K is the rotation of OpRotate and the number of levels of OpDropLevel.
+	K int `json:",omitempty"`
+	// MinScale is the minimum scale of OpRescale.
+	MinScale float64 `json:",omitempty"`
+
+	// Level, Scale and Degree describe the output of the node when it was recorded.
+	Level  int
+	Scale  float64
+	Degree int
+}
+
+// Circuit is a directed acyclic graph of evaluator operations. The nodes are stored in a topological order:
+// the operands of a node are always nodes of lower index.
+type Circuit struct {
+	// Nodes are the operations of the circuit.
+	Nodes []Node
+	// Inputs are the indexes of the OpInput nodes, in the order of the input ciphertexts.
+	Inputs []int
+	// Outputs are the indexes of the nodes whose outputs are the outputs of the circuit, in order.
+	Outputs []int
+}
+
+// CopyNew creates a deep copy of the circuit.
+func (c *Circuit) CopyNew() *Circuit {
+	cpy := &Circuit{
+		Nodes:   make([]Node, len(c.Nodes)),
+		Inputs:  append([]int{}, c.Inputs...),
+		Outputs: append([]int{}, c.Outputs...),
+	}
+	for i, node := range c.Nodes {
+		cpy.Nodes[i] = node
+		cpy.Nodes[i].Args = append([]int(nil), node.Args...)
+	}
+	return cpy
+}
+
+// Validate checks that the circuit is well formed: the operations are known and have the right number of
+// operands, the nodes are in a topological order, and the inputs and outputs are valid nodes.
+func (c *Circuit) Validate() error {
+
+	inputs := 0
+	for i, node := range c.Nodes {
+
+		n, ok := arity[node.Op]
+		if !ok {
+			return fmt.Errorf("invalid circuit: node %d has an unknown operation %q", i, node.Op)
+		}
+
+		if len(node.Args) != n {
+			return fmt.Errorf("invalid circuit: node %d (%s) has %d operands instead of %d", i, node.Op, len(node.Args), n)
+		}
+
+		for _, j := range node.Args {
+			if j < 0 || j >= i {
+				return fmt.Errorf("invalid circuit: node %d (%s) has an operand %d that is not a previous node", i, node.Op, j)
+			}
+		}
+
+		if node.Op == OpInput {
+			inputs++
+		}
+	}
+
+	if len(c.Inputs) != inputs {
+		return fmt.Errorf("invalid circuit: %d inputs are listed but the circuit has %d input nodes", len(c.Inputs), inputs)
+	}
+
+	seen := make(map[int]bool)
+	for _, i := range c.Inputs {
+		if i < 0 || i >= len(c.Nodes) || c.Nodes[i].Op != OpInput || seen[i] {
+			return fmt.Errorf("invalid circuit: input %d is not a distinct input node", i)
+		}
+		seen[i] = true
+	}
+
+	for _, i := range c.Outputs {
+		if i < 0 || i >= len(c.Nodes) {
+			return fmt.Errorf("invalid circuit: output %d is not a node", i)
+		}
+	}
+
+	return nil
+}
+
+// EliminateDeadCode returns a copy of the circuit without the nodes on which no output depends. The inputs are
+// kept, so that the circuit is executed on the same inputs.
+func (c *Circuit) EliminateDeadCode() *Circuit {
+
+	live := make([]bool, len(c.Nodes))
+
+	for _, i := range c.Inputs {
+		live[i] = true
+	}
+
+	for _, i := range c.Outputs {
+		live[i] = true
+	}
+
+	// The operands of a node have lower indexes, so a single backward pass marks all the live nodes.
+	for i := len(c.Nodes) - 1; i >= 0; i-- {
+		if live[i] {
+			for _, j := range c.Nodes[i].Args {
+				live[j] = true
+			}
+		}
+	}
+
+	index := make([]int, len(c.Nodes))
+	out := &Circuit{}
+
+	for i, node := range c.Nodes {
+		if live[i] {
+			index[i] = len(out.Nodes)
+			args := make([]int, len(node.Args))
+			for k, j := range node.Args {
+				args[k] = index[j]
+			}
+			node.Args = args
+			out.Nodes = append(out.Nodes, node)
+		}
+	}
+
+	for _, i := range c.Inputs {
+		out.Inputs = append(out.Inputs, index[i])
+	}
+
+	for _, i := range c.Outputs {
+		out.Outputs = append(out.Outputs, index[i])
+	}
+
+	return out
+}
+
+// HoistRescale returns a copy of the circuit in which the additions and subtractions of two rescaled ciphertexts
+// are replaced by the rescaling of the addition (or subtraction) of the ciphertexts before their rescaling, which
+// saves one rescaling each. A pair is only hoisted if the two ciphertexts had the same level and scale before
+// their rescaling, with the same minimum scale, and if the rescaled ciphertexts are not used elsewhere. The nodes
+// that become unused are eliminated (see EliminateDeadCode).
+func (c *Circuit) HoistRescale() *Circuit {
+
+	out := c.CopyNew()
+
+	uses := make([]int, len(out.Nodes))
+	for _, node := range out.Nodes {
+		for _, j := range node.Args {
+			uses[j]++
+		}
+	}
+
+	for _, i := range out.Outputs {
+		uses[i]++
+	}
+
+	// A single forward pass suffices: a hoisted node becomes a rescaling that its consumers, which have
+	// higher indexes, can hoist in turn.
+	for i := range out.Nodes {
+
+		node := &out.Nodes[i]
+
+		if node.Op != OpAdd && node.Op != OpSub {
+			continue
+		}
+
+		a, b := node.Args[0], node.Args[1]
+		if a == b || out.Nodes[a].Op != OpRescale || out.Nodes[b].Op != OpRescale || uses[a] != 1 || uses[b] != 1 {
+			continue
+		}
+
+		if out.Nodes[a].MinScale != out.Nodes[b].MinScale {
+			continue
+		}
+
+		x, y := out.Nodes[a].Args[0], out.Nodes[b].Args[0]
+		if out.Nodes[x].Level != out.Nodes[y].Level || out.Nodes[x].Scale != out.Nodes[y].Scale {
+			continue
+		}
+
+		// The addition takes the place of the last of the two rescalings, which comes after x and y.
+		last, other := b, a
+		if a > b {
+			last, other = a, b
+		}
+
+		out.Nodes[last] = Node{
+			Op:     node.Op,
+			Args:   []int{x, y},
+			Level:  out.Nodes[x].Level,
+			Scale:  out.Nodes[x].Scale,
+			Degree: utils.MaxInt(out.Nodes[x].Degree, out.Nodes[y].Degree),
+		}
+
+		uses[other] = 0
+
+		*node = Node{
+			Op:       OpRescale,
+			Args:     []int{last},
+			MinScale: out.Nodes[other].MinScale,
+			Level:    node.Level,
+			Scale:    node.Scale,
+			Degree:   node.Degree,
+		}
+	}
+
+	return out.EliminateDeadCode()
+}
+
+// Optimize returns a copy of the circuit on which all the optimization passes have been applied.
+func (c *Circuit) Optimize() *Circuit {
+	// Eliminating the dead code first removes the uses of the rescaled ciphertexts that would prevent hoisting.
+	return c.EliminateDeadCode().HoistRescale()
+}
+
+// MarshalBinary encodes the circuit in a slice of bytes. The encoding is JSON.
+func (c *Circuit) MarshalBinary() (data []byte, err error) {
+	return json.Marshal(c)
+}
+
+// UnmarshalBinary decodes a slice of bytes generated by MarshalBinary on the circuit, and checks that the decoded
+// circuit is well formed.
+func (c *Circuit) UnmarshalBinary(data []byte) (err error) {
+
+	var decoded Circuit
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if err = decoded.Validate(); err != nil {
+		return err
+	}
+
+	*c = decoded
+
+	return nil
+}
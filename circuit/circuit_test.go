@@ -0,0 +1,203 @@
+package circuit
+
+import (
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+type testContext struct {
+	params    ckks.Parameters
+	encoder   ckks.Encoder
+	encryptor ckks.Encryptor
+	decryptor ckks.Decryptor
+	eval      ckks.Evaluator
+}
+
+func newTestContext(t *testing.T) *testContext {
+
+	params, err := ckks.NewParametersFromLiteral(ckks.PN13QP218)
+	require.NoError(t, err)
+
+	kgen := ckks.NewKeyGenerator(params)
+	sk := kgen.GenSecretKey()
+	rlk := kgen.GenRelinearizationKey(sk)
+	rtks := kgen.GenRotationKeysForRotations([]int{1}, true, sk)
+
+	return &testContext{
+		params:    params,
+		encoder:   ckks.NewEncoder(params),
+		encryptor: ckks.NewEncryptorFromSk(params, sk),
+		decryptor: ckks.NewDecryptor(params, sk),
+		eval:      ckks.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks}),
+	}
+}
+
+func (tc *testContext) encrypt() (values []complex128, ct *ckks.Ciphertext) {
+	values = make([]complex128, tc.params.Slots())
+	for i := range values {
+		values[i] = utils.RandComplex128(-1, 1)
+	}
+	return values, tc.encryptor.EncryptNew(tc.encoder.EncodeNTTNew(values, tc.params.LogSlots()))
+}
+
+func (tc *testContext) verify(t *testing.T, want []complex128, ct *ckks.Ciphertext) {
+	have := tc.encoder.Decode(tc.decryptor.DecryptNew(ct), tc.params.LogSlots())
+	stats := ckks.GetPrecisionStats(tc.params, tc.encoder, nil, want, have, tc.params.LogSlots(), 0)
+	require.Greater(t, real(stats.MinPrecision), 10.0)
+	require.Greater(t, imag(stats.MinPrecision), 10.0)
+}
+
+// compute evaluates rot(conj(x^2 + y^2), 1) * 0.5 + 1 with separately rescaled squares, and a dead operation.
+func compute(t *testing.T, eval ckks.Evaluator, params ckks.Parameters, x, y *ckks.Ciphertext) *ckks.Ciphertext {
+
+	x2 := eval.MulRelinNew(x, x)
+	require.NoError(t, eval.Rescale(x2, params.Scale(), x2))
+
+	y2 := eval.MulNew(y, y)
+	eval.Relinearize(y2, y2)
+	require.NoError(t, eval.Rescale(y2, params.Scale(), y2))
+
+	// Dead operation
+	eval.NegNew(x2)
+
+	res := eval.AddNew(x2, y2)
+	eval.Conjugate(res, res)
+	eval.Rotate(res, 1, res)
+	eval.MultByConst(res, 0.5, res)
+	eval.AddConst(res, 1, res)
+
+	return res
+}
+
+func want(x, y []complex128) (values []complex128) {
+	values = make([]complex128, len(x))
+	for i := range values {
+		j := (i + 1) % len(x)
+		s := x[j]*x[j] + y[j]*y[j]
+		values[i] = complex(real(s), -imag(s))*0.5 + 1
+	}
+	return
+}
+
+func countOp(c *Circuit, op Operation) (n int) {
+	for _, node := range c.Nodes {
+		if node.Op == op {
+			n++
+		}
+	}
+	return
+}
+
+func TestCircuit(t *testing.T) {
+
+	tc := newTestContext(t)
+
+	xValues, x := tc.encrypt()
+	yValues, y := tc.encrypt()
+
+	rec := NewRecorder(tc.eval)
+
+	// Checks that the Recorder complies to the ckks.Evaluator interface
+	var _ ckks.Evaluator = rec
+
+	rec.Input(x)
+	rec.Input(y)
+	res := compute(t, rec, tc.params, x, y)
+	rec.Output(res)
+
+	tc.verify(t, want(xValues, yValues), res)
+
+	circuit, err := rec.Circuit()
+	require.NoError(t, err)
+	require.NoError(t, circuit.Validate())
+	require.Len(t, circuit.Inputs, 2)
+	require.Len(t, circuit.Outputs, 1)
+	require.Equal(t, 1, countOp(circuit, OpNeg))
+	require.Equal(t, 2, countOp(circuit, OpRescale))
+
+	t.Run("EliminateDeadCode", func(t *testing.T) {
+		optimized := circuit.EliminateDeadCode()
+		require.NoError(t, optimized.Validate())
+		require.Equal(t, len(circuit.Nodes)-1, len(optimized.Nodes))
+		require.Equal(t, 0, countOp(optimized, OpNeg))
+	})
+
+	t.Run("HoistRescale", func(t *testing.T) {
+
+		// The dead negation uses the rescaling of x^2, which prevents hoisting.
+		require.Equal(t, 2, countOp(circuit.HoistRescale(), OpRescale))
+
+		optimized := circuit.Optimize()
+		require.NoError(t, optimized.Validate())
+		require.Equal(t, 1, countOp(optimized, OpRescale))
+		require.Equal(t, 0, countOp(optimized, OpNeg))
+
+		outputs, err := optimized.Execute(tc.eval, []*ckks.Ciphertext{x, y})
+		require.NoError(t, err)
+		tc.verify(t, want(xValues, yValues), outputs[0])
+		require.Equal(t, res.Level(), outputs[0].Level())
+		require.Equal(t, res.Scale(), outputs[0].Scale())
+	})
+
+	t.Run("MarshalBinary", func(t *testing.T) {
+		data, err := circuit.MarshalBinary()
+		require.NoError(t, err)
+
+		decoded := new(Circuit)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.Equal(t, circuit, decoded)
+
+		require.Error(t, decoded.UnmarshalBinary([]byte(`{"Nodes":[{"Op":"Add","Args":[0,1]}]}`)))
+		require.Error(t, decoded.UnmarshalBinary([]byte(`{"Nodes":[{"Op":"Bootstrapp"}]}`)))
+	})
+
+	t.Run("Execute", func(t *testing.T) {
+
+		xValues, x := tc.encrypt()
+		yValues, y := tc.encrypt()
+
+		outputs, err := circuit.Execute(tc.eval, []*ckks.Ciphertext{x, y})
+		require.NoError(t, err)
+		require.Len(t, outputs, 1)
+		tc.verify(t, want(xValues, yValues), outputs[0])
+
+		_, err = circuit.Execute(tc.eval, []*ckks.Ciphertext{x})
+		require.Error(t, err)
+	})
+
+	t.Run("ExecuteParallel", func(t *testing.T) {
+
+		xValues, x := tc.encrypt()
+		yValues, y := tc.encrypt()
+
+		for _, workers := range []int{1, 4} {
+			outputs, err := circuit.Optimize().ExecuteParallel(tc.eval, []*ckks.Ciphertext{x, y}, workers)
+			require.NoError(t, err)
+			tc.verify(t, want(xValues, yValues), outputs[0])
+		}
+
+		_, err = circuit.ExecuteParallel(tc.eval, []*ckks.Ciphertext{x, y}, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("Recorder/Invalid", func(t *testing.T) {
+
+		_, z := tc.encrypt()
+
+		rec := NewRecorder(tc.eval)
+		rec.Input(x)
+		rec.AddNew(x, z)
+		_, err := rec.Circuit()
+		require.Error(t, err)
+
+		rec = NewRecorder(tc.eval)
+		rec.Input(x)
+		rec.MulRelinNew(x, tc.encoder.EncodeNTTNew([]complex128{1}, tc.params.LogSlots()))
+		_, err = rec.Circuit()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,192 @@
+package circuit
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+)
+
+// Execute evaluates the circuit with eval on the input ciphertexts, which are not modified, and returns the
+// output ciphertexts. The evaluator must have the keys required by the circuit (relinearization key, rotation keys).
+func (c *Circuit) Execute(eval ckks.Evaluator, inputs []*ckks.Ciphertext) (outputs []*ckks.Ciphertext, err error) {
+
+	values, err := c.bindInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, node := range c.Nodes {
+		if node.Op != OpInput {
+			if values[i], err = evalNode(eval, node, values); err != nil {
+				return nil, fmt.Errorf("cannot Execute: node %d (%s): %w", i, node.Op, err)
+			}
+		}
+	}
+
+	return c.collectOutputs(values), nil
+}
+
+// ExecuteParallel evaluates the circuit on the input ciphertexts like Execute, with workers goroutines, each using
+// a shallow copy of eval. The operations whose operands are available are evaluated concurrently.
+func (c *Circuit) ExecuteParallel(eval ckks.Evaluator, inputs []*ckks.Ciphertext, workers int) (outputs []*ckks.Ciphertext, err error) {
+
+	if workers < 1 {
+		return nil, fmt.Errorf("cannot ExecuteParallel: the number of workers must be positive")
+	}
+
+	values, err := c.bindInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	// pending[i] is the number of operands of the node i not yet evaluated.
+	pending := make([]int, len(c.Nodes))
+	consumers := make([][]int, len(c.Nodes))
+	ready := make(chan int, len(c.Nodes))
+
+	remaining := 0
+	for i, node := range c.Nodes {
+		if node.Op == OpInput {
+			continue
+		}
+		remaining++
+		for _, j := range node.Args {
+			if c.Nodes[j].Op != OpInput {
+				pending[i]++
+				consumers[j] = append(consumers[j], i)
+			}
+		}
+		if pending[i] == 0 {
+			ready <- i
+		}
+	}
+
+	if remaining == 0 {
+		return c.collectOutputs(values), nil
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(eval ckks.Evaluator) {
+			defer wg.Done()
+			for i := range ready {
+
+				mutex.Lock()
+				failed := err != nil
+				mutex.Unlock()
+
+				// After a failure, the remaining nodes are only drained.
+				var ct *ckks.Ciphertext
+				var errNode error
+				if !failed {
+					ct, errNode = evalNode(eval, c.Nodes[i], values)
+				}
+
+				mutex.Lock()
+				values[i] = ct
+				if errNode != nil && err == nil {
+					err = fmt.Errorf("cannot ExecuteParallel: node %d (%s): %w", i, c.Nodes[i].Op, errNode)
+				}
+				for _, j := range consumers[i] {
+					if pending[j]--; pending[j] == 0 {
+						ready <- j
+					}
+				}
+				if remaining--; remaining == 0 {
+					close(ready)
+				}
+				mutex.Unlock()
+			}
+		}(eval.ShallowCopy())
+	}
+
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.collectOutputs(values), nil
+}
+
+// bindInputs checks the circuit and the inputs, and returns the slice of the values of the nodes in which the
+// values of the input nodes are set.
+func (c *Circuit) bindInputs(inputs []*ckks.Ciphertext) (values []*ckks.Ciphertext, err error) {
+
+	if err = c.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(inputs) != len(c.Inputs) {
+		return nil, fmt.Errorf("cannot execute the circuit: %d inputs are given but the circuit has %d inputs", len(inputs), len(c.Inputs))
+	}
+
+	values = make([]*ckks.Ciphertext, len(c.Nodes))
+	for k, i := range c.Inputs {
+		if inputs[k] == nil {
+			return nil, fmt.Errorf("cannot execute the circuit: input %d is nil", k)
+		}
+		values[i] = inputs[k]
+	}
+
+	return values, nil
+}
+
+// collectOutputs returns the values of the output nodes. An output that is an input of the circuit is copied, so
+// that the outputs never share memory with the inputs.
+func (c *Circuit) collectOutputs(values []*ckks.Ciphertext) (outputs []*ckks.Ciphertext) {
+	outputs = make([]*ckks.Ciphertext, len(c.Outputs))
+	for k, i := range c.Outputs {
+		if c.Nodes[i].Op == OpInput {
+			outputs[k] = values[i].CopyNew()
+		} else {
+			outputs[k] = values[i]
+		}
+	}
+	return
+}
+
+// evalNode evaluates the operation of node on the values of its operands, in a newly created element.
+func evalNode(eval ckks.Evaluator, node Node, values []*ckks.Ciphertext) (ctOut *ckks.Ciphertext, err error) {
+
+	args := make([]*ckks.Ciphertext, len(node.Args))
+	for k, j := range node.Args {
+		args[k] = values[j]
+	}
+
+	constant := complex(node.ConstReal, node.ConstImag)
+
+	switch node.Op {
+	case OpAdd:
+		return eval.AddNew(args[0], args[1]), nil
+	case OpSub:
+		return eval.SubNew(args[0], args[1]), nil
+	case OpNeg:
+		return eval.NegNew(args[0]), nil
+	case OpAddConst:
+		return eval.AddConstNew(args[0], constant), nil
+	case OpMultByConst:
+		return eval.MultByConstNew(args[0], constant), nil
+	case OpMul:
+		return eval.MulNew(args[0], args[1]), nil
+	case OpMulRelin:
+		return eval.MulRelinNew(args[0], args[1]), nil
+	case OpRelinearize:
+		return eval.RelinearizeNew(args[0]), nil
+	case OpRescale:
+		ctOut = args[0].CopyNew()
+		return ctOut, eval.Rescale(ctOut, node.MinScale, ctOut)
+	case OpRotate:
+		return eval.RotateNew(args[0], node.K), nil
+	case OpConjugate:
+		return eval.ConjugateNew(args[0]), nil
+	case OpDropLevel:
+		return eval.DropLevelNew(args[0], node.K), nil
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", node.Op)
+}
@@ -0,0 +1,307 @@
+package circuit
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// recorderState is the state shared by a Recorder and its shallow copies.
+type recorderState struct {
+	sync.Mutex
+	circuit Circuit
+	nodes   map[*ckks.Ciphertext]int
+	err     error
+}
+
+// Recorder is a ckks.Evaluator that evaluates the operations with the wrapped Evaluator and records them in a
+// Circuit. The recorded operations are Add, Sub, Neg, AddConst, MultByConst, Mul, MulRelin, Relinearize, Rescale,
+// Rotate, Conjugate and DropLevel (and their New variants), on ciphertext operands only. The other methods are the
+// ones of the wrapped Evaluator and are not recorded: a ciphertext that they modify must not be used afterward as
+// the operand of a recorded operation.
+//
+// The operands of the recorded operations must be registered with Input or be outputs of recorded operations,
+// otherwise the recording fails (see Circuit).
+type Recorder struct {
+	ckks.Evaluator
+	state *recorderState
+}
+
+// NewRecorder creates a new Recorder wrapping eval.
+func NewRecorder(eval ckks.Evaluator) *Recorder {
+	return &Recorder{Evaluator: eval, state: &recorderState{nodes: make(map[*ckks.Ciphertext]int)}}
+}
+
+// ShallowCopy creates a shallow copy of this Recorder, which records in the same Circuit.
+func (rec *Recorder) ShallowCopy() ckks.Evaluator {
+	return &Recorder{Evaluator: rec.Evaluator.ShallowCopy(), state: rec.state}
+}
+
+// Input registers ct as the next input of the circuit.
+func (rec *Recorder) Input(ct *ckks.Ciphertext) {
+	rec.state.Lock()
+	defer rec.state.Unlock()
+	rec.state.circuit.Inputs = append(rec.state.circuit.Inputs, len(rec.state.circuit.Nodes))
+	rec.state.add(Node{Op: OpInput}, ct)
+}
+
+// Output registers the current value of ct as the next output of the circuit.
+func (rec *Recorder) Output(ct *ckks.Ciphertext) {
+	rec.state.Lock()
+	defer rec.state.Unlock()
+	if i, ok := rec.state.nodes[ct]; ok {
+		rec.state.circuit.Outputs = append(rec.state.circuit.Outputs, i)
+	} else if rec.state.err == nil {
+		rec.state.err = errors.New("cannot Output: the ciphertext is neither an input nor the output of a recorded operation")
+	}
+}
+
+// Circuit returns a copy of the circuit recorded so far, or the first error encountered during the recording.
+func (rec *Recorder) Circuit() (*Circuit, error) {
+	rec.state.Lock()
+	defer rec.state.Unlock()
+	if rec.state.err != nil {
+		return nil, rec.state.err
+	}
+	return rec.state.circuit.CopyNew(), nil
+}
+
+// add appends node to the circuit as the current value of ctOut.
+func (state *recorderState) add(node Node, ctOut *ckks.Ciphertext) {
+	node.Level = ctOut.Level()
+	node.Scale = ctOut.Scale()
+	node.Degree = ctOut.Degree()
+	state.nodes[ctOut] = len(state.circuit.Nodes)
+	state.circuit.Nodes = append(state.circuit.Nodes, node)
+}
+
+// record appends to the circuit the operation node on the operands ops, whose result is ctOut. It must be called
+// after the evaluation of the operation.
+func (rec *Recorder) record(node Node, ctOut *ckks.Ciphertext, ops ...ckks.Operand) {
+
+	rec.state.Lock()
+	defer rec.state.Unlock()
+
+	if rec.state.err != nil {
+		return
+	}
+
+	for _, op := range ops {
+
+		ct, ok := op.(*ckks.Ciphertext)
+		if !ok {
+			rec.state.err = fmt.Errorf("cannot record %s: the operands must be ciphertexts", node.Op)
+			return
+		}
+
+		i, ok := rec.state.nodes[ct]
+		if !ok {
+			rec.state.err = fmt.Errorf("cannot record %s: an operand is neither an input nor the output of a recorded operation", node.Op)
+			return
+		}
+
+		node.Args = append(node.Args, i)
+	}
+
+	rec.state.add(node, ctOut)
+}
+
+// recordConst records the operation op on ctIn with the given constant, whose result is ctOut.
+func (rec *Recorder) recordConst(op Operation, ctIn *ckks.Ciphertext, constant interface{}, ctOut *ckks.Ciphertext) {
+
+	var c complex128
+
+	switch constant := constant.(type) {
+	case complex128:
+		c = constant
+	case float64:
+		c = complex(constant, 0)
+	case *big.Float:
+		f, _ := constant.Float64()
+		c = complex(f, 0)
+	case *ring.Complex:
+		re, _ := constant.Real().Float64()
+		im, _ := constant.Imag().Float64()
+		c = complex(re, im)
+	case uint64:
+		c = complex(float64(constant), 0)
+	case int64:
+		c = complex(float64(constant), 0)
+	case int:
+		c = complex(float64(constant), 0)
+	default:
+		rec.state.Lock()
+		if rec.state.err == nil {
+			rec.state.err = fmt.Errorf("cannot record %s: invalid constant type %T", op, constant)
+		}
+		rec.state.Unlock()
+		return
+	}
+
+	rec.record(Node{Op: op, ConstReal: real(c), ConstImag: imag(c)}, ctOut, ctIn)
+}
+
+// Add adds op0 to op1 and returns the result in ctOut.
+func (rec *Recorder) Add(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.Add(op0, op1, ctOut)
+	rec.record(Node{Op: OpAdd}, ctOut, op0, op1)
+}
+
+// AddNew adds op0 to op1 and returns the result in a newly created element.
+func (rec *Recorder) AddNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.AddNew(op0, op1)
+	rec.record(Node{Op: OpAdd}, ctOut, op0, op1)
+	return
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut.
+func (rec *Recorder) Sub(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.Sub(op0, op1, ctOut)
+	rec.record(Node{Op: OpSub}, ctOut, op0, op1)
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a newly created element.
+func (rec *Recorder) SubNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.SubNew(op0, op1)
+	rec.record(Node{Op: OpSub}, ctOut, op0, op1)
+	return
+}
+
+// Neg negates ctIn and returns the result in ctOut.
+func (rec *Recorder) Neg(ctIn *ckks.Ciphertext, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.Neg(ctIn, ctOut)
+	rec.record(Node{Op: OpNeg}, ctOut, ctIn)
+}
+
+// NegNew negates ctIn and returns the result in a newly created element.
+func (rec *Recorder) NegNew(ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.NegNew(ctIn)
+	rec.record(Node{Op: OpNeg}, ctOut, ctIn)
+	return
+}
+
+// AddConst adds the input constant to ctIn and returns the result in ctOut. The constant is recorded as a complex128.
+func (rec *Recorder) AddConst(ctIn *ckks.Ciphertext, constant interface{}, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.AddConst(ctIn, constant, ctOut)
+	rec.recordConst(OpAddConst, ctIn, constant, ctOut)
+}
+
+// AddConstNew adds the input constant to ctIn and returns the result in a newly created element. The constant is
+// recorded as a complex128.
+func (rec *Recorder) AddConstNew(ctIn *ckks.Ciphertext, constant interface{}) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.AddConstNew(ctIn, constant)
+	rec.recordConst(OpAddConst, ctIn, constant, ctOut)
+	return
+}
+
+// MultByConst multiplies ctIn by the input constant and returns the result in ctOut. The constant is recorded as
+// a complex128.
+func (rec *Recorder) MultByConst(ctIn *ckks.Ciphertext, constant interface{}, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.MultByConst(ctIn, constant, ctOut)
+	rec.recordConst(OpMultByConst, ctIn, constant, ctOut)
+}
+
+// MultByConstNew multiplies ctIn by the input constant and returns the result in a newly created element. The
+// constant is recorded as a complex128.
+func (rec *Recorder) MultByConstNew(ctIn *ckks.Ciphertext, constant interface{}) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.MultByConstNew(ctIn, constant)
+	rec.recordConst(OpMultByConst, ctIn, constant, ctOut)
+	return
+}
+
+// Mul multiplies op0 by op1 without relinearization and returns the result in ctOut.
+func (rec *Recorder) Mul(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.Mul(op0, op1, ctOut)
+	rec.record(Node{Op: OpMul}, ctOut, op0, op1)
+}
+
+// MulNew multiplies op0 by op1 without relinearization and returns the result in a newly created element.
+func (rec *Recorder) MulNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.MulNew(op0, op1)
+	rec.record(Node{Op: OpMul}, ctOut, op0, op1)
+	return
+}
+
+// MulRelin multiplies op0 by op1 with relinearization and returns the result in ctOut.
+func (rec *Recorder) MulRelin(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.MulRelin(op0, op1, ctOut)
+	rec.record(Node{Op: OpMulRelin}, ctOut, op0, op1)
+}
+
+// MulRelinNew multiplies op0 by op1 with relinearization and returns the result in a newly created element.
+func (rec *Recorder) MulRelinNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.MulRelinNew(op0, op1)
+	rec.record(Node{Op: OpMulRelin}, ctOut, op0, op1)
+	return
+}
+
+// Relinearize relinearizes ctIn and returns the result in ctOut.
+func (rec *Recorder) Relinearize(ctIn *ckks.Ciphertext, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.Relinearize(ctIn, ctOut)
+	rec.record(Node{Op: OpRelinearize}, ctOut, ctIn)
+}
+
+// RelinearizeNew relinearizes ctIn and returns the result in a newly created element.
+func (rec *Recorder) RelinearizeNew(ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.RelinearizeNew(ctIn)
+	rec.record(Node{Op: OpRelinearize}, ctOut, ctIn)
+	return
+}
+
+// Rescale divides ctIn by the last moduli of the moduli chain as long as the scale stays above minScale, and
+// returns the result in ctOut.
+func (rec *Recorder) Rescale(ctIn *ckks.Ciphertext, minScale float64, ctOut *ckks.Ciphertext) (err error) {
+	if err = rec.Evaluator.Rescale(ctIn, minScale, ctOut); err != nil {
+		rec.state.Lock()
+		if rec.state.err == nil {
+			rec.state.err = fmt.Errorf("cannot record Rescale: %w", err)
+		}
+		rec.state.Unlock()
+		return
+	}
+	rec.record(Node{Op: OpRescale, MinScale: minScale}, ctOut, ctIn)
+	return
+}
+
+// Rotate rotates the slots of ctIn by k positions to the left and returns the result in ctOut.
+func (rec *Recorder) Rotate(ctIn *ckks.Ciphertext, k int, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.Rotate(ctIn, k, ctOut)
+	rec.record(Node{Op: OpRotate, K: k}, ctOut, ctIn)
+}
+
+// RotateNew rotates the slots of ctIn by k positions to the left and returns the result in a newly created element.
+func (rec *Recorder) RotateNew(ctIn *ckks.Ciphertext, k int) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.RotateNew(ctIn, k)
+	rec.record(Node{Op: OpRotate, K: k}, ctOut, ctIn)
+	return
+}
+
+// Conjugate conjugates the slots of ctIn and returns the result in ctOut.
+func (rec *Recorder) Conjugate(ctIn *ckks.Ciphertext, ctOut *ckks.Ciphertext) {
+	rec.Evaluator.Conjugate(ctIn, ctOut)
+	rec.record(Node{Op: OpConjugate}, ctOut, ctIn)
+}
+
+// ConjugateNew conjugates the slots of ctIn and returns the result in a newly created element.
+func (rec *Recorder) ConjugateNew(ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.ConjugateNew(ctIn)
+	rec.record(Node{Op: OpConjugate}, ctOut, ctIn)
+	return
+}
+
+// DropLevel reduces the level of ctIn by levels.
+func (rec *Recorder) DropLevel(ctIn *ckks.Ciphertext, levels int) {
+	rec.Evaluator.DropLevel(ctIn, levels)
+	rec.record(Node{Op: OpDropLevel, K: levels}, ctIn, ctIn)
+}
+
+// DropLevelNew reduces the level of ctIn by levels and returns the result in a newly created element.
+func (rec *Recorder) DropLevelNew(ctIn *ckks.Ciphertext, levels int) (ctOut *ckks.Ciphertext) {
+	ctOut = rec.Evaluator.DropLevelNew(ctIn, levels)
+	rec.record(Node{Op: OpDropLevel, K: levels}, ctOut, ctIn)
+	return
+}
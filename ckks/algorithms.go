@@ -1,7 +1,10 @@
 package ckks
 
 import (
+	"math"
 	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
 // PowerOf2 computes op^(2^logPow2), consuming logPow2 levels, and returns the result on opOut. Providing an evaluation
@@ -65,12 +68,14 @@ func (eval *evaluator) Power(op *Ciphertext, degree int, opOut *Ciphertext) {
 		logDegree = bits.Len64(uint64(degree)) - 1
 		po2Degree = 1 << logDegree
 
-		tmp := NewCiphertext(eval.params, 1, tmpct0.Level(), tmpct0.Scale())
+		tmp := newCiphertextFromPool(eval.params, 1, tmpct0.Level(), tmpct0.Scale())
 
 		eval.PowerOf2(tmpct0, logDegree, tmp)
 
 		eval.MulRelin(opOut.El(), tmp.El(), opOut)
 
+		tmp.Release()
+
 		if err := eval.Rescale(opOut, eval.scale, opOut); err != nil {
 			panic(err)
 		}
@@ -111,3 +116,70 @@ func (eval *evaluator) InverseNew(op *Ciphertext, steps int) (opOut *Ciphertext)
 
 	return opOut
 }
+
+// WeightedSumNew computes sum_i weights[i] * cts[i] and returns the result on a new element, consuming a single level.
+// The input ciphertexts can be at different levels and scales: all the terms are computed at the smallest level among
+// the inputs and the weights are scaled such that every term ends up with the same scale, so that the sum is rescaled
+// only once. The output scale is the largest scale among the inputs.
+// The procedure will panic if len(cts) != len(weights), if len(cts) == 0 or if the smallest level is 0.
+func (eval *evaluator) WeightedSumNew(cts []*Ciphertext, weights []float64) (ctOut *Ciphertext) {
+
+	if len(cts) == 0 {
+		panic("cannot WeightedSumNew: no input ciphertext")
+	}
+
+	if len(cts) != len(weights) {
+		panic("cannot WeightedSumNew: number of ciphertexts and weights do not match")
+	}
+
+	level, degree, scale := cts[0].Level(), cts[0].Degree(), cts[0].Scale()
+	for _, ct := range cts[1:] {
+		level = utils.MinInt(level, ct.Level())
+		degree = utils.MaxInt(degree, ct.Degree())
+		scale = math.Max(scale, ct.Scale())
+	}
+
+	if level == 0 {
+		panic("cannot WeightedSumNew: inputs must be at a level greater than 0")
+	}
+
+	// Each term cts[i] * weights[i] is scaled to scale * Q[level], which is always at least
+	// the scale cts[i] would have after the multiplication by a (rational) constant.
+	ctOut = NewCiphertext(eval.params, degree, level, scale*float64(eval.ringQ.Modulus[level]))
+
+	// ctOut starts as an encryption of zero, hence it is real until a non-real term is added.
+	ctOut.MetaData.IsReal = true
+
+	for i := range cts {
+		eval.MultByConstAndAdd(cts[i], weights[i], ctOut)
+	}
+
+	if err := eval.Rescale(ctOut, scale, ctOut); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// WeightedAverageNew computes sum_i weights[i] * cts[i] / sum_i weights[i] and returns the result on a new element,
+// consuming a single level. Since the weights are public, the renormalization by their sum is folded into the weights
+// and does not require an (approximate) homomorphic inversion.
+// The procedure will panic if the sum of the weights is zero, or under the same conditions as WeightedSumNew.
+func (eval *evaluator) WeightedAverageNew(cts []*Ciphertext, weights []float64) (ctOut *Ciphertext) {
+
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+
+	if sum == 0 {
+		panic("cannot WeightedAverageNew: sum of the weights is zero")
+	}
+
+	normalized := make([]float64, len(weights))
+	for i := range weights {
+		normalized[i] = weights[i] / sum
+	}
+
+	return eval.WeightedSumNew(cts, normalized)
+}
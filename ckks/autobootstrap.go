@@ -0,0 +1,100 @@
+package ckks
+
+import (
+	"math"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// AutoBootstrapEvaluator is a LevelGuardEvaluator that refreshes its inputs by bootstrapping them, so that
+// circuits of arbitrary depth can be evaluated without manual level accounting:
+//
+//   - Before an operation consuming levels (see LevelGuardEvaluator), each Ciphertext operand whose level is
+//     lower than the depth of the operation plus one is replaced by a bootstrapped copy. The extra level is kept
+//     for the bootstrapping, which uses it to match the scale of its input.
+//   - Mul, MulRelin and MultByConst rescale their output as long as its scale stays above half of the default
+//     scale of the parameters, and the bootstrapped ciphertexts are brought back to the default scale of the
+//     parameters if the bootstrapping changed it.
+//
+// The Bootstrapper is shared with the shallow copies of the AutoBootstrapEvaluator, which must therefore not be
+// used concurrently.
+type AutoBootstrapEvaluator struct {
+	*LevelGuardEvaluator
+	params       Parameters
+	bootstrapper *Bootstrapper
+	bootstraps   *int
+}
+
+// NewAutoBootstrapEvaluator creates a new AutoBootstrapEvaluator wrapping eval, which bootstraps the ciphertexts
+// with btp. eval must hold the relinearization key and the keys of the circuit, btp the bootstrapping keys.
+func NewAutoBootstrapEvaluator(params Parameters, eval Evaluator, btp *Bootstrapper) *AutoBootstrapEvaluator {
+	if btp == nil {
+		panic("cannot NewAutoBootstrapEvaluator: the Bootstrapper is nil")
+	}
+	return newAutoBootstrapEvaluator(params, eval, btp, new(int))
+}
+
+func newAutoBootstrapEvaluator(params Parameters, eval Evaluator, btp *Bootstrapper, bootstraps *int) *AutoBootstrapEvaluator {
+	abe := &AutoBootstrapEvaluator{params: params, bootstrapper: btp, bootstraps: bootstraps}
+	abe.LevelGuardEvaluator = &LevelGuardEvaluator{
+		Evaluator: eval,
+		minLevel:  1,
+		refresh:   func(ct *Ciphertext, depth int) *Ciphertext { return abe.Bootstrap(ct) },
+		rescale:   abe.rescale,
+	}
+	return abe
+}
+
+// Bootstraps returns the number of bootstrappings performed so far by the AutoBootstrapEvaluator and its
+// shallow copies.
+func (eval *AutoBootstrapEvaluator) Bootstraps() int {
+	return *eval.bootstraps
+}
+
+// ShallowCopy creates a shallow copy of this AutoBootstrapEvaluator in which all the read-only data-structures
+// are shared with the receiver and the temporary buffers of the wrapped Evaluator are reallocated.
+func (eval *AutoBootstrapEvaluator) ShallowCopy() Evaluator {
+	return newAutoBootstrapEvaluator(eval.params, eval.LevelGuardEvaluator.Evaluator.ShallowCopy(), eval.bootstrapper, eval.bootstraps)
+}
+
+// WithKey creates a shallow copy of this AutoBootstrapEvaluator in which the read-only data-structures are
+// shared with the receiver but the EvaluationKey of the wrapped Evaluator is evaluationKey.
+func (eval *AutoBootstrapEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return newAutoBootstrapEvaluator(eval.params, eval.LevelGuardEvaluator.Evaluator.WithKey(evaluationKey), eval.bootstrapper, eval.bootstraps)
+}
+
+// WithRotationKeyProvider creates a shallow copy of this AutoBootstrapEvaluator in which the read-only
+// data-structures are shared with the receiver but the rotation keys of the wrapped Evaluator are provided by rtkp.
+func (eval *AutoBootstrapEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	return newAutoBootstrapEvaluator(eval.params, eval.LevelGuardEvaluator.Evaluator.WithRotationKeyProvider(rtkp), eval.bootstrapper, eval.bootstraps)
+}
+
+// Bootstrap returns a bootstrapped copy of ctIn at the default scale of the parameters.
+func (eval *AutoBootstrapEvaluator) Bootstrap(ctIn *Ciphertext) (ctOut *Ciphertext) {
+
+	checkNoSlotScales("Bootstrap", ctIn.El())
+
+	ctOut = eval.bootstrapper.Bootstrapp(ctIn.CopyNew())
+	ctOut.MetaData.IsReal = ctIn.MetaData.IsReal
+	ctOut.MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
+	*eval.bootstraps++
+
+	if math.Abs(ctOut.Scale()-eval.params.Scale()) > autoScaleTolerance*eval.params.Scale() {
+		if ctOut.Level() == 0 {
+			panic("cannot Bootstrap: the bootstrapped ciphertext has no level left to restore the default scale")
+		}
+		eval.LevelGuardEvaluator.Evaluator.SetScale(ctOut, eval.params.Scale())
+	}
+
+	return
+}
+
+// rescale rescales ct in place as long as its scale stays above half of the default scale.
+func (eval *AutoBootstrapEvaluator) rescale(ct *Ciphertext) {
+	if ct.Level() == 0 || ct.Scale()/float64(eval.params.Q()[ct.Level()]) < eval.params.Scale()/2 {
+		return
+	}
+	if err := eval.LevelGuardEvaluator.Evaluator.Rescale(ct, eval.params.Scale(), ct); err != nil {
+		panic(err)
+	}
+}
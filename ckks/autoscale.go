@@ -0,0 +1,195 @@
+package ckks
+
+import (
+	"math"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// autoScaleTolerance is the relative tolerance under which two scales are considered equal.
+const autoScaleTolerance = 1.0 / (1 << 40)
+
+// AutoScaleEvaluator is an Evaluator that manages the scales of the ciphertexts automatically, following
+// the waterline approach of the EVA compiler:
+//
+//   - Mul, MulRelin and MultByConst rescale their output as long as its scale stays above waterline/2.
+//   - Add and Sub align the scale of the operand with the smallest scale on the largest one before the evaluation.
+//     If the ratio between the scales is an integer, the alignment is a multiplication by this integer and does not
+//     consume any level. Else the operand is multiplied by the ratio and rescaled, which consumes one level.
+//
+// If the operand with the smallest scale cannot be aligned (e.g. it is a Plaintext or is at level 0), the operand
+// with the largest scale is multiplied by the inverse ratio and rescaled instead. The inputs are never modified by
+// the alignment. All the other methods are the ones of the wrapped Evaluator.
+type AutoScaleEvaluator struct {
+	Evaluator
+	params    Parameters
+	waterline float64
+}
+
+// NewAutoScaleEvaluator creates a new AutoScaleEvaluator wrapping eval. The waterline is the minimum scale
+// that the ciphertexts are allowed to reach when rescaled, it is usually the default scale of the parameters.
+func NewAutoScaleEvaluator(params Parameters, eval Evaluator, waterline float64) *AutoScaleEvaluator {
+	if waterline <= 0 {
+		panic("cannot NewAutoScaleEvaluator: waterline must be positive")
+	}
+	return &AutoScaleEvaluator{Evaluator: eval, params: params, waterline: waterline}
+}
+
+// Waterline returns the minimum scale of the ciphertexts after a rescaling.
+func (eval *AutoScaleEvaluator) Waterline() float64 {
+	return eval.waterline
+}
+
+// ShallowCopy creates a shallow copy of this AutoScaleEvaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated.
+func (eval *AutoScaleEvaluator) ShallowCopy() Evaluator {
+	return &AutoScaleEvaluator{Evaluator: eval.Evaluator.ShallowCopy(), params: eval.params, waterline: eval.waterline}
+}
+
+// WithKey creates a shallow copy of this AutoScaleEvaluator in which the read-only data-structures are
+// shared with the receiver but the EvaluationKey is evaluationKey.
+func (eval *AutoScaleEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return &AutoScaleEvaluator{Evaluator: eval.Evaluator.WithKey(evaluationKey), params: eval.params, waterline: eval.waterline}
+}
+
+// WithRotationKeyProvider creates a shallow copy of this AutoScaleEvaluator in which the read-only data-structures
+// are shared with the receiver but the rotation keys are provided by rtkp.
+func (eval *AutoScaleEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	return &AutoScaleEvaluator{Evaluator: eval.Evaluator.WithRotationKeyProvider(rtkp), params: eval.params, waterline: eval.waterline}
+}
+
+// Add adds op0 to op1 after aligning their scales and returns the result in ctOut.
+func (eval *AutoScaleEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.alignScales(op0, op1)
+	eval.Evaluator.Add(op0, op1, ctOut)
+}
+
+// AddNew adds op0 to op1 after aligning their scales and returns the result in a newly created element.
+func (eval *AutoScaleEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	op0, op1 = eval.alignScales(op0, op1)
+	return eval.Evaluator.AddNew(op0, op1)
+}
+
+// Sub subtracts op1 from op0 after aligning their scales and returns the result in ctOut.
+func (eval *AutoScaleEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.alignScales(op0, op1)
+	eval.Evaluator.Sub(op0, op1, ctOut)
+}
+
+// SubNew subtracts op1 from op0 after aligning their scales and returns the result in a newly created element.
+func (eval *AutoScaleEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	op0, op1 = eval.alignScales(op0, op1)
+	return eval.Evaluator.SubNew(op0, op1)
+}
+
+// Mul multiplies op0 with op1 without relinearization, rescales the result and returns it in ctOut.
+func (eval *AutoScaleEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Evaluator.Mul(op0, op1, ctOut)
+	eval.rescale(ctOut)
+}
+
+// MulNew multiplies op0 with op1 without relinearization, rescales the result and returns it in a newly created element.
+func (eval *AutoScaleEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.Evaluator.MulNew(op0, op1)
+	eval.rescale(ctOut)
+	return
+}
+
+// MulRelin multiplies op0 with op1 with relinearization, rescales the result and returns it in ctOut.
+func (eval *AutoScaleEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Evaluator.MulRelin(op0, op1, ctOut)
+	eval.rescale(ctOut)
+}
+
+// MulRelinNew multiplies op0 with op1 with relinearization, rescales the result and returns it in a newly created element.
+func (eval *AutoScaleEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.Evaluator.MulRelinNew(op0, op1)
+	eval.rescale(ctOut)
+	return
+}
+
+// MultByConst multiplies ctIn by the input constant, rescales the result and returns it in ctOut.
+func (eval *AutoScaleEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	eval.Evaluator.MultByConst(ctIn, constant, ctOut)
+	eval.rescale(ctOut)
+}
+
+// MultByConstNew multiplies ctIn by the input constant, rescales the result and returns it in a newly created element.
+func (eval *AutoScaleEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	ctOut = eval.Evaluator.MultByConstNew(ctIn, constant)
+	eval.rescale(ctOut)
+	return
+}
+
+// rescale rescales ct in place as long as its scale stays above waterline/2.
+func (eval *AutoScaleEvaluator) rescale(ct *Ciphertext) {
+	if ct.Level() == 0 || ct.Scale()/float64(eval.params.Q()[ct.Level()]) < eval.waterline/2 {
+		return
+	}
+	if err := eval.Evaluator.Rescale(ct, eval.waterline, ct); err != nil {
+		panic(err)
+	}
+}
+
+// alignScales returns op0 and op1 with one of the operands replaced by a new ciphertext encrypting the same
+// message at the scale of the other operand. The operand of smallest scale is aligned on the other one, unless
+// this requires a level that it does not have, in which case the operand of largest scale is aligned instead.
+func (eval *AutoScaleEvaluator) alignScales(op0, op1 Operand) (Operand, Operand) {
+
+	if math.Abs(op0.Scale()-op1.Scale()) <= autoScaleTolerance*utils.MaxFloat64(op0.Scale(), op1.Scale()) {
+		return op0, op1
+	}
+
+	swapped := op0.Scale() > op1.Scale()
+	if swapped {
+		op0, op1 = op1, op0
+	}
+
+	ctLow, isCtLow := op0.(*Ciphertext)
+	ctHigh, isCtHigh := op1.(*Ciphertext)
+
+	ratio := op1.Scale() / op0.Scale()
+
+	switch {
+	case isCtLow && math.Abs(ratio-math.Round(ratio)) <= autoScaleTolerance*ratio:
+		op0 = eval.scaleUpByInteger(ctLow, math.Round(ratio), op1)
+	case isCtLow && utils.MinInt(ctLow.Level(), op1.Level()+1) > 0:
+		op0 = eval.rescaleTo(ctLow, op1.Scale(), utils.MinInt(ctLow.Level(), op1.Level()+1))
+	case isCtHigh && utils.MinInt(ctHigh.Level(), op0.Level()+1) > 0:
+		op1 = eval.rescaleTo(ctHigh, op0.Scale(), utils.MinInt(ctHigh.Level(), op0.Level()+1))
+	default:
+		panic("cannot align scales: the ratio between the scales is not an integer and no Ciphertext operand has a level left")
+	}
+
+	if swapped {
+		return op1, op0
+	}
+
+	return op0, op1
+}
+
+// scaleUpByInteger returns a new ciphertext encrypting the message of ct at the scale of target, given
+// that the ratio between the two scales is the integer factor. It does not consume any level.
+func (eval *AutoScaleEvaluator) scaleUpByInteger(ct *Ciphertext, factor float64, target Operand) *Ciphertext {
+	ctOut := NewCiphertext(eval.params, ct.Degree(), utils.MinInt(ct.Level(), target.Level()), ct.Scale())
+	eval.Evaluator.MultByConst(ct, factor, ctOut)
+	ctOut.SetScale(target.Scale())
+	return ctOut
+}
+
+// rescaleTo returns a new ciphertext at the given level minus one encrypting the message of ct at the given scale.
+func (eval *AutoScaleEvaluator) rescaleTo(ct *Ciphertext, scale float64, level int) *Ciphertext {
+
+	// Multiplies by the ratio scaled by qi and declares the scale as the target scale times qi, so that the
+	// rescaling by qi lands exactly on the target scale.
+	ctOut := NewCiphertext(eval.params, ct.Degree(), level, ct.Scale())
+	eval.Evaluator.MultByConst(ct, scale/ct.Scale(), ctOut)
+	ctOut.SetScale(scale * float64(eval.params.Q()[level]))
+	if err := eval.Evaluator.Rescale(ctOut, scale, ctOut); err != nil {
+		panic(err)
+	}
+	ctOut.SetScale(scale)
+
+	return ctOut
+}
@@ -15,6 +15,42 @@ func (btp *Bootstrapper) Bootstrapp(ct *Ciphertext) *Ciphertext {
 	//var t time.Time
 	var ct0, ct1 *Ciphertext
 
+	metadata := ct.Metadata()
+
+	// ModUp ct_{Q_0} -> ct_{Q_L}
+	//t = time.Now()
+	ct = btp.raiseModulus(ct)
+	//log.Println("After ModUp  :", time.Now().Sub(t), ct.Level(), ct.Scale())
+
+	//SubSum X -> (N/dslots) * Y^dslots
+	//t = time.Now()
+	ct = btp.subSum(ct)
+	//log.Println("After SubSum :", time.Now().Sub(t), ct.Level(), ct.Scale())
+	// Part 1 : Coeffs to slots
+
+	//t = time.Now()
+	ct0, ct1 = CoeffsToSlots(ct, btp.pDFTInv, btp.evaluator)
+	//log.Println("After CtS    :", time.Now().Sub(t), ct0.Level(), ct0.Scale())
+
+	// Part 2 : SineEval
+	//t = time.Now()
+	ct0, ct1 = btp.evaluateSine(ct0, ct1)
+	//log.Println("After Sine   :", time.Now().Sub(t), ct0.Level(), ct0.Scale())
+
+	// Part 3 : Slots to coeffs
+	//t = time.Now()
+	ct0 = SlotsToCoeffs(ct0, ct1, btp.pDFT, btp.evaluator)
+
+	ct0.SetScale(math.Exp2(math.Round(math.Log2(ct0.Scale())))) // rounds to the nearest power of two
+	ct0.SetMetadata(metadata)
+	//log.Println("After StC    :", time.Now().Sub(t), ct0.Level(), ct0.Scale())
+	return ct0
+}
+
+// raiseModulus brings the scale of ct to Q0/2^{10}, raises its modulus from Q0 to QL and brings its scale to
+// sineQi/(Q0/scale).
+func (btp *Bootstrapper) raiseModulus(ct *Ciphertext) *Ciphertext {
+
 	// Drops the level to 1
 	for ct.Level() > 1 {
 		btp.evaluator.DropLevel(ct, 1)
@@ -47,46 +83,17 @@ func (btp *Bootstrapper) Bootstrapp(ct *Ciphertext) *Ciphertext {
 	}
 
 	// ModUp ct_{Q_0} -> ct_{Q_L}
-	//t = time.Now()
 	ct = btp.modUp(ct)
-	//log.Println("After ModUp  :", time.Now().Sub(t), ct.Level(), ct.Scale())
 
 	// Brings the ciphertext scale to sineQi/(Q0/scale) if its under
 	btp.evaluator.ScaleUp(ct, math.Round(btp.postscale/ct.Scale()), ct)
 
-	//SubSum X -> (N/dslots) * Y^dslots
-	//t = time.Now()
-	ct = btp.subSum(ct)
-	//log.Println("After SubSum :", time.Now().Sub(t), ct.Level(), ct.Scale())
-	// Part 1 : Coeffs to slots
-
-	//t = time.Now()
-	ct0, ct1 = CoeffsToSlots(ct, btp.pDFTInv, btp.evaluator)
-	//log.Println("After CtS    :", time.Now().Sub(t), ct0.Level(), ct0.Scale())
-
-	// Part 2 : SineEval
-	//t = time.Now()
-	ct0, ct1 = btp.evaluateSine(ct0, ct1)
-	//log.Println("After Sine   :", time.Now().Sub(t), ct0.Level(), ct0.Scale())
-
-	// Part 3 : Slots to coeffs
-	//t = time.Now()
-	ct0 = SlotsToCoeffs(ct0, ct1, btp.pDFT, btp.evaluator)
-
-	ct0.SetScale(math.Exp2(math.Round(math.Log2(ct0.Scale())))) // rounds to the nearest power of two
-	//log.Println("After StC    :", time.Now().Sub(t), ct0.Level(), ct0.Scale())
-	return ct0
+	return ct
 }
 
 func (btp *Bootstrapper) subSum(ct *Ciphertext) *Ciphertext {
 
-	for i := btp.params.LogSlots(); i < btp.params.MaxLogSlots(); i++ {
-
-		btp.evaluator.Rotate(ct, 1<<i, btp.evaluator.ctxpool)
-
-		btp.evaluator.Add(ct, btp.evaluator.ctxpool, ct)
-	}
-
+	btp.evaluator.Trace(ct, btp.params.LogSlots(), btp.params.MaxLogSlots(), ct)
 	return ct
 }
 
@@ -94,7 +101,7 @@ func (btp *Bootstrapper) modUp(ct *Ciphertext) *Ciphertext {
 
 	ringQ := btp.evaluator.ringQ
 
-	ct.InvNTT(ringQ, &ct.Element.Element)
+	ct.InvNTT(ringQ, ct.Element)
 
 	// Extend the ciphertext with zero polynomials.
 	for u := range ct.Value {
@@ -128,7 +135,7 @@ func (btp *Bootstrapper) modUp(ct *Ciphertext) *Ciphertext {
 		}
 	}
 
-	ct.NTT(ringQ, &ct.Element.Element)
+	ct.NTT(ringQ, ct.Element)
 
 	return ct
 }
@@ -194,16 +201,23 @@ func dft(vec *Ciphertext, plainVectors []*PtDiagMatrix, forward bool, eval Evalu
 // Sine Evaluation ct0 = Q/(2pi) * sin((2pi/Q) * ct0)
 func (btp *Bootstrapper) evaluateSine(ct0, ct1 *Ciphertext) (*Ciphertext, *Ciphertext) {
 
-	ct0.MulScale(btp.MessageRatio)
+	var err error
+
 	btp.evaluator.scale = btp.sinescale // Reference scale is changed to the Qi used for the SineEval (which is also close to the new ciphetext scale)
 
-	ct0 = btp.evaluateCheby(ct0)
+	ct0.MulScale(btp.MessageRatio)
+
+	if ct0, err = btp.evalMod.evaluate(btp.evaluator, ct0, btp.SineEvalModuli.Qi); err != nil {
+		panic(err)
+	}
 
 	ct0.DivScale(btp.MessageRatio * btp.postscale / btp.params.Scale())
 
 	if ct1 != nil {
 		ct1.MulScale(btp.MessageRatio)
-		ct1 = btp.evaluateCheby(ct1)
+		if ct1, err = btp.evalMod.evaluate(btp.evaluator, ct1, btp.SineEvalModuli.Qi); err != nil {
+			panic(err)
+		}
 		ct1.DivScale(btp.MessageRatio * btp.postscale / btp.params.Scale())
 	}
 
@@ -212,50 +226,3 @@ func (btp *Bootstrapper) evaluateSine(ct0, ct1 *Ciphertext) (*Ciphertext, *Ciphe
 
 	return ct0, ct1
 }
-
-func (btp *Bootstrapper) evaluateCheby(ct *Ciphertext) *Ciphertext {
-
-	var err error
-
-	cheby := btp.sineEvalPoly
-
-	targetScale := btp.sinescale
-
-	// Compute the scales that the ciphertext should have before the double angle
-	// formula such that after it it has the scale it had before the polynomial
-	// evaluation
-	for i := 0; i < btp.SinRescal; i++ {
-		targetScale = math.Sqrt(targetScale * float64(btp.SineEvalModuli.Qi[i]))
-	}
-
-	// Division by 1/2^r and change of variable for the Chebysehev evaluation
-	if btp.SinType == Cos1 || btp.SinType == Cos2 {
-		btp.AddConst(ct, -0.5/(complex(btp.scFac, 0)*(cheby.b-cheby.a)), ct)
-	}
-
-	// Chebyshev evaluation
-	if ct, err = btp.EvaluateCheby(ct, cheby, targetScale); err != nil {
-		panic(err)
-	}
-
-	// Double angle
-	sqrt2pi := btp.sqrt2pi
-	for i := 0; i < btp.SinRescal; i++ {
-		sqrt2pi *= sqrt2pi
-		btp.MulRelin(ct, ct, ct)
-		btp.Add(ct, ct, ct)
-		btp.AddConst(ct, -sqrt2pi, ct)
-		if err := btp.Rescale(ct, btp.evaluator.scale, ct); err != nil {
-			panic(err)
-		}
-	}
-
-	// ArcSine
-	if btp.ArcSineDeg > 0 {
-		if ct, err = btp.EvaluatePoly(ct, btp.arcSinePoly, ct.Scale()); err != nil {
-			panic(err)
-		}
-	}
-
-	return ct
-}
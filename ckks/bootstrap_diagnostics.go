@@ -0,0 +1,206 @@
+package ckks
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// BootstrappingStageStats stores the precision of a stage of the bootstrapping.
+type BootstrappingStageStats struct {
+	Stage    string  // Name of the stage
+	Level    int     // Level of the output of the stage
+	LogScale float64 // Log2 of the scale of the output of the stage
+
+	// Precision is the precision of the output of the stage, compared to the stage evaluated in the clear on the
+	// decryption of its actual input, so that it measures the error introduced by the stage alone. The values are
+	// expressed in units of the scale of the message, the real (resp. imaginary) part of the i-th value being
+	// the coefficient of the i-th (resp. i+N/2-th) reduced coefficient.
+	Precision PrecisionStats
+}
+
+// BootstrappingDiagnostics stores the measurements of Bootstrapper.Diagnose.
+type BootstrappingDiagnostics struct {
+	// Stages are the stats of the stages CoeffsToSlots (including the SubSum), EvalMod and SlotsToCoeffs.
+	Stages []BootstrappingStageStats
+
+	// Precision is the end-to-end precision of the bootstrapping.
+	Precision PrecisionStats
+
+	// Coefficients is the number of coefficients reduced by EvalMod.
+	Coefficients int
+	// SinRange is the bound K of the interval [-K, K] on which EvalMod approximates the modular reduction.
+	SinRange int
+	// ExpectedStd is the expected standard deviation sqrt((H+1)/12) of the inputs of EvalMod, in units of Q0.
+	ExpectedStd float64
+	// MeasuredStd is the measured standard deviation of the inputs of EvalMod, in units of Q0.
+	MeasuredStd float64
+	// MaxInput is the largest absolute value of the inputs of EvalMod, in units of Q0.
+	MaxInput float64
+	// ExpectedFailureProbability is the expected probability that an input of EvalMod is outside of [-K, K].
+	ExpectedFailureProbability float64
+	// Failures is the number of inputs of EvalMod outside of [-K, K], whose coefficient is not correctly reduced.
+	Failures int
+}
+
+func (diag BootstrappingDiagnostics) String() (s string) {
+
+	for _, stage := range diag.Stages {
+		s += fmt.Sprintf("%-13s : level %2d, scale 2^%.2f, AVG Prec (%.2f, %.2f) Log2, MIN Prec (%.2f, %.2f) Log2\n", stage.Stage, stage.Level, stage.LogScale,
+			real(stage.Precision.MeanPrecision), imag(stage.Precision.MeanPrecision), real(stage.Precision.MinPrecision), imag(stage.Precision.MinPrecision))
+	}
+
+	s += fmt.Sprintf("Bootstrapping : AVG Prec (%.2f, %.2f) Log2, MIN Prec (%.2f, %.2f) Log2\n",
+		real(diag.Precision.MeanPrecision), imag(diag.Precision.MeanPrecision), real(diag.Precision.MinPrecision), imag(diag.Precision.MinPrecision))
+	s += fmt.Sprintf("EvalMod input : std %.2f (expected %.2f), max %.2f, K = %d\n", diag.MeasuredStd, diag.ExpectedStd, diag.MaxInput, diag.SinRange)
+	s += fmt.Sprintf("Failures      : %d / %d (expected %.3g)\n", diag.Failures, diag.Coefficients, diag.ExpectedFailureProbability*float64(diag.Coefficients))
+
+	return
+}
+
+// Diagnose bootstraps the encryption under sk of a random vector and measures, on its decryptions, the precision
+// of each stage of the bootstrapping and the distribution of the inputs of the homomorphic modular reduction (EvalMod),
+// whose values outside of [-K, K] are failures of the bootstrapping. The measurements are to be used to tune the
+// BootstrappingParameters: the precision of a stage is measured against the stage evaluated in the clear on the
+// decryption of its actual input, and the expected failure probability assumes inputs distributed as the sum of
+// H+1 uniform variables on [-1/2, 1/2]. The secret key must be the one of the bootstrapping key of the Bootstrapper.
+// Returns an error if the Bootstrapper is bit-reversed.
+func (btp *Bootstrapper) Diagnose(sk *rlwe.SecretKey) (diag *BootstrappingDiagnostics, err error) {
+
+	if sk == nil {
+		return nil, errors.New("cannot Diagnose: the secret key is nil")
+	}
+
+	if btp.BitReversed {
+		return nil, errors.New("cannot Diagnose: bit-reversed bootstrapping is not supported")
+	}
+
+	params := btp.params
+	encoder := btp.encoder
+	decryptor := NewDecryptor(params, sk)
+
+	slots := params.Slots()
+	gap := params.N() / (2 * slots)
+
+	values := make([]complex128, slots)
+	for i := range values {
+		values[i] = utils.RandComplex128(-1, 1)
+	}
+
+	plaintext := NewPlaintext(params, 0, params.Scale())
+	encoder.Encode(plaintext, values, params.LogSlots())
+	ct := NewEncryptorFromSk(params, sk).EncryptNew(plaintext)
+
+	diag = &BootstrappingDiagnostics{
+		Coefficients: 2 * slots,
+		SinRange:     btp.SinRange,
+		ExpectedStd:  math.Sqrt(float64(btp.H+1) / 12),
+	}
+
+	// Index of the coefficient whose value is in the k-th slot of the output of CoeffsToSlots
+	coeffIndex := make([]int, slots)
+	for k := range coeffIndex {
+		coeffIndex[k] = int(utils.BitReverse64(uint64(k), uint64(params.LogSlots()))) * gap
+	}
+
+	// Coefficients of the input, in units of the scale of the message
+	ct = btp.raiseModulus(ct)
+	coeffs := encoder.DecodeCoeffs(decryptor.DecryptNew(ct))
+	q0OverScale := float64(params.Q()[0]) / btp.prescale
+
+	want := make([]complex128, slots)
+	for k, j := range coeffIndex {
+		want[k] = complex(coeffs[j], coeffs[j+params.N()/2])
+	}
+
+	var sum, sumSquares float64
+	for _, c := range want {
+		for _, x := range []float64{real(c) / q0OverScale, imag(c) / q0OverScale} {
+			sum += x
+			sumSquares += x * x
+			diag.MaxInput = math.Max(diag.MaxInput, math.Abs(x))
+			if math.Abs(x) > float64(btp.SinRange) {
+				diag.Failures++
+			}
+		}
+	}
+
+	n := float64(diag.Coefficients)
+	diag.MeasuredStd = math.Sqrt(sumSquares/n - (sum/n)*(sum/n))
+	diag.ExpectedFailureProbability = math.Erfc(float64(btp.SinRange) / (diag.ExpectedStd * math.Sqrt2))
+
+	// CoeffsToSlots, whose output is scaled by (coeffsToSlotsDiffScale^depth) * N
+	ct = btp.subSum(ct)
+	ct0, ct1 := CoeffsToSlots(ct, btp.pDFTInv, btp.evaluator)
+
+	have := btp.decodeCoeffsToSlots(decryptor, ct0, ct1, float64(params.N())*math.Pow(real(btp.coeffsToSlotsDiffScale), float64(btp.CtSDepth(false))))
+	btp.addStage(diag, "CoeffsToSlots", ct0, want, have)
+
+	// EvalMod, whose ideal output is the input reduced modulo Q0 and scaled by slotsToCoeffsDiffScale^-depth
+	for k, c := range have {
+		want[k] = complex(modCenter(real(c), q0OverScale), modCenter(imag(c), q0OverScale))
+	}
+
+	ct0, ct1 = btp.evaluateSine(ct0, ct1)
+
+	have = btp.decodeCoeffsToSlots(decryptor, ct0, ct1, math.Pow(real(btp.slotsToCoeffsDiffScale), -float64(btp.StCDepth(false))))
+	btp.addStage(diag, "EvalMod", ct0, want, have)
+
+	// SlotsToCoeffs, whose ideal output is the decoding of the reduced coefficients
+	for k, j := range coeffIndex {
+		want[j/gap] = have[k]
+	}
+	fft(want, slots, encoder.(*encoderComplex128).m, encoder.(*encoderComplex128).rotGroup, encoder.(*encoderComplex128).roots)
+
+	ct0 = SlotsToCoeffs(ct0, ct1, btp.pDFT, btp.evaluator)
+	ct0.SetScale(math.Exp2(math.Round(math.Log2(ct0.Scale()))))
+
+	have = encoder.Decode(decryptor.DecryptNew(ct0), params.LogSlots())
+	btp.addStage(diag, "SlotsToCoeffs", ct0, want, have)
+
+	diag.Precision = GetPrecisionStats(params, encoder, nil, values, have, params.LogSlots(), 0)
+
+	return diag, nil
+}
+
+// decodeCoeffsToSlots decodes the output of CoeffsToSlots (or of EvalMod) divided by scaling, and returns the vector
+// whose real (resp. imaginary) parts are the values of the first (resp. second) half of the coefficients.
+func (btp *Bootstrapper) decodeCoeffsToSlots(decryptor Decryptor, ct0, ct1 *Ciphertext, scaling float64) (values []complex128) {
+
+	slots := btp.params.Slots()
+
+	values = make([]complex128, slots)
+
+	v0 := btp.encoder.Decode(decryptor.DecryptNew(ct0), btp.logdslots)
+
+	if ct1 == nil {
+		// Sparse packing: the two halves are in the first and the last slots of ct0
+		for k := range values {
+			values[k] = complex(real(v0[k]), real(v0[k+slots])) / complex(scaling, 0)
+		}
+	} else {
+		v1 := btp.encoder.Decode(decryptor.DecryptNew(ct1), btp.logdslots)
+		for k := range values {
+			values[k] = complex(real(v0[k]), real(v1[k])) / complex(scaling, 0)
+		}
+	}
+
+	return
+}
+
+func (btp *Bootstrapper) addStage(diag *BootstrappingDiagnostics, stage string, ct *Ciphertext, want, have []complex128) {
+	diag.Stages = append(diag.Stages, BootstrappingStageStats{
+		Stage:     stage,
+		Level:     ct.Level(),
+		LogScale:  math.Log2(ct.Scale()),
+		Precision: GetPrecisionStats(btp.params, btp.encoder, nil, want, have, btp.params.LogSlots(), 0),
+	})
+}
+
+// modCenter returns x reduced modulo q in [-q/2, q/2].
+func modCenter(x, q float64) float64 {
+	return x - q*math.Round(x/q)
+}
@@ -2,6 +2,7 @@ package ckks
 
 import (
 	"math"
+	"sort"
 
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -373,6 +374,34 @@ func (b *BootstrappingParameters) RotationsForBootstrapping(logSlots int) (rotat
 	return
 }
 
+// GaloisElementsForBootstrapping returns the sorted Galois elements of the rotation keys required by the
+// Bootstrapping operation on ciphertexts of 2^logSlots slots, i.e. the superset of the keys of the SubSum, the
+// CoeffsToSlots and the SlotsToCoeffs, in which the keys shared by the different steps appear only once, and the
+// conjugation key of the CoeffsToSlots. The EvalSine step only requires the relinearization key.
+func (b *BootstrappingParameters) GaloisElementsForBootstrapping(params Parameters, logSlots int) (galEls []uint64) {
+
+	rotations := b.RotationsForBootstrapping(logSlots)
+
+	galEls = make([]uint64, 0, len(rotations)+1)
+	seen := make(map[uint64]bool, len(rotations)+1)
+
+	add := func(galEl uint64) {
+		if !seen[galEl] {
+			seen[galEl] = true
+			galEls = append(galEls, galEl)
+		}
+	}
+
+	for _, k := range rotations {
+		add(params.GaloisElementForColumnRotationBy(k))
+	}
+	add(params.GaloisElementForRowRotation())
+
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	return
+}
+
 // DefaultBootstrapParams are default bootstrapping params for the bootstrapping.
 var DefaultBootstrapParams = []*BootstrappingParameters{
 
@@ -1124,14 +1153,14 @@ func genFFTDiagMatrix(logL, fftLevel int, a, b, c []complex128, forward, bitreve
 	vectors = make(map[int][]complex128)
 
 	if bitreversed {
-		sliceBitReverseInPlaceComplex128(a, 1<<logL)
-		sliceBitReverseInPlaceComplex128(b, 1<<logL)
-		sliceBitReverseInPlaceComplex128(c, 1<<logL)
+		SliceBitReverseInPlaceComplex128(a, 1<<logL)
+		SliceBitReverseInPlaceComplex128(b, 1<<logL)
+		SliceBitReverseInPlaceComplex128(c, 1<<logL)
 
 		if len(a) > 1<<logL {
-			sliceBitReverseInPlaceComplex128(a[1<<logL:], 1<<logL)
-			sliceBitReverseInPlaceComplex128(b[1<<logL:], 1<<logL)
-			sliceBitReverseInPlaceComplex128(c[1<<logL:], 1<<logL)
+			SliceBitReverseInPlaceComplex128(a[1<<logL:], 1<<logL)
+			SliceBitReverseInPlaceComplex128(b[1<<logL:], 1<<logL)
+			SliceBitReverseInPlaceComplex128(c[1<<logL:], 1<<logL)
 		}
 	}
 
@@ -1176,14 +1205,14 @@ func multiplyFFTMatrixWithNextFFTLevel(vec map[int][]complex128, logL, N, nextLe
 	}
 
 	if bitreversed {
-		sliceBitReverseInPlaceComplex128(a, 1<<logL)
-		sliceBitReverseInPlaceComplex128(b, 1<<logL)
-		sliceBitReverseInPlaceComplex128(c, 1<<logL)
+		SliceBitReverseInPlaceComplex128(a, 1<<logL)
+		SliceBitReverseInPlaceComplex128(b, 1<<logL)
+		SliceBitReverseInPlaceComplex128(c, 1<<logL)
 
 		if len(a) > 1<<logL {
-			sliceBitReverseInPlaceComplex128(a[1<<logL:], 1<<logL)
-			sliceBitReverseInPlaceComplex128(b[1<<logL:], 1<<logL)
-			sliceBitReverseInPlaceComplex128(c[1<<logL:], 1<<logL)
+			SliceBitReverseInPlaceComplex128(a[1<<logL:], 1<<logL)
+			SliceBitReverseInPlaceComplex128(b[1<<logL:], 1<<logL)
+			SliceBitReverseInPlaceComplex128(c[1<<logL:], 1<<logL)
 		}
 	}
 
@@ -1,14 +1,17 @@
 package ckks
 
 import (
+	"io/ioutil"
 	"math"
 	"math/cmplx"
+	"os"
 	"runtime"
 	"testing"
 
 	"github.com/ldsec/lattigo/v2/ckks/bettersine"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBootstrap(t *testing.T) {
@@ -51,6 +54,9 @@ func TestBootstrap(t *testing.T) {
 			testCoeffsToSlots,
 			testSlotsToCoeffs,
 			testbootstrap,
+			testBootstrapDiagnose,
+			testAutoBootstrap,
+			testBootstrapRotationKeyStore,
 		} {
 			testSet(testContext, btpParams, t)
 			runtime.GC()
@@ -284,10 +290,10 @@ func testCoeffsToSlots(testContext *testParams, btpParams *BootstrappingParamete
 
 		// Applies the same on the plaintext
 		// Data is not bit-reversed
-		//sliceBitReverseInPlaceComplex128(values, params.Slots())
+		//SliceBitReverseInPlaceComplex128(values, params.Slots())
 		encoder := testContext.encoder.(*encoderComplex128)
 		invfft(values, params.Slots(), encoder.m, encoder.rotGroup, encoder.roots)
-		sliceBitReverseInPlaceComplex128(values, params.Slots())
+		SliceBitReverseInPlaceComplex128(values, params.Slots())
 
 		// Verify the output values, and switch depending on if the original plaintext was sparse or not
 		if params.LogSlots() < params.LogN()-1 {
@@ -363,8 +369,8 @@ func testSlotsToCoeffs(testContext *testParams, btpParams *BootstrappingParamete
 		}
 
 		// Ouputs of the homomorphic FFT^-1 is bit-reversed
-		sliceBitReverseInPlaceComplex128(values0, params.Slots())
-		sliceBitReverseInPlaceComplex128(values1, params.Slots())
+		SliceBitReverseInPlaceComplex128(values0, params.Slots())
+		SliceBitReverseInPlaceComplex128(values1, params.Slots())
 
 		// Encodes and encrypts the test vectors
 		logSlots := params.LogSlots()
@@ -392,9 +398,9 @@ func testSlotsToCoeffs(testContext *testParams, btpParams *BootstrappingParamete
 			}
 		}
 
-		sliceBitReverseInPlaceComplex128(values0, params.Slots())
+		SliceBitReverseInPlaceComplex128(values0, params.Slots())
 		fft(values0, params.Slots(), encoder.m, encoder.rotGroup, encoder.roots)
-		//sliceBitReverseInPlaceComplex128(values0, params.Slots())
+		//SliceBitReverseInPlaceComplex128(values0, params.Slots())
 
 		valuesTest := testContext.encoder.DecodePublic(testContext.decryptor.DecryptNew(res), params.LogSlots(), 0)
 
@@ -450,6 +456,129 @@ func testbootstrap(testContext *testParams, btpParams *BootstrappingParameters,
 	})
 }
 
+func testBootstrapDiagnose(testContext *testParams, btpParams *BootstrappingParameters, t *testing.T) {
+
+	t.Run(testString(testContext, "Bootstrapping/Diagnose/"), func(t *testing.T) {
+
+		params := testContext.params
+
+		rotations := btpParams.RotationsForBootstrapping(params.LogSlots())
+		rotkeys := testContext.kgen.GenRotationKeysForRotations(rotations, true, testContext.sk)
+
+		btp, err := NewBootstrapper(params, btpParams, BootstrappingKey{testContext.rlk, rotkeys})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		diag, err := btp.Diagnose(testContext.sk)
+		require.NoError(t, err)
+
+		require.Len(t, diag.Stages, 3)
+		for _, stage := range diag.Stages {
+			require.Greater(t, real(stage.Precision.MeanPrecision), 15.0, stage.Stage)
+		}
+		require.Greater(t, real(diag.Precision.MeanPrecision), 15.0)
+		require.Equal(t, diag.Stages[2].Level, params.MaxLevel()-btpParams.CtSDepth(true)-btpParams.SineEvalDepth(true)-btpParams.StCDepth(true))
+
+		require.Equal(t, 2*params.Slots(), diag.Coefficients)
+		require.Zero(t, diag.Failures)
+		require.InDelta(t, diag.ExpectedStd, diag.MeasuredStd, 0.2*diag.ExpectedStd)
+		require.LessOrEqual(t, diag.MaxInput, float64(btpParams.SinRange))
+
+		_, err = btp.Diagnose(nil)
+		require.Error(t, err)
+	})
+}
+
+func testAutoBootstrap(testContext *testParams, btpParams *BootstrappingParameters, t *testing.T) {
+
+	t.Run(testString(testContext, "Bootstrapping/AutoBootstrap/"), func(t *testing.T) {
+
+		params := testContext.params
+
+		rotations := btpParams.RotationsForBootstrapping(params.LogSlots())
+		rotkeys := testContext.kgen.GenRotationKeysForRotations(rotations, true, testContext.sk)
+
+		btp, err := NewBootstrapper(params, btpParams, BootstrappingKey{testContext.rlk, rotkeys})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		eval := NewAutoBootstrapEvaluator(params, testContext.evaluator, btp)
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-0.5, -0.5), complex(0.5, 0.5), t)
+
+		// Leaves a single level for the bootstrapping, such that the first multiplication bootstraps its operands
+		eval.DropLevel(ciphertext, ciphertext.Level()-1)
+
+		// Squares more times than there are levels after a bootstrapping
+		squarings := params.MaxLevel() - ciphertext.Level() + 2
+		for i := 0; i < squarings; i++ {
+			ciphertext = eval.MulRelinNew(ciphertext, ciphertext)
+			for j := range values {
+				values[j] *= values[j]
+			}
+		}
+
+		require.GreaterOrEqual(t, eval.Bootstraps(), 1)
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+	})
+}
+
+func testBootstrapRotationKeyStore(testContext *testParams, btpParams *BootstrappingParameters, t *testing.T) {
+
+	t.Run(testString(testContext, "Bootstrapping/RotationKeyStore/"), func(t *testing.T) {
+
+		params := testContext.params
+
+		galEls := btpParams.GaloisElementsForBootstrapping(params, params.LogSlots())
+		require.Len(t, galEls, len(btpParams.RotationsForBootstrapping(params.LogSlots()))+1)
+		for i := 1; i < len(galEls); i++ {
+			require.Less(t, galEls[i-1], galEls[i])
+		}
+
+		dir, err := ioutil.TempDir("", "lattigo-btp-keys")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		store, err := rlwe.NewRotationKeyStore(dir, 4)
+		require.NoError(t, err)
+
+		// Interrupted generation
+		generated, err := GenRotationKeysToStore(testContext.kgen, testContext.sk, galEls[:len(galEls)/2], store)
+		require.NoError(t, err)
+		require.Equal(t, len(galEls)/2, generated)
+
+		_, err = NewBootstrapperWithRotationKeyProvider(params, btpParams, testContext.rlk, store)
+		require.Error(t, err)
+
+		// Resumed generation
+		generated, err = GenRotationKeysToStore(testContext.kgen, testContext.sk, galEls, store)
+		require.NoError(t, err)
+		require.Equal(t, len(galEls)-len(galEls)/2, generated)
+		require.Empty(t, store.MissingGaloisElements(galEls))
+
+		stored, err := store.GaloisElements()
+		require.NoError(t, err)
+		require.Equal(t, galEls, stored)
+
+		btp, err := NewBootstrapperWithRotationKeyProvider(params, btpParams, testContext.rlk, store)
+		require.NoError(t, err)
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		eval := testContext.evaluator
+		for ciphertext.Level() != 0 {
+			eval.DropLevel(ciphertext, 1)
+		}
+
+		ciphertext = btp.Bootstrapp(ciphertext)
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+	})
+}
+
 func newTestVectorsSineBootstrapp(testContext *testParams, btpParams *BootstrappingParameters, encryptor Encryptor, a, b float64, t *testing.T) (values []complex128, plaintext *Plaintext, ciphertext *Ciphertext) {
 
 	logSlots := testContext.params.LogSlots()
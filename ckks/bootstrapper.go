@@ -5,7 +5,6 @@ import (
 	"math"
 	"math/cmplx"
 
-	"github.com/ldsec/lattigo/v2/ckks/bettersine"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 )
@@ -23,20 +22,18 @@ type Bootstrapper struct {
 
 	encoder Encoder // Encoder
 
-	prescale     float64                 // Q[0]/(Q[0]/|m|)
-	postscale    float64                 // Qi sineeval/(Q[0]/|m|)
-	sinescale    float64                 // Qi sineeval
-	sqrt2pi      float64                 // (1/2pi)^{-2^r}
-	scFac        float64                 // 2^{r}
-	sineEvalPoly *ChebyshevInterpolation // Coefficients of the Chebyshev Interpolation of sin(2*pi*x) or cos(2*pi*x/r)
-	arcSinePoly  *Poly                   // Coefficients of the Taylor series of arcsine(x)
+	prescale  float64      // Q[0]/(Q[0]/|m|)
+	postscale float64      // Qi sineeval/(Q[0]/|m|)
+	sinescale float64      // Qi sineeval
+	evalMod   *evalModPoly // Polynomials of the SineEval
 
 	coeffsToSlotsDiffScale complex128      // Matrice rescaling
 	slotsToCoeffsDiffScale complex128      // Matrice rescaling
 	pDFT                   []*PtDiagMatrix // Matrice vectors
 	pDFTInv                []*PtDiagMatrix // Matrice vectors
 
-	rotKeyIndex []int // a list of the required rotation keys
+	rotKeyIndex []int                    // a list of the required rotation keys
+	rtkp        rlwe.RotationKeyProvider // the provider of the rotation keys
 }
 
 func sin2pi2pi(x complex128) complex128 {
@@ -50,17 +47,43 @@ func cos2pi(x complex128) complex128 {
 // NewBootstrapper creates a new Bootstrapper.
 func NewBootstrapper(params Parameters, btpParams *BootstrappingParameters, btpKey BootstrappingKey) (btp *Bootstrapper, err error) {
 
+	var rtkp rlwe.RotationKeyProvider
+	if btpKey.Rtks != nil {
+		rtkp = btpKey.Rtks
+	}
+
+	if btp, err = newBootstrapperWithKeys(params, btpParams, btpKey.Rlk, rtkp); err != nil {
+		return nil, err
+	}
+
+	btp.BootstrappingKey.Rtks = btpKey.Rtks
+
+	return btp, nil
+}
+
+// NewBootstrapperWithRotationKeyProvider creates a new Bootstrapper which queries the rotation keys from rtkp during
+// the Bootstrapping operation instead of holding them in memory, e.g. from an rlwe.RotationKeyStore streaming the keys
+// from the disk. The rotation keys required by rtkp are listed by BootstrappingParameters.GaloisElementsForBootstrapping.
+// The presence of the keys is checked without loading them if rtkp has a method Has(galEl uint64) bool (as the
+// rlwe.RotationKeyStore does), and by querying each of them otherwise.
+func NewBootstrapperWithRotationKeyProvider(params Parameters, btpParams *BootstrappingParameters, rlk *rlwe.RelinearizationKey, rtkp rlwe.RotationKeyProvider) (btp *Bootstrapper, err error) {
+	return newBootstrapperWithKeys(params, btpParams, rlk, rtkp)
+}
+
+func newBootstrapperWithKeys(params Parameters, btpParams *BootstrappingParameters, rlk *rlwe.RelinearizationKey, rtkp rlwe.RotationKeyProvider) (btp *Bootstrapper, err error) {
+
 	if btpParams.SinType == SinType(Sin) && btpParams.SinRescal != 0 {
 		return nil, fmt.Errorf("cannot use double angle formul for SinType = Sin -> must use SinType = Cos")
 	}
 
 	btp = newBootstrapper(params, btpParams)
 
-	btp.BootstrappingKey = &BootstrappingKey{btpKey.Rlk, btpKey.Rtks}
+	btp.BootstrappingKey = &BootstrappingKey{Rlk: rlk}
+	btp.rtkp = rtkp
 	if err = btp.CheckKeys(); err != nil {
 		return nil, fmt.Errorf("invalid bootstrapping key: %w", err)
 	}
-	btp.evaluator = btp.evaluator.WithKey(rlwe.EvaluationKey{Rlk: btpKey.Rlk, Rtks: btpKey.Rtks}).(*evaluator)
+	btp.evaluator = btp.evaluator.WithKey(rlwe.EvaluationKey{Rlk: rlk}).WithRotationKeyProvider(rtkp).(*evaluator)
 
 	return btp, nil
 }
@@ -87,7 +110,11 @@ func newBootstrapper(params Parameters, btpParams *BootstrappingParameters) (btp
 	btp.encoder = NewEncoder(params)
 	btp.evaluator = NewEvaluator(params, rlwe.EvaluationKey{}).(*evaluator) // creates an evaluator without keys for genDFTMatrices
 
-	btp.genSinePoly()
+	var err error
+	if btp.evalMod, err = newEvalModPoly(btpParams.EvalModParameters()); err != nil {
+		panic(err)
+	}
+
 	btp.genDFTMatrices()
 
 	btp.ctxpool = NewCiphertext(params, 1, params.MaxLevel(), 0)
@@ -102,14 +129,23 @@ func (btp *Bootstrapper) CheckKeys() (err error) {
 		return fmt.Errorf("relinearization key is nil")
 	}
 
-	if btp.Rtks == nil {
+	if btp.rtkp == nil {
 		return fmt.Errorf("rotation key is nil")
 	}
 
+	has := func(galEl uint64) bool {
+		_, ok := btp.rtkp.GetRotationKey(galEl)
+		return ok
+	}
+
+	if store, ok := btp.rtkp.(interface{ Has(galEl uint64) bool }); ok {
+		has = store.Has
+	}
+
 	rotMissing := []int{}
 	for _, i := range btp.rotKeyIndex {
 		galEl := btp.params.GaloisElementForColumnRotationBy(int(i))
-		if _, generated := btp.Rtks.Keys[galEl]; !generated {
+		if !has(galEl) {
 			rotMissing = append(rotMissing, i)
 		}
 	}
@@ -118,6 +154,10 @@ func (btp *Bootstrapper) CheckKeys() (err error) {
 		return fmt.Errorf("rotation key(s) missing: %d", rotMissing)
 	}
 
+	if !has(btp.params.GaloisElementForRowRotation()) {
+		return fmt.Errorf("conjugation key missing")
+	}
+
 	return nil
 }
 
@@ -163,13 +203,11 @@ func AddMatrixRotToList(pVec *PtDiagMatrix, rotations []int, slots int, repack b
 
 func (btp *Bootstrapper) genDFTMatrices() {
 
-	a := real(btp.sineEvalPoly.a)
-	b := real(btp.sineEvalPoly.b)
 	n := float64(btp.params.N())
 	qDiff := float64(btp.params.Q()[0]) / math.Exp2(math.Round(math.Log2(float64(btp.params.Q()[0]))))
 
 	// Change of variable for the evaluation of the Chebyshev polynomial + cancelling factor for the DFT and SubSum + evantual scaling factor for the double angle formula
-	btp.coeffsToSlotsDiffScale = complex(math.Pow(2.0/((b-a)*n*btp.scFac*qDiff), 1.0/float64(btp.CtSDepth(false))), 0)
+	btp.coeffsToSlotsDiffScale = complex(math.Pow(btp.evalMod.changeOfVariable()/(n*qDiff), 1.0/float64(btp.CtSDepth(false))), 0)
 
 	// Rescaling factor to set the final ciphertext to the desired scale
 	btp.slotsToCoeffsDiffScale = complex(math.Pow((qDiff*btp.params.Scale())/btp.postscale, 1.0/float64(btp.StCDepth(false))), 0)
@@ -200,56 +238,3 @@ func (btp *Bootstrapper) genDFTMatrices() {
 		btp.rotKeyIndex = AddMatrixRotToList(pVec, btp.rotKeyIndex, btp.params.Slots(), (i == 0) && (btp.params.LogSlots() < btp.params.MaxLogSlots()))
 	}
 }
-
-func (btp *Bootstrapper) genSinePoly() {
-
-	K := int(btp.SinRange)
-	deg := int(btp.SinDeg)
-	btp.scFac = float64(int(1 << btp.SinRescal))
-
-	if btp.ArcSineDeg > 0 {
-		btp.sqrt2pi = 1.0
-
-		coeffs := make([]complex128, btp.ArcSineDeg+1)
-
-		coeffs[1] = 0.15915494309189535
-
-		for i := 3; i < btp.ArcSineDeg+1; i += 2 {
-
-			coeffs[i] = coeffs[i-2] * complex(float64(i*i-4*i+4)/float64(i*i-i), 0)
-
-		}
-
-		btp.arcSinePoly = NewPoly(coeffs)
-
-	} else {
-		btp.sqrt2pi = math.Pow(0.15915494309189535, 1.0/btp.scFac)
-	}
-
-	if btp.SinType == Sin {
-
-		btp.sineEvalPoly = Approximate(sin2pi2pi, -complex(float64(K)/btp.scFac, 0), complex(float64(K)/btp.scFac, 0), deg)
-
-	} else if btp.SinType == Cos1 {
-
-		btp.sineEvalPoly = new(ChebyshevInterpolation)
-
-		btp.sineEvalPoly.coeffs = bettersine.Approximate(K, deg, btp.MessageRatio, int(btp.SinRescal))
-
-		btp.sineEvalPoly.maxDeg = btp.sineEvalPoly.Degree()
-		btp.sineEvalPoly.a = complex(float64(-K)/btp.scFac, 0)
-		btp.sineEvalPoly.b = complex(float64(K)/btp.scFac, 0)
-		btp.sineEvalPoly.lead = true
-
-	} else if btp.SinType == Cos2 {
-
-		btp.sineEvalPoly = Approximate(cos2pi, -complex(float64(K)/btp.scFac, 0), complex(float64(K)/btp.scFac, 0), deg)
-
-	} else {
-		panic("Bootstrapper -> invalid sineType")
-	}
-
-	for i := range btp.sineEvalPoly.coeffs {
-		btp.sineEvalPoly.coeffs[i] *= complex(btp.sqrt2pi, 0)
-	}
-}
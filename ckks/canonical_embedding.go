@@ -0,0 +1,284 @@
+package ckks
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// CanonicalEmbedding returns the canonical embedding of the real polynomial of degree N-1 with the given
+// coefficients, i.e. its evaluations at the N primitive 2N-th roots of unity exp(i*pi*(2j+1)/N), for j = 0 to N-1.
+// N must be a power of two.
+func CanonicalEmbedding(coeffs []float64) (values []complex128) {
+
+	N := len(coeffs)
+
+	if N == 0 || N&(N-1) != 0 {
+		panic("cannot CanonicalEmbedding: the number of coefficients must be a power of two")
+	}
+
+	// m(zeta^(2j+1)) = sum_k (m_k * zeta^k) * omega^(jk), with omega = zeta^2 a primitive N-th root of unity
+	values = make([]complex128, N)
+	for k, c := range coeffs {
+		values[k] = complex(c, 0) * cmplx.Exp(complex(0, math.Pi*float64(k)/float64(N)))
+	}
+
+	embeddingFFT(values)
+
+	return
+}
+
+// CanonicalEmbeddingNorm returns the infinity norm of the canonical embedding of the real polynomial with the
+// given coefficients (see CanonicalEmbedding).
+func CanonicalEmbeddingNorm(coeffs []float64) (norm float64) {
+	for _, v := range CanonicalEmbedding(coeffs) {
+		norm = math.Max(norm, cmplx.Abs(v))
+	}
+	return
+}
+
+// PlaintextCanonicalEmbeddingNorm returns the infinity norm of the canonical embedding of the polynomial of the
+// plaintext, whose coefficients are centered modulo the moduli of its level. The norm is at the scale of the
+// plaintext: the norm of the encoded message is the returned value divided by plaintext.Scale().
+func PlaintextCanonicalEmbeddingNorm(params Parameters, plaintext *Plaintext) float64 {
+
+	ringQ := params.RingQ()
+	level := plaintext.Level()
+
+	pol := plaintext.value.CopyNew()
+	if plaintext.IsNTT() {
+		ringQ.InvNTTLvl(level, pol, pol)
+	}
+
+	bigintCoeffs := make([]*big.Int, ringQ.N)
+	ringQ.PolyToBigint(pol, bigintCoeffs)
+
+	Q := params.QLvl(level)
+	QHalf := new(big.Int).Rsh(Q, 1)
+
+	coeffs := make([]float64, ringQ.N)
+	for i, c := range bigintCoeffs {
+		if c.Cmp(QHalf) > 0 {
+			c.Sub(c, Q)
+		}
+		coeffs[i], _ = new(big.Float).SetInt(c).Float64()
+	}
+
+	return CanonicalEmbeddingNorm(coeffs)
+}
+
+// embeddingFFT computes in place the discrete Fourier transform sum_k values[k] * exp(2*i*pi*j*k/N) of values, whose
+// length N is a power of two.
+func embeddingFFT(values []complex128) {
+
+	N := len(values)
+	logN := bits.Len64(uint64(N)) - 1
+
+	for i := range values {
+		if j := int(utils.BitReverse64(uint64(i), uint64(logN))); i < j {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	for m := 2; m <= N; m <<= 1 {
+		wm := cmplx.Exp(complex(0, 2*math.Pi/float64(m)))
+		for k := 0; k < N; k += m {
+			w := complex(1, 0)
+			for j := 0; j < m>>1; j++ {
+				u, v := values[k+j], w*values[k+j+m>>1]
+				values[k+j], values[k+j+m>>1] = u+v, u-v
+				w *= wm
+			}
+		}
+	}
+}
+
+// CanonicalBound is a heuristic worst-case bound on the infinity norms of the canonical embeddings of the message
+// and of the error of a ciphertext, both at the scale of the ciphertext. The bounds follow the average-case
+// heuristic of the CKKS literature, in which the canonical norm of a polynomial with independent coefficients of
+// variance V is bounded by 6*sqrt(N*V) with overwhelming probability. They are meant to reason about the
+// worst-case precision of a circuit; GetPrecisionStats reports the empirical precision of a ciphertext.
+type CanonicalBound struct {
+	Level   int
+	Scale   float64
+	Message float64
+	Error   float64
+}
+
+// LogPrecision returns the worst-case number of bits of precision of the decoded values, log2(Scale/Error).
+func (b CanonicalBound) LogPrecision() float64 {
+	return math.Log2(b.Scale / b.Error)
+}
+
+// MessageBound returns the bound on the magnitude of the decoded values, Message/Scale.
+func (b CanonicalBound) MessageBound() float64 {
+	return b.Message / b.Scale
+}
+
+// CanonicalBoundEstimator propagates CanonicalBounds through the homomorphic operations, for the given parameters
+// and secret key distribution.
+type CanonicalBoundEstimator struct {
+	params Parameters
+	h      float64
+}
+
+// NewCanonicalBoundEstimator creates a new CanonicalBoundEstimator for the parameters and the Hamming weight of
+// the secret key. A Hamming weight of zero stands for the uniform ternary secret of KeyGenerator.GenSecretKey,
+// of expected Hamming weight 2N/3.
+func NewCanonicalBoundEstimator(params Parameters, hammingWeight int) *CanonicalBoundEstimator {
+	h := float64(hammingWeight)
+	if hammingWeight == 0 {
+		h = 2 * float64(params.N()) / 3
+	}
+	return &CanonicalBoundEstimator{params: params, h: h}
+}
+
+// EncodingError returns the bound on the canonical norm of the rounding error of the encoding, 6*sqrt(N/12).
+func (est *CanonicalBoundEstimator) EncodingError() float64 {
+	return 6 * math.Sqrt(float64(est.params.N())/12)
+}
+
+// FreshError returns the bound on the canonical norm of the error of a fresh encryption, with a public key if
+// publicKey is true and with the secret key otherwise. The error of the encoding is not included.
+//
+// If the parameters have a modulus P, Encryptor.Encrypt samples the encryption of zero modulo QP and divides it
+// by P, so that its error is the error of the division (see DivisionByPError) plus the error of the encryption
+// modulo QP divided by P. Encryptor.EncryptFast samples it modulo Q only. The returned bound covers both.
+func (est *CanonicalBoundEstimator) FreshError(publicKey bool) float64 {
+
+	N := float64(est.params.N())
+	sigma := est.params.Sigma()
+
+	if !publicKey {
+		return 6 * sigma * math.Sqrt(N)
+	}
+
+	// u*e + e0 + e1*s, with u ternary of weight 2N/3
+	errorQ := 8*math.Sqrt2*sigma*N + 6*sigma*math.Sqrt(N) + 16*sigma*math.Sqrt(est.h*N)
+
+	if est.params.PCount() == 0 {
+		return errorQ
+	}
+
+	P, _ := new(big.Float).SetInt(est.params.PBigInt()).Float64()
+
+	return math.Max(errorQ, errorQ/P+est.DivisionByPError())
+}
+
+// DivisionByPError returns the bound on the canonical norm of the error r0 + r1*s of the division by P of a
+// ciphertext modulo QP (the encryption of Encryptor.Encrypt or the end of a key-switching). The division
+// floors the coefficients, so that r0 and r1 have independent coefficients in (-1, 0], of mean -1/2 and
+// variance 1/12. The canonical norm of the polynomial of constant coefficients -1/2 is 1/(2*sin(pi/(2N))),
+// which is attained at the root exp(i*pi/N), and the one of s is bounded by 6*sqrt(h), hence the bound
+// (1+6*sqrt(h))/(2*sin(pi/(2N))) + 6*sqrt(N*(1+h)/12).
+func (est *CanonicalBoundEstimator) DivisionByPError() float64 {
+	N := float64(est.params.N())
+	mean := (1 + 6*math.Sqrt(est.h)) / (2 * math.Sin(math.Pi/(2*N)))
+	return mean + 6*math.Sqrt(N*(1+est.h)/12)
+}
+
+// RescaleError returns the bound on the canonical norm of the rounding error of a rescaling,
+// sqrt(N/3)*(3+8*sqrt(h)).
+func (est *CanonicalBoundEstimator) RescaleError() float64 {
+	return math.Sqrt(float64(est.params.N())/3) * (3 + 8*math.Sqrt(est.h))
+}
+
+// KeySwitchError returns the bound on the canonical norm of the error added by a key-switching (e.g. a
+// relinearization or a rotation) at the given level, including the error of the division by P (see
+// DivisionByPError).
+func (est *CanonicalBoundEstimator) KeySwitchError(level int) float64 {
+
+	params := est.params
+	N := float64(params.N())
+	alpha := params.PCount()
+	beta := int(math.Ceil(float64(level+1) / float64(alpha)))
+
+	P, _ := new(big.Float).SetInt(params.PBigInt()).Float64()
+
+	// Each decomposed digit is uniform modulo the product of its moduli and multiplies an error of variance sigma^2
+	var digits float64
+	for i := 0; i < beta; i++ {
+		D := 1.0
+		for j := i * alpha; j < (i+1)*alpha && j < level+1; j++ {
+			D *= float64(params.Q()[j])
+		}
+		digits += D * D / 12
+	}
+
+	return 6*math.Sqrt(N*N*params.Sigma()*params.Sigma()*digits)/P + est.DivisionByPError()
+}
+
+// Fresh returns the CanonicalBound of a fresh encryption at the maximum level and at the default scale of the
+// parameters of a message whose decoded values are bounded by messageBound.
+func (est *CanonicalBoundEstimator) Fresh(messageBound float64, publicKey bool) CanonicalBound {
+	scale := est.params.Scale()
+	return CanonicalBound{
+		Level:   est.params.MaxLevel(),
+		Scale:   scale,
+		Message: messageBound * scale,
+		Error:   est.EncodingError() + est.FreshError(publicKey),
+	}
+}
+
+// Add returns the CanonicalBound of the sum of two ciphertexts of the same scale.
+func (est *CanonicalBoundEstimator) Add(b0, b1 CanonicalBound) CanonicalBound {
+	return CanonicalBound{
+		Level:   utils.MinInt(b0.Level, b1.Level),
+		Scale:   math.Max(b0.Scale, b1.Scale),
+		Message: b0.Message + b1.Message,
+		Error:   b0.Error + b1.Error,
+	}
+}
+
+// MulRelin returns the CanonicalBound of the relinearized product of two ciphertexts, before rescaling.
+func (est *CanonicalBoundEstimator) MulRelin(b0, b1 CanonicalBound) CanonicalBound {
+	level := utils.MinInt(b0.Level, b1.Level)
+	return CanonicalBound{
+		Level:   level,
+		Scale:   b0.Scale * b1.Scale,
+		Message: b0.Message * b1.Message,
+		Error:   b0.Message*b1.Error + b1.Message*b0.Error + b0.Error*b1.Error + est.KeySwitchError(level),
+	}
+}
+
+// MultByConst returns the CanonicalBound of the product of a ciphertext by a constant, before rescaling.
+// Following Evaluator.MultByConst, a non-integer constant is scaled by the modulus of the current level and
+// rounded, which adds an error proportional to the message.
+func (est *CanonicalBoundEstimator) MultByConst(b CanonicalBound, constant float64) CanonicalBound {
+
+	if constant == math.Round(constant) {
+		c := math.Abs(constant)
+		return CanonicalBound{Level: b.Level, Scale: b.Scale, Message: b.Message * c, Error: b.Error * c}
+	}
+
+	q := float64(est.params.Q()[b.Level])
+	c := math.Abs(constant) * q
+
+	return CanonicalBound{Level: b.Level, Scale: b.Scale * q, Message: b.Message * c, Error: b.Error*c + b.Message/2}
+}
+
+// KeySwitch returns the CanonicalBound of a ciphertext after a key-switching (e.g. a rotation or a conjugation).
+func (est *CanonicalBoundEstimator) KeySwitch(b CanonicalBound) CanonicalBound {
+	b.Error += est.KeySwitchError(b.Level)
+	return b
+}
+
+// Rescale returns the CanonicalBound of a ciphertext after its division by the modulus of its level.
+func (est *CanonicalBoundEstimator) Rescale(b CanonicalBound) CanonicalBound {
+
+	if b.Level == 0 {
+		panic("cannot Rescale: the bound is already at level 0")
+	}
+
+	q := float64(est.params.Q()[b.Level])
+
+	return CanonicalBound{
+		Level:   b.Level - 1,
+		Scale:   b.Scale / q,
+		Message: b.Message / q,
+		Error:   b.Error/q + est.RescaleError(),
+	}
+}
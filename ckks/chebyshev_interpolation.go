@@ -43,6 +43,28 @@ func Approximate(function func(complex128) complex128, a, b complex128, degree i
 	return
 }
 
+// evaluate returns the value of the interpolant at x in [a, b].
+func (c *ChebyshevInterpolation) evaluate(x complex128) complex128 {
+	return evaluateChebyshevBasis(c.coeffs, (2*x-c.a-c.b)/(c.b-c.a))
+}
+
+// evaluateChebyshevBasis returns sum_i coeffs[i] * T_i(u).
+func evaluateChebyshevBasis(coeffs []complex128, u complex128) (y complex128) {
+	var t0, t1 complex128 = 1, u
+	for i, c := range coeffs {
+		switch i {
+		case 0:
+			y += c * t0
+		case 1:
+			y += c * t1
+		default:
+			t0, t1 = t1, 2*u*t1-t0
+			y += c * t1
+		}
+	}
+	return
+}
+
 func chebyshevNodes(n int, a, b complex128) (u []complex128) {
 	u = make([]complex128, n)
 	var x, y complex128
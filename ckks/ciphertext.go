@@ -1,20 +1,35 @@
 package ckks
 
 import (
+	"fmt"
+
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 )
 
-// Ciphertext is *ring.Poly array representing a polynomial of degree > 0 with coefficients in R_Q.
+// Ciphertext is *ring.Poly array representing a polynomial of degree > 0 with coefficients in R_Q. It embeds an
+// rlwe.Ciphertext of the scheme rlwe.SchemeCKKS, whose MetaData store its scale, its real flag, its slot scales and
+// its metadata, but keeps its own binary encoding (see MarshalBinary).
 type Ciphertext struct {
-	*Element
+	*rlwe.Ciphertext
 }
 
 // NewCiphertext creates a new Ciphertext parameterized by degree, level and scale.
 func NewCiphertext(params Parameters, degree, level int, scale float64) (ciphertext *Ciphertext) {
 
-	ciphertext = &Ciphertext{newElement(params, degree, level, scale)}
-	ciphertext.Element.Element.IsNTT = true
+	ciphertext = newElement(params, degree, level, scale).ciphertext()
+	ciphertext.Element.IsNTT = true
+
+	return ciphertext
+}
+
+// newCiphertextFromPool creates a new Ciphertext parameterized by degree, level and scale, whose polynomials are
+// drawn from ring.DefaultPolyPool.
+func newCiphertextFromPool(params Parameters, degree, level int, scale float64) (ciphertext *Ciphertext) {
+
+	ciphertext = newElementFromRLWE(rlwe.NewElementAtLevelFromPool(params.Parameters, degree, level), scale).ciphertext()
+	ciphertext.Element.IsNTT = true
 
 	return ciphertext
 }
@@ -36,12 +51,103 @@ func NewCiphertextRandom(prng utils.PRNG, params Parameters, degree, level int,
 	return ciphertext
 }
 
+// NewCiphertextFromElement returns the element el as a Ciphertext. The two share their polynomials and their MetaData.
+// It replaces the composite literal &Ciphertext{el} of the previous versions.
+func NewCiphertextFromElement(el *Element) *Ciphertext {
+	return el.ciphertext()
+}
+
+// El returns the receiver ciphertext as an Element, which shares its polynomials and its MetaData.
+func (ct *Ciphertext) El() *Element {
+	return (*Element)(ct.Ciphertext)
+}
+
+// Scale returns the scale of the ciphertext.
+func (ct *Ciphertext) Scale() float64 {
+	return ct.El().Scale()
+}
+
+// SetScale sets the scale of the ciphertext.
+func (ct *Ciphertext) SetScale(scale float64) {
+	ct.El().SetScale(scale)
+}
+
+// MulScale multiplies the scale of the ciphertext with the input scale.
+func (ct *Ciphertext) MulScale(scale float64) {
+	ct.El().MulScale(scale)
+}
+
+// DivScale divides the scale of the ciphertext by the input scale.
+func (ct *Ciphertext) DivScale(scale float64) {
+	ct.El().DivScale(scale)
+}
+
+// IsNTT returns true if the ciphertext is in the NTT domain.
+func (ct *Ciphertext) IsNTT() bool {
+	return ct.El().IsNTT()
+}
+
+// IsReal returns true if the ciphertext is known to encrypt a purely real message (see Element.IsReal).
+func (ct *Ciphertext) IsReal() bool {
+	return ct.El().IsReal()
+}
+
+// SetIsReal sets the flag indicating whether the ciphertext encrypts a purely real message (see Element.SetIsReal).
+func (ct *Ciphertext) SetIsReal(isReal bool) {
+	ct.El().SetIsReal(isReal)
+}
+
+// SlotScales returns the per-slot scaling factors of the ciphertext (see Element.SlotScales).
+func (ct *Ciphertext) SlotScales() []float64 {
+	return ct.El().SlotScales()
+}
+
+// SetSlotScales sets the per-slot scaling factors of the ciphertext (see Element.SetSlotScales).
+func (ct *Ciphertext) SetSlotScales(slotScales []float64) {
+	ct.El().SetSlotScales(slotScales)
+}
+
+// Metadata returns the metadata of the ciphertext (see Element.Metadata).
+func (ct *Ciphertext) Metadata() Metadata {
+	return ct.El().Metadata()
+}
+
+// SetMetadata sets the metadata of the ciphertext to a copy of md (see Element.SetMetadata).
+func (ct *Ciphertext) SetMetadata(md Metadata) {
+	ct.El().SetMetadata(md)
+}
+
+// SetTag sets the value of the tag key in the metadata of the ciphertext.
+func (ct *Ciphertext) SetTag(key, value string) {
+	ct.El().SetTag(key, value)
+}
+
+// Resize resizes the degree of the ciphertext.
+func (ct *Ciphertext) Resize(params Parameters, degree int) {
+	ct.El().Resize(params, degree)
+}
+
 // Copy copies the given ciphertext ctp into the receiver ciphertext.
 func (ct *Ciphertext) Copy(ctp *Ciphertext) {
-	ct.Element.Copy(ctp.Element)
+	ct.El().Copy(ctp.El())
 }
 
 // CopyNew makes a deep copy of the receiver ciphertext and returns it.
 func (ct *Ciphertext) CopyNew() *Ciphertext {
-	return &Ciphertext{ct.Element.CopyNew()}
+	return &Ciphertext{ct.Ciphertext.CopyNew()}
+}
+
+// RLWE returns the embedded rlwe.Ciphertext of the receiver ciphertext.
+func (ct *Ciphertext) RLWE() *rlwe.Ciphertext {
+	return ct.Ciphertext
+}
+
+// NewCiphertextFromRLWE returns the rlwe.Ciphertext ct as a Ciphertext. The two ciphertexts share their polynomials
+// and their MetaData.
+// Returns an error if ct is not a ciphertext of the scheme rlwe.SchemeCKKS.
+func NewCiphertextFromRLWE(ct *rlwe.Ciphertext) (*Ciphertext, error) {
+	if ct.Scheme != rlwe.SchemeCKKS {
+		return nil, fmt.Errorf("cannot NewCiphertextFromRLWE: scheme %s is not CKKS", ct.Scheme)
+	}
+	return &Ciphertext{ct}, nil
 }
@@ -1,14 +1,19 @@
 package ckks
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"math"
+	"math/big"
 	"math/cmplx"
 	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/ldsec/lattigo/v2/internal/prngtest"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -51,6 +56,10 @@ type testParams struct {
 	evaluator   Evaluator
 }
 
+func TestMain(m *testing.M) {
+	prngtest.Main(m)
+}
+
 func TestCKKS(t *testing.T) {
 
 	defaultParams := DefaultParams[:4] // the default test runs for ring degree N=2^12, 2^13, 2^14, 2^15
@@ -86,16 +95,36 @@ func TestCKKS(t *testing.T) {
 			testEvaluatorAddConst,
 			testEvaluatorMultByConst,
 			testEvaluatorMultByConstAndAdd,
+			testEvaluatorConstVector,
+			testEvaluatorMultByGaussianInteger,
 			testEvaluatorMul,
+			testAutoScale,
+			testLevelGuard,
+			testDebugEvaluator,
+			testCleartextEvaluator,
+			testRecordingEvaluator,
 			testFunctions,
 			testDecryptPublic,
+			testCompression,
 			testEvaluatePoly,
 			testChebyshevInterpolator,
+			testEvalPiecewise,
+			testRounding,
+			testEvalMod,
+			testPolyEvaluationPlan,
 			testSwitchKeys,
 			testAutomorphisms,
 			testInnerSum,
+			testTrace,
 			testReplicate,
+			testVectorCiphertext,
+			testFixedPoint,
+			testRealOnly,
+			testSlotScales,
+			testMetadata,
+			testCanonicalEmbedding,
 			testLinearTransform,
+			testHomomorphicDFT,
 			testMarshaller,
 		} {
 			testSet(testContext, t)
@@ -193,6 +222,22 @@ func testParameters(testContext *testParams, t *testing.T) {
 		assert.False(t, params1.Equals(testContext.params))
 		assert.True(t, params2.Equals(testContext.params))
 	})
+
+	t.Run(testString(testContext, "Parameters/Describe/"), func(t *testing.T) {
+		params := testContext.params
+		assert.Equal(t, 128, params.EstimatedSecurity())
+		assert.NoError(t, params.Validate(0))
+		assert.NoError(t, params.Validate(128))
+		assert.Error(t, params.Validate(192))
+		assert.Contains(t, params.String(), fmt.Sprintf("logN=%d", params.LogN()))
+		assert.Contains(t, params.Describe(), fmt.Sprintf("%d (%d slots)", params.LogSlots(), params.Slots()))
+
+		rtks := testContext.kgen.GenRotationKeysForRotations([]int{1, -1}, true, testContext.sk)
+		description := rtks.Describe()
+		assert.Contains(t, description, "column rotation by 1 (left)")
+		assert.Contains(t, description, fmt.Sprintf("column rotation by %d (left)", params.N()/2-1))
+		assert.Contains(t, description, "row rotation (conjugation)")
+	})
 }
 
 func testEncoder(testContext *testParams, t *testing.T) {
@@ -237,6 +282,149 @@ func testEncoder(testContext *testParams, t *testing.T) {
 		require.GreaterOrEqual(t, math.Log2(1/meanprec), minPrec)
 	})
 
+	t.Run(testString(testContext, "Encoder/SlotMapping/"), func(t *testing.T) {
+
+		logN := testContext.params.LogN()
+		logSlots := testContext.params.LogSlots() - 1
+		N := testContext.params.N()
+
+		values := make([]complex128, 1<<logSlots)
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		}
+
+		plaintext := NewPlaintext(testContext.params, testContext.params.MaxLevel(), testContext.params.Scale())
+		testContext.encoder.Encode(plaintext, values, logSlots)
+		coeffs := testContext.encoder.DecodeCoeffs(plaintext)
+
+		// Only the coefficients given by SlotToCoeffIndices are non-zero
+		nonZero := make([]bool, N)
+		for k := range values {
+			re, im := SlotToCoeffIndices(logN, logSlots, k)
+			nonZero[re], nonZero[im] = true, true
+		}
+
+		for i := range coeffs {
+			if !nonZero[i] {
+				require.Zero(t, coeffs[i])
+			}
+		}
+
+		// The slot j is the evaluation of the polynomial at zeta^e_j
+		exponents := SlotRootExponents(logN, logSlots)
+		require.Len(t, exponents, len(values))
+
+		for _, j := range []int{0, 1, 2, len(values) - 1} {
+			var eval complex128
+			for i, c := range coeffs {
+				eval += complex(c, 0) * cmplx.Exp(complex(0, math.Pi*float64((i*exponents[j])%(2*N))/float64(N)))
+			}
+			require.InDelta(t, 0, cmplx.Abs(eval-values[j]), 1e-6)
+		}
+	})
+
+	t.Run(testString(testContext, "Encoder/ReEncodeAtScale/"), func(t *testing.T) {
+
+		values, plaintext, _ := newTestVectors(testContext, nil, complex(-1, -1), complex(1, 1), t)
+
+		newScale := plaintext.Scale() * 7
+
+		require.NoError(t, testContext.encoder.ReEncodeAtScale(plaintext, newScale))
+		require.Equal(t, newScale, plaintext.Scale())
+
+		verifyTestVectors(testContext, nil, values, plaintext, testContext.params.LogSlots(), 0, t)
+
+		require.Error(t, testContext.encoder.ReEncodeAtScale(plaintext, newScale*1.5))
+		require.Error(t, testContext.encoder.ReEncodeAtScale(plaintext, newScale/7))
+		require.Error(t, testContext.encoder.ReEncodeAtScale(plaintext, newScale*math.Exp2(float64(testContext.params.LogQLvl(plaintext.Level())))))
+		require.Equal(t, newScale, plaintext.Scale())
+	})
+
+	t.Run(testString(testContext, "Encoder/StochasticRounding/"), func(t *testing.T) {
+
+		encoder := NewEncoderWithRounding(testContext.params, StochasticRounding)
+
+		// The encoding is as precise as with the rounding to the nearest
+		values, _, _ := newTestVectors(testContext, nil, complex(-1, -1), complex(1, 1), t)
+		plaintext := encoder.EncodeNTTAtLvlNew(testContext.params.MaxLevel(), values, testContext.params.LogSlots())
+		verifyTestVectors(testContext, nil, values, plaintext, testContext.params.LogSlots(), 0, t)
+
+		// Iterated multiplications of independent encodings of c at a low scale: the rounding errors of the
+		// rounding to the nearest are identical and accumulate linearly, while the stochastic rounding errors
+		// are unbiased and average out over the trials.
+		ringQ := testContext.ringQ
+		logSlots := 4
+		c := complex(1.0/3, 0)
+		scale := float64(1 << 10)
+		depth := 4
+		trials := 256
+
+		// Smallest level that can store the product at scale^depth
+		level := 0
+		for testContext.params.LogQLvl(level) < 10*depth+2 {
+			level++
+		}
+
+		cs := make([]complex128, 1<<logSlots)
+		for i := range cs {
+			cs[i] = c
+		}
+
+		want := cmplx.Pow(c, complex(float64(depth), 0))
+
+		bias := func(encoder Encoder) float64 {
+
+			var mean complex128
+			for trial := 0; trial < trials; trial++ {
+
+				acc := NewPlaintext(testContext.params, level, scale)
+				encoder.EncodeNTT(acc, cs, logSlots)
+
+				for i := 1; i < depth; i++ {
+					pt := NewPlaintext(testContext.params, level, scale)
+					encoder.EncodeNTT(pt, cs, logSlots)
+					ringQ.MFormLvl(level, pt.value, pt.value)
+					ringQ.MulCoeffsMontgomeryLvl(level, acc.value, pt.value, acc.value)
+				}
+
+				acc.SetScale(math.Pow(scale, float64(depth)))
+
+				mean += testContext.encoder.Decode(acc, logSlots)[0]
+			}
+
+			return cmplx.Abs(mean/complex(float64(trials), 0) - want)
+		}
+
+		biasNearest := bias(testContext.encoder)
+		biasStochastic := bias(encoder)
+
+		if *printPrecisionStats {
+			t.Logf("bias nearest: 2^%.2f, bias stochastic: 2^%.2f", math.Log2(biasNearest), math.Log2(biasStochastic))
+		}
+
+		require.Less(t, biasStochastic, biasNearest/4)
+	})
+
+	t.Run(testString(testContext, "Encoder/EncodeBigComplex/Interoperability/"), func(t *testing.T) {
+
+		logSlots := testContext.params.LogSlots()
+
+		encoderBig := NewEncoderBigComplex(testContext.params, 128)
+
+		values, plaintext, _ := newTestVectors(testContext, nil, complex(-1, -1), complex(1, 1), t)
+
+		// Encoder -> EncoderBigComplex
+		valuesHave := BigComplexToComplex128(encoderBig.Decode(plaintext, logSlots))
+
+		verifyTestVectors(testContext, nil, values, valuesHave, logSlots, 0, t)
+
+		// EncoderBigComplex -> Encoder
+		plaintext = encoderBig.EncodeNTTAtLvlNew(testContext.params.MaxLevel()-1, Complex128ToBigComplex(values, 128), logSlots)
+
+		require.Equal(t, testContext.params.MaxLevel()-1, plaintext.Level())
+
+		verifyTestVectors(testContext, nil, values, plaintext, logSlots, 0, t)
+	})
 }
 
 func testEncryptor(testContext *testParams, t *testing.T) {
@@ -276,6 +464,37 @@ func testEncryptor(testContext *testParams, t *testing.T) {
 		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
 	})
 
+	t.Run(testString(testContext, "Encryptor/WithPRNG/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values, plaintext, _ := newTestVectors(testContext, nil, complex(-1, -1), complex(1, 1), t)
+
+		newPRNG := func() utils.PRNG {
+			prng, err := utils.NewKeyedPRNG([]byte{'l', 'a', 't', 't', 'i', 'g', 'o'})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return prng
+		}
+
+		for _, newEncryptor := range []func() Encryptor{
+			func() Encryptor { return NewEncryptorFromPkWithPRNG(testContext.params, testContext.pk, newPRNG()) },
+			func() Encryptor { return NewEncryptorFromSkWithPRNG(testContext.params, testContext.sk, newPRNG()) },
+		} {
+			ciphertext0 := newEncryptor().EncryptNew(plaintext)
+			ciphertext1 := newEncryptor().EncryptNew(plaintext)
+
+			for i := range ciphertext0.Value {
+				require.True(t, ciphertext0.Value[i].Equals(ciphertext1.Value[i]))
+			}
+
+			verifyTestVectors(testContext, testContext.decryptor, values, ciphertext0, testContext.params.LogSlots(), 0, t)
+		}
+	})
+
 	t.Run(testString(testContext, "Encryptor/EncryptFromPk/Lvl=1/"), func(t *testing.T) {
 
 		if testContext.params.PCount() == 0 {
@@ -539,6 +758,29 @@ func testEvaluatorRescale(testContext *testParams, t *testing.T) {
 
 		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
 	})
+
+	t.Run(testString(testContext, "Evaluator/SetScale/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		// Non-integer ratios between the scales, to a larger and to a smaller scale
+		for _, target := range []float64{4 * testContext.params.Scale(), testContext.params.Scale() / 4} {
+
+			values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			ciphertext.MulScale(1.00001)
+			for i := range values {
+				values[i] /= 1.00001
+			}
+
+			testContext.evaluator.SetScale(ciphertext, target)
+			require.Equal(t, target, ciphertext.Scale())
+
+			verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+		}
+	})
 }
 
 func testEvaluatorAddConst(testContext *testParams, t *testing.T) {
@@ -577,6 +819,29 @@ func testEvaluatorMultByConst(testContext *testParams, t *testing.T) {
 		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
 	})
 
+	t.Run(testString(testContext, "Evaluator/MultByConst/BigFloat/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		constant := new(big.Float).SetPrec(128)
+		constant.Quo(ring.NewFloat(1, 128), ring.NewFloat(3, 128))
+
+		constantF64, _ := constant.Float64()
+
+		for i := range values {
+			values[i] *= complex(constantF64, 0)
+		}
+
+		testContext.evaluator.MultByConst(ciphertext, constant, ciphertext)
+		testContext.evaluator.AddConst(ciphertext, ring.NewComplex(constant, constant), ciphertext)
+
+		for i := range values {
+			values[i] += complex(constantF64, constantF64)
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
 }
 
 func testEvaluatorMultByConstAndAdd(testContext *testParams, t *testing.T) {
@@ -599,6 +864,155 @@ func testEvaluatorMultByConstAndAdd(testContext *testParams, t *testing.T) {
 
 }
 
+func testEvaluatorConstVector(testContext *testParams, t *testing.T) {
+
+	t.Run(testString(testContext, "Evaluator/AddConstVector/"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		constants := make([]complex128, len(values1)/2)
+		for i := range constants {
+			constants[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+			values1[i] += constants[i]
+		}
+
+		ciphertext2, err := testContext.evaluator.AddConstVectorNew(ciphertext1, constants)
+		require.NoError(t, err)
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext2, testContext.params.LogSlots(), 0, t)
+
+		_, err = testContext.evaluator.AddConstVectorNew(ciphertext1, make([]complex128, testContext.params.Slots()+1))
+		require.Error(t, err)
+	})
+
+	t.Run(testString(testContext, "Evaluator/MulConstVectorThenAdd/"), func(t *testing.T) {
+
+		params := testContext.params
+
+		if params.MaxLevel() == 0 {
+			t.Skip("#Qi is 1")
+		}
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		weights1 := make([]complex128, len(values1))
+		weights2 := make([]complex128, len(values1))
+		want := make([]complex128, len(values1))
+		for i := range weights1 {
+			weights1[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+			weights2[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+			want[i] = 2*values1[i]*weights1[i] + values2[i]*weights2[i]
+		}
+
+		eval := testContext.evaluator.ShallowCopy()
+
+		level := ciphertext1.Level()
+		acc := NewCiphertext(params, 1, level, ciphertext1.Scale()*float64(params.Q()[level]))
+
+		// The second use of weights1 is encoded once
+		require.NoError(t, eval.MulConstVectorThenAdd(ciphertext1, weights1, acc))
+		require.NoError(t, eval.MulConstVectorThenAdd(ciphertext2, weights2, acc))
+		cached := len(testContext.evaluator.(*evaluator).constVectors.plaintexts)
+		require.NoError(t, eval.MulConstVectorThenAdd(ciphertext1, weights1, acc))
+		require.Equal(t, cached, len(testContext.evaluator.(*evaluator).constVectors.plaintexts))
+
+		require.NoError(t, eval.Rescale(acc, params.Scale(), acc))
+
+		verifyTestVectors(testContext, testContext.decryptor, want, acc, params.LogSlots(), 0, t)
+
+		require.Error(t, eval.MulConstVectorThenAdd(ciphertext1, weights1, NewCiphertext(params, 1, level, ciphertext1.Scale()/2)))
+	})
+}
+
+func testEvaluatorMultByGaussianInteger(testContext *testParams, t *testing.T) {
+
+	constants := [][2]int64{{0, 1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}, {3, -2}, {-5, 0}, {0, 0}}
+
+	t.Run(testString(testContext, "Evaluator/MultByGaussianInteger/"), func(t *testing.T) {
+
+		for _, c := range constants {
+
+			values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			level, scale := ciphertext.Level(), ciphertext.Scale()
+
+			ciphertext2 := testContext.evaluator.MultByGaussianIntegerNew(ciphertext, c[0], c[1])
+			ciphertext3 := testContext.evaluator.MultByConjGaussianNew(ciphertext, c[0], c[1])
+
+			require.Equal(t, level, ciphertext2.Level())
+			require.Equal(t, scale, ciphertext2.Scale())
+			require.Equal(t, level, ciphertext3.Level())
+			require.Equal(t, scale, ciphertext3.Scale())
+
+			valuesConj := make([]complex128, len(values))
+			for i := range values {
+				valuesConj[i] = values[i] * complex(float64(c[0]), -float64(c[1]))
+				values[i] *= complex(float64(c[0]), float64(c[1]))
+			}
+
+			verifyTestVectors(testContext, testContext.decryptor, values, ciphertext2, testContext.params.LogSlots(), 0, t)
+			verifyTestVectors(testContext, testContext.decryptor, valuesConj, ciphertext3, testContext.params.LogSlots(), 0, t)
+		}
+	})
+
+	t.Run(testString(testContext, "Evaluator/MultByGaussianInteger/Reduction/"), func(t *testing.T) {
+
+		// Constants congruent to 1 + i modulo the first prime must give the same result as 1 + i at level 0
+		qi := int64(testContext.ringQ.Modulus[0])
+
+		_, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		testContext.evaluator.DropLevel(ciphertext, ciphertext.Level())
+
+		want := testContext.evaluator.MultByGaussianIntegerNew(ciphertext, 1, 1)
+
+		for _, c := range [][2]int64{{1 + qi, 1 - qi}, {1 - 2*qi, 1 + 3*qi}} {
+			have := testContext.evaluator.MultByGaussianIntegerNew(ciphertext, c[0], c[1])
+			for u := range want.Value {
+				require.True(t, testContext.ringQ.EqualLvl(0, want.Value[u], have.Value[u]))
+			}
+		}
+	})
+
+	t.Run(testString(testContext, "Evaluator/MultByGaussianIntegerAndAdd/"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values2[i] += complex(-2, 3) * values1[i]
+		}
+
+		testContext.evaluator.MultByGaussianIntegerAndAdd(ciphertext1, -2, 3, ciphertext2)
+
+		verifyTestVectors(testContext, testContext.decryptor, values2, ciphertext2, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "Evaluator/MultByGaussianInteger/Cleartext/"), func(t *testing.T) {
+
+		_, plaintext, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		clear := NewCleartextEvaluator(testContext.params)
+		cleartext := NewCleartextEncryptor(testContext.params).EncryptNew(plaintext)
+
+		for i, c := range constants[:len(constants)-1] {
+			if i&1 == 0 {
+				ciphertext = testContext.evaluator.MultByGaussianIntegerNew(ciphertext, c[0], c[1])
+				cleartext = clear.MultByGaussianIntegerNew(cleartext, c[0], c[1])
+			} else {
+				ciphertext = testContext.evaluator.MultByConjGaussianNew(ciphertext, c[0], c[1])
+				cleartext = clear.MultByConjGaussianNew(cleartext, c[0], c[1])
+			}
+		}
+
+		require.Equal(t, ciphertext.Level(), cleartext.Level())
+		require.Equal(t, ciphertext.Scale(), cleartext.Scale())
+
+		values := testContext.encoder.Decode(testContext.decryptor.DecryptNew(ciphertext), testContext.params.LogSlots())
+		verifyTestVectors(testContext, NewCleartextDecryptor(testContext.params), values, cleartext, testContext.params.LogSlots(), 0, t)
+	})
+}
+
 func testEvaluatorMul(testContext *testParams, t *testing.T) {
 
 	t.Run(testString(testContext, "Evaluator/Mul/ct0*pt->ct0/"), func(t *testing.T) {
@@ -752,444 +1166,2568 @@ func testEvaluatorMul(testContext *testParams, t *testing.T) {
 
 }
 
-func testFunctions(testContext *testParams, t *testing.T) {
+func testLevelGuard(testContext *testParams, t *testing.T) {
 
-	t.Run(testString(testContext, "Evaluator/PowerOf2/"), func(t *testing.T) {
+	if testContext.params.PCount() == 0 {
+		return
+	}
 
-		if testContext.params.PCount() == 0 {
-			t.Skip("#Pi is empty")
-		}
+	t.Run(testString(testContext, "LevelGuard/MulRelin/"), func(t *testing.T) {
 
-		if testContext.params.MaxLevel() < 3 {
-			t.Skip("skipping test for params max level < 3")
+		params := testContext.params
+		logSlots := params.LogSlots()
+
+		// Simulates an interactive refresh by a decryption and a re-encryption at the maximum level
+		var refreshes int
+		refresh := func(ct *Ciphertext, depth int) *Ciphertext {
+			refreshes++
+			values := testContext.encoder.Decode(testContext.decryptor.DecryptNew(ct), logSlots)
+			return testContext.encryptorSk.EncryptNew(testContext.encoder.EncodeNTTAtLvlNew(params.MaxLevel(), values, logSlots))
 		}
 
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		eval := NewLevelGuardEvaluator(testContext.evaluator, 0, refresh)
 
-		n := 2
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		valuesWant := make([]complex128, len(values))
-		for i := 0; i < len(valuesWant); i++ {
-			valuesWant[i] = values[i]
+		// Multiplies by a message of modulus one to keep the magnitude of the values constant
+		for i := range values2 {
+			values2[i] /= complex(cmplx.Abs(values2[i]), 0)
 		}
+		ciphertext2 = testContext.encryptorSk.EncryptNew(testContext.encoder.EncodeNTTAtLvlNew(params.MaxLevel(), values2, logSlots))
 
-		for i := 0; i < n; i++ {
-			for j := 0; j < len(valuesWant); j++ {
-				valuesWant[j] *= valuesWant[j]
+		for i := 0; i < params.MaxLevel()+2; i++ {
+
+			eval.MulRelin(ciphertext1, ciphertext2, ciphertext1)
+			if err := eval.Rescale(ciphertext1, params.Scale(), ciphertext1); err != nil {
+				t.Fatal(err)
+			}
+
+			for j := range values1 {
+				values1[j] *= values2[j]
 			}
+
+			require.GreaterOrEqual(t, ciphertext1.Level(), eval.MinLevel())
 		}
 
-		testContext.evaluator.PowerOf2(ciphertext, n, ciphertext)
+		require.GreaterOrEqual(t, refreshes, 1)
 
-		verifyTestVectors(testContext, testContext.decryptor, valuesWant, ciphertext, testContext.params.LogSlots(), 0, t)
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, logSlots, 0, t)
 	})
+}
 
-	t.Run(testString(testContext, "Evaluator/Power/"), func(t *testing.T) {
+func testDebugEvaluator(testContext *testParams, t *testing.T) {
 
-		if testContext.params.PCount() == 0 {
-			t.Skip("#Pi is empty")
+	if testContext.params.PCount() == 0 || testContext.params.MaxLevel() < 2 {
+		return
+	}
+
+	t.Run(testString(testContext, "DebugEvaluator/Trace/"), func(t *testing.T) {
+
+		params := testContext.params
+
+		buf := new(bytes.Buffer)
+		eval := NewDebugEvaluator(params, testContext.evaluator, buf)
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorPk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorPk, complex(-1, -1), complex(1, 1), t)
+
+		eval.SetMessageBound(ciphertext1, math.Sqrt2)
+		eval.SetMessageBound(ciphertext2, math.Sqrt2)
+
+		ciphertext3 := eval.MulRelinNew(ciphertext1, ciphertext2)
+		if err := eval.Rescale(ciphertext3, params.Scale(), ciphertext3); err != nil {
+			t.Fatal(err)
 		}
+		eval.Add(ciphertext3, ciphertext3, ciphertext3)
 
-		if testContext.params.MaxLevel() < 4 {
-			t.Skip("skipping test for params max level < 4")
+		for i := range values1 {
+			values1[i] = 2 * values1[i] * values2[i]
 		}
 
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext3, params.LogSlots(), 0, t)
 
-		n := 3
+		precStats := GetPrecisionStats(params, testContext.encoder, testContext.decryptor, values1, ciphertext3, params.LogSlots(), 0)
 
-		for i := range values {
-			values[i] = cmplx.Pow(values[i], complex(float64(n), 0))
-		}
+		// The estimation is a worst-case bound on the error
+		require.LessOrEqual(t, eval.EstimatedPrecision(ciphertext3), real(precStats.MinPrecision)+1)
+		require.Greater(t, eval.EstimatedPrecision(ciphertext3), 0.0)
 
-		testContext.evaluator.Power(ciphertext, n, ciphertext)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3)
+		require.True(t, strings.HasPrefix(lines[0], "#0 MulRelinNew:"))
+		require.True(t, strings.HasPrefix(lines[1], "#1 Rescale:"))
+		require.True(t, strings.HasPrefix(lines[2], "#2 Add:"))
+		require.Contains(t, lines[2], "precision~")
 
-		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+		eval.Reset()
+		buf.Reset()
+		eval.Neg(ciphertext3, ciphertext3)
+		require.True(t, strings.HasPrefix(buf.String(), "#0 Neg:"))
 	})
 
-	t.Run(testString(testContext, "Evaluator/Inverse/"), func(t *testing.T) {
-
-		if testContext.params.PCount() == 0 {
-			t.Skip("#Pi is empty")
-		}
+	t.Run(testString(testContext, "DebugEvaluator/Panics/"), func(t *testing.T) {
 
-		if testContext.params.MaxLevel() < 7 {
-			t.Skip("skipping test for params max level < 7")
+		params := testContext.params
+		eval := NewDebugEvaluator(params, testContext.evaluator, nil)
+
+		_, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		_, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		requirePanics := func(substring string, f func()) {
+			defer func() {
+				r := recover()
+				require.NotNil(t, r)
+				require.Contains(t, r.(string), substring)
+			}()
+			f()
 		}
 
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(0.1, 0), complex(1, 0), t)
+		ciphertext3 := eval.MulNew(ciphertext1, ciphertext2)
 
-		n := 7
+		requirePanics("rescale needed before AddNew: scales 2^", func() { eval.AddNew(ciphertext3, ciphertext1) })
+		requirePanics("relinearize needed before RotateNew: ciphertext of degree 2", func() { eval.RotateNew(ciphertext3, 1) })
+		requirePanics("relinearize needed before MulRelin", func() { eval.MulRelinNew(ciphertext3, ciphertext1) })
 
-		for i := range values {
-			values[i] = 1.0 / values[i]
-		}
+		ciphertext4 := ciphertext1.CopyNew()
+		ciphertext4.SetScale(ciphertext1.Scale() * 1.5)
+		requirePanics("scale mismatch in Sub", func() { eval.SubNew(ciphertext1, ciphertext4) })
 
-		ciphertext = testContext.evaluator.InverseNew(ciphertext, n)
+		eval.DropLevel(ciphertext4, ciphertext4.Level())
+		requirePanics("cannot Rescale", func() { eval.Rescale(ciphertext4, params.Scale(), ciphertext4) })
 
-		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+		eval.SetMinPrecision(60)
+		requirePanics("precision loss in AddNew", func() { eval.AddNew(ciphertext1, ciphertext2) })
 	})
 }
 
-func testEvaluatePoly(testContext *testParams, t *testing.T) {
+func testAutoScale(testContext *testParams, t *testing.T) {
 
-	var err error
+	if testContext.params.PCount() == 0 {
+		return
+	}
 
-	t.Run(testString(testContext, "EvaluatePoly/Exp/"), func(t *testing.T) {
+	eval := NewAutoScaleEvaluator(testContext.params, testContext.evaluator, testContext.params.Scale())
 
-		if testContext.params.PCount() == 0 {
-			t.Skip("#Pi is empty")
-		}
+	t.Run(testString(testContext, "AutoScale/MulRelin/"), func(t *testing.T) {
 
-		if testContext.params.MaxLevel() < 3 {
-			t.Skip("skipping test for params max level < 3")
-		}
-
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		coeffs := []complex128{
-			complex(1.0, 0),
-			complex(1.0, 0),
-			complex(1.0/2, 0),
-			complex(1.0/6, 0),
-			complex(1.0/24, 0),
-			complex(1.0/120, 0),
-			complex(1.0/720, 0),
-			complex(1.0/5040, 0),
+		for i := range values1 {
+			values1[i] *= values2[i]
 		}
 
-		poly := NewPoly(coeffs)
+		ciphertext3 := eval.MulRelinNew(ciphertext1, ciphertext2)
 
-		for i := range values {
-			values[i] = cmplx.Exp(values[i])
+		require.Equal(t, ciphertext1.Level()-1, ciphertext3.Level())
+		require.GreaterOrEqual(t, ciphertext3.Scale(), eval.Waterline()/2)
+		require.Less(t, ciphertext3.Scale(), 2*eval.Waterline())
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext3, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "AutoScale/Add/IntegerRatio/"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] += values2[i]
 		}
 
-		if ciphertext, err = testContext.evaluator.EvaluatePoly(ciphertext, poly, ciphertext.Scale()); err != nil {
-			t.Error(err)
+		ciphertext2.SetScale(ciphertext2.Scale() * 3)
+		eval.MultByConst(ciphertext2, 3, ciphertext2)
+
+		ciphertext3 := eval.AddNew(ciphertext1, ciphertext2)
+
+		require.Equal(t, ciphertext1.Level(), ciphertext3.Level())
+		require.Equal(t, ciphertext2.Scale(), ciphertext3.Scale())
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext3, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "AutoScale/Sub/RationalRatio/"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values3, _, ciphertext3 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] = values1[i]*values2[i] - values3[i]
 		}
 
-		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+		eval.MulRelin(ciphertext1, ciphertext2, ciphertext1)
+
+		require.NotEqual(t, ciphertext1.Scale(), ciphertext3.Scale())
+
+		eval.Sub(ciphertext1, ciphertext3, ciphertext1)
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
 	})
 }
 
-func testChebyshevInterpolator(testContext *testParams, t *testing.T) {
+func testCleartextEvaluator(testContext *testParams, t *testing.T) {
 
-	var err error
+	t.Run(testString(testContext, "Cleartext/Circuit/"), func(t *testing.T) {
 
-	t.Run(testString(testContext, "ChebyshevInterpolator/Sin/"), func(t *testing.T) {
+		if testContext.params.PCount() == 0 || testContext.params.MaxLevel() < 4 {
+			t.Skip("not enough levels")
+		}
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations([]int{5}, true, testContext.sk)
+		evaluator := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		// The same circuit is evaluated with both evaluators and compared after decryption.
+		circuit := func(eval Evaluator, ct0, ct1 *Ciphertext, pt *Plaintext) *Ciphertext {
+			ct := eval.MulRelinNew(ct0, ct1)
+			if err := eval.Rescale(ct, testContext.params.Scale(), ct); err != nil {
+				panic(err)
+			}
+			eval.Add(ct, pt, ct)
+			eval.Rotate(ct, 5, ct)
+			eval.Conjugate(ct, ct)
+			eval.RotateBy(ct, rlwe.CoeffShift(3), ct)
+			eval.MultByConst(ct, 0.5, ct)
+			if err := eval.Rescale(ct, testContext.params.Scale(), ct); err != nil {
+				panic(err)
+			}
+			ct, err := eval.EvaluatePoly(ct, NewPoly([]complex128{0.5, 1, -0.25, 0.125}), ct.Scale())
+			if err != nil {
+				panic(err)
+			}
+			return ct
+		}
+
+		_, plaintext0, ciphertext0 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		_, plaintext1, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		_, plaintext2, _ := newTestVectors(testContext, nil, complex(-1, -1), complex(1, 1), t)
+
+		encryptor := NewCleartextEncryptor(testContext.params)
+		decryptor := NewCleartextDecryptor(testContext.params)
+
+		ciphertext := circuit(evaluator, ciphertext0, ciphertext1, plaintext2)
+		cleartext := circuit(NewCleartextEvaluator(testContext.params), encryptor.EncryptNew(plaintext0), encryptor.EncryptNew(plaintext1), plaintext2)
+
+		require.Equal(t, ciphertext.Degree(), cleartext.Degree())
+		require.Equal(t, ciphertext.Level(), cleartext.Level())
+		require.Equal(t, ciphertext.Scale(), cleartext.Scale())
+
+		values := testContext.encoder.Decode(testContext.decryptor.DecryptNew(ciphertext), testContext.params.LogSlots())
+		verifyTestVectors(testContext, decryptor, values, cleartext, testContext.params.LogSlots(), 0, t)
+	})
+}
+
+func testFunctions(testContext *testParams, t *testing.T) {
+
+	t.Run(testString(testContext, "Evaluator/PowerOf2/"), func(t *testing.T) {
 
 		if testContext.params.PCount() == 0 {
 			t.Skip("#Pi is empty")
 		}
 
-		if testContext.params.MaxLevel() < 5 {
-			t.Skip("skipping test for params max level < 5")
+		if testContext.params.MaxLevel() < 3 {
+			t.Skip("skipping test for params max level < 3")
 		}
 
-		eval := testContext.evaluator
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+		n := 2
 
-		cheby := Approximate(cmplx.Sin, complex(-1.5, 0), complex(1.5, 0), 15)
+		valuesWant := make([]complex128, len(values))
+		for i := 0; i < len(valuesWant); i++ {
+			valuesWant[i] = values[i]
+		}
 
-		for i := range values {
-			values[i] = cmplx.Sin(values[i])
+		for i := 0; i < n; i++ {
+			for j := 0; j < len(valuesWant); j++ {
+				valuesWant[j] *= valuesWant[j]
+			}
 		}
 
-		eval.MultByConst(ciphertext, 2/(cheby.b-cheby.a), ciphertext)
-		eval.AddConst(ciphertext, (-cheby.a-cheby.b)/(cheby.b-cheby.a), ciphertext)
-		eval.Rescale(ciphertext, eval.(*evaluator).scale, ciphertext)
+		testContext.evaluator.PowerOf2(ciphertext, n, ciphertext)
 
-		if ciphertext, err = eval.EvaluateCheby(ciphertext, cheby, ciphertext.Scale()); err != nil {
-			t.Error(err)
+		verifyTestVectors(testContext, testContext.decryptor, valuesWant, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "Evaluator/Power/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
 		}
 
-		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
-	})
-}
+		if testContext.params.MaxLevel() < 4 {
+			t.Skip("skipping test for params max level < 4")
+		}
 
-func testDecryptPublic(testContext *testParams, t *testing.T) {
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-	var err error
+		n := 3
 
-	t.Run(testString(testContext, "DecryptPublic/Sin/"), func(t *testing.T) {
+		for i := range values {
+			values[i] = cmplx.Pow(values[i], complex(float64(n), 0))
+		}
+
+		testContext.evaluator.Power(ciphertext, n, ciphertext)
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "Evaluator/Inverse/"), func(t *testing.T) {
 
 		if testContext.params.PCount() == 0 {
 			t.Skip("#Pi is empty")
 		}
 
-		if testContext.params.MaxLevel() < 5 {
-			t.Skip("skipping test for params max level < 5")
+		if testContext.params.MaxLevel() < 7 {
+			t.Skip("skipping test for params max level < 7")
 		}
 
-		eval := testContext.evaluator
-
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(0.1, 0), complex(1, 0), t)
 
-		cheby := Approximate(cmplx.Sin, complex(-1.5, 0), complex(1.5, 0), 15)
+		n := 7
 
 		for i := range values {
-			values[i] = cmplx.Sin(values[i])
+			values[i] = 1.0 / values[i]
 		}
 
-		eval.MultByConst(ciphertext, 2/(cheby.b-cheby.a), ciphertext)
-		eval.AddConst(ciphertext, (-cheby.a-cheby.b)/(cheby.b-cheby.a), ciphertext)
-		eval.Rescale(ciphertext, eval.(*evaluator).scale, ciphertext)
+		ciphertext = testContext.evaluator.InverseNew(ciphertext, n)
 
-		if ciphertext, err = eval.EvaluateCheby(ciphertext, cheby, ciphertext.Scale()); err != nil {
-			t.Error(err)
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "Evaluator/InverseRange/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
 		}
 
-		plaintext := testContext.decryptor.DecryptNew(ciphertext)
+		a, b, n := 0.5, 1.0, 4
 
-		valuesHave := testContext.encoder.Decode(plaintext, testContext.params.LogSlots())
+		if testContext.params.MaxLevel() < InverseRangeDepth(n) {
+			t.Skipf("skipping test for params max level < %d", InverseRangeDepth(n))
+		}
 
-		verifyTestVectors(testContext, nil, values, valuesHave, testContext.params.LogSlots(), 0, t)
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(a, 0), complex(b, 0), t)
 
-		sigma := testContext.encoder.GetErrSTDCoeffDomain(values, valuesHave, plaintext.Scale())
+		// Negative values on [-b, -a]
+		valuesNeg := make([]complex128, len(values))
+		for i := range values {
+			valuesNeg[i] = -1.0 / values[i]
+			values[i] = 1.0 / values[i]
+		}
 
-		valuesHave = testContext.encoder.DecodePublic(plaintext, testContext.params.LogSlots(), sigma)
+		ciphertextNeg, err := testContext.evaluator.InverseRangeNew(testContext.evaluator.NegNew(ciphertext), -b, -a, n)
+		require.NoError(t, err)
 
-		verifyTestVectors(testContext, nil, values, valuesHave, testContext.params.LogSlots(), 0, t)
-	})
-}
+		ciphertext, err = testContext.evaluator.InverseRangeNew(ciphertext, a, b, n)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, ciphertext.Level(), testContext.params.MaxLevel()-InverseRangeDepth(n))
 
-func testSwitchKeys(testContext *testParams, t *testing.T) {
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+		verifyTestVectors(testContext, testContext.decryptor, valuesNeg, ciphertextNeg, testContext.params.LogSlots(), 0, t)
 
-	var sk2 *rlwe.SecretKey
-	var decryptorSk2 Decryptor
-	var switchingKey *rlwe.SwitchingKey
+		if ciphertext.Level() >= InverseRangeDepth(n) {
+			testContext.evaluator.DropLevel(ciphertext, ciphertext.Level()-InverseRangeDepth(n)+1)
+		}
+		_, err = testContext.evaluator.InverseRangeNew(ciphertext, a, b, n)
+		require.Error(t, err)
 
-	if testContext.params.PCount() != 0 {
-		sk2 = testContext.kgen.GenSecretKey()
-		decryptorSk2 = NewDecryptor(testContext.params, sk2)
-		switchingKey = testContext.kgen.GenSwitchingKey(testContext.sk, sk2)
-	}
+		_, err = testContext.evaluator.InverseRangeNew(ciphertextNeg, -1, 1, 1)
+		require.Error(t, err)
+	})
 
-	t.Run(testString(testContext, "SwitchKeys/"), func(t *testing.T) {
+	t.Run(testString(testContext, "Evaluator/InvSqrt/"), func(t *testing.T) {
 
 		if testContext.params.PCount() == 0 {
 			t.Skip("#Pi is empty")
 		}
 
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		a, b, n := 0.5, 1.0, 1
 
-		testContext.evaluator.SwitchKeys(ciphertext, switchingKey, ciphertext)
+		depth, err := InvSqrtDepth(a, b, n)
+		require.NoError(t, err)
 
-		verifyTestVectors(testContext, decryptorSk2, values, ciphertext, testContext.params.LogSlots(), 0, t)
-	})
+		if testContext.params.MaxLevel() < depth {
+			t.Skipf("skipping test for params max level < %d", depth)
+		}
 
-	t.Run(testString(testContext, "SwitchKeysNew/"), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(a, 0), complex(b, 0), t)
 
-		if testContext.params.PCount() == 0 {
-			t.Skip("#Pi is empty")
+		for i := range values {
+			values[i] = complex(1/math.Sqrt(real(values[i])), 0)
 		}
 
-		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		ciphertext, err = testContext.evaluator.InvSqrtNew(ciphertext, a, b, n)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, ciphertext.Level(), testContext.params.MaxLevel()-depth)
 
-		ciphertext = testContext.evaluator.SwitchKeysNew(ciphertext, switchingKey)
+		have := testContext.encoder.Decode(testContext.decryptor.DecryptNew(ciphertext), testContext.params.LogSlots())
 
-		verifyTestVectors(testContext, decryptorSk2, values, ciphertext, testContext.params.LogSlots(), 0, t)
-	})
+		var maxErr float64
+		for i := range values {
+			maxErr = math.Max(maxErr, math.Abs(real(have[i])/real(values[i])-1))
+		}
 
-}
+		// One Newton iteration from a relative precision of 2^-4
+		require.Less(t, math.Log2(maxErr), -7.0)
 
-func testAutomorphisms(testContext *testParams, t *testing.T) {
+		if ciphertext.Level() >= depth {
+			testContext.evaluator.DropLevel(ciphertext, ciphertext.Level()-depth+1)
+		}
+		_, err = testContext.evaluator.InvSqrtNew(ciphertext, a, b, n)
+		require.Error(t, err)
 
-	if testContext.params.PCount() == 0 {
-		t.Skip("#Pi is empty")
-	}
-	rots := []int{0, 1, -1, 4, -4, 63, -63}
-	rotKey := testContext.kgen.GenRotationKeysForRotations(rots, true, testContext.sk)
-	evaluator := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+		_, err = testContext.evaluator.InvSqrtNew(ciphertext, -1, b, n)
+		require.Error(t, err)
+	})
 
-	t.Run(testString(testContext, "Conjugate/"), func(t *testing.T) {
+	t.Run(testString(testContext, "Evaluator/MaskSlots/"), func(t *testing.T) {
 
-		if testContext.params.PCount() == 0 {
-			t.Skip("#Pi is empty")
+		if testContext.params.MaxLevel() < 1 {
+			t.Skip("skipping test for params max level < 1")
 		}
 
 		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
+		mask := make([]bool, len(values)/2)
 		for i := range values {
-			values[i] = complex(real(values[i]), -imag(values[i]))
+			if i < len(mask) && i%3 == 0 {
+				mask[i] = true
+			} else {
+				values[i] = 0
+			}
 		}
 
-		evaluator.Conjugate(ciphertext, ciphertext)
+		scale := ciphertext.Scale()
+
+		ciphertextOut, err := testContext.evaluator.MaskSlotsNew(ciphertext, mask)
+		require.NoError(t, err)
+		require.Equal(t, ciphertext.Level()-1, ciphertextOut.Level())
+		require.Equal(t, scale, ciphertextOut.Scale())
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertextOut, testContext.params.LogSlots(), 0, t)
+
+		require.NoError(t, testContext.evaluator.MaskSlots(ciphertext, mask, ciphertext))
+		require.Equal(t, scale, ciphertext.Scale())
 
 		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+
+		_, err = testContext.evaluator.MaskSlotsNew(ciphertext, make([]bool, testContext.params.Slots()+1))
+		require.Error(t, err)
+
+		testContext.evaluator.DropLevel(ciphertext, ciphertext.Level())
+		_, err = testContext.evaluator.MaskSlotsNew(ciphertext, mask)
+		require.Error(t, err)
 	})
 
-	t.Run(testString(testContext, "ConjugateNew/"), func(t *testing.T) {
+	t.Run(testString(testContext, "Evaluator/ExtractSlotRange/"), func(t *testing.T) {
 
 		if testContext.params.PCount() == 0 {
 			t.Skip("#Pi is empty")
 		}
 
+		if testContext.params.MaxLevel() < 1 {
+			t.Skip("skipping test for params max level < 1")
+		}
+
+		slots := testContext.params.Slots()
+		start, end := 1, slots/2+1
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForExtractSlotRange(start, end), false, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
 		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		for i := range values {
-			values[i] = complex(real(values[i]), -imag(values[i]))
-		}
+		want := make([]complex128, slots)
+		copy(want, values[start:end])
 
-		ciphertext = evaluator.ConjugateNew(ciphertext)
+		ciphertextOut, err := eval.ExtractSlotRangeNew(ciphertext, start, end)
+		require.NoError(t, err)
+		require.Equal(t, ciphertext.Level()-1, ciphertextOut.Level())
 
-		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+		verifyTestVectors(testContext, testContext.decryptor, want, ciphertextOut, testContext.params.LogSlots(), 0, t)
+
+		// Range starting at the slot 0, which does not need any rotation
+		want = make([]complex128, slots)
+		copy(want, values[:1])
+
+		require.NoError(t, testContext.evaluator.ExtractSlotRange(ciphertext, 0, 1, ciphertext))
+
+		verifyTestVectors(testContext, testContext.decryptor, want, ciphertext, testContext.params.LogSlots(), 0, t)
+
+		_, err = eval.ExtractSlotRangeNew(ciphertext, end, start)
+		require.Error(t, err)
+
+		_, err = eval.ExtractSlotRangeNew(ciphertext, 0, slots+1)
+		require.Error(t, err)
 	})
 
-	t.Run(testString(testContext, "Rotate/"), func(t *testing.T) {
+	t.Run(testString(testContext, "Evaluator/WeightedAverage/"), func(t *testing.T) {
+
+		if testContext.params.MaxLevel() < 2 {
+			t.Skip("skipping test for params max level < 2")
+		}
 
+		values0, _, ciphertext0 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		ciphertext2 := NewCiphertext(testContext.params, ciphertext1.Degree(), ciphertext1.Level(), ciphertext1.Scale())
+		// misaligned levels and scales
+		testContext.evaluator.DropLevel(ciphertext1, 1)
+		testContext.evaluator.MultByConst(ciphertext2, 2, ciphertext2)
+		ciphertext2.MulScale(2)
 
-		for _, n := range rots {
-			evaluator.Rotate(ciphertext1, n, ciphertext2)
-			verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), ciphertext2, testContext.params.LogSlots(), 0, t)
+		weights := []float64{0.5, 3, 1.25}
+
+		valuesWant := make([]complex128, len(values0))
+		for i := range valuesWant {
+			valuesWant[i] = (complex(weights[0], 0)*values0[i] + complex(weights[1], 0)*values1[i] + complex(weights[2], 0)*values2[i]) / complex(weights[0]+weights[1]+weights[2], 0)
 		}
+
+		ciphertext := testContext.evaluator.WeightedAverageNew([]*Ciphertext{ciphertext0, ciphertext1, ciphertext2}, weights)
+
+		require.Equal(t, testContext.params.MaxLevel()-2, ciphertext.Level())
+
+		verifyTestVectors(testContext, testContext.decryptor, valuesWant, ciphertext, testContext.params.LogSlots(), 0, t)
 	})
+}
 
-	t.Run(testString(testContext, "RotateNew/"), func(t *testing.T) {
+func testEvaluatePoly(testContext *testParams, t *testing.T) {
 
-		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+	var err error
+
+	t.Run(testString(testContext, "EvaluatePoly/Exp/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		if testContext.params.MaxLevel() < 3 {
+			t.Skip("skipping test for params max level < 3")
+		}
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		coeffs := []complex128{
+			complex(1.0, 0),
+			complex(1.0, 0),
+			complex(1.0/2, 0),
+			complex(1.0/6, 0),
+			complex(1.0/24, 0),
+			complex(1.0/120, 0),
+			complex(1.0/720, 0),
+			complex(1.0/5040, 0),
+		}
+
+		poly := NewPoly(coeffs)
+
+		for i := range values {
+			values[i] = cmplx.Exp(values[i])
+		}
+
+		if ciphertext, err = testContext.evaluator.EvaluatePoly(ciphertext, poly, ciphertext.Scale()); err != nil {
+			t.Error(err)
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+}
+
+func testChebyshevInterpolator(testContext *testParams, t *testing.T) {
+
+	var err error
+
+	t.Run(testString(testContext, "ChebyshevInterpolator/Sin/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		if testContext.params.MaxLevel() < 5 {
+			t.Skip("skipping test for params max level < 5")
+		}
+
+		eval := testContext.evaluator
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		cheby := Approximate(cmplx.Sin, complex(-1.5, 0), complex(1.5, 0), 15)
+
+		for i := range values {
+			values[i] = cmplx.Sin(values[i])
+		}
+
+		eval.MultByConst(ciphertext, 2/(cheby.b-cheby.a), ciphertext)
+		eval.AddConst(ciphertext, (-cheby.a-cheby.b)/(cheby.b-cheby.a), ciphertext)
+		eval.Rescale(ciphertext, eval.(*evaluator).scale, ciphertext)
+
+		if ciphertext, err = eval.EvaluateCheby(ciphertext, cheby, ciphertext.Scale()); err != nil {
+			t.Error(err)
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+}
+
+func testRounding(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 || testContext.params.LogN() > 12 {
+		return
+	}
+
+	// The default test parameters do not have enough levels for the evaluation
+	logQ := []int{55}
+	for i := 0; i < 13; i++ {
+		logQ = append(logQ, 45)
+	}
+
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:     testContext.params.LogN(),
+		LogQ:     logQ,
+		LogP:     []int{61},
+		Sigma:    rlwe.DefaultSigma,
+		LogSlots: testContext.params.LogN() - 1,
+		Scale:    1 << 45,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc, err := genTestParams(params, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// verify evaluates round on values in [-1, 1] and compares the result with the rounding of want
+	verify := func(t *testing.T, values []complex128, want func(x float64) float64, round func(eval Evaluator, ct *Ciphertext) (*Ciphertext, error)) {
+
+		pt := tc.encoder.EncodeNTTNew(values, params.LogSlots())
+
+		ct, err := round(tc.evaluator, tc.encryptorSk.EncryptNew(pt))
+		require.NoError(t, err)
+		require.InDelta(t, 1, ct.Scale()/params.Scale(), 1e-9)
+
+		ctClear, err := round(NewCleartextEvaluator(params), NewCleartextEncryptor(params).EncryptNew(pt))
+		require.NoError(t, err)
+		require.Equal(t, ctClear.Level(), ct.Level())
+
+		// Two iterations of a Chebyshev interpolant of degree 31 and of the change of basis
+		require.Equal(t, params.MaxLevel()-2*6, ct.Level())
+
+		have := tc.encoder.Decode(tc.decryptor.DecryptNew(ct), params.LogSlots())
+
+		var maxErr float64
+		for i := range values {
+			maxErr = math.Max(maxErr, math.Abs(real(have[i])-want(real(values[i]))))
+		}
+
+		require.Less(t, math.Log2(maxErr), -15.0)
+	}
+
+	t.Run(testString(tc, "Rounding/Round/"), func(t *testing.T) {
+
+		// Integers of [-1, 1] with an error of at most 0.1
+		values := make([]complex128, params.Slots())
+		for i := range values {
+			n := float64(i%3 - 1)
+			e := utils.RandFloat64(0, 0.1)
+			values[i] = complex(n-math.Copysign(e, n+0.5), 0)
+		}
+
+		verify(t, values, math.Round, func(eval Evaluator, ct *Ciphertext) (*Ciphertext, error) {
+			return eval.RoundNew(ct, 1, 2)
+		})
+	})
+
+	t.Run(testString(tc, "Rounding/Floor/"), func(t *testing.T) {
+
+		// Values of [-0.6, 0.6] whose fractional part is in [0.4, 0.6]
+		values := make([]complex128, params.Slots())
+		for i := range values {
+			values[i] = complex(float64(i%2-1)+utils.RandFloat64(0.4, 0.6), 0)
+		}
+
+		verify(t, values, math.Floor, func(eval Evaluator, ct *Ciphertext) (*Ciphertext, error) {
+			return eval.FloorNew(ct, 1, 2)
+		})
+	})
+
+	t.Run(testString(tc, "Rounding/NotEnoughLevels/"), func(t *testing.T) {
+		_, _, ct := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+		_, err := testContext.evaluator.RoundNew(ct, 1, 1)
+		require.Error(t, err)
+	})
+}
+
+func testEvalMod(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 || testContext.params.LogN() > 12 {
+		return
+	}
+
+	evm := EvalModParameters{
+		SinType:       Cos1,
+		MessageRatio:  256.0,
+		SinRange:      16,
+		SinDeg:        63,
+		SinRescal:     2,
+		ScalingFactor: 1 << 50,
+	}
+
+	// The default test parameters do not have enough levels for the evaluation
+	logQ := []int{55}
+	for i := 0; i < evm.Depth()+1; i++ {
+		logQ = append(logQ, 50)
+	}
+
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:     testContext.params.LogN(),
+		LogQ:     logQ,
+		LogP:     []int{61},
+		Sigma:    rlwe.DefaultSigma,
+		LogSlots: testContext.params.LogN() - 1,
+		Scale:    evm.ScalingFactor,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc, err := genTestParams(params, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(testString(tc, "EvalMod/"), func(t *testing.T) {
+
+		// Multiples of MessageRatio in (-K, K) * MessageRatio plus a message in [-1, 1]
+		values := make([]complex128, params.Slots())
+		want := make([]complex128, params.Slots())
+		for i := range values {
+			m := utils.RandFloat64(-1, 1)
+			values[i] = complex(math.Round(utils.RandFloat64(-15, 15))*evm.MessageRatio+m, 0)
+			want[i] = complex(evm.MessageRatio*math.Sin(2*math.Pi*real(values[i])/evm.MessageRatio)/(2*math.Pi), 0)
+		}
+
+		pt := tc.encoder.EncodeNTTNew(values, params.LogSlots())
+
+		ct, err := tc.evaluator.EvalModNew(tc.encryptorSk.EncryptNew(pt), evm)
+		require.NoError(t, err)
+		require.Equal(t, params.MaxLevel()-evm.Depth(), ct.Level())
+		require.InDelta(t, 1, ct.Scale()/params.Scale(), 1e-9)
+
+		ctClear, err := NewCleartextEvaluator(params).EvalModNew(NewCleartextEncryptor(params).EncryptNew(pt), evm)
+		require.NoError(t, err)
+		require.Equal(t, ctClear.Level(), ct.Level())
+
+		have := tc.encoder.Decode(tc.decryptor.DecryptNew(ct), params.LogSlots())
+
+		var maxErr float64
+		for i := range have {
+			maxErr = math.Max(maxErr, math.Abs(real(have[i])-real(want[i])))
+		}
+
+		require.Less(t, math.Log2(maxErr), -15.0)
+	})
+
+	t.Run(testString(tc, "EvalMod/InvalidParameters/"), func(t *testing.T) {
+
+		ct := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNTTNew(make([]complex128, params.Slots()), params.LogSlots()))
+
+		invalid := evm
+		invalid.SinType = Sin
+		_, err := tc.evaluator.EvalModNew(ct, invalid)
+		require.Error(t, err)
+
+		_, err = tc.evaluator.EvalModNew(tc.evaluator.DropLevelNew(ct, 2), evm)
+		require.Error(t, err)
+	})
+}
+
+func testEvalPiecewise(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 || testContext.params.LogN() > 12 {
+		return
+	}
+
+	// The default test parameters do not have enough levels for the evaluation
+	logQ := []int{55}
+	for i := 0; i < 13; i++ {
+		logQ = append(logQ, 45)
+	}
+
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:     testContext.params.LogN(),
+		LogQ:     logQ,
+		LogP:     []int{61},
+		Sigma:    rlwe.DefaultSigma,
+		LogSlots: testContext.params.LogN() - 1,
+		Scale:    1 << 45,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc, err := genTestParams(params, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make([]complex128, params.Slots())
+	for i := range values {
+		values[i] = complex(utils.RandFloat64(-1, 1), 0)
+	}
+
+	pt := tc.encoder.EncodeNTTNew(values, params.LogSlots())
+
+	verify := func(t *testing.T, breakpoints []float64, polys []*Poly, f func(x float64) float64) {
+
+		ct, err := tc.evaluator.EvalPiecewise(tc.encryptorSk.EncryptNew(pt), breakpoints, polys, params.Scale())
+		require.NoError(t, err)
+		require.InDelta(t, 1, ct.Scale()/params.Scale(), 1e-9)
+
+		ctClear, err := NewCleartextEvaluator(params).EvalPiecewise(NewCleartextEncryptor(params).EncryptNew(pt), breakpoints, polys, params.Scale())
+		require.NoError(t, err)
+		require.Equal(t, ctClear.Level(), ct.Level())
+
+		have := tc.encoder.Decode(tc.decryptor.DecryptNew(ct), params.LogSlots())
+
+		// Away from the breakpoints the error decays exponentially
+		var maxErr float64
+		for i := range values {
+			x := real(values[i])
+			near := false
+			for _, bp := range breakpoints {
+				near = near || math.Abs(x-bp) < 0.1
+			}
+
+			if !near {
+				maxErr = math.Max(maxErr, math.Abs(real(have[i])-f(x)))
+			}
+		}
+
+		require.Less(t, math.Log2(maxErr), -15.0)
+
+		want := make([]complex128, len(values))
+		for i := range values {
+			want[i] = complex(f(real(values[i])), 0)
+		}
+
+		require.Greater(t, real(GetPrecisionStats(params, tc.encoder, nil, want, have, params.LogSlots(), 0).MeanPrecision), 10.0)
+	}
+
+	t.Run(testString(tc, "EvalPiecewise/ReLU/"), func(t *testing.T) {
+		verify(t, []float64{-1, 0, 1}, []*Poly{NewPoly([]complex128{0}), NewPoly([]complex128{0, 1})}, func(x float64) float64 {
+			return math.Max(x, 0)
+		})
+	})
+
+	t.Run(testString(tc, "EvalPiecewise/Clip/"), func(t *testing.T) {
+		verify(t, []float64{-1, -0.5, 0.5, 1}, []*Poly{NewPoly([]complex128{-0.5}), NewPoly([]complex128{0, 1}), NewPoly([]complex128{0.5})}, func(x float64) float64 {
+			return math.Max(-0.5, math.Min(x, 0.5))
+		})
+	})
+
+	t.Run(testString(tc, "EvalPiecewise/Errors/"), func(t *testing.T) {
+
+		ct := tc.encryptorSk.EncryptNew(pt)
+
+		_, err := tc.evaluator.EvalPiecewise(ct, []float64{-1, 1}, []*Poly{NewPoly([]complex128{0}), NewPoly([]complex128{1})}, params.Scale())
+		require.Error(t, err)
+
+		_, err = tc.evaluator.EvalPiecewise(ct, []float64{-1, 1, 0}, []*Poly{NewPoly([]complex128{0}), NewPoly([]complex128{1})}, params.Scale())
+		require.Error(t, err)
+
+		_, err = tc.evaluator.EvalPiecewise(tc.evaluator.DropLevelNew(ct, 4), []float64{-1, 0, 1}, []*Poly{NewPoly([]complex128{0}), NewPoly([]complex128{0, 1})}, params.Scale())
+		require.Error(t, err)
+	})
+}
+
+func testPolyEvaluationPlan(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 || testContext.params.MaxLevel() < 5 {
+		return
+	}
+
+	params := testContext.params
+	eval := testContext.evaluator
+
+	// Returns the largest absolute error between the decrypted ciphertext and the values
+	maxErr := func(values []complex128, ct *Ciphertext) (err float64) {
+		have := testContext.encoder.Decode(testContext.decryptor.DecryptNew(ct), params.LogSlots())
+		for i := range values {
+			err = math.Max(err, cmplx.Abs(have[i]-values[i]))
+		}
+		return
+	}
+
+	t.Run(testString(testContext, "EvaluatePlan/Exact/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		cheby := Approximate(cmplx.Sin, complex(-1, 0), complex(1, 0), 15)
+
+		for i := range values {
+			values[i] = cmplx.Sin(values[i])
+		}
+
+		plan, err := NewPolyEvaluationPlan(params, ciphertext, cheby, ciphertext.Scale())
+		require.NoError(t, err)
+		require.False(t, plan.Normalize)
+		require.Equal(t, 15, plan.Degree)
+		require.Equal(t, 2, plan.LogSplit)
+		require.Zero(t, plan.TruncationError)
+
+		ctPlan, err := eval.EvaluatePlan(ciphertext, plan)
+		require.NoError(t, err)
+
+		ctCheby, err := eval.EvaluateCheby(ciphertext, cheby, ciphertext.Scale())
+		require.NoError(t, err)
+
+		require.Equal(t, ctCheby.Level(), ctPlan.Level())
+		require.Equal(t, plan.OutputLevel, ctPlan.Level())
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ctPlan, params.LogSlots(), 0, t)
+		require.Less(t, maxErr(values, ctPlan), plan.ErrorBound())
+	})
+
+	t.Run(testString(testContext, "EvaluatePlan/Truncated/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		// Not enough levels for the full degree
+		eval.DropLevel(ciphertext, ciphertext.Level()-3)
+
+		sin4 := func(x complex128) complex128 { return cmplx.Sin(4 * x) }
+
+		cheby := Approximate(sin4, complex(-1, 0), complex(1, 0), 31)
+
+		for i := range values {
+			values[i] = sin4(values[i])
+		}
+
+		_, err := eval.EvaluateCheby(ciphertext, cheby, ciphertext.Scale())
+		require.Error(t, err)
+
+		plan, err := NewPolyEvaluationPlan(params, ciphertext, cheby, ciphertext.Scale())
+		require.NoError(t, err)
+		require.Equal(t, 7, plan.Degree)
+		require.Equal(t, 0, plan.OutputLevel)
+		require.Greater(t, plan.TruncationError, 0.0)
+
+		// The ciphertext is encrypted with the secret key, whose noise is smaller than the default assumption
+		noisePk := plan.NoiseError
+		est := NewCanonicalBoundEstimator(params, 0)
+		plan.EstimateNoise(est, CanonicalBound{
+			Level:   ciphertext.Level(),
+			Scale:   ciphertext.Scale(),
+			Message: ciphertext.Scale(),
+			Error:   est.EncodingError() + est.FreshError(false),
+		})
+		require.Less(t, plan.NoiseError, noisePk)
+
+		ct, err := eval.EvaluatePlan(ciphertext, plan)
+		require.NoError(t, err)
+
+		require.Less(t, maxErr(values, ct), plan.ErrorBound())
+	})
+
+	t.Run(testString(testContext, "EvaluatePlan/Scale/"), func(t *testing.T) {
+
+		values, _, _ := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		// Ciphertext whose scale is far from the moduli
+		pt := NewPlaintext(params, params.MaxLevel(), params.Scale()*(1<<10))
+		testContext.encoder.EncodeNTT(pt, values, params.LogSlots())
+		ciphertext := testContext.encryptorSk.EncryptNew(pt)
+
+		pol := NewPoly([]complex128{0.5, 0, -0.25, 1})
+
+		for i := range values {
+			values[i] = 0.5 - 0.25*values[i]*values[i] + values[i]*values[i]*values[i]
+		}
+
+		plan, err := NewPolyEvaluationPlan(params, ciphertext, pol, params.Scale())
+		require.NoError(t, err)
+		require.True(t, plan.Normalize)
+		require.Equal(t, params.MaxLevel()-3, plan.OutputLevel)
+
+		ct, err := eval.EvaluatePlan(ciphertext, plan)
+		require.NoError(t, err)
+		require.Equal(t, plan.OutputLevel, ct.Level())
+
+		require.Less(t, maxErr(values, ct), 1e-3)
+	})
+
+	t.Run(testString(testContext, "EvaluatePlan/Cleartext/"), func(t *testing.T) {
+
+		_, pt, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		cheby := Approximate(cmplx.Exp, complex(-1, 0), complex(1, 0), 31)
+
+		eval.DropLevel(ciphertext, ciphertext.Level()-3)
+
+		plan, err := NewPolyEvaluationPlan(params, ciphertext, cheby, ciphertext.Scale())
+		require.NoError(t, err)
+
+		ct, err := eval.EvaluatePlan(ciphertext, plan)
+		require.NoError(t, err)
+
+		ctClear, err := NewCleartextEvaluator(params).EvaluatePlan(NewCleartextEncryptor(params).EncryptNew(pt), plan)
+		require.NoError(t, err)
+		require.Equal(t, ct.Level(), ctClear.Level())
+
+		verifyTestVectors(testContext, testContext.decryptor, getCleartext(ctClear.El(), params.Slots()), ct, params.LogSlots(), 0, t)
+	})
+}
+
+func testDecryptPublic(testContext *testParams, t *testing.T) {
+
+	var err error
+
+	t.Run(testString(testContext, "DecryptPublic/Sin/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		if testContext.params.MaxLevel() < 5 {
+			t.Skip("skipping test for params max level < 5")
+		}
+
+		eval := testContext.evaluator
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		cheby := Approximate(cmplx.Sin, complex(-1.5, 0), complex(1.5, 0), 15)
+
+		for i := range values {
+			values[i] = cmplx.Sin(values[i])
+		}
+
+		eval.MultByConst(ciphertext, 2/(cheby.b-cheby.a), ciphertext)
+		eval.AddConst(ciphertext, (-cheby.a-cheby.b)/(cheby.b-cheby.a), ciphertext)
+		eval.Rescale(ciphertext, eval.(*evaluator).scale, ciphertext)
+
+		if ciphertext, err = eval.EvaluateCheby(ciphertext, cheby, ciphertext.Scale()); err != nil {
+			t.Error(err)
+		}
+
+		plaintext := testContext.decryptor.DecryptNew(ciphertext)
+
+		valuesHave := testContext.encoder.Decode(plaintext, testContext.params.LogSlots())
+
+		verifyTestVectors(testContext, nil, values, valuesHave, testContext.params.LogSlots(), 0, t)
+
+		sigma := testContext.encoder.GetErrSTDCoeffDomain(values, valuesHave, plaintext.Scale())
+
+		valuesHave = testContext.encoder.DecodePublic(plaintext, testContext.params.LogSlots(), sigma)
+
+		verifyTestVectors(testContext, nil, values, valuesHave, testContext.params.LogSlots(), 0, t)
+	})
+}
+
+func testCompression(testContext *testParams, t *testing.T) {
+
+	t.Run(testString(testContext, "Compression/ModulusSwitch/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		compressed := testContext.evaluator.CompressNew(ciphertext, 0)
+
+		require.Equal(t, 0, compressed.DroppedBits())
+		require.Equal(t, ciphertext.Degree(), compressed.Degree())
+
+		ctOut := compressed.Decompress(testContext.params)
+
+		require.Equal(t, 0, ctOut.Level())
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ctOut, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "Compression/DroppedBits/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		droppedBits := testContext.params.CompressionDroppedBits(ciphertext.Scale(), minPrec+2)
+		require.Greater(t, droppedBits, 0)
+
+		compressed := testContext.evaluator.CompressNew(ciphertext, droppedBits)
+
+		data, err := compressed.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, compressed.GetDataLen(), len(data))
+
+		full, err := ciphertext.MarshalBinary()
+		require.NoError(t, err)
+		require.Less(t, len(data), len(full))
+
+		compressedTest := new(CompressedCiphertext)
+		require.NoError(t, compressedTest.UnmarshalBinary(data))
+		require.Equal(t, compressed, compressedTest)
+
+		// Malformed headers and lengths are rejected before any allocation
+		require.Error(t, compressedTest.UnmarshalBinary(data[:len(data)-1]))
+		require.Error(t, compressedTest.UnmarshalBinary(append(data, 0)))
+		header := append([]byte{}, data[:20]...)
+		header[19] = 62
+		require.Error(t, compressedTest.UnmarshalBinary(header))
+
+		verifyTestVectors(testContext, testContext.decryptor, values, compressedTest.Decompress(testContext.params), testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "Compression/Sanitize/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		logPrecision := 20.0
+
+		ctOut := testContext.evaluator.SanitizeNew(ciphertext, logPrecision)
+
+		require.Equal(t, 0, ctOut.Level())
+		require.Equal(t, ciphertext.Scale(), ctOut.Scale())
+
+		// The slots carry an error of standard deviation about 2^-logPrecision
+		valuesHave := testContext.encoder.Decode(testContext.decryptor.DecryptNew(ctOut), testContext.params.LogSlots())
+
+		var variance float64
+		for i := range values {
+			variance += math.Pow(cmplx.Abs(valuesHave[i]-values[i]), 2)
+		}
+		variance /= float64(len(values))
+
+		require.InDelta(t, -logPrecision, math.Log2(math.Sqrt(variance)), 0.5)
+
+		require.Panics(t, func() { testContext.evaluator.SanitizeNew(ciphertext, math.Log2(ciphertext.Scale())) })
+	})
+
+	t.Run(testString(testContext, "Compression/FloodingLogPrecision/"), func(t *testing.T) {
+		require.InDelta(t, 30-0.5-math.Log2(math.Sqrt(2*math.Log(1.25e6))), FloodingLogPrecision(math.Exp2(-30), 1, 1e-6), 1e-9)
+		require.Less(t, FloodingLogPrecision(math.Exp2(-30), 0.5, 1e-6), FloodingLogPrecision(math.Exp2(-30), 1, 1e-6))
+	})
+}
+
+func testSwitchKeys(testContext *testParams, t *testing.T) {
+
+	var sk2 *rlwe.SecretKey
+	var decryptorSk2 Decryptor
+	var switchingKey *rlwe.SwitchingKey
+
+	if testContext.params.PCount() != 0 {
+		sk2 = testContext.kgen.GenSecretKey()
+		decryptorSk2 = NewDecryptor(testContext.params, sk2)
+		switchingKey = testContext.kgen.GenSwitchingKey(testContext.sk, sk2)
+	}
+
+	t.Run(testString(testContext, "SwitchKeys/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		testContext.evaluator.SwitchKeys(ciphertext, switchingKey, ciphertext)
+
+		verifyTestVectors(testContext, decryptorSk2, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "SwitchKeysNew/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		ciphertext = testContext.evaluator.SwitchKeysNew(ciphertext, switchingKey)
+
+		verifyTestVectors(testContext, decryptorSk2, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "RGSW/CMux/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		params := testContext.params
+		rgswEncryptor := rlwe.NewRGSWEncryptor(params.Parameters, testContext.sk)
+		rgswEvaluator := rlwe.NewRGSWEvaluator(params.Parameters)
+
+		values0, _, ciphertext0 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for bit, values := range [][]complex128{values0, values1} {
+			m := params.RingQ().NewPoly()
+			for i := range m.Coeffs {
+				m.Coeffs[i][0] = uint64(bit)
+			}
+			ciphertext := NewCiphertext(params, 1, ciphertext0.Level(), ciphertext0.Scale())
+			rgswEvaluator.CMux(rgswEncryptor.EncryptNew(m), ciphertext0.Element, ciphertext1.Element, ciphertext.Element)
+			verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+		}
+	})
+	t.Run(testString(testContext, "SwitchKeys/ExternalKeyGenerator/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		prng, err := utils.NewPRNG()
+		require.NoError(t, err)
+
+		op := rlwe.NewSecretKeyOperator(testContext.params.Parameters, testContext.sk, prng)
+		kgen := rlwe.NewExternalKeyGenerator(testContext.params.Parameters, op, prng)
+
+		pk := kgen.GenPublicKey()
+		rlk := kgen.GenRelinearizationKey(1)
+		galEl := testContext.params.GaloisElementForColumnRotationBy(1)
+		rtks := kgen.GenRotationKeys([]uint64{galEl})
+
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks})
+
+		values, _, ciphertext := newTestVectors(testContext, NewEncryptorFromPk(testContext.params, pk), complex(-1, -1), complex(1, 1), t)
+
+		ciphertext = eval.MulRelinNew(ciphertext, ciphertext)
+		eval.Rotate(ciphertext, 1, ciphertext)
+
+		slots := len(values)
+		want := make([]complex128, slots)
+		for i := range want {
+			want[i] = values[(i+1)%slots] * values[(i+1)%slots]
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, want, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "SwitchKeys/Gadget/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		params := testContext.params.Parameters
+		galEl := testContext.params.GaloisElementForColumnRotationBy(1)
+
+		for _, gadget := range []rlwe.Gadget{
+			rlwe.NewRNSGadget(params),
+			rlwe.NewBitGadget(params, 16),
+			rlwe.NewHybridGadget(params, 20),
+		} {
+			eval := NewEvaluatorWithGadget(testContext.params, gadget, rlwe.EvaluationKey{
+				Rlk:  rlwe.GenRelinearizationKeyWithGadget(params, gadget, testContext.sk, 1),
+				Rtks: rlwe.GenRotationKeysWithGadget(params, gadget, []uint64{galEl}, testContext.sk),
+			})
+
+			values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			ciphertext = eval.MulRelinNew(ciphertext, ciphertext)
+			eval.Rotate(ciphertext, 1, ciphertext)
+
+			slots := len(values)
+			want := make([]complex128, slots)
+			for i := range want {
+				want[i] = values[(i+1)%slots] * values[(i+1)%slots]
+			}
+
+			verifyTestVectors(testContext, testContext.decryptor, want, ciphertext, testContext.params.LogSlots(), 0, t)
+
+			// The hoisted rotations decompose the ciphertext once with the gadget and give the same result as the
+			// rotations. The noise of the gadgets without P is not divided, so the precision of the linear
+			// transformation is compared with the one of the rotation.
+			values, _, ciphertext = newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			rotatedWant := eval.RotateNew(ciphertext, 1)
+			rotated := eval.RotateHoisted(ciphertext, []int{0, 1})
+			require.True(t, testContext.ringQ.Equal(ciphertext.Value[0], rotated[0].Value[0]))
+			require.True(t, testContext.ringQ.Equal(rotatedWant.Value[0], rotated[1].Value[0]))
+			require.True(t, testContext.ringQ.Equal(rotatedWant.Value[1], rotated[1].Value[1]))
+
+			diagMatrix := map[int][]complex128{0: make([]complex128, slots), 1: make([]complex128, slots)}
+			for i := 0; i < slots; i++ {
+				diagMatrix[0][i] = complex(0.5, 0)
+				diagMatrix[1][i] = complex(0.25, 0)
+			}
+			ptDiagMatrix := testContext.encoder.EncodeDiagMatrixAtLvl(ciphertext.Level(), diagMatrix, testContext.params.Scale(), testContext.params.LogSlots())
+			res := eval.LinearTransform(ciphertext, ptDiagMatrix)[0]
+			for i := range want {
+				want[i] = 0.5*values[i] + 0.25*values[(i+1)%slots]
+			}
+
+			precRot := GetPrecisionStats(testContext.params, testContext.encoder, testContext.decryptor, utils.RotateComplex128Slice(values, 1), rotatedWant, testContext.params.LogSlots(), 0)
+			precLT := GetPrecisionStats(testContext.params, testContext.encoder, testContext.decryptor, want, res, testContext.params.LogSlots(), 0)
+			require.GreaterOrEqual(t, real(precLT.MeanPrecision), math.Min(real(precRot.MeanPrecision)-1, minPrec))
+		}
+	})
+
+	t.Run(testString(testContext, "SwitchKeys/ParameterPair/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		large := testContext.params
+
+		small, err := NewParametersFromLiteral(ParametersLiteral{
+			LogN:     large.LogN() - 1,
+			Q:        large.Q()[:utils.MaxInt(1, large.QCount()-1)],
+			P:        large.P(),
+			Sigma:    large.Sigma(),
+			LogSlots: large.LogN() - 2,
+			Scale:    large.Scale(),
+		})
+		require.NoError(t, err)
+
+		pp, err := NewParameterPair(large, small)
+		require.NoError(t, err)
+
+		_, err = NewParameterPair(small, large)
+		require.Error(t, err)
+
+		skSmall := NewKeyGenerator(small).GenSecretKey()
+		rs := NewRingSwitcher(pp, pp.GenRingSwitchingKeys(testContext.sk, skSmall))
+
+		logSlots := small.LogSlots()
+		values := make([]complex128, 1<<logSlots)
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		}
+
+		ctLarge := testContext.encryptorSk.EncryptNew(testContext.encoder.EncodeNTTAtLvlNew(pp.MaxLevel(), values, logSlots))
+
+		ctSmall := rs.ToSmallNew(ctLarge)
+		require.Equal(t, small.N(), ctSmall.Value[0].Degree())
+		require.Equal(t, pp.MaxLevel(), ctSmall.Level())
+
+		verifyTestVectors(&testParams{params: small, encoder: NewEncoder(small)}, NewDecryptor(small, skSmall), values, ctSmall, logSlots, 0, t)
+
+		ctLarge = rs.ToLargeNew(ctSmall)
+		require.Equal(t, large.N(), ctLarge.Value[0].Degree())
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ctLarge, logSlots, 0, t)
+	})
+}
+
+func testAutomorphisms(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+	rots := []int{0, 1, -1, 4, -4, 63, -63}
+	rotKey := testContext.kgen.GenRotationKeysForRotations(rots, true, testContext.sk)
+	evaluator := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+	t.Run(testString(testContext, "Conjugate/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values {
+			values[i] = complex(real(values[i]), -imag(values[i]))
+		}
+
+		evaluator.Conjugate(ciphertext, ciphertext)
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "ConjugateNew/"), func(t *testing.T) {
+
+		if testContext.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values {
+			values[i] = complex(real(values[i]), -imag(values[i]))
+		}
+
+		ciphertext = evaluator.ConjugateNew(ciphertext)
+
+		verifyTestVectors(testContext, testContext.decryptor, values, ciphertext, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "Rotate/"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		ciphertext2 := NewCiphertext(testContext.params, ciphertext1.Degree(), ciphertext1.Level(), ciphertext1.Scale())
+
+		for _, n := range rots {
+			evaluator.Rotate(ciphertext1, n, ciphertext2)
+			verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), ciphertext2, testContext.params.LogSlots(), 0, t)
+		}
+	})
+
+	t.Run(testString(testContext, "RotateNew/"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for _, n := range rots {
+			verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), evaluator.RotateNew(ciphertext1, n), testContext.params.LogSlots(), 0, t)
+		}
+
+	})
+
+	t.Run(testString(testContext, "RotateHoisted/"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		ciphertexts := evaluator.RotateHoisted(ciphertext1, rots)
+
+		for _, n := range rots {
+			verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), ciphertexts[n], testContext.params.LogSlots(), 0, t)
+		}
+	})
+
+	t.Run(testString(testContext, "RotateBy/"), func(t *testing.T) {
+
+		params := testContext.params.Parameters
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, 4), evaluator.RotateByNew(ciphertext1, rlwe.RotateLeft(4)), testContext.params.LogSlots(), 0, t)
+		verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, -4), evaluator.RotateByNew(ciphertext1, rlwe.RotateRight(4)), testContext.params.LogSlots(), 0, t)
+
+		rot, ok := rlwe.SlotRotationFromGaloisElement(params, rlwe.RotateRight(4).GaloisElement(params))
+		require.True(t, ok)
+		require.Equal(t, rlwe.RotateRight(4).Normalized(params), rot)
+		_, ok = rlwe.SlotRotationFromGaloisElement(params, params.GaloisElementForRowRotation())
+		require.False(t, ok)
+
+		// X^N = -1
+		ciphertext2 := evaluator.RotateByNew(ciphertext1, rlwe.CoeffShift(testContext.params.N()))
+		values2 := make([]complex128, len(values1))
+		for i := range values1 {
+			values2[i] = -values1[i]
+		}
+		verifyTestVectors(testContext, testContext.decryptor, values2, ciphertext2, testContext.params.LogSlots(), 0, t)
+
+		evaluator.RotateBy(ciphertext1, rlwe.CoeffShift(5), ciphertext2)
+		evaluator.RotateBy(ciphertext2, rlwe.CoeffShift(-5), ciphertext2)
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext2, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "MultByMonomial/"), func(t *testing.T) {
+
+		N := testContext.params.N()
+
+		values := make([]float64, N)
+		for i := range values {
+			values[i] = utils.RandFloat64(-1, 1)
+		}
+
+		plaintext := NewPlaintext(testContext.params, testContext.params.MaxLevel(), testContext.params.Scale())
+		testContext.encoder.EncodeCoeffs(values, plaintext)
+		ciphertext := testContext.encryptorSk.EncryptNew(plaintext)
+
+		verifyCoeffs := func(want []float64, ciphertext *Ciphertext) {
+			have := testContext.encoder.DecodeCoeffs(testContext.decryptor.DecryptNew(ciphertext))
+			for i := range want {
+				require.InDelta(t, want[i], have[i], 1e-3)
+			}
+		}
+
+		for _, k := range []int{0, 1, 7, -3, N + 5, 2*N - 1} {
+			verifyCoeffs(NegacyclicShift(values, k), evaluator.MultByMonomialNew(ciphertext, k))
+		}
+
+		// Convolution with a kernel of three taps
+		kernel := make([]float64, N)
+		kernel[0], kernel[1], kernel[2] = 0.5, -0.25, 0.125
+
+		acc := NewCiphertext(testContext.params, 1, ciphertext.Level(), ciphertext.Scale())
+		tmp := NewCiphertext(testContext.params, 1, ciphertext.Level(), ciphertext.Scale())
+		for j := 0; j < 3; j++ {
+			evaluator.MultByMonomial(ciphertext, j, tmp)
+			evaluator.MultByConst(tmp, kernel[j], tmp)
+			evaluator.Add(acc, tmp, acc)
+		}
+
+		verifyCoeffs(NegacyclicConvolution(values, kernel), acc)
+
+		require.Equal(t, []float64{-3, 1, 2}, NegacyclicShift([]float64{1, 2, 3}, 1))
+		require.Equal(t, []float64{2, 3, -1}, NegacyclicShift([]float64{1, 2, 3}, -1))
+	})
+
+	t.Run(testString(testContext, "RotationKeyProvider/"), func(t *testing.T) {
+
+		// Generates the rotation keys on demand and caches them
+		cache := make(map[uint64]*rlwe.SwitchingKey)
+		provider := rlwe.RotationKeyProviderFunc(func(galEl uint64) (*rlwe.SwitchingKey, bool) {
+			if _, inCache := cache[galEl]; !inCache {
+				cache[galEl] = testContext.kgen.GenSwitchingKeyForGalois(galEl, testContext.sk)
+			}
+			return cache[galEl], true
+		})
+
+		evaluator := testContext.evaluator.WithRotationKeyProvider(provider)
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for _, n := range []int{1, -5} {
+			verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), evaluator.RotateNew(ciphertext1, n), testContext.params.LogSlots(), 0, t)
+		}
+
+		ciphertexts := evaluator.RotateHoisted(ciphertext1, []int{1, 3})
+		verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, 3), ciphertexts[3], testContext.params.LogSlots(), 0, t)
+
+		require.Len(t, cache, 3)
+	})
+}
+
+func testTrace(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	params := testContext.params
+	logN, logSlots := params.LogN(), params.LogSlots()
+
+	rotKey := testContext.kgen.GenRotationKeysForRotations(params.RotationsForTrace(0, logN), true, testContext.sk)
+	eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+	// verifyTrace verifies the trace over 2^logTerms terms, whose result and error are divided by 2^logTerms by
+	// reinterpreting the scale of the ciphertext, so that the precision is measured relatively to the number of terms
+	verifyTrace := func(valuesWant []complex128, ciphertext *Ciphertext, logTerms int) {
+		ciphertext.MulScale(math.Exp2(float64(logTerms)))
+		for j := range valuesWant {
+			valuesWant[j] /= complex(math.Exp2(float64(logTerms)), 0)
+		}
+		verifyTestVectors(testContext, testContext.decryptor, valuesWant, ciphertext, logSlots, 0, t)
+	}
+
+	t.Run(testString(testContext, "Trace/Slots/"), func(t *testing.T) {
+
+		values, plaintext, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		start := utils.MaxInt(logSlots-4, 0)
+
+		eval.Trace(ciphertext, start, logSlots, ciphertext)
+
+		want := make([]complex128, len(values))
+		for j := range want {
+			for k := 0; k < 1<<(logSlots-start); k++ {
+				want[j] += values[(j+k<<start)%len(values)]
+			}
+		}
+
+		ctClear := NewCleartextEvaluator(params).TraceNew(NewCleartextEncryptor(params).EncryptNew(plaintext), start, logSlots)
+		verifyTestVectors(testContext, NewCleartextDecryptor(params), want, ctClear, logSlots, 0, t)
+
+		verifyTrace(want, ciphertext, logSlots-start)
+	})
+
+	t.Run(testString(testContext, "Trace/SubSum/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		// The sparse slots are replicated 2^(LogN-1-LogSlots) times on the N/2 slots
+		ciphertext = eval.TraceNew(ciphertext, logSlots, logN-1)
+
+		for j := range values {
+			values[j] *= complex(math.Exp2(float64(logN-1-logSlots)), 0)
+		}
+
+		verifyTrace(values, ciphertext, logN-1-logSlots)
+	})
+
+	t.Run(testString(testContext, "Trace/Full/"), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		eval.Trace(ciphertext, 0, logN, ciphertext)
+
+		// N times the real part of the constant coefficient
+		var sum complex128
+		for _, v := range values {
+			sum += v
+		}
+
+		for j := range values {
+			values[j] = complex(real(sum)*math.Exp2(float64(logN-logSlots)), 0)
+		}
+
+		verifyTrace(values, ciphertext, logN)
+	})
+
+	t.Run(testString(testContext, "Trace/InvalidRange/"), func(t *testing.T) {
+		_, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		require.Panics(t, func() { eval.TraceNew(ciphertext, 2, 1) })
+		require.Panics(t, func() { eval.TraceNew(ciphertext, 0, logN+1) })
+	})
+}
+
+func testInnerSum(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	t.Run(testString(testContext, "InnerSum/"), func(t *testing.T) {
+		batch := 2
+		n := 35
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForInnerSum(batch, n), false, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		eval.InnerSum(ciphertext1, batch, n, ciphertext1)
+
+		tmp0 := make([]complex128, len(values1))
+		copy(tmp0, values1)
+
+		for i := 1; i < n; i++ {
+
+			tmp1 := utils.RotateComplex128Slice(tmp0, i*batch)
+
+			for j := range values1 {
+				values1[j] += tmp1[j]
+			}
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "InnerSumLog/"), func(t *testing.T) {
+
+		batch := 3
+		n := 15
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForInnerSumLog(batch, n), false, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		eval.InnerSumLog(ciphertext1, batch, n, ciphertext1)
+
+		tmp0 := make([]complex128, len(values1))
+		copy(tmp0, values1)
+
+		for i := 1; i < n; i++ {
+
+			tmp1 := utils.RotateComplex128Slice(tmp0, i*batch)
+
+			for j := range values1 {
+				values1[j] += tmp1[j]
+			}
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
+
+	})
+
+	for _, layout := range []GroupLayout{GroupGathered, GroupReplicated} {
+
+		for _, average := range []bool{false, true} {
+
+			name := map[GroupLayout]string{GroupGathered: "Gathered", GroupReplicated: "Replicated"}[layout]
+			op := map[bool]string{false: "InnerSumGroups", true: "AverageGroups"}[average]
+
+			t.Run(testString(testContext, op+"/"+name+"/"), func(t *testing.T) {
+
+				if testContext.params.MaxLevel() == 0 {
+					t.Skip("skipping test for params max level = 0")
+				}
+
+				batch := 2
+				n := 4
+
+				rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForInnerSumGroups(batch, n, layout), false, testContext.sk)
+				eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+				values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+				want := make([]complex128, len(values))
+				for i := 0; i < len(values); i += batch * n {
+					for j := 0; j < batch; j++ {
+
+						var sum complex128
+						for k := 0; k < n; k++ {
+							sum += values[i+k*batch+j]
+						}
+
+						if average {
+							sum /= complex(float64(n), 0)
+						}
+
+						for k := 0; k < n; k++ {
+							if k == 0 || layout == GroupReplicated {
+								want[i+k*batch+j] = sum
+							}
+						}
+					}
+				}
+
+				scale := ciphertext.Scale()
+
+				if average {
+					eval.AverageGroups(ciphertext, batch, n, layout, ciphertext)
+				} else {
+					eval.InnerSumGroups(ciphertext, batch, n, layout, ciphertext)
+				}
+
+				require.Equal(t, testContext.params.MaxLevel()-1, ciphertext.Level())
+				require.InDelta(t, 1, ciphertext.Scale()/scale, 1e-9)
+
+				verifyTestVectors(testContext, testContext.decryptor, want, ciphertext, testContext.params.LogSlots(), 0, t)
+			})
+		}
+	}
+}
+
+func testReplicate(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	t.Run(testString(testContext, "Replicate/"), func(t *testing.T) {
+		batch := 2
+		n := 35
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForReplicate(batch, n), false, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		eval.Replicate(ciphertext1, batch, n, ciphertext1)
+
+		tmp0 := make([]complex128, len(values1))
+		copy(tmp0, values1)
+
+		for i := 1; i < n; i++ {
+
+			tmp1 := utils.RotateComplex128Slice(tmp0, i*-batch)
+
+			for j := range values1 {
+				values1[j] += tmp1[j]
+			}
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "ReplicateLog/"), func(t *testing.T) {
+
+		batch := 3
+		n := 15
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForReplicateLog(batch, n), false, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		eval.ReplicateLog(ciphertext1, batch, n, ciphertext1)
+
+		tmp0 := make([]complex128, len(values1))
+		copy(tmp0, values1)
+
+		for i := 1; i < n; i++ {
+
+			tmp1 := utils.RotateComplex128Slice(tmp0, i*-batch)
+
+			for j := range values1 {
+				values1[j] += tmp1[j]
+			}
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
+
+	})
+}
+
+func testVectorCiphertext(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		return
+	}
+
+	params := testContext.params
+	slots := params.Slots()
+	length := 2*slots + slots/2
+
+	newTestVector := func() (values []complex128, vct *VectorCiphertext) {
+		values = make([]complex128, length)
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		}
+		return values, EncryptVectorNew(params, testContext.encoder, testContext.encryptorSk, values)
+	}
+
+	verifyTestVector := func(valuesWant []complex128, vct *VectorCiphertext, t *testing.T) {
+		require.Equal(t, len(valuesWant), vct.Len())
+		chunk := make([]complex128, slots)
+		for i, ct := range vct.Ciphertexts {
+			for j := range chunk {
+				chunk[j] = 0
+			}
+			copy(chunk, valuesWant[i*slots:utils.MinInt((i+1)*slots, length)])
+			verifyTestVectors(testContext, testContext.decryptor, chunk, ct, params.LogSlots(), 0, t)
+		}
+	}
+
+	t.Run(testString(testContext, "VectorCiphertext/Encrypt/"), func(t *testing.T) {
+		values, vct := newTestVector()
+		require.Equal(t, 3, len(vct.Ciphertexts))
+		valuesHave := DecryptVectorNew(params, testContext.encoder, testContext.decryptor, vct)
+		require.Equal(t, length, len(valuesHave))
+		verifyTestVector(values, vct, t)
+	})
+
+	t.Run(testString(testContext, "VectorCiphertext/AddMul/"), func(t *testing.T) {
+
+		eval := NewVectorEvaluator(params, testContext.evaluator)
+
+		values1, vct1 := newTestVector()
+		values2, vct2 := newTestVector()
+
+		for i := range values1 {
+			values1[i] = (values1[i] + values2[i]) * values2[i]
+		}
+
+		vct3 := eval.AddNew(vct1, vct2)
+		eval.Mul(vct3, vct2, vct3)
+		if err := eval.Rescale(vct3, params.Scale(), vct3); err != nil {
+			t.Fatal(err)
+		}
+
+		verifyTestVector(values1, vct3, t)
+	})
+
+	for _, k := range []int{slots + 3, -5} {
+
+		t.Run(testString(testContext, fmt.Sprintf("VectorCiphertext/Rotate/k=%d/", k)), func(t *testing.T) {
+
+			rotKey := testContext.kgen.GenRotationKeysForRotations(params.RotationsForVectorRotate(length, k), false, testContext.sk)
+			eval := NewVectorEvaluator(params, testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey}))
+
+			values, vct := newTestVector()
+
+			vct = eval.RotateNew(vct, k)
+
+			require.Equal(t, params.MaxLevel()-1, vct.Level())
+			require.Equal(t, params.Scale(), vct.Scale())
+
+			verifyTestVector(utils.RotateComplex128Slice(values, k), vct, t)
+		})
+	}
+
+	t.Run(testString(testContext, "VectorCiphertext/InnerSum/"), func(t *testing.T) {
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(params.RotationsForVectorInnerSum(), false, testContext.sk)
+		eval := NewVectorEvaluator(params, testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey}))
+
+		values, vct := newTestVector()
+
+		var sum complex128
+		for _, v := range values {
+			sum += v
+		}
+
+		valuesWant := make([]complex128, slots)
+		for i := range valuesWant {
+			valuesWant[i] = sum
+		}
+
+		// The noise of the rotations is summed over all the slots, so the expected precision is lowered accordingly.
+		precStats := GetPrecisionStats(params, testContext.encoder, testContext.decryptor, valuesWant, eval.InnerSum(vct), params.LogSlots(), 0)
+		require.GreaterOrEqual(t, real(precStats.MeanPrecision), minPrec-float64(params.LogSlots())/2)
+		require.GreaterOrEqual(t, imag(precStats.MeanPrecision), minPrec-float64(params.LogSlots())/2)
+	})
+}
+
+func testRecordingEvaluator(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		return
+	}
+
+	params := testContext.params
+
+	t.Run(testString(testContext, "RecordingEvaluator/"), func(t *testing.T) {
+
+		eval := NewRecordingEvaluator(params, testContext.evaluator, testContext.decryptor)
+
+		values1, _, ct1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ct2 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		eval.SetReference(ct1, values1)
+		eval.SetReference(ct2, values2)
+
+		ct3 := eval.MulRelinNew(ct1, ct2)
+		require.NoError(t, eval.Rescale(ct3, params.Scale(), ct3))
+		eval.AddConst(ct3, 1, ct3)
+
+		for i := range values1 {
+			values1[i] = values1[i]*values2[i] + 1
+		}
+		verifyTestVectors(testContext, testContext.decryptor, values1, ct3, params.LogSlots(), 0, t)
+
+		trace := eval.Records()
+		require.Len(t, trace, 3)
+
+		require.Equal(t, "MulRelinNew", trace[0].Operation)
+		require.Equal(t, []int{params.MaxLevel(), params.MaxLevel()}, trace[0].Levels)
+		require.InDelta(t, 2*math.Log2(params.Scale()), trace[0].OutLogScale, 1e-9)
+
+		require.Equal(t, "Rescale", trace[1].Operation)
+		require.Equal(t, params.MaxLevel()-1, trace[1].OutLevel)
+
+		require.Equal(t, "AddConst", trace[2].Operation)
+		require.Equal(t, []int{params.MaxLevel() - 1}, trace[2].Levels)
+
+		for i, record := range trace {
+			require.Equal(t, i, record.Index)
+			require.Equal(t, 1, record.OutDegree)
+			require.Greater(t, record.MinPrecision, 10.0)
+			require.GreaterOrEqual(t, record.MeanPrecision, record.MinPrecision)
+		}
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, eval.WriteTrace(buf))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3)
+		var record OperationRecord
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+		require.Equal(t, trace[1], record)
+
+		// Without decryptor, only the levels and scales are logged
+		eval = NewRecordingEvaluator(params, testContext.evaluator, nil)
+		eval.MulRelinNew(ct1, ct2)
+		require.Equal(t, 0.0, eval.Records()[0].MinPrecision)
+
+		eval.Reset()
+		require.Len(t, eval.Records(), 0)
+	})
+}
+
+func testFixedPoint(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 || testContext.params.MaxLevel() < 2 {
+		return
+	}
+
+	params := testContext.params
+	encoder := NewFixedPointEncoder(params)
+	eval := NewFixedPointEvaluator(params, testContext.evaluator)
+
+	// Integers of [-16, 16) with 4 fractional bits, i.e. real values of [-1, 1)
+	newTestVector := func() (values []int64, ct *FixedPointCiphertext) {
+		values = make([]int64, params.Slots())
+		for i := range values {
+			values[i] = int64(utils.RandUint64()%32) - 16
+		}
+		return values, EncryptFixedPointNew(encoder, testContext.encryptorSk, values, 4)
+	}
+
+	t.Run(testString(testContext, "FixedPoint/Encoder/"), func(t *testing.T) {
+		values, _ := newTestVector()
+		require.Equal(t, values, encoder.Decode(encoder.EncodeNTTNew(values, 4), 4))
+	})
+
+	t.Run(testString(testContext, "FixedPoint/Evaluator/"), func(t *testing.T) {
+
+		a, ctA := newTestVector()
+		b, ctB := newTestVector()
+
+		// a * b has 8 fractional bits, and a is aligned on them in the sum
+		ctOut, err := eval.MulNew(ctA, ctB)
+		require.NoError(t, err)
+		require.Equal(t, 8, ctOut.FracBits)
+		require.InDelta(t, 1, ctOut.Scale()/params.Scale(), 1e-3)
+
+		eval.Add(ctOut, ctA, ctOut)
+		require.Equal(t, 8, ctOut.FracBits)
+
+		// Multiplication by 1.5, i.e. 3 with 1 fractional bit
+		require.NoError(t, eval.MulByFixedPoint(ctOut, 3, 1, ctOut))
+		require.Equal(t, 9, ctOut.FracBits)
+
+		eval.Sub(ctOut, ctB, ctOut)
+
+		want := make([]int64, params.Slots())
+		for i := range want {
+			want[i] = 3*(a[i]*b[i]+a[i]<<4) - b[i]<<5
+		}
+
+		require.Equal(t, want, DecryptFixedPointNew(encoder, testContext.decryptor, ctOut))
+
+		eval.Requantize(ctOut, 4)
+		have := DecryptFixedPointNew(encoder, testContext.decryptor, ctOut)
+		for i := range want {
+			require.InDelta(t, float64(want[i])/32, float64(have[i]), 0.5+1e-9)
+		}
+	})
+}
+
+func testRealOnly(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		return
+	}
+
+	params := testContext.params
+
+	newRealTestVector := func() (values []complex128, ct *Ciphertext) {
+		values = make([]complex128, params.Slots())
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), 0)
+		}
+		return values, testContext.encryptorSk.EncryptNew(testContext.encoder.EncodeNTTNew(values, params.LogSlots()))
+	}
+
+	t.Run(testString(testContext, "RealOnly/Flag/"), func(t *testing.T) {
+
+		values1, ct1 := newRealTestVector()
+		values2, ct2 := newRealTestVector()
+		_, _, ctComplex := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		require.True(t, ct1.IsReal())
+		require.False(t, ctComplex.IsReal())
+
+		ct3 := testContext.evaluator.AddNew(ct1, ct2)
+		require.True(t, ct3.IsReal())
+		require.False(t, testContext.evaluator.AddNew(ct1, ctComplex).IsReal())
+
+		testContext.evaluator.MulRelin(ct3, ct2, ct3)
+		require.True(t, ct3.IsReal())
+
+		require.True(t, testContext.evaluator.MultByConstNew(ct3, 0.5).IsReal())
+		require.False(t, testContext.evaluator.MultByConstNew(ct3, complex(0.5, 0.5)).IsReal())
+		require.False(t, testContext.evaluator.MultByiNew(ct3).IsReal())
+
+		if err := testContext.evaluator.Rescale(ct3, params.Scale(), ct3); err != nil {
+			t.Fatal(err)
+		}
+		require.True(t, ct3.IsReal())
+
+		for i := range values1 {
+			values1[i] = (values1[i] + values2[i]) * values2[i]
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ct3, params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "RealOnly/Conjugate/"), func(t *testing.T) {
+
+		// No rotation key: the conjugation of a real ciphertext must not require one
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk})
+
+		values, ct := newRealTestVector()
+		ctConj := eval.ConjugateNew(ct)
+
+		require.True(t, ctConj.IsReal())
+		verifyTestVectors(testContext, testContext.decryptor, values, ctConj, params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "RealOnly/PackUnpack/"), func(t *testing.T) {
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(nil, true, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		values1, ct1 := newRealTestVector()
+		values2, ct2 := newRealTestVector()
+
+		_, _, ctComplex := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		require.Panics(t, func() { eval.PackRealNew(ct1, ctComplex) })
+
+		ctPacked := eval.PackRealNew(ct1, ct2)
+		require.False(t, ctPacked.IsReal())
+
+		// A single multiplication by a real constant for both vectors
+		eval.MultByConst(ctPacked, 3, ctPacked)
+
+		for i := range values1 {
+			values1[i] *= 3
+			values2[i] *= 3
+		}
+
+		ctRe, ctIm := eval.UnpackRealNew(ctPacked)
+
+		require.True(t, ctRe.IsReal())
+		require.True(t, ctIm.IsReal())
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, ctRe, params.LogSlots(), 0, t)
+		verifyTestVectors(testContext, testContext.decryptor, values2, ctIm, params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "RealOnly/RealImagPart/"), func(t *testing.T) {
+
+		rotKey := testContext.kgen.GenConjugationKey(testContext.sk)
+		require.Len(t, rotKey.Keys, 1)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+		require.True(t, eval.HasConjugationKey())
+		require.False(t, testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk}).HasConjugationKey())
+
+		values, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		valuesRe := make([]complex128, len(values))
+		valuesIm := make([]complex128, len(values))
+		for i, v := range values {
+			valuesRe[i] = complex(real(v), 0)
+			valuesIm[i] = complex(imag(v), 0)
+		}
+
+		ctRe := eval.RealPartNew(ciphertext)
+		require.True(t, ctRe.IsReal())
+		require.Equal(t, ciphertext.Level(), ctRe.Level())
+		verifyTestVectors(testContext, testContext.decryptor, valuesRe, ctRe, params.LogSlots(), 0, t)
+
+		ctIm := eval.ImagPartNew(ciphertext)
+		require.True(t, ctIm.IsReal())
+		verifyTestVectors(testContext, testContext.decryptor, valuesIm, ctIm, params.LogSlots(), 0, t)
+
+		// Purely real inputs do not require the conjugation key
+		evalNoKey := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk})
+		evalNoKey.RealPart(ctRe, ctRe)
+		verifyTestVectors(testContext, testContext.decryptor, valuesRe, ctRe, params.LogSlots(), 0, t)
+
+		evalNoKey.ImagPart(ctRe, ctRe)
+		verifyTestVectors(testContext, testContext.decryptor, make([]complex128, len(values)), ctRe, params.LogSlots(), 0, t)
+	})
+
+	t.Run(testString(testContext, "RealOnly/EncodeReal/"), func(t *testing.T) {
+
+		rotKey := testContext.kgen.GenConjugationKey(testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		logSlots := params.LogSlots()
+		slots := params.Slots()
+
+		// 2*slots real values, i.e. N values at the maximum number of slots
+		values := make([]float64, 2*slots)
+		for i := range values {
+			values[i] = utils.RandFloat64(-1, 1)
+		}
+
+		plaintext := NewPlaintext(params, params.MaxLevel(), params.Scale())
+		testContext.encoder.EncodeRealNTT(plaintext, values, logSlots)
+		ciphertext := testContext.encryptorSk.EncryptNew(plaintext)
+
+		// A single multiplication by a real constant for all the values
+		eval.MultByConst(ciphertext, 3, ciphertext)
+		for i := range values {
+			values[i] *= 3
+		}
+
+		have := testContext.encoder.DecodeReal(testContext.decryptor.DecryptNew(ciphertext), logSlots)
+		require.Len(t, have, 2*slots)
+
+		var maxErr float64
+		for i := range values {
+			maxErr = math.Max(maxErr, math.Abs(have[i]-values[i]))
+		}
+		require.GreaterOrEqual(t, -math.Log2(maxErr), minPrec)
+
+		// Conversion to the standard layout
+		valuesRe := make([]complex128, slots)
+		valuesIm := make([]complex128, slots)
+		for i := 0; i < slots; i++ {
+			valuesRe[i] = complex(values[i], 0)
+			valuesIm[i] = complex(values[slots+i], 0)
+		}
+
+		ctRe, ctIm := eval.UnpackRealNew(ciphertext)
+		verifyTestVectors(testContext, testContext.decryptor, valuesRe, ctRe, logSlots, 0, t)
+		verifyTestVectors(testContext, testContext.decryptor, valuesIm, ctIm, logSlots, 0, t)
+
+		require.Panics(t, func() { testContext.encoder.EncodeReal(plaintext, make([]float64, 2*slots+1), logSlots) })
+	})
+}
+
+func testMetadata(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	params := testContext.params
+
+	rotKey := testContext.kgen.GenRotationKeysForRotations(append(params.RotationsForInnerSum(1, 4), 1), true, testContext.sk)
+	eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+	t.Run(testString(testContext, "Metadata/Encryption/"), func(t *testing.T) {
+
+		_, plaintext, _ := newTestVectors(testContext, nil, complex(-1, -1), complex(1, 1), t)
+		plaintext.SetTag("feature", "age")
+
+		ciphertext := testContext.encryptorSk.EncryptNew(plaintext)
+		require.True(t, ciphertext.Metadata().Equals(Metadata{"feature": "age"}))
+
+		// The tags of the copies are not shared
+		ciphertext.SetTag("batch", "0")
+		require.True(t, plaintext.Metadata().Equals(Metadata{"feature": "age"}))
+
+		require.True(t, testContext.decryptor.DecryptNew(ciphertext).Metadata().Equals(Metadata{"feature": "age", "batch": "0"}))
+	})
+
+	t.Run(testString(testContext, "Metadata/Evaluator/"), func(t *testing.T) {
+
+		values0, plaintext, ct0 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values1, _, ct1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		ct0.SetMetadata(Metadata{"feature": "age", "batch": "0"})
+		ct1.SetMetadata(Metadata{"feature": "income", "source": "bank"})
+		plaintext.SetTag("model", "v1")
+
+		// The first operand takes precedence
+		ctOut := eval.AddNew(ct0, ct1)
+		require.True(t, ctOut.Metadata().Equals(Metadata{"feature": "age", "batch": "0", "source": "bank"}))
+		ctOut = eval.AddNew(ct1, ct0)
+		require.True(t, ctOut.Metadata().Equals(Metadata{"feature": "income", "batch": "0", "source": "bank"}))
+
+		// Plaintext operands
+		ctOut = eval.MulNew(ct0, plaintext)
+		require.True(t, ctOut.Metadata().Equals(Metadata{"feature": "age", "batch": "0", "model": "v1"}))
+
+		ctOut = eval.MulRelinNew(ct0, ct1)
+		require.NoError(t, eval.Rescale(ctOut, params.Scale(), ctOut))
+		eval.Rotate(ctOut, 1, ctOut)
+		eval.InnerSum(ctOut, 1, 4, ctOut)
+		eval.AddConst(ctOut, 1, ctOut)
+		require.True(t, ctOut.Metadata().Equals(Metadata{"feature": "age", "batch": "0", "source": "bank"}))
+
+		// The metadata do not change the result
+		want := make([]complex128, len(values0))
+		for i := range want {
+			want[i] = 1
+			for j := 0; j < 4; j++ {
+				k := (i + j + 1) % len(values0)
+				want[i] += values0[k] * values1[k]
+			}
+		}
+		verifyTestVectors(testContext, testContext.decryptor, want, ctOut, params.LogSlots(), 0, t)
+
+		poly := NewPoly([]complex128{1, 2})
+		ctOut, err := eval.EvaluatePoly(ct0, poly, params.Scale())
+		require.NoError(t, err)
+		require.True(t, ctOut.Metadata().Equals(ct0.Metadata()))
+
+		// In-place operations keep the metadata of their operand
+		ct0.SetMetadata(nil)
+		eval.Neg(ct0, ct0)
+		require.Nil(t, ct0.Metadata())
+	})
+
+	t.Run(testString(testContext, "Metadata/Marshaller/"), func(t *testing.T) {
+
+		_, _, ciphertext := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		data, err := ciphertext.MarshalBinary()
+		require.NoError(t, err)
+		require.Len(t, data, ciphertext.GetDataLen(true))
+
+		ciphertext.SetMetadata(Metadata{"feature": "age", "batch": "0", "": "empty key"})
+
+		dataWithMetadata, err := ciphertext.MarshalBinary()
+		require.NoError(t, err)
+		require.Len(t, dataWithMetadata, ciphertext.GetDataLen(true))
+		require.Greater(t, len(dataWithMetadata), len(data))
+
+		ciphertextTest := new(Ciphertext)
+		require.NoError(t, ciphertextTest.UnmarshalBinary(dataWithMetadata))
+		require.True(t, ciphertextTest.Metadata().Equals(ciphertext.Metadata()))
+		for i := range ciphertext.Value {
+			require.True(t, testContext.ringQ.EqualLvl(ciphertext.Level(), ciphertext.Value[i], ciphertextTest.Value[i]))
+		}
+
+		// The ciphertexts without metadata keep the previous format
+		require.NoError(t, ciphertextTest.UnmarshalBinary(data))
+		require.Nil(t, ciphertextTest.Metadata())
+
+		require.Error(t, ciphertextTest.UnmarshalBinary(dataWithMetadata[:len(dataWithMetadata)-1]))
+
+		dataVersioned, err := MarshalVersioned(params, ciphertext)
+		require.NoError(t, err)
+		ciphertextTest = new(Ciphertext)
+		_, err = UnmarshalVersioned(params, dataVersioned, ciphertextTest)
+		require.NoError(t, err)
+		require.True(t, ciphertextTest.Metadata().Equals(ciphertext.Metadata()))
+	})
+}
+
+func testSlotScales(testContext *testParams, t *testing.T) {
+
+	if testContext.params.PCount() == 0 {
+		return
+	}
+
+	params := testContext.params
+	logSlots := params.LogSlots()
+	slots := params.Slots()
+
+	// Messages whose magnitudes range from 2^-8 to 2^7, normalized to [-1, 1] by their slot scales
+	newSlotScaledTestVector := func() (values []complex128, slotScales []float64, ct *Ciphertext) {
+		values = make([]complex128, slots)
+		slotScales = make([]float64, slots)
+		for i := range values {
+			magnitude := math.Exp2(float64(i%16 - 8))
+			values[i] = complex(utils.RandFloat64(-1, 1)*magnitude, utils.RandFloat64(-1, 1)*magnitude)
+			slotScales[i] = 1 / magnitude
+		}
+		pt := NewPlaintext(params, params.MaxLevel(), params.Scale())
+		testContext.encoder.EncodeSlotScaledNTT(pt, values, slotScales, logSlots)
+		return values, slotScales, testContext.encryptorSk.EncryptNew(pt)
+	}
+
+	// verify checks the precision of each slot relative to its slot scale: the values multiplied by their slot
+	// scales are encoded at the scale of the ciphertext like unscaled values, so that their mean precision must
+	// meet the bound of verifyTestVectors.
+	verify := func(t *testing.T, valuesWant []complex128, ct *Ciphertext) {
+		have := testContext.encoder.DecodeSlotScaled(testContext.decryptor.DecryptNew(ct), logSlots)
+		scaledWant := make([]complex128, len(have))
+		scaledHave := make([]complex128, len(have))
+		for i := range have {
+			scaledWant[i] = valuesWant[i] * complex(ct.SlotScales()[i], 0)
+			scaledHave[i] = have[i] * complex(ct.SlotScales()[i], 0)
+		}
+		verifyTestVectors(testContext, testContext.decryptor, scaledWant, scaledHave, logSlots, 0, t)
+	}
+
+	t.Run(testString(testContext, "SlotScales/Encode/"), func(t *testing.T) {
+		values, slotScales, ct := newSlotScaledTestVector()
+		require.Equal(t, slotScales, ct.SlotScales())
+		verify(t, values, ct)
+	})
+
+	t.Run(testString(testContext, "SlotScales/AddMul/"), func(t *testing.T) {
+
+		values1, slotScales1, ct1 := newSlotScaledTestVector()
+		values2, slotScales2, ct2 := newSlotScaledTestVector()
+
+		eval := testContext.evaluator
+
+		ctSum := eval.AddNew(ct1, ct1)
+		require.Equal(t, slotScales1, ctSum.SlotScales())
+
+		ctProd := eval.MulRelinNew(ct1, ct2)
+		if err := eval.Rescale(ctProd, params.Scale(), ctProd); err != nil {
+			t.Fatal(err)
+		}
 
-		for _, n := range rots {
-			verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), evaluator.RotateNew(ciphertext1, n), testContext.params.LogSlots(), 0, t)
+		valuesSum := make([]complex128, slots)
+		valuesProd := make([]complex128, slots)
+		for i := range values1 {
+			valuesSum[i] = 2 * values1[i]
+			valuesProd[i] = values1[i] * values2[i]
+			require.InDelta(t, slotScales1[i]*slotScales2[i], ctProd.SlotScales()[i], 1e-9*ctProd.SlotScales()[i])
 		}
 
+		verify(t, valuesSum, ctSum)
+		verify(t, valuesProd, ctProd)
+
+		_, _, ctUnscaled := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		require.Panics(t, func() { eval.AddNew(ct1, ctUnscaled) })
+		require.Panics(t, func() { eval.AddConstNew(ct1, 1) })
 	})
 
-	t.Run(testString(testContext, "RotateHoisted/"), func(t *testing.T) {
+	t.Run(testString(testContext, "SlotScales/Rotate/"), func(t *testing.T) {
 
-		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values, slotScales, ct := newSlotScaledTestVector()
 
-		ciphertexts := evaluator.RotateHoisted(ciphertext1, rots)
+		rotKey := testContext.kgen.GenRotationKeysForRotations([]int{3}, false, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
 
-		for _, n := range rots {
-			verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), ciphertexts[n], testContext.params.LogSlots(), 0, t)
-		}
+		ctRot := eval.RotateNew(ct, 3)
+
+		require.Equal(t, slotScales[3], ctRot.SlotScales()[0])
+		verify(t, utils.RotateComplex128Slice(values, 3), ctRot)
+
+		require.Panics(t, func() { eval.AddNew(ct, ctRot) })
 	})
 }
 
-func testInnerSum(testContext *testParams, t *testing.T) {
+func testCanonicalEmbedding(testContext *testParams, t *testing.T) {
 
-	if testContext.params.PCount() == 0 {
-		t.Skip("#Pi is empty")
-	}
+	params := testContext.params
 
-	t.Run(testString(testContext, "InnerSum/"), func(t *testing.T) {
-		batch := 2
-		n := 35
+	t.Run(testString(testContext, "CanonicalEmbedding/Monomial/"), func(t *testing.T) {
+		coeffs := make([]float64, params.N())
+		coeffs[1] = 3
+		for _, v := range CanonicalEmbedding(coeffs) {
+			require.InDelta(t, 3, cmplx.Abs(v), 1e-9)
+		}
+		require.InDelta(t, 3, CanonicalEmbeddingNorm(coeffs), 1e-9)
+	})
 
-		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForInnerSum(batch, n), false, testContext.sk)
-		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+	t.Run(testString(testContext, "CanonicalEmbedding/Plaintext/"), func(t *testing.T) {
 
-		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values, plaintext, _ := newTestVectors(testContext, nil, complex(-1, -1), complex(1, 1), t)
 
-		eval.InnerSum(ciphertext1, batch, n, ciphertext1)
+		var maxValue float64
+		for _, v := range values {
+			maxValue = math.Max(maxValue, cmplx.Abs(v))
+		}
 
-		tmp0 := make([]complex128, len(values1))
-		copy(tmp0, values1)
+		// The slots are evaluations of the plaintext at the roots of unity, up to the rounding of the encoding
+		norm := PlaintextCanonicalEmbeddingNorm(params, plaintext) / plaintext.Scale()
+		require.InDelta(t, maxValue, norm, 1e-3)
+	})
 
-		for i := 1; i < n; i++ {
+	if params.PCount() == 0 {
+		return
+	}
 
-			tmp1 := utils.RotateComplex128Slice(tmp0, i*batch)
+	t.Run(testString(testContext, "CanonicalEmbedding/Bounds/"), func(t *testing.T) {
 
-			for j := range values1 {
-				values1[j] += tmp1[j]
+		est := NewCanonicalBoundEstimator(params, 0)
+
+		// maxError returns the largest error of the decoded values of the ciphertext
+		maxError := func(valuesWant []complex128, ct *Ciphertext) (maxErr float64) {
+			have := testContext.encoder.Decode(testContext.decryptor.DecryptNew(ct), params.LogSlots())
+			for i := range have {
+				maxErr = math.Max(maxErr, cmplx.Abs(have[i]-valuesWant[i]))
 			}
+			return
 		}
 
-		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
-	})
-
-	t.Run(testString(testContext, "InnerSumLog/"), func(t *testing.T) {
+		values0, _, ct0 := newTestVectors(testContext, testContext.encryptorPk, complex(-1, -1), complex(1, 1), t)
+		values1, _, ct1 := newTestVectors(testContext, testContext.encryptorPk, complex(-1, -1), complex(1, 1), t)
 
-		batch := 3
-		n := 15
+		b := est.Fresh(math.Sqrt2, true)
+		require.LessOrEqual(t, maxError(values0, ct0), b.Error/b.Scale)
 
-		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForInnerSumLog(batch, n), false, testContext.sk)
-		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+		// The bound covers the division by P of Encrypt and the encryption modulo Q of EncryptFast
+		for i := 0; i < 4; i++ {
+			values, plaintext, ct := newTestVectors(testContext, testContext.encryptorPk, complex(-1, -1), complex(1, 1), t)
+			require.LessOrEqual(t, maxError(values, ct), b.Error/b.Scale)
+			require.LessOrEqual(t, maxError(values, testContext.encryptorPk.EncryptFastNew(plaintext)), b.Error/b.Scale)
+		}
 
-		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		rotKey := testContext.kgen.GenRotationKeysForRotations([]int{1}, false, testContext.sk)
+		evalRot := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+		bRot := est.KeySwitch(b)
+		require.LessOrEqual(t, maxError(utils.RotateComplex128Slice(values0, 1), evalRot.RotateNew(ct0, 1)), bRot.Error/bRot.Scale)
 
-		eval.InnerSumLog(ciphertext1, batch, n, ciphertext1)
+		valuesSum := make([]complex128, len(values0))
+		for i := range values0 {
+			valuesSum[i] = values0[i] + values1[i]
+		}
 
-		tmp0 := make([]complex128, len(values1))
-		copy(tmp0, values1)
+		bSum := est.Add(b, b)
+		require.LessOrEqual(t, maxError(valuesSum, testContext.evaluator.AddNew(ct0, ct1)), bSum.Error/bSum.Scale)
 
-		for i := 1; i < n; i++ {
+		ctProd := testContext.evaluator.MulRelinNew(ct0, ct1)
+		if err := testContext.evaluator.Rescale(ctProd, params.Scale(), ctProd); err != nil {
+			t.Fatal(err)
+		}
 
-			tmp1 := utils.RotateComplex128Slice(tmp0, i*batch)
+		bProd := est.Rescale(est.MulRelin(b, b))
+		require.Equal(t, ctProd.Level(), bProd.Level)
+		require.InDelta(t, ctProd.Scale(), bProd.Scale, 1e-9*bProd.Scale)
 
-			for j := range values1 {
-				values1[j] += tmp1[j]
-			}
+		valuesProd := make([]complex128, len(values0))
+		for i := range values0 {
+			valuesProd[i] = values0[i] * values1[i]
 		}
 
-		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
-
+		require.LessOrEqual(t, maxError(valuesProd, ctProd), bProd.Error/bProd.Scale)
+		require.Greater(t, bProd.LogPrecision(), 0.0)
 	})
 }
 
-func testReplicate(testContext *testParams, t *testing.T) {
+func testHomomorphicDFT(testContext *testParams, t *testing.T) {
 
-	if testContext.params.PCount() == 0 {
-		t.Skip("#Pi is empty")
+	params := testContext.params
+
+	skipIfInvalid := func(t *testing.T) {
+		if params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+		if params.MaxLevel() < 4 {
+			t.Skip("not enough levels")
+		}
 	}
 
-	t.Run(testString(testContext, "Replicate/"), func(t *testing.T) {
-		batch := 2
-		n := 35
+	logSlots := utils.MinInt(5, params.MaxLogSlots())
+	slots := 1 << logSlots
 
-		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForReplicate(batch, n), false, testContext.sk)
-		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+	// want[j] = scaling * sum_k values[k] * zeta^(k*5^j), with zeta = exp(2*i*pi/(4*slots))
+	specialDFT := func(values []complex128, scaling complex128) (want []complex128) {
+		want = make([]complex128, slots)
+		pow5 := 1
+		for j := range want {
+			for k, v := range values {
+				want[j] += v * cmplx.Exp(complex(0, 2*math.Pi*float64((k*pow5)%(4*slots))/float64(4*slots)))
+			}
+			want[j] *= scaling
+			pow5 = (pow5 * 5) % (4 * slots)
+		}
+		return
+	}
 
-		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+	encrypt := func() (values []complex128, ciphertext *Ciphertext) {
+		values = make([]complex128, slots)
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		}
+		return values, testContext.encryptorSk.EncryptNew(testContext.encoder.EncodeNTTAtLvlNew(params.MaxLevel(), values, logSlots))
+	}
 
-		eval.Replicate(ciphertext1, batch, n, ciphertext1)
+	genMatrices := func(literal DFTLiteral) (*DFTMatrices, Evaluator) {
+		matrices, err := NewDFTMatrices(params, testContext.encoder, literal)
+		require.NoError(t, err)
+		rotKey := testContext.kgen.GenRotationKeysForRotations(matrices.Rotations(), false, testContext.sk)
+		return matrices, testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+	}
 
-		tmp0 := make([]complex128, len(values1))
-		copy(tmp0, values1)
+	scaling := complex(1/float64(slots), 0)
 
-		for i := 1; i < n; i++ {
+	for _, bitReversed := range []bool{false, true} {
 
-			tmp1 := utils.RotateComplex128Slice(tmp0, i*-batch)
+		t.Run(testString(testContext, fmt.Sprintf("HomomorphicDFT/BitReversed=%t/", bitReversed)), func(t *testing.T) {
 
-			for j := range values1 {
-				values1[j] += tmp1[j]
-			}
-		}
+			skipIfInvalid(t)
 
-		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
-	})
+			values, ciphertext := encrypt()
 
-	t.Run(testString(testContext, "ReplicateLog/"), func(t *testing.T) {
+			matrices, eval := genMatrices(DFTLiteral{LogSlots: logSlots, LevelStart: params.MaxLevel(), Depth: 2, Scaling: scaling, BitReversed: bitReversed})
 
-		batch := 3
-		n := 15
+			res := HomomorphicDFT(ciphertext, matrices, eval)
+			require.Equal(t, params.MaxLevel()-2, res.Level())
+			require.Equal(t, ciphertext.Scale(), res.Scale())
 
-		rotKey := testContext.kgen.GenRotationKeysForRotations(testContext.params.RotationsForReplicateLog(batch, n), false, testContext.sk)
-		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+			// The input is in bit-reversed order, unless BitReversed, in which case the output is.
+			if !bitReversed {
+				SliceBitReverseInPlaceComplex128(values, slots)
+			}
+			want := specialDFT(values, scaling)
+			if bitReversed {
+				SliceBitReverseInPlaceComplex128(want, slots)
+			}
 
-		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+			verifyTestVectors(testContext, testContext.decryptor, want, res, logSlots, 0, t)
+		})
 
-		eval.ReplicateLog(ciphertext1, batch, n, ciphertext1)
+		t.Run(testString(testContext, fmt.Sprintf("HomomorphicIDFT/BitReversed=%t/", bitReversed)), func(t *testing.T) {
 
-		tmp0 := make([]complex128, len(values1))
-		copy(tmp0, values1)
+			skipIfInvalid(t)
 
-		for i := 1; i < n; i++ {
+			values, ciphertext := encrypt()
 
-			tmp1 := utils.RotateComplex128Slice(tmp0, i*-batch)
+			matricesIDFT, evalIDFT := genMatrices(DFTLiteral{Inverse: true, LogSlots: logSlots, LevelStart: params.MaxLevel(), Depth: 2, Scaling: scaling, BitReversed: bitReversed})
+			matricesDFT, evalDFT := genMatrices(DFTLiteral{LogSlots: logSlots, LevelStart: params.MaxLevel() - 2, Depth: 2, BitReversed: bitReversed})
 
-			for j := range values1 {
-				values1[j] += tmp1[j]
-			}
-		}
+			// The output order of the IDFT is the input order of the DFT, which reverts it up to the factor slots.
+			res := HomomorphicDFT(HomomorphicIDFT(ciphertext, matricesIDFT, evalIDFT), matricesDFT, evalDFT)
 
-		verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
+			verifyTestVectors(testContext, testContext.decryptor, values, res, logSlots, 0, t)
+		})
+	}
 
+	t.Run(testString(testContext, "HomomorphicDFT/InvalidLiteral/"), func(t *testing.T) {
+		skipIfInvalid(t)
+		_, err := NewDFTMatrices(params, testContext.encoder, DFTLiteral{LogSlots: logSlots, LevelStart: params.MaxLevel(), Depth: logSlots + 1})
+		require.Error(t, err)
+		_, err = NewDFTMatrices(params, testContext.encoder, DFTLiteral{LogSlots: logSlots, LevelStart: 1, Depth: 2})
+		require.Error(t, err)
 	})
 }
 
@@ -1250,6 +3788,111 @@ func testLinearTransform(testContext *testParams, t *testing.T) {
 		verifyTestVectors(testContext, testContext.decryptor, values1, res, testContext.params.LogSlots(), 0, t)
 	})
 
+	t.Run(testString(testContext, "LinearTransform/Permutation/"), func(t *testing.T) {
+
+		params := testContext.params
+		slots := params.Slots()
+
+		// A random permutation within each block of 16 slots, and a permutation of a single diagonal
+		random := make([]int, slots)
+		for i := range random {
+			random[i] = i
+		}
+		for i := slots - 1; i > 0; i-- {
+			if i&15 != 0 {
+				j := i&^15 + int(utils.RandUint64()%uint64(i&15+1))
+				random[i], random[j] = random[j], random[i]
+			}
+		}
+
+		shift := make([]int, slots)
+		for i := range shift {
+			shift[i] = (i + 3) % slots
+		}
+
+		for _, perm := range [][]int{random, shift} {
+
+			values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			matrix, rots, err := GenPermutationTransform(params, testContext.encoder, perm, ciphertext1.Level())
+			require.NoError(t, err)
+
+			if len(rots) > 1 {
+				// The rotations must not exceed the ones of the naive evaluation
+				diags, err := PermutationDiagonals(perm)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(rots), len(diags))
+			} else {
+				require.Equal(t, []int{3}, rots)
+			}
+
+			rotKey := testContext.kgen.GenRotationKeysForRotations(rots, false, testContext.sk)
+			eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+			res := eval.LinearTransform(ciphertext1, matrix)[0]
+			require.NoError(t, eval.Rescale(res, params.Scale(), res))
+
+			want := make([]complex128, slots)
+			for i := range want {
+				want[i] = values1[perm[i]]
+			}
+
+			verifyTestVectors(testContext, testContext.decryptor, want, res, params.LogSlots(), 0, t)
+		}
+
+		_, _, err := GenPermutationTransform(params, testContext.encoder, []int{0, 1, 1, 3}, params.MaxLevel())
+		require.Error(t, err)
+		_, _, err = GenPermutationTransform(params, testContext.encoder, []int{0, 1, 2}, params.MaxLevel())
+		require.Error(t, err)
+	})
+
+	t.Run(testString(testContext, "LinearTransform/KeyRequest/"), func(t *testing.T) {
+
+		params := testContext.params
+
+		values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		diagMatrix := make(map[int][]complex128)
+
+		diagMatrix[-1] = make([]complex128, params.Slots())
+		diagMatrix[0] = make([]complex128, params.Slots())
+
+		for i := 0; i < params.Slots(); i++ {
+			diagMatrix[-1][i] = complex(1, 0)
+			diagMatrix[0][i] = complex(1, 0)
+		}
+
+		ptDiagMatrix := testContext.encoder.EncodeDiagMatrixAtLvl(params.MaxLevel(), diagMatrix, params.Scale(), params.LogSlots())
+
+		// The rotations -1 of the linear transform and of ReplicateLog, and 0 of the linear transform, are merged
+		kr := rlwe.NewKeyRequest(params.Parameters)
+		kr.AddRotations(params.RotationsForLinearTransform(ptDiagMatrix)...)
+		kr.AddRotations(params.RotationsForInnerSumLog(1, 4)...)
+		kr.AddRotations(params.RotationsForReplicateLog(1, 4)...)
+		kr.AddConjugation()
+
+		require.Equal(t, 7, kr.Len())
+		require.Equal(t, kr.GaloisElements(), kr.Missing(nil))
+
+		rotKey := testContext.kgen.GenRotationKeys(kr.GaloisElements(), testContext.sk)
+
+		require.Len(t, rotKey.Keys, kr.Len())
+		require.Empty(t, kr.Missing(rotKey))
+
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		res := eval.ConjugateNew(eval.LinearTransform(ciphertext1, ptDiagMatrix)[0])
+
+		tmp := make([]complex128, params.Slots())
+		copy(tmp, values1)
+
+		for i := 0; i < params.Slots(); i++ {
+			values1[i] = cmplx.Conj(values1[i] + tmp[(i-1+params.Slots())%params.Slots()])
+		}
+
+		verifyTestVectors(testContext, testContext.decryptor, values1, res, testContext.params.LogSlots(), 0, t)
+	})
+
 	t.Run(testString(testContext, "LinearTransform/Naive/"), func(t *testing.T) {
 
 		params := testContext.params
@@ -1285,6 +3928,84 @@ func testLinearTransform(testContext *testParams, t *testing.T) {
 
 		verifyTestVectors(testContext, testContext.decryptor, values1, res, testContext.params.LogSlots(), 0, t)
 	})
+
+	t.Run(testString(testContext, "LinearTransform/Precomputed/"), func(t *testing.T) {
+
+		params := testContext.params
+
+		if params.MaxLevel() < 2 {
+			t.Skip("skipping test for params max level < 2")
+		}
+
+		diagMatrix := make(map[int][]complex128)
+		for _, k := range []int{-15, -4, -1, 0, 1, 4, 15} {
+			diagMatrix[k] = make([]complex128, params.Slots())
+			for i := range diagMatrix[k] {
+				diagMatrix[k][i] = complex(0.25, 0)
+			}
+		}
+
+		lt := NewLinearTransformPrecomputedBSGS(params, testContext.encoder, diagMatrix, 1, params.MaxLevel(), 1.0, params.LogSlots())
+
+		rotKey := testContext.kgen.GenRotationKeysForRotations(lt.Rotations(params), false, testContext.sk)
+		eval := testContext.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey})
+
+		// The same precomputed transform is evaluated on ciphertexts at different levels.
+		for _, level := range []int{params.MaxLevel(), params.MaxLevel() - 1} {
+
+			values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+			eval.DropLevel(ciphertext1, ciphertext1.Level()-level)
+
+			res := eval.LinearTransform(ciphertext1, lt)[0]
+			if err := eval.Rescale(res, params.Scale(), res); err != nil {
+				t.Fatal(err)
+			}
+
+			require.Equal(t, level-1, res.Level())
+			require.InDelta(t, ciphertext1.Scale(), res.Scale(), ciphertext1.Scale()*1e-9)
+
+			tmp := make([]complex128, params.Slots())
+			copy(tmp, values1)
+
+			for i := range values1 {
+				values1[i] = 0
+				for k := range diagMatrix {
+					values1[i] += 0.25 * tmp[(i+k+params.Slots())%params.Slots()]
+				}
+			}
+
+			verifyTestVectors(testContext, testContext.decryptor, values1, res, params.LogSlots(), 0, t)
+		}
+	})
+
+	t.Run(testString(testContext, "LowMemory/"), func(t *testing.T) {
+
+		rots := []int{1, 4}
+		rotKey := testContext.kgen.GenRotationKeysForRotations(rots, false, testContext.sk)
+		evalLowMem := NewEvaluatorWithOptions(testContext.params, rlwe.EvaluationKey{Rlk: testContext.rlk, Rtks: rotKey}, rlwe.Options{LowMemory: true})
+
+		for _, eval := range []Evaluator{evalLowMem, evalLowMem.ShallowCopy()} {
+
+			// The pools are allocated on the first use.
+			require.Nil(t, eval.(*evaluator).c2QiQDecomp)
+			require.Nil(t, eval.(*evaluator).ctxpool)
+
+			values1, _, ciphertext1 := newTestVectors(testContext, testContext.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			for i := range values1 {
+				values1[i] *= values1[i]
+			}
+
+			eval.MulRelin(ciphertext1, ciphertext1, ciphertext1)
+			eval.Rescale(ciphertext1, testContext.params.Scale(), ciphertext1)
+			verifyTestVectors(testContext, testContext.decryptor, values1, ciphertext1, testContext.params.LogSlots(), 0, t)
+
+			ciphertexts := eval.RotateHoisted(ciphertext1, rots)
+			for _, n := range rots {
+				verifyTestVectors(testContext, testContext.decryptor, utils.RotateComplex128Slice(values1, n), ciphertexts[n], testContext.params.LogSlots(), 0, t)
+			}
+		}
+	})
 }
 
 func testMarshaller(testctx *testParams, t *testing.T) {
@@ -1333,6 +4054,36 @@ func testMarshaller(testctx *testParams, t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, 2, paramsWithLogModuliNoP.QCount())
 		assert.Equal(t, 0, paramsWithLogModuliNoP.PCount())
+
+		// checks that ckks.Parameters can be unmarshalled from the name of a default parameter set
+		var paramsNamed Parameters
+		err = json.Unmarshal([]byte(`"PN12QP109"`), &paramsNamed)
+		assert.Nil(t, err)
+		paramsWant, err := NewParametersFromLiteral(PN12QP109)
+		assert.Nil(t, err)
+		assert.True(t, paramsWant.Equals(paramsNamed))
+
+		// checks that the fields of a default parameter set can be overridden
+		var paramsPreset Parameters
+		err = json.Unmarshal([]byte(`{"Preset":"PN13QP218","LogSlots":10,"LogQ":[50,40],"LogP":[60],"Scale":1099511627776}`), &paramsPreset)
+		assert.Nil(t, err)
+		assert.Equal(t, 13, paramsPreset.LogN())
+		assert.Equal(t, 10, paramsPreset.LogSlots())
+		assert.Equal(t, float64(1<<40), paramsPreset.Scale())
+		assert.Equal(t, 2, paramsPreset.QCount())
+		assert.Equal(t, 1, paramsPreset.PCount())
+
+		// checks that invalid representations are rejected
+		for _, data := range []string{
+			`"PN12QP0"`,
+			`{"Preset":"PN12QP0"}`,
+			`{"Preset":"PN12QP109","T":65537}`,
+			`{"Preset":"PN12QP109","Scale":-1}`,
+			`{"Preset":"PN12QP109","Sigma":0}`,
+			`{"Preset":"PN12QP109"} {}`,
+		} {
+			assert.NotNil(t, json.Unmarshal([]byte(data), new(Parameters)), data)
+		}
 	})
 
 	t.Run("Marshaller/Ciphertext/", func(t *testing.T) {
@@ -1371,6 +4122,128 @@ func testMarshaller(testctx *testParams, t *testing.T) {
 			require.Equal(t, ciphertext.Scale(), testctx.params.Scale())
 			require.Equal(t, len(ciphertext.Value), 1)
 		})
+
+		t.Run(testString(testctx, "Versioned/"), func(t *testing.T) {
+
+			ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 1, testctx.params.MaxLevel()-1, testctx.params.Scale())
+
+			data, err := MarshalVersioned(testctx.params, ciphertextWant)
+			require.NoError(t, err)
+
+			header, err := rlwe.Peek(data)
+			require.NoError(t, err)
+			require.Equal(t, rlwe.FormatVersion, header.Version)
+			require.Equal(t, rlwe.SchemeCKKS, header.Scheme)
+			require.Equal(t, rlwe.ObjectCiphertext, header.Type)
+			require.Equal(t, ciphertextWant.Level(), header.Level)
+			require.Equal(t, ciphertextWant.Degree(), header.Degree)
+			require.Equal(t, ciphertextWant.Scale(), header.Scale)
+			require.Equal(t, ciphertextWant.IsNTT(), header.IsNTT)
+
+			ciphertextTest := new(Ciphertext)
+			_, err = UnmarshalVersioned(testctx.params, data, ciphertextTest)
+			require.NoError(t, err)
+			require.Equal(t, ciphertextWant.Scale(), ciphertextTest.Scale())
+			for i := range ciphertextWant.Value {
+				require.True(t, testctx.ringQ.EqualLvl(ciphertextWant.Level(), ciphertextWant.Value[i], ciphertextTest.Value[i]))
+			}
+
+			// Wrong type
+			_, err = UnmarshalVersioned(testctx.params, data, new(rlwe.PublicKey))
+			require.True(t, errors.Is(err, rlwe.ErrIncompatible))
+
+			// Wrong parameters
+			otherParams, err := NewParameters(testctx.params.Parameters, testctx.params.LogSlots(), testctx.params.Scale()*2)
+			require.NoError(t, err)
+			_, err = UnmarshalVersioned(otherParams, data, new(Ciphertext))
+			require.True(t, errors.Is(err, rlwe.ErrIncompatible))
+
+			// More recent version
+			dataNext := append([]byte{}, data...)
+			dataNext[4] = rlwe.FormatVersion + 1
+			_, err = rlwe.Peek(dataNext)
+			require.True(t, errors.Is(err, rlwe.ErrUnsupportedVersion))
+			_, err = UnmarshalVersioned(testctx.params, dataNext, new(Ciphertext))
+			require.True(t, errors.Is(err, rlwe.ErrUnsupportedVersion))
+
+			// No header
+			raw, err := ciphertextWant.MarshalBinary()
+			require.NoError(t, err)
+			_, err = rlwe.Peek(raw)
+			require.True(t, errors.Is(err, rlwe.ErrNoHeader))
+
+			// Truncated data
+			_, err = UnmarshalVersioned(testctx.params, data[:rlwe.HeaderSize+16], new(Ciphertext))
+			require.Error(t, err)
+
+			// Keys
+			dataSk, err := MarshalVersioned(testctx.params, testctx.sk)
+			require.NoError(t, err)
+			header, err = rlwe.Peek(dataSk)
+			require.NoError(t, err)
+			require.Equal(t, rlwe.ObjectSecretKey, header.Type)
+			skTest := new(rlwe.SecretKey)
+			_, err = UnmarshalVersioned(testctx.params, dataSk, skTest)
+			require.NoError(t, err)
+			require.True(t, testctx.ringQP.Equal(testctx.sk.Value, skTest.Value))
+		})
+
+		t.Run(testString(testctx, "RLWE/"), func(t *testing.T) {
+
+			values, plaintext, ciphertextWant := newTestVectors(testctx, testctx.encryptorSk, complex(-1, -1), complex(1, 1), t)
+			ciphertextWant.SetIsReal(true)
+
+			data, err := ciphertextWant.RLWE().MarshalBinary()
+			require.NoError(t, err)
+
+			ctRLWE := new(rlwe.Ciphertext)
+			require.NoError(t, ctRLWE.UnmarshalBinary(data))
+			require.Equal(t, rlwe.SchemeCKKS, ctRLWE.Scheme)
+			require.Equal(t, ciphertextWant.Scale(), ctRLWE.Scale)
+			require.True(t, ctRLWE.IsReal)
+			require.Equal(t, ciphertextWant.IsNTT(), ctRLWE.IsNTT)
+
+			ciphertextTest, err := NewCiphertextFromRLWE(ctRLWE)
+			require.NoError(t, err)
+			require.Equal(t, ciphertextWant.Scale(), ciphertextTest.Scale())
+			require.True(t, ciphertextTest.IsReal())
+			ciphertextTest.SetIsReal(false)
+			verifyTestVectors(testctx, testctx.decryptor, values, ciphertextTest, testctx.params.LogSlots(), 0, t)
+
+			// The slot scales and the tags go through the conversions and the rlwe serialization.
+			slotScales := make([]float64, testctx.params.Slots())
+			for i := range slotScales {
+				slotScales[i] = float64(i + 1)
+			}
+			ciphertextWant.SetSlotScales(slotScales)
+			ciphertextWant.SetTag("feature", "age")
+
+			data, err = ciphertextWant.RLWE().MarshalBinary()
+			require.NoError(t, err)
+			ctRLWE = new(rlwe.Ciphertext)
+			require.NoError(t, ctRLWE.UnmarshalBinary(data))
+			require.Equal(t, slotScales, ctRLWE.SlotScales)
+			require.True(t, ctRLWE.Tags.Equals(rlwe.Tags{"feature": "age"}))
+
+			ciphertextTest, err = NewCiphertextFromRLWE(ctRLWE)
+			require.NoError(t, err)
+			require.Equal(t, slotScales, ciphertextTest.SlotScales())
+			require.Equal(t, "age", ciphertextTest.Metadata()["feature"])
+
+			ptRLWE := plaintext.RLWE()
+			require.True(t, ptRLWE.Value[0] == plaintext.Value[0])
+			plaintextTest, err := NewPlaintextFromRLWE(ptRLWE)
+			require.NoError(t, err)
+			verifyTestVectors(testctx, testctx.decryptor, values, plaintextTest, testctx.params.LogSlots(), 0, t)
+
+			ctRLWE.Scheme = rlwe.SchemeBFV
+			_, err = NewCiphertextFromRLWE(ctRLWE)
+			require.Error(t, err)
+
+			ciphertextTest = NewCiphertextFromElement(ciphertextWant.El())
+			require.True(t, ciphertextTest.Value[0] == ciphertextWant.Value[0])
+			require.Equal(t, ciphertextWant.Scale(), ciphertextTest.Scale())
+		})
 	})
 
 	t.Run(testString(testctx, "Marshaller/Sk/"), func(t *testing.T) {
@@ -0,0 +1,1104 @@
+package ckks
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// The cleartext Encryptor, Decryptor and Evaluator execute a circuit in the clear, for debugging and for the
+// comparison of the precision of a circuit with its exact evaluation, without any change to the code of the circuit.
+// A cleartext Ciphertext is a regular Ciphertext that stores the N/2 slots (as complex128) in the coefficients of
+// its first polynomial, and zero everywhere else: it must only be manipulated by the cleartext Encryptor, Decryptor
+// and Evaluator. Plaintexts are always decoded with the maximum number of slots, which replicates the sparse
+// plaintexts and makes the rotations consistent for any number of slots.
+//
+// The levels, scales and degrees of the cleartext ciphertexts are tracked as for the encrypted ones, so that the
+// same scale management applies, but the slots store the exact messages (without the scale) and the keys are ignored.
+// The only error of a cleartext evaluation is the encoding error of the plaintexts and the one introduced by a
+// mismatch of scales, which is reproduced.
+
+// cleartextEncryptor is an Encryptor storing the decoded plaintext in the ciphertext.
+type cleartextEncryptor struct {
+	params  Parameters
+	encoder Encoder
+}
+
+// NewCleartextEncryptor instantiates a new Encryptor producing cleartext ciphertexts.
+func NewCleartextEncryptor(params Parameters) Encryptor {
+	return &cleartextEncryptor{params: params, encoder: NewEncoder(params)}
+}
+
+func (enc *cleartextEncryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
+	ciphertext := NewCiphertext(enc.params, 1, plaintext.Level(), plaintext.Scale())
+	enc.Encrypt(plaintext, ciphertext)
+	return ciphertext
+}
+
+func (enc *cleartextEncryptor) Encrypt(plaintext *Plaintext, ciphertext *Ciphertext) {
+	values := enc.encoder.Decode(plaintext, enc.params.MaxLogSlots())
+	setCleartextOutput(enc.params, ciphertext.El(), 1, plaintext.Level(), plaintext.Scale(), values)
+}
+
+func (enc *cleartextEncryptor) EncryptFastNew(plaintext *Plaintext) *Ciphertext {
+	return enc.EncryptNew(plaintext)
+}
+
+func (enc *cleartextEncryptor) EncryptFast(plaintext *Plaintext, ciphertext *Ciphertext) {
+	enc.Encrypt(plaintext, ciphertext)
+}
+
+func (enc *cleartextEncryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext {
+	return enc.EncryptNew(plaintext)
+}
+
+func (enc *cleartextEncryptor) EncryptFromCRP(plaintext *Plaintext, ciphertext *Ciphertext, crp *ring.Poly) {
+	enc.Encrypt(plaintext, ciphertext)
+}
+
+// cleartextDecryptor is a Decryptor encoding the slots stored in the ciphertext.
+type cleartextDecryptor struct {
+	params  Parameters
+	encoder Encoder
+}
+
+// NewCleartextDecryptor instantiates a new Decryptor for cleartext ciphertexts.
+func NewCleartextDecryptor(params Parameters) Decryptor {
+	return &cleartextDecryptor{params: params, encoder: NewEncoder(params)}
+}
+
+func (dec *cleartextDecryptor) DecryptNew(ciphertext *Ciphertext) (plaintext *Plaintext) {
+	plaintext = NewPlaintext(dec.params, ciphertext.Level(), ciphertext.Scale())
+	dec.Decrypt(ciphertext, plaintext)
+	return
+}
+
+func (dec *cleartextDecryptor) Decrypt(ciphertext *Ciphertext, plaintext *Plaintext) {
+	level := utils.MinInt(ciphertext.Level(), plaintext.Level())
+	plaintext.value.Coeffs = plaintext.value.Coeffs[:level+1]
+	plaintext.SetScale(ciphertext.Scale())
+	dec.encoder.EncodeNTT(plaintext, getCleartext(ciphertext.El(), dec.params.N()>>1), dec.params.MaxLogSlots())
+}
+
+// setCleartextOutput resizes el to the given degree, drops it to the given level if
+// it is at a higher level, and writes the scale and the slots.
+func setCleartextOutput(params Parameters, el *Element, degree, level int, scale float64, values []complex128) {
+
+	el.Resize(params, degree)
+
+	for i := range el.Value {
+		if el.Level() > level {
+			el.Value[i].Coeffs = el.Value[i].Coeffs[:level+1]
+		}
+		for j := range el.Value[i].Coeffs {
+			for k := range el.Value[i].Coeffs[j] {
+				el.Value[i].Coeffs[j][k] = 0
+			}
+		}
+	}
+
+	for i, v := range values {
+		el.Value[0].Coeffs[0][2*i] = math.Float64bits(real(v))
+		el.Value[0].Coeffs[0][2*i+1] = math.Float64bits(imag(v))
+	}
+
+	el.SetScale(scale)
+}
+
+// getCleartext returns a copy of the slots stored in the element.
+func getCleartext(el *Element, slots int) (values []complex128) {
+	values = make([]complex128, slots)
+	coeffs := el.Value[0].Coeffs[0]
+	for i := range values {
+		values[i] = complex(math.Float64frombits(coeffs[2*i]), math.Float64frombits(coeffs[2*i+1]))
+	}
+	return
+}
+
+// cleartextEvaluator is an Evaluator operating on the slots stored in cleartext ciphertexts.
+type cleartextEvaluator struct {
+	params       Parameters
+	encoder      Encoder
+	ringQ        *ring.Ring
+	constVectors *constVectorCache
+}
+
+// NewCleartextEvaluator instantiates a new Evaluator operating on cleartext ciphertexts. Plaintext operands
+// and plaintext matrices are decoded before the evaluation. The methods exposing the internal key-switching
+// procedure (DecompInternal) and the compression of ciphertexts (CompressNew) are not supported.
+func NewCleartextEvaluator(params Parameters) Evaluator {
+	return &cleartextEvaluator{params: params, encoder: NewEncoder(params), ringQ: params.RingQ(), constVectors: newConstVectorCache(params)}
+}
+
+// values returns the slots of the operand, decoding it if it is a plaintext.
+func (eval *cleartextEvaluator) values(op Operand) []complex128 {
+	if pt, isPt := op.(*Plaintext); isPt {
+		return eval.encoder.Decode(pt, eval.params.MaxLogSlots())
+	}
+	return getCleartext(op.El(), eval.params.N()>>1)
+}
+
+func (eval *cleartextEvaluator) setOutput(ctOut *Ciphertext, degree, level int, scale float64, values []complex128) {
+	setCleartextOutput(eval.params, ctOut.El(), degree, level, scale, values)
+}
+
+// alignScale returns the values of an operand of scale `from` added to an operand of scale `to` > `from`,
+// reproducing the error of the evaluator when the ratio between the scales is not an integer.
+func alignScale(values []complex128, from, to float64) []complex128 {
+	factor := math.Max(math.Floor(to/from), 1) * from / to
+	for i := range values {
+		values[i] *= complex(factor, 0)
+	}
+	return values
+}
+
+func (eval *cleartextEvaluator) binary(op0, op1 Operand, ctOut *Ciphertext, sub bool) {
+
+	v0, v1 := eval.values(op0), eval.values(op1)
+
+	scale := math.Max(op0.Scale(), op1.Scale())
+	if op0.Scale() < scale {
+		v0 = alignScale(v0, op0.Scale(), scale)
+	} else if op1.Scale() < scale {
+		v1 = alignScale(v1, op1.Scale(), scale)
+	}
+
+	for i := range v0 {
+		if sub {
+			v0[i] -= v1[i]
+		} else {
+			v0[i] += v1[i]
+		}
+	}
+
+	level := utils.MinInt(utils.MinInt(op0.Level(), op1.Level()), ctOut.Level())
+	eval.setOutput(ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), level, scale, v0)
+}
+
+func (eval *cleartextEvaluator) newCiphertextBinary(op0, op1 Operand) *Ciphertext {
+	return NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()), 1)
+}
+
+func (eval *cleartextEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.binary(op0, op1, ctOut, false)
+}
+
+func (eval *cleartextEvaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.binary(op0, op1, ctOut, false)
+}
+
+func (eval *cleartextEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.AddNew(op0, op1)
+}
+
+func (eval *cleartextEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.binary(op0, op1, ctOut, true)
+}
+
+func (eval *cleartextEvaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.binary(op0, op1, ctOut, true)
+}
+
+func (eval *cleartextEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.Sub(op0, op1, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.SubNew(op0, op1)
+}
+
+// unary applies f on the slots of ctIn and returns the result in ctOut with the given scale.
+func (eval *cleartextEvaluator) unary(ctIn, ctOut *Ciphertext, scale float64, f func(complex128) complex128) {
+	values := eval.values(ctIn)
+	for i := range values {
+		values[i] = f(values[i])
+	}
+	eval.setOutput(ctOut, ctIn.Degree(), utils.MinInt(ctIn.Level(), ctOut.Level()), scale, values)
+}
+
+func (eval *cleartextEvaluator) newCiphertextUnary(ctIn *Ciphertext) *Ciphertext {
+	return NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+}
+
+func (eval *cleartextEvaluator) Neg(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.unary(ctIn, ctOut, ctIn.Scale(), func(v complex128) complex128 { return -v })
+}
+
+func (eval *cleartextEvaluator) NegNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.Neg(ctIn, ctOut)
+	return
+}
+
+// constToComplex converts a constant accepted by the evaluator to a complex128 and
+// returns whether both its real and imaginary parts are integers.
+func constToComplex(constant interface{}) (c complex128, isInt bool) {
+
+	cReal, cImag := new(big.Float), new(big.Float)
+
+	switch constant := constant.(type) {
+	case complex128:
+		cReal.SetFloat64(real(constant))
+		cImag.SetFloat64(imag(constant))
+	case float64:
+		cReal.SetFloat64(constant)
+	case *big.Float:
+		cReal.Set(constant)
+	case *ring.Complex:
+		cReal.Set(constant.Real())
+		cImag.Set(constant.Imag())
+	case uint64:
+		cReal.SetUint64(constant)
+	case int64:
+		cReal.SetInt64(constant)
+	case int:
+		cReal.SetInt64(int64(constant))
+	}
+
+	re, _ := cReal.Float64()
+	im, _ := cImag.Float64()
+
+	return complex(re, im), cReal.IsInt() && cImag.IsInt()
+}
+
+// constScale returns the scale by which the constant is scaled by the evaluator at the given level.
+func (eval *cleartextEvaluator) constScale(level int, isInt bool) float64 {
+	if isInt {
+		return 1
+	}
+	return float64(eval.params.Q()[level])
+}
+
+func (eval *cleartextEvaluator) AddConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.AddConst(ctIn, constant, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) AddConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	c, _ := constToComplex(constant)
+	eval.unary(ctIn, ctOut, ctIn.Scale(), func(v complex128) complex128 { return v + c })
+}
+
+func (eval *cleartextEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.MultByConst(ctIn, constant, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	c, isInt := constToComplex(constant)
+	scale := ctIn.Scale() * eval.constScale(utils.MinInt(ctIn.Level(), ctOut.Level()), isInt)
+	eval.unary(ctIn, ctOut, scale, func(v complex128) complex128 { return v * c })
+}
+
+func (eval *cleartextEvaluator) MultByGaussianInteger(ctIn *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
+	c := complex(float64(cReal), float64(cImag))
+	eval.unary(ctIn, ctOut, ctIn.Scale(), func(v complex128) complex128 { return v * c })
+}
+
+func (eval *cleartextEvaluator) MultByGaussianIntegerNew(ctIn *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.MultByGaussianInteger(ctIn, cReal, cImag, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) MultByConjGaussianNew(ctIn *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.MultByConjGaussian(ctIn, cReal, cImag, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) MultByConjGaussian(ctIn *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
+	eval.MultByGaussianInteger(ctIn, cReal, -cImag, ctOut)
+}
+
+func (eval *cleartextEvaluator) MultByConstAndAdd(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	c, isInt := constToComplex(constant)
+
+	termScale := ctIn.Scale() * eval.constScale(level, isInt)
+
+	acc := eval.values(ctOut)
+	scale := ctOut.Scale()
+
+	// The accumulator is brought to the scale of the term if it is smaller, else the
+	// constant is scaled such that the term matches the scale of the accumulator.
+	if scale < termScale {
+		acc = alignScale(acc, scale, termScale)
+		scale = termScale
+	}
+
+	values := eval.values(ctIn)
+	for i := range acc {
+		acc[i] += values[i] * c
+	}
+
+	eval.setOutput(ctOut, ctOut.Degree(), level, scale, acc)
+}
+
+func (eval *cleartextEvaluator) MultByGaussianIntegerAndAdd(ctIn *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
+	eval.MultByConstAndAdd(ctIn, complex(float64(cReal), float64(cImag)), ctOut)
+}
+
+func (eval *cleartextEvaluator) MultByiNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.MultByi(ctIn, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) MultByi(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.unary(ctIn, ctOut, ctIn.Scale(), func(v complex128) complex128 { return v * 1i })
+}
+
+func (eval *cleartextEvaluator) DivByiNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.DivByi(ctIn, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) DivByi(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.unary(ctIn, ctOut, ctIn.Scale(), func(v complex128) complex128 { return v * -1i })
+}
+
+func (eval *cleartextEvaluator) ConjugateNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.Conjugate(ctIn, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) Conjugate(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.unary(ctIn, ctOut, ctIn.Scale(), cmplx.Conj)
+}
+
+func (eval *cleartextEvaluator) HasConjugationKey() bool {
+	return true
+}
+
+func (eval *cleartextEvaluator) RealPartNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.RealPart(ctIn, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) RealPart(ctIn *Ciphertext, ctOut *Ciphertext) {
+	scale := 2 * ctIn.Scale()
+	if ctIn.IsReal() {
+		scale = ctIn.Scale()
+	}
+	eval.unary(ctIn, ctOut, scale, func(v complex128) complex128 { return complex(real(v), 0) })
+	ctOut.SetIsReal(true)
+}
+
+func (eval *cleartextEvaluator) ImagPartNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.ImagPart(ctIn, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) ImagPart(ctIn *Ciphertext, ctOut *Ciphertext) {
+	scale := 2 * ctIn.Scale()
+	if ctIn.IsReal() {
+		scale = ctIn.Scale()
+	}
+	eval.unary(ctIn, ctOut, scale, func(v complex128) complex128 { return complex(imag(v), 0) })
+	ctOut.SetIsReal(true)
+}
+
+func (eval *cleartextEvaluator) PackRealNew(ctRe, ctIm *Ciphertext) (ctOut *Ciphertext) {
+
+	if !isRealVector(eval.values(ctRe)) || !isRealVector(eval.values(ctIm)) {
+		panic("cannot PackRealNew: inputs must be purely real")
+	}
+
+	ctOut = eval.MultByiNew(ctIm)
+	eval.Add(ctRe, ctOut, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) UnpackRealNew(ctIn *Ciphertext) (ctRe, ctIm *Ciphertext) {
+
+	values := eval.values(ctIn)
+	valuesRe := make([]complex128, len(values))
+	valuesIm := make([]complex128, len(values))
+	for i, v := range values {
+		valuesRe[i] = complex(real(v), 0)
+		valuesIm[i] = complex(imag(v), 0)
+	}
+
+	ctRe, ctIm = eval.newCiphertextUnary(ctIn), eval.newCiphertextUnary(ctIn)
+	eval.setOutput(ctRe, 1, ctIn.Level(), 2*ctIn.Scale(), valuesRe)
+	eval.setOutput(ctIm, 1, ctIn.Level(), 2*ctIn.Scale(), valuesIm)
+	ctRe.SetIsReal(true)
+	ctIm.SetIsReal(true)
+	return
+}
+
+func (eval *cleartextEvaluator) mul(op0, op1 Operand, relin bool, ctOut *Ciphertext) {
+
+	v0, v1 := eval.values(op0), eval.values(op1)
+	for i := range v0 {
+		v0[i] *= v1[i]
+	}
+
+	degree := op0.Degree() + op1.Degree()
+	if relin && degree > 1 {
+		degree = 1
+	}
+
+	level := utils.MinInt(utils.MinInt(op0.Level(), op1.Level()), ctOut.Level())
+	eval.setOutput(ctOut, degree, level, op0.Scale()*op1.Scale(), v0)
+}
+
+func (eval *cleartextEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.mul(op0, op1, false, ctOut)
+}
+
+func (eval *cleartextEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op0.Degree()+op1.Degree(), utils.MinInt(op0.Level(), op1.Level()), 0)
+	eval.mul(op0, op1, false, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.mul(op0, op1, true, ctOut)
+}
+
+func (eval *cleartextEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, utils.MinInt(op0.Level(), op1.Level()), 0)
+	eval.mul(op0, op1, true, ctOut)
+	return
+}
+
+// rotate rotates the slots of ctIn by k positions to the left and returns the result in ctOut.
+func (eval *cleartextEvaluator) rotate(ctIn *Ciphertext, k int, ctOut *Ciphertext) {
+	eval.setOutput(ctOut, ctIn.Degree(), utils.MinInt(ctIn.Level(), ctOut.Level()), ctIn.Scale(), rotate(eval.values(ctIn), k))
+}
+
+func (eval *cleartextEvaluator) RotateNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.Rotate(ctIn, k, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) Rotate(ctIn *Ciphertext, k int, ctOut *Ciphertext) {
+	eval.rotate(ctIn, k, ctOut)
+}
+
+func (eval *cleartextEvaluator) RotateHoisted(ctIn *Ciphertext, rotations []int) (ctOut map[int]*Ciphertext) {
+	ctOut = make(map[int]*Ciphertext)
+	for _, k := range rotations {
+		ctOut[k] = eval.RotateNew(ctIn, k)
+	}
+	return
+}
+
+func (eval *cleartextEvaluator) RotateBy(ctIn *Ciphertext, rot rlwe.Rotation, ctOut *Ciphertext) {
+	switch rot := rot.(type) {
+	case rlwe.SlotRotation:
+		eval.Rotate(ctIn, int(rot), ctOut)
+	case rlwe.CoeffShift:
+
+		// The slots of X^k are the evaluations of X^k, obtained by decoding the plaintext X^k with a scale of 1.
+		degree := rot.MonomialDegree(eval.params.Parameters)
+		monomial := NewPlaintext(eval.params, 0, 1)
+		monomial.Element.Element.IsNTT = false
+		if degree < eval.params.N() {
+			monomial.value.Coeffs[0][degree] = 1
+		} else {
+			monomial.value.Coeffs[0][degree-eval.params.N()] = eval.params.Q()[0] - 1
+		}
+
+		factors := eval.encoder.Decode(monomial, eval.params.MaxLogSlots())
+
+		values := eval.values(ctIn)
+		for i := range values {
+			values[i] *= factors[i]
+		}
+
+		eval.setOutput(ctOut, ctIn.Degree(), utils.MinInt(ctIn.Level(), ctOut.Level()), ctIn.Scale(), values)
+	default:
+		panic(fmt.Sprintf("cannot RotateBy: unsupported rotation type %T", rot))
+	}
+}
+
+func (eval *cleartextEvaluator) RotateByNew(ctIn *Ciphertext, rot rlwe.Rotation) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.RotateBy(ctIn, rot, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) MultByMonomial(ctIn *Ciphertext, k int, ctOut *Ciphertext) {
+	eval.RotateBy(ctIn, rlwe.CoeffShift(k), ctOut)
+}
+
+func (eval *cleartextEvaluator) MultByMonomialNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext) {
+	return eval.RotateByNew(ctIn, rlwe.CoeffShift(k))
+}
+
+func (eval *cleartextEvaluator) MulByPow2New(ctIn *Ciphertext, pow2 int) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.MulByPow2(ctIn.El(), pow2, ctOut.El())
+	return
+}
+
+func (eval *cleartextEvaluator) MulByPow2(ctIn *Element, pow2 int, ctOut *Element) {
+	eval.unary(ctIn.ciphertext(), ctOut.ciphertext(), ctIn.Scale(), func(v complex128) complex128 { return v * complex(math.Pow(2, float64(pow2)), 0) })
+}
+
+func (eval *cleartextEvaluator) PowerOf2(ctIn *Ciphertext, logPow2 int, ctOut *Ciphertext) {
+
+	if logPow2 == 0 {
+		if ctIn != ctOut {
+			ctOut.Copy(ctIn)
+		}
+		return
+	}
+
+	eval.MulRelin(ctIn, ctIn, ctOut)
+	if err := eval.Rescale(ctOut, eval.params.Scale(), ctOut); err != nil {
+		panic(err)
+	}
+
+	for i := 1; i < logPow2; i++ {
+		eval.MulRelin(ctOut, ctOut, ctOut)
+		if err := eval.Rescale(ctOut, eval.params.Scale(), ctOut); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (eval *cleartextEvaluator) Power(ctIn *Ciphertext, degree int, ctOut *Ciphertext) {
+
+	if degree < 1 {
+		panic("eval.Power -> degree cannot be smaller than 1")
+	}
+
+	tmpct0 := ctIn.CopyNew()
+
+	logDegree := bits.Len64(uint64(degree)) - 1
+	po2Degree := 1 << logDegree
+
+	eval.PowerOf2(tmpct0, logDegree, ctOut)
+
+	degree -= po2Degree
+
+	for degree > 0 {
+
+		logDegree = bits.Len64(uint64(degree)) - 1
+		po2Degree = 1 << logDegree
+
+		tmp := NewCiphertext(eval.params, 1, tmpct0.Level(), tmpct0.Scale())
+
+		eval.PowerOf2(tmpct0, logDegree, tmp)
+
+		eval.MulRelin(ctOut, tmp, ctOut)
+
+		if err := eval.Rescale(ctOut, eval.params.Scale(), ctOut); err != nil {
+			panic(err)
+		}
+
+		degree -= po2Degree
+	}
+}
+
+func (eval *cleartextEvaluator) PowerNew(ctIn *Ciphertext, degree int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale())
+	eval.Power(ctIn, degree, ctOut)
+	return
+}
+
+// evaluatePoly evaluates the polynomial on each slot of ctIn with the function eval and
+// returns the result at targetScale, consuming ceil(log2(deg+1)) levels.
+func (eval *cleartextEvaluator) evaluatePoly(ctIn *Ciphertext, pol *Poly, targetScale float64, evaluate func(x complex128) complex128) (ctOut *Ciphertext, err error) {
+
+	if err := checkEnoughLevels(ctIn.Level(), pol, 1); err != nil {
+		return ctIn, err
+	}
+
+	values := eval.values(ctIn)
+	for i := range values {
+		values[i] = evaluate(values[i])
+	}
+
+	level := ctIn.Level() - bits.Len64(uint64(pol.Degree()))
+
+	ctOut = NewCiphertext(eval.params, 1, level, targetScale)
+	eval.setOutput(ctOut, 1, level, targetScale, values)
+
+	return
+}
+
+func (eval *cleartextEvaluator) EvaluatePoly(ctIn *Ciphertext, pol *Poly, targetScale float64) (ctOut *Ciphertext, err error) {
+	return eval.evaluatePoly(ctIn, pol, targetScale, func(x complex128) (y complex128) {
+		for i := len(pol.coeffs) - 1; i >= 0; i-- {
+			y = y*x + pol.coeffs[i]
+		}
+		return
+	})
+}
+
+func (eval *cleartextEvaluator) EvaluateCheby(ctIn *Ciphertext, cheby *ChebyshevInterpolation, targetScale float64) (ctOut *Ciphertext, err error) {
+	return eval.evaluatePoly(ctIn, &cheby.Poly, targetScale, func(x complex128) complex128 {
+		return evaluateChebyshevBasis(cheby.coeffs, x)
+	})
+}
+
+func (eval *cleartextEvaluator) EvaluatePlan(ctIn *Ciphertext, plan *PolyEvaluationPlan) (ctOut *Ciphertext, err error) {
+
+	if ctIn.Level() < plan.InputLevel {
+		return nil, fmt.Errorf("cannot EvaluatePlan: ciphertext level %d < plan input level %d", ctIn.Level(), plan.InputLevel)
+	}
+
+	ct := eval.DropLevelNew(ctIn, ctIn.Level()-plan.OutputLevel-plan.LogDegree)
+
+	if plan.cheby {
+		return eval.EvaluateCheby(ct, &ChebyshevInterpolation{Poly: *plan.poly}, plan.TargetScale)
+	}
+
+	return eval.EvaluatePoly(ct, plan.poly, plan.TargetScale)
+}
+
+func (eval *cleartextEvaluator) EvalPiecewise(ctIn *Ciphertext, breakpoints []float64, polys []*Poly, targetScale float64) (ctOut *Ciphertext, err error) {
+	return evalPiecewise(eval, eval.params, ctIn, breakpoints, polys, targetScale)
+}
+
+func (eval *cleartextEvaluator) RoundNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalRound(eval, ctIn, K, 0, iterations)
+}
+
+func (eval *cleartextEvaluator) FloorNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalRound(eval, ctIn, K, -0.5, iterations)
+}
+
+func (eval *cleartextEvaluator) EvalModNew(ctIn *Ciphertext, evm EvalModParameters) (ctOut *Ciphertext, err error) {
+	return evalEvalMod(eval, eval.params, ctIn, evm)
+}
+
+func (eval *cleartextEvaluator) InverseRangeNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalInverseRange(eval, eval.params, ctIn, a, b, iterations)
+}
+
+func (eval *cleartextEvaluator) InvSqrtNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalInvSqrt(eval, eval.params, ctIn, a, b, iterations)
+}
+
+func (eval *cleartextEvaluator) AddConstVector(ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error) {
+	return evalAddConstVector(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+func (eval *cleartextEvaluator) AddConstVectorNew(ctIn *Ciphertext, values []complex128) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+	return ctOut, eval.AddConstVector(ctIn, values, ctOut)
+}
+
+func (eval *cleartextEvaluator) MulConstVectorThenAdd(ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error) {
+	return evalMulConstVectorThenAdd(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+func (eval *cleartextEvaluator) MaskSlots(ctIn *Ciphertext, mask []bool, ctOut *Ciphertext) (err error) {
+	return evalMaskSlots(eval, eval.params, ctIn, mask, ctOut)
+}
+
+func (eval *cleartextEvaluator) MaskSlotsNew(ctIn *Ciphertext, mask []bool) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+	return ctOut, eval.MaskSlots(ctIn, mask, ctOut)
+}
+
+func (eval *cleartextEvaluator) ExtractSlotRange(ctIn *Ciphertext, start, end int, ctOut *Ciphertext) (err error) {
+	return evalExtractSlotRange(eval, eval.params, ctIn, start, end, ctOut)
+}
+
+func (eval *cleartextEvaluator) ExtractSlotRangeNew(ctIn *Ciphertext, start, end int) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+	return ctOut, eval.ExtractSlotRange(ctIn, start, end, ctOut)
+}
+
+func (eval *cleartextEvaluator) InverseNew(ctIn *Ciphertext, steps int) (ctOut *Ciphertext) {
+
+	cbar := eval.NegNew(ctIn)
+
+	eval.AddConst(cbar, 1, cbar)
+
+	tmp := eval.AddConstNew(cbar, 1)
+	ctOut = tmp.CopyNew()
+
+	for i := 1; i < steps; i++ {
+
+		eval.MulRelin(cbar, cbar, cbar)
+
+		if err := eval.Rescale(cbar, eval.params.Scale(), cbar); err != nil {
+			panic(err)
+		}
+
+		tmp = eval.AddConstNew(cbar, 1)
+
+		eval.MulRelin(tmp, ctOut, tmp)
+
+		if err := eval.Rescale(tmp, eval.params.Scale(), tmp); err != nil {
+			panic(err)
+		}
+
+		ctOut = tmp.CopyNew()
+	}
+
+	return
+}
+
+func (eval *cleartextEvaluator) WeightedSumNew(cts []*Ciphertext, weights []float64) (ctOut *Ciphertext) {
+
+	if len(cts) == 0 {
+		panic("cannot WeightedSumNew: no input ciphertext")
+	}
+
+	if len(cts) != len(weights) {
+		panic("cannot WeightedSumNew: number of ciphertexts and weights do not match")
+	}
+
+	level, degree, scale := cts[0].Level(), cts[0].Degree(), cts[0].Scale()
+	for _, ct := range cts[1:] {
+		level = utils.MinInt(level, ct.Level())
+		degree = utils.MaxInt(degree, ct.Degree())
+		scale = math.Max(scale, ct.Scale())
+	}
+
+	if level == 0 {
+		panic("cannot WeightedSumNew: inputs must be at a level greater than 0")
+	}
+
+	ctOut = NewCiphertext(eval.params, degree, level, scale*float64(eval.params.Q()[level]))
+
+	for i := range cts {
+		eval.MultByConstAndAdd(cts[i], weights[i], ctOut)
+	}
+
+	if err := eval.Rescale(ctOut, scale, ctOut); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+func (eval *cleartextEvaluator) WeightedAverageNew(cts []*Ciphertext, weights []float64) (ctOut *Ciphertext) {
+
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+
+	if sum == 0 {
+		panic("cannot WeightedAverageNew: sum of the weights is zero")
+	}
+
+	normalized := make([]float64, len(weights))
+	for i := range weights {
+		normalized[i] = weights[i] / sum
+	}
+
+	return eval.WeightedSumNew(cts, normalized)
+}
+
+// decodeDiagonals returns the diagonals of the matrix, indexed by their rotation.
+func (eval *cleartextEvaluator) decodeDiagonals(matrix *PtDiagMatrix) (diags map[int][]complex128) {
+
+	diags = make(map[int][]complex128)
+
+	for k, vec := range matrix.Vec {
+
+		pt := NewPlaintext(eval.params, matrix.Level, matrix.Scale)
+		eval.ringQ.InvMFormLvl(matrix.Level, vec[0], pt.value)
+
+		diag := eval.encoder.Decode(pt, eval.params.MaxLogSlots())
+
+		// The diagonals of the baby-step giant-step matrices are pre-rotated by the giant step.
+		if !matrix.naive {
+			diag = rotate(diag, (k/matrix.N1)*matrix.N1)
+		}
+
+		diags[k] = diag
+	}
+
+	return
+}
+
+func (eval *cleartextEvaluator) multiplyByDiagMatrix(ctIn *Ciphertext, matrix *PtDiagMatrix, ctOut *Ciphertext) {
+
+	values := eval.values(ctIn)
+	res := make([]complex128, len(values))
+
+	for k, diag := range eval.decodeDiagonals(matrix) {
+		rotated := rotate(values, k)
+		for i := range res {
+			res[i] += diag[i] * rotated[i]
+		}
+	}
+
+	level := utils.MinInt(ctOut.Level(), utils.MinInt(ctIn.Level(), matrix.Level))
+	eval.setOutput(ctOut, 1, level, matrix.Scale*ctIn.Scale(), res)
+}
+
+func (eval *cleartextEvaluator) LinearTransform(ctIn *Ciphertext, linearTransform interface{}) (ctOut []*Ciphertext) {
+
+	switch element := linearTransform.(type) {
+	case []*PtDiagMatrix:
+
+		var maxLevel int
+		for _, matrix := range element {
+			maxLevel = utils.MaxInt(maxLevel, matrix.Level)
+		}
+
+		minLevel := utils.MinInt(maxLevel, ctIn.Level())
+
+		ctOut = make([]*Ciphertext, len(element))
+		for i, matrix := range element {
+			ctOut[i] = NewCiphertext(eval.params, 1, minLevel, ctIn.Scale())
+			eval.multiplyByDiagMatrix(ctIn, matrix, ctOut[i])
+		}
+
+	case *LinearTransformPrecomputed:
+		ctOut = eval.LinearTransform(ctIn, element.Matrix(ctIn.Level()))
+
+	case *PtDiagMatrix:
+		ctOut = []*Ciphertext{NewCiphertext(eval.params, 1, utils.MinInt(element.Level, ctIn.Level()), ctIn.Scale())}
+		eval.multiplyByDiagMatrix(ctIn, element, ctOut[0])
+	}
+
+	return
+}
+
+func (eval *cleartextEvaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix *PtDiagMatrix, c2QiQDecomp, c2QiPDecomp []*ring.Poly, ctOut *Ciphertext) {
+	eval.multiplyByDiagMatrix(ctIn, matrix, ctOut)
+}
+
+func (eval *cleartextEvaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix *PtDiagMatrix, c2QiQDecomp, c2QiPDecomp []*ring.Poly, ctOut *Ciphertext) {
+	eval.multiplyByDiagMatrix(ctIn, matrix, ctOut)
+}
+
+// innerSum sums the n rotations of ctIn by multiples of batchSize and returns the result in ctOut.
+func (eval *cleartextEvaluator) innerSum(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
+
+	values := eval.values(ctIn)
+	res := make([]complex128, len(values))
+
+	for i := 0; i < n; i++ {
+		rotated := rotate(values, i*batchSize)
+		for j := range res {
+			res[j] += rotated[j]
+		}
+	}
+
+	eval.setOutput(ctOut, ctIn.Degree(), ctIn.Level(), ctIn.Scale(), res)
+}
+
+func (eval *cleartextEvaluator) InnerSumLog(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
+	eval.innerSum(ctIn, batchSize, n, ctOut)
+}
+
+func (eval *cleartextEvaluator) InnerSum(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
+	eval.innerSum(ctIn, batchSize, n, ctOut)
+}
+
+func (eval *cleartextEvaluator) ReplicateLog(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
+	eval.innerSum(ctIn, -batchSize, n, ctOut)
+}
+
+func (eval *cleartextEvaluator) Replicate(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
+	eval.innerSum(ctIn, -batchSize, n, ctOut)
+}
+
+func (eval *cleartextEvaluator) Trace(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int, ctOut *Ciphertext) {
+	evalTrace(eval, eval.params, ctIn, logSlotsStart, logSlotsEnd, ctOut)
+}
+
+func (eval *cleartextEvaluator) TraceNew(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.Trace(ctIn, logSlotsStart, logSlotsEnd, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) InnerSumGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext) {
+	eval.reduceGroups(ctIn, batch, n, layout, 1, ctOut)
+}
+
+func (eval *cleartextEvaluator) AverageGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext) {
+	eval.reduceGroups(ctIn, batch, n, layout, 1/float64(n), ctOut)
+}
+
+func (eval *cleartextEvaluator) reduceGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, factor float64, ctOut *Ciphertext) {
+
+	checkGroups(eval.params, batch, n, layout)
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	if level == 0 {
+		panic("cannot reduceGroups: input Ciphertext already at level 0")
+	}
+
+	values := eval.values(ctIn)
+	res := make([]complex128, len(values))
+
+	for i := 0; i < len(values); i += batch * n {
+		for j := 0; j < batch; j++ {
+
+			var sum complex128
+			for k := 0; k < n; k++ {
+				sum += values[i+k*batch+j]
+			}
+			sum *= complex(factor, 0)
+
+			res[i+j] = sum
+			if layout == GroupReplicated {
+				for k := 1; k < n; k++ {
+					res[i+k*batch+j] = sum
+				}
+			}
+		}
+	}
+
+	eval.setOutput(ctOut, 1, level-1, ctIn.Scale(), res)
+}
+
+func (eval *cleartextEvaluator) SwitchKeysNew(ctIn *Ciphertext, switchingKey *rlwe.SwitchingKey) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.SwitchKeys(ctIn, switchingKey, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) SwitchKeys(ctIn *Ciphertext, switchingKey *rlwe.SwitchingKey, ctOut *Ciphertext) {
+	eval.setOutput(ctOut, 1, utils.MinInt(ctIn.Level(), ctOut.Level()), ctIn.Scale(), eval.values(ctIn))
+}
+
+func (eval *cleartextEvaluator) RelinearizeNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale())
+	eval.Relinearize(ctIn, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) Relinearize(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.setOutput(ctOut, 1, utils.MinInt(ctIn.Level(), ctOut.Level()), ctIn.Scale(), eval.values(ctIn))
+}
+
+func (eval *cleartextEvaluator) ScaleUpNew(ctIn *Ciphertext, scale float64) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextUnary(ctIn)
+	eval.ScaleUp(ctIn, scale, ctOut)
+	return
+}
+
+func (eval *cleartextEvaluator) ScaleUp(ctIn *Ciphertext, scale float64, ctOut *Ciphertext) {
+	// The evaluator multiplies by the integer part of the scale.
+	factor := complex(float64(uint64(scale))/scale, 0)
+	eval.unary(ctIn, ctOut, ctIn.Scale()*scale, func(v complex128) complex128 { return v * factor })
+}
+
+func (eval *cleartextEvaluator) SetScale(ct *Ciphertext, scale float64) {
+	_, isInt := constToComplex(scale / ct.Scale())
+	ct.MulScale(scale / ct.Scale() * eval.constScale(ct.Level(), isInt))
+	if err := eval.Rescale(ct, scale, ct); err != nil {
+		panic(err)
+	}
+	ct.SetScale(scale)
+}
+
+func (eval *cleartextEvaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+
+	if minScale <= 0 {
+		return errors.New("cannot Rescale: minScale is 0")
+	}
+
+	if ctIn.Scale() == 0 {
+		return errors.New("cannot Rescale: ciphertext scale is 0")
+	}
+
+	if ctIn.Level() == 0 {
+		return errors.New("cannot Rescale: input Ciphertext already at level 0")
+	}
+
+	if ctOut.Degree() != ctIn.Degree() {
+		return errors.New("cannot Rescale : ctIn.Degree() != ctOut.Degree()")
+	}
+
+	level, scale := ctIn.Level(), ctIn.Scale()
+	for level > 0 && scale/float64(eval.params.Q()[level]) >= minScale/2 {
+		scale /= float64(eval.params.Q()[level])
+		level--
+	}
+
+	eval.setOutput(ctOut, ctIn.Degree(), level, scale, eval.values(ctIn))
+
+	return nil
+}
+
+func (eval *cleartextEvaluator) DropLevelNew(ctIn *Ciphertext, levels int) (ctOut *Ciphertext) {
+	ctOut = ctIn.CopyNew()
+	eval.DropLevel(ctOut, levels)
+	return
+}
+
+func (eval *cleartextEvaluator) DropLevel(ct *Ciphertext, levels int) {
+	eval.setOutput(ct, ct.Degree(), ct.Level()-levels, ct.Scale(), eval.values(ct))
+}
+
+func (eval *cleartextEvaluator) ReduceNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return ctIn.CopyNew()
+}
+
+func (eval *cleartextEvaluator) Reduce(ctIn *Ciphertext, ctOut *Ciphertext) error {
+	if ctIn != ctOut {
+		ctOut.Copy(ctIn)
+	}
+	return nil
+}
+
+func (eval *cleartextEvaluator) CompressNew(ctIn *Ciphertext, droppedBits int) (ctOut *CompressedCiphertext) {
+	panic("cannot CompressNew: not supported by the cleartext evaluator")
+}
+
+func (eval *cleartextEvaluator) SanitizeNew(ctIn *Ciphertext, logPrecision float64) (ctOut *Ciphertext) {
+
+	sigma := math.Exp2(-logPrecision) / math.Sqrt2
+
+	// Box-Muller transform
+	values := eval.values(ctIn)
+	for i := range values {
+		r := sigma * math.Sqrt(-2*math.Log(1-utils.RandFloat64(0, 1)))
+		theta := 2 * math.Pi * utils.RandFloat64(0, 1)
+		values[i] += complex(r*math.Cos(theta), r*math.Sin(theta))
+	}
+
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), 0, ctIn.Scale())
+	eval.setOutput(ctOut, ctIn.Degree(), 0, ctIn.Scale(), values)
+	return
+}
+
+func (eval *cleartextEvaluator) DecompInternal(level int, c2NTT *ring.Poly, c2QiQDecomp, c2QiPDecomp []*ring.Poly) {
+	panic("cannot DecompInternal: not supported by the cleartext evaluator")
+}
+
+func (eval *cleartextEvaluator) ShallowCopy() Evaluator {
+	return NewCleartextEvaluator(eval.params)
+}
+
+func (eval *cleartextEvaluator) WithKey(rlwe.EvaluationKey) Evaluator {
+	return eval.ShallowCopy()
+}
+
+func (eval *cleartextEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	return eval.ShallowCopy()
+}
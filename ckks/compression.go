@@ -0,0 +1,242 @@
+package ckks
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// CompressedCiphertext is a ciphertext switched to the lowest level, in the coefficient domain, whose coefficients
+// are rounded to their high-order bits to minimize its transmission size. It is meant for "result" ciphertexts,
+// on which no further homomorphic operations are performed, and must be decompressed before being decrypted.
+type CompressedCiphertext struct {
+	scale       float64
+	isReal      bool
+	q0          uint64
+	droppedBits int
+	value       [][]uint64
+}
+
+// CompressNew switches ctIn to the lowest level and rounds the coefficients of each of its polynomials to their
+// high-order bits, dropping the droppedBits low-order bits, and returns the result in a newly created element.
+// droppedBits is the size-vs-noise tradeoff knob: each dropped bit saves one bit per coefficient but doubles the
+// rounding error (see Parameters.CompressionDroppedBits). droppedBits = 0 only switches the modulus.
+func (eval *evaluator) CompressNew(ctIn *Ciphertext, droppedBits int) (ctOut *CompressedCiphertext) {
+
+	q0 := eval.ringQ.Modulus[0]
+
+	if droppedBits < 0 || droppedBits >= bits.Len64(q0) {
+		panic(fmt.Sprintf("cannot CompressNew: droppedBits must be in [0, %d[", bits.Len64(q0)))
+	}
+
+	ctOut = &CompressedCiphertext{
+		scale:       ctIn.Scale(),
+		isReal:      ctIn.IsReal(),
+		q0:          q0,
+		droppedBits: droppedBits,
+		value:       make([][]uint64, ctIn.Degree()+1),
+	}
+
+	pool := eval.poolQ[0]
+
+	for i := range ctIn.Value {
+
+		if ctIn.IsNTT() {
+			eval.ringQ.InvNTTLvl(0, ctIn.Value[i], pool)
+		} else {
+			eval.ringQ.CopyLvl(0, ctIn.Value[i], pool)
+		}
+
+		ctOut.value[i] = make([]uint64, eval.ringQ.N)
+
+		for j, c := range pool.Coeffs[0] {
+			ctOut.value[i][j] = roundHighBits(c, droppedBits)
+		}
+	}
+
+	return
+}
+
+// roundHighBits returns round(c / 2^droppedBits).
+func roundHighBits(c uint64, droppedBits int) uint64 {
+	if droppedBits == 0 {
+		return c
+	}
+	return (c + (1 << (droppedBits - 1))) >> droppedBits
+}
+
+// Scale returns the scale of the compressed ciphertext.
+func (ct *CompressedCiphertext) Scale() float64 {
+	return ct.scale
+}
+
+// Degree returns the degree of the compressed ciphertext.
+func (ct *CompressedCiphertext) Degree() int {
+	return len(ct.value) - 1
+}
+
+// DroppedBits returns the number of low-order bits dropped from each coefficient.
+func (ct *CompressedCiphertext) DroppedBits() int {
+	return ct.droppedBits
+}
+
+// Decompress returns the ciphertext at level 0, in the NTT domain, represented by the compressed ciphertext.
+// The dropped low-order bits are set to zero. Panics if params do not match the compressed ciphertext.
+func (ct *CompressedCiphertext) Decompress(params Parameters) (ctOut *Ciphertext) {
+
+	if params.Q()[0] != ct.q0 || len(ct.value) == 0 || len(ct.value[0]) != params.N() {
+		panic("cannot Decompress: parameters do not match the compressed ciphertext")
+	}
+
+	ringQ := params.RingQ()
+
+	ctOut = NewCiphertext(params, ct.Degree(), 0, ct.scale)
+	ctOut.SetIsReal(ct.isReal)
+
+	for i := range ct.value {
+		coeffs := ctOut.Value[i].Coeffs[0]
+		for j, c := range ct.value[i] {
+			coeffs[j] = (c << ct.droppedBits) % ct.q0
+		}
+		ringQ.NTTLvl(0, ctOut.Value[i], ctOut.Value[i])
+	}
+
+	return
+}
+
+// coeffBits returns the bit-size of the compressed coefficients.
+func (ct *CompressedCiphertext) coeffBits() int {
+	return bits.Len64(roundHighBits(ct.q0-1, ct.droppedBits))
+}
+
+// GetDataLen returns the length in bytes of the marshaled compressed ciphertext.
+func (ct *CompressedCiphertext) GetDataLen() (dataLen int) {
+	// MetaData is :
+	// 1 byte : Degree + 1
+	// 8 byte : Scale
+	// 1 byte : isReal
+	// 1 byte : droppedBits
+	// 8 byte : q0
+	// 1 byte : logN
+	dataLen = 20
+	if len(ct.value) != 0 {
+		dataLen += (len(ct.value)*len(ct.value[0])*ct.coeffBits() + 7) >> 3
+	}
+	return
+}
+
+// MarshalBinary encodes the compressed ciphertext on a byte slice, using ceil(log2(q0/2^droppedBits)) bits per
+// coefficient.
+func (ct *CompressedCiphertext) MarshalBinary() (data []byte, err error) {
+
+	if len(ct.value) == 0 || len(ct.value) > 0xFF {
+		return nil, errors.New("cannot MarshalBinary: invalid degree")
+	}
+
+	data = make([]byte, ct.GetDataLen())
+
+	data[0] = uint8(len(ct.value))
+	binary.LittleEndian.PutUint64(data[1:9], math.Float64bits(ct.scale))
+	if ct.isReal {
+		data[9] = 1
+	}
+	data[10] = uint8(ct.droppedBits)
+	binary.LittleEndian.PutUint64(data[11:19], ct.q0)
+	data[19] = uint8(bits.Len64(uint64(len(ct.value[0]))) - 1)
+
+	w := ct.coeffBits()
+
+	var pos int
+	for i := range ct.value {
+		for _, c := range ct.value[i] {
+			for b := 0; b < w; b++ {
+				if (c>>b)&1 == 1 {
+					data[20+(pos>>3)] |= 1 << (pos & 7)
+				}
+				pos++
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled compressed ciphertext on the target compressed ciphertext.
+func (ct *CompressedCiphertext) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 20 {
+		return errors.New("too small bytearray")
+	}
+
+	if data[0] == 0 || data[19] < rlwe.MinLogN || data[19] > rlwe.MaxLogN {
+		return errors.New("invalid metadata")
+	}
+
+	scale := math.Float64frombits(binary.LittleEndian.Uint64(data[1:9]))
+	isReal := data[9] == 1
+	droppedBits := int(data[10])
+	q0 := binary.LittleEndian.Uint64(data[11:19])
+
+	if q0 < 2 || droppedBits >= bits.Len64(q0) {
+		return errors.New("invalid metadata")
+	}
+
+	// Checks the length announced by the header before allocating anything
+	N := 1 << data[19]
+	w := bits.Len64(roundHighBits(q0-1, droppedBits))
+	if len(data) != 20+(int(data[0])*N*w+7)>>3 {
+		return errors.New("invalid bytearray length")
+	}
+
+	ct.scale = scale
+	ct.isReal = isReal
+	ct.droppedBits = droppedBits
+	ct.q0 = q0
+
+	ct.value = make([][]uint64, data[0])
+	for i := range ct.value {
+		ct.value[i] = make([]uint64, N)
+	}
+
+	var pos int
+	for i := range ct.value {
+		for j := range ct.value[i] {
+			var c uint64
+			for b := 0; b < w; b++ {
+				c |= uint64((data[20+(pos>>3)]>>(pos&7))&1) << b
+				pos++
+			}
+			ct.value[i][j] = c
+		}
+	}
+
+	return nil
+}
+
+// CompressionDroppedBits returns the largest number of low-order bits that can be dropped by Evaluator.CompressNew
+// on a ciphertext of the given scale such that the standard deviation of the rounding error in the slots stays
+// below 2^-logPrecision, assuming a ternary secret. Returns 0 if no bit can be dropped.
+func (p Parameters) CompressionDroppedBits(scale, logPrecision float64) (droppedBits int) {
+
+	// The rounding errors e0 + e1*s of the coefficients have a variance of 2^(2k)/12 * (1 + 2N/3)
+	// and the decoding multiplies the standard deviation by about sqrt(N).
+	N := float64(p.N())
+	logStd := math.Log2(math.Sqrt(N*(1+2*N/3)/12) / scale)
+
+	droppedBits = int(math.Floor(-logPrecision - logStd))
+
+	q0Bits := bits.Len64(p.Q()[0])
+	if droppedBits >= q0Bits {
+		droppedBits = q0Bits - 1
+	}
+
+	if droppedBits < 0 {
+		droppedBits = 0
+	}
+
+	return
+}
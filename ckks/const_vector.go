@@ -0,0 +1,151 @@
+package ckks
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// constVectorCacheSize is the maximum number of encoded constant vectors cached by an evaluator.
+const constVectorCacheSize = 64
+
+type constVectorKey struct {
+	digest [sha256.Size]byte
+	level  int
+	scale  float64
+}
+
+// constVectorCache caches the plaintexts of the constant vectors of AddConstVector and MulConstVectorThenAdd, so
+// that the constants used repeatedly (e.g., the weights of a model applied to many ciphertexts) are encoded once.
+// The oldest plaintext is evicted when the cache is full. It is shared by the shallow copies of an evaluator and is
+// safe for concurrent use.
+type constVectorCache struct {
+	sync.Mutex
+	params     Parameters
+	encoder    Encoder
+	plaintexts map[constVectorKey]*Plaintext
+	keys       []constVectorKey // from the oldest to the most recent plaintext
+}
+
+func newConstVectorCache(params Parameters) *constVectorCache {
+	return &constVectorCache{params: params, plaintexts: make(map[constVectorKey]*Plaintext)}
+}
+
+// get returns the plaintext of values encoded in the NTT domain on Params.LogSlots() slots at the given level and
+// scale. The returned plaintext must not be modified.
+func (c *constVectorCache) get(values []complex128, level int, scale float64) (pt *Plaintext, err error) {
+
+	params := c.params
+
+	if len(values) > params.Slots() {
+		return nil, fmt.Errorf("the vector has %d values but the parameters have %d slots", len(values), params.Slots())
+	}
+
+	h := sha256.New()
+	var buf [16]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(buf[:8], math.Float64bits(real(v)))
+		binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(imag(v)))
+		h.Write(buf[:])
+	}
+
+	key := constVectorKey{level: level, scale: scale}
+	copy(key.digest[:], h.Sum(nil))
+
+	c.Lock()
+	defer c.Unlock()
+
+	if pt, ok := c.plaintexts[key]; ok {
+		return pt, nil
+	}
+
+	if c.encoder == nil {
+		c.encoder = NewEncoder(params)
+	}
+
+	slots := make([]complex128, params.Slots())
+	copy(slots, values)
+
+	pt = NewPlaintext(params, level, scale)
+	c.encoder.EncodeNTT(pt, slots, params.LogSlots())
+
+	if len(c.keys) == constVectorCacheSize {
+		delete(c.plaintexts, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+
+	c.plaintexts[key] = pt
+	c.keys = append(c.keys, key)
+
+	return pt, nil
+}
+
+// AddConstVector adds the vector of constants values to the slots of ctIn and returns the result in ctOut. The
+// vector is encoded on Params.LogSlots() slots at the level and scale of ctIn, and the slots beyond its length are
+// left unchanged. The encodings of the last vectors are cached by the evaluator and its shallow copies. Returns an
+// error if the vector is longer than the number of slots.
+func (eval *evaluator) AddConstVector(ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error) {
+	return evalAddConstVector(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+// AddConstVectorNew adds the vector of constants values to the slots of ctIn and returns the result in a newly
+// created element. See AddConstVector.
+func (eval *evaluator) AddConstVectorNew(ctIn *Ciphertext, values []complex128) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+	return ctOut, eval.AddConstVector(ctIn, values, ctOut)
+}
+
+// MulConstVectorThenAdd multiplies the slots of ctIn by the vector of constants values and adds the result to
+// ctOut, i.e. it evaluates ctOut += ctIn * values. The vector is encoded on Params.LogSlots() slots with the scale
+// ctOut.Scale()/ctIn.Scale(), so that the product is added to ctOut without any alignment of the scales: ctOut
+// must have a scale larger than the one of ctIn, typically ctIn.Scale() * Q[level] so that a Rescale of the
+// accumulated result brings it back to the scale of ctIn. The slots beyond the length of the vector are multiplied
+// by zero. The level of ctOut is dropped to the one of ctIn if it is larger. The encodings of the last vectors are
+// cached by the evaluator and its shallow copies. Returns an error if the vector is longer than the number of slots
+// or if the scale of ctOut is smaller than the scale of ctIn.
+func (eval *evaluator) MulConstVectorThenAdd(ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error) {
+	return evalMulConstVectorThenAdd(eval, eval.constVectors, ctIn, values, ctOut)
+}
+
+// evalAddConstVector implements AddConstVector on top of the Evaluator interface, so that it is shared by the
+// evaluator and the cleartext evaluator.
+func evalAddConstVector(eval Evaluator, cache *constVectorCache, ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error) {
+
+	var pt *Plaintext
+	if pt, err = cache.get(values, ctIn.Level(), ctIn.Scale()); err != nil {
+		return fmt.Errorf("cannot AddConstVector: %w", err)
+	}
+
+	eval.Add(ctIn, pt, ctOut)
+
+	return nil
+}
+
+// evalMulConstVectorThenAdd implements MulConstVectorThenAdd on top of the Evaluator interface, so that it is
+// shared by the evaluator and the cleartext evaluator.
+func evalMulConstVectorThenAdd(eval Evaluator, cache *constVectorCache, ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error) {
+
+	if ctOut.Scale() < ctIn.Scale() {
+		return fmt.Errorf("cannot MulConstVectorThenAdd: the scale 2^%.2f of ctOut is smaller than the scale 2^%.2f of ctIn", math.Log2(ctOut.Scale()), math.Log2(ctIn.Scale()))
+	}
+
+	level := ctIn.Level()
+	if ctOut.Level() < level {
+		level = ctOut.Level()
+	}
+
+	var pt *Plaintext
+	if pt, err = cache.get(values, level, ctOut.Scale()/ctIn.Scale()); err != nil {
+		return fmt.Errorf("cannot MulConstVectorThenAdd: %w", err)
+	}
+
+	if ctOut.Level() > level {
+		eval.DropLevel(ctOut, ctOut.Level()-level)
+	}
+
+	eval.Add(ctOut, eval.MulNew(ctIn, pt), ctOut)
+
+	return nil
+}
@@ -0,0 +1,491 @@
+package ckks
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strings"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// debugScaleTolerance is the relative misalignment of the scales of two operands tolerated by the DebugEvaluator,
+// which covers the small differences left by the rescalings by primes close to, but not equal to, the scale.
+const debugScaleTolerance = 1.0 / (1 << 8)
+
+// debugState is the state shared by a DebugEvaluator and its shallow copies.
+type debugState struct {
+	sync.Mutex
+	step   int
+	bounds map[*Ciphertext]CanonicalBound
+}
+
+// DebugEvaluator is an Evaluator that checks the scales, levels and degrees of the operands of each operation and
+// panics with an actionable message when the operation would silently produce a wrong result, e.g.
+//
+//	rescale needed before Add: scales 2^40.00 vs 2^80.00
+//
+// instead of aligning the scales by a huge integer. The checks are:
+//
+//   - the operands of Add and Sub must have the same scale, up to an integer ratio smaller than the square root of
+//     the modulus of their level (a larger ratio means that an operand is the unrescaled output of a product);
+//   - the operands of the rotations, conjugations and multiplications must be relinearized;
+//   - the messages must not overflow the modulus of their level, i.e. the estimated magnitude of the output of an
+//     operation, at its scale, must be smaller than half of the product of the moduli of its level;
+//   - the rescalings must not be called at level 0;
+//   - the estimated precision of the output of an operation must not be smaller than the minimum precision set with
+//     SetMinPrecision, if any.
+//
+// The precision of the outputs is estimated without the secret key, by propagating a CanonicalBound through the
+// operations (see CanonicalBoundEstimator): the ciphertexts that were not produced by the DebugEvaluator are assumed
+// to be fresh public-key encryptions of messages bounded by 1, unless their bound is set with SetMessageBound.
+//
+// If a writer is given, each checked operation is logged on one line with the levels and scales of its inputs and
+// its output, and the estimated precision of its output. The other methods are the ones of the wrapped Evaluator
+// and are neither checked nor logged. The DebugEvaluator keeps a reference to the ciphertexts it has seen until
+// Reset is called: it is meant for debugging only.
+type DebugEvaluator struct {
+	Evaluator
+	params    Parameters
+	estimator *CanonicalBoundEstimator
+
+	w            io.Writer
+	minPrecision float64
+
+	state *debugState
+}
+
+// NewDebugEvaluator creates a new DebugEvaluator wrapping eval, logging the operations on w, which can be nil.
+func NewDebugEvaluator(params Parameters, eval Evaluator, w io.Writer) *DebugEvaluator {
+	return &DebugEvaluator{
+		Evaluator: eval,
+		params:    params,
+		estimator: NewCanonicalBoundEstimator(params, 0),
+		w:         w,
+		state:     &debugState{bounds: make(map[*Ciphertext]CanonicalBound)},
+	}
+}
+
+// SetMinPrecision sets the minimum estimated precision, in bits, of the outputs of the operations, below which the
+// DebugEvaluator panics. A minimum precision of 0 disables the check.
+func (eval *DebugEvaluator) SetMinPrecision(bits float64) {
+	eval.minPrecision = bits
+}
+
+// SetMessageBound registers ct as a fresh public-key encryption of a message whose decoded values are bounded by
+// messageBound in absolute value, which is the starting point of the estimation of the precision of the
+// operations on ct.
+func (eval *DebugEvaluator) SetMessageBound(ct *Ciphertext, messageBound float64) {
+	eval.state.Lock()
+	defer eval.state.Unlock()
+	eval.state.bounds[ct] = eval.freshBound(ct, messageBound)
+}
+
+// EstimatedPrecision returns the estimated precision, in bits, of the decoded values of ct.
+func (eval *DebugEvaluator) EstimatedPrecision(ct *Ciphertext) float64 {
+	eval.state.Lock()
+	defer eval.state.Unlock()
+	return eval.bound(ct).LogPrecision()
+}
+
+// Reset forgets the bounds of the ciphertexts and restarts the numbering of the operations.
+func (eval *DebugEvaluator) Reset() {
+	eval.state.Lock()
+	defer eval.state.Unlock()
+	eval.state.step = 0
+	eval.state.bounds = make(map[*Ciphertext]CanonicalBound)
+}
+
+// ShallowCopy creates a shallow copy of this DebugEvaluator in which all the read-only data-structures are shared
+// with the receiver and the temporary buffers are reallocated. The copy shares the bounds of the receiver.
+func (eval *DebugEvaluator) ShallowCopy() Evaluator {
+	return eval.withEvaluator(eval.Evaluator.ShallowCopy())
+}
+
+// WithKey creates a shallow copy of this DebugEvaluator in which the read-only data-structures are shared with the
+// receiver but the EvaluationKey is evaluationKey. The copy shares the bounds of the receiver.
+func (eval *DebugEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return eval.withEvaluator(eval.Evaluator.WithKey(evaluationKey))
+}
+
+// WithRotationKeyProvider creates a shallow copy of this DebugEvaluator in which the read-only data-structures are
+// shared with the receiver but the rotation keys are provided by rtkp. The copy shares the bounds of the receiver.
+func (eval *DebugEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	return eval.withEvaluator(eval.Evaluator.WithRotationKeyProvider(rtkp))
+}
+
+func (eval *DebugEvaluator) withEvaluator(evaluator Evaluator) *DebugEvaluator {
+	debug := *eval
+	debug.Evaluator = evaluator
+	return &debug
+}
+
+// freshBound returns the CanonicalBound of a fresh public-key encryption of a message bounded by messageBound at
+// the level and scale of op.
+func (eval *DebugEvaluator) freshBound(op Operand, messageBound float64) CanonicalBound {
+	b := CanonicalBound{Level: op.Level(), Scale: op.Scale(), Message: messageBound * op.Scale(), Error: eval.estimator.EncodingError()}
+	if _, isCt := op.(*Ciphertext); isCt {
+		b.Error += eval.estimator.FreshError(true)
+	}
+	return b
+}
+
+// bound returns the CanonicalBound of op. The caller must hold the lock of the state.
+func (eval *DebugEvaluator) bound(op Operand) CanonicalBound {
+	if ct, isCt := op.(*Ciphertext); isCt {
+		if b, ok := eval.state.bounds[ct]; ok {
+			return b
+		}
+	}
+	return eval.freshBound(op, 1)
+}
+
+// trace evaluates f, which returns the output of the operation, propagates the bounds of the operands to the
+// output with propagate, checks the output and logs the operation.
+func (eval *DebugEvaluator) trace(operation string, ops []Operand, propagate func(in []CanonicalBound) CanonicalBound, f func() *Ciphertext) *Ciphertext {
+
+	// The inputs are described before the evaluation, which can overwrite them.
+	eval.state.Lock()
+	in := make([]CanonicalBound, len(ops))
+	for i, op := range ops {
+		in[i] = eval.bound(op)
+		in[i].Level, in[i].Scale = op.Level(), op.Scale()
+	}
+	eval.state.Unlock()
+
+	ctOut := f()
+
+	out := propagate(in)
+
+	// The level and the scale of the output are the ones of the evaluator: the estimated magnitudes are rescaled
+	// accordingly.
+	if out.Scale != 0 && out.Scale != ctOut.Scale() {
+		ratio := ctOut.Scale() / out.Scale
+		out.Message *= ratio
+		out.Error *= ratio
+	}
+	out.Level, out.Scale = ctOut.Level(), ctOut.Scale()
+
+	eval.state.Lock()
+	eval.state.bounds[ctOut] = out
+	step := eval.state.step
+	eval.state.step++
+	eval.state.Unlock()
+
+	if eval.w != nil {
+		inputs := make([]string, len(ops))
+		for i, op := range ops {
+			inputs[i] = describeOperand(op)
+		}
+		fmt.Fprintf(eval.w, "#%d %s: in=[%s] out=(level %d, scale 2^%.2f, degree %d) precision~%.1f bits\n",
+			step, operation, strings.Join(inputs, ", "), ctOut.Level(), math.Log2(ctOut.Scale()), ctOut.Degree(), out.LogPrecision())
+	}
+
+	if logQ := eval.logQLvl(out.Level); math.Log2(out.Message) >= logQ-1 {
+		panic(fmt.Sprintf("modulus overflow in %s: the output message 2^%.2f (scale 2^%.2f) exceeds half of the modulus 2^%.2f at level %d: rescale the operands before the operation, or use a smaller scale",
+			operation, math.Log2(out.Message), math.Log2(out.Scale), logQ, out.Level))
+	}
+
+	if eval.minPrecision > 0 && out.LogPrecision() < eval.minPrecision {
+		panic(fmt.Sprintf("precision loss in %s: the estimated precision %.1f bits of the output is below the minimum of %.1f bits: use a larger scale, or rescale later",
+			operation, out.LogPrecision(), eval.minPrecision))
+	}
+
+	return ctOut
+}
+
+func describeOperand(op Operand) string {
+	kind := "pt"
+	if _, isCt := op.(*Ciphertext); isCt {
+		kind = "ct"
+	}
+	return fmt.Sprintf("%s(level %d, scale 2^%.2f)", kind, op.Level(), math.Log2(op.Scale()))
+}
+
+// logQLvl returns the log2 of the product of the moduli up to the given level.
+func (eval *DebugEvaluator) logQLvl(level int) (logQ float64) {
+	for _, qi := range eval.params.Q()[:level+1] {
+		logQ += math.Log2(float64(qi))
+	}
+	return
+}
+
+// checkScales panics if the scales of op0 and op1 cannot be aligned by the evaluator without corrupting the result.
+func (eval *DebugEvaluator) checkScales(operation string, op0, op1 Operand) {
+
+	s0, s1 := op0.Scale(), op1.Scale()
+	if math.Abs(s0-s1) <= debugScaleTolerance*math.Max(s0, s1) {
+		return
+	}
+
+	large, small := op0, op1
+	if s1 > s0 {
+		large, small = op1, op0
+	}
+
+	ratio := large.Scale() / small.Scale()
+
+	if logQ := math.Log2(float64(eval.params.Q()[large.Level()])); math.Log2(ratio) >= logQ/2 {
+		if large.Level() == 0 {
+			panic(fmt.Sprintf("cannot %s: scales 2^%.2f vs 2^%.2f cannot be aligned at level 0: rescale before the last level", operation, math.Log2(s0), math.Log2(s1)))
+		}
+		panic(fmt.Sprintf("rescale needed before %s: scales 2^%.2f vs 2^%.2f", operation, math.Log2(s0), math.Log2(s1)))
+	}
+
+	// The evaluator aligns the scales by multiplying the operand of smaller scale by floor(ratio)
+	if ratio-math.Floor(ratio) > debugScaleTolerance*ratio {
+		panic(fmt.Sprintf("scale mismatch in %s: scales 2^%.2f vs 2^%.2f have the non-integer ratio %.4f, which cannot be aligned exactly: set the scales with SetScale or align them with MultByConst",
+			operation, math.Log2(s0), math.Log2(s1), ratio))
+	}
+}
+
+// checkRelinearized panics if one of the operands is a ciphertext of degree larger than 1.
+func checkRelinearized(operation string, ops ...Operand) {
+	for _, op := range ops {
+		if op.Degree() > 1 {
+			panic(fmt.Sprintf("relinearize needed before %s: ciphertext of degree %d", operation, op.Degree()))
+		}
+	}
+}
+
+func (eval *DebugEvaluator) add(in []CanonicalBound) CanonicalBound {
+	return eval.estimator.Add(in[0], in[1])
+}
+
+func (eval *DebugEvaluator) mul(in []CanonicalBound) CanonicalBound {
+	return eval.estimator.MulRelin(in[0], in[1])
+}
+
+func (eval *DebugEvaluator) keySwitch(in []CanonicalBound) CanonicalBound {
+	return eval.estimator.KeySwitch(in[0])
+}
+
+func identityBound(in []CanonicalBound) CanonicalBound {
+	return in[0]
+}
+
+func (eval *DebugEvaluator) multByConst(constant interface{}) func(in []CanonicalBound) CanonicalBound {
+	return func(in []CanonicalBound) CanonicalBound {
+		c, isInt := constToComplex(constant)
+		if isInt {
+			return eval.estimator.MultByConst(in[0], math.Round(cmplx.Abs(c)))
+		}
+		return eval.estimator.MultByConst(in[0], cmplx.Abs(c))
+	}
+}
+
+func addConstBound(constant interface{}) func(in []CanonicalBound) CanonicalBound {
+	return func(in []CanonicalBound) CanonicalBound {
+		c, _ := constToComplex(constant)
+		b := in[0]
+		b.Message += cmplx.Abs(c) * b.Scale
+		return b
+	}
+}
+
+// Add adds op0 to op1 and returns the result in ctOut. It panics if their scales cannot be aligned.
+func (eval *DebugEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.checkScales("Add", op0, op1)
+	eval.trace("Add", []Operand{op0, op1}, eval.add, func() *Ciphertext {
+		eval.Evaluator.Add(op0, op1, ctOut)
+		return ctOut
+	})
+}
+
+// AddNew adds op0 to op1 and returns the result in a newly created element. It panics if their scales cannot be
+// aligned.
+func (eval *DebugEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	eval.checkScales("AddNew", op0, op1)
+	return eval.trace("AddNew", []Operand{op0, op1}, eval.add, func() *Ciphertext {
+		return eval.Evaluator.AddNew(op0, op1)
+	})
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut. It panics if their scales cannot be aligned.
+func (eval *DebugEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.checkScales("Sub", op0, op1)
+	eval.trace("Sub", []Operand{op0, op1}, eval.add, func() *Ciphertext {
+		eval.Evaluator.Sub(op0, op1, ctOut)
+		return ctOut
+	})
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a newly created element. It panics if their scales
+// cannot be aligned.
+func (eval *DebugEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	eval.checkScales("SubNew", op0, op1)
+	return eval.trace("SubNew", []Operand{op0, op1}, eval.add, func() *Ciphertext {
+		return eval.Evaluator.SubNew(op0, op1)
+	})
+}
+
+// Neg negates ctIn and returns the result in ctOut.
+func (eval *DebugEvaluator) Neg(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.trace("Neg", []Operand{ctIn}, identityBound, func() *Ciphertext {
+		eval.Evaluator.Neg(ctIn, ctOut)
+		return ctOut
+	})
+}
+
+// NegNew negates ctIn and returns the result in a newly created element.
+func (eval *DebugEvaluator) NegNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.trace("NegNew", []Operand{ctIn}, identityBound, func() *Ciphertext {
+		return eval.Evaluator.NegNew(ctIn)
+	})
+}
+
+// AddConst adds the input constant to ctIn and returns the result in ctOut.
+func (eval *DebugEvaluator) AddConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	eval.trace("AddConst", []Operand{ctIn}, addConstBound(constant), func() *Ciphertext {
+		eval.Evaluator.AddConst(ctIn, constant, ctOut)
+		return ctOut
+	})
+}
+
+// AddConstNew adds the input constant to ctIn and returns the result in a newly created element.
+func (eval *DebugEvaluator) AddConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	return eval.trace("AddConstNew", []Operand{ctIn}, addConstBound(constant), func() *Ciphertext {
+		return eval.Evaluator.AddConstNew(ctIn, constant)
+	})
+}
+
+// MultByConst multiplies ctIn by the input constant and returns the result in ctOut. It panics if the result
+// overflows the modulus.
+func (eval *DebugEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	eval.trace("MultByConst", []Operand{ctIn}, eval.multByConst(constant), func() *Ciphertext {
+		eval.Evaluator.MultByConst(ctIn, constant, ctOut)
+		return ctOut
+	})
+}
+
+// MultByConstNew multiplies ctIn by the input constant and returns the result in a newly created element. It panics
+// if the result overflows the modulus.
+func (eval *DebugEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	return eval.trace("MultByConstNew", []Operand{ctIn}, eval.multByConst(constant), func() *Ciphertext {
+		return eval.Evaluator.MultByConstNew(ctIn, constant)
+	})
+}
+
+// Mul multiplies op0 with op1 without relinearization and returns the result in ctOut. It panics if the operands
+// are not relinearized or if the result overflows the modulus.
+func (eval *DebugEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	checkRelinearized("Mul", op0, op1)
+	eval.trace("Mul", []Operand{op0, op1}, eval.mul, func() *Ciphertext {
+		eval.Evaluator.Mul(op0, op1, ctOut)
+		return ctOut
+	})
+}
+
+// MulNew multiplies op0 with op1 without relinearization and returns the result in a newly created element. It
+// panics if the operands are not relinearized or if the result overflows the modulus.
+func (eval *DebugEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	checkRelinearized("MulNew", op0, op1)
+	return eval.trace("MulNew", []Operand{op0, op1}, eval.mul, func() *Ciphertext {
+		return eval.Evaluator.MulNew(op0, op1)
+	})
+}
+
+// MulRelin multiplies op0 with op1 with relinearization and returns the result in ctOut. It panics if the operands
+// are not relinearized or if the result overflows the modulus.
+func (eval *DebugEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	checkRelinearized("MulRelin", op0, op1)
+	eval.trace("MulRelin", []Operand{op0, op1}, eval.mul, func() *Ciphertext {
+		eval.Evaluator.MulRelin(op0, op1, ctOut)
+		return ctOut
+	})
+}
+
+// MulRelinNew multiplies op0 with op1 with relinearization and returns the result in a newly created element. It
+// panics if the operands are not relinearized or if the result overflows the modulus.
+func (eval *DebugEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	checkRelinearized("MulRelinNew", op0, op1)
+	return eval.trace("MulRelinNew", []Operand{op0, op1}, eval.mul, func() *Ciphertext {
+		return eval.Evaluator.MulRelinNew(op0, op1)
+	})
+}
+
+// Relinearize relinearizes ctIn and returns the result in ctOut.
+func (eval *DebugEvaluator) Relinearize(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.trace("Relinearize", []Operand{ctIn}, eval.keySwitch, func() *Ciphertext {
+		eval.Evaluator.Relinearize(ctIn, ctOut)
+		return ctOut
+	})
+}
+
+// RelinearizeNew relinearizes ctIn and returns the result in a newly created element.
+func (eval *DebugEvaluator) RelinearizeNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.trace("RelinearizeNew", []Operand{ctIn}, eval.keySwitch, func() *Ciphertext {
+		return eval.Evaluator.RelinearizeNew(ctIn)
+	})
+}
+
+// Rotate rotates the slots of ctIn by k positions to the left and returns the result in ctOut. It panics if ctIn
+// is not relinearized.
+func (eval *DebugEvaluator) Rotate(ctIn *Ciphertext, k int, ctOut *Ciphertext) {
+	checkRelinearized("Rotate", ctIn)
+	eval.trace("Rotate", []Operand{ctIn}, eval.keySwitch, func() *Ciphertext {
+		eval.Evaluator.Rotate(ctIn, k, ctOut)
+		return ctOut
+	})
+}
+
+// RotateNew rotates the slots of ctIn by k positions to the left and returns the result in a newly created element.
+// It panics if ctIn is not relinearized.
+func (eval *DebugEvaluator) RotateNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext) {
+	checkRelinearized("RotateNew", ctIn)
+	return eval.trace("RotateNew", []Operand{ctIn}, eval.keySwitch, func() *Ciphertext {
+		return eval.Evaluator.RotateNew(ctIn, k)
+	})
+}
+
+// Conjugate conjugates the slots of ctIn and returns the result in ctOut. It panics if ctIn is not relinearized.
+func (eval *DebugEvaluator) Conjugate(ctIn *Ciphertext, ctOut *Ciphertext) {
+	checkRelinearized("Conjugate", ctIn)
+	eval.trace("Conjugate", []Operand{ctIn}, eval.keySwitch, func() *Ciphertext {
+		eval.Evaluator.Conjugate(ctIn, ctOut)
+		return ctOut
+	})
+}
+
+// ConjugateNew conjugates the slots of ctIn and returns the result in a newly created element. It panics if ctIn is
+// not relinearized.
+func (eval *DebugEvaluator) ConjugateNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	checkRelinearized("ConjugateNew", ctIn)
+	return eval.trace("ConjugateNew", []Operand{ctIn}, eval.keySwitch, func() *Ciphertext {
+		return eval.Evaluator.ConjugateNew(ctIn)
+	})
+}
+
+// Rescale divides ctIn by the last moduli of its level as long as its scale stays above minScale and returns the
+// result in ctOut (see Evaluator.Rescale). It panics if ctIn is at level 0.
+func (eval *DebugEvaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+
+	if ctIn.Level() == 0 {
+		panic(fmt.Sprintf("cannot Rescale: the ciphertext of scale 2^%.2f is at level 0: bootstrap it, or use parameters with more levels", math.Log2(ctIn.Scale())))
+	}
+
+	levelIn := ctIn.Level()
+
+	eval.trace("Rescale", []Operand{ctIn}, func(in []CanonicalBound) CanonicalBound {
+		b := in[0]
+		for i := levelIn; i > ctOut.Level(); i-- {
+			b = eval.estimator.Rescale(b)
+		}
+		return b
+	}, func() *Ciphertext {
+		err = eval.Evaluator.Rescale(ctIn, minScale, ctOut)
+		return ctOut
+	})
+
+	return
+}
+
+// DropLevel reduces the level of ctIn by levels and returns the result in ctIn.
+func (eval *DebugEvaluator) DropLevel(ctIn *Ciphertext, levels int) {
+	eval.trace("DropLevel", []Operand{ctIn}, identityBound, func() *Ciphertext {
+		eval.Evaluator.DropLevel(ctIn, levels)
+		return ctIn
+	})
+}
@@ -44,7 +44,7 @@ func NewDecryptor(params Parameters, sk *rlwe.SecretKey) Decryptor {
 
 func (decryptor *decryptor) DecryptNew(ciphertext *Ciphertext) (plaintext *Plaintext) {
 
-	plaintext = NewPlaintext(decryptor.params, ciphertext.Level(), ciphertext.Scale())
+	plaintext = newPlaintextFromPool(decryptor.params, ciphertext.Level(), ciphertext.Scale())
 
 	decryptor.Decrypt(ciphertext, plaintext)
 
@@ -56,6 +56,9 @@ func (decryptor *decryptor) Decrypt(ciphertext *Ciphertext, plaintext *Plaintext
 	level := utils.MinInt(ciphertext.Level(), plaintext.Level())
 
 	plaintext.SetScale(ciphertext.Scale())
+	plaintext.MetaData.IsReal = ciphertext.MetaData.IsReal
+	plaintext.MetaData.SlotScales = copySlotScales(ciphertext.MetaData.SlotScales)
+	plaintext.MetaData.Tags = ciphertext.MetaData.Tags.CopyNew()
 
 	decryptor.ringQ.CopyLvl(level, ciphertext.Value[ciphertext.Degree()], plaintext.value)
 
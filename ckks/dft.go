@@ -0,0 +1,120 @@
+package ckks
+
+import (
+	"fmt"
+	"math/cmplx"
+)
+
+// DFTLiteral describes a homomorphic discrete Fourier transform of size n = 2^LogSlots on the slots of a
+// ciphertext, evaluated as a product of Depth sparse matrices.
+//
+// The forward transform (Inverse = false) is the special FFT of the CKKS decoding, w_j = sum_k v_k * zeta^(k*5^j)
+// with zeta = exp(2*i*pi/(4n)), which takes its input in bit-reversed order and returns its output in natural
+// order. The inverse transform (Inverse = true) is the inverse of the forward transform multiplied by n, which
+// takes its input in natural order and returns its output in bit-reversed order. With BitReversed, the orders of
+// the input and of the output are swapped. The result is multiplied by Scaling.
+type DFTLiteral struct {
+	Inverse      bool       // Computes the inverse transform (the CKKS encoding) instead of the forward transform (the CKKS decoding)
+	LogSlots     int        // Log2 of the size of the transform, which must be the log2 of the number of slots of the ciphertexts
+	LevelStart   int        // Level of the input ciphertexts, the transform consumes Depth levels
+	Depth        int        // Number of matrices, in [1, LogSlots], over which the LogSlots radix-2 layers of the transform are split
+	Scaling      complex128 // Constant by which the result is multiplied, spread over the matrices (0 stands for 1)
+	BitReversed  bool       // Swaps the orders of the input and of the output
+	MaxN1N2Ratio float64    // Maximum ratio between the inner and outer loops of the baby-step giant-step evaluation (0 stands for 16)
+}
+
+// DFTMatrices stores the plaintext matrices of a homomorphic discrete Fourier transform.
+type DFTMatrices struct {
+	DFTLiteral
+	Matrices []*PtDiagMatrix
+}
+
+// NewDFTMatrices encodes the matrices of the homomorphic discrete Fourier transform described by literal. Each
+// matrix is encoded at the scale of the modulus of its level, so that the transform preserves the scale of the
+// ciphertexts.
+func NewDFTMatrices(params Parameters, encoder Encoder, literal DFTLiteral) (*DFTMatrices, error) {
+
+	if literal.LogSlots < 1 || literal.LogSlots > params.MaxLogSlots() {
+		return nil, fmt.Errorf("cannot NewDFTMatrices: LogSlots must be in [1, %d] but is %d", params.MaxLogSlots(), literal.LogSlots)
+	}
+
+	if literal.Depth < 1 || literal.Depth > literal.LogSlots {
+		return nil, fmt.Errorf("cannot NewDFTMatrices: Depth must be in [1, LogSlots=%d] but is %d", literal.LogSlots, literal.Depth)
+	}
+
+	if literal.LevelStart > params.MaxLevel() || literal.LevelStart < literal.Depth {
+		return nil, fmt.Errorf("cannot NewDFTMatrices: LevelStart must be in [Depth=%d, %d] but is %d", literal.Depth, params.MaxLevel(), literal.LevelStart)
+	}
+
+	if literal.Scaling == 0 {
+		literal.Scaling = 1
+	}
+
+	if literal.MaxN1N2Ratio == 0 {
+		literal.MaxN1N2Ratio = 16.0
+	}
+
+	slots := 1 << literal.LogSlots
+
+	roots := computeRoots(slots << 1)
+	pow5 := make([]int, (slots<<1)+1)
+	pow5[0] = 1
+	for i := 1; i < (slots<<1)+1; i++ {
+		pow5[i] = pow5[i-1] * 5
+		pow5[i] &= (slots << 2) - 1
+	}
+
+	scaling := cmplx.Pow(literal.Scaling, complex(1/float64(literal.Depth), 0))
+
+	pVec := computeDFTMatrices(literal.LogSlots, literal.LogSlots, literal.Depth, roots, pow5, scaling, literal.Inverse, literal.BitReversed)
+
+	matrices := make([]*PtDiagMatrix, literal.Depth)
+	for i := range matrices {
+		level := literal.LevelStart - i
+		matrices[i] = encoder.EncodeDiagMatrixBSGSAtLvl(level, pVec[i], float64(params.Q()[level]), literal.MaxN1N2Ratio, literal.LogSlots)
+	}
+
+	return &DFTMatrices{DFTLiteral: literal, Matrices: matrices}, nil
+}
+
+// Rotations returns the list of rotations needed to evaluate the transform.
+func (m *DFTMatrices) Rotations() (rotations []int) {
+	rotations = []int{}
+	for _, matrix := range m.Matrices {
+		rotations = AddMatrixRotToList(matrix, rotations, 1<<m.LogSlots, false)
+	}
+	return
+}
+
+// HomomorphicDFT evaluates the forward discrete Fourier transform of matrices on the slots of ct and returns the
+// result in a new ciphertext, at the scale of ct and Depth levels below LevelStart. The evaluator must have the
+// rotation keys of matrices.Rotations.
+func HomomorphicDFT(ct *Ciphertext, matrices *DFTMatrices, eval Evaluator) *Ciphertext {
+	if matrices.Inverse {
+		panic("cannot HomomorphicDFT: the matrices are the ones of an inverse transform")
+	}
+	return evaluateDFT(ct, matrices, eval)
+}
+
+// HomomorphicIDFT evaluates the inverse discrete Fourier transform of matrices on the slots of ct and returns the
+// result in a new ciphertext, at the scale of ct and Depth levels below LevelStart. The evaluator must have the
+// rotation keys of matrices.Rotations.
+func HomomorphicIDFT(ct *Ciphertext, matrices *DFTMatrices, eval Evaluator) *Ciphertext {
+	if !matrices.Inverse {
+		panic("cannot HomomorphicIDFT: the matrices are the ones of a forward transform")
+	}
+	return evaluateDFT(ct, matrices, eval)
+}
+
+func evaluateDFT(ct *Ciphertext, matrices *DFTMatrices, eval Evaluator) *Ciphertext {
+
+	if ct.Level() < matrices.LevelStart {
+		panic(fmt.Sprintf("cannot evaluate the DFT: the level of the ciphertext is %d but the transform starts at level %d", ct.Level(), matrices.LevelStart))
+	}
+
+	if ct.Level() > matrices.LevelStart {
+		ct = eval.DropLevelNew(ct, ct.Level()-matrices.LevelStart)
+	}
+
+	return dft(ct, matrices.Matrices, !matrices.Inverse, eval)
+}
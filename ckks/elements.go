@@ -4,14 +4,26 @@ import (
 	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
-// Element is a generic type for ciphertext and plaintexts
+// Element is a generic type for ciphertext and plaintexts. It has the layout of an rlwe.Ciphertext of the scheme
+// rlwe.SchemeCKKS, whose MetaData store the scale, the real flag, the slot scales and the metadata of the element.
 type Element struct {
-	rlwe.Element
-	scale float64
+	*rlwe.Element
+	rlwe.MetaData
 }
 
 func newElement(params Parameters, degree, level int, scale float64) *Element {
-	return &Element{*rlwe.NewElementAtLevel(params.Parameters, degree, level), scale}
+	return newElementFromRLWE(rlwe.NewElementAtLevel(params.Parameters, degree, level), scale)
+}
+
+// newElementFromRLWE returns the rlwe.Element el as an Element of the given scale. The two elements share their
+// polynomials.
+func newElementFromRLWE(el *rlwe.Element, scale float64) *Element {
+	return &Element{el, rlwe.MetaData{Scheme: rlwe.SchemeCKKS, Scale: scale}}
+}
+
+// ciphertext returns the receiver as a Ciphertext, which shares its polynomials and its MetaData.
+func (el *Element) ciphertext() *Ciphertext {
+	return &Ciphertext{(*rlwe.Ciphertext)(el)}
 }
 
 // El returns itself.
@@ -21,7 +33,7 @@ func (el *Element) El() *Element {
 
 // Scale returns the scale of the target element.
 func (el *Element) Scale() float64 {
-	return el.scale
+	return el.MetaData.Scale
 }
 
 // IsNTT returns true if the underlying rlwe.Element is in the NTT domain.
@@ -31,17 +43,62 @@ func (el *Element) IsNTT() bool {
 
 // SetScale sets the scale of the the target element to the input scale.
 func (el *Element) SetScale(scale float64) {
-	el.scale = scale
+	el.MetaData.Scale = scale
 }
 
 // MulScale multiplies the scale of the target element with the input scale.
 func (el *Element) MulScale(scale float64) {
-	el.scale *= scale
+	el.MetaData.Scale *= scale
 }
 
 // DivScale divides the scale of the target element by the input scale.
 func (el *Element) DivScale(scale float64) {
-	el.scale /= scale
+	el.MetaData.Scale /= scale
+}
+
+// IsReal returns true if the target element is known to encode a purely real message.
+// The flag is conservative: false means that the message may have imaginary components.
+func (el *Element) IsReal() bool {
+	return el.MetaData.IsReal
+}
+
+// SetIsReal sets the flag indicating whether the target element encodes a purely real message.
+// Setting it on an element whose message has non-zero imaginary components leads to incorrect results.
+func (el *Element) SetIsReal(isReal bool) {
+	el.MetaData.IsReal = isReal
+}
+
+// SlotScales returns the per-slot scaling factors of the target element, or nil if all the slots have the
+// scale of the element only (see Encoder.EncodeSlotScaledNTT).
+func (el *Element) SlotScales() []float64 {
+	return el.MetaData.SlotScales
+}
+
+// SetSlotScales sets the per-slot scaling factors of the target element. A nil value indicates that all the
+// slots have the scale of the element only.
+func (el *Element) SetSlotScales(slotScales []float64) {
+	el.MetaData.SlotScales = copySlotScales(slotScales)
+}
+
+// Metadata returns the metadata of the target element, or nil if it has none. The returned map must not be
+// modified: use SetMetadata or SetTag.
+func (el *Element) Metadata() Metadata {
+	return el.MetaData.Tags
+}
+
+// SetMetadata sets the metadata of the target element to a copy of md. A nil or empty value removes the metadata.
+func (el *Element) SetMetadata(md Metadata) {
+	el.MetaData.Tags = md.CopyNew()
+}
+
+// SetTag sets the value of the tag key in the metadata of the target element.
+func (el *Element) SetTag(key, value string) {
+	md := el.MetaData.Tags.CopyNew()
+	if md == nil {
+		md = Metadata{}
+	}
+	md[key] = value
+	el.MetaData.Tags = md
 }
 
 // Resize resizes the degree of the target element.
@@ -51,11 +108,11 @@ func (el *Element) Resize(params Parameters, degree int) {
 
 // Copy copies the `other` into the reciever Element.
 func (el *Element) Copy(other *Element) {
-	el.Element.Copy(&other.Element)
-	el.scale = other.scale
+	el.Element.Copy(other.Element)
+	el.MetaData = other.MetaData.CopyNew()
 }
 
 // CopyNew creates a deep copy of the receiver Element and returns it.
 func (el *Element) CopyNew() *Element {
-	return &Element{*el.Element.CopyNew(), el.scale}
+	return &Element{el.Element.CopyNew(), el.MetaData.CopyNew()}
 }
@@ -3,6 +3,8 @@
 package ckks
 
 import (
+	"encoding/binary"
+	"fmt"
 	"math"
 	"math/big"
 	"math/bits"
@@ -26,18 +28,26 @@ type Encoder interface {
 	EncodeNTT(plaintext *Plaintext, values []complex128, logSlots int)
 	EncodeNTTNew(values []complex128, logSlots int) (plaintext *Plaintext)
 	EncodeNTTAtLvlNew(level int, values []complex128, logSlots int) (plaintext *Plaintext)
+	EncodeSlotScaledNTT(plaintext *Plaintext, values []complex128, slotScales []float64, logSlots int)
+
+	EncodeReal(plaintext *Plaintext, values []float64, logSlots int)
+	EncodeRealNTT(plaintext *Plaintext, values []float64, logSlots int)
 
 	EncodeDiagMatrixBSGSAtLvl(level int, vector map[int][]complex128, scale, maxM1N2Ratio float64, logSlots int) (matrix *PtDiagMatrix)
 	EncodeDiagMatrixAtLvl(level int, vector map[int][]complex128, scale float64, logSlots int) (matrix *PtDiagMatrix)
 
 	Decode(plaintext *Plaintext, logSlots int) (res []complex128)
 	DecodePublic(plaintext *Plaintext, logSlots int, sigma float64) []complex128
+	DecodeSlotScaled(plaintext *Plaintext, logSlots int) (res []complex128)
+	DecodeReal(plaintext *Plaintext, logSlots int) (res []float64)
 
 	Embed(values []complex128, logSlots int)
 	ScaleUp(pol *ring.Poly, scale float64, moduli []uint64)
 
 	WipeInternalMemory()
 
+	ReEncodeAtScale(plaintext *Plaintext, newScale float64) (err error)
+
 	EncodeCoeffs(values []float64, plaintext *Plaintext)
 	DecodeCoeffs(plaintext *Plaintext) (res []float64)
 	DecodeCoeffsPublic(plaintext *Plaintext, bound float64) (res []float64)
@@ -47,6 +57,9 @@ type Encoder interface {
 }
 
 // EncoderBigComplex is an interface implenting the encoding algorithms with arbitrary precision.
+// Plaintexts produced by an EncoderBigComplex and by an Encoder instantiated with the same parameters
+// share the same representation and can be decoded by either of them, as well as mixed in the same circuit.
+// The functions Complex128ToBigComplex and BigComplexToComplex128 can be used to convert the values between both paths.
 type EncoderBigComplex interface {
 	Encode(plaintext *Plaintext, values []*ring.Complex, logSlots int)
 	EncodeNew(values []*ring.Complex, logSlots int) (plaintext *Plaintext)
@@ -54,6 +67,7 @@ type EncoderBigComplex interface {
 	EncodeNTT(plaintext *Plaintext, values []*ring.Complex, logSlots int)
 	EncodeNTTAtLvlNew(level int, values []*ring.Complex, logSlots int) (plaintext *Plaintext)
 	Decode(plaintext *Plaintext, logSlots int) (res []*ring.Complex)
+	DecodePublic(plaintext *Plaintext, logSlots int, sigma float64) (res []*ring.Complex)
 	FFT(values []*ring.Complex, N int)
 	InvFFT(values []*ring.Complex, N int)
 
@@ -81,8 +95,28 @@ type encoderComplex128 struct {
 	values      []complex128
 	valuesfloat []float64
 	roots       []complex128
+
+	rounding    RoundingMode
+	prng        utils.PRNG
+	dither      []float64
+	ditherBytes []byte
 }
 
+// RoundingMode is the rounding applied by an Encoder to the scaled values to obtain the integer coefficients
+// of the plaintexts.
+type RoundingMode int
+
+const (
+	// RoundToNearest rounds the scaled values to the nearest integer. The rounding error of a value is a
+	// deterministic function of the value, so that the errors of the encodings of the same values are
+	// correlated and accumulate linearly over the multiplications of a circuit.
+	RoundToNearest RoundingMode = iota
+	// StochasticRounding rounds the scaled values x to floor(x) + 1 with probability x - floor(x) and to
+	// floor(x) otherwise. The rounding error is unbiased and independent across encodings, at the cost of
+	// twice the variance of RoundToNearest on uniformly distributed fractional parts.
+	StochasticRounding
+)
+
 func newEncoder(params Parameters) encoder {
 
 	m := 2 * params.N()
@@ -151,6 +185,41 @@ func NewEncoder(params Parameters) Encoder {
 	}
 }
 
+// NewEncoderWithRounding creates a new Encoder that rounds the scaled values with the given rounding mode
+// (see RoundingMode). NewEncoder is equivalent to NewEncoderWithRounding with RoundToNearest.
+// The plaintexts of an Encoder with StochasticRounding are encoded with fresh randomness and are not
+// reproducible, except in the deterministic mode of the test suites of the library (see the -prng-seed flag).
+func NewEncoderWithRounding(params Parameters, rounding RoundingMode) Encoder {
+
+	encoder := NewEncoder(params).(*encoderComplex128)
+
+	switch rounding {
+	case RoundToNearest:
+	case StochasticRounding:
+		prng, err := utils.NewPRNG()
+		if err != nil {
+			panic(err)
+		}
+		encoder.rounding = rounding
+		encoder.prng = prng
+		encoder.dither = make([]float64, encoder.m>>1)
+		encoder.ditherBytes = make([]byte, 8*(encoder.m>>1))
+	default:
+		panic(fmt.Sprintf("cannot NewEncoderWithRounding: invalid rounding mode %d", rounding))
+	}
+
+	return encoder
+}
+
+// sampleDither samples n fresh uniform values in [0, 1) used by the stochastic rounding. They are shared by all
+// the calls to ScaleUp following an Embed, so that the same values are rounded to the same integers modulo Q and P.
+func (encoder *encoderComplex128) sampleDither(n int) {
+	encoder.prng.Clock(encoder.ditherBytes[:8*n])
+	for i := 0; i < n; i++ {
+		encoder.dither[i] = float64(binary.LittleEndian.Uint64(encoder.ditherBytes[8*i:])>>11) / (1 << 53)
+	}
+}
+
 // EncodeNew encodes a slice of complex128 of length slots = 2^{logSlots} on new plaintext at the maximum level.
 func (encoder *encoderComplex128) EncodeNew(values []complex128, logSlots int) (plaintext *Plaintext) {
 	return encoder.EncodeAtLvlNew(encoder.params.MaxLevel(), values, logSlots)
@@ -166,8 +235,9 @@ func (encoder *encoderComplex128) EncodeAtLvlNew(level int, values []complex128,
 // Encode encodes a slice of complex128 of length slots = 2^{logSlots} on the input plaintext.
 func (encoder *encoderComplex128) Encode(plaintext *Plaintext, values []complex128, logSlots int) {
 	encoder.Embed(values, logSlots)
-	encoder.ScaleUp(plaintext.value, plaintext.scale, encoder.ringQ.Modulus[:plaintext.Level()+1])
+	encoder.ScaleUp(plaintext.value, plaintext.MetaData.Scale, encoder.ringQ.Modulus[:plaintext.Level()+1])
 	plaintext.Element.Element.IsNTT = false
+	plaintext.MetaData.IsReal = isRealVector(values)
 }
 
 // EncodeNTTNew encodes a slice of complex128 of length slots = 2^{logSlots} on new plaintext at the maximum level.
@@ -216,6 +286,10 @@ func (encoder *encoderComplex128) Embed(values []complex128, logSlots int) {
 		encoder.valuesfloat[idx] = real(encoder.values[i])
 		encoder.valuesfloat[jdx] = imag(encoder.values[i])
 	}
+
+	if encoder.rounding == StochasticRounding {
+		encoder.sampleDither(len(encoder.valuesfloat))
+	}
 }
 
 // GetErrSTDSlotDomain returns the scaled standard deviation of the difference between two complex vectors in the slot domains
@@ -254,6 +328,10 @@ func (encoder *encoderComplex128) GetErrSTDCoeffDomain(valuesWant, valuesHave []
 
 // ScaleUp writes the internaly stored encoded values on a polynomial.
 func (encoder *encoderComplex128) ScaleUp(pol *ring.Poly, scale float64, moduli []uint64) {
+	if encoder.rounding == StochasticRounding {
+		scaleUpVecStochastic(encoder.valuesfloat, encoder.dither, scale, moduli, pol.Coeffs)
+		return
+	}
 	scaleUpVecExact(encoder.valuesfloat, scale, moduli, pol.Coeffs)
 }
 
@@ -268,6 +346,40 @@ func (encoder *encoderComplex128) WipeInternalMemory() {
 	}
 }
 
+// ReEncodeAtScale changes the scale of the plaintext to newScale without re-encoding its values, so that a
+// precomputed plaintext can be adapted to the scale of the ciphertext it will be operated with.
+// The change is done by an exact multiplication of the plaintext by the integer newScale/plaintext.Scale(),
+// hence it does not introduce any error and can be applied in the NTT or in the coefficient domain.
+// Returns an error if the ratio is not a positive integer or if newScale is too large for the level of
+// the plaintext. The caller must ensure that the encoded values times newScale stay below Q_level/2.
+func (encoder *encoderComplex128) ReEncodeAtScale(plaintext *Plaintext, newScale float64) (err error) {
+
+	ratio := newScale / plaintext.Scale()
+
+	if math.IsNaN(ratio) || ratio < 1 || math.Abs(ratio-math.Round(ratio)) > 1e-9*ratio || ratio > 1<<53 {
+		return fmt.Errorf("cannot ReEncodeAtScale: ratio %f between the scales is not an exact positive integer", ratio)
+	}
+
+	level := plaintext.Level()
+
+	var logQ float64
+	for _, qi := range encoder.ringQ.Modulus[:level+1] {
+		logQ += math.Log2(float64(qi))
+	}
+
+	if math.Log2(newScale) >= logQ-1 {
+		return fmt.Errorf("cannot ReEncodeAtScale: scale 2^%.2f too large for the plaintext level %d", math.Log2(newScale), level)
+	}
+
+	if factor := uint64(math.Round(ratio)); factor != 1 {
+		encoder.ringQ.MulScalarBigintLvl(level, plaintext.value, ring.NewUint(factor), plaintext.value)
+	}
+
+	plaintext.SetScale(newScale)
+
+	return nil
+}
+
 // DecodePublic decodes the Plaintext values to a slice of complex128 values of size at most N/2.
 // Adds a Gaussian error to the plaintext of variance sigma and bound floor(sqrt(2*pi)*sigma) before decoding
 func (encoder *encoderComplex128) DecodePublic(plaintext *Plaintext, logSlots int, bound float64) (res []complex128) {
@@ -279,6 +391,57 @@ func (encoder *encoderComplex128) Decode(plaintext *Plaintext, logSlots int) (re
 	return encoder.decodePublic(plaintext, logSlots, 0)
 }
 
+// EncodeReal encodes a slice of float64 of length at most 2*slots = 2^{logSlots+1} on the input plaintext, i.e. up to N
+// real values instead of the N/2 complex values of Encode. The values are packed in the real and imaginary parts of
+// the slots: the slot j encodes values[j] + sqrt(-1)*values[slots+j], which is the layout of Evaluator.PackRealNew, so that
+// Evaluator.UnpackRealNew converts a Ciphertext of this layout into two Ciphertexts of the standard layout.
+// Additions, rotations and multiplications by real constants or real plaintexts operate on all the values at once,
+// but multiplications between two such Ciphertexts mix the real and imaginary parts.
+func (encoder *encoderComplex128) EncodeReal(plaintext *Plaintext, values []float64, logSlots int) {
+	encoder.Encode(plaintext, packRealValues(values, logSlots), logSlots)
+}
+
+// EncodeRealNTT encodes a slice of float64 of length at most 2*slots = 2^{logSlots+1} on the input plaintext (see
+// EncodeReal). Returns a plaintext in the NTT domain.
+func (encoder *encoderComplex128) EncodeRealNTT(plaintext *Plaintext, values []float64, logSlots int) {
+	encoder.EncodeNTT(plaintext, packRealValues(values, logSlots), logSlots)
+}
+
+// DecodeReal decodes the Plaintext values encoded with EncodeReal to a slice of 2*slots = 2^{logSlots+1} float64 values.
+func (encoder *encoderComplex128) DecodeReal(plaintext *Plaintext, logSlots int) (res []float64) {
+
+	slots := 1 << logSlots
+	values := encoder.decodePublic(plaintext, logSlots, 0)
+
+	res = make([]float64, 2*slots)
+	for i, v := range values {
+		res[i], res[slots+i] = real(v), imag(v)
+	}
+
+	return
+}
+
+// packRealValues returns the complex slots encoding values[j] + sqrt(-1)*values[slots+j].
+func packRealValues(values []float64, logSlots int) (packed []complex128) {
+
+	slots := 1 << logSlots
+
+	if len(values) > 2*slots {
+		panic("cannot EncodeReal: too many values for the given number of slots")
+	}
+
+	packed = make([]complex128, slots)
+	for i, v := range values {
+		if i < slots {
+			packed[i] += complex(v, 0)
+		} else {
+			packed[i-slots] += complex(0, v)
+		}
+	}
+
+	return
+}
+
 func polyToComplexNoCRT(coeffs []uint64, values []complex128, scale float64, logSlots int, Q uint64) {
 
 	slots := 1 << logSlots
@@ -476,6 +639,7 @@ func (encoder *encoderComplex128) EncodeDiagMatrixBSGSAtLvl(level int, diagMatri
 func (encoder *encoderComplex128) EncodeDiagMatrixAtLvl(level int, diagMatrix map[int][]complex128, scale float64, logSlots int) (matrix *PtDiagMatrix) {
 
 	matrix = new(PtDiagMatrix)
+	matrix.LogSlots = logSlots
 	matrix.Vec = make(map[int][2]*ring.Poly)
 	matrix.Level = level
 	matrix.Scale = scale
@@ -578,8 +742,15 @@ func (encoder *encoderComplex128) decodePublic(plaintext *Plaintext, logSlots in
 
 	res = make([]complex128, slots)
 
-	for i := range res {
-		res[i] = encoder.values[i]
+	// If the plaintext is known to be real, the imaginary parts are only noise and are discarded.
+	if plaintext.MetaData.IsReal {
+		for i := range res {
+			res[i] = complex(real(encoder.values[i]), 0)
+		}
+	} else {
+		for i := range res {
+			res[i] = encoder.values[i]
+		}
 	}
 
 	for i := range encoder.values {
@@ -615,7 +786,7 @@ func invfft(values []complex128, N, M int, rotGroup []int, roots []complex128) {
 		values[i] /= complex(float64(N), 0)
 	}
 
-	sliceBitReverseInPlaceComplex128(values, N)
+	SliceBitReverseInPlaceComplex128(values, N)
 }
 
 func fft(values []complex128, N, M int, rotGroup []int, roots []complex128) {
@@ -623,7 +794,7 @@ func fft(values []complex128, N, M int, rotGroup []int, roots []complex128) {
 	var lenh, lenq, gap, idx int
 	var u, v complex128
 
-	sliceBitReverseInPlaceComplex128(values, N)
+	SliceBitReverseInPlaceComplex128(values, N)
 
 	for len := 2; len <= N; len <<= 1 {
 		for i := 0; i < N; i += len {
@@ -650,7 +821,12 @@ func (encoder *encoderComplex128) EncodeCoeffs(values []float64, plaintext *Plai
 		panic("cannot EncodeCoeffs : too many values (maximum is N)")
 	}
 
-	scaleUpVecExact(values, plaintext.scale, encoder.ringQ.Modulus[:plaintext.Level()+1], plaintext.value.Coeffs)
+	if encoder.rounding == StochasticRounding {
+		encoder.sampleDither(len(values))
+		scaleUpVecStochastic(values, encoder.dither, plaintext.MetaData.Scale, encoder.ringQ.Modulus[:plaintext.Level()+1], plaintext.value.Coeffs)
+	} else {
+		scaleUpVecExact(values, plaintext.MetaData.Scale, encoder.ringQ.Modulus[:plaintext.Level()+1], plaintext.value.Coeffs)
+	}
 
 	plaintext.Element.Element.IsNTT = false
 }
@@ -711,7 +887,7 @@ func (encoder *encoderComplex128) decodeCoeffsPublic(plaintext *Plaintext, sigma
 				encoder.bigintCoeffs[i].Sub(encoder.bigintCoeffs[i], Q)
 			}
 
-			res[i] = scaleDown(encoder.bigintCoeffs[i], plaintext.scale)
+			res[i] = scaleDown(encoder.bigintCoeffs[i], plaintext.MetaData.Scale)
 		}
 		// We can directly get the coefficients
 	} else {
@@ -727,7 +903,7 @@ func (encoder *encoderComplex128) decodeCoeffsPublic(plaintext *Plaintext, sigma
 				res[i] = float64(coeffs[i])
 			}
 
-			res[i] /= plaintext.scale
+			res[i] /= plaintext.MetaData.Scale
 		}
 	}
 
@@ -736,13 +912,12 @@ func (encoder *encoderComplex128) decodeCoeffsPublic(plaintext *Plaintext, sigma
 
 type encoderBigComplex struct {
 	encoder
-	zero            *big.Float
-	cMul            *ring.ComplexMultiplier
-	logPrecision    int
-	values          []*ring.Complex
-	valuesfloat     []*big.Float
-	roots           []*ring.Complex
-	gaussianSampler *ring.GaussianSampler
+	zero         *big.Float
+	cMul         *ring.ComplexMultiplier
+	logPrecision int
+	values       []*ring.Complex
+	valuesfloat  []*big.Float
+	roots        []*ring.Complex
 }
 
 // NewEncoderBigComplex creates a new encoder using arbitrary precision complex arithmetic.
@@ -817,7 +992,7 @@ func (encoder *encoderBigComplex) EncodeNTTNew(values []*ring.Complex, logSlots
 // EncodeNTTAtLvlNew encodes a slice of ring.Complex of length slots = 2^{logSlots} on a plaintext at the desired level.
 // Returns a plaintext in the NTT domain.
 func (encoder *encoderBigComplex) EncodeNTTAtLvlNew(level int, values []*ring.Complex, logSlots int) (plaintext *Plaintext) {
-	plaintext = NewPlaintext(encoder.params, encoder.params.MaxLevel(), encoder.params.Scale())
+	plaintext = NewPlaintext(encoder.params, level, encoder.params.Scale())
 	encoder.EncodeNTT(plaintext, values, logSlots)
 	return
 }
@@ -856,7 +1031,9 @@ func (encoder *encoderBigComplex) Encode(plaintext *Plaintext, values []*ring.Co
 		encoder.valuesfloat[jdx].Set(encoder.values[i].Imag())
 	}
 
-	scaleUpVecExactBigFloat(encoder.valuesfloat, plaintext.scale, encoder.ringQ.Modulus[:plaintext.Level()+1], plaintext.value.Coeffs)
+	scaleUpVecExactBigFloat(encoder.valuesfloat, plaintext.MetaData.Scale, encoder.ringQ.Modulus[:plaintext.Level()+1], plaintext.value.Coeffs)
+
+	plaintext.Element.Element.IsNTT = false
 
 	coeffsBigInt := make([]*big.Int, encoder.params.N())
 
@@ -891,7 +1068,11 @@ func (encoder *encoderBigComplex) decodePublic(plaintext *Plaintext, logSlots in
 		panic("cannot Decode: too many slots for the given ring degree")
 	}
 
-	encoder.ringQ.InvNTTLvl(plaintext.Level(), plaintext.value, encoder.polypool)
+	if plaintext.IsNTT() {
+		encoder.ringQ.InvNTTLvl(plaintext.Level(), plaintext.value, encoder.polypool)
+	} else {
+		encoder.ringQ.CopyLvl(plaintext.Level(), plaintext.value, encoder.polypool)
+	}
 
 	if sigma != 0 {
 		// B = floor(sigma * sqrt(2*pi))
@@ -981,7 +1162,7 @@ func (encoder *encoderBigComplex) InvFFT(values []*ring.Complex, N int) {
 		values[i][1].Quo(values[i][1], NBig)
 	}
 
-	sliceBitReverseInPlaceRingComplex(values, N)
+	SliceBitReverseInPlaceRingComplex(values, N)
 }
 
 // FFT evaluates the decoding matrix on a slice fo ring.Complex values.
@@ -992,7 +1173,7 @@ func (encoder *encoderBigComplex) FFT(values []*ring.Complex, N int) {
 	u := ring.NewComplex(nil, nil)
 	v := ring.NewComplex(nil, nil)
 
-	sliceBitReverseInPlaceRingComplex(values, N)
+	SliceBitReverseInPlaceRingComplex(values, N)
 
 	for len := 2; len <= N; len <<= 1 {
 		for i := 0; i < N; i += len {
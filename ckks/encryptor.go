@@ -79,7 +79,21 @@ type skEncryptor struct {
 // NewEncryptorFromPk creates a new Encryptor with the provided public-key.
 // This Encryptor can be used to encrypt Plaintexts, using the stored key.
 func NewEncryptorFromPk(params Parameters, pk *rlwe.PublicKey) Encryptor {
-	enc := newEncryptor(params)
+	return NewEncryptorFromPkWithPRNG(params, pk, newEncryptorPRNG())
+}
+
+// NewEncryptorFromSk creates a new Encryptor with the provided secret-key.
+// This Encryptor can be used to encrypt Plaintexts, using the stored key.
+func NewEncryptorFromSk(params Parameters, sk *rlwe.SecretKey) Encryptor {
+	return NewEncryptorFromSkWithPRNG(params, sk, newEncryptorPRNG())
+}
+
+// NewEncryptorFromPkWithPRNG creates a new Encryptor with the provided public-key, which draws all the
+// randomness of the encryption from the provided PRNG. Two such Encryptors with PRNGs in the same state
+// produce the same Ciphertexts for the same sequence of calls, and the encryption randomness of a
+// Ciphertext can be reproduced by anyone knowing the state of the PRNG (e.g. the key of a KeyedPRNG).
+func NewEncryptorFromPkWithPRNG(params Parameters, pk *rlwe.PublicKey, prng utils.PRNG) Encryptor {
+	enc := newEncryptor(params, prng)
 
 	if pk.Value[0].Degree() != params.N() || pk.Value[1].Degree() != params.N() {
 		panic("cannot newEncryptor: pk ring degree does not match params ring degree")
@@ -88,10 +102,10 @@ func NewEncryptorFromPk(params Parameters, pk *rlwe.PublicKey) Encryptor {
 	return &pkEncryptor{enc, pk}
 }
 
-// NewEncryptorFromSk creates a new Encryptor with the provided secret-key.
-// This Encryptor can be used to encrypt Plaintexts, using the stored key.
-func NewEncryptorFromSk(params Parameters, sk *rlwe.SecretKey) Encryptor {
-	enc := newEncryptor(params)
+// NewEncryptorFromSkWithPRNG creates a new Encryptor with the provided secret-key, which draws all the
+// randomness of the encryption from the provided PRNG (see NewEncryptorFromPkWithPRNG).
+func NewEncryptorFromSkWithPRNG(params Parameters, sk *rlwe.SecretKey, prng utils.PRNG) Encryptor {
+	enc := newEncryptor(params, prng)
 
 	if sk.Value.Degree() != params.N() {
 		panic("cannot newEncryptor: sk ring degree does not match params ring degree")
@@ -100,7 +114,15 @@ func NewEncryptorFromSk(params Parameters, sk *rlwe.SecretKey) Encryptor {
 	return &skEncryptor{enc, sk}
 }
 
-func newEncryptor(params Parameters) encryptor {
+func newEncryptorPRNG() utils.PRNG {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return prng
+}
+
+func newEncryptor(params Parameters, prng utils.PRNG) encryptor {
 
 	var q, p *ring.Ring
 	var err error
@@ -108,13 +130,7 @@ func newEncryptor(params Parameters) encryptor {
 		panic(err)
 	}
 
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
-
 	var baseconverter *ring.FastBasisExtender
-	var poolP [3]*ring.Poly
 	if params.PCount() != 0 {
 
 		if p, err = ring.NewRing(params.N(), params.P()); err != nil {
@@ -122,16 +138,12 @@ func newEncryptor(params Parameters) encryptor {
 		}
 
 		baseconverter = ring.NewFastBasisExtender(q, p)
-
-		poolP = [3]*ring.Poly{p.NewPoly(), p.NewPoly(), p.NewPoly()}
 	}
 
 	return encryptor{
 		params:          params,
 		ringQ:           q,
 		ringP:           p,
-		poolQ:           [3]*ring.Poly{q.NewPoly(), q.NewPoly(), q.NewPoly()},
-		poolP:           poolP,
 		baseconverter:   baseconverter,
 		gaussianSampler: ring.NewGaussianSampler(prng, q, params.Sigma(), int(6*params.Sigma())),
 		ternarySampler:  ring.NewTernarySampler(prng, q, 0.5, false),
@@ -139,6 +151,19 @@ func newEncryptor(params Parameters) encryptor {
 	}
 }
 
+// allocatePools allocates, if not already done, the memory pools of the encryption, which are allocated on their
+// first use rather than by the constructors (see rlwe.Options).
+func (encryptor *encryptor) allocatePools() {
+	if encryptor.poolQ[0] == nil {
+		q := encryptor.ringQ
+		encryptor.poolQ = [3]*ring.Poly{q.NewPoly(), q.NewPoly(), q.NewPoly()}
+	}
+	if encryptor.ringP != nil && encryptor.poolP[0] == nil {
+		p := encryptor.ringP
+		encryptor.poolP = [3]*ring.Poly{p.NewPoly(), p.NewPoly(), p.NewPoly()}
+	}
+}
+
 // EncryptNew encrypts the input Plaintext using the stored key and returns
 // the result on a newly created Ciphertext.
 //
@@ -150,7 +175,7 @@ func (encryptor *pkEncryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
 		panic("Cannot EncryptNew : modulus P is empty -> use instead EncryptFastNew")
 	}
 
-	ciphertext := NewCiphertext(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
+	ciphertext := newCiphertextFromPool(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
 	encryptor.encrypt(plaintext, ciphertext, false)
 
 	return ciphertext
@@ -166,7 +191,7 @@ func (encryptor *pkEncryptor) Encrypt(plaintext *Plaintext, ciphertext *Cipherte
 }
 
 func (encryptor *pkEncryptor) EncryptFastNew(plaintext *Plaintext) *Ciphertext {
-	ciphertext := NewCiphertext(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
+	ciphertext := newCiphertextFromPool(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
 	encryptor.encrypt(plaintext, ciphertext, true)
 
 	return ciphertext
@@ -191,6 +216,8 @@ func (encryptor *pkEncryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.
 // encrypt with sk: ciphertext = [-a*sk + m + e, a]
 func (encryptor *pkEncryptor) encrypt(plaintext *Plaintext, ciphertext *Ciphertext, fast bool) {
 
+	encryptor.allocatePools()
+
 	lvl := utils.MinInt(plaintext.Level(), ciphertext.Level())
 
 	poolQ0 := encryptor.poolQ[0]
@@ -305,11 +332,14 @@ func (encryptor *pkEncryptor) encrypt(plaintext *Plaintext, ciphertext *Cipherte
 	ciphertext.Value[0].Coeffs = ciphertext.Value[0].Coeffs[:lvl+1]
 	ciphertext.Value[1].Coeffs = ciphertext.Value[1].Coeffs[:lvl+1]
 
-	ciphertext.Element.Element.IsNTT = true
+	ciphertext.Element.IsNTT = true
+	ciphertext.MetaData.IsReal = plaintext.MetaData.IsReal
+	ciphertext.MetaData.SlotScales = copySlotScales(plaintext.MetaData.SlotScales)
+	ciphertext.MetaData.Tags = plaintext.MetaData.Tags.CopyNew()
 }
 
 func (encryptor *skEncryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
-	ciphertext := NewCiphertext(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
+	ciphertext := newCiphertextFromPool(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
 	encryptor.Encrypt(plaintext, ciphertext)
 	return ciphertext
 }
@@ -327,7 +357,7 @@ func (encryptor *skEncryptor) EncryptFast(plaintext *Plaintext, ciphertext *Ciph
 }
 
 func (encryptor *skEncryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext {
-	ciphertext := NewCiphertext(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
+	ciphertext := newCiphertextFromPool(encryptor.params, 1, plaintext.Level(), plaintext.Scale())
 	encryptor.EncryptFromCRP(plaintext, ciphertext, crp)
 	return ciphertext
 }
@@ -348,6 +378,8 @@ func (encryptor *skEncryptor) encrypt(plaintext *Plaintext, ciphertext *Cipherte
 
 	ringQ := encryptor.ringQ
 
+	encryptor.allocatePools()
+
 	lvl := utils.MinInt(plaintext.Level(), ciphertext.Level())
 
 	poolQ0 := encryptor.poolQ[0]
@@ -370,7 +402,10 @@ func (encryptor *skEncryptor) encrypt(plaintext *Plaintext, ciphertext *Cipherte
 	ciphertext.Value[0].Coeffs = ciphertext.Value[0].Coeffs[:lvl+1]
 	ciphertext.Value[1].Coeffs = ciphertext.Value[1].Coeffs[:lvl+1]
 
-	ciphertext.Element.Element.IsNTT = true
+	ciphertext.Element.IsNTT = true
+	ciphertext.MetaData.IsReal = plaintext.MetaData.IsReal
+	ciphertext.MetaData.SlotScales = copySlotScales(plaintext.MetaData.SlotScales)
+	ciphertext.MetaData.Tags = plaintext.MetaData.Tags.CopyNew()
 }
 
 func extendBasisSmallNormAndCenter(ringQ, ringP *ring.Ring, polQ, polP *ring.Poly) {
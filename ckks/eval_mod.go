@@ -0,0 +1,241 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ckks/bettersine"
+)
+
+// EvalModParameters are the parameters of the homomorphic modular reduction evaluated by EvalModNew, which is the
+// SineEval step of the bootstrapping (see BootstrappingParameters.EvalModParameters).
+type EvalModParameters struct {
+	SinType       SinType // Chose between [Sin(2*pi*x)] or [cos(2*pi*x/r) with double angle formula]
+	MessageRatio  float64 // Modulus of the reduction, i.e. ratio between the modulus and the reduced values
+	SinRange      int     // K parameter: the quotients of the values by MessageRatio must lie in (-K, K)
+	SinDeg        int     // Degree of the interpolation
+	SinRescal     int     // Number of rescale and double angle formula (only applies for cos)
+	ArcSineDeg    int     // Degree of the Taylor arcsine composed with f(2*pi*x) (if zero then not used)
+	ScalingFactor float64 // Scaling factor of the evaluation, close to the moduli it consumes
+}
+
+// EvalModParameters returns the parameters of the modular reduction of the bootstrapping.
+func (b *BootstrappingParameters) EvalModParameters() EvalModParameters {
+	return EvalModParameters{
+		SinType:       b.SinType,
+		MessageRatio:  b.MessageRatio,
+		SinRange:      b.SinRange,
+		SinDeg:        b.SinDeg,
+		SinRescal:     b.SinRescal,
+		ArcSineDeg:    b.ArcSineDeg,
+		ScalingFactor: b.SineEvalModuli.ScalingFactor,
+	}
+}
+
+// Depth returns the number of levels consumed by EvalModNew.
+func (evm EvalModParameters) Depth() (depth int) {
+	depth = 1 + int(math.Ceil(math.Log2(float64(evm.SinDeg+1)))) + evm.SinRescal
+	if evm.ArcSineDeg > 0 {
+		depth += int(math.Ceil(math.Log2(float64(evm.ArcSineDeg + 1))))
+	}
+	if evm.MessageRatio != math.Round(evm.MessageRatio) {
+		depth++
+	}
+	return
+}
+
+// evalModPoly stores the polynomials of the modular reduction.
+type evalModPoly struct {
+	EvalModParameters
+	scale        float64                 // ScalingFactor rounded to the nearest power of two
+	sqrt2pi      float64                 // (1/2pi)^{-2^r}
+	scFac        float64                 // 2^{r}
+	sineEvalPoly *ChebyshevInterpolation // Coefficients of the Chebyshev Interpolation of sin(2*pi*x) or cos(2*pi*x/r)
+	arcSinePoly  *Poly                   // Coefficients of the Taylor series of arcsine(x)
+}
+
+func newEvalModPoly(evm EvalModParameters) (p *evalModPoly, err error) {
+
+	if evm.SinType != Sin && evm.SinType != Cos1 && evm.SinType != Cos2 {
+		return nil, fmt.Errorf("invalid SinType %d", evm.SinType)
+	}
+
+	if evm.SinType == Sin && evm.SinRescal != 0 {
+		return nil, fmt.Errorf("cannot use double angle formul for SinType = Sin -> must use SinType = Cos")
+	}
+
+	if evm.MessageRatio <= 0 || evm.ScalingFactor <= 0 || evm.SinRange < 1 || evm.SinDeg < 1 || evm.SinRescal < 0 || evm.ArcSineDeg < 0 {
+		return nil, fmt.Errorf("invalid EvalModParameters")
+	}
+
+	p = &evalModPoly{EvalModParameters: evm}
+
+	p.scale = math.Exp2(math.Round(math.Log2(evm.ScalingFactor)))
+
+	K := evm.SinRange
+	deg := evm.SinDeg
+	p.scFac = float64(int(1 << evm.SinRescal))
+
+	if evm.ArcSineDeg > 0 {
+		p.sqrt2pi = 1.0
+
+		coeffs := make([]complex128, evm.ArcSineDeg+1)
+
+		coeffs[1] = 0.15915494309189535
+
+		for i := 3; i < evm.ArcSineDeg+1; i += 2 {
+
+			coeffs[i] = coeffs[i-2] * complex(float64(i*i-4*i+4)/float64(i*i-i), 0)
+
+		}
+
+		p.arcSinePoly = NewPoly(coeffs)
+
+	} else {
+		p.sqrt2pi = math.Pow(0.15915494309189535, 1.0/p.scFac)
+	}
+
+	switch evm.SinType {
+	case Sin:
+
+		p.sineEvalPoly = Approximate(sin2pi2pi, -complex(float64(K)/p.scFac, 0), complex(float64(K)/p.scFac, 0), deg)
+
+	case Cos1:
+
+		p.sineEvalPoly = new(ChebyshevInterpolation)
+
+		p.sineEvalPoly.coeffs = bettersine.Approximate(K, deg, evm.MessageRatio, evm.SinRescal)
+
+		p.sineEvalPoly.maxDeg = p.sineEvalPoly.Degree()
+		p.sineEvalPoly.a = complex(float64(-K)/p.scFac, 0)
+		p.sineEvalPoly.b = complex(float64(K)/p.scFac, 0)
+		p.sineEvalPoly.lead = true
+
+	case Cos2:
+
+		p.sineEvalPoly = Approximate(cos2pi, -complex(float64(K)/p.scFac, 0), complex(float64(K)/p.scFac, 0), deg)
+	}
+
+	for i := range p.sineEvalPoly.coeffs {
+		p.sineEvalPoly.coeffs[i] *= complex(p.sqrt2pi, 0)
+	}
+
+	return p, nil
+}
+
+// changeOfVariable returns the constant by which the values must be multiplied before the evaluation, which the
+// bootstrapping merges with CoeffsToSlots.
+func (p *evalModPoly) changeOfVariable() float64 {
+	return 2 / (real(p.sineEvalPoly.b-p.sineEvalPoly.a) * p.scFac)
+}
+
+// evaluate evaluates (1/2pi) * sin(2pi * x) on the values x of ct, which must have been multiplied by
+// changeOfVariable. The output has a scale close to ScalingFactor. The moduli doubleAngleModuli are the ones
+// consumed by the double angle formulas, from the last one.
+func (p *evalModPoly) evaluate(eval Evaluator, ct *Ciphertext, doubleAngleModuli []uint64) (*Ciphertext, error) {
+
+	var err error
+
+	cheby := p.sineEvalPoly
+
+	targetScale := p.scale
+
+	// Compute the scales that the ciphertext should have before the double angle
+	// formula such that after it it has the scale it had before the polynomial
+	// evaluation
+	for i := 0; i < p.SinRescal; i++ {
+		targetScale = math.Sqrt(targetScale * float64(doubleAngleModuli[i]))
+	}
+
+	// Division by 1/2^r and change of variable for the Chebysehev evaluation
+	if p.SinType == Cos1 || p.SinType == Cos2 {
+		eval.AddConst(ct, -0.5/(complex(p.scFac, 0)*(cheby.b-cheby.a)), ct)
+	}
+
+	// Chebyshev evaluation
+	if ct, err = eval.EvaluateCheby(ct, cheby, targetScale); err != nil {
+		return nil, err
+	}
+
+	// Double angle
+	sqrt2pi := p.sqrt2pi
+	for i := 0; i < p.SinRescal; i++ {
+		sqrt2pi *= sqrt2pi
+		eval.MulRelin(ct, ct, ct)
+		eval.Add(ct, ct, ct)
+		eval.AddConst(ct, -sqrt2pi, ct)
+		if err = eval.Rescale(ct, p.scale, ct); err != nil {
+			return nil, err
+		}
+	}
+
+	// ArcSine
+	if p.ArcSineDeg > 0 {
+		if ct, err = eval.EvaluatePoly(ct, p.arcSinePoly, ct.Scale()); err != nil {
+			return nil, err
+		}
+	}
+
+	return ct, nil
+}
+
+// EvalModNew evaluates the homomorphic modular reduction of the bootstrapping on ctIn and returns the result in a
+// newly created element: the real values x of ctIn are mapped to MessageRatio * (1/2pi) * sin(2pi * x /
+// MessageRatio), which approximates x mod MessageRatio, centered around zero, for the values close to a multiple of
+// MessageRatio, i.e. x = I * MessageRatio + m with I an integer in (-K, K) and |m| much smaller than MessageRatio.
+// If ArcSineDeg > 0, the arcsine is composed with the sine, which corrects the approximation for larger |m|.
+//
+// The scale of ctIn should be close to ScalingFactor, which should be close to the moduli of the levels consumed by
+// the evaluation, and the output has a scale close to ScalingFactor. EvalModNew consumes evm.Depth() levels,
+// including the change of variable of the Chebyshev interpolation that the bootstrapping merges with CoeffsToSlots.
+// Returns an error if the parameters are invalid or if ctIn does not have enough levels.
+func (eval *evaluator) EvalModNew(ctIn *Ciphertext, evm EvalModParameters) (ctOut *Ciphertext, err error) {
+	return evalEvalMod(eval, eval.params, ctIn, evm)
+}
+
+// evalEvalMod implements EvalModNew on top of the Evaluator interface, so that it is shared by the homomorphic and
+// the cleartext evaluators.
+func evalEvalMod(eval Evaluator, params Parameters, ctIn *Ciphertext, evm EvalModParameters) (ctOut *Ciphertext, err error) {
+
+	checkNoSlotScales("EvalModNew", ctIn.El())
+
+	var p *evalModPoly
+	if p, err = newEvalModPoly(evm); err != nil {
+		return nil, fmt.Errorf("cannot EvalModNew: %w", err)
+	}
+
+	if ctIn.Level() < evm.Depth() {
+		return nil, fmt.Errorf("cannot EvalModNew: ciphertext level %d < depth %d", ctIn.Level(), evm.Depth())
+	}
+
+	ctOut = ctIn.CopyNew()
+
+	// Change of variable for the Chebyshev evaluation and division by MessageRatio
+	scale := ctOut.Scale()
+	eval.MultByConst(ctOut, p.changeOfVariable()/evm.MessageRatio, ctOut)
+	if err = eval.Rescale(ctOut, scale, ctOut); err != nil {
+		return nil, fmt.Errorf("cannot EvalModNew: %w", err)
+	}
+
+	// The double angle formulas consume the levels following the Chebyshev evaluation
+	level := ctOut.Level() - int(math.Ceil(math.Log2(float64(evm.SinDeg+1))))
+	doubleAngleModuli := make([]uint64, evm.SinRescal)
+	for i := range doubleAngleModuli {
+		doubleAngleModuli[i] = params.Q()[level-evm.SinRescal+1+i]
+	}
+
+	if ctOut, err = p.evaluate(eval, ctOut, doubleAngleModuli); err != nil {
+		return nil, fmt.Errorf("cannot EvalModNew: %w", err)
+	}
+
+	// Multiplication by MessageRatio, which consumes a level if it is not an integer
+	scale = ctOut.Scale()
+	eval.MultByConst(ctOut, evm.MessageRatio, ctOut)
+	if evm.MessageRatio != math.Round(evm.MessageRatio) {
+		if err = eval.Rescale(ctOut, scale, ctOut); err != nil {
+			return nil, fmt.Errorf("cannot EvalModNew: %w", err)
+		}
+	}
+
+	return ctOut, nil
+}
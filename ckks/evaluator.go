@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"unsafe"
 
 	"github.com/ldsec/lattigo/v2/ring"
@@ -49,7 +50,10 @@ type Evaluator interface {
 	// Constant Multiplication
 	MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext)
 	MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext)
+	MultByGaussianIntegerNew(ctIn *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext)
 	MultByGaussianInteger(ctIn *Ciphertext, cReal, cImag int64, ctOut *Ciphertext)
+	MultByConjGaussianNew(ctIn *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext)
+	MultByConjGaussian(ctIn *Ciphertext, cReal, cImag int64, ctOut *Ciphertext)
 
 	// Constant Multiplication with Addition
 	MultByConstAndAdd(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext)
@@ -64,6 +68,15 @@ type Evaluator interface {
 	// Conjugation
 	ConjugateNew(ctIn *Ciphertext) (ctOut *Ciphertext)
 	Conjugate(ctIn *Ciphertext, ctOut *Ciphertext)
+	RealPartNew(ctIn *Ciphertext) (ctOut *Ciphertext)
+	RealPart(ctIn *Ciphertext, ctOut *Ciphertext)
+	ImagPartNew(ctIn *Ciphertext) (ctOut *Ciphertext)
+	ImagPart(ctIn *Ciphertext, ctOut *Ciphertext)
+	HasConjugationKey() bool
+
+	// Packing of real messages
+	PackRealNew(ctRe, ctIm *Ciphertext) (ctOut *Ciphertext)
+	UnpackRealNew(ctIn *Ciphertext) (ctRe, ctIm *Ciphertext)
 
 	// Multiplication
 	Mul(op0, op1 Operand, ctOut *Ciphertext)
@@ -75,11 +88,21 @@ type Evaluator interface {
 	RotateNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext)
 	Rotate(ctIn *Ciphertext, k int, ctOut *Ciphertext)
 	RotateHoisted(ctIn *Ciphertext, rotations []int) (ctOut map[int]*Ciphertext)
+	RotateBy(ctIn *Ciphertext, rot rlwe.Rotation, ctOut *Ciphertext)
+	RotateByNew(ctIn *Ciphertext, rot rlwe.Rotation) (ctOut *Ciphertext)
+	MultByMonomial(ctIn *Ciphertext, k int, ctOut *Ciphertext)
+	MultByMonomialNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext)
 
 	// ===========================
 	// === Advanced Arithmetic ===
 	// ===========================
 
+	// Compression
+	CompressNew(ctIn *Ciphertext, droppedBits int) (ctOut *CompressedCiphertext)
+
+	// Sanitization
+	SanitizeNew(ctIn *Ciphertext, logPrecision float64) (ctOut *Ciphertext)
+
 	// Multiplication by 2^{s}
 	MulByPow2New(ctIn *Ciphertext, pow2 int) (ctOut *Ciphertext)
 	MulByPow2(ctIn *Element, pow2 int, ctOut *Element)
@@ -92,9 +115,33 @@ type Evaluator interface {
 	// Polynomial evaluation
 	EvaluatePoly(ctIn *Ciphertext, coeffs *Poly, targetScale float64) (ctOut *Ciphertext, err error)
 	EvaluateCheby(ctIn *Ciphertext, cheby *ChebyshevInterpolation, targetScale float64) (ctOut *Ciphertext, err error)
+	EvaluatePlan(ctIn *Ciphertext, plan *PolyEvaluationPlan) (ctOut *Ciphertext, err error)
+	EvalPiecewise(ctIn *Ciphertext, breakpoints []float64, polys []*Poly, targetScale float64) (ctOut *Ciphertext, err error)
+
+	// Rounding
+	RoundNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error)
+	FloorNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error)
+
+	// Modular reduction
+	EvalModNew(ctIn *Ciphertext, evm EvalModParameters) (ctOut *Ciphertext, err error)
 
 	// Inversion
 	InverseNew(ctIn *Ciphertext, steps int) (ctOut *Ciphertext)
+	InverseRangeNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error)
+	InvSqrtNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error)
+
+	// Weighted sums
+	WeightedSumNew(cts []*Ciphertext, weights []float64) (ctOut *Ciphertext)
+	WeightedAverageNew(cts []*Ciphertext, weights []float64) (ctOut *Ciphertext)
+
+	// Slot masking
+	AddConstVector(ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error)
+	AddConstVectorNew(ctIn *Ciphertext, values []complex128) (ctOut *Ciphertext, err error)
+	MulConstVectorThenAdd(ctIn *Ciphertext, values []complex128, ctOut *Ciphertext) (err error)
+	MaskSlots(ctIn *Ciphertext, mask []bool, ctOut *Ciphertext) (err error)
+	MaskSlotsNew(ctIn *Ciphertext, mask []bool) (ctOut *Ciphertext, err error)
+	ExtractSlotRange(ctIn *Ciphertext, start, end int, ctOut *Ciphertext) (err error)
+	ExtractSlotRangeNew(ctIn *Ciphertext, start, end int) (ctOut *Ciphertext, err error)
 
 	// Linear Transformations
 	LinearTransform(ctIn *Ciphertext, linearTransform interface{}) (ctOut []*Ciphertext)
@@ -108,6 +155,12 @@ type Evaluator interface {
 	// Replicatation (inverse of Inner sum)
 	ReplicateLog(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext)
 	Replicate(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext)
+	InnerSumGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext)
+	AverageGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext)
+
+	// Trace
+	Trace(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int, ctOut *Ciphertext)
+	TraceNew(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int) (ctOut *Ciphertext)
 
 	// =============================
 	// === Ciphertext Management ===
@@ -142,6 +195,7 @@ type Evaluator interface {
 	DecompInternal(level int, c2NTT *ring.Poly, c2QiQDecomp, c2QiPDecomp []*ring.Poly)
 	ShallowCopy() Evaluator
 	WithKey(rlwe.EvaluationKey) Evaluator
+	WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator
 }
 
 // evaluator is a struct that holds the necessary elements to execute the homomorphic operations between Ciphertexts and/or Plaintexts.
@@ -151,10 +205,12 @@ type evaluator struct {
 	*evaluatorBuffers
 
 	rlk             *rlwe.RelinearizationKey
-	rtks            *rlwe.RotationKeySet
+	rtks            rlwe.RotationKeyProvider
 	permuteNTTIndex map[uint64][]uint64
 
 	baseconverter *ring.FastBasisExtender
+
+	ks *rlwe.KeySwitcher // the key-switch of the gadget of NewEvaluatorWithGadget, nil for the RNS decomposition
 }
 
 type evaluatorBase struct {
@@ -165,6 +221,20 @@ type evaluatorBase struct {
 	ringP *ring.Ring
 
 	decomposer *ring.Decomposer
+
+	constVectors *constVectorCache
+
+	lowMemory bool // see rlwe.Options
+
+	gadget rlwe.Gadget // the gadget of NewEvaluatorWithGadget, nil for the RNS decomposition
+}
+
+// digits returns the number of digits of the decomposition of the key-switches at the maximum level.
+func (evalBase *evaluatorBase) digits() int {
+	if evalBase.gadget != nil {
+		return evalBase.gadget.Digits(evalBase.params.MaxLevel())
+	}
+	return evalBase.params.Beta()
 }
 
 type evaluatorBuffers struct {
@@ -182,6 +252,7 @@ func newEvaluatorBase(params Parameters) *evaluatorBase {
 	ev.params = params
 	ev.scale = params.Scale()
 	ev.ringQ = params.RingQ()
+	ev.constVectors = newConstVectorCache(params)
 
 	if params.PCount() != 0 {
 		ev.ringP = params.RingP()
@@ -197,33 +268,79 @@ func newEvaluatorBuffers(evalBase *evaluatorBase) *evaluatorBuffers {
 	buff.poolQMul = [3]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly(), ringQ.NewPoly()}
 	if evalBase.params.PCount() > 0 {
 		buff.poolP = [6]*ring.Poly{ringP.NewPoly(), ringP.NewPoly(), ringP.NewPoly(), ringP.NewPoly(), ringP.NewPoly(), ringP.NewPoly()}
-
-		buff.c2QiQDecomp = make([]*ring.Poly, evalBase.params.Beta())
-		buff.c2QiPDecomp = make([]*ring.Poly, evalBase.params.Beta())
-
-		for i := 0; i < evalBase.params.Beta(); i++ {
-			buff.c2QiQDecomp[i] = ringQ.NewPoly()
-			buff.c2QiPDecomp[i] = ringP.NewPoly()
-		}
 	}
 	buff.poolInvNTT = ringQ.NewPoly()
-	buff.ctxpool = NewCiphertext(evalBase.params, 2, evalBase.params.MaxLevel(), evalBase.params.Scale())
+
+	// With rlwe.Options.LowMemory, the largest pools are allocated on their first use.
+	if !evalBase.lowMemory {
+		buff.allocateHoistingPools(evalBase)
+		buff.allocateCtxPool(evalBase)
+	}
 	return buff
 }
 
+// allocateHoistingPools allocates, if not already done, the memory pools for the decomposition of a ciphertext in
+// the hoisted operations.
+func (buff *evaluatorBuffers) allocateHoistingPools(evalBase *evaluatorBase) {
+
+	if buff.c2QiQDecomp != nil || evalBase.params.PCount() == 0 {
+		return
+	}
+
+	buff.c2QiQDecomp = make([]*ring.Poly, evalBase.digits())
+	buff.c2QiPDecomp = make([]*ring.Poly, evalBase.digits())
+
+	for i := 0; i < evalBase.digits(); i++ {
+		buff.c2QiQDecomp[i] = evalBase.ringQ.NewPoly()
+		buff.c2QiPDecomp[i] = evalBase.ringP.NewPoly()
+	}
+}
+
+// allocateCtxPool allocates, if not already done, the memory pool for the ciphertexts that need to be scaled up.
+func (buff *evaluatorBuffers) allocateCtxPool(evalBase *evaluatorBase) {
+	if buff.ctxpool == nil {
+		buff.ctxpool = NewCiphertext(evalBase.params, 2, evalBase.params.MaxLevel(), evalBase.params.Scale())
+	}
+}
+
 // NewEvaluator creates a new Evaluator, that can be used to do homomorphic
 // operations on the Ciphertexts and/or Plaintexts. It stores a small pool of polynomials
 // and Ciphertexts that will be used for intermediate values.
 func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator {
+	return NewEvaluatorWithOptions(params, evaluationKey, rlwe.DefaultOptions())
+}
+
+// NewEvaluatorWithOptions creates a new Evaluator with the options opts, e.g. to allocate its memory pools on their
+// first use with opts.LowMemory. The options are kept by ShallowCopy and WithKey.
+func NewEvaluatorWithOptions(params Parameters, evaluationKey rlwe.EvaluationKey, opts rlwe.Options) Evaluator {
+	return newEvaluator(params, nil, evaluationKey, opts)
+}
+
+// NewEvaluatorWithGadget creates a new Evaluator whose key-switches (relinearization, SwitchKeys, rotations and
+// conjugation, hoisted or not) decompose the ciphertexts with the gadget decomposition gadget instead of the RNS
+// decomposition. The keys of evaluationKey must be generated for the gadget (see rlwe.GenRelinearizationKeyWithGadget
+// and rlwe.GenRotationKeysWithGadget). The hoisted rotations and the operations based on them (e.g. the linear
+// transformations) decompose the ciphertext once with the rlwe.KeySwitcher of the gadget and require a non-empty
+// modulus P, as with the RNS decomposition. The slices of the decomposition given to DecompInternal,
+// MultiplyByDiagMatrix and MultiplyByDiagMatrixBSGS must have gadget.Digits(params.MaxLevel()) elements.
+func NewEvaluatorWithGadget(params Parameters, gadget rlwe.Gadget, evaluationKey rlwe.EvaluationKey) Evaluator {
+	return newEvaluator(params, gadget, evaluationKey, rlwe.DefaultOptions())
+}
+
+func newEvaluator(params Parameters, gadget rlwe.Gadget, evaluationKey rlwe.EvaluationKey, opts rlwe.Options) *evaluator {
 	eval := new(evaluator)
 	eval.evaluatorBase = newEvaluatorBase(params)
+	eval.evaluatorBase.lowMemory = opts.LowMemory
+	eval.evaluatorBase.gadget = gadget
 	eval.evaluatorBuffers = newEvaluatorBuffers(eval.evaluatorBase)
 
+	if gadget != nil {
+		eval.ks = rlwe.NewKeySwitcher(params.Parameters, gadget)
+	}
+
 	eval.rlk = evaluationKey.Rlk
 	eval.rtks = evaluationKey.Rtks
-	if eval.rtks != nil {
-		eval.permuteNTTIndex = *eval.permuteNTTIndexesForKey(eval.rtks)
-	}
+	eval.permuteNTTIndex = *eval.permuteNTTIndexesForKey(eval.rtks)
 
 	if params.PCount() != 0 {
 		eval.baseconverter = ring.NewFastBasisExtender(eval.ringQ, eval.ringP)
@@ -232,8 +349,11 @@ func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator
 	return eval
 }
 
-func (eval *evaluator) permuteNTTIndexesForKey(rtks *rlwe.RotationKeySet) *map[uint64][]uint64 {
-	if rtks == nil {
+// permuteNTTIndexesForKey precomputes the NTT permutation indexes for the keys of rtkp if it is a RotationKeySet.
+// For the other RotationKeyProviders, the indexes are computed on the fly by permuteNTTIndexFor.
+func (eval *evaluator) permuteNTTIndexesForKey(rtkp rlwe.RotationKeyProvider) *map[uint64][]uint64 {
+	rtks, isSet := rtkp.(*rlwe.RotationKeySet)
+	if !isSet || rtks == nil {
 		return &map[uint64][]uint64{}
 	}
 	permuteNTTIndex := make(map[uint64][]uint64, len(rtks.Keys))
@@ -243,6 +363,14 @@ func (eval *evaluator) permuteNTTIndexesForKey(rtks *rlwe.RotationKeySet) *map[u
 	return &permuteNTTIndex
 }
 
+// permuteNTTIndexFor returns the NTT permutation indexes of the automorphism X -> X^galEl.
+func (eval *evaluator) permuteNTTIndexFor(galEl uint64) []uint64 {
+	if index, ok := eval.permuteNTTIndex[galEl]; ok {
+		return index
+	}
+	return ring.PermuteNTTIndex(galEl, uint64(eval.ringQ.N))
+}
+
 // ShallowCopy creates a shallow copy of this evaluator in which all the read-only data-structures are
 // shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
 // Evaluators can be used concurrently.
@@ -254,6 +382,7 @@ func (eval *evaluator) ShallowCopy() Evaluator {
 		rtks:             eval.rtks,
 		permuteNTTIndex:  eval.permuteNTTIndex,
 		baseconverter:    eval.baseconverter.ShallowCopy(),
+		ks:               eval.ks.ShallowCopy(),
 	}
 }
 
@@ -261,7 +390,7 @@ func (eval *evaluator) ShallowCopy() Evaluator {
 // and where the temporary buffers are shared. The receiver and the returned Evaluators cannot be used concurrently.
 func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
 	var indexes map[uint64][]uint64
-	if evaluationKey.Rtks == eval.rtks {
+	if rtks, isSet := eval.rtks.(*rlwe.RotationKeySet); isSet && evaluationKey.Rtks == rtks {
 		indexes = eval.permuteNTTIndex
 	} else {
 		indexes = *eval.permuteNTTIndexesForKey(evaluationKey.Rtks)
@@ -273,6 +402,24 @@ func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
 		rtks:             evaluationKey.Rtks,
 		permuteNTTIndex:  indexes,
 		baseconverter:    eval.baseconverter,
+		ks:               eval.ks,
+	}
+}
+
+// WithRotationKeyProvider creates a shallow copy of the receiver Evaluator for which the rotation keys are queried from
+// rtkp and where the temporary buffers are shared. The receiver and the returned Evaluators cannot be used concurrently.
+func (eval *evaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	if rtkp == nil {
+		rtkp = (*rlwe.RotationKeySet)(nil)
+	}
+	return &evaluator{
+		evaluatorBase:    eval.evaluatorBase,
+		evaluatorBuffers: eval.evaluatorBuffers,
+		rlk:              eval.rlk,
+		rtks:             rtkp,
+		permuteNTTIndex:  *eval.permuteNTTIndexesForKey(rtkp),
+		baseconverter:    eval.baseconverter,
+		ks:               eval.ks,
 	}
 }
 
@@ -386,6 +533,8 @@ func (eval *evaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
 
 func (eval *evaluator) evaluateInPlace(c0, c1, ctOut *Element, evaluate func(int, *ring.Poly, *ring.Poly, *ring.Poly)) {
 
+	eval.allocateCtxPool(eval.evaluatorBase)
+
 	var tmp0, tmp1 *Element
 
 	level := utils.MinInt(utils.MinInt(c0.Level(), c1.Level()), ctOut.Level())
@@ -397,7 +546,7 @@ func (eval *evaluator) evaluateInPlace(c0, c1, ctOut *Element, evaluate func(int
 	ctOut.Resize(eval.params, maxDegree)
 
 	if ctOut.Level() > level {
-		eval.DropLevel(&Ciphertext{ctOut}, ctOut.Level()-utils.MinInt(c0.Level(), c1.Level()))
+		eval.DropLevel(ctOut.ciphertext(), ctOut.Level()-utils.MinInt(c0.Level(), c1.Level()))
 	}
 
 	// Checks whether or not the receiver element is the same as one of the input elements
@@ -409,11 +558,11 @@ func (eval *evaluator) evaluateInPlace(c0, c1, ctOut *Element, evaluate func(int
 
 			tmp1 = eval.ctxpool.El()
 
-			eval.MultByConst(&Ciphertext{c1}, math.Floor(c0.Scale()/c1.Scale()), &Ciphertext{tmp1})
+			eval.MultByConst(c1.ciphertext(), math.Floor(c0.Scale()/c1.Scale()), tmp1.ciphertext())
 
 		} else if c1.Scale() > c0.Scale() && math.Floor(c1.Scale()/c0.Scale()) > 1 {
 
-			eval.MultByConst(&Ciphertext{c0}, math.Floor(c1.Scale()/c0.Scale()), &Ciphertext{c0})
+			eval.MultByConst(c0.ciphertext(), math.Floor(c1.Scale()/c0.Scale()), c0.ciphertext())
 
 			c0.SetScale(c1.Scale())
 
@@ -432,11 +581,11 @@ func (eval *evaluator) evaluateInPlace(c0, c1, ctOut *Element, evaluate func(int
 
 			tmp0 = eval.ctxpool.El()
 
-			eval.MultByConst(&Ciphertext{c0}, math.Floor(c1.Scale()/c0.Scale()), &Ciphertext{tmp0})
+			eval.MultByConst(c0.ciphertext(), math.Floor(c1.Scale()/c0.Scale()), tmp0.ciphertext())
 
 		} else if c0.Scale() > c1.Scale() && math.Floor(c0.Scale()/c1.Scale()) > 1 {
 
-			eval.MultByConst(&Ciphertext{c1}, math.Floor(c0.Scale()/c1.Scale()), &Ciphertext{ctOut})
+			eval.MultByConst(c1.ciphertext(), math.Floor(c0.Scale()/c1.Scale()), ctOut.ciphertext())
 
 			ctOut.SetScale(c0.Scale())
 
@@ -455,7 +604,7 @@ func (eval *evaluator) evaluateInPlace(c0, c1, ctOut *Element, evaluate func(int
 
 			tmp0 = eval.ctxpool.El()
 
-			eval.MultByConst(&Ciphertext{c0}, math.Floor(c1.Scale()/c0.Scale()), &Ciphertext{tmp0})
+			eval.MultByConst(c0.ciphertext(), math.Floor(c1.Scale()/c0.Scale()), tmp0.ciphertext())
 
 			tmp1 = c1
 
@@ -463,7 +612,7 @@ func (eval *evaluator) evaluateInPlace(c0, c1, ctOut *Element, evaluate func(int
 
 			tmp1 = eval.ctxpool.El()
 
-			eval.MultByConst(&Ciphertext{c1}, math.Floor(c0.Scale()/c1.Scale()), &Ciphertext{tmp1})
+			eval.MultByConst(c1.ciphertext(), math.Floor(c0.Scale()/c1.Scale()), tmp1.ciphertext())
 
 			tmp0 = c0
 
@@ -478,6 +627,9 @@ func (eval *evaluator) evaluateInPlace(c0, c1, ctOut *Element, evaluate func(int
 	}
 
 	ctOut.SetScale(utils.MaxFloat64(c0.Scale(), c1.Scale()))
+	ctOut.MetaData.IsReal = c0.MetaData.IsReal && c1.MetaData.IsReal
+	ctOut.MetaData.SlotScales = addSlotScales(c0.MetaData.SlotScales, c1.MetaData.SlotScales)
+	ctOut.MetaData.Tags = mergeMetadata(c0.MetaData.Tags, c1.MetaData.Tags)
 
 	// If the inputs degrees differ, it copies the remaining degree on the receiver.
 	// Also checks that the receiver is not one of the inputs to avoid unnecessary work.
@@ -507,6 +659,9 @@ func (eval *evaluator) Neg(ct0 *Ciphertext, ctOut *Ciphertext) {
 	}
 
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 }
 
 // NegNew negates ct0 and returns the result in a newly created element.
@@ -516,80 +671,60 @@ func (eval *evaluator) NegNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
 	return
 }
 
-// AddConstNew adds the input constant (which can be a uint64, int64, float64 or complex128) to ct0 and returns the result in a new element.
+// AddConstNew adds the input constant (which can be a uint64, int64, float64, complex128, *big.Float or *ring.Complex) to ct0 and returns the result in a new element.
 func (eval *evaluator) AddConstNew(ct0 *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
 	ctOut = ct0.CopyNew()
 	eval.AddConst(ct0, constant, ctOut)
 	return ctOut
 }
 
-func (eval *evaluator) getConstAndScale(level int, constant interface{}) (cReal, cImag, scale float64) {
+func (eval *evaluator) getConstAndScale(level int, constant interface{}) (cReal, cImag *big.Float, scale float64) {
+
+	// Converts to big.Float and determines if a scaling is required (which is the case if either real or imag have a rational part)
+	cReal, cImag = new(big.Float), new(big.Float)
 
-	// Converts to float64 and determines if a scaling is required (which is the case if either real or imag have a rational part)
-	scale = 1
 	switch constant := constant.(type) {
 	case complex128:
-		cReal = real(constant)
-		cImag = imag(constant)
-
-		if cReal != 0 {
-			valueInt := int64(cReal)
-			valueFloat := cReal - float64(valueInt)
-
-			if valueFloat != 0 {
-				scale = float64(eval.ringQ.Modulus[level])
-			}
-		}
-
-		if cImag != 0 {
-			valueInt := int64(cImag)
-			valueFloat := cImag - float64(valueInt)
-
-			if valueFloat != 0 {
-				scale = float64(eval.ringQ.Modulus[level])
-			}
-		}
-
+		cReal.SetFloat64(real(constant))
+		cImag.SetFloat64(imag(constant))
 	case float64:
-		cReal = constant
-		cImag = float64(0)
-
-		if cReal != 0 {
-			valueInt := int64(cReal)
-			valueFloat := cReal - float64(valueInt)
-
-			if valueFloat != 0 {
-				scale = float64(eval.ringQ.Modulus[level])
-			}
-		}
-
+		cReal.SetFloat64(constant)
+	case *big.Float:
+		cReal.Set(constant)
+	case *ring.Complex:
+		cReal.Set(constant.Real())
+		cImag.Set(constant.Imag())
 	case uint64:
-		cReal = float64(constant)
-		cImag = float64(0)
-
+		cReal.SetUint64(constant)
 	case int64:
-		cReal = float64(constant)
-		cImag = float64(0)
-
+		cReal.SetInt64(constant)
 	case int:
-		cReal = float64(constant)
-		cImag = float64(0)
+		cReal.SetInt64(int64(constant))
+	}
+
+	scale = 1
+	if !cReal.IsInt() || !cImag.IsInt() {
+		scale = float64(eval.ringQ.Modulus[level])
 	}
 
 	return
 }
 
-// AddConst adds the input constant (which can be a uint64, int64, float64 or complex128) to ct0 and returns the result in ctOut.
+// AddConst adds the input constant (which can be a uint64, int64, float64, complex128, *big.Float or *ring.Complex) to ct0 and returns the result in ctOut.
 func (eval *evaluator) AddConst(ct0 *Ciphertext, constant interface{}, ctOut *Ciphertext) {
 
 	var level = utils.MinInt(ct0.Level(), ctOut.Level())
 	var scaledConst, scaledConstReal, scaledConstImag uint64
 
+	checkNoSlotScales("AddConst", ct0.El())
+
 	cReal, cImag, _ := eval.getConstAndScale(level, constant)
 
 	ringQ := eval.ringQ
 
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal && cImag.Sign() == 0
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 
 	// Component wise addition of the following vector to the ciphertext:
 	// [a + b*psi_qi^2, ....., a + b*psi_qi^2, a - b*psi_qi^2, ...., a - b*psi_qi^2] mod Qi
@@ -603,12 +738,12 @@ func (eval *evaluator) AddConst(ct0 *Ciphertext, constant interface{}, ctOut *Ci
 
 		qi = ringQ.Modulus[i]
 
-		if cReal != 0 {
+		if cReal.Sign() != 0 {
 			scaledConstReal = scaleUpExact(cReal, ctOut.Scale(), qi)
 			scaledConst = scaledConstReal
 		}
 
-		if cImag != 0 {
+		if cImag.Sign() != 0 {
 			scaledConstImag = ring.MRed(scaleUpExact(cImag, ctOut.Scale(), qi), ringQ.NttPsi[i][1], qi, ringQ.MredParams[i])
 			scaledConst = ring.CRed(scaledConst+scaledConstImag, qi)
 		}
@@ -631,7 +766,7 @@ func (eval *evaluator) AddConst(ct0 *Ciphertext, constant interface{}, ctOut *Ci
 			z[7] = ring.CRed(x[7]+scaledConst, qi)
 		}
 
-		if cImag != 0 {
+		if cImag.Sign() != 0 {
 			scaledConst = ring.CRed(scaledConstReal+(qi-scaledConstImag), qi)
 		}
 
@@ -668,6 +803,10 @@ func (eval *evaluator) MultByConstAndAdd(ct0 *Ciphertext, constant interface{},
 
 	cReal, cImag, scale := eval.getConstAndScale(level, constant)
 
+	ctOut.MetaData.IsReal = ctOut.MetaData.IsReal && ct0.MetaData.IsReal && cImag.Sign() == 0
+	ctOut.MetaData.SlotScales = addSlotScales(ctOut.MetaData.SlotScales, ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = mergeMetadata(ctOut.MetaData.Tags, ct0.MetaData.Tags)
+
 	var scaledConst, scaledConstReal, scaledConstImag uint64
 
 	ringQ := eval.ringQ
@@ -726,12 +865,12 @@ func (eval *evaluator) MultByConstAndAdd(ct0 *Ciphertext, constant interface{},
 		scaledConstImag = 0
 		scaledConst = 0
 
-		if cReal != 0 {
+		if cReal.Sign() != 0 {
 			scaledConstReal = scaleUpExact(cReal, scale, qi)
 			scaledConst = scaledConstReal
 		}
 
-		if cImag != 0 {
+		if cImag.Sign() != 0 {
 			scaledConstImag = scaleUpExact(cImag, scale, qi)
 			scaledConstImag = ring.MRed(scaledConstImag, ringQ.NttPsi[i][1], qi, mredParams)
 			scaledConst = ring.CRed(scaledConst+scaledConstImag, qi)
@@ -759,7 +898,7 @@ func (eval *evaluator) MultByConstAndAdd(ct0 *Ciphertext, constant interface{},
 			}
 		}
 
-		if cImag != 0 {
+		if cImag.Sign() != 0 {
 			scaledConst = ring.CRed(scaledConstReal+(qi-scaledConstImag), qi)
 			scaledConst = ring.MForm(scaledConst, qi, bredParams)
 		}
@@ -787,7 +926,7 @@ func (eval *evaluator) MultByConstAndAdd(ct0 *Ciphertext, constant interface{},
 
 // MultByConstNew multiplies ct0 by the input constant and returns the result in a newly created element.
 // The scale of the output element will depend on the scale of the input element and the constant (if the constant
-// needs to be scaled (its rational part is not zero)). The constant can be a uint64, int64, float64 or complex128.
+// needs to be scaled (its rational part is not zero)). The constant can be a uint64, int64, float64, complex128, *big.Float or *ring.Complex.
 func (eval *evaluator) MultByConstNew(ct0 *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
 	ctOut = NewCiphertext(eval.params, ct0.Degree(), ct0.Level(), ct0.Scale())
 	eval.MultByConst(ct0, constant, ctOut)
@@ -796,7 +935,7 @@ func (eval *evaluator) MultByConstNew(ct0 *Ciphertext, constant interface{}) (ct
 
 // MultByConst multiplies ct0 by the input constant and returns the result in ctOut.
 // The scale of the output element will depend on the scale of the input element and the constant (if the constant
-// needs to be scaled (its rational part is not zero)). The constant can be a uint64, int64, float64 or complex128.
+// needs to be scaled (its rational part is not zero)). The constant can be a uint64, int64, float64, complex128, *big.Float or *ring.Complex.
 func (eval *evaluator) MultByConst(ct0 *Ciphertext, constant interface{}, ctOut *Ciphertext) {
 
 	var level = utils.MinInt(ct0.Level(), ctOut.Level())
@@ -819,12 +958,12 @@ func (eval *evaluator) MultByConst(ct0 *Ciphertext, constant interface{}, ctOut
 		scaledConstImag = 0
 		scaledConst = 0
 
-		if cReal != 0 {
+		if cReal.Sign() != 0 {
 			scaledConstReal = scaleUpExact(cReal, scale, qi)
 			scaledConst = scaledConstReal
 		}
 
-		if cImag != 0 {
+		if cImag.Sign() != 0 {
 			scaledConstImag = scaleUpExact(cImag, scale, qi)
 			scaledConstImag = ring.MRed(scaledConstImag, ringQ.NttPsi[i][1], qi, mredParams)
 			scaledConst = ring.CRed(scaledConst+scaledConstImag, qi)
@@ -852,7 +991,7 @@ func (eval *evaluator) MultByConst(ct0 *Ciphertext, constant interface{}, ctOut
 			}
 		}
 
-		if cImag != 0 {
+		if cImag.Sign() != 0 {
 			scaledConst = ring.CRed(scaledConstReal+(qi-scaledConstImag), qi)
 			scaledConst = ring.MForm(scaledConst, qi, bredParams)
 		}
@@ -878,101 +1017,66 @@ func (eval *evaluator) MultByConst(ct0 *Ciphertext, constant interface{}, ctOut
 	}
 
 	ctOut.SetScale(ct0.Scale() * scale)
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal && cImag.Sign() == 0
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 }
 
-func (eval *evaluator) MultByGaussianInteger(ct0 *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
-
-	ringQ := eval.ringQ
-
-	level := utils.MinInt(ct0.Level(), ctOut.Level())
-	var scaledConst, scaledConstReal, scaledConstImag uint64
+// MultByGaussianIntegerNew multiplies ct0 by the Gaussian integer cReal + i*cImag and returns the result in a newly
+// created element. It does not change the scale and does not consume a level (see MultByGaussianInteger).
+func (eval *evaluator) MultByGaussianIntegerNew(ct0 *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree(), ct0.Level(), ct0.Scale())
+	eval.MultByGaussianInteger(ct0, cReal, cImag, ctOut)
+	return
+}
 
+// MultByGaussianInteger multiplies ct0 by the Gaussian integer cReal + i*cImag and returns the result in ctOut.
+// The product is exact: it does not change the scale and does not consume a level, so that the products by i,
+// by +-1 +- i or by small Gaussian integers, for example in the butterflies of a DFT, come for free, the error
+// being only multiplied by |cReal + i*cImag|. The same operations are applied on every coefficient, whatever
+// their values.
+func (eval *evaluator) MultByGaussianInteger(ct0 *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal && cImag == 0
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
+	eval.multByGaussianInteger(ct0, cReal, cImag, ctOut, false)
+}
 
-	for i := 0; i < level+1; i++ {
-
-		qi := ringQ.Modulus[i]
-		bredParams := ringQ.BredParams[i]
-		mredParams := ringQ.MredParams[i]
-
-		scaledConstReal = 0
-		scaledConstImag = 0
-		scaledConst = 0
-
-		if cReal != 0 {
-			if cReal < 0 {
-				scaledConstReal = uint64(int64(qi) + cReal%int64(qi))
-			} else {
-				scaledConstReal = uint64(cReal)
-			}
-			scaledConst = scaledConstReal
-		}
-
-		if cImag != 0 {
-			if cImag < 0 {
-				scaledConstImag = uint64(int64(qi) + cImag%int64(qi))
-			} else {
-				scaledConstImag = uint64(cImag)
-			}
-			scaledConstImag = ring.MRed(scaledConstImag, ringQ.NttPsi[i][1], qi, mredParams)
-			scaledConst = ring.CRed(scaledConst+scaledConstImag, qi)
-		}
-
-		scaledConst = ring.MForm(scaledConst, qi, bredParams)
-
-		for u := range ct0.Value {
-			p0tmp := ct0.Value[u].Coeffs[i]
-			p1tmp := ctOut.Value[u].Coeffs[i]
-
-			for j := 0; j < ringQ.N>>1; j = j + 8 {
-
-				x := (*[8]uint64)(unsafe.Pointer(&p0tmp[j]))
-				z := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
-
-				z[0] = ring.MRed(x[0], scaledConst, qi, mredParams)
-				z[1] = ring.MRed(x[1], scaledConst, qi, mredParams)
-				z[2] = ring.MRed(x[2], scaledConst, qi, mredParams)
-				z[3] = ring.MRed(x[3], scaledConst, qi, mredParams)
-				z[4] = ring.MRed(x[4], scaledConst, qi, mredParams)
-				z[5] = ring.MRed(x[5], scaledConst, qi, mredParams)
-				z[6] = ring.MRed(x[6], scaledConst, qi, mredParams)
-				z[7] = ring.MRed(x[7], scaledConst, qi, mredParams)
-			}
-		}
-
-		if cImag != 0 {
-			scaledConst = ring.CRed(scaledConstReal+(qi-scaledConstImag), qi)
-			scaledConst = ring.MForm(scaledConst, qi, bredParams)
-		}
-
-		for u := range ct0.Value {
-			p0tmp := ct0.Value[u].Coeffs[i]
-			p1tmp := ctOut.Value[u].Coeffs[i]
-
-			for j := ringQ.N >> 1; j < ringQ.N; j = j + 8 {
-
-				x := (*[8]uint64)(unsafe.Pointer(&p0tmp[j]))
-				z := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
+// MultByConjGaussianNew multiplies ct0 by the conjugate cReal - i*cImag of the Gaussian integer cReal + i*cImag and
+// returns the result in a newly created element. It does not change the scale and does not consume a level.
+func (eval *evaluator) MultByConjGaussianNew(ct0 *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree(), ct0.Level(), ct0.Scale())
+	eval.MultByConjGaussian(ct0, cReal, cImag, ctOut)
+	return
+}
 
-				z[0] = ring.MRed(x[0], scaledConst, qi, mredParams)
-				z[1] = ring.MRed(x[1], scaledConst, qi, mredParams)
-				z[2] = ring.MRed(x[2], scaledConst, qi, mredParams)
-				z[3] = ring.MRed(x[3], scaledConst, qi, mredParams)
-				z[4] = ring.MRed(x[4], scaledConst, qi, mredParams)
-				z[5] = ring.MRed(x[5], scaledConst, qi, mredParams)
-				z[6] = ring.MRed(x[6], scaledConst, qi, mredParams)
-				z[7] = ring.MRed(x[7], scaledConst, qi, mredParams)
-			}
-		}
+// MultByConjGaussian multiplies ct0 by the conjugate cReal - i*cImag of the Gaussian integer cReal + i*cImag and
+// returns the result in ctOut. It does not change the scale and does not consume a level (see MultByGaussianInteger).
+func (eval *evaluator) MultByConjGaussian(ct0 *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
+	if cImag == math.MinInt64 {
+		panic("cannot MultByConjGaussian: cImag overflows")
 	}
+	eval.MultByGaussianInteger(ct0, cReal, -cImag, ctOut)
 }
 
+// MultByGaussianIntegerAndAdd multiplies ct0 by the Gaussian integer cReal + i*cImag and adds the result to ctOut.
+// It does not change the scale and does not consume a level (see MultByGaussianInteger).
 func (eval *evaluator) MultByGaussianIntegerAndAdd(ct0 *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
+	ctOut.MetaData.IsReal = ctOut.MetaData.IsReal && ct0.MetaData.IsReal && cImag == 0
+	ctOut.MetaData.SlotScales = addSlotScales(ctOut.MetaData.SlotScales, ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = mergeMetadata(ctOut.MetaData.Tags, ct0.MetaData.Tags)
+	eval.multByGaussianInteger(ct0, cReal, cImag, ctOut, true)
+}
+
+// multByGaussianInteger multiplies ct0 by cReal + i*cImag and stores the result in ctOut, or adds it to ctOut if add
+// is true. In the NTT domain, the imaginary unit is the root Psi^(N/2) on the first half of the coefficients and
+// its opposite on the second half, so that the product is a multiplication of each half by a constant.
+func (eval *evaluator) multByGaussianInteger(ct0 *Ciphertext, cReal, cImag int64, ctOut *Ciphertext, add bool) {
 
 	ringQ := eval.ringQ
 
 	level := utils.MinInt(ct0.Level(), ctOut.Level())
-	var scaledConst, scaledConstReal, scaledConstImag uint64
 
 	for i := 0; i < level+1; i++ {
 
@@ -980,76 +1084,61 @@ func (eval *evaluator) MultByGaussianIntegerAndAdd(ct0 *Ciphertext, cReal, cImag
 		bredParams := ringQ.BredParams[i]
 		mredParams := ringQ.MredParams[i]
 
-		scaledConstReal = 0
-		scaledConstImag = 0
-		scaledConst = 0
+		re := reduceInt64(cReal, qi)
+		im := ring.MRed(reduceInt64(cImag, qi), ringQ.NttPsi[i][1], qi, mredParams)
 
-		if cReal != 0 {
-			if cReal < 0 {
-				scaledConstReal = uint64(int64(qi) + cReal%int64(qi))
-			} else {
-				scaledConstReal = uint64(cReal)
-			}
-			scaledConst = scaledConstReal
+		constants := [2]uint64{
+			ring.MForm(ring.CRed(re+im, qi), qi, bredParams),
+			ring.MForm(ring.CRed(re+qi-im, qi), qi, bredParams),
 		}
 
-		if cImag != 0 {
-			if cImag < 0 {
-				scaledConstImag = uint64(int64(qi) + cImag%int64(qi))
-			} else {
-				scaledConstImag = uint64(cImag)
-			}
-			scaledConstImag = ring.MRed(scaledConstImag, ringQ.NttPsi[i][1], qi, mredParams)
-			scaledConst = ring.CRed(scaledConst+scaledConstImag, qi)
-		}
-
-		scaledConst = ring.MForm(scaledConst, qi, bredParams)
-
-		for u := range ct0.Value {
-			p0tmp := ct0.Value[u].Coeffs[i]
-			p1tmp := ctOut.Value[u].Coeffs[i]
-
-			for j := 0; j < ringQ.N>>1; j = j + 8 {
-
-				x := (*[8]uint64)(unsafe.Pointer(&p0tmp[j]))
-				z := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
-
-				z[0] = ring.CRed(z[0]+ring.MRed(x[0], scaledConst, qi, mredParams), qi)
-				z[1] = ring.CRed(z[1]+ring.MRed(x[1], scaledConst, qi, mredParams), qi)
-				z[2] = ring.CRed(z[2]+ring.MRed(x[2], scaledConst, qi, mredParams), qi)
-				z[3] = ring.CRed(z[3]+ring.MRed(x[3], scaledConst, qi, mredParams), qi)
-				z[4] = ring.CRed(z[4]+ring.MRed(x[4], scaledConst, qi, mredParams), qi)
-				z[5] = ring.CRed(z[5]+ring.MRed(x[5], scaledConst, qi, mredParams), qi)
-				z[6] = ring.CRed(z[6]+ring.MRed(x[6], scaledConst, qi, mredParams), qi)
-				z[7] = ring.CRed(z[7]+ring.MRed(x[7], scaledConst, qi, mredParams), qi)
+		for half, scaledConst := range constants {
+
+			start, end := half*(ringQ.N>>1), (half+1)*(ringQ.N>>1)
+
+			for u := range ct0.Value {
+				p0tmp := ct0.Value[u].Coeffs[i]
+				p1tmp := ctOut.Value[u].Coeffs[i]
+
+				for j := start; j < end; j = j + 8 {
+
+					x := (*[8]uint64)(unsafe.Pointer(&p0tmp[j]))
+					z := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
+
+					if add {
+						z[0] = ring.CRed(z[0]+ring.MRed(x[0], scaledConst, qi, mredParams), qi)
+						z[1] = ring.CRed(z[1]+ring.MRed(x[1], scaledConst, qi, mredParams), qi)
+						z[2] = ring.CRed(z[2]+ring.MRed(x[2], scaledConst, qi, mredParams), qi)
+						z[3] = ring.CRed(z[3]+ring.MRed(x[3], scaledConst, qi, mredParams), qi)
+						z[4] = ring.CRed(z[4]+ring.MRed(x[4], scaledConst, qi, mredParams), qi)
+						z[5] = ring.CRed(z[5]+ring.MRed(x[5], scaledConst, qi, mredParams), qi)
+						z[6] = ring.CRed(z[6]+ring.MRed(x[6], scaledConst, qi, mredParams), qi)
+						z[7] = ring.CRed(z[7]+ring.MRed(x[7], scaledConst, qi, mredParams), qi)
+					} else {
+						z[0] = ring.MRed(x[0], scaledConst, qi, mredParams)
+						z[1] = ring.MRed(x[1], scaledConst, qi, mredParams)
+						z[2] = ring.MRed(x[2], scaledConst, qi, mredParams)
+						z[3] = ring.MRed(x[3], scaledConst, qi, mredParams)
+						z[4] = ring.MRed(x[4], scaledConst, qi, mredParams)
+						z[5] = ring.MRed(x[5], scaledConst, qi, mredParams)
+						z[6] = ring.MRed(x[6], scaledConst, qi, mredParams)
+						z[7] = ring.MRed(x[7], scaledConst, qi, mredParams)
+					}
+				}
 			}
 		}
+	}
+}
 
-		if cImag != 0 {
-			scaledConst = ring.CRed(scaledConstReal+(qi-scaledConstImag), qi)
-			scaledConst = ring.MForm(scaledConst, qi, bredParams)
-		}
-
-		for u := range ct0.Value {
-			p0tmp := ct0.Value[u].Coeffs[i]
-			p1tmp := ctOut.Value[u].Coeffs[i]
-
-			for j := ringQ.N >> 1; j < ringQ.N; j = j + 8 {
-
-				x := (*[8]uint64)(unsafe.Pointer(&p0tmp[j]))
-				z := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
-
-				z[0] = ring.CRed(z[0]+ring.MRed(x[0], scaledConst, qi, mredParams), qi)
-				z[1] = ring.CRed(z[1]+ring.MRed(x[1], scaledConst, qi, mredParams), qi)
-				z[2] = ring.CRed(z[2]+ring.MRed(x[2], scaledConst, qi, mredParams), qi)
-				z[3] = ring.CRed(z[3]+ring.MRed(x[3], scaledConst, qi, mredParams), qi)
-				z[4] = ring.CRed(z[4]+ring.MRed(x[4], scaledConst, qi, mredParams), qi)
-				z[5] = ring.CRed(z[5]+ring.MRed(x[5], scaledConst, qi, mredParams), qi)
-				z[6] = ring.CRed(z[6]+ring.MRed(x[6], scaledConst, qi, mredParams), qi)
-				z[7] = ring.CRed(z[7]+ring.MRed(x[7], scaledConst, qi, mredParams), qi)
-			}
+// reduceInt64 returns c mod q in [0, q).
+func reduceInt64(c int64, q uint64) uint64 {
+	if c < 0 {
+		if r := uint64(-c) % q; r != 0 {
+			return q - r
 		}
+		return 0
 	}
+	return uint64(c) % q
 }
 
 // MultByiNew multiplies ct0 by the imaginary number i, and returns the result in a newly created element.
@@ -1066,6 +1155,9 @@ func (eval *evaluator) MultByi(ct0 *Ciphertext, ctOut *Ciphertext) {
 
 	var level = utils.MinInt(ct0.Level(), ctOut.Level())
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = false
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 
 	ringQ := eval.ringQ
 
@@ -1140,6 +1232,9 @@ func (eval *evaluator) DivByi(ct0 *Ciphertext, ctOut *Ciphertext) {
 	ringQ := eval.ringQ
 
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = false
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 
 	var imag uint64
 
@@ -1208,17 +1303,27 @@ func (eval *evaluator) ScaleUp(ct0 *Ciphertext, scale float64, ctOut *Ciphertext
 	ctOut.SetScale(ct0.Scale() * scale)
 }
 
-// SetScale sets the scale of the ciphertext to the input scale (consumes a level)
+// SetScale sets the scale of the ciphertext to the input scale (consumes a level if the ratio between the scales is not an integer)
 func (eval *evaluator) SetScale(ct *Ciphertext, scale float64) {
 
 	var tmp = eval.params.Scale()
 
 	eval.scale = scale
 
-	eval.MultByConst(ct, scale/ct.Scale(), ct)
+	ratio := scale / ct.Scale()
+
+	// A non-integer ratio is scaled by the current modulus, which is then removed by the rescaling
+	_, _, constScale := eval.getConstAndScale(ct.Level(), ratio)
+
+	eval.MultByConst(ct, ratio, ct)
+
+	if constScale != 1 {
 
-	if err := eval.Rescale(ct, scale, ct); err != nil {
-		panic(err)
+		ct.SetScale(scale * constScale)
+
+		if err := eval.Rescale(ct, scale, ct); err != nil {
+			panic(err)
+		}
 	}
 
 	ct.SetScale(scale)
@@ -1237,6 +1342,9 @@ func (eval *evaluator) MulByPow2New(ct0 *Ciphertext, pow2 int) (ctOut *Ciphertex
 func (eval *evaluator) MulByPow2(ct0 *Element, pow2 int, ctOut *Element) {
 	var level = utils.MinInt(ct0.Level(), ctOut.Level())
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 	for i := range ctOut.Value {
 		eval.ringQ.MulByPow2Lvl(level, ct0.Value[i], pow2, ctOut.Value[i])
 	}
@@ -1266,6 +1374,9 @@ func (eval *evaluator) Reduce(ct0 *Ciphertext, ctOut *Ciphertext) error {
 	}
 
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 
 	return nil
 }
@@ -1326,8 +1437,11 @@ func (eval *evaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Cipher
 		return errors.New("cannot Rescale : ctIn.Degree() != ctOut.Degree()")
 	}
 
-	ctOut.scale = ctIn.scale
-	ctOut.Element.Element.IsNTT = true
+	ctOut.MetaData.Scale = ctIn.MetaData.Scale
+	ctOut.MetaData.IsReal = ctIn.MetaData.IsReal
+	ctOut.MetaData.SlotScales = copySlotScales(ctIn.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
+	ctOut.Element.IsNTT = true
 
 	var nbRescale int
 	// Divides the scale by each moduli of the modulus chain as long as the scale isn't smaller than minScale/2
@@ -1389,7 +1503,7 @@ func (eval *evaluator) mulRelin(op0, op1 Operand, relin bool, ctOut *Ciphertext)
 	level := utils.MinInt(utils.MinInt(el0.Level(), el1.Level()), elOut.Level())
 
 	if ctOut.Level() > level {
-		eval.DropLevel(&Ciphertext{elOut}, elOut.Level()-level)
+		eval.DropLevel(elOut.ciphertext(), elOut.Level()-level)
 	}
 
 	if el0.Degree() > 1 || el1.Degree() > 1 {
@@ -1397,6 +1511,9 @@ func (eval *evaluator) mulRelin(op0, op1 Operand, relin bool, ctOut *Ciphertext)
 	}
 
 	elOut.SetScale(el0.Scale() * el1.Scale())
+	elOut.MetaData.IsReal = el0.MetaData.IsReal && el1.MetaData.IsReal
+	elOut.MetaData.SlotScales = mulSlotScales(el0.MetaData.SlotScales, el1.MetaData.SlotScales)
+	elOut.MetaData.Tags = mergeMetadata(el0.MetaData.Tags, el1.MetaData.Tags)
 
 	ringQ := eval.ringQ
 
@@ -1488,6 +1605,9 @@ func (eval *evaluator) Relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 	}
 
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 
 	level := utils.MinInt(ct0.Level(), ctOut.Level())
 	ringQ := eval.ringQ
@@ -1522,6 +1642,9 @@ func (eval *evaluator) SwitchKeys(ct0 *Ciphertext, switchingKey *rlwe.SwitchingK
 	ringQ := eval.ringQ
 
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.IsReal = ct0.MetaData.IsReal
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 
 	eval.SwitchKeysInPlace(level, ct0.Value[1], switchingKey, eval.poolQ[1], eval.poolQ[2])
 
@@ -1529,6 +1652,62 @@ func (eval *evaluator) SwitchKeys(ct0 *Ciphertext, switchingKey *rlwe.SwitchingK
 	ringQ.CopyLvl(level, eval.poolQ[2], ctOut.Value[1])
 }
 
+// RotateBy applies the rotation rot to ct0 and returns the result in ctOut. A rlwe.SlotRotation rotates the slots
+// (see Rotate) and a rlwe.CoeffShift multiplies the plaintext by a monomial. See rlwe.Rotation for the conventions.
+func (eval *evaluator) RotateBy(ct0 *Ciphertext, rot rlwe.Rotation, ctOut *Ciphertext) {
+	switch rot := rot.(type) {
+	case rlwe.SlotRotation:
+		eval.Rotate(ct0, int(rot), ctOut)
+	case rlwe.CoeffShift:
+
+		checkNoSlotScales("RotateBy", ct0.El())
+
+		if ct0.Degree() != ctOut.Degree() {
+			panic("cannot RotateBy: input and output must be of the same degree")
+		}
+
+		level := utils.MinInt(ct0.Level(), ctOut.Level())
+
+		// NTT(X^k) in the Montgomery domain
+		monomial := eval.poolQ[0]
+		for i := 0; i < level+1; i++ {
+			for j := range monomial.Coeffs[i] {
+				monomial.Coeffs[i][j] = 0
+			}
+		}
+
+		if degree := rot.MonomialDegree(eval.params.Parameters); degree < eval.params.N() {
+			for i := 0; i < level+1; i++ {
+				monomial.Coeffs[i][degree] = 1
+			}
+		} else {
+			for i := 0; i < level+1; i++ {
+				monomial.Coeffs[i][degree-eval.params.N()] = eval.ringQ.Modulus[i] - 1
+			}
+		}
+
+		eval.ringQ.NTTLvl(level, monomial, monomial)
+		eval.ringQ.MFormLvl(level, monomial, monomial)
+
+		for i := range ct0.Value {
+			eval.ringQ.MulCoeffsMontgomeryLvl(level, ct0.Value[i], monomial, ctOut.Value[i])
+		}
+
+		ctOut.SetScale(ct0.Scale())
+		ctOut.MetaData.IsReal = false
+
+	default:
+		panic(fmt.Sprintf("cannot RotateBy: unsupported rotation type %T", rot))
+	}
+}
+
+// RotateByNew applies RotateBy and returns the result in a new Ciphertext.
+func (eval *evaluator) RotateByNew(ct0 *Ciphertext, rot rlwe.Rotation) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree(), ct0.Level(), ct0.Scale())
+	eval.RotateBy(ct0, rot, ctOut)
+	return
+}
+
 // RotateNew rotates the columns of ct0 by k positions to the left, and returns the result in a newly created element.
 // If the provided element is a Ciphertext, a key-switching operation is necessary and a rotation key for the specific rotation needs to be provided.
 func (eval *evaluator) RotateNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
@@ -1550,6 +1729,9 @@ func (eval *evaluator) Rotate(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
 	} else {
 
 		ctOut.SetScale(ct0.Scale())
+		ctOut.MetaData.IsReal = ct0.MetaData.IsReal
+		ctOut.MetaData.SlotScales = rotateSlotScales(ct0.MetaData.SlotScales, k)
+		ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 
 		galEl := eval.params.GaloisElementForColumnRotationBy(k)
 
@@ -1568,26 +1750,136 @@ func (eval *evaluator) ConjugateNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
 
 // Conjugate conjugates ct0 (which is equivalent to a row rotation) and returns the result in ctOut.
 // If the provided element is a Ciphertext, a key-switching operation is necessary and a rotation key for the row rotation needs to be provided.
+// If ct0 is flagged as purely real (see Element.IsReal), the conjugation is the identity and ct0 is simply copied on ctOut.
 func (eval *evaluator) Conjugate(ct0 *Ciphertext, ctOut *Ciphertext) {
 
+	if ct0.IsReal() {
+		if ct0 != ctOut {
+			ctOut.Copy(ct0)
+		}
+		return
+	}
+
 	galEl := eval.params.GaloisElementForRowRotation()
 	ctOut.SetScale(ct0.Scale())
+	ctOut.MetaData.SlotScales = copySlotScales(ct0.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ct0.MetaData.Tags.CopyNew()
 	eval.permuteNTT(ct0, galEl, ctOut)
 }
 
+// HasConjugationKey returns true if the evaluator has the rotation key for the complex conjugation of the slots,
+// i.e. if Conjugate, RealPart, ImagPart and UnpackRealNew can be called on ciphertexts that are not flagged as purely
+// real (see KeyGenerator.GenConjugationKey).
+func (eval *evaluator) HasConjugationKey() bool {
+	return rlwe.HasRotationKeys(eval.rtks, eval.params.GaloisElementForRowRotation())
+}
+
+// RealPartNew isolates the real part of the slots of ctIn and returns the result in a newly created element.
+// See RealPart.
+func (eval *evaluator) RealPartNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale())
+	eval.RealPart(ctIn, ctOut)
+	return
+}
+
+// RealPart isolates the real part of the slots of ctIn, computed as (ctIn + conj(ctIn))/2, and returns the result in
+// ctOut, which is flagged as purely real. It requires the rotation key for the conjugation (see
+// KeyGenerator.GenConjugationKey), unless ctIn is itself flagged as purely real. The division by two is applied
+// by doubling the scale and does not consume a level.
+func (eval *evaluator) RealPart(ctIn *Ciphertext, ctOut *Ciphertext) {
+
+	if ctIn.IsReal() {
+		if ctIn != ctOut {
+			ctOut.Copy(ctIn)
+		}
+		return
+	}
+
+	ctConj := eval.ConjugateNew(ctIn)
+	eval.Add(ctIn, ctConj, ctOut)
+	ctOut.MulScale(2)
+	ctOut.SetIsReal(true)
+}
+
+// ImagPartNew isolates the imaginary part of the slots of ctIn and returns the result in a newly created element.
+// See ImagPart.
+func (eval *evaluator) ImagPartNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale())
+	eval.ImagPart(ctIn, ctOut)
+	return
+}
+
+// ImagPart isolates the imaginary part of the slots of ctIn, computed as (ctIn - conj(ctIn))/2i, and returns the
+// result, as a real message, in ctOut, which is flagged as purely real. It requires the rotation key for the
+// conjugation (see KeyGenerator.GenConjugationKey), unless ctIn is itself flagged as purely real, in which case ctOut
+// encrypts zero. The division by two is applied by doubling the scale and does not consume a level.
+func (eval *evaluator) ImagPart(ctIn *Ciphertext, ctOut *Ciphertext) {
+
+	if ctIn.IsReal() {
+		eval.Sub(ctIn, ctIn, ctOut)
+		ctOut.SetIsReal(true)
+		return
+	}
+
+	ctConj := eval.ConjugateNew(ctIn)
+	eval.Sub(ctIn, ctConj, ctOut)
+	eval.DivByi(ctOut, ctOut)
+	ctOut.MulScale(2)
+	ctOut.SetIsReal(true)
+}
+
+// PackRealNew packs two Ciphertext encrypting purely real messages m0 and m1 into a new Ciphertext encrypting m0 + i*m1.
+// Additions, rotations and multiplications by real constants or real plaintexts then operate on both messages at once,
+// which halves their cost, and UnpackRealNew recovers the two messages. Multiplications between packed Ciphertexts
+// mix the real and imaginary parts and are not supported.
+// The procedure will panic if either input is not flagged as purely real (see Element.IsReal).
+func (eval *evaluator) PackRealNew(ctRe, ctIm *Ciphertext) (ctOut *Ciphertext) {
+
+	if !ctRe.IsReal() || !ctIm.IsReal() {
+		panic("cannot PackRealNew: inputs must be flagged as purely real")
+	}
+
+	ctOut = eval.MultByiNew(ctIm)
+	eval.Add(ctRe, ctOut, ctOut)
+	return
+}
+
+// UnpackRealNew splits ctIn, encrypting m0 + i*m1, into two new Ciphertext encrypting the real messages m0 and m1,
+// which are flagged as purely real. It requires the rotation key for the conjugation, unless ctIn is itself flagged
+// as purely real. The division by two is applied by doubling the scale and does not consume a level.
+func (eval *evaluator) UnpackRealNew(ctIn *Ciphertext) (ctRe, ctIm *Ciphertext) {
+
+	ctConj := eval.ConjugateNew(ctIn)
+
+	// ctRe = m + conj(m) = 2 * m0
+	ctRe = eval.AddNew(ctIn, ctConj)
+
+	// ctIm = (m - conj(m))/i = 2 * m1
+	ctIm = eval.SubNew(ctIn, ctConj)
+	eval.DivByi(ctIm, ctIm)
+
+	ctRe.MulScale(2)
+	ctIm.MulScale(2)
+
+	ctRe.SetIsReal(true)
+	ctIm.SetIsReal(true)
+
+	return
+}
+
 func (eval *evaluator) permuteNTT(ct0 *Ciphertext, galEl uint64, ctOut *Ciphertext) {
 
 	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
 		panic("input and output Ciphertext must be of degree 1")
 	}
 
-	rtk, generated := eval.rtks.Keys[galEl]
+	rtk, generated := eval.rtks.GetRotationKey(galEl)
 	if !generated {
 		panic(fmt.Sprintf("rotation key k=%d not available", eval.params.InverseGaloisElement(galEl)))
 	}
 
 	level := utils.MinInt(ct0.Level(), ctOut.Level())
-	index := eval.permuteNTTIndex[galEl]
+	index := eval.permuteNTTIndexFor(galEl)
 	pool2Q := eval.poolQ[1]
 	pool3Q := eval.poolQ[2]
 
@@ -1634,12 +1926,12 @@ func (eval *evaluator) permuteNTTHoistedNoModDown(level int, c2QiQDecomp, c2QiPD
 
 	galEl := eval.params.GaloisElementForColumnRotationBy(k)
 
-	rtk, generated := eval.rtks.Keys[galEl]
+	rtk, generated := eval.rtks.GetRotationKey(galEl)
 	if !generated {
 		fmt.Println(k)
 		panic("switching key not available")
 	}
-	index := eval.permuteNTTIndex[galEl]
+	index := eval.permuteNTTIndexFor(galEl)
 
 	eval.keyswitchHoistedNoModDown(levelQ, c2QiQDecomp, c2QiPDecomp, rtk, pool2Q, pool3Q, pool2P, pool3P)
 
@@ -1652,6 +1944,14 @@ func (eval *evaluator) permuteNTTHoistedNoModDown(level int, c2QiQDecomp, c2QiPD
 
 func (eval *evaluator) SwitchKeysInPlaceNoModDown(level int, cx *ring.Poly, evakey *rlwe.SwitchingKey, pool2Q, pool2P, pool3Q, pool3P *ring.Poly) {
 
+	if eval.ks != nil {
+		eval.ringQ.InvNTTLvl(level, cx, eval.poolInvNTT)
+		eval.ks.SwitchKeysNoModDown(level, cx, eval.poolInvNTT, evakey, pool2Q, pool3Q, pool2P, pool3P)
+		return
+	}
+
+	eval.ringQ.CountKeySwitch()
+
 	var reduce int
 
 	ringQ := eval.ringQ
@@ -1729,6 +2029,13 @@ func (eval *evaluator) SwitchKeysInPlaceNoModDown(level int, cx *ring.Poly, evak
 // SwitchKeysInPlace applies the general key-switching procedure of the form [c0 + cx*evakey[0], c1 + cx*evakey[1]]
 func (eval *evaluator) SwitchKeysInPlace(level int, cx *ring.Poly, evakey *rlwe.SwitchingKey, p0, p1 *ring.Poly) {
 
+	if eval.ks != nil {
+		// The key-switch is counted by the KeySwitcher (see ring.Ring.CountKeySwitch)
+		eval.ringQ.InvNTTLvl(level, cx, eval.poolInvNTT)
+		eval.ks.SwitchKeys(level, cx, eval.poolInvNTT, evakey, p0, p1)
+		return
+	}
+
 	eval.SwitchKeysInPlaceNoModDown(level, cx, evakey, p0, eval.poolP[1], p1, eval.poolP[2])
 
 	eval.baseconverter.ModDownSplitNTTPQ(level, p0, eval.poolP[1], p0)
@@ -1742,6 +2049,11 @@ func (eval *evaluator) DecompInternal(levelQ int, c2NTT *ring.Poly, c2QiQDecomp,
 	c2InvNTT := eval.poolInvNTT
 	ringQ.InvNTTLvl(levelQ, c2NTT, c2InvNTT)
 
+	if eval.ks != nil {
+		eval.ks.Decompose(levelQ, c2NTT, c2InvNTT, c2QiQDecomp, c2QiPDecomp)
+		return
+	}
+
 	alpha := eval.params.PCount()
 	beta := int(math.Ceil(float64(levelQ+1) / float64(alpha)))
 
@@ -1792,12 +2104,12 @@ func (eval *evaluator) permuteNTTHoisted(level int, c0, c1 *ring.Poly, c2QiQDeco
 	}
 
 	galEl := eval.params.GaloisElementForColumnRotationBy(k)
-	rtk, generated := eval.rtks.Keys[galEl]
+	rtk, generated := eval.rtks.GetRotationKey(galEl)
 	if !generated {
 		panic(fmt.Sprintf("specific rotation has not been generated: %d", k))
 	}
 
-	index := eval.permuteNTTIndex[galEl]
+	index := eval.permuteNTTIndexFor(galEl)
 
 	pool2Q := eval.poolQ[0]
 	pool3Q := eval.poolQ[1]
@@ -1824,6 +2136,13 @@ func (eval *evaluator) keyswitchHoisted(level int, c2QiQDecomp, c2QiPDecomp []*r
 
 func (eval *evaluator) keyswitchHoistedNoModDown(level int, c2QiQDecomp, c2QiPDecomp []*ring.Poly, evakey *rlwe.SwitchingKey, pool2Q, pool3Q, pool2P, pool3P *ring.Poly) {
 
+	if eval.ks != nil {
+		eval.ks.SwitchKeysHoistedNoModDown(level, c2QiQDecomp, c2QiPDecomp, evakey, pool2Q, pool3Q, pool2P, pool3P)
+		return
+	}
+
+	eval.ringQ.CountKeySwitch()
+
 	ringQ := eval.ringQ
 	ringP := eval.ringP
 
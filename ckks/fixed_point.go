@@ -0,0 +1,167 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// The fixed-point encoding maps an int64 v with f fractional bits to the real value v * 2^-f, which is encoded on a
+// slot as any other real value, so that the arithmetic of the fixed-point values is the one of CKKS. The number of
+// fractional bits is tracked by the FixedPointCiphertext and the FixedPointEvaluator, and the values are rounded back
+// to int64 with the tracked number of fractional bits at decoding. The decoded values are exact as long as the
+// error of the CKKS evaluation is smaller than 2^-(f+1), i.e. as long as f is smaller than the precision of the
+// ciphertext, and as long as the values fit in an int64.
+
+// FixedPointCiphertext is a Ciphertext whose slots encrypt fixed-point values with FracBits fractional bits.
+type FixedPointCiphertext struct {
+	*Ciphertext
+	FracBits int
+}
+
+// CopyNew creates a deep copy of the receiver FixedPointCiphertext and returns it.
+func (ct *FixedPointCiphertext) CopyNew() *FixedPointCiphertext {
+	return &FixedPointCiphertext{Ciphertext: ct.Ciphertext.CopyNew(), FracBits: ct.FracBits}
+}
+
+// FixedPointEncoder encodes fixed-point values on the slots of plaintexts and decodes them.
+type FixedPointEncoder struct {
+	params  Parameters
+	encoder Encoder
+}
+
+// NewFixedPointEncoder creates a new FixedPointEncoder.
+func NewFixedPointEncoder(params Parameters) *FixedPointEncoder {
+	return &FixedPointEncoder{params: params, encoder: NewEncoder(params)}
+}
+
+// EncodeNTT encodes the values with fracBits fractional bits on the first slots of the plaintext, in the NTT domain.
+// The remaining slots are set to zero.
+func (enc *FixedPointEncoder) EncodeNTT(plaintext *Plaintext, values []int64, fracBits int) {
+
+	if len(values) > enc.params.Slots() {
+		panic(fmt.Sprintf("cannot EncodeNTT: the number of values (%d) is larger than the number of slots (%d)", len(values), enc.params.Slots()))
+	}
+
+	slots := make([]complex128, enc.params.Slots())
+	for i, v := range values {
+		slots[i] = complex(math.Ldexp(float64(v), -fracBits), 0)
+	}
+
+	enc.encoder.EncodeNTT(plaintext, slots, enc.params.LogSlots())
+}
+
+// EncodeNTTNew encodes the values with fracBits fractional bits on a new plaintext at the maximum level and with the
+// default scale of the parameters. See EncodeNTT.
+func (enc *FixedPointEncoder) EncodeNTTNew(values []int64, fracBits int) (plaintext *Plaintext) {
+	plaintext = NewPlaintext(enc.params, enc.params.MaxLevel(), enc.params.Scale())
+	enc.EncodeNTT(plaintext, values, fracBits)
+	return
+}
+
+// Decode decodes the slots of the plaintext, e.g. the decryption of a FixedPointCiphertext, and rounds their real
+// part to fixed-point values with fracBits fractional bits.
+func (enc *FixedPointEncoder) Decode(plaintext *Plaintext, fracBits int) (values []int64) {
+
+	slots := enc.encoder.Decode(plaintext, enc.params.LogSlots())
+
+	values = make([]int64, len(slots))
+	for i, v := range slots {
+		values[i] = int64(math.Round(math.Ldexp(real(v), fracBits)))
+	}
+
+	return
+}
+
+// EncryptFixedPointNew encodes the values with fracBits fractional bits and encrypts them in a new
+// FixedPointCiphertext.
+func EncryptFixedPointNew(encoder *FixedPointEncoder, encryptor Encryptor, values []int64, fracBits int) *FixedPointCiphertext {
+	return &FixedPointCiphertext{Ciphertext: encryptor.EncryptNew(encoder.EncodeNTTNew(values, fracBits)), FracBits: fracBits}
+}
+
+// DecryptFixedPointNew decrypts and decodes the FixedPointCiphertext and returns its fixed-point values.
+func DecryptFixedPointNew(encoder *FixedPointEncoder, decryptor Decryptor, ct *FixedPointCiphertext) (values []int64) {
+	return encoder.Decode(decryptor.DecryptNew(ct.Ciphertext), ct.FracBits)
+}
+
+// FixedPointEvaluator evaluates operations on FixedPointCiphertexts with an underlying Evaluator and tracks their
+// number of fractional bits: the sum of two fixed-point values has the largest of their numbers of fractional bits,
+// and their product has the sum of their numbers of fractional bits. The multiplications are rescaled to the default
+// scale of the parameters, so that the scales of the ciphertexts do not have to be managed.
+type FixedPointEvaluator struct {
+	params Parameters
+	eval   Evaluator
+}
+
+// NewFixedPointEvaluator creates a new FixedPointEvaluator operating with eval, which must hold the relinearization
+// key for the multiplications.
+func NewFixedPointEvaluator(params Parameters, eval Evaluator) *FixedPointEvaluator {
+	return &FixedPointEvaluator{params: params, eval: eval}
+}
+
+// Add adds op0 to op1 and returns the result in ctOut.
+func (eval *FixedPointEvaluator) Add(op0, op1, ctOut *FixedPointCiphertext) {
+	eval.eval.Add(op0.Ciphertext, op1.Ciphertext, ctOut.Ciphertext)
+	ctOut.FracBits = utils.MaxInt(op0.FracBits, op1.FracBits)
+}
+
+// AddNew adds op0 to op1 and returns the result in a new FixedPointCiphertext.
+func (eval *FixedPointEvaluator) AddNew(op0, op1 *FixedPointCiphertext) (ctOut *FixedPointCiphertext) {
+	ctOut = &FixedPointCiphertext{Ciphertext: NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()), 1)}
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut.
+func (eval *FixedPointEvaluator) Sub(op0, op1, ctOut *FixedPointCiphertext) {
+	eval.eval.Sub(op0.Ciphertext, op1.Ciphertext, ctOut.Ciphertext)
+	ctOut.FracBits = utils.MaxInt(op0.FracBits, op1.FracBits)
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a new FixedPointCiphertext.
+func (eval *FixedPointEvaluator) SubNew(op0, op1 *FixedPointCiphertext) (ctOut *FixedPointCiphertext) {
+	ctOut = &FixedPointCiphertext{Ciphertext: NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()), 1)}
+	eval.Sub(op0, op1, ctOut)
+	return
+}
+
+// Mul multiplies op0 by op1 with relinearization, rescales the result and returns it in ctOut.
+func (eval *FixedPointEvaluator) Mul(op0, op1, ctOut *FixedPointCiphertext) (err error) {
+	fracBits := op0.FracBits + op1.FracBits
+	eval.eval.MulRelin(op0.Ciphertext, op1.Ciphertext, ctOut.Ciphertext)
+	if err = eval.eval.Rescale(ctOut.Ciphertext, eval.params.Scale(), ctOut.Ciphertext); err != nil {
+		return fmt.Errorf("cannot Mul: %w", err)
+	}
+	ctOut.FracBits = fracBits
+	return nil
+}
+
+// MulNew multiplies op0 by op1 with relinearization, rescales the result and returns it in a new
+// FixedPointCiphertext.
+func (eval *FixedPointEvaluator) MulNew(op0, op1 *FixedPointCiphertext) (ctOut *FixedPointCiphertext, err error) {
+	ctOut = &FixedPointCiphertext{Ciphertext: NewCiphertext(eval.params, 1, utils.MinInt(op0.Level(), op1.Level()), 1)}
+	return ctOut, eval.Mul(op0, op1, ctOut)
+}
+
+// MulByFixedPoint multiplies op0 by the fixed-point constant c with fracBits fractional bits and returns the result in
+// ctOut. The multiplication consumes a level if the real value of the constant is not an integer.
+func (eval *FixedPointEvaluator) MulByFixedPoint(op0 *FixedPointCiphertext, c int64, fracBits int, ctOut *FixedPointCiphertext) (err error) {
+	outFracBits := op0.FracBits + fracBits
+	scale := op0.Scale()
+	constant := math.Ldexp(float64(c), -fracBits)
+	eval.eval.MultByConst(op0.Ciphertext, constant, ctOut.Ciphertext)
+	if constant != math.Trunc(constant) {
+		if err = eval.eval.Rescale(ctOut.Ciphertext, scale, ctOut.Ciphertext); err != nil {
+			return fmt.Errorf("cannot MulByFixedPoint: %w", err)
+		}
+	}
+	ctOut.FracBits = outFracBits
+	return nil
+}
+
+// Requantize sets the number of fractional bits of ct to fracBits, e.g. to drop the fractional bits accumulated by
+// the multiplications: the values are rounded to fracBits fractional bits at decoding.
+func (eval *FixedPointEvaluator) Requantize(ct *FixedPointCiphertext, fracBits int) {
+	ct.FracBits = fracBits
+}
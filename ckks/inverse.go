@@ -0,0 +1,214 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// invSqrtLogPrecision is the log2 of the relative precision of the initial guess of InvSqrtNew.
+const invSqrtLogPrecision = 4
+
+// invSqrtMaxDegree is the maximum degree of the Chebyshev approximation of the initial guess of InvSqrtNew.
+const invSqrtMaxDegree = 63
+
+// InverseRangeNew approximates 1/x for the real values x of ctIn lying in the interval [a, b], which must not
+// contain zero, and returns the result in a newly created element. The values are first scaled by c = 2/(a+b),
+// so that 1 - c*x lies in [-r, r] with r = |b-a|/|b+a| < 1, and the inverse is then approximated with the
+// Goldschmidt iteration 1/x = c * prod_i (1 + (1-c*x)^(2^i)), whose relative error after n iterations is
+// r^(2^n). It consumes at most iterations+1 levels (1 level for a single iteration), and returns an error if ctIn
+// does not have enough levels or if the arguments are invalid.
+func (eval *evaluator) InverseRangeNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalInverseRange(eval, eval.params, ctIn, a, b, iterations)
+}
+
+// InvSqrtNew approximates 1/sqrt(x) for the real values x of ctIn lying in the interval [a, b], with 0 < a < b, and
+// returns the result in a newly created element. The initial guess is a Chebyshev approximation of 1/sqrt(x) on [a, b],
+// of the smallest degree 2^d-1 reaching a relative precision of 2^-4, which is then refined with the given number of
+// Newton iterations y = y * (3 - x*y^2) / 2, each squaring the relative error (up to a factor 3/2). The initial guess
+// consumes at most d+1 levels and each iteration at most 3 levels. Returns an error if ctIn does not have enough levels, if the
+// arguments are invalid or if the interval is too wide for the initial guess.
+func (eval *evaluator) InvSqrtNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalInvSqrt(eval, eval.params, ctIn, a, b, iterations)
+}
+
+// InverseRangeDepth returns the maximum number of levels consumed by InverseRangeNew with the given number of iterations.
+func InverseRangeDepth(iterations int) int {
+	if iterations == 1 {
+		return 1
+	}
+	return iterations + 1
+}
+
+// InvSqrtDepth returns the maximum number of levels consumed by InvSqrtNew on the interval [a, b] with the given number
+// of iterations, and an error if the interval is invalid or too wide for the initial guess.
+func InvSqrtDepth(a, b float64, iterations int) (depth int, err error) {
+	var cheby *ChebyshevInterpolation
+	if cheby, err = invSqrtApproximation(a, b); err != nil {
+		return 0, err
+	}
+	return invSqrtDepth(cheby, iterations), nil
+}
+
+func invSqrtDepth(cheby *ChebyshevInterpolation, iterations int) int {
+	return 1 + bits.Len64(uint64(cheby.Degree())) + 3*iterations
+}
+
+// evalInverseRange implements InverseRangeNew on top of the Evaluator interface, so that it is shared by the
+// evaluator and the cleartext evaluator.
+func evalInverseRange(eval Evaluator, params Parameters, ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+
+	checkNoSlotScales("InverseRangeNew", ctIn.El())
+
+	if !(a < b) || (a <= 0 && b >= 0) {
+		return nil, fmt.Errorf("cannot InverseRangeNew: [%v, %v] is not an interval excluding zero", a, b)
+	}
+
+	if iterations < 1 {
+		return nil, fmt.Errorf("cannot InverseRangeNew: the number of iterations must be at least 1")
+	}
+
+	if depth := InverseRangeDepth(iterations); ctIn.Level() < depth {
+		return nil, fmt.Errorf("cannot InverseRangeNew: %d iterations require %d levels but ctIn is at level %d", iterations, depth, ctIn.Level())
+	}
+
+	scale := params.Scale()
+	c := 2 / (a + b)
+
+	// cbar = 1 - c*x
+	cbar := eval.MultByConstNew(ctIn, -c)
+	eval.AddConst(cbar, 1, cbar)
+	if err = eval.Rescale(cbar, scale, cbar); err != nil {
+		return nil, err
+	}
+
+	// ctOut = c * (1 + cbar) = 2c - c^2 * x
+	ctOut = eval.MultByConstNew(ctIn, -c*c)
+	eval.AddConst(ctOut, 2*c, ctOut)
+	if err = eval.Rescale(ctOut, scale, ctOut); err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < iterations; i++ {
+
+		eval.MulRelin(cbar, cbar, cbar)
+		if err = eval.Rescale(cbar, scale, cbar); err != nil {
+			return nil, err
+		}
+
+		tmp := eval.AddConstNew(cbar, 1)
+
+		eval.MulRelin(tmp, ctOut, tmp)
+		if err = eval.Rescale(tmp, scale, tmp); err != nil {
+			return nil, err
+		}
+
+		ctOut = tmp
+	}
+
+	return ctOut, nil
+}
+
+// evalInvSqrt implements InvSqrtNew on top of the Evaluator interface, so that it is shared by the evaluator and
+// the cleartext evaluator.
+func evalInvSqrt(eval Evaluator, params Parameters, ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+
+	checkNoSlotScales("InvSqrtNew", ctIn.El())
+
+	if iterations < 0 {
+		return nil, fmt.Errorf("cannot InvSqrtNew: the number of iterations cannot be negative")
+	}
+
+	var cheby *ChebyshevInterpolation
+	if cheby, err = invSqrtApproximation(a, b); err != nil {
+		return nil, err
+	}
+
+	if depth := invSqrtDepth(cheby, iterations); ctIn.Level() < depth {
+		return nil, fmt.Errorf("cannot InvSqrtNew: %d iterations on [%v, %v] require %d levels but ctIn is at level %d", iterations, a, b, depth, ctIn.Level())
+	}
+
+	scale := params.Scale()
+
+	// Change of basis y = (2x - a - b)/(b - a) for the Chebyshev evaluation
+	y := eval.MultByConstNew(ctIn, 2/(b-a))
+	eval.AddConst(y, -(a+b)/(b-a), y)
+	if err = eval.Rescale(y, scale, y); err != nil {
+		return nil, err
+	}
+
+	if ctOut, err = eval.EvaluateCheby(y, cheby, scale); err != nil {
+		return nil, err
+	}
+
+	if iterations == 0 {
+		return ctOut, nil
+	}
+
+	// xHalf = -x/2
+	xHalf := eval.MultByConstNew(ctIn, -0.5)
+	if err = eval.Rescale(xHalf, scale, xHalf); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < iterations; i++ {
+
+		// tmp = -x/2 * y^2
+		tmp := eval.MulRelinNew(xHalf, ctOut)
+		if err = eval.Rescale(tmp, scale, tmp); err != nil {
+			return nil, err
+		}
+
+		eval.MulRelin(tmp, ctOut, tmp)
+		if err = eval.Rescale(tmp, scale, tmp); err != nil {
+			return nil, err
+		}
+
+		// y = y * (3/2 - x/2 * y^2)
+		eval.AddConst(tmp, 1.5, tmp)
+		eval.MulRelin(tmp, ctOut, ctOut)
+		if err = eval.Rescale(ctOut, scale, ctOut); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctOut, nil
+}
+
+// invSqrtApproximation returns the Chebyshev interpolant of 1/sqrt(x) on [a, b] of the smallest degree 2^d-1
+// reaching a relative precision of 2^-invSqrtLogPrecision, or an error if the interval is invalid or if the
+// interpolant of maximum degree does not reach a relative precision of 1/2, which ensures the convergence of
+// the Newton iterations.
+func invSqrtApproximation(a, b float64) (cheby *ChebyshevInterpolation, err error) {
+
+	if !(0 < a && a < b) {
+		return nil, fmt.Errorf("cannot InvSqrtNew: [%v, %v] is not an interval of positive values", a, b)
+	}
+
+	invSqrt := func(x complex128) complex128 {
+		return 1 / cmplx.Sqrt(x)
+	}
+
+	for degree := 3; degree <= invSqrtMaxDegree; degree = 2*degree + 1 {
+
+		cheby = Approximate(invSqrt, complex(a, 0), complex(b, 0), degree)
+
+		// The relative error is measured on a geometric grid of the interval, denser close to a
+		var maxErr float64
+		for i := 0; i <= 8*degree; i++ {
+			x := a * math.Pow(b/a, float64(i)/float64(8*degree))
+			maxErr = math.Max(maxErr, math.Abs(real(cheby.evaluate(complex(x, 0)))*math.Sqrt(x)-1))
+		}
+
+		if maxErr <= math.Exp2(-invSqrtLogPrecision) {
+			return cheby, nil
+		}
+
+		if degree == invSqrtMaxDegree && maxErr <= 0.5 {
+			return cheby, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot InvSqrtNew: the interval [%v, %v] is too wide for the initial guess", a, b)
+}
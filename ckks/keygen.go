@@ -1,6 +1,7 @@
 package ckks
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ldsec/lattigo/v2/ring"
@@ -23,6 +24,7 @@ type KeyGenerator interface {
 	GenRotationKeys(galEls []uint64, sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
 	GenRotationKeysForRotations(ks []int, includeConjugate bool, sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
 	GenRotationKeysForInnerSum(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
+	GenConjugationKey(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet)
 }
 
 // KeyGenerator is a structure that stores the elements required to create new keys,
@@ -91,11 +93,10 @@ func (keygen *keyGenerator) GenSecretKeySparse(hw int) (sk *rlwe.SecretKey) {
 	if err != nil {
 		panic(err)
 	}
-	ternarySamplerMontgomery := ring.NewTernarySamplerSparse(prng, keygen.ringQP, hw, true)
+	ternarySamplerMontgomeryNTT := ring.NewTernarySamplerWithHammingWeight(prng, keygen.ringQP, hw, true, true)
 
 	sk = new(rlwe.SecretKey)
-	sk.Value = ternarySamplerMontgomery.ReadNew()
-	keygen.ringQP.NTT(sk.Value, sk.Value)
+	sk.Value = ternarySamplerMontgomeryNTT.ReadNew()
 	return sk
 }
 
@@ -269,7 +270,28 @@ func (keygen *keyGenerator) GenRotationKeysForRotations(ks []int, includeConjuga
 	return keygen.GenRotationKeys(galEls, sk)
 }
 
+// GenConjugationKey generates a RotationKeySet containing only the key for the complex conjugation of the slots,
+// as required by Evaluator.Conjugate, RealPart and ImagPart.
+func (keygen *keyGenerator) GenConjugationKey(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet) {
+	return keygen.GenRotationKeys([]uint64{keygen.params.GaloisElementForRowRotation()}, sk)
+}
+
 // GenRotationKeysForInnerSum generates a RotationKeySet supporting the InnerSum operation of the Evaluator
 func (keygen *keyGenerator) GenRotationKeysForInnerSum(sk *rlwe.SecretKey) (rks *rlwe.RotationKeySet) {
 	return keygen.GenRotationKeys(keygen.params.GaloisElementsForRowInnerSum(), sk)
 }
+
+// GenRotationKeysToStore generates with kgen the rotation keys for the Galois elements galEls that are not already in
+// store, one at a time, and writes each of them in the store as soon as it is generated, so that at most one rotation
+// key is held in memory (e.g., to generate the bootstrapping keys, see
+// BootstrappingParameters.GaloisElementsForBootstrapping). An interrupted generation is resumed by calling it again
+// with the same store. Returns the number of generated keys.
+func GenRotationKeysToStore(kgen KeyGenerator, sk *rlwe.SecretKey, galEls []uint64, store *rlwe.RotationKeyStore) (generated int, err error) {
+	for _, galEl := range store.MissingGaloisElements(galEls) {
+		if err = store.Store(galEl, kgen.GenSwitchingKeyForGalois(galEl, sk)); err != nil {
+			return generated, fmt.Errorf("cannot GenRotationKeysToStore: %w", err)
+		}
+		generated++
+	}
+	return generated, nil
+}
@@ -0,0 +1,211 @@
+package ckks
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RefreshFunc is a function refreshing a Ciphertext, e.g. by bootstrapping it or by running an interactive
+// refresh protocol. It must return a new Ciphertext encrypting the same message as ct, at a level of at least
+// minLevel+depth, where depth is the number of levels about to be consumed by the operation, and at a scale
+// suitable for the operation. It must not modify ct.
+type RefreshFunc func(ct *Ciphertext, depth int) *Ciphertext
+
+// LevelGuardEvaluator is an Evaluator that calls a RefreshFunc on the Ciphertext operands of the operations which
+// would otherwise leave them below a minimum level, so that circuits of arbitrary depth can be evaluated without
+// level checks in the application code. The guarded operations and their depths are:
+//
+//   - Mul, MulNew, MulRelin, MulRelinNew, MultByConst and MultByConstNew: 1 (the level consumed by the rescaling
+//     of their output);
+//   - PowerOf2, Power and PowerNew: the depth of the exponentiation;
+//   - EvaluatePoly and EvaluateCheby: the depth of the polynomial, log2(degree+1).
+//
+// An operand is refreshed if its level is lower than minLevel+depth, and the output Ciphertext of the in-place
+// operations is reallocated if its level is lower than the one of the refreshed operands. The inputs are never
+// modified, so that a ciphertext used several times at a low level is refreshed each time: it should be refreshed
+// explicitly in this case. All the other methods are the ones of the wrapped Evaluator.
+type LevelGuardEvaluator struct {
+	Evaluator
+	minLevel int
+	refresh  RefreshFunc
+
+	// rescale, if not nil, is applied on the output of the operations of depth 1
+	rescale func(ct *Ciphertext)
+}
+
+// NewLevelGuardEvaluator creates a new LevelGuardEvaluator wrapping eval, which refreshes with refresh the
+// Ciphertext operands that would otherwise go below minLevel.
+func NewLevelGuardEvaluator(eval Evaluator, minLevel int, refresh RefreshFunc) *LevelGuardEvaluator {
+
+	if refresh == nil {
+		panic("cannot NewLevelGuardEvaluator: the RefreshFunc is nil")
+	}
+
+	if minLevel < 0 {
+		panic("cannot NewLevelGuardEvaluator: minLevel cannot be negative")
+	}
+
+	return &LevelGuardEvaluator{Evaluator: eval, minLevel: minLevel, refresh: refresh}
+}
+
+// MinLevel returns the minimum level below which the operands are refreshed.
+func (eval *LevelGuardEvaluator) MinLevel() int {
+	return eval.minLevel
+}
+
+// ShallowCopy creates a shallow copy of this LevelGuardEvaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers of the wrapped Evaluator are reallocated.
+func (eval *LevelGuardEvaluator) ShallowCopy() Evaluator {
+	return &LevelGuardEvaluator{Evaluator: eval.Evaluator.ShallowCopy(), minLevel: eval.minLevel, refresh: eval.refresh, rescale: eval.rescale}
+}
+
+// WithKey creates a shallow copy of this LevelGuardEvaluator in which the read-only data-structures are shared
+// with the receiver but the EvaluationKey of the wrapped Evaluator is evaluationKey.
+func (eval *LevelGuardEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return &LevelGuardEvaluator{Evaluator: eval.Evaluator.WithKey(evaluationKey), minLevel: eval.minLevel, refresh: eval.refresh, rescale: eval.rescale}
+}
+
+// WithRotationKeyProvider creates a shallow copy of this LevelGuardEvaluator in which the read-only
+// data-structures are shared with the receiver but the rotation keys of the wrapped Evaluator are provided by rtkp.
+func (eval *LevelGuardEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	return &LevelGuardEvaluator{Evaluator: eval.Evaluator.WithRotationKeyProvider(rtkp), minLevel: eval.minLevel, refresh: eval.refresh, rescale: eval.rescale}
+}
+
+// Mul multiplies op0 with op1 without relinearization, after refreshing them if needed, and returns the result
+// in ctOut.
+func (eval *LevelGuardEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.ensureLevels(op0, op1, 1)
+	growLevel(ctOut, utils.MinInt(op0.Level(), op1.Level()))
+	eval.Evaluator.Mul(op0, op1, ctOut)
+	eval.rescaleOutput(ctOut)
+}
+
+// MulNew multiplies op0 with op1 without relinearization, after refreshing them if needed, and returns the
+// result in a newly created element.
+func (eval *LevelGuardEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	op0, op1 = eval.ensureLevels(op0, op1, 1)
+	ctOut = eval.Evaluator.MulNew(op0, op1)
+	eval.rescaleOutput(ctOut)
+	return
+}
+
+// MulRelin multiplies op0 with op1 with relinearization, after refreshing them if needed, and returns the
+// result in ctOut.
+func (eval *LevelGuardEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.ensureLevels(op0, op1, 1)
+	growLevel(ctOut, utils.MinInt(op0.Level(), op1.Level()))
+	eval.Evaluator.MulRelin(op0, op1, ctOut)
+	eval.rescaleOutput(ctOut)
+}
+
+// MulRelinNew multiplies op0 with op1 with relinearization, after refreshing them if needed, and returns the
+// result in a newly created element.
+func (eval *LevelGuardEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	op0, op1 = eval.ensureLevels(op0, op1, 1)
+	ctOut = eval.Evaluator.MulRelinNew(op0, op1)
+	eval.rescaleOutput(ctOut)
+	return
+}
+
+// MultByConst multiplies ctIn by the input constant, after refreshing it if needed, and returns the result in
+// ctOut.
+func (eval *LevelGuardEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	ctIn = eval.ensureLevel(ctIn, 1).(*Ciphertext)
+	growLevel(ctOut, ctIn.Level())
+	eval.Evaluator.MultByConst(ctIn, constant, ctOut)
+	eval.rescaleOutput(ctOut)
+}
+
+// MultByConstNew multiplies ctIn by the input constant, after refreshing it if needed, and returns the result
+// in a newly created element.
+func (eval *LevelGuardEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	ctIn = eval.ensureLevel(ctIn, 1).(*Ciphertext)
+	ctOut = eval.Evaluator.MultByConstNew(ctIn, constant)
+	eval.rescaleOutput(ctOut)
+	return
+}
+
+// PowerOf2 computes ctIn^(2^logPow2), after refreshing ctIn if needed, and returns the result in ctOut.
+func (eval *LevelGuardEvaluator) PowerOf2(ctIn *Ciphertext, logPow2 int, ctOut *Ciphertext) {
+	ctIn = eval.ensureLevel(ctIn, logPow2).(*Ciphertext)
+	growLevel(ctOut, ctIn.Level())
+	eval.Evaluator.PowerOf2(ctIn, logPow2, ctOut)
+}
+
+// Power computes ctIn^degree, after refreshing ctIn if needed, and returns the result in ctOut.
+func (eval *LevelGuardEvaluator) Power(ctIn *Ciphertext, degree int, ctOut *Ciphertext) {
+	ctIn = eval.ensureLevel(ctIn, powerDepth(degree)).(*Ciphertext)
+	growLevel(ctOut, ctIn.Level())
+	eval.Evaluator.Power(ctIn, degree, ctOut)
+}
+
+// PowerNew computes ctIn^degree, after refreshing ctIn if needed, and returns the result in a newly created
+// element.
+func (eval *LevelGuardEvaluator) PowerNew(ctIn *Ciphertext, degree int) (ctOut *Ciphertext) {
+	return eval.Evaluator.PowerNew(eval.ensureLevel(ctIn, powerDepth(degree)).(*Ciphertext), degree)
+}
+
+// EvaluatePoly evaluates the polynomial in standard basis on ctIn, after refreshing it if needed.
+// See Evaluator.EvaluatePoly.
+func (eval *LevelGuardEvaluator) EvaluatePoly(ctIn *Ciphertext, coeffs *Poly, targetScale float64) (ctOut *Ciphertext, err error) {
+	return eval.Evaluator.EvaluatePoly(eval.ensureLevel(ctIn, bits.Len64(uint64(coeffs.Degree()))).(*Ciphertext), coeffs, targetScale)
+}
+
+// EvaluateCheby evaluates the polynomial in Chebyshev basis on ctIn, after refreshing it if needed.
+// See Evaluator.EvaluateCheby.
+func (eval *LevelGuardEvaluator) EvaluateCheby(ctIn *Ciphertext, cheby *ChebyshevInterpolation, targetScale float64) (ctOut *Ciphertext, err error) {
+	return eval.Evaluator.EvaluateCheby(eval.ensureLevel(ctIn, bits.Len64(uint64(cheby.Degree()))).(*Ciphertext), cheby, targetScale)
+}
+
+// ensureLevel returns op, or a refreshed copy of op if it is a Ciphertext whose level is lower than
+// minLevel+depth.
+func (eval *LevelGuardEvaluator) ensureLevel(op Operand, depth int) Operand {
+
+	ct, isCt := op.(*Ciphertext)
+	if !isCt || ct.Level() >= eval.minLevel+depth {
+		return op
+	}
+
+	ct = eval.refresh(ct, depth)
+
+	if ct.Level() < eval.minLevel+depth {
+		panic(fmt.Sprintf("cannot refresh: the operation requires level %d but the refreshed ciphertext is at level %d", eval.minLevel+depth, ct.Level()))
+	}
+
+	return ct
+}
+
+// ensureLevels applies ensureLevel on op0 and op1, refreshing only once an operand given twice.
+func (eval *LevelGuardEvaluator) ensureLevels(op0, op1 Operand, depth int) (Operand, Operand) {
+	if op0 == op1 {
+		op0 = eval.ensureLevel(op0, depth)
+		return op0, op0
+	}
+	return eval.ensureLevel(op0, depth), eval.ensureLevel(op1, depth)
+}
+
+// growLevel reallocates the polynomials of ctOut at the given level if ctOut is at a lower level, so that the
+// output of an operation on refreshed operands is not truncated to the level of ctOut.
+func growLevel(ctOut *Ciphertext, level int) {
+	if ctOut.Level() >= level {
+		return
+	}
+	for i := range ctOut.Value {
+		ctOut.Value[i] = ring.NewPoly(len(ctOut.Value[i].Coeffs[0]), level+1)
+	}
+}
+
+func (eval *LevelGuardEvaluator) rescaleOutput(ct *Ciphertext) {
+	if eval.rescale != nil {
+		eval.rescale(ct)
+	}
+}
+
+// powerDepth returns the number of levels consumed by Evaluator.Power for the given degree.
+func powerDepth(degree int) int {
+	return bits.Len64(uint64(degree)) + bits.OnesCount64(uint64(degree)) - 2
+}
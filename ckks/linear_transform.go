@@ -5,10 +5,66 @@ import (
 	"github.com/ldsec/lattigo/v2/utils"
 )
 
+// LinearTransformPrecomputed is a diagonalized plaintext matrix encoded once at each level of a range of levels, so that
+// it can be evaluated with Evaluator.LinearTransform on any number of ciphertexts at any of these levels without re-encoding
+// its diagonals. At each level, the diagonals are stored in the NTT domain and Montgomery form at the scale Q[level], so
+// that the rescaling following the evaluation brings the ciphertext back to its input scale.
+type LinearTransformPrecomputed struct {
+	MinLevel int
+	MaxLevel int
+	Matrices map[int]*PtDiagMatrix // Matrices[level] is the matrix encoded at this level
+}
+
+// NewLinearTransformPrecomputed encodes the diagonalized matrix at each level from minLevel to maxLevel for the naive approach
+// (see Encoder.EncodeDiagMatrixAtLvl). The memory footprint grows quadratically with the number of levels.
+func NewLinearTransformPrecomputed(params Parameters, encoder Encoder, diagMatrix map[int][]complex128, minLevel, maxLevel, logSlots int) *LinearTransformPrecomputed {
+	return newLinearTransformPrecomputed(params, minLevel, maxLevel, func(level int) *PtDiagMatrix {
+		return encoder.EncodeDiagMatrixAtLvl(level, diagMatrix, float64(params.Q()[level]), logSlots)
+	})
+}
+
+// NewLinearTransformPrecomputedBSGS encodes the diagonalized matrix at each level from minLevel to maxLevel for the baby-step
+// giant-step approach (see Encoder.EncodeDiagMatrixBSGSAtLvl). The memory footprint grows quadratically with the number of levels.
+func NewLinearTransformPrecomputedBSGS(params Parameters, encoder Encoder, diagMatrix map[int][]complex128, minLevel, maxLevel int, maxM1N2Ratio float64, logSlots int) *LinearTransformPrecomputed {
+	return newLinearTransformPrecomputed(params, minLevel, maxLevel, func(level int) *PtDiagMatrix {
+		return encoder.EncodeDiagMatrixBSGSAtLvl(level, diagMatrix, float64(params.Q()[level]), maxM1N2Ratio, logSlots)
+	})
+}
+
+func newLinearTransformPrecomputed(params Parameters, minLevel, maxLevel int, encode func(level int) *PtDiagMatrix) (lt *LinearTransformPrecomputed) {
+
+	if minLevel < 0 || minLevel > maxLevel || maxLevel > params.MaxLevel() {
+		panic("cannot NewLinearTransformPrecomputed: invalid range of levels")
+	}
+
+	lt = &LinearTransformPrecomputed{MinLevel: minLevel, MaxLevel: maxLevel, Matrices: make(map[int]*PtDiagMatrix)}
+	for level := minLevel; level <= maxLevel; level++ {
+		lt.Matrices[level] = encode(level)
+	}
+
+	return
+}
+
+// Matrix returns the matrix to evaluate on a ciphertext at the given level. Ciphertexts above MaxLevel are
+// evaluated with the matrix at MaxLevel, and the output is at MaxLevel.
+func (lt *LinearTransformPrecomputed) Matrix(level int) *PtDiagMatrix {
+	if level < lt.MinLevel {
+		panic("cannot LinearTransform: ciphertext level is below the minimum level of the LinearTransformPrecomputed")
+	}
+	return lt.Matrices[utils.MinInt(level, lt.MaxLevel)]
+}
+
+// Rotations returns the rotations needed to evaluate the linear transform.
+func (lt *LinearTransformPrecomputed) Rotations(params Parameters) []int {
+	return params.RotationsForDiagMatrixMult(lt.Matrices[lt.MaxLevel])
+}
+
 // RotateHoisted takes an input Ciphertext and a list of rotations and returns a map of Ciphertext, where each element of the map is the input Ciphertext
 // rotation by one element of the list. It is much faster than sequential calls to Rotate.
 func (eval *evaluator) RotateHoisted(ctIn *Ciphertext, rotations []int) (cOut map[int]*Ciphertext) {
 
+	eval.allocateHoistingPools(eval.evaluatorBase)
+
 	level := ctIn.Level()
 
 	eval.DecompInternal(level, ctIn.Value[1], eval.c2QiQDecomp, eval.c2QiPDecomp)
@@ -21,6 +77,9 @@ func (eval *evaluator) RotateHoisted(ctIn *Ciphertext, rotations []int) (cOut ma
 		} else {
 			cOut[i] = NewCiphertext(eval.params, 1, level, ctIn.Scale())
 			eval.permuteNTTHoisted(level, ctIn.Value[0], ctIn.Value[1], eval.c2QiQDecomp, eval.c2QiPDecomp, i, cOut[i].Value[0], cOut[i].Value[1])
+			cOut[i].MetaData.IsReal = ctIn.MetaData.IsReal
+			cOut[i].MetaData.SlotScales = rotateSlotScales(ctIn.MetaData.SlotScales, i)
+			cOut[i].MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
 		}
 	}
 
@@ -28,11 +87,13 @@ func (eval *evaluator) RotateHoisted(ctIn *Ciphertext, rotations []int) (cOut ma
 }
 
 // LinearTransform evaluates a linear transform on the ciphertext. The linearTransform can either be an (ordered) list of
-// PtDiagMatrix or a single PtDiagMatrix. In either case a list of ciphertext is return (the second case returnign a list of
-// containing a single ciphertext. A PtDiagMatrix is a diagonalized plaintext matrix contructed with an Encoder using
-// the method encoder.EncodeDiagMatrixAtLvl(*).
+// PtDiagMatrix, a single PtDiagMatrix or a LinearTransformPrecomputed. In either case a list of ciphertext is return (the
+// last two cases returning a list containing a single ciphertext). A PtDiagMatrix is a diagonalized plaintext matrix
+// contructed with an Encoder using the method encoder.EncodeDiagMatrixAtLvl(*).
 func (eval *evaluator) LinearTransform(ctIn *Ciphertext, linearTransform interface{}) (ctOut []*Ciphertext) {
 
+	eval.allocateHoistingPools(eval.evaluatorBase)
+
 	switch element := linearTransform.(type) {
 	case []*PtDiagMatrix:
 		ctOut = make([]*Ciphertext, len(element))
@@ -56,6 +117,10 @@ func (eval *evaluator) LinearTransform(ctIn *Ciphertext, linearTransform interfa
 			}
 		}
 
+	case *LinearTransformPrecomputed:
+
+		ctOut = eval.LinearTransform(ctIn, element.Matrix(ctIn.Level()))
+
 	case *PtDiagMatrix:
 
 		minLevel := utils.MinInt(element.Level, ctIn.Level())
@@ -79,11 +144,19 @@ func (eval *evaluator) LinearTransform(ctIn *Ciphertext, linearTransform interfa
 // This method is faster than InnerSum when the number of rotations is large and uses log2(n) + HW(n) insteadn of 'n' keys.
 func (eval *evaluator) InnerSumLog(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
 
+	eval.allocateHoistingPools(eval.evaluatorBase)
+
+	checkNoSlotScales("InnerSumLog", ctIn.El())
+
 	ringQ := eval.ringQ
 	ringP := eval.ringP
 
 	levelQ := ctIn.Level()
 
+	// Rotations and additions preserve a purely real message
+	ctOut.MetaData.IsReal = ctIn.MetaData.IsReal
+	ctOut.MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
+
 	//QiOverF := eval.params.QiOverflowMargin(levelQ)
 	//PiOverF := eval.params.PiOverflowMargin()
 
@@ -158,9 +231,9 @@ func (eval *evaluator) InnerSumLog(ctIn *Ciphertext, batchSize, n int, ctOut *Ci
 					}
 
 					if i == 0 {
-						ring.PermuteNTTWithIndexLvl(levelQ, ctIn.Value[0], eval.permuteNTTIndex[eval.params.GaloisElementForColumnRotationBy(k)], tmpc2)
+						ring.PermuteNTTWithIndexLvl(levelQ, ctIn.Value[0], eval.permuteNTTIndexFor(eval.params.GaloisElementForColumnRotationBy(k)), tmpc2)
 					} else {
-						ring.PermuteNTTWithIndexLvl(levelQ, tmpc0, eval.permuteNTTIndex[eval.params.GaloisElementForColumnRotationBy(k)], tmpc2)
+						ring.PermuteNTTWithIndexLvl(levelQ, tmpc0, eval.permuteNTTIndexFor(eval.params.GaloisElementForColumnRotationBy(k)), tmpc2)
 					}
 
 					ringQ.MulScalarBigintLvl(levelQ, tmpc2, ringP.ModulusBigint, tmpc2)
@@ -210,11 +283,19 @@ func (eval *evaluator) InnerSumLog(ctIn *Ciphertext, batchSize, n int, ctOut *Ci
 // This method is faster than InnerSumLog when the number of rotations is small but uses 'n' keys instead of log(n) + HW(n).
 func (eval *evaluator) InnerSum(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphertext) {
 
+	eval.allocateHoistingPools(eval.evaluatorBase)
+
+	checkNoSlotScales("InnerSum", ctIn.El())
+
 	ringQ := eval.ringQ
 	ringP := eval.ringP
 
 	levelQ := ctIn.Level()
 
+	// Rotations and additions preserve a purely real message
+	ctOut.MetaData.IsReal = ctIn.MetaData.IsReal
+	ctOut.MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
+
 	QiOverF := eval.params.QiOverflowMargin(levelQ) >> 1
 	PiOverF := eval.params.PiOverflowMargin() >> 1
 
@@ -259,12 +340,12 @@ func (eval *evaluator) InnerSum(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphe
 
 				galEl := eval.params.GaloisElementForColumnRotationBy(i)
 
-				_, generated := eval.rtks.Keys[galEl]
+				_, generated := eval.rtks.GetRotationKey(galEl)
 				if !generated {
 					panic("switching key not available")
 				}
 
-				index := eval.permuteNTTIndex[galEl]
+				index := eval.permuteNTTIndexFor(galEl)
 
 				ring.PermuteNTTWithIndexLvl(levelQ, tmpQ0, index, tmpQ1)  // phi(P*c0)
 				ringQ.AddLvl(levelQ, vecRotQ[i][0], tmpQ1, vecRotQ[i][0]) // phi(d0_Q) += phi(P*c0)
@@ -344,17 +425,104 @@ func (eval *evaluator) Replicate(ctIn *Ciphertext, batchSize, n int, ctOut *Ciph
 	eval.InnerSum(ctIn, -batchSize, n, ctOut)
 }
 
+// GroupLayout specifies where the result of a reduction over groups of sub-vectors is stored.
+type GroupLayout int
+
+const (
+	// GroupGathered stores the result of each group in the first sub-vector of the group and zeroes the other sub-vectors.
+	GroupGathered GroupLayout = iota
+	// GroupReplicated stores the result of each group in all the sub-vectors of the group.
+	GroupReplicated
+)
+
+// InnerSumGroups splits the slots of `ctIn` in groups of `n` consecutive sub-vectors of size `batch` and sums the
+// sub-vectors of each group (in parallel). The result of each group is stored according to `layout`, and the slots
+// that do not hold a result are set to zero. `n`*`batch` must divide the number of slots.
+// The operation uses log2(n) + HW(n) rotations (twice for GroupReplicated, see RotationsForInnerSumGroups) and
+// consumes one level to isolate the result of each group. The scale of the output is the scale of the input.
+func (eval *evaluator) InnerSumGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext) {
+	eval.reduceGroups(ctIn, batch, n, layout, 1, ctOut)
+}
+
+// AverageGroups is identical to InnerSumGroups, but divides the result of each group by `n`.
+func (eval *evaluator) AverageGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext) {
+	eval.reduceGroups(ctIn, batch, n, layout, 1/float64(n), ctOut)
+}
+
+func (eval *evaluator) reduceGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, factor float64, ctOut *Ciphertext) {
+
+	checkGroups(eval.params, batch, n, layout)
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	if level == 0 {
+		panic("cannot reduceGroups: input Ciphertext already at level 0")
+	}
+
+	// The sum of each group is in its first sub-vector
+	eval.InnerSumLog(ctIn, batch, n, ctOut)
+
+	// Multiplies by the mask factor * [1, 0, ..., 0] of each group at the scale of the current modulus,
+	// such that the rescaling preserves the scale of the input
+	mask := make([]complex128, eval.params.Slots())
+	for i := 0; i < len(mask); i += batch * n {
+		for j := 0; j < batch; j++ {
+			mask[i+j] = complex(factor, 0)
+		}
+	}
+
+	pt := NewPlaintext(eval.params, level, float64(eval.params.Q()[level]))
+	NewEncoder(eval.params).EncodeNTT(pt, mask, eval.params.LogSlots())
+
+	scale := ctIn.Scale()
+	isReal := ctIn.MetaData.IsReal
+
+	eval.Mul(ctOut, pt, ctOut)
+
+	if err := eval.Rescale(ctOut, scale, ctOut); err != nil {
+		panic(err)
+	}
+
+	if layout == GroupReplicated {
+		eval.ReplicateLog(ctOut, batch, n, ctOut)
+	}
+
+	ctOut.MetaData.IsReal = isReal
+}
+
+// checkGroups panics if the groups of n sub-vectors of size batch do not partition the slots.
+func checkGroups(params Parameters, batch, n int, layout GroupLayout) {
+
+	if batch < 1 || n < 1 {
+		panic("cannot reduceGroups: batch and n must be positive")
+	}
+
+	if params.Slots()%(batch*n) != 0 {
+		panic("cannot reduceGroups: batch * n does not divide the number of slots")
+	}
+
+	if layout != GroupGathered && layout != GroupReplicated {
+		panic("cannot reduceGroups: invalid layout")
+	}
+}
+
 // MultiplyByDiagMatrix multiplies the ciphertext "ctIn" by the plaintext matrix "matrix" and returns the result on the ciphertext
 // "ctOut". Memory pools for the decomposed ciphertext c2QiQDecomp, c2QiPDecomp must be provided, those are list of poly of ringQ and ringP
-// respectively, each of size params.Beta().
+// respectively, each of size params.Beta() (or the number of digits of the gadget of NewEvaluatorWithGadget).
 // The naive approach is used (single hoisting and no baby-step giant-step), which is faster than MultiplyByDiagMatrixBSGS
 // for matrix of only a few non-zero diagonals but uses more keys.
 func (eval *evaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix *PtDiagMatrix, c2QiQDecomp, c2QiPDecomp []*ring.Poly, ctOut *Ciphertext) {
 
+	checkNoSlotScales("MultiplyByDiagMatrix", ctIn.El())
+
 	ringQ := eval.ringQ
 	ringP := eval.ringP
 
 	levelQ := utils.MinInt(ctOut.Level(), utils.MinInt(ctIn.Level(), matrix.Level))
+
+	// The encoded diagonals are not tracked, the output is assumed to be complex
+	ctOut.MetaData.IsReal = false
+	ctOut.MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
 	levelP := eval.params.PCount() - 1
 
 	QiOverF := eval.params.QiOverflowMargin(levelQ)
@@ -387,12 +555,12 @@ func (eval *evaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix *PtDiagMatr
 
 			galEl := eval.params.GaloisElementForColumnRotationBy(k)
 
-			rtk, generated := eval.rtks.Keys[galEl]
+			rtk, generated := eval.rtks.GetRotationKey(galEl)
 			if !generated {
 				panic("switching key not available")
 			}
 
-			index := eval.permuteNTTIndex[galEl]
+			index := eval.permuteNTTIndexFor(galEl)
 
 			eval.keyswitchHoistedNoModDown(levelQ, c2QiQDecomp, c2QiPDecomp, rtk, ksResQ0, ksResQ1, ksResP0, ksResP1)
 
@@ -458,11 +626,15 @@ func (eval *evaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix *PtDiagMatr
 
 // MultiplyByDiagMatrixBSGS multiplies the ciphertext "ctIn" by the plaintext matrix "matrix" and returns the result on the ciphertext
 // "ctOut". Memory pools for the decomposed ciphertext c2QiQDecomp, c2QiPDecomp must be provided, those are list of poly of ringQ and ringP
-// respectively, each of size params.Beta().
+// respectively, each of size params.Beta() (or the number of digits of the gadget of NewEvaluatorWithGadget).
 // The BSGS approach is used (double hoisting with baby-step giant-step), which is faster than MultiplyByDiagMatrix
 // for matrix with more than a few non-zero diagonals and uses much less keys.
 func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix *PtDiagMatrix, c2QiQDecomp, c2QiPDecomp []*ring.Poly, ctOut *Ciphertext) {
 
+	eval.allocateHoistingPools(eval.evaluatorBase)
+
+	checkNoSlotScales("MultiplyByDiagMatrixBSGS", ctIn.El())
+
 	// N1*N2 = N
 	N1 := matrix.N1
 
@@ -470,6 +642,10 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix *PtDiag
 	ringP := eval.ringP
 
 	levelQ := utils.MinInt(ctOut.Level(), utils.MinInt(ctIn.Level(), matrix.Level))
+
+	// The encoded diagonals are not tracked, the output is assumed to be complex
+	ctOut.MetaData.IsReal = false
+	ctOut.MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
 	levelP := eval.params.PCount() - 1
 
 	QiOverF := eval.params.QiOverflowMargin(levelQ)
@@ -513,12 +689,12 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix *PtDiag
 
 			galEl := eval.params.GaloisElementForColumnRotationBy(i)
 
-			_, generated := eval.rtks.Keys[galEl]
+			_, generated := eval.rtks.GetRotationKey(galEl)
 			if !generated {
 				panic("switching key not available")
 			}
 
-			index := eval.permuteNTTIndex[galEl]
+			index := eval.permuteNTTIndexFor(galEl)
 
 			ring.PermuteNTTWithIndexLvl(levelQ, tmpQ0, index, tmpQ1)  // phi(P*c0)
 			ringQ.AddLvl(levelQ, vecRotQ[i][0], tmpQ1, vecRotQ[i][0]) // phi(d0_Q) += phi(P*c0)
@@ -602,12 +778,12 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix *PtDiag
 
 			galEl := eval.params.GaloisElementForColumnRotationBy(N1 * j)
 
-			rtk, generated := eval.rtks.Keys[galEl]
+			rtk, generated := eval.rtks.GetRotationKey(galEl)
 			if !generated {
 				panic("switching key not available")
 			}
 
-			index := eval.permuteNTTIndex[galEl]
+			index := eval.permuteNTTIndexFor(galEl)
 
 			eval.SwitchKeysInPlaceNoModDown(levelQ, tmpQ1, rtk, pool2Q, pool2P, pool3Q, pool3P) // Switchkey(phi(tmpRes_1)) = (d0, d1) in base QP
 
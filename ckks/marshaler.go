@@ -1,11 +1,13 @@
 package ckks
 
 import (
+	"encoding"
 	"encoding/binary"
 	"errors"
 	"math"
 
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
 // GetDataLen returns the length in bytes of the target Ciphertext.
@@ -14,8 +16,12 @@ func (ciphertext *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 	// 1 byte : Degree
 	// 9 byte : Scale
 	// 1 byte : isNTT
+	// and, if the ciphertext has Metadata, their encoding after the polynomials
 	if WithMetaData {
 		dataLen += 11
+		if len(ciphertext.MetaData.Tags) != 0 {
+			dataLen += ciphertext.MetaData.Tags.GetDataLen()
+		}
 	}
 
 	for _, el := range ciphertext.Value {
@@ -26,7 +32,7 @@ func (ciphertext *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 }
 
 // MarshalBinary encodes a Ciphertext on a byte slice. The total size
-// in byte is 4 + 8* N * numberModuliQ * (degree + 1).
+// in byte is 4 + 8* N * numberModuliQ * (degree + 1), plus the size of the Metadata of the ciphertext, if any.
 func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
 
 	data = make([]byte, ciphertext.GetDataLen(true))
@@ -52,23 +58,81 @@ func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
 		pointer += inc
 	}
 
+	if len(ciphertext.MetaData.Tags) != 0 {
+		data[9] = 1
+		if _, err = ciphertext.MetaData.Tags.WriteTo(data[pointer:]); err != nil {
+			return nil, err
+		}
+	}
+
 	return data, nil
 }
 
+// MarshalVersioned encodes obj, a Ciphertext or a key of the parameters params, on a slice of bytes prefixed by its
+// rlwe.Header, whose content can be inspected with rlwe.Peek.
+func MarshalVersioned(params Parameters, obj encoding.BinaryMarshaler) (data []byte, err error) {
+
+	var h rlwe.Header
+	if h, err = newHeader(params, obj); err != nil {
+		return nil, err
+	}
+
+	return rlwe.MarshalWithHeader(h, obj)
+}
+
+// UnmarshalVersioned decodes data, previously marshaled with MarshalVersioned, on obj, a Ciphertext or a key of the
+// parameters params, and returns its rlwe.Header. Returns an error, without decoding the object, if data were
+// marshaled with an unsupported version, or for another scheme, type of object or parameters.
+func UnmarshalVersioned(params Parameters, data []byte, obj encoding.BinaryUnmarshaler) (h rlwe.Header, err error) {
+
+	var want rlwe.Header
+	if want, err = newHeader(params, obj); err != nil {
+		return want, err
+	}
+
+	return rlwe.UnmarshalWithHeader(data, want, obj)
+}
+
+// newHeader returns the rlwe.Header of obj, a Ciphertext or a key of the parameters params.
+func newHeader(params Parameters, obj interface{}) (h rlwe.Header, err error) {
+
+	var hash [8]byte
+	if hash, err = rlwe.ParametersHash(params); err != nil {
+		return h, err
+	}
+
+	if ciphertext, ok := obj.(*Ciphertext); ok {
+
+		if ciphertext.Ciphertext == nil || ciphertext.Element == nil {
+			return rlwe.NewHeader(rlwe.SchemeCKKS, hash, new(rlwe.Element))
+		}
+
+		if h, err = rlwe.NewHeader(rlwe.SchemeCKKS, hash, ciphertext.Element); err != nil {
+			return h, err
+		}
+
+		h.Scale = ciphertext.Scale()
+
+		return h, nil
+	}
+
+	return rlwe.NewHeader(rlwe.SchemeCKKS, hash, obj)
+}
+
 // UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
 func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
 	if len(data) < 11 { // cf. ciphertext.GetDataLen()
 		return errors.New("too small bytearray")
 	}
 
-	ciphertext.Element = new(Element)
+	ciphertext.Ciphertext = &rlwe.Ciphertext{Element: new(rlwe.Element), MetaData: rlwe.MetaData{Scheme: rlwe.SchemeCKKS}}
 
 	ciphertext.Value = make([]*ring.Poly, uint8(data[0]))
 
-	ciphertext.scale = math.Float64frombits(binary.LittleEndian.Uint64(data[1:9]))
+	ciphertext.MetaData.Scale = math.Float64frombits(binary.LittleEndian.Uint64(data[1:9]))
 
 	if uint8(data[10]) == 1 {
-		ciphertext.Element.Element.IsNTT = true
+		ciphertext.Element.IsNTT = true
 	}
 
 	var pointer, inc int
@@ -85,6 +149,13 @@ func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
 		pointer += inc
 	}
 
+	if uint8(data[9]) == 1 {
+		if inc, err = ciphertext.MetaData.Tags.Decode(data[pointer:]); err != nil {
+			return err
+		}
+		pointer += inc
+	}
+
 	if pointer != len(data) {
 		return errors.New("remaining unparsed data")
 	}
@@ -0,0 +1,99 @@
+package ckks
+
+import (
+	"fmt"
+)
+
+// MaskSlotsNew multiplies the slots of ctIn by the 0/1 vector mask, zeroing the slots i for which mask[i] is false,
+// and returns the result in a newly created element. See MaskSlots.
+func (eval *evaluator) MaskSlotsNew(ctIn *Ciphertext, mask []bool) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+	return ctOut, eval.MaskSlots(ctIn, mask, ctOut)
+}
+
+// MaskSlots multiplies the slots of ctIn by the 0/1 vector mask, zeroing the slots i for which mask[i] is false
+// (as well as the slots i >= len(mask)), and returns the result in ctOut. The mask is encoded on Params.LogSlots()
+// slots with a scale equal to the last modulus of the ciphertext, so that the operation consumes exactly one level
+// and preserves the scale of ctIn. Returns an error if ctIn is at level 0 or if the mask is longer than the
+// number of slots.
+func (eval *evaluator) MaskSlots(ctIn *Ciphertext, mask []bool, ctOut *Ciphertext) (err error) {
+	return evalMaskSlots(eval, eval.params, ctIn, mask, ctOut)
+}
+
+// ExtractSlotRangeNew keeps the slots [start, end) of ctIn, zeroes the other slots and rotates the result so that
+// the range starts at the slot 0, and returns the result in a newly created element. See ExtractSlotRange.
+func (eval *evaluator) ExtractSlotRangeNew(ctIn *Ciphertext, start, end int) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+	return ctOut, eval.ExtractSlotRange(ctIn, start, end, ctOut)
+}
+
+// ExtractSlotRange keeps the slots [start, end) of ctIn, zeroes the other slots and rotates the result by start
+// positions to the left, so that the range starts at the slot 0, and returns the result in ctOut. The operation
+// consumes one level and needs the rotation key for start if start is not zero (see
+// Parameters.RotationsForExtractSlotRange). Returns an error if ctIn is at level 0 or if the range is not a non-empty
+// range of [0, Params.Slots()).
+func (eval *evaluator) ExtractSlotRange(ctIn *Ciphertext, start, end int, ctOut *Ciphertext) (err error) {
+	return evalExtractSlotRange(eval, eval.params, ctIn, start, end, ctOut)
+}
+
+// evalMaskSlots implements MaskSlots on top of the Evaluator interface, so that it is shared by the evaluator
+// and the cleartext evaluator.
+func evalMaskSlots(eval Evaluator, params Parameters, ctIn *Ciphertext, mask []bool, ctOut *Ciphertext) (err error) {
+
+	checkNoSlotScales("MaskSlots", ctIn.El())
+
+	if len(mask) > params.Slots() {
+		return fmt.Errorf("cannot MaskSlots: the mask has %d values but the parameters have %d slots", len(mask), params.Slots())
+	}
+
+	level := ctIn.Level()
+	if ctOut.Level() < level {
+		level = ctOut.Level()
+	}
+
+	if level == 0 {
+		return fmt.Errorf("cannot MaskSlots: the ciphertext is at level 0")
+	}
+
+	values := make([]complex128, params.Slots())
+	for i, keep := range mask {
+		if keep {
+			values[i] = 1
+		}
+	}
+
+	// Encoding the mask with a scale equal to q_level makes the rescaling exact.
+	pt := NewPlaintext(params, level, float64(params.Q()[level]))
+	NewEncoder(params).EncodeNTT(pt, values, params.LogSlots())
+
+	// ctIn and ctOut can be the same element
+	scale := ctIn.Scale()
+
+	eval.Mul(ctIn, pt, ctOut)
+
+	return eval.Rescale(ctOut, scale, ctOut)
+}
+
+// evalExtractSlotRange implements ExtractSlotRange on top of the Evaluator interface, so that it is shared by the
+// evaluator and the cleartext evaluator.
+func evalExtractSlotRange(eval Evaluator, params Parameters, ctIn *Ciphertext, start, end int, ctOut *Ciphertext) (err error) {
+
+	if start < 0 || end > params.Slots() || start >= end {
+		return fmt.Errorf("cannot ExtractSlotRange: [%d, %d) is not a non-empty range of [0, %d)", start, end, params.Slots())
+	}
+
+	mask := make([]bool, end)
+	for i := start; i < end; i++ {
+		mask[i] = true
+	}
+
+	if err = evalMaskSlots(eval, params, ctIn, mask, ctOut); err != nil {
+		return err
+	}
+
+	if start != 0 {
+		eval.Rotate(ctOut, start, ctOut)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package ckks
+
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Metadata is a set of tags, as key-value pairs, attached to a Ciphertext or a Plaintext, e.g. to track the provenance
+// of a ciphertext (feature name, batch id) through a pipeline without side tables keyed by pointer. The metadata are
+// not encrypted and are not authenticated. They are stored in the Tags of the rlwe.MetaData of the element.
+//
+// The metadata are propagated by the Encryptor (from the plaintext to the ciphertext), the Decryptor (from the
+// ciphertext to the plaintext), the serialization of the ciphertexts, the rlwe.Ciphertext conversions and the
+// operations of the Evaluator: the result of an operation carries the union of the metadata of its operands,
+// plaintexts included, the first operand taking precedence if they have different values for the same key. The
+// cleartext Evaluator does not propagate them, and the compressed ciphertexts (see Evaluator.CompressNew) do not
+// carry them.
+type Metadata = rlwe.Tags
+
+// mergeMetadata returns the union of the metadata a and b, a taking precedence on the keys of both.
+func mergeMetadata(a, b Metadata) Metadata {
+	if len(b) == 0 {
+		return a.CopyNew()
+	}
+	md := b.CopyNew()
+	for k, v := range a {
+		md[k] = v
+	}
+	return md
+}
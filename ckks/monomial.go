@@ -0,0 +1,73 @@
+package ckks
+
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// MultByMonomial multiplies ctIn by the monomial X^k and returns the result in ctOut. It is equivalent to
+// RotateBy(ctIn, rlwe.CoeffShift(k), ctOut): the operation does not require any key and does not add noise, and on
+// a plaintext packed in the coefficients (see Encoder.EncodeCoeffs) it is the negacyclic shift of the coefficients
+// by k positions (see NegacyclicShift). A negative k shifts the coefficients toward the lower degrees.
+func (eval *evaluator) MultByMonomial(ctIn *Ciphertext, k int, ctOut *Ciphertext) {
+	eval.RotateBy(ctIn, rlwe.CoeffShift(k), ctOut)
+}
+
+// MultByMonomialNew multiplies ctIn by the monomial X^k and returns the result in a newly created element. See
+// MultByMonomial.
+func (eval *evaluator) MultByMonomialNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext) {
+	return eval.RotateByNew(ctIn, rlwe.CoeffShift(k))
+}
+
+// NegacyclicShift returns the coefficients of the multiplication by X^k of the polynomial of coefficients values in
+// Z[X]/(X^n+1), where n = len(values): the coefficient i+k of the output is the coefficient i of the input, with a
+// sign change for each wrap around X^n = -1. A negative k shifts the coefficients toward the lower degrees. It is
+// the cleartext counterpart of Evaluator.MultByMonomial on the N coefficients of a plaintext encoded with
+// Encoder.EncodeCoeffs.
+func NegacyclicShift(values []float64, k int) (shifted []float64) {
+
+	n := len(values)
+	shifted = make([]float64, n)
+
+	if n == 0 {
+		return
+	}
+
+	// X^(2n) = 1
+	k %= 2 * n
+	if k < 0 {
+		k += 2 * n
+	}
+
+	for i, v := range values {
+		j := i + k
+		for j >= n {
+			j -= n
+			v = -v
+		}
+		shifted[j] = v
+	}
+
+	return
+}
+
+// NegacyclicConvolution returns the coefficients of the product of the polynomials of coefficients a and b in
+// Z[X]/(X^n+1), where n = len(a) = len(b), i.e. the sum of the negacyclic shifts of a by j scaled by b[j]. It is
+// the cleartext counterpart of a convolution evaluated as a sum of MultByMonomial on coefficient-encoded data.
+func NegacyclicConvolution(a, b []float64) (c []float64) {
+
+	if len(a) != len(b) {
+		panic("cannot NegacyclicConvolution: the polynomials must have the same degree")
+	}
+
+	c = make([]float64, len(a))
+	for j, bj := range b {
+		if bj == 0 {
+			continue
+		}
+		for i, v := range NegacyclicShift(a, j) {
+			c[i] += bj * v
+		}
+	}
+
+	return
+}
@@ -0,0 +1,90 @@
+package ckks
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// ParameterPair is a pair of CKKS parameters sharing their first moduli: a Large set with a large ring degree,
+// typically bootstrappable, and a Small set with a smaller ring degree, on which the operations are cheaper.
+// Ciphertexts can be moved across the two rings with a RingSwitcher, so that most of a circuit can be evaluated
+// in the small ring and the large ring is only used when needed (e.g. for the bootstrapping).
+//
+// The ring switching maps a message of at most Small.MaxSlots() slots of the large ring onto the same slots of
+// the small ring, and conversely. Messages with more slots cannot be represented in the small ring.
+type ParameterPair struct {
+	Large Parameters
+	Small Parameters
+}
+
+// NewParameterPair creates a new ParameterPair from the large and small parameters. It returns an error if
+// the ring degree of small is not smaller than the one of large, or if the moduli Q of small are not the first
+// moduli Q of large.
+func NewParameterPair(large, small Parameters) (pp ParameterPair, err error) {
+
+	if err = rlwe.CheckRingSwitching(large.Parameters, small.Parameters); err != nil {
+		return ParameterPair{}, fmt.Errorf("cannot NewParameterPair: %w", err)
+	}
+
+	return ParameterPair{Large: large, Small: small}, nil
+}
+
+// MaxLevel returns the maximum level of the ciphertexts that can be moved across the two rings.
+func (pp ParameterPair) MaxLevel() int {
+	return pp.Small.MaxLevel()
+}
+
+// RingSwitchingKeys are the key bridges between the secret keys of the two rings of a ParameterPair.
+// Both are switching keys of the large parameters.
+type RingSwitchingKeys = rlwe.RingSwitchingKeys
+
+// GenRingSwitchingKeys generates the key bridges between the secret key skLarge of the large parameters and
+// the secret key skSmall of the small parameters.
+func (pp ParameterPair) GenRingSwitchingKeys(skLarge, skSmall *rlwe.SecretKey) (rsk *RingSwitchingKeys) {
+	return rlwe.GenRingSwitchingKeys(pp.Large.Parameters, pp.Small.Parameters, skLarge, skSmall)
+}
+
+// RingSwitcher moves ciphertexts across the two rings of a ParameterPair with its RingSwitchingKeys.
+// A RingSwitcher is not safe for concurrent use.
+type RingSwitcher struct {
+	pp ParameterPair
+	*rlwe.RingSwitcher
+}
+
+// NewRingSwitcher creates a new RingSwitcher for the parameter pair pp and the key bridges keys.
+func NewRingSwitcher(pp ParameterPair, keys *RingSwitchingKeys) *RingSwitcher {
+	return &RingSwitcher{pp: pp, RingSwitcher: rlwe.NewRingSwitcher(pp.Large.Parameters, pp.Small.Parameters, keys)}
+}
+
+// ToSmallNew moves ctLarge, a ciphertext of the large parameters, to the small ring and returns the result in a
+// newly created ciphertext of the small parameters. The message of ctLarge must have at most Small.MaxSlots()
+// slots. The output is at the level of ctLarge, or at the maximum level of the small parameters if lower.
+func (rs *RingSwitcher) ToSmallNew(ctLarge *Ciphertext) (ctSmall *Ciphertext) {
+
+	level := ctLarge.Level()
+	if level > rs.pp.MaxLevel() {
+		level = rs.pp.MaxLevel()
+	}
+
+	ctSmall = NewCiphertext(rs.pp.Small, 1, level, ctLarge.Scale())
+	ctSmall.MetaData.IsReal = ctLarge.MetaData.IsReal
+	ctSmall.MetaData.Tags = ctLarge.MetaData.Tags.CopyNew()
+
+	rs.SwitchDown(ctLarge.Element, ctSmall.Element)
+
+	return
+}
+
+// ToLargeNew moves ctSmall, a ciphertext of the small parameters, to the large ring and returns the result in a
+// newly created ciphertext of the large parameters, at the same level, whose message has the same slots.
+func (rs *RingSwitcher) ToLargeNew(ctSmall *Ciphertext) (ctLarge *Ciphertext) {
+
+	ctLarge = NewCiphertext(rs.pp.Large, 1, ctSmall.Level(), ctSmall.Scale())
+	ctLarge.MetaData.IsReal = ctSmall.MetaData.IsReal
+	ctLarge.MetaData.Tags = ctSmall.MetaData.Tags.CopyNew()
+
+	rs.SwitchUp(ctSmall.Element, ctLarge.Element)
+
+	return
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"strings"
 
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
@@ -165,6 +166,21 @@ var DefaultParams = []ParametersLiteral{PN12QP109, PN13QP218, PN14QP438, PN15QP8
 // DefaultPostQuantumParams is a set of default CKKS parameters ensuring 128 bit security in a post-quantum setting.
 var DefaultPostQuantumParams = []ParametersLiteral{PN12QP101pq, PN13QP202pq, PN14QP411pq, PN15QP827pq, PN16QP1654pq}
 
+// NamedParams are the default parameter sets indexed by their name, which can be referenced in the JSON
+// representation of the parameters (see Parameters.UnmarshalJSON). Applications can add their own parameter sets.
+var NamedParams = map[string]ParametersLiteral{
+	"PN12QP109":    PN12QP109,
+	"PN13QP218":    PN13QP218,
+	"PN14QP438":    PN14QP438,
+	"PN15QP880":    PN15QP880,
+	"PN16QP1761":   PN16QP1761,
+	"PN12QP101pq":  PN12QP101pq,
+	"PN13QP202pq":  PN13QP202pq,
+	"PN14QP411pq":  PN14QP411pq,
+	"PN15QP827pq":  PN15QP827pq,
+	"PN16QP1654pq": PN16QP1654pq,
+}
+
 // Parameters represents a parameter set for the CKKS cryptosystem. Its fields are private and
 // immutable. See ParametersLiteral for user-specified parameters.
 type Parameters struct {
@@ -277,6 +293,24 @@ func (p Parameters) RotationsForInnerSumLog(batch, n int) (rotations []int) {
 	return
 }
 
+// RotationsForInnerSumGroups generates the rotations that will be performed by the
+// `Evaluator.InnerSumGroups` and `Evaluator.AverageGroups` operations when performed with parameters
+// `batch`, `n` and `layout`.
+func (p Parameters) RotationsForInnerSumGroups(batch, n int, layout GroupLayout) (rotations []int) {
+
+	rotations = p.RotationsForInnerSumLog(batch, n)
+
+	if layout == GroupReplicated {
+		for _, k := range p.RotationsForReplicateLog(batch, n) {
+			if !utils.IsInSliceInt(k, rotations) {
+				rotations = append(rotations, k)
+			}
+		}
+	}
+
+	return
+}
+
 // RotationsForReplicate generates the rotations that will be performed by the
 // `Evaluator.Replicate` operation when performed with parameters `batch` and `n`.
 func (p Parameters) RotationsForReplicate(batch, n int) (rotations []int) {
@@ -289,8 +323,38 @@ func (p Parameters) RotationsForReplicateLog(batch, n int) (rotations []int) {
 	return p.RotationsForInnerSumLog(-batch, n)
 }
 
+// RotationsForExtractSlotRange generates the rotations that will be performed by the
+// `Evaluator.ExtractSlotRange` operation when performed with parameters `start` and `end`.
+func (p Parameters) RotationsForExtractSlotRange(start, end int) (rotations []int) {
+	rotations = []int{}
+	if start != 0 {
+		rotations = append(rotations, start)
+	}
+	return
+}
+
+// RotationsForVectorRotate generates the rotations that will be performed by the
+// `VectorEvaluator.Rotate` operation when rotating a vector of the given length by k.
+func (p Parameters) RotationsForVectorRotate(length, k int) (rotations []int) {
+	rotations = []int{}
+	for _, terms := range vectorRotationTerms(p.Slots(), length, k) {
+		for _, term := range terms {
+			if !utils.IsInSliceInt(term.rot, rotations) && term.rot != 0 {
+				rotations = append(rotations, term.rot)
+			}
+		}
+	}
+	return
+}
+
+// RotationsForVectorInnerSum generates the rotations that will be performed by the
+// `VectorEvaluator.InnerSum` operation.
+func (p Parameters) RotationsForVectorInnerSum() (rotations []int) {
+	return p.RotationsForInnerSumLog(1, p.Slots())
+}
+
 // RotationsForSubSum generates the rotations that will be performed by the
-// `Evaluator.SubSum` operation.
+// SubSum of the bootstrapping, i.e. by `Evaluator.Trace` from LogN-1 to `logSlots`.
 func (p Parameters) RotationsForSubSum(logSlots int) (rotations []int) {
 	rotations = []int{}
 
@@ -306,6 +370,17 @@ func (p Parameters) RotationsForSubSum(logSlots int) (rotations []int) {
 	return
 }
 
+// RotationsForTrace generates the rotations that will be performed by the
+// `Evaluator.Trace` operation when performed with parameters `logSlotsStart` and `logSlotsEnd`.
+// The trace up to LogN also requires the conjugation key.
+func (p Parameters) RotationsForTrace(logSlotsStart, logSlotsEnd int) (rotations []int) {
+	rotations = []int{}
+	for i := logSlotsStart; i < utils.MinInt(logSlotsEnd, p.LogN()-1); i++ {
+		rotations = append(rotations, 1<<i)
+	}
+	return
+}
+
 // RotationsForDiagMatrixMult generates of all the rotations needed for a the multiplication
 // with the provided diagonal plaintext matrix.
 func (p Parameters) RotationsForDiagMatrixMult(matrix *PtDiagMatrix) []int {
@@ -317,7 +392,7 @@ func (p Parameters) RotationsForDiagMatrixMult(matrix *PtDiagMatrix) []int {
 
 	N1 := matrix.N1
 
-	if len(matrix.Vec) < 3 {
+	if matrix.naive || len(matrix.Vec) < 3 {
 
 		for j := range matrix.Vec {
 
@@ -347,6 +422,33 @@ func (p Parameters) RotationsForDiagMatrixMult(matrix *PtDiagMatrix) []int {
 	return rotKeyIndex
 }
 
+// RotationsForLinearTransform generates the rotations that will be performed by the
+// `Evaluator.LinearTransform` operation on the given linear transform, which can be a *PtDiagMatrix,
+// a []*PtDiagMatrix or a *LinearTransformPrecomputed.
+func (p Parameters) RotationsForLinearTransform(linearTransform interface{}) (rotations []int) {
+
+	rotations = []int{}
+
+	switch element := linearTransform.(type) {
+	case *PtDiagMatrix:
+		rotations = p.RotationsForDiagMatrixMult(element)
+	case []*PtDiagMatrix:
+		for _, matrix := range element {
+			for _, k := range p.RotationsForDiagMatrixMult(matrix) {
+				if !utils.IsInSliceInt(k, rotations) {
+					rotations = append(rotations, k)
+				}
+			}
+		}
+	case *LinearTransformPrecomputed:
+		rotations = element.Rotations(p)
+	default:
+		panic(fmt.Sprintf("cannot RotationsForLinearTransform: invalid linear transform type %T", linearTransform))
+	}
+
+	return
+}
+
 // Equals compares two sets of parameters for equality.
 func (p Parameters) Equals(other Parameters) bool {
 	res := p.Parameters.Equals(other.Parameters)
@@ -355,6 +457,23 @@ func (p Parameters) Equals(other Parameters) bool {
 	return res
 }
 
+// String returns a single-line summary of the parameters.
+func (p Parameters) String() string {
+	return fmt.Sprintf("CKKS(%s, levels=%d, logSlots=%d, logScale=%.2f)", p.Parameters.String(), p.MaxLevel()+1, p.logSlots, math.Log2(p.scale))
+}
+
+// Describe returns a multi-line human-readable description of the parameters, including the multiplicative
+// depth, the number of slots and the default scale.
+func (p Parameters) Describe() string {
+	var sb strings.Builder
+	sb.WriteString("CKKS parameters:\n")
+	sb.WriteString(p.Parameters.Describe())
+	fmt.Fprintf(&sb, "%-10s: %d (%d levels)\n", "depth", p.MaxLevel(), p.MaxLevel()+1)
+	fmt.Fprintf(&sb, "%-10s: %d (%d slots)\n", "logSlots", p.logSlots, p.Slots())
+	fmt.Fprintf(&sb, "%-10s: %.2f\n", "logScale", math.Log2(p.scale))
+	return sb.String()
+}
+
 // CopyNew makes a deep copy of the receiver and returns it.
 func (p Parameters) CopyNew() Parameters {
 	p.Parameters = p.Parameters.CopyNew()
@@ -405,9 +524,100 @@ func (p Parameters) MarshalJSON() ([]byte, error) {
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
+// The representation is either the name of a parameter set of NamedParams (e.g., "PN14QP438"), or a ParametersLiteral
+// object, which can start from a parameter set of NamedParams given by the field "Preset" and override some of its
+// fields (e.g., {"Preset": "PN14QP438", "LogSlots": 12}). The decoding of an object with a "Preset" is strict: it returns an error
+// if the object has unknown fields, references an unknown parameter set, or has a non-positive Sigma or Scale. An object
+// without "Preset" is decoded as by the previous versions, ignoring its unknown fields.
 func (p *Parameters) UnmarshalJSON(data []byte) (err error) {
 	var params ParametersLiteral
-	json.Unmarshal(data, &params)
+	if params, err = parametersLiteralFromJSON(data); err != nil {
+		return err
+	}
 	*p, err = NewParametersFromLiteral(params)
 	return
 }
+
+// parametersLiteralFromJSON decodes the JSON representation of the parameters described in Parameters.UnmarshalJSON.
+func parametersLiteralFromJSON(data []byte) (pl ParametersLiteral, err error) {
+
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		return namedParametersLiteral(name)
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return ParametersLiteral{}, err
+	}
+
+	raw, ok := jsonField(fields, "Preset")
+	if !ok {
+		// A ParametersLiteral object is decoded as by the previous versions, which ignored its unknown fields
+		err = json.Unmarshal(data, &pl)
+		return pl, err
+	}
+
+	if err = json.Unmarshal(raw, &name); err != nil {
+		return ParametersLiteral{}, fmt.Errorf("invalid parameters: Preset must be a string")
+	}
+
+	if pl, err = namedParametersLiteral(name); err != nil {
+		return ParametersLiteral{}, err
+	}
+
+	// The moduli given either by their values or by their sizes replace the ones of the preset.
+	if _, ok := jsonField(fields, "Q"); ok {
+		pl.LogQ = nil
+	} else if _, ok := jsonField(fields, "LogQ"); ok {
+		pl.Q = nil
+	}
+
+	if _, ok := jsonField(fields, "P"); ok {
+		pl.LogP = nil
+	} else if _, ok := jsonField(fields, "LogP"); ok {
+		pl.P = nil
+	}
+
+	literal := struct {
+		Preset string
+		*ParametersLiteral
+	}{ParametersLiteral: &pl}
+
+	if err = utils.UnmarshalJSONStrict(data, &literal); err != nil {
+		return ParametersLiteral{}, err
+	}
+
+	if pl.Sigma <= 0 {
+		return ParametersLiteral{}, fmt.Errorf("invalid parameters: Sigma=%v must be positive", pl.Sigma)
+	}
+
+	if pl.Scale <= 0 {
+		return ParametersLiteral{}, fmt.Errorf("invalid parameters: Scale=%v must be positive", pl.Scale)
+	}
+
+	return pl, nil
+}
+
+// namedParametersLiteral returns a copy of the parameter set of NamedParams with the given name.
+func namedParametersLiteral(name string) (ParametersLiteral, error) {
+	pl, ok := NamedParams[name]
+	if !ok {
+		return ParametersLiteral{}, fmt.Errorf("invalid parameters: unknown parameter set %q", name)
+	}
+	pl.Q = append([]uint64(nil), pl.Q...)
+	pl.P = append([]uint64(nil), pl.P...)
+	pl.LogQ = append([]int(nil), pl.LogQ...)
+	pl.LogP = append([]int(nil), pl.LogP...)
+	return pl, nil
+}
+
+// jsonField returns the raw value of the field of fields matching key, case-insensitively like encoding/json.
+func jsonField(fields map[string]json.RawMessage, key string) (raw json.RawMessage, ok bool) {
+	for k, v := range fields {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
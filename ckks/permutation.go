@@ -0,0 +1,127 @@
+package ckks
+
+import (
+	"fmt"
+	"sort"
+)
+
+// permutationBSGSRatios are the ratios tried by GenPermutationTransform for the baby-step giant-step split.
+var permutationBSGSRatios = []float64{1, 2, 4, 8, 16}
+
+// PermutationDiagonals returns the diagonal decomposition of the slot permutation perm, whose evaluation sets the
+// i-th slot of the output to the perm[i]-th slot of the input: the k-th diagonal is the 0/1 mask of the slots i
+// such that perm[i] = i+k mod len(perm), and only the non-zero diagonals are returned. The length of perm must be
+// a power of two. Returns an error if perm is not a permutation.
+func PermutationDiagonals(perm []int) (diagMatrix map[int][]complex128, err error) {
+
+	slots := len(perm)
+	if slots == 0 || slots&(slots-1) != 0 {
+		return nil, fmt.Errorf("cannot PermutationDiagonals: the length of the permutation (%d) is not a power of two", slots)
+	}
+
+	seen := make([]bool, slots)
+	diagMatrix = make(map[int][]complex128)
+
+	for i, j := range perm {
+
+		if j < 0 || j >= slots || seen[j] {
+			return nil, fmt.Errorf("cannot PermutationDiagonals: invalid or repeated index %d at position %d", j, i)
+		}
+		seen[j] = true
+
+		k := (j - i + slots) & (slots - 1)
+		if diagMatrix[k] == nil {
+			diagMatrix[k] = make([]complex128, slots)
+		}
+		diagMatrix[k][i] = 1
+	}
+
+	return diagMatrix, nil
+}
+
+// GenPermutationTransform compiles the slot permutation perm (see PermutationDiagonals) into a linear transform
+// encoded at the given level, and returns it along with the rotations needed to evaluate it with
+// Evaluator.LinearTransform. The permutation is evaluated as a sum of rotations of the input masked by the
+// diagonals of the permutation matrix: the transform uses the baby-step giant-step evaluation if it requires
+// fewer rotation keys than the naive evaluation, which requires one rotation per non-zero diagonal.
+//
+// The length of perm must be a power of two smaller than or equal to the number of slots of the parameters, and
+// the ciphertexts must be encoded with log2(len(perm)) slots. The transform is encoded at the scale Q[level], so
+// that a Rescale after its evaluation brings the ciphertext back to its input scale. Returns an error if perm is not
+// a permutation.
+func GenPermutationTransform(params Parameters, encoder Encoder, perm []int, level int) (matrix *PtDiagMatrix, rotations []int, err error) {
+
+	if len(perm) > params.Slots() {
+		return nil, nil, fmt.Errorf("cannot GenPermutationTransform: the length of the permutation (%d) is larger than the number of slots (%d)", len(perm), params.Slots())
+	}
+
+	if level < 0 || level > params.MaxLevel() {
+		return nil, nil, fmt.Errorf("cannot GenPermutationTransform: invalid level %d", level)
+	}
+
+	diagMatrix, err := PermutationDiagonals(perm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slots := len(perm)
+	logSlots := 0
+	for 1<<logSlots < slots {
+		logSlots++
+	}
+
+	scale := float64(params.Q()[level])
+
+	// Counts the rotation keys of the naive evaluation, which rotates the input by each non-zero diagonal
+	naive := len(diagMatrix)
+	if _, ok := diagMatrix[0]; ok {
+		naive--
+	}
+
+	bestRatio, bestCount := 0.0, naive
+	if len(diagMatrix) >= 3 {
+		for _, ratio := range permutationBSGSRatios {
+			N1 := findbestbabygiantstepsplit(diagMatrix, slots, ratio)
+			if count := len(bsgsRotations(diagMatrix, slots, N1)); count < bestCount {
+				bestRatio, bestCount = ratio, count
+			}
+		}
+	}
+
+	if bestRatio == 0 {
+		matrix = encoder.EncodeDiagMatrixAtLvl(level, diagMatrix, scale, logSlots)
+	} else {
+		matrix = encoder.EncodeDiagMatrixBSGSAtLvl(level, diagMatrix, scale, bestRatio, logSlots)
+	}
+
+	for _, k := range params.RotationsForDiagMatrixMult(matrix) {
+		if k != 0 {
+			rotations = append(rotations, k)
+		}
+	}
+
+	sort.Ints(rotations)
+
+	return matrix, rotations, nil
+}
+
+// bsgsRotations returns the non-zero rotations of the baby-step giant-step evaluation of diagMatrix with the
+// split N1 (see Parameters.RotationsForDiagMatrixMult).
+func bsgsRotations(diagMatrix map[int][]complex128, slots, N1 int) (rotations map[int]bool) {
+
+	rotations = make(map[int]bool)
+
+	index, _ := bsgsIndex(diagMatrix, slots, N1)
+	for j := range index {
+		for _, i := range index[j] {
+			if giant := (j * N1) & (slots - 1); giant != 0 {
+				rotations[giant] = true
+			}
+			if i != 0 {
+				rotations[i] = true
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,263 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// piecewiseIndicatorDegree is the degree of the Chebyshev approximation of the smooth step functions
+// used by EvalPiecewise to separate the intervals.
+const piecewiseIndicatorDegree = 63
+
+// piecewiseIndicatorLogPrecision is the target log2 precision of the Chebyshev approximation of the
+// smooth step functions, from which their sharpness is derived. The precision is then improved by
+// piecewiseCleaningSteps iterations of the cleaning polynomial 3x^2 - 2x^3, each of them roughly
+// doubling the number of correct bits away from the breakpoints.
+const piecewiseIndicatorLogPrecision = 6
+
+// piecewiseCleaningSteps is the number of iterations of the cleaning polynomial applied on the step functions.
+const piecewiseCleaningSteps = 2
+
+// EvalPiecewise evaluates on ctIn the piecewise function equal to polys[i] on the interval
+// [breakpoints[i], breakpoints[i+1]] and returns the result in a newly created element.
+//
+// The function is rewritten as polys[0] + sum_{j>0} step_j * (polys[j] - polys[j-1]), where step_j is a
+// Chebyshev approximation of a smooth step function centered at breakpoints[j], sharpened by two iterations
+// of the cleaning polynomial 3x^2 - 2x^3. The approximation error
+// near a breakpoint thus scales with the jump of the function at this breakpoint and vanishes for
+// continuous functions (e.g. ReLU, hard-sigmoid or clipping), which gives a better precision than a single
+// global polynomial of the same degree. Constant differences are folded into the step approximation.
+//
+// breakpoints must be strictly increasing and have len(polys)+1 elements, the first and the last one giving
+// the domain [a, b] on which the input values must lie. The polynomials are in the standard basis.
+// All the terms are brought to a common level and the output has scale targetScale. The evaluation consumes
+// one level for the change of basis, ten levels for the step functions and one level for the
+// products, or the depth of the polynomials if it is larger.
+// Returns an error if the parameters are inconsistent or if ctIn does not have enough levels.
+func (eval *evaluator) EvalPiecewise(ctIn *Ciphertext, breakpoints []float64, polys []*Poly, targetScale float64) (ctOut *Ciphertext, err error) {
+	return evalPiecewise(eval, eval.params, ctIn, breakpoints, polys, targetScale)
+}
+
+// piecewiseTerm is a term step_j * diff of a piecewise function.
+type piecewiseTerm struct {
+	step  *ChebyshevInterpolation
+	clean *Poly // last cleaning polynomial, scaled by the folded constant
+	diff  *Poly // nil if the difference was folded into clean
+}
+
+// evalPiecewise implements EvalPiecewise on top of the Evaluator interface, so that it is shared by
+// the homomorphic and the cleartext evaluators.
+func evalPiecewise(eval Evaluator, params Parameters, ctIn *Ciphertext, breakpoints []float64, polys []*Poly, targetScale float64) (ctOut *Ciphertext, err error) {
+
+	checkNoSlotScales("EvalPiecewise", ctIn.El())
+
+	if len(polys) == 0 || len(breakpoints) != len(polys)+1 {
+		return nil, fmt.Errorf("cannot EvalPiecewise: %d breakpoints for %d polynomials", len(breakpoints), len(polys))
+	}
+
+	for i := 1; i < len(breakpoints); i++ {
+		if breakpoints[i] <= breakpoints[i-1] {
+			return nil, fmt.Errorf("cannot EvalPiecewise: breakpoints are not strictly increasing")
+		}
+	}
+
+	if targetScale <= 0 {
+		return nil, fmt.Errorf("cannot EvalPiecewise: targetScale <= 0")
+	}
+
+	a, b := breakpoints[0], breakpoints[len(breakpoints)-1]
+
+	// Sharpness of the step functions in the Chebyshev domain [-1, 1], chosen such that the
+	// Chebyshev approximation error is about 2^-piecewiseIndicatorLogPrecision.
+	sharpness := float64(piecewiseIndicatorDegree) * math.Pi / (2 * piecewiseIndicatorLogPrecision * math.Ln2)
+
+	terms := []piecewiseTerm{}
+	for j := 1; j < len(polys); j++ {
+
+		diff := subPoly(polys[j], polys[j-1])
+		if diff == nil {
+			continue
+		}
+
+		var c complex128 = 1
+		if diff.Degree() == 0 {
+			c, diff = diff.coeffs[0], nil
+		}
+
+		center := (2*breakpoints[j] - a - b) / (b - a)
+		step := Approximate(func(y complex128) complex128 {
+			return complex(0.5*(1+math.Tanh(sharpness*(real(y)-center))), 0)
+		}, -1, 1, piecewiseIndicatorDegree)
+
+		terms = append(terms, piecewiseTerm{step: step, clean: NewPoly([]complex128{0, 0, 3 * c, -2 * c}), diff: diff})
+	}
+
+	// Change of basis y = (2x - a - b)/(b - a) for the Chebyshev evaluation of the step functions
+	var y *Ciphertext
+	if len(terms) != 0 {
+		y = eval.MultByConstNew(ctIn, 2/(b-a))
+		eval.AddConst(y, -(a+b)/(b-a), y)
+		if err = eval.Rescale(y, params.Scale(), y); err != nil {
+			return nil, err
+		}
+	}
+
+	cleaning := NewPoly([]complex128{0, 0, 3, -2})
+
+	stepDepth := bits.Len64(uint64(piecewiseIndicatorDegree)) + piecewiseCleaningSteps*bits.Len64(uint64(cleaning.Degree()))
+
+	// Computes the common output level
+	base := polys[0]
+	levelOut := ctIn.Level()
+	if base.Degree() > 0 {
+		levelOut = ctIn.Level() - bits.Len64(uint64(base.Degree()))
+	}
+
+	for _, term := range terms {
+		level := y.Level() - stepDepth
+		if term.diff != nil {
+			level = utils.MinInt(level, ctIn.Level()-bits.Len64(uint64(term.diff.Degree()))) - 1
+		}
+		levelOut = utils.MinInt(levelOut, level)
+	}
+
+	if levelOut < 0 {
+		return nil, fmt.Errorf("cannot EvalPiecewise: ciphertext level %d is not enough", ctIn.Level())
+	}
+
+	var res []*Ciphertext
+
+	for _, term := range terms {
+
+		var ct *Ciphertext
+
+		if term.diff == nil {
+
+			if ct, err = evalStep(eval, params, y, term, cleaning, targetScale); err != nil {
+				return nil, err
+			}
+
+		} else {
+
+			// The product is computed at levelOut+1, the scale of the polynomial is set such that the
+			// result has scale targetScale after the rescaling.
+			stepScale := params.Scale()
+			diffScale := targetScale * float64(params.Q()[levelOut+1]) / stepScale
+
+			var step, diff *Ciphertext
+			if step, err = evalStep(eval, params, y, term, cleaning, stepScale); err != nil {
+				return nil, err
+			}
+
+			if diff, err = eval.EvaluatePoly(ctIn, term.diff, diffScale); err != nil {
+				return nil, err
+			}
+
+			if err = dropToLevel(eval, step, levelOut+1); err != nil {
+				return nil, err
+			}
+
+			if err = dropToLevel(eval, diff, levelOut+1); err != nil {
+				return nil, err
+			}
+
+			ct = eval.MulRelinNew(step, diff)
+			if err = eval.Rescale(ct, targetScale, ct); err != nil {
+				return nil, err
+			}
+		}
+
+		if err = dropToLevel(eval, ct, levelOut); err != nil {
+			return nil, err
+		}
+
+		res = append(res, ct)
+	}
+
+	if base.Degree() > 0 {
+
+		var ct *Ciphertext
+		if ct, err = eval.EvaluatePoly(ctIn, base, targetScale); err != nil {
+			return nil, err
+		}
+
+		if err = dropToLevel(eval, ct, levelOut); err != nil {
+			return nil, err
+		}
+
+		res = append(res, ct)
+	}
+
+	if len(res) == 0 {
+		ctOut = NewCiphertext(params, 1, levelOut, targetScale)
+	} else {
+		ctOut = res[0]
+		for _, ct := range res[1:] {
+			eval.Add(ctOut, ct, ctOut)
+		}
+	}
+
+	if base.Degree() == 0 && base.coeffs[0] != 0 {
+		eval.AddConst(ctOut, base.coeffs[0], ctOut)
+	}
+
+	ctOut.MetaData.IsReal = ctIn.MetaData.IsReal && isRealVector(base.coeffs)
+	ctOut.MetaData.Tags = mergeMetadata(ctIn.MetaData.Tags, ctOut.MetaData.Tags)
+	for _, term := range terms {
+		ctOut.MetaData.IsReal = ctOut.MetaData.IsReal && isRealVector(term.clean.coeffs)
+		if term.diff != nil {
+			ctOut.MetaData.IsReal = ctOut.MetaData.IsReal && isRealVector(term.diff.coeffs)
+		}
+	}
+
+	return ctOut, nil
+}
+
+// evalStep evaluates the step function of term on y and returns it with scale targetScale.
+func evalStep(eval Evaluator, params Parameters, y *Ciphertext, term piecewiseTerm, cleaning *Poly, targetScale float64) (ct *Ciphertext, err error) {
+
+	if ct, err = eval.EvaluateCheby(y, term.step, params.Scale()); err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < piecewiseCleaningSteps; i++ {
+		if ct, err = eval.EvaluatePoly(ct, cleaning, params.Scale()); err != nil {
+			return nil, err
+		}
+	}
+
+	return eval.EvaluatePoly(ct, term.clean, targetScale)
+}
+
+// dropToLevel drops the level of ct to level, and returns an error if ct is below level.
+func dropToLevel(eval Evaluator, ct *Ciphertext, level int) (err error) {
+	if ct.Level() < level {
+		return fmt.Errorf("cannot EvalPiecewise: intermediate ciphertext at level %d < %d", ct.Level(), level)
+	}
+	eval.DropLevel(ct, ct.Level()-level)
+	return nil
+}
+
+// subPoly returns p1 - p0 with its trailing zero coefficients removed, or nil if p1 = p0.
+func subPoly(p1, p0 *Poly) *Poly {
+
+	coeffs := make([]complex128, utils.MaxInt(len(p1.coeffs), len(p0.coeffs)))
+	copy(coeffs, p1.coeffs)
+	for i, c := range p0.coeffs {
+		coeffs[i] -= c
+	}
+
+	for len(coeffs) != 0 && cmplx.Abs(coeffs[len(coeffs)-1]) == 0 {
+		coeffs = coeffs[:len(coeffs)-1]
+	}
+
+	if len(coeffs) == 0 {
+		return nil
+	}
+
+	return NewPoly(coeffs)
+}
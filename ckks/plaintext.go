@@ -1,7 +1,10 @@
 package ckks
 
 import (
+	"fmt"
+
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
 // Plaintext is is a Element with only one Poly.
@@ -19,3 +22,33 @@ func NewPlaintext(params Parameters, level int, scale float64) *Plaintext {
 
 	return plaintext
 }
+
+// newPlaintextFromPool creates a new Plaintext of level level and scale scale, whose polynomial is drawn from
+// ring.DefaultPolyPool.
+func newPlaintextFromPool(params Parameters, level int, scale float64) *Plaintext {
+
+	plaintext := &Plaintext{Element: newElementFromRLWE(rlwe.NewElementAtLevelFromPool(params.Parameters, 0, level), scale)}
+	plaintext.value = plaintext.Element.Value[0]
+	plaintext.Element.Element.IsNTT = true
+
+	return plaintext
+}
+
+// RLWE returns the receiver plaintext as an rlwe.Plaintext of the scheme rlwe.SchemeCKKS. The two plaintexts share
+// their polynomial and their MetaData.
+func (pt *Plaintext) RLWE() *rlwe.Plaintext {
+	return (*rlwe.Plaintext)(pt.Element)
+}
+
+// NewPlaintextFromRLWE returns the rlwe.Plaintext pt as a Plaintext. The two plaintexts share their polynomial and
+// their MetaData.
+// Returns an error if pt is not a plaintext of the scheme rlwe.SchemeCKKS or if its degree is not zero.
+func NewPlaintextFromRLWE(pt *rlwe.Plaintext) (*Plaintext, error) {
+	if pt.Scheme != rlwe.SchemeCKKS {
+		return nil, fmt.Errorf("cannot NewPlaintextFromRLWE: scheme %s is not CKKS", pt.Scheme)
+	}
+	if pt.Degree() != 0 {
+		return nil, fmt.Errorf("cannot NewPlaintextFromRLWE: the element has degree %d instead of 0", pt.Degree())
+	}
+	return &Plaintext{(*Element)(pt), pt.Value[0]}, nil
+}
@@ -59,29 +59,7 @@ func (eval *evaluator) EvaluatePoly(ct0 *Ciphertext, pol *Poly, targetScale floa
 		return ct0, err
 	}
 
-	C := make(map[int]*Ciphertext)
-
-	C[1] = ct0.CopyNew()
-
-	logDegree := bits.Len64(uint64(pol.Degree()))
-	logSplit := (logDegree >> 1) //optimalSplit(logDegree) //
-
-	for i := 2; i < (1 << logSplit); i++ {
-		if err = computePowerBasis(i, C, eval); err != nil {
-			return nil, err
-		}
-	}
-
-	for i := logSplit; i < logDegree; i++ {
-		if err = computePowerBasis(1<<i, C, eval); err != nil {
-			return nil, err
-		}
-	}
-
-	opOut, err = recurse(targetScale, logSplit, logDegree, pol, C, eval)
-
-	C = nil
-	return opOut, err
+	return eval.evaluatePolynomial(ct0, pol, false, optimalSplit(bits.Len64(uint64(pol.Degree()))), targetScale)
 }
 
 // EvaluateCheby evaluates a polynomial in Chebyshev basis on the input Ciphertext in ceil(log2(deg+1))+1 levels.
@@ -95,26 +73,56 @@ func (eval *evaluator) EvaluateCheby(op *Ciphertext, cheby *ChebyshevInterpolati
 		return op, err
 	}
 
+	return eval.evaluatePolynomial(op, &cheby.Poly, true, optimalSplit(bits.Len64(uint64(cheby.Degree()))), tartetScale)
+}
+
+// optimalSplit returns the log2 of the baby-step giant-step split minimizing the number of
+// non-scalar multiplications 2^logSplit + 2^(logDegree-logSplit) for a polynomial of degree < 2^logDegree.
+func optimalSplit(logDegree int) (logSplit int) {
+	logSplit = 1
+	for i := 2; i < logDegree; i++ {
+		if (1<<i)+(1<<(logDegree-i)) < (1<<logSplit)+(1<<(logDegree-logSplit)) {
+			logSplit = i
+		}
+	}
+	return
+}
+
+// evaluatePolynomial evaluates pol in the standard or Chebyshev basis on ct0 with the baby-step giant-step
+// algorithm, using a baby-step of 2^logSplit powers.
+func (eval *evaluator) evaluatePolynomial(ct0 *Ciphertext, pol *Poly, cheby bool, logSplit int, targetScale float64) (opOut *Ciphertext, err error) {
+
+	checkNoSlotScales("EvaluatePoly", ct0.El())
+
+	computeBasis, recursion := computePowerBasis, recurse
+	if cheby {
+		computeBasis, recursion = computePowerBasisCheby, recurseCheby
+	}
+
 	C := make(map[int]*Ciphertext)
 
-	C[1] = op.CopyNew()
+	C[1] = ct0.CopyNew()
 
-	logDegree := int(bits.Len64(uint64(cheby.Degree())))
-	logSplit := (logDegree >> 1) //optimalSplit(logDegree) //
+	logDegree := bits.Len64(uint64(pol.Degree()))
 
 	for i := 2; i < (1 << logSplit); i++ {
-		if err = computePowerBasisCheby(i, C, eval); err != nil {
+		if err = computeBasis(i, C, eval); err != nil {
 			return nil, err
 		}
 	}
 
 	for i := logSplit; i < logDegree; i++ {
-		if err = computePowerBasisCheby(1<<i, C, eval); err != nil {
+		if err = computeBasis(1<<i, C, eval); err != nil {
 			return nil, err
 		}
 	}
 
-	opOut, err = recurseCheby(tartetScale, logSplit, logDegree, &cheby.Poly, C, eval)
+	opOut, err = recursion(targetScale, logSplit, logDegree, pol, C, eval)
+
+	if err == nil {
+		opOut.MetaData.IsReal = ct0.MetaData.IsReal && isRealVector(pol.coeffs)
+		opOut.MetaData.Tags = mergeMetadata(ct0.MetaData.Tags, opOut.MetaData.Tags)
+	}
 
 	C = nil
 
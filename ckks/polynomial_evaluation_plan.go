@@ -0,0 +1,255 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// PolyEvaluationPlan is a level-aware plan for the evaluation of a polynomial on a ciphertext. Instead of
+// failing, the plan adapts the evaluation to the levels and the scale of the ciphertext:
+//
+//   - if the scale of the ciphertext is too far from the default scale and from the moduli for the power basis
+//     to be rescaled exactly once per multiplication, the ciphertext is first brought to the scale of the
+//     next modulus (consuming one level),
+//   - if there are not enough levels, the polynomial is truncated to the largest degree that can be evaluated,
+//   - the baby-step giant-step split is chosen to minimize the number of non-scalar multiplications.
+//
+// The plan also provides an estimate of the error introduced by the truncation, by the scaling and rounding of
+// the coefficients at each level, and by the noise of the ciphertext propagated through the evaluation.
+type PolyEvaluationPlan struct {
+	// InputLevel is the level at which the ciphertext is evaluated.
+	InputLevel int
+	// OutputLevel is the level of the result.
+	OutputLevel int
+	// TargetScale is the scale of the result.
+	TargetScale float64
+
+	// Normalize indicates whether the ciphertext is first brought to the scale of the next modulus.
+	Normalize bool
+	// Degree is the degree of the evaluated polynomial, after truncation.
+	Degree int
+	// LogDegree is the depth of the evaluation of the (truncated) polynomial.
+	LogDegree int
+	// LogSplit is the log2 of the number of powers of the baby-step.
+	LogSplit int
+
+	// TruncationError is an upper bound on the absolute error introduced by the truncation, for inputs
+	// in [-1, 1] (after the change of basis in the Chebyshev case).
+	TruncationError float64
+	// RoundingError is an estimate of the absolute error introduced by the rounding of the coefficients
+	// scaled at each level.
+	RoundingError float64
+	// NoiseError is an estimate of the absolute error introduced by the noise of the ciphertext, and by the
+	// rescalings and key-switchings of the evaluation (see EstimateNoise). NewPolyEvaluationPlan assumes a
+	// fresh public key encryption of values in [-1, 1].
+	NoiseError float64
+
+	poly  *Poly
+	cheby bool
+}
+
+// NewPolyEvaluationPlan returns the plan for the evaluation of pol on ctIn with a result at scale targetScale.
+// pol can be a *Poly (standard basis) or a *ChebyshevInterpolation (Chebyshev basis, the change of basis
+// must be applied on ctIn by the caller as for EvaluateCheby).
+// Returns an error if pol is not supported or empty, if targetScale is not positive or if the scale of ctIn is
+// too far from the moduli to be normalized.
+func NewPolyEvaluationPlan(params Parameters, ctIn *Ciphertext, pol interface{}, targetScale float64) (plan *PolyEvaluationPlan, err error) {
+
+	plan = &PolyEvaluationPlan{InputLevel: ctIn.Level(), TargetScale: targetScale}
+
+	var coeffs []complex128
+	switch pol := pol.(type) {
+	case *Poly:
+		coeffs = pol.coeffs
+	case *ChebyshevInterpolation:
+		coeffs = pol.coeffs
+		plan.cheby = true
+	default:
+		return nil, fmt.Errorf("cannot NewPolyEvaluationPlan: invalid polynomial type %T", pol)
+	}
+
+	if len(coeffs) == 0 {
+		return nil, fmt.Errorf("cannot NewPolyEvaluationPlan: empty polynomial")
+	}
+
+	if targetScale <= 0 {
+		return nil, fmt.Errorf("cannot NewPolyEvaluationPlan: targetScale <= 0")
+	}
+
+	depth := ctIn.Level()
+
+	plan.Normalize = len(coeffs) > 2 && ctIn.Level() > 1 && !isWellScaled(params, ctIn.Scale(), ctIn.Level())
+	if plan.Normalize {
+
+		if factor := normalizationFactor(params, ctIn.Scale(), ctIn.Level()); factor < 1 || factor > math.MaxInt64/2 {
+			return nil, fmt.Errorf("cannot NewPolyEvaluationPlan: ciphertext scale %f cannot be normalized", ctIn.Scale())
+		}
+
+		depth--
+	}
+
+	// Truncation to the largest degree that can be evaluated
+	plan.Degree = utils.MinInt(len(coeffs)-1, (1<<depth)-1)
+	for _, c := range coeffs[plan.Degree+1:] {
+		plan.TruncationError += cmplx.Abs(c)
+	}
+
+	// Trailing zero coefficients do not need to be evaluated
+	for plan.Degree > 0 && cmplx.Abs(coeffs[plan.Degree]) == 0 {
+		plan.Degree--
+	}
+
+	plan.poly = NewPoly(coeffs[:plan.Degree+1])
+
+	plan.LogDegree = bits.Len64(uint64(plan.Degree))
+	plan.LogSplit = optimalSplit(plan.LogDegree)
+	plan.OutputLevel = ctIn.Level() - plan.LogDegree
+	if plan.Normalize {
+		plan.OutputLevel--
+	}
+
+	// Each non-zero coefficient is scaled by about targetScale before being rounded
+	for _, c := range plan.poly.coeffs[1:] {
+		if cmplx.Abs(c) != 0 {
+			plan.RoundingError += 0.5 / targetScale
+		}
+	}
+
+	// Without modulus P, the ciphertexts cannot be relinearized and the polynomial cannot be evaluated
+	if params.PCount() != 0 {
+		est := NewCanonicalBoundEstimator(params, 0)
+		plan.EstimateNoise(est, CanonicalBound{
+			Level:   ctIn.Level(),
+			Scale:   ctIn.Scale(),
+			Message: ctIn.Scale(),
+			Error:   est.EncodingError() + est.FreshError(true),
+		})
+	}
+
+	return plan, nil
+}
+
+// EstimateNoise sets the NoiseError of the plan for an input ciphertext of CanonicalBound input, whose message
+// must be bounded by 1 in the Chebyshev case. The bound is propagated with est through the power basis, as
+// computed by the evaluator, and weighted by the coefficients of the polynomial, to which is added the error of
+// one rescaled key-switching and one rescaling per level of the baby-step giant-step recursion.
+func (plan *PolyEvaluationPlan) EstimateNoise(est *CanonicalBoundEstimator, input CanonicalBound) {
+
+	if plan.Normalize {
+		factor := math.Round(normalizationFactor(est.params, input.Scale, input.Level))
+		input.Scale *= factor
+		input.Message *= factor
+		input.Error *= factor
+		input = est.Rescale(input)
+	}
+
+	powers := map[int]CanonicalBound{1: input}
+
+	var power func(n int) CanonicalBound
+	power = func(n int) CanonicalBound {
+
+		if b, ok := powers[n]; ok {
+			return b
+		}
+
+		a, b := (n+1)>>1, n>>1
+
+		pow := est.MulRelin(power(a), power(b))
+		if pow.Level > 0 {
+			pow = est.Rescale(pow)
+		}
+
+		// T_n = 2*T_a*T_b - T_(a-b), which is bounded by 1
+		if plan.cheby {
+			pow.Error *= 2
+			if a != b {
+				pow.Error += power(a - b).Error
+			}
+			pow.Message = pow.Scale
+		}
+
+		powers[n] = pow
+
+		return pow
+	}
+
+	plan.NoiseError = 0
+	for i, c := range plan.poly.coeffs[1:] {
+		if abs := cmplx.Abs(c); abs != 0 {
+			pow := power(i + 1)
+			plan.NoiseError += abs * pow.Error / pow.Scale
+		}
+	}
+
+	// The key-switchings of the recursion are followed by a rescaling
+	keySwitch := est.KeySwitchError(plan.InputLevel) / float64(est.params.Q()[plan.InputLevel])
+	plan.NoiseError += float64(plan.LogDegree) * (est.RescaleError() + keySwitch) / plan.TargetScale
+}
+
+// ErrorBound returns an estimate of the total error of the evaluation introduced by the plan and by the noise
+// of the ciphertext.
+func (plan *PolyEvaluationPlan) ErrorBound() float64 {
+	return plan.TruncationError + plan.RoundingError + plan.NoiseError
+}
+
+// isWellScaled checks that a ciphertext of the given scale at the given level can be used as the base of the
+// power basis, i.e. that its scale is within a factor 2^(1/8) of the default scale or of the modulus of the
+// current level.
+func isWellScaled(params Parameters, scale float64, level int) bool {
+	for _, ref := range []float64{params.Scale(), float64(params.Q()[level])} {
+		if math.Abs(math.Log2(scale/ref)) <= 0.125 {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizationFactor returns the factor by which a ciphertext of the given scale at the given level
+// must be multiplied to have the scale of the modulus of the next level after one rescaling.
+func normalizationFactor(params Parameters, scale float64, level int) float64 {
+	q := params.Q()
+	return float64(q[level]) * float64(q[level-1]) / scale
+}
+
+// EvaluatePlan evaluates the polynomial of plan on ctIn and returns the result in a newly created element.
+// Returns an error if ctIn is below the input level of the plan.
+func (eval *evaluator) EvaluatePlan(ctIn *Ciphertext, plan *PolyEvaluationPlan) (ctOut *Ciphertext, err error) {
+
+	if ctIn.Level() < plan.InputLevel {
+		return nil, fmt.Errorf("cannot EvaluatePlan: ciphertext level %d < plan input level %d", ctIn.Level(), plan.InputLevel)
+	}
+
+	ct := eval.DropLevelNew(ctIn, ctIn.Level()-plan.InputLevel)
+
+	if plan.Normalize {
+
+		factor := math.Round(normalizationFactor(eval.params, ct.Scale(), ct.Level()))
+
+		eval.MultByGaussianInteger(ct, int64(factor), 0, ct)
+		ct.MulScale(factor)
+
+		if err = eval.Rescale(ct, float64(eval.params.Q()[ct.Level()-1]), ct); err != nil {
+			return nil, err
+		}
+	}
+
+	if ctOut, err = eval.evaluatePolynomial(ct, plan.poly, plan.cheby, plan.LogSplit, plan.TargetScale); err != nil {
+		return nil, err
+	}
+
+	if ctOut.Level() < plan.OutputLevel {
+		return nil, fmt.Errorf("cannot EvaluatePlan: output level %d < plan output level %d", ctOut.Level(), plan.OutputLevel)
+	}
+
+	eval.DropLevel(ctOut, ctOut.Level()-plan.OutputLevel)
+
+	if math.Abs(ctOut.Scale()/plan.TargetScale-1) > 1e-9 {
+		return nil, fmt.Errorf("cannot EvaluatePlan: output scale %f != plan target scale %f", ctOut.Scale(), plan.TargetScale)
+	}
+
+	return ctOut, nil
+}
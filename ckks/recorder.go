@@ -0,0 +1,764 @@
+package ckks
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// recorderMaxPrecision is the precision (in bits) reported for exact results, for which the precision is infinite.
+const recorderMaxPrecision = 64
+
+// OperationRecord is the entry logged by a RecordingEvaluator for one evaluated operation.
+type OperationRecord struct {
+	// Index is the position of the operation in the trace.
+	Index int
+	// Operation is the name of the evaluator method.
+	Operation string
+	// Levels and LogScales are the levels and the log2 of the scales of the operands.
+	Levels    []int
+	LogScales []float64
+	// OutLevel, OutLogScale and OutDegree describe the output ciphertext.
+	OutLevel    int
+	OutLogScale float64
+	OutDegree   int
+	// MinPrecision and MeanPrecision are the minimum and mean precision (in bits, over the real and imaginary
+	// parts of all the slots) of the decrypted output with respect to the cleartext evaluation of the circuit.
+	// They are only present if the RecordingEvaluator was given a Decryptor.
+	MinPrecision  float64 `json:",omitempty"`
+	MeanPrecision float64 `json:",omitempty"`
+}
+
+// recorderState is the state shared by a RecordingEvaluator and its shallow copies.
+type recorderState struct {
+	sync.Mutex
+	records []OperationRecord
+	shadows map[*Ciphertext]*Ciphertext
+}
+
+// RecordingEvaluator is an Evaluator that logs, for each evaluated operation, the levels and scales of the operands
+// and of the output, to help diagnosing the scale and level management of a circuit. The trace is deterministic
+// if the evaluation is (e.g., with the -prng-seed flag of the test suites), so that two runs can be compared
+// operation by operation.
+//
+// If a Decryptor is given, the RecordingEvaluator additionally evaluates the circuit in the clear (see
+// NewCleartextEvaluator) alongside the encrypted one and logs the precision of the decrypted output of each
+// operation with respect to its cleartext evaluation, which shows where the precision of a circuit collapses.
+// The cleartext value of a ciphertext that was not produced by the RecordingEvaluator is obtained by decrypting it,
+// unless it was registered with SetReference. This mode requires the secret key and must only be used for debugging.
+//
+// Composite operations (e.g. EvaluatePoly) are logged as a single operation. The methods that are not logged are
+// the ones of the wrapped Evaluator.
+type RecordingEvaluator struct {
+	Evaluator
+	params Parameters
+
+	decryptor          Decryptor
+	encoder            Encoder
+	cleartextEncryptor Encryptor
+	cleartextEvaluator Evaluator
+
+	state *recorderState
+}
+
+// NewRecordingEvaluator creates a new RecordingEvaluator wrapping eval. The decryptor can be nil, in which
+// case the precision of the operations is not logged.
+func NewRecordingEvaluator(params Parameters, eval Evaluator, decryptor Decryptor) *RecordingEvaluator {
+	recorder := &RecordingEvaluator{Evaluator: eval, params: params, decryptor: decryptor, state: &recorderState{shadows: make(map[*Ciphertext]*Ciphertext)}}
+	if decryptor != nil {
+		recorder.encoder = NewEncoder(params)
+		recorder.cleartextEncryptor = NewCleartextEncryptor(params)
+		recorder.cleartextEvaluator = NewCleartextEvaluator(params)
+	}
+	return recorder
+}
+
+// Records returns a copy of the records logged so far.
+func (eval *RecordingEvaluator) Records() []OperationRecord {
+	eval.state.Lock()
+	defer eval.state.Unlock()
+	return append([]OperationRecord{}, eval.state.records...)
+}
+
+// WriteTrace writes the records logged so far on w, as one JSON object per line.
+func (eval *RecordingEvaluator) WriteTrace(w io.Writer) (err error) {
+	enc := json.NewEncoder(w)
+	for _, record := range eval.Records() {
+		if err = enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset clears the records and the cleartext values of the ciphertexts.
+func (eval *RecordingEvaluator) Reset() {
+	eval.state.Lock()
+	defer eval.state.Unlock()
+	eval.state.records = nil
+	eval.state.shadows = make(map[*Ciphertext]*Ciphertext)
+}
+
+// SetReference registers the exact values of the slots of ct, against which the precision of the operations
+// taking ct as input is measured. It has no effect if the RecordingEvaluator has no Decryptor.
+func (eval *RecordingEvaluator) SetReference(ct *Ciphertext, values []complex128) {
+	if eval.decryptor == nil {
+		return
+	}
+
+	// The values are replicated on the maximum number of slots, as for the cleartext ciphertexts.
+	slots := eval.params.MaxSlots()
+	replicated := make([]complex128, slots)
+	for i := range replicated {
+		replicated[i] = values[i%len(values)]
+	}
+
+	shadow := NewCiphertext(eval.params, ct.Degree(), ct.Level(), ct.Scale())
+	setCleartextOutput(eval.params, shadow.El(), ct.Degree(), ct.Level(), ct.Scale(), replicated)
+
+	eval.state.Lock()
+	eval.state.shadows[ct] = shadow
+	eval.state.Unlock()
+}
+
+// ShallowCopy creates a shallow copy of this RecordingEvaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The copy logs in the same trace.
+func (eval *RecordingEvaluator) ShallowCopy() Evaluator {
+	return eval.withEvaluator(eval.Evaluator.ShallowCopy())
+}
+
+// WithKey creates a shallow copy of this RecordingEvaluator in which the read-only data-structures are
+// shared with the receiver but the EvaluationKey is evaluationKey. The copy logs in the same trace.
+func (eval *RecordingEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return eval.withEvaluator(eval.Evaluator.WithKey(evaluationKey))
+}
+
+// WithRotationKeyProvider creates a shallow copy of this RecordingEvaluator in which the read-only data-structures
+// are shared with the receiver but the rotation keys are provided by rtkp. The copy logs in the same trace.
+func (eval *RecordingEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) Evaluator {
+	return eval.withEvaluator(eval.Evaluator.WithRotationKeyProvider(rtkp))
+}
+
+func (eval *RecordingEvaluator) withEvaluator(evaluator Evaluator) *RecordingEvaluator {
+	recorder := *eval
+	recorder.Evaluator = evaluator
+	return &recorder
+}
+
+// record evaluates f on the operands with the wrapped evaluator and logs the operation. If ctOut is nil, the output
+// is the one returned by f, and nothing is logged if it is nil (i.e., if the evaluation returned an error). If the
+// precision is logged, f is also evaluated with the cleartext evaluator on the cleartext values of the operands.
+func (eval *RecordingEvaluator) record(operation string, ops []Operand, ctOut *Ciphertext, f func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext) *Ciphertext {
+
+	record := OperationRecord{Operation: operation, Levels: make([]int, len(ops)), LogScales: make([]float64, len(ops))}
+	for i, op := range ops {
+		record.Levels[i] = op.Level()
+		record.LogScales[i] = math.Log2(op.Scale())
+	}
+
+	// The cleartext operands must be retrieved before the evaluation, which can overwrite them.
+	var shadowOps []Operand
+	var shadowOut *Ciphertext
+	if eval.decryptor != nil {
+		eval.state.Lock()
+		shadowOps = make([]Operand, len(ops))
+		for i, op := range ops {
+			shadowOps[i] = eval.shadow(op)
+		}
+		eval.state.Unlock()
+		if ctOut != nil {
+			shadowOut = NewCiphertext(eval.params, ctOut.Degree(), ctOut.Level(), ctOut.Scale())
+		}
+	}
+
+	if ctOut = f(eval.Evaluator, ops, ctOut); ctOut == nil {
+		return nil
+	}
+
+	eval.state.Lock()
+	defer eval.state.Unlock()
+
+	record.Index = len(eval.state.records)
+	record.OutLevel = ctOut.Level()
+	record.OutLogScale = math.Log2(ctOut.Scale())
+	record.OutDegree = ctOut.Degree()
+
+	if eval.decryptor != nil {
+		shadowOut = f(eval.cleartextEvaluator, shadowOps, shadowOut)
+		eval.state.shadows[ctOut] = shadowOut
+		record.MinPrecision, record.MeanPrecision = eval.precision(ctOut, shadowOut)
+	}
+
+	eval.state.records = append(eval.state.records, record)
+
+	return ctOut
+}
+
+// shadow returns the cleartext value of the operand. Plaintexts are returned as is.
+func (eval *RecordingEvaluator) shadow(op Operand) Operand {
+	ct, isCt := op.(*Ciphertext)
+	if !isCt {
+		return op
+	}
+	if shadow, ok := eval.state.shadows[ct]; ok {
+		return shadow
+	}
+	shadow := eval.cleartextEncryptor.EncryptNew(eval.decryptor.DecryptNew(ct))
+	shadow.Resize(eval.params, ct.Degree())
+	eval.state.shadows[ct] = shadow
+	return shadow
+}
+
+// precision returns the minimum and mean precision of the decryption of ct with respect to its cleartext value.
+func (eval *RecordingEvaluator) precision(ct, shadow *Ciphertext) (minPrec, meanPrec float64) {
+	logSlots := eval.params.MaxLogSlots()
+	valuesWant := getCleartext(shadow.El(), 1<<logSlots)
+	valuesTest := eval.encoder.Decode(eval.decryptor.DecryptNew(ct), logSlots)
+	stats := GetPrecisionStats(eval.params, eval.encoder, nil, valuesWant, valuesTest, logSlots, 0)
+	minPrec = math.Min(math.Min(real(stats.MinPrecision), imag(stats.MinPrecision)), recorderMaxPrecision)
+	meanPrec = math.Min(math.Min(real(stats.MeanPrecision), imag(stats.MeanPrecision)), recorderMaxPrecision)
+	return
+}
+
+// Add adds op0 to op1 and returns the result in ctOut.
+func (eval *RecordingEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.record("Add", []Operand{op0, op1}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Add(ops[0], ops[1], ctOut)
+		return ctOut
+	})
+}
+
+// AddNew adds op0 to op1 and returns the result in a newly created element.
+func (eval *RecordingEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.record("AddNew", []Operand{op0, op1}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.AddNew(ops[0], ops[1])
+	})
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut.
+func (eval *RecordingEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.record("Sub", []Operand{op0, op1}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Sub(ops[0], ops[1], ctOut)
+		return ctOut
+	})
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a newly created element.
+func (eval *RecordingEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.record("SubNew", []Operand{op0, op1}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.SubNew(ops[0], ops[1])
+	})
+}
+
+// Neg negates ctIn and returns the result in ctOut.
+func (eval *RecordingEvaluator) Neg(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.record("Neg", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Neg(ops[0].(*Ciphertext), ctOut)
+		return ctOut
+	})
+}
+
+// NegNew negates ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) NegNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.record("NegNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.NegNew(ops[0].(*Ciphertext))
+	})
+}
+
+// AddConst adds the input constant to ctIn and returns the result in ctOut.
+func (eval *RecordingEvaluator) AddConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	eval.record("AddConst", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.AddConst(ops[0].(*Ciphertext), constant, ctOut)
+		return ctOut
+	})
+}
+
+// AddConstNew adds the input constant to ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) AddConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	return eval.record("AddConstNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.AddConstNew(ops[0].(*Ciphertext), constant)
+	})
+}
+
+// MultByConst multiplies ctIn by the input constant and returns the result in ctOut.
+func (eval *RecordingEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	eval.record("MultByConst", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.MultByConst(ops[0].(*Ciphertext), constant, ctOut)
+		return ctOut
+	})
+}
+
+// MultByConstNew multiplies ctIn by the input constant and returns the result in a newly created element.
+func (eval *RecordingEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	return eval.record("MultByConstNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.MultByConstNew(ops[0].(*Ciphertext), constant)
+	})
+}
+
+// MultByConstAndAdd multiplies ctIn by the input constant and adds the result to ctOut.
+func (eval *RecordingEvaluator) MultByConstAndAdd(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	eval.record("MultByConstAndAdd", []Operand{ctIn, ctOut}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		eval.MultByConstAndAdd(ops[0].(*Ciphertext), constant, ops[1].(*Ciphertext))
+		return ops[1].(*Ciphertext)
+	})
+}
+
+// MultByGaussianInteger multiplies ctIn by the Gaussian integer cReal + i*cImag and returns the result in ctOut.
+func (eval *RecordingEvaluator) MultByGaussianInteger(ctIn *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
+	eval.record("MultByGaussianInteger", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.MultByGaussianInteger(ops[0].(*Ciphertext), cReal, cImag, ctOut)
+		return ctOut
+	})
+}
+
+// MultByGaussianIntegerNew multiplies ctIn by the Gaussian integer cReal + i*cImag and returns the result in a newly
+// created element.
+func (eval *RecordingEvaluator) MultByGaussianIntegerNew(ctIn *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext) {
+	return eval.record("MultByGaussianIntegerNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.MultByGaussianIntegerNew(ops[0].(*Ciphertext), cReal, cImag)
+	})
+}
+
+// MultByConjGaussian multiplies ctIn by the conjugate of the Gaussian integer cReal + i*cImag and returns the
+// result in ctOut.
+func (eval *RecordingEvaluator) MultByConjGaussian(ctIn *Ciphertext, cReal, cImag int64, ctOut *Ciphertext) {
+	eval.record("MultByConjGaussian", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.MultByConjGaussian(ops[0].(*Ciphertext), cReal, cImag, ctOut)
+		return ctOut
+	})
+}
+
+// MultByConjGaussianNew multiplies ctIn by the conjugate of the Gaussian integer cReal + i*cImag and returns the
+// result in a newly created element.
+func (eval *RecordingEvaluator) MultByConjGaussianNew(ctIn *Ciphertext, cReal, cImag int64) (ctOut *Ciphertext) {
+	return eval.record("MultByConjGaussianNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.MultByConjGaussianNew(ops[0].(*Ciphertext), cReal, cImag)
+	})
+}
+
+// MultByi multiplies ctIn by the imaginary unit and returns the result in ctOut.
+func (eval *RecordingEvaluator) MultByi(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.record("MultByi", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.MultByi(ops[0].(*Ciphertext), ctOut)
+		return ctOut
+	})
+}
+
+// MultByiNew multiplies ctIn by the imaginary unit and returns the result in a newly created element.
+func (eval *RecordingEvaluator) MultByiNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.record("MultByiNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.MultByiNew(ops[0].(*Ciphertext))
+	})
+}
+
+// DivByi divides ctIn by the imaginary unit and returns the result in ctOut.
+func (eval *RecordingEvaluator) DivByi(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.record("DivByi", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.DivByi(ops[0].(*Ciphertext), ctOut)
+		return ctOut
+	})
+}
+
+// DivByiNew divides ctIn by the imaginary unit and returns the result in a newly created element.
+func (eval *RecordingEvaluator) DivByiNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.record("DivByiNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.DivByiNew(ops[0].(*Ciphertext))
+	})
+}
+
+// Conjugate conjugates ctIn and returns the result in ctOut.
+func (eval *RecordingEvaluator) Conjugate(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.record("Conjugate", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Conjugate(ops[0].(*Ciphertext), ctOut)
+		return ctOut
+	})
+}
+
+// ConjugateNew conjugates ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) ConjugateNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.record("ConjugateNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.ConjugateNew(ops[0].(*Ciphertext))
+	})
+}
+
+// RealPart isolates the real part of the slots of ctIn and returns the result in ctOut.
+func (eval *RecordingEvaluator) RealPart(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.record("RealPart", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.RealPart(ops[0].(*Ciphertext), ctOut)
+		return ctOut
+	})
+}
+
+// RealPartNew isolates the real part of the slots of ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) RealPartNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.record("RealPartNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.RealPartNew(ops[0].(*Ciphertext))
+	})
+}
+
+// ImagPart isolates the imaginary part of the slots of ctIn and returns the result in ctOut.
+func (eval *RecordingEvaluator) ImagPart(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.record("ImagPart", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.ImagPart(ops[0].(*Ciphertext), ctOut)
+		return ctOut
+	})
+}
+
+// ImagPartNew isolates the imaginary part of the slots of ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) ImagPartNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.record("ImagPartNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.ImagPartNew(ops[0].(*Ciphertext))
+	})
+}
+
+// SanitizeNew truncates the precision of ctIn to about logPrecision bits and returns the result in a newly created element.
+func (eval *RecordingEvaluator) SanitizeNew(ctIn *Ciphertext, logPrecision float64) (ctOut *Ciphertext) {
+	return eval.record("SanitizeNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.SanitizeNew(ops[0].(*Ciphertext), logPrecision)
+	})
+}
+
+// Mul multiplies op0 by op1 without relinearization and returns the result in ctOut.
+func (eval *RecordingEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.record("Mul", []Operand{op0, op1}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Mul(ops[0], ops[1], ctOut)
+		return ctOut
+	})
+}
+
+// MulNew multiplies op0 by op1 without relinearization and returns the result in a newly created element.
+func (eval *RecordingEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.record("MulNew", []Operand{op0, op1}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.MulNew(ops[0], ops[1])
+	})
+}
+
+// MulRelin multiplies op0 by op1 with relinearization and returns the result in ctOut.
+func (eval *RecordingEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.record("MulRelin", []Operand{op0, op1}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.MulRelin(ops[0], ops[1], ctOut)
+		return ctOut
+	})
+}
+
+// MulRelinNew multiplies op0 by op1 with relinearization and returns the result in a newly created element.
+func (eval *RecordingEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	return eval.record("MulRelinNew", []Operand{op0, op1}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.MulRelinNew(ops[0], ops[1])
+	})
+}
+
+// Relinearize applies the relinearization procedure on ctIn and returns the result in ctOut.
+func (eval *RecordingEvaluator) Relinearize(ctIn *Ciphertext, ctOut *Ciphertext) {
+	eval.record("Relinearize", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Relinearize(ops[0].(*Ciphertext), ctOut)
+		return ctOut
+	})
+}
+
+// RelinearizeNew applies the relinearization procedure on ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) RelinearizeNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return eval.record("RelinearizeNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.RelinearizeNew(ops[0].(*Ciphertext))
+	})
+}
+
+// Rotate rotates the columns of ctIn by k positions to the left and returns the result in ctOut.
+func (eval *RecordingEvaluator) Rotate(ctIn *Ciphertext, k int, ctOut *Ciphertext) {
+	eval.record("Rotate", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Rotate(ops[0].(*Ciphertext), k, ctOut)
+		return ctOut
+	})
+}
+
+// RotateNew rotates the columns of ctIn by k positions to the left and returns the result in a newly created element.
+func (eval *RecordingEvaluator) RotateNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext) {
+	return eval.record("RotateNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.RotateNew(ops[0].(*Ciphertext), k)
+	})
+}
+
+// Rescale divides ctIn by the last modulus while its scale is greater than minScale and returns the result in ctOut.
+func (eval *RecordingEvaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+	eval.record("Rescale", []Operand{ctIn}, ctOut, func(evaluator Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		if errEval := evaluator.Rescale(ops[0].(*Ciphertext), minScale, ctOut); evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ctOut
+	})
+	return
+}
+
+// DropLevel reduces the level of ctIn by levels and returns the result in ctIn.
+func (eval *RecordingEvaluator) DropLevel(ctIn *Ciphertext, levels int) {
+	eval.record("DropLevel", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		eval.DropLevel(ops[0].(*Ciphertext), levels)
+		return ops[0].(*Ciphertext)
+	})
+}
+
+// DropLevelNew reduces the level of ctIn by levels and returns the result in a newly created element.
+func (eval *RecordingEvaluator) DropLevelNew(ctIn *Ciphertext, levels int) (ctOut *Ciphertext) {
+	return eval.record("DropLevelNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.DropLevelNew(ops[0].(*Ciphertext), levels)
+	})
+}
+
+// ScaleUp multiplies ctIn by scale and sets its scale to its previous scale times scale, and returns the result in ctOut.
+func (eval *RecordingEvaluator) ScaleUp(ctIn *Ciphertext, scale float64, ctOut *Ciphertext) {
+	eval.record("ScaleUp", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.ScaleUp(ops[0].(*Ciphertext), scale, ctOut)
+		return ctOut
+	})
+}
+
+// ScaleUpNew multiplies ctIn by scale and sets its scale to its previous scale times scale, and returns the result in a newly created element.
+func (eval *RecordingEvaluator) ScaleUpNew(ctIn *Ciphertext, scale float64) (ctOut *Ciphertext) {
+	return eval.record("ScaleUpNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.ScaleUpNew(ops[0].(*Ciphertext), scale)
+	})
+}
+
+// SetScale sets the scale of ctIn to the input scale (consumes a level).
+func (eval *RecordingEvaluator) SetScale(ctIn *Ciphertext, scale float64) {
+	eval.record("SetScale", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		eval.SetScale(ops[0].(*Ciphertext), scale)
+		return ops[0].(*Ciphertext)
+	})
+}
+
+// Power computes ctIn^degree and returns the result in ctOut.
+func (eval *RecordingEvaluator) Power(ctIn *Ciphertext, degree int, ctOut *Ciphertext) {
+	eval.record("Power", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Power(ops[0].(*Ciphertext), degree, ctOut)
+		return ctOut
+	})
+}
+
+// PowerNew computes ctIn^degree and returns the result in a newly created element.
+func (eval *RecordingEvaluator) PowerNew(ctIn *Ciphertext, degree int) (ctOut *Ciphertext) {
+	return eval.record("PowerNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.PowerNew(ops[0].(*Ciphertext), degree)
+	})
+}
+
+// PowerOf2 computes ctIn^(2^logPow2) and returns the result in ctOut.
+func (eval *RecordingEvaluator) PowerOf2(ctIn *Ciphertext, logPow2 int, ctOut *Ciphertext) {
+	eval.record("PowerOf2", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.PowerOf2(ops[0].(*Ciphertext), logPow2, ctOut)
+		return ctOut
+	})
+}
+
+// EvaluatePoly evaluates the polynomial coeffs on ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) EvaluatePoly(ctIn *Ciphertext, coeffs *Poly, targetScale float64) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("EvaluatePoly", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.EvaluatePoly(ops[0].(*Ciphertext), coeffs, targetScale)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// EvaluateCheby evaluates the Chebyshev interpolant cheby on ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) EvaluateCheby(ctIn *Ciphertext, cheby *ChebyshevInterpolation, targetScale float64) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("EvaluateCheby", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.EvaluateCheby(ops[0].(*Ciphertext), cheby, targetScale)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// EvaluatePlan evaluates the polynomial evaluation plan on ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) EvaluatePlan(ctIn *Ciphertext, plan *PolyEvaluationPlan) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("EvaluatePlan", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.EvaluatePlan(ops[0].(*Ciphertext), plan)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// EvalPiecewise evaluates the piecewise function defined by breakpoints and polys on ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) EvalPiecewise(ctIn *Ciphertext, breakpoints []float64, polys []*Poly, targetScale float64) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("EvalPiecewise", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.EvalPiecewise(ops[0].(*Ciphertext), breakpoints, polys, targetScale)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// RoundNew approximates the rounding of the values of ctIn to the nearest integer and returns the result in a newly created element.
+func (eval *RecordingEvaluator) RoundNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("RoundNew", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.RoundNew(ops[0].(*Ciphertext), K, iterations)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// FloorNew approximates the floor of the values of ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) FloorNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("FloorNew", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.FloorNew(ops[0].(*Ciphertext), K, iterations)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// EvalModNew evaluates the homomorphic modular reduction of the bootstrapping on ctIn and returns the result in a newly created element.
+func (eval *RecordingEvaluator) EvalModNew(ctIn *Ciphertext, evm EvalModParameters) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("EvalModNew", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.EvalModNew(ops[0].(*Ciphertext), evm)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// InverseRangeNew approximates 1/ctIn for values in [a, b] and returns the result in a newly created element.
+func (eval *RecordingEvaluator) InverseRangeNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("InverseRangeNew", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.InverseRangeNew(ops[0].(*Ciphertext), a, b, iterations)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// InvSqrtNew approximates 1/sqrt(ctIn) for values in [a, b] and returns the result in a newly created element.
+func (eval *RecordingEvaluator) InvSqrtNew(ctIn *Ciphertext, a, b float64, iterations int) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("InvSqrtNew", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.InvSqrtNew(ops[0].(*Ciphertext), a, b, iterations)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// MaskSlots zeroes the slots of ctIn for which mask is false and returns the result in ctOut.
+func (eval *RecordingEvaluator) MaskSlots(ctIn *Ciphertext, mask []bool, ctOut *Ciphertext) (err error) {
+	eval.record("MaskSlots", []Operand{ctIn}, ctOut, func(evaluator Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		if errEval := evaluator.MaskSlots(ops[0].(*Ciphertext), mask, ctOut); evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ctOut
+	})
+	return
+}
+
+// MaskSlotsNew zeroes the slots of ctIn for which mask is false and returns the result in a newly created element.
+func (eval *RecordingEvaluator) MaskSlotsNew(ctIn *Ciphertext, mask []bool) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("MaskSlotsNew", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.MaskSlotsNew(ops[0].(*Ciphertext), mask)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// ExtractSlotRange keeps the slots [start, end) of ctIn, rotated to start at the slot 0, and returns the result in ctOut.
+func (eval *RecordingEvaluator) ExtractSlotRange(ctIn *Ciphertext, start, end int, ctOut *Ciphertext) (err error) {
+	eval.record("ExtractSlotRange", []Operand{ctIn}, ctOut, func(evaluator Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		if errEval := evaluator.ExtractSlotRange(ops[0].(*Ciphertext), start, end, ctOut); evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ctOut
+	})
+	return
+}
+
+// ExtractSlotRangeNew keeps the slots [start, end) of ctIn, rotated to start at the slot 0, and returns the result in
+// a newly created element.
+func (eval *RecordingEvaluator) ExtractSlotRangeNew(ctIn *Ciphertext, start, end int) (ctOut *Ciphertext, err error) {
+	ctOut = eval.record("ExtractSlotRangeNew", []Operand{ctIn}, nil, func(evaluator Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		ct, errEval := evaluator.ExtractSlotRangeNew(ops[0].(*Ciphertext), start, end)
+		if evaluator == eval.Evaluator {
+			err = errEval
+		}
+		return ct
+	})
+	return
+}
+
+// InverseNew computes 1/ctIn with the given number of iterations and returns the result in a newly created element.
+func (eval *RecordingEvaluator) InverseNew(ctIn *Ciphertext, steps int) (ctOut *Ciphertext) {
+	return eval.record("InverseNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.InverseNew(ops[0].(*Ciphertext), steps)
+	})
+}
+
+// InnerSum applies an inner sum on ctIn and returns the result in ctOut.
+func (eval *RecordingEvaluator) InnerSum(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext) {
+	eval.record("InnerSum", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.InnerSum(ops[0].(*Ciphertext), batch, n, ctOut)
+		return ctOut
+	})
+}
+
+// InnerSumGroups sums ctIn by groups of n sub-vectors of size batch and returns the result in ctOut.
+func (eval *RecordingEvaluator) InnerSumGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext) {
+	eval.record("InnerSumGroups", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.InnerSumGroups(ops[0].(*Ciphertext), batch, n, layout, ctOut)
+		return ctOut
+	})
+}
+
+// AverageGroups averages ctIn by groups of n sub-vectors of size batch and returns the result in ctOut.
+func (eval *RecordingEvaluator) AverageGroups(ctIn *Ciphertext, batch, n int, layout GroupLayout, ctOut *Ciphertext) {
+	eval.record("AverageGroups", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.AverageGroups(ops[0].(*Ciphertext), batch, n, layout, ctOut)
+		return ctOut
+	})
+}
+
+// Trace evaluates the partial algebraic trace of ctIn from logSlotsEnd to logSlotsStart and returns the result in ctOut.
+func (eval *RecordingEvaluator) Trace(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int, ctOut *Ciphertext) {
+	eval.record("Trace", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.Trace(ops[0].(*Ciphertext), logSlotsStart, logSlotsEnd, ctOut)
+		return ctOut
+	})
+}
+
+// TraceNew evaluates the partial algebraic trace of ctIn from logSlotsEnd to logSlotsStart and returns the result in a
+// newly created element.
+func (eval *RecordingEvaluator) TraceNew(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int) (ctOut *Ciphertext) {
+	return eval.record("TraceNew", []Operand{ctIn}, nil, func(eval Evaluator, ops []Operand, _ *Ciphertext) *Ciphertext {
+		return eval.TraceNew(ops[0].(*Ciphertext), logSlotsStart, logSlotsEnd)
+	})
+}
+
+// InnerSumLog applies an inner sum on ctIn with a logarithmic number of rotations and returns the result in ctOut.
+func (eval *RecordingEvaluator) InnerSumLog(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext) {
+	eval.record("InnerSumLog", []Operand{ctIn}, ctOut, func(eval Evaluator, ops []Operand, ctOut *Ciphertext) *Ciphertext {
+		eval.InnerSumLog(ops[0].(*Ciphertext), batch, n, ctOut)
+		return ctOut
+	})
+}
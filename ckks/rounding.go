@@ -0,0 +1,112 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// roundingLogPrecision is the target log2 precision of the Chebyshev approximation of sin(2*pi*x)/(2*pi)
+// used by RoundNew and FloorNew.
+const roundingLogPrecision = 30
+
+// roundingMaxDegree is the maximum degree of the Chebyshev approximation used by RoundNew and FloorNew.
+const roundingMaxDegree = 255
+
+// RoundNew approximates the rounding of the real values of ctIn to the nearest integer and returns the result
+// in a newly created element.
+//
+// Each iteration evaluates x - sin(2*pi*x)/(2*pi), the function used by the modular reduction of the
+// bootstrapping, whose fixed points are the integers. If x = n + e with n an integer, an iteration maps the
+// error e to about (2*pi)^2 * e^3 / 6, so that values close to integers converge cubically: one iteration is
+// enough to re-synchronize values with an error |e| < 2^-10 to a precision of 2^-27, while values with an
+// arbitrary fractional part require several iterations. Values close to the half-integers converge slowly, and
+// the half-integers themselves are not rounded.
+//
+// The input values must be real and lie in [-K, K]. The sine is approximated on [-K-1/2, K+1/2] by a Chebyshev
+// interpolant of degree 2^d-1, with d the smallest value giving a 2^-30 precision (e.g. d = 5 for K = 1 and
+// d = 7 for K = 8). Each iteration consumes d+1 levels and the output has the scale of ctIn.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) RoundNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalRound(eval, ctIn, K, 0, iterations)
+}
+
+// FloorNew approximates the rounding of the real values of ctIn to the largest integer lower or equal to them,
+// i.e. RoundNew(ctIn - 1/2), and returns the result in a newly created element. The input values must lie
+// in [-K, K] and must not be close to an integer, and the sine is approximated on [-K-1, K+1]. See RoundNew.
+func (eval *evaluator) FloorNew(ctIn *Ciphertext, K float64, iterations int) (ctOut *Ciphertext, err error) {
+	return evalRound(eval, ctIn, K, -0.5, iterations)
+}
+
+// evalRound implements RoundNew and FloorNew on top of the Evaluator interface, so that it is shared by the
+// homomorphic and the cleartext evaluators.
+func evalRound(eval Evaluator, ctIn *Ciphertext, K, offset float64, iterations int) (ctOut *Ciphertext, err error) {
+
+	checkNoSlotScales("RoundNew", ctIn.El())
+
+	if K <= 0 {
+		return nil, fmt.Errorf("cannot RoundNew: K must be positive")
+	}
+
+	if iterations < 1 {
+		return nil, fmt.Errorf("cannot RoundNew: the number of iterations must be at least 1")
+	}
+
+	// The iterations move the values toward their nearest integer, so that they stay in [-K', K']
+	K += math.Abs(offset) + 0.5
+	sine := roundingSineApproximation(K)
+
+	ctOut = ctIn.CopyNew()
+
+	if offset != 0 {
+		eval.AddConst(ctOut, offset, ctOut)
+	}
+
+	for i := 0; i < iterations; i++ {
+
+		// Change of basis y = x/K' for the Chebyshev evaluation
+		y := eval.MultByConstNew(ctOut, 1/K)
+		if err = eval.Rescale(y, ctOut.Scale(), y); err != nil {
+			return nil, err
+		}
+
+		var s *Ciphertext
+		if s, err = eval.EvaluateCheby(y, sine, ctOut.Scale()); err != nil {
+			return nil, err
+		}
+
+		eval.DropLevel(ctOut, ctOut.Level()-s.Level())
+		eval.Sub(ctOut, s, ctOut)
+	}
+
+	return ctOut, nil
+}
+
+// roundingSineApproximation returns the Chebyshev interpolant of sin(2*pi*x)/(2*pi) on [-K, K] of the smallest
+// degree 2^d-1 reaching a precision of 2^-roundingLogPrecision.
+func roundingSineApproximation(K float64) (cheby *ChebyshevInterpolation) {
+
+	sine := func(x complex128) complex128 {
+		return cmplx.Sin(2*math.Pi*x) / (2 * math.Pi)
+	}
+
+	for degree := 7; ; degree = 2*degree + 1 {
+
+		cheby = Approximate(sine, complex(-K, 0), complex(K, 0), degree)
+
+		if degree >= roundingMaxDegree {
+			return
+		}
+
+		// The error is measured on a grid of points of the interval
+		var maxErr float64
+		for i := 0; i <= 8*degree; i++ {
+			x := -K + 2*K*float64(i)/float64(8*degree)
+			maxErr = math.Max(maxErr, math.Abs(real(cheby.evaluate(complex(x, 0)))-real(sine(complex(x, 0)))))
+		}
+
+		if maxErr < math.Exp2(-roundingLogPrecision) {
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// SanitizeNew deliberately truncates the precision of ctIn to about logPrecision bits, as a final step before the
+// release of a result, and returns the result in a newly created element at level 0. It:
+//
+//   - switches ctIn to the lowest level,
+//   - rounds the coefficients of its polynomials to their high-order bits, dropping the bits whose rounding
+//     error stays below half of the target error,
+//   - adds to its first polynomial a fresh discrete Gaussian noise (see ring.DiscreteGaussianSampler) such that
+//     the slots carry, with the rounding error, an additional error of standard deviation 2^-logPrecision.
+//
+// The flooding noise hides the low-order bits of the result, and in particular the error of the computation, which
+// can depend on the secret key and on the inputs. FloodingLogPrecision gives the logPrecision achieving a
+// differential privacy guarantee. The dropped bits are zero, so the result can be compressed with CompressNew at a
+// negligible additional error. The procedure panics if logPrecision is too large for the scale of ctIn (i.e.
+// if the flooding noise would be smaller than the rounding of the integer coefficients) or too small for the modulus.
+func (eval *evaluator) SanitizeNew(ctIn *Ciphertext, logPrecision float64) (ctOut *Ciphertext) {
+
+	ringQ := eval.ringQ
+	q0 := ringQ.Modulus[0]
+	N := float64(ringQ.N)
+
+	// Standard deviation of the noise on the coefficients, such that the slots carry an error of
+	// standard deviation 2^-logPrecision.
+	sigma := ctIn.Scale() * math.Exp2(-logPrecision) / math.Sqrt(N)
+
+	if sigma < 1 {
+		panic(fmt.Sprintf("cannot SanitizeNew: logPrecision=%f is too large for the ciphertext scale", logPrecision))
+	}
+
+	bound := 6 * sigma
+	if bound >= float64(q0>>1) {
+		panic(fmt.Sprintf("cannot SanitizeNew: logPrecision=%f is too small for the modulus q0", logPrecision))
+	}
+
+	// The rounding error of the coefficients e0 + e1*s has a standard deviation of about 2^k * sqrt((1+2N/3)/12)
+	// if the k low-order bits are dropped: at most sigma/2, and the flooding noise makes up the rest of sigma.
+	roundingStd := math.Sqrt((1 + 2*N/3) / 12)
+	droppedBits := int(math.Floor(math.Log2(sigma / (2 * roundingStd))))
+	if droppedBits < 0 {
+		droppedBits = 0
+	}
+
+	var sigmaRounding float64
+	if droppedBits > 0 {
+		sigmaRounding = math.Exp2(float64(droppedBits)) * roundingStd
+	}
+
+	sigmaFlooding := math.Sqrt(sigma*sigma - sigmaRounding*sigmaRounding)
+
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), 0, ctIn.Scale())
+	ctOut.MetaData.IsReal = ctIn.MetaData.IsReal
+	ctOut.MetaData.SlotScales = copySlotScales(ctIn.MetaData.SlotScales)
+	ctOut.MetaData.Tags = ctIn.MetaData.Tags.CopyNew()
+
+	for i := range ctIn.Value {
+
+		if ctIn.IsNTT() {
+			ringQ.InvNTTLvl(0, ctIn.Value[i], ctOut.Value[i])
+		} else {
+			ringQ.CopyLvl(0, ctIn.Value[i], ctOut.Value[i])
+		}
+
+		coeffs := ctOut.Value[i].Coeffs[0]
+		for j, c := range coeffs {
+			coeffs[j] = (roundHighBits(c, droppedBits) << droppedBits) % q0
+		}
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	ring.NewDiscreteGaussianSampler(prng, ringQ, sigmaFlooding, true).ReadAndAddLvl(0, ctOut.Value[0])
+
+	for i := range ctOut.Value {
+		ringQ.NTTLvl(0, ctOut.Value[i], ctOut.Value[i])
+	}
+
+	return
+}
+
+// FloodingLogPrecision returns the logPrecision for Evaluator.SanitizeNew such that the released result satisfies
+// (epsilon, delta)-differential privacy, by the Gaussian mechanism, with respect to any change of l2-norm at most
+// sensitivity of the underlying (slot-domain) message, including its error. The Gaussian mechanism only gives this
+// guarantee for epsilon < 1; the noise is sampled from a rounded Gaussian, so the guarantee is approximate.
+func FloodingLogPrecision(sensitivity, epsilon, delta float64) float64 {
+
+	if sensitivity <= 0 || epsilon <= 0 || delta <= 0 || delta >= 1 {
+		panic("cannot FloodingLogPrecision: sensitivity and epsilon must be positive and delta in ]0, 1[")
+	}
+
+	// The canonical embedding scales the l2-norm of the coefficients by sqrt(N/2) and the standard deviation of
+	// the noise on each slot by sqrt(N), hence the factor sqrt(2) on the standard deviation in the slot domain.
+	return -math.Log2(math.Sqrt2 * sensitivity * math.Sqrt(2*math.Log(1.25/delta)) / epsilon)
+}
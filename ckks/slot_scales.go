@@ -0,0 +1,163 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Per-slot scaling factors (or diagonal rescaling) enable the packing of messages of different magnitudes in the
+// same plaintext: each slot i carries a known factor slotScales[i], by which the message is multiplied before the
+// encoding (see Encoder.EncodeSlotScaledNTT) and divided after the decoding (see Encoder.DecodeSlotScaled), such
+// that the small slots do not waste the precision given by the scale of the plaintext to the largest slot.
+//
+// The slot scales are tracked as metadata by the Evaluator: the additions require operands with the same slot
+// scales, the multiplications multiply them slot-wise and the rotations rotate them. The operations which cannot
+// track them (the addition of constants, the linear transformations and the polynomial evaluation) panic on
+// elements with slot scales. The slot scales are not tracked by the cleartext Evaluator and are not serialized.
+
+// EncodeSlotScaledNTT encodes the values multiplied slot-wise by slotScales on the input plaintext, in the NTT
+// domain, and records the slot scales in the plaintext. slotScales must have 2^logSlots non-zero values.
+func (encoder *encoderComplex128) EncodeSlotScaledNTT(plaintext *Plaintext, values []complex128, slotScales []float64, logSlots int) {
+
+	if len(slotScales) != 1<<logSlots {
+		panic(fmt.Sprintf("cannot EncodeSlotScaledNTT: len(slotScales)=%d does not match the number of slots %d", len(slotScales), 1<<logSlots))
+	}
+
+	if len(values) > len(slotScales) {
+		panic("cannot EncodeSlotScaledNTT: there are more values than slots")
+	}
+
+	scaled := make([]complex128, len(values))
+	for i := range values {
+		if slotScales[i] == 0 {
+			panic(fmt.Sprintf("cannot EncodeSlotScaledNTT: slotScales[%d] is zero", i))
+		}
+		scaled[i] = values[i] * complex(slotScales[i], 0)
+	}
+
+	encoder.EncodeNTT(plaintext, scaled, logSlots)
+	plaintext.SetSlotScales(slotScales)
+}
+
+// DecodeSlotScaled decodes the input plaintext on a new slice of complex128 and divides each slot by its slot
+// scale. It is equivalent to Decode if the plaintext has no slot scales.
+func (encoder *encoderComplex128) DecodeSlotScaled(plaintext *Plaintext, logSlots int) (res []complex128) {
+
+	res = encoder.Decode(plaintext, logSlots)
+
+	if plaintext.MetaData.SlotScales == nil {
+		return
+	}
+
+	if len(plaintext.MetaData.SlotScales) != 1<<logSlots {
+		panic(fmt.Sprintf("cannot DecodeSlotScaled: the plaintext has %d slot scales but logSlots=%d", len(plaintext.MetaData.SlotScales), logSlots))
+	}
+
+	for i := range res {
+		res[i] /= complex(plaintext.MetaData.SlotScales[i], 0)
+	}
+
+	return
+}
+
+// copySlotScales returns a copy of the slot scales, preserving nil.
+func copySlotScales(slotScales []float64) []float64 {
+	if slotScales == nil {
+		return nil
+	}
+	return append([]float64{}, slotScales...)
+}
+
+// slotScalesEqual returns true if the slot scales a and b are equal up to the floating point error, nil being
+// equal to a vector of ones.
+func slotScalesEqual(a, b []float64) bool {
+
+	if a == nil && b == nil {
+		return true
+	}
+
+	if a != nil && b != nil && len(a) != len(b) {
+		return false
+	}
+
+	n := utils.MaxInt(len(a), len(b))
+	for i := 0; i < n; i++ {
+		x, y := slotScaleAt(a, i), slotScaleAt(b, i)
+		if math.Abs(x-y) > 1e-9*math.Max(math.Abs(x), math.Abs(y)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addSlotScales returns the slot scales of the sum of elements with slot scales a and b, and panics if they
+// are not equal.
+func addSlotScales(a, b []float64) []float64 {
+
+	if !slotScalesEqual(a, b) {
+		panic("cannot add elements with different slot scales")
+	}
+
+	if a != nil {
+		return copySlotScales(a)
+	}
+
+	return copySlotScales(b)
+}
+
+// mulSlotScales returns the slot scales of the product of elements with slot scales a and b.
+func mulSlotScales(a, b []float64) (c []float64) {
+
+	if a == nil || b == nil {
+		if a == nil {
+			return copySlotScales(b)
+		}
+		return copySlotScales(a)
+	}
+
+	if len(a) != len(b) {
+		panic("cannot multiply elements with slot scales for different numbers of slots")
+	}
+
+	c = make([]float64, len(a))
+	for i := range a {
+		c[i] = a[i] * b[i]
+	}
+
+	return
+}
+
+// rotateSlotScales returns the slot scales of an element with slot scales s rotated by k positions to the left.
+func rotateSlotScales(s []float64, k int) (r []float64) {
+
+	if s == nil {
+		return nil
+	}
+
+	n := len(s)
+	k = ((k % n) + n) % n
+
+	r = make([]float64, n)
+	for i := range r {
+		r[i] = s[(i+k)%n]
+	}
+
+	return
+}
+
+// checkNoSlotScales panics if the element has slot scales, for the operations that cannot track them.
+func checkNoSlotScales(operation string, el *Element) {
+	if el.MetaData.SlotScales != nil {
+		panic(fmt.Sprintf("cannot %s: the operation does not support elements with slot scales", operation))
+	}
+}
+
+func slotScaleAt(s []float64, i int) float64 {
+	if s == nil {
+		return 1
+	}
+	return s[i]
+}
@@ -0,0 +1,57 @@
+package ckks
+
+import (
+	"fmt"
+)
+
+// Trace evaluates the partial algebraic trace of ctIn from the subfield of 2^logSlotsEnd slots to the subfield of
+// 2^logSlotsStart slots and returns the result in ctOut, i.e. the sum of the images of ctIn by the automorphisms
+// X -> X^(5^(k*2^logSlotsStart)) for 0 <= k < 2^(logSlotsEnd-logSlotsStart). On the slots, indexed with respect to the
+// maximum number of slots N/2, it sums the values of the slots congruent modulo 2^logSlotsStart within each block of
+// 2^logSlotsEnd slots, i.e. it evaluates the sum of the rotations of ctIn by the multiples of 2^logSlotsStart smaller
+// than 2^logSlotsEnd with logSlotsEnd - logSlotsStart rotations. If logSlotsEnd = LogN, the last automorphism is the
+// conjugation, so that the trace from LogN to 0 is the full trace of the plaintext, i.e. N times the real part of its
+// constant coefficient.
+//
+// The trace is used by the bootstrapping to map a ciphertext of 2^logSlots slots to its subring (SubSum), and can be used
+// to pack and unpack sparse ciphertexts and to replicate values on the slots. It does not consume levels, but multiplies
+// the values that are invariant under the automorphisms by 2^(logSlotsEnd-logSlotsStart). The rotation keys are given by
+// Parameters.RotationsForTrace, plus the conjugation key if logSlotsEnd = LogN.
+func (eval *evaluator) Trace(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int, ctOut *Ciphertext) {
+	evalTrace(eval, eval.params, ctIn, logSlotsStart, logSlotsEnd, ctOut)
+}
+
+// TraceNew evaluates the partial algebraic trace of ctIn from logSlotsEnd to logSlotsStart and returns the result in a
+// newly created element. See Trace.
+func (eval *evaluator) TraceNew(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale())
+	eval.Trace(ctIn, logSlotsStart, logSlotsEnd, ctOut)
+	return
+}
+
+// evalTrace implements Trace on top of the Evaluator interface, so that it is shared by the homomorphic and the
+// cleartext evaluators.
+func evalTrace(eval Evaluator, params Parameters, ctIn *Ciphertext, logSlotsStart, logSlotsEnd int, ctOut *Ciphertext) {
+
+	if logSlotsStart < 0 || logSlotsStart > logSlotsEnd || logSlotsEnd > params.LogN() {
+		panic(fmt.Sprintf("cannot Trace: invalid range of slots [%d, %d] for LogN = %d", logSlotsStart, logSlotsEnd, params.LogN()))
+	}
+
+	if ctIn != ctOut {
+		ctOut.Copy(ctIn)
+	}
+
+	if logSlotsStart == logSlotsEnd {
+		return
+	}
+
+	tmp := NewCiphertext(params, ctOut.Degree(), ctOut.Level(), ctOut.Scale())
+	for i := logSlotsStart; i < logSlotsEnd; i++ {
+		if i == params.LogN()-1 {
+			eval.Conjugate(ctOut, tmp)
+		} else {
+			eval.Rotate(ctOut, 1<<i, tmp)
+		}
+		eval.Add(ctOut, tmp, ctOut)
+	}
+}
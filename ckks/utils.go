@@ -27,29 +27,23 @@ func StandardDeviation(vec []float64, scale float64) (std float64) {
 	return math.Sqrt(err/n) * scale
 }
 
-func scaleUpExact(value float64, n float64, q uint64) (res uint64) {
+func scaleUpExact(value *big.Float, n float64, q uint64) (res uint64) {
 
-	var isNegative bool
-	var xFlo *big.Float
-	var xInt *big.Int
+	xFlo := new(big.Float).SetPrec(value.Prec() + 53)
+	xFlo.Mul(value, big.NewFloat(n))
 
-	isNegative = false
-	if value < 0 {
-		isNegative = true
-		xFlo = big.NewFloat(-n * value)
-	} else {
-		xFlo = big.NewFloat(n * value)
-	}
+	isNegative := xFlo.Signbit()
 
+	xFlo.Abs(xFlo)
 	xFlo.Add(xFlo, big.NewFloat(0.5))
 
-	xInt = new(big.Int)
+	xInt := new(big.Int)
 	xFlo.Int(xInt)
 	xInt.Mod(xInt, ring.NewUint(q))
 
 	res = xInt.Uint64()
 
-	if isNegative {
+	if isNegative && res != 0 {
 		res = q - res
 	}
 
@@ -112,6 +106,55 @@ func scaleUpVecExact(values []float64, n float64, moduli []uint64, coeffs [][]ui
 	}
 }
 
+// scaleUpVecStochastic scales the values by n and rounds them to floor(n*values[i] + dither[i]), which is an
+// unbiased stochastic rounding if the dither values are uniform in [0, 1).
+func scaleUpVecStochastic(values, dither []float64, n float64, moduli []uint64, coeffs [][]uint64) {
+
+	var isNegative bool
+	var x float64
+	xInt := new(big.Int)
+	tmp := new(big.Int)
+
+	for i := range values {
+
+		x = math.Floor(n*values[i] + dither[i])
+
+		isNegative = x < 0
+		if isNegative {
+			x = -x
+		}
+
+		if x > 1.8446744073709552e+19 {
+
+			big.NewFloat(x).Int(xInt)
+
+			for j := range moduli {
+				tmp.Mod(xInt, ring.NewUint(moduli[j]))
+				coeffs[j][i] = tmp.Uint64()
+			}
+		} else {
+			for j := range moduli {
+				coeffs[j][i] = uint64(x) % moduli[j]
+			}
+		}
+
+		if isNegative {
+			for j := range moduli {
+				if coeffs[j][i] != 0 {
+					coeffs[j][i] = moduli[j] - coeffs[j][i]
+				}
+			}
+		}
+	}
+
+	for i := range moduli {
+		tmp := coeffs[i]
+		for j := len(values); j < len(tmp); j++ {
+			tmp[j] = 0
+		}
+	}
+}
+
 func scaleUpVecExactBigFloat(values []*big.Float, scale float64, moduli []uint64, coeffs [][]uint64) {
 
 	prec := int(values[0].Prec())
@@ -194,7 +237,10 @@ func GenSwitchkeysRescalingParams(Q, P []uint64) (params []uint64) {
 	return
 }
 
-func sliceBitReverseInPlaceComplex128(slice []complex128, N int) {
+// SliceBitReverseInPlaceComplex128 applies the bit-reversal permutation on the first N elements of slice, with N a
+// power of two: the element at index i is swapped with the element at index utils.BitReverse64(i, log2(N)).
+// This is the permutation applied by the encoder between the slots and its FFT.
+func SliceBitReverseInPlaceComplex128(slice []complex128, N int) {
 
 	var bit, j int
 
@@ -215,7 +261,9 @@ func sliceBitReverseInPlaceComplex128(slice []complex128, N int) {
 	}
 }
 
-func sliceBitReverseInPlaceRingComplex(slice []*ring.Complex, N int) {
+// SliceBitReverseInPlaceRingComplex applies the bit-reversal permutation on the first N elements of slice,
+// with N a power of two (see SliceBitReverseInPlaceComplex128).
+func SliceBitReverseInPlaceRingComplex(slice []*ring.Complex, N int) {
 
 	var bit, j int
 
@@ -235,3 +283,76 @@ func sliceBitReverseInPlaceRingComplex(slice []*ring.Complex, N int) {
 		}
 	}
 }
+
+// SlotRootExponents returns, for a ring degree N = 2^logN and 2^logSlots slots, the exponents e_j = 5^j mod 2N
+// such that the slot j of a plaintext is the evaluation m(zeta^e_j) of its polynomial m(X) = sum_i (m_i/scale) X^i
+// at zeta = exp(i*pi/N), a primitive 2N-th root of unity. The slot j of the conjugated plaintext is the evaluation
+// at zeta^-e_j, and the rotation of the slots by k positions to the left is the automorphism X -> X^(5^k).
+func SlotRootExponents(logN, logSlots int) (exponents []int) {
+
+	if logSlots < 0 || logSlots > logN-1 {
+		panic("cannot SlotRootExponents: logSlots must be in [0, logN-1]")
+	}
+
+	mask := (2 << logN) - 1
+
+	exponents = make([]int, 1<<logSlots)
+	for j, e := 0, 1; j < len(exponents); j, e = j+1, (e*int(GaloisGen))&mask {
+		exponents[j] = e
+	}
+
+	return
+}
+
+// SlotToCoeffIndices returns, for a ring degree N = 2^logN and 2^logSlots slots, the indices re and im of the
+// coefficients of a plaintext storing the real and the imaginary part of the k-th coefficient w_k of the
+// inverse of the canonical embedding of the slots, such that
+//
+//	m(X) = sum_k Re(w_k) X^re(k) + Im(w_k) X^im(k),  with re(k) = k*gap, im(k) = N/2 + k*gap and gap = N/(2*2^logSlots).
+//
+// where the slots are in the order given by SlotRootExponents. In particular, a plaintext with 2^logSlots slots
+// only has non-zero coefficients at the multiples of gap.
+func SlotToCoeffIndices(logN, logSlots, k int) (re, im int) {
+
+	if logSlots < 0 || logSlots > logN-1 {
+		panic("cannot SlotToCoeffIndices: logSlots must be in [0, logN-1]")
+	}
+
+	if k < 0 || k >= 1<<logSlots {
+		panic("cannot SlotToCoeffIndices: k must be in [0, 2^logSlots[")
+	}
+
+	gap := 1 << (logN - 1 - logSlots)
+
+	return k * gap, (1 << (logN - 1)) + k*gap
+}
+
+// Complex128ToBigComplex converts a slice of complex128 into a slice of arbitrary precision complex
+// numbers with logPrecision bits of precision, e.g. to move values from the Encoder to the EncoderBigComplex.
+func Complex128ToBigComplex(values []complex128, logPrecision int) (res []*ring.Complex) {
+	res = make([]*ring.Complex, len(values))
+	for i := range values {
+		res[i] = ring.NewComplex(ring.NewFloat(real(values[i]), logPrecision), ring.NewFloat(imag(values[i]), logPrecision))
+	}
+	return
+}
+
+// BigComplexToComplex128 converts a slice of arbitrary precision complex numbers into a slice
+// of complex128, e.g. to move values from the EncoderBigComplex to the Encoder.
+func BigComplexToComplex128(values []*ring.Complex) (res []complex128) {
+	res = make([]complex128, len(values))
+	for i := range values {
+		res[i] = values[i].Float64()
+	}
+	return
+}
+
+// isRealVector returns true if all the values have a zero imaginary part.
+func isRealVector(values []complex128) bool {
+	for _, v := range values {
+		if imag(v) != 0 {
+			return false
+		}
+	}
+	return true
+}
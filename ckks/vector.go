@@ -0,0 +1,320 @@
+package ckks
+
+import (
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// VectorCiphertext is a vector of arbitrary length encrypted across several Ciphertexts. The i-th Ciphertext
+// stores the values [i*Slots, (i+1)*Slots) of the vector and the slots of the last Ciphertext after the end
+// of the vector are zero. The operations on VectorCiphertexts are carried out by a VectorEvaluator.
+type VectorCiphertext struct {
+	Ciphertexts []*Ciphertext
+	length      int
+}
+
+// NewVectorCiphertext allocates a new VectorCiphertext of the given length with Ciphertexts of degree 1 at the given level and scale.
+func NewVectorCiphertext(params Parameters, length, level int, scale float64) (vct *VectorCiphertext) {
+	vct = &VectorCiphertext{Ciphertexts: make([]*Ciphertext, vectorChunks(params, length)), length: length}
+	for i := range vct.Ciphertexts {
+		vct.Ciphertexts[i] = NewCiphertext(params, 1, level, scale)
+	}
+	return
+}
+
+// vectorChunks returns the number of Ciphertexts needed to store a vector of the given length.
+func vectorChunks(params Parameters, length int) int {
+	if length < 1 {
+		panic("cannot create VectorCiphertext: length must be positive")
+	}
+	return (length + params.Slots() - 1) / params.Slots()
+}
+
+// Len returns the length of the encrypted vector.
+func (vct *VectorCiphertext) Len() int {
+	return vct.length
+}
+
+// Level returns the smallest level among the Ciphertexts of the vector.
+func (vct *VectorCiphertext) Level() (level int) {
+	level = vct.Ciphertexts[0].Level()
+	for _, ct := range vct.Ciphertexts[1:] {
+		level = utils.MinInt(level, ct.Level())
+	}
+	return
+}
+
+// Scale returns the scale of the first Ciphertext of the vector.
+func (vct *VectorCiphertext) Scale() float64 {
+	return vct.Ciphertexts[0].Scale()
+}
+
+// CopyNew creates a deep copy of the receiver VectorCiphertext and returns it.
+func (vct *VectorCiphertext) CopyNew() *VectorCiphertext {
+	cts := make([]*Ciphertext, len(vct.Ciphertexts))
+	for i := range cts {
+		cts[i] = vct.Ciphertexts[i].CopyNew()
+	}
+	return &VectorCiphertext{Ciphertexts: cts, length: vct.length}
+}
+
+// EncryptVectorNew encodes the values at the maximum level and default scale of the parameters, and encrypts them in a new VectorCiphertext.
+func EncryptVectorNew(params Parameters, encoder Encoder, encryptor Encryptor, values []complex128) (vct *VectorCiphertext) {
+
+	slots := params.Slots()
+
+	vct = &VectorCiphertext{Ciphertexts: make([]*Ciphertext, vectorChunks(params, len(values))), length: len(values)}
+
+	chunk := make([]complex128, slots)
+	for i := range vct.Ciphertexts {
+		for j := range chunk {
+			chunk[j] = 0
+		}
+		copy(chunk, values[i*slots:utils.MinInt((i+1)*slots, len(values))])
+		vct.Ciphertexts[i] = encryptor.EncryptNew(encoder.EncodeNTTNew(chunk, params.LogSlots()))
+	}
+
+	return
+}
+
+// DecryptVectorNew decrypts and decodes the VectorCiphertext and returns the values of the vector.
+func DecryptVectorNew(params Parameters, encoder Encoder, decryptor Decryptor, vct *VectorCiphertext) (values []complex128) {
+	values = make([]complex128, 0, len(vct.Ciphertexts)*params.Slots())
+	for _, ct := range vct.Ciphertexts {
+		values = append(values, encoder.Decode(decryptor.DecryptNew(ct), params.LogSlots())...)
+	}
+	return values[:vct.length]
+}
+
+// VectorEvaluator evaluates operations on VectorCiphertexts with an underlying Evaluator. The element-wise operations
+// are applied on each Ciphertext independently, while the rotations move the values across the Ciphertexts.
+type VectorEvaluator struct {
+	params  Parameters
+	eval    Evaluator
+	encoder Encoder
+}
+
+// NewVectorEvaluator creates a new VectorEvaluator operating with eval, which must hold the relinearization key for the
+// multiplications and the rotation keys given by Parameters.RotationsForVectorRotate and Parameters.RotationsForVectorInnerSum.
+func NewVectorEvaluator(params Parameters, eval Evaluator) *VectorEvaluator {
+	return &VectorEvaluator{params: params, eval: eval, encoder: NewEncoder(params)}
+}
+
+func checkVectorsLen(op0, op1 *VectorCiphertext) {
+	if op0.length != op1.length {
+		panic("cannot evaluate: vectors do not have the same length")
+	}
+}
+
+// Add adds op0 to op1 element-wise and returns the result in opOut.
+func (eval *VectorEvaluator) Add(op0, op1, opOut *VectorCiphertext) {
+	checkVectorsLen(op0, op1)
+	checkVectorsLen(op0, opOut)
+	for i := range op0.Ciphertexts {
+		eval.eval.Add(op0.Ciphertexts[i], op1.Ciphertexts[i], opOut.Ciphertexts[i])
+	}
+}
+
+// AddNew adds op0 to op1 element-wise and returns the result in a new VectorCiphertext.
+func (eval *VectorEvaluator) AddNew(op0, op1 *VectorCiphertext) (opOut *VectorCiphertext) {
+	opOut = NewVectorCiphertext(eval.params, op0.length, utils.MinInt(op0.Level(), op1.Level()), 1)
+	eval.Add(op0, op1, opOut)
+	return
+}
+
+// Sub subtracts op1 from op0 element-wise and returns the result in opOut.
+func (eval *VectorEvaluator) Sub(op0, op1, opOut *VectorCiphertext) {
+	checkVectorsLen(op0, op1)
+	checkVectorsLen(op0, opOut)
+	for i := range op0.Ciphertexts {
+		eval.eval.Sub(op0.Ciphertexts[i], op1.Ciphertexts[i], opOut.Ciphertexts[i])
+	}
+}
+
+// SubNew subtracts op1 from op0 element-wise and returns the result in a new VectorCiphertext.
+func (eval *VectorEvaluator) SubNew(op0, op1 *VectorCiphertext) (opOut *VectorCiphertext) {
+	opOut = NewVectorCiphertext(eval.params, op0.length, utils.MinInt(op0.Level(), op1.Level()), 1)
+	eval.Sub(op0, op1, opOut)
+	return
+}
+
+// Mul multiplies op0 with op1 element-wise with relinearization and returns the result in opOut.
+func (eval *VectorEvaluator) Mul(op0, op1, opOut *VectorCiphertext) {
+	checkVectorsLen(op0, op1)
+	checkVectorsLen(op0, opOut)
+	for i := range op0.Ciphertexts {
+		eval.eval.MulRelin(op0.Ciphertexts[i], op1.Ciphertexts[i], opOut.Ciphertexts[i])
+	}
+}
+
+// MulNew multiplies op0 with op1 element-wise with relinearization and returns the result in a new VectorCiphertext.
+func (eval *VectorEvaluator) MulNew(op0, op1 *VectorCiphertext) (opOut *VectorCiphertext) {
+	opOut = NewVectorCiphertext(eval.params, op0.length, utils.MinInt(op0.Level(), op1.Level()), 1)
+	eval.Mul(op0, op1, opOut)
+	return
+}
+
+// MultByConst multiplies op0 by the constant and returns the result in opOut.
+// See Evaluator.MultByConst for the accepted constants and the scale of the output.
+func (eval *VectorEvaluator) MultByConst(op0 *VectorCiphertext, constant interface{}, opOut *VectorCiphertext) {
+	checkVectorsLen(op0, opOut)
+	for i := range op0.Ciphertexts {
+		eval.eval.MultByConst(op0.Ciphertexts[i], constant, opOut.Ciphertexts[i])
+	}
+}
+
+// Rescale rescales each Ciphertext of op0 as long as its scale stays above minScale/2 and returns the result in opOut.
+func (eval *VectorEvaluator) Rescale(op0 *VectorCiphertext, minScale float64, opOut *VectorCiphertext) (err error) {
+	checkVectorsLen(op0, opOut)
+	for i := range op0.Ciphertexts {
+		if err = eval.eval.Rescale(op0.Ciphertexts[i], minScale, opOut.Ciphertexts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vectorRotationTerm is the contribution of the rotation by rot of the input Ciphertext src
+// to the slots of an output Ciphertext for which mask is true.
+type vectorRotationTerm struct {
+	src, rot int
+	mask     []bool
+}
+
+// vectorRotationTerms returns, for each output Ciphertext of the rotation by k of a vector of the given length,
+// the list of the rotated input Ciphertexts it is made of.
+func vectorRotationTerms(slots, length, k int) (terms [][]*vectorRotationTerm) {
+
+	k %= length
+	if k < 0 {
+		k += length
+	}
+
+	terms = make([][]*vectorRotationTerm, (length+slots-1)/slots)
+
+	for j := range terms {
+
+		index := make(map[[2]int]*vectorRotationTerm)
+
+		for s := 0; s < slots && j*slots+s < length; s++ {
+
+			// The slot s of the output j stores the value src of the vector,
+			// which is in the slot s+rot of the input src/slots.
+			src := (j*slots + s + k) % length
+			key := [2]int{src / slots, (src%slots - s + slots) % slots}
+
+			term, ok := index[key]
+			if !ok {
+				term = &vectorRotationTerm{src: key[0], rot: key[1], mask: make([]bool, slots)}
+				index[key] = term
+				terms[j] = append(terms[j], term)
+			}
+
+			term.mask[s] = true
+		}
+	}
+
+	return
+}
+
+// isFull returns true if the mask selects all the slots.
+func (term *vectorRotationTerm) isFull() bool {
+	for _, b := range term.mask {
+		if !b {
+			return false
+		}
+	}
+	return true
+}
+
+// Rotate rotates the vector op0 by k positions to the left, cyclically over its length, and returns the
+// result in opOut. The values crossing the boundaries of the Ciphertexts are moved with masked rotations
+// of the input Ciphertexts: unless k is a multiple of the number of slots and the length of the vector is
+// a multiple of the number of slots, this consumes one level. The required rotation keys are given by
+// Parameters.RotationsForVectorRotate.
+func (eval *VectorEvaluator) Rotate(op0 *VectorCiphertext, k int, opOut *VectorCiphertext) {
+
+	checkVectorsLen(op0, opOut)
+
+	params := eval.params
+	level := op0.Level()
+	scale := op0.Scale()
+
+	rotated := make(map[[2]int]*Ciphertext)
+	getRotated := func(src, rot int) *Ciphertext {
+		key := [2]int{src, rot}
+		if rotated[key] == nil {
+			if rot == 0 {
+				rotated[key] = op0.Ciphertexts[src]
+			} else {
+				rotated[key] = eval.eval.RotateNew(op0.Ciphertexts[src], rot)
+			}
+		}
+		return rotated[key]
+	}
+
+	res := make([]*Ciphertext, len(op0.Ciphertexts))
+
+	for j, terms := range vectorRotationTerms(params.Slots(), op0.length, k) {
+
+		if len(terms) == 1 && terms[0].isFull() {
+			res[j] = getRotated(terms[0].src, terms[0].rot).CopyNew()
+			continue
+		}
+
+		if level == 0 {
+			panic("cannot Rotate: the masking of the values crossing the Ciphertexts requires a level")
+		}
+
+		// The masks are scaled by the last modulus, which is then removed by the rescaling.
+		res[j] = NewCiphertext(params, 1, level, scale*float64(params.Q()[level]))
+		tmp := NewCiphertext(params, 1, level, scale)
+		mask := make([]complex128, params.Slots())
+
+		for _, term := range terms {
+
+			for s := range mask {
+				mask[s] = 0
+				if term.mask[s] {
+					mask[s] = 1
+				}
+			}
+
+			pt := NewPlaintext(params, level, float64(params.Q()[level]))
+			eval.encoder.EncodeNTT(pt, mask, params.LogSlots())
+
+			eval.eval.Mul(getRotated(term.src, term.rot), pt, tmp)
+			eval.eval.Add(res[j], tmp, res[j])
+		}
+
+		if err := eval.eval.Rescale(res[j], scale, res[j]); err != nil {
+			panic(err)
+		}
+
+		res[j].SetScale(scale)
+	}
+
+	opOut.Ciphertexts = res
+}
+
+// RotateNew rotates the vector op0 by k positions to the left, cyclically over its length, and returns the
+// result in a new VectorCiphertext. See Rotate for the details.
+func (eval *VectorEvaluator) RotateNew(op0 *VectorCiphertext, k int) (opOut *VectorCiphertext) {
+	opOut = &VectorCiphertext{length: op0.length}
+	eval.Rotate(op0, k, opOut)
+	return
+}
+
+// InnerSum returns a new Ciphertext in which all the slots store the sum of all the values of the vector op0.
+// The Ciphertexts of the vector are first added together, then summed with Evaluator.InnerSumLog, which
+// requires the rotation keys given by Parameters.RotationsForVectorInnerSum.
+func (eval *VectorEvaluator) InnerSum(op0 *VectorCiphertext) (ctOut *Ciphertext) {
+
+	ctOut = op0.Ciphertexts[0].CopyNew()
+	for _, ct := range op0.Ciphertexts[1:] {
+		eval.eval.Add(ctOut, ct, ctOut)
+	}
+
+	eval.eval.InnerSumLog(ctOut, 1, eval.params.Slots(), ctOut)
+
+	return
+}
@@ -0,0 +1,128 @@
+// Command lattigo provides command-line tools for the Lattigo library.
+//
+// Usage:
+//
+//	lattigo <command> [flags]
+//
+// The commands are:
+//
+//	plan    ranks the default BFV and CKKS parameter sets for a workload (see package planner)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/planner"
+)
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: lattigo <command> [flags]\n\n")
+	fmt.Fprintf(w, "Commands:\n")
+	fmt.Fprintf(w, "  plan    ranks the default BFV and CKKS parameter sets for a workload\n\n")
+	fmt.Fprintf(w, "Run 'lattigo <command> -h' for the flags of a command.\n")
+}
+
+func main() {
+
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "plan":
+		if err := plan(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+}
+
+func plan(args []string, out io.Writer) (err error) {
+
+	var w planner.Workload
+
+	flags := flag.NewFlagSet("plan", flag.ContinueOnError)
+	scheme := flags.String("scheme", "any", "scheme of the candidates: any, bfv or ckks")
+	flags.IntVar(&w.VectorSize, "vector-size", 1<<12, "number of values of each input vector")
+	flags.IntVar(&w.Inputs, "inputs", 1, "number of input vectors uploaded per request")
+	flags.IntVar(&w.Outputs, "outputs", 1, "number of output vectors downloaded per request")
+	flags.IntVar(&w.Depth, "depth", 1, "multiplicative depth of the computation")
+	flags.IntVar(&w.Additions, "adds", 0, "number of additions per input vector")
+	flags.IntVar(&w.Multiplications, "muls", 1, "number of multiplications per input vector")
+	flags.IntVar(&w.Rotations, "rots", 0, "number of rotations per input vector")
+	flags.IntVar(&w.RotationKeys, "rotation-keys", 0, "number of distinct rotations")
+	flags.DurationVar(&w.LatencyTarget, "latency", 0, "latency target per request, 0 for none")
+	flags.IntVar(&w.BandwidthBudget, "bandwidth", 0, "budget in bytes of the ciphertexts exchanged per request, 0 for none")
+	flags.Float64Var(&w.MinSecurity, "security", 128, "minimum classical security level in bits")
+	calibrate := flags.Bool("calibrate", false, "measure the cost model on this host instead of using the default one")
+	top := flags.Int("top", 0, "number of configurations to print, 0 for all")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(*scheme) {
+	case "any":
+		w.Scheme = planner.AnyScheme
+	case "bfv":
+		w.Scheme = planner.BFV
+	case "ckks":
+		w.Scheme = planner.CKKS
+	default:
+		return fmt.Errorf("invalid scheme %q", *scheme)
+	}
+
+	costModel := planner.DefaultCostModel
+	if *calibrate {
+		costModel = planner.MeasureCostModel(14)
+	}
+
+	configurations, err := planner.Plan(w, planner.DefaultCandidates(), costModel)
+	if err != nil {
+		return err
+	}
+
+	if *top > 0 && *top < len(configurations) {
+		configurations = configurations[:*top]
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tSCHEME\tPARAMETERS\tLOGN\tLOGQP\tDEPTH\tSECURITY\tCT/VECTOR\tLATENCY\tBANDWIDTH\tKEYS\tSTATUS")
+	for i, c := range configurations {
+		status := "ok"
+		if !c.Feasible() {
+			status = strings.Join(c.Violations, "; ")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%d\t%.1f\t%d\t%v\t%s\t%s\t%s\n",
+			i+1, c.Scheme, c.Name, c.Parameters.LogN(), c.Parameters.LogQP(), c.MaxDepth, c.Security.Classical,
+			c.CiphertextsPerVector, c.Latency.Round(time.Microsecond), formatBytes(c.Bandwidth), formatBytes(c.KeysSize()), status)
+	}
+
+	return tw.Flush()
+}
+
+func formatBytes(n int) string {
+	const unit = 1 << 10
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,125 @@
+package cryptodb
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Aggregator evaluates the aggregations of a Plan on encrypted columns.
+// An Aggregator is not safe for concurrent use.
+type Aggregator struct {
+	plan  *Plan
+	eval  bfv.Evaluator
+	units []*bfv.PlaintextMul
+	tmp   *bfv.Ciphertext
+}
+
+// NewAggregator creates a new Aggregator evaluating the aggregations of plan with the evaluation key evk, which
+// must contain the keys listed by the plan (see Plan.GenEvaluationKey).
+func NewAggregator(plan *Plan, evk rlwe.EvaluationKey) *Aggregator {
+
+	params := plan.params
+	encoder := bfv.NewEncoder(params)
+
+	// The unit vectors of the output slots, which select the results and clear the partial sums of the
+	// other slots
+	units := make([]*bfv.PlaintextMul, plan.groups)
+	slots := make([]uint64, params.N())
+	for g := range units {
+		slots[plan.OutputSlot(g)] = 1
+		units[g] = bfv.NewPlaintextMul(params)
+		encoder.EncodeUintMul(slots, units[g])
+		slots[plan.OutputSlot(g)] = 0
+	}
+
+	return &Aggregator{
+		plan:  plan,
+		eval:  bfv.NewEvaluator(params, evk),
+		units: units,
+		tmp:   bfv.NewCiphertext(params, 1),
+	}
+}
+
+// Sum returns the encryption of the sum of the records of column selected by mask, which is a public mask
+// (*bfv.PlaintextMul) or an encrypted mask (*bfv.Ciphertext), in the output slot of the group 0.
+func (a *Aggregator) Sum(column *bfv.Ciphertext, mask bfv.Operand) (ctOut *bfv.Ciphertext) {
+	return a.SumBy(column, []bfv.Operand{mask})
+}
+
+// SumBy returns the encryption of the sums of the records of column selected by each of the masks, i.e. the
+// SUM of a GROUP BY whose groups are selected by the masks, in the output slots of the groups (see
+// Plan.OutputSlot). The masks are public (*bfv.PlaintextMul) or encrypted (*bfv.Ciphertext).
+func (a *Aggregator) SumBy(column *bfv.Ciphertext, masks []bfv.Operand) (ctOut *bfv.Ciphertext) {
+
+	a.checkGroups(len(masks))
+
+	ctOut = bfv.NewCiphertext(a.plan.params, 1)
+	masked := bfv.NewCiphertext(a.plan.params, 1)
+
+	for g, mask := range masks {
+
+		switch mask := mask.(type) {
+		case *bfv.PlaintextMul:
+			a.eval.Mul(column, mask, masked)
+		case *bfv.Ciphertext:
+			if !a.plan.encryptedMasks {
+				panic("cannot SumBy: the plan does not support encrypted masks")
+			}
+			a.eval.Relinearize(a.eval.MulNew(column, mask), masked)
+		default:
+			panic(fmt.Sprintf("cannot SumBy: invalid mask type %T", mask))
+		}
+
+		a.aggregate(g, masked, ctOut)
+	}
+
+	return
+}
+
+// Count returns the encryption of the number of records selected by the encrypted mask in the output slot of
+// the group 0. The number of records selected by a public mask is public (see CountSelections).
+func (a *Aggregator) Count(mask *bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+	return a.CountBy([]*bfv.Ciphertext{mask})
+}
+
+// CountBy returns the encryption of the number of records selected by each of the encrypted masks, i.e. the
+// COUNT of a GROUP BY whose groups are selected by the masks, in the output slots of the groups.
+func (a *Aggregator) CountBy(masks []*bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+
+	a.checkGroups(len(masks))
+
+	ctOut = bfv.NewCiphertext(a.plan.params, 1)
+	masked := bfv.NewCiphertext(a.plan.params, 1)
+
+	for g, mask := range masks {
+		masked.Copy(mask.El())
+		a.aggregate(g, masked, ctOut)
+	}
+
+	return
+}
+
+func (a *Aggregator) checkGroups(groups int) {
+	if groups < 1 || groups > a.plan.groups {
+		panic(fmt.Sprintf("cannot aggregate: the number of masks must be in [1, %d]", a.plan.groups))
+	}
+}
+
+// aggregate reduces ct, which is overwritten, and adds its sum to the output slot of the group g of ctOut.
+func (a *Aggregator) aggregate(g int, ct, ctOut *bfv.Ciphertext) {
+
+	for _, k := range a.plan.rotations {
+		a.eval.RotateColumns(ct, k, a.tmp)
+		a.eval.Add(ct, a.tmp, ct)
+	}
+
+	if a.plan.rotateRows {
+		a.eval.RotateRows(ct, a.tmp)
+		a.eval.Add(ct, a.tmp, ct)
+	}
+
+	a.eval.Mul(ct, a.units[g], ct)
+	a.eval.Add(ctOut, ct, ctOut)
+}
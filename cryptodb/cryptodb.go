@@ -0,0 +1,229 @@
+// Package cryptodb implements SQL-like aggregations (SUM, COUNT and AVG, with GROUP BY) over the columns of a table
+// encrypted with the BFV scheme, for privacy-preserving analytics.
+//
+// A column stores one record per slot, in the order of the slots (see bfv.Encoder.EncodeUint), and the slots
+// beyond the last record must be zero. The records taken into account by an aggregation are selected by a mask,
+// which stores 1 in the slots of the selected records and 0 elsewhere, and which can be public (a
+// bfv.PlaintextMul, see EncodeSelection) or encrypted (a bfv.Ciphertext). The masked records are reduced with a
+// tree of rotations and additions, and the results of the groups are packed in the slots of a single ciphertext
+// (see Plan.OutputSlot), the other slots being set to zero so that the decryptor learns nothing but the
+// aggregates.
+//
+// The aggregates are computed modulo the plaintext modulus t of the parameters: the sums of the groups must be
+// smaller than t to be decoded exactly. The averages are computed by the decryptor from the decrypted sums and
+// counts (see Averages). An aggregation consumes the noise budget of two multiplications, by the mask and by the
+// unit vector of the output slot, which requires parameters with a large enough modulus Q (e.g. bfv.PN13QP218).
+package cryptodb
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Plan describes the aggregations over a table of a given number of records with a given number of groups, and
+// the evaluation keys they require. The reduction sums, in each row of N/2 slots, the windows of 2^k slots with
+// k rotations by powers of two, where 2^k is the smallest window covering the records and the output slots of
+// the groups, and tables of more than N/2 records additionally require the rotation of the rows.
+type Plan struct {
+	params         bfv.Parameters
+	records        int
+	groups         int
+	encryptedMasks bool
+
+	rotations  []int
+	rotateRows bool
+	offset     int
+}
+
+// NewPlan creates the Plan of the aggregations over a table of records records, in groups groups (1 without
+// GROUP BY), with public masks or, if encryptedMasks is true, encrypted masks. Returns an error if the records do
+// not fit in the slots of the parameters or if the results of the groups do not fit in a row.
+func NewPlan(params bfv.Parameters, records, groups int, encryptedMasks bool) (p *Plan, err error) {
+
+	slots := params.N() >> 1
+
+	if records < 1 || records > params.N() {
+		return nil, fmt.Errorf("cannot NewPlan: the number of records must be in [1, %d]", params.N())
+	}
+
+	if groups < 1 || groups > slots {
+		return nil, fmt.Errorf("cannot NewPlan: the number of groups must be in [1, %d]", slots)
+	}
+
+	p = &Plan{params: params, records: records, groups: groups, encryptedMasks: encryptedMasks}
+
+	recordsPerRow := records
+	if records > slots {
+		recordsPerRow = slots
+		p.rotateRows = true
+	}
+
+	// With the right rotations by 1, 2, ..., 2^k, the slot j receives the sum of the slots j-2^(k+1)+1 to j:
+	// the slots 2^k-1 to 2^(k+1)-1 all receive the sum of the records if there are at most 2^k of them.
+	k := bits.Len(uint(utils.MaxInt(recordsPerRow, groups-1) - 1))
+
+	if 2<<k <= slots {
+		for i := 0; i <= k; i++ {
+			p.rotations = append(p.rotations, -(1 << i))
+		}
+		p.offset = (1 << k) - 1
+	} else {
+		// The window is the full row: all the slots receive the sum
+		for i := 1; i < slots; i <<= 1 {
+			p.rotations = append(p.rotations, i)
+		}
+	}
+
+	return p, nil
+}
+
+// Records returns the number of records of the table.
+func (p *Plan) Records() int {
+	return p.records
+}
+
+// Groups returns the number of groups of the aggregations.
+func (p *Plan) Groups() int {
+	return p.groups
+}
+
+// Rotations returns the column rotations performed by the reduction.
+func (p *Plan) Rotations() []int {
+	return append([]int{}, p.rotations...)
+}
+
+// RotateRows returns true if the reduction rotates the rows, i.e. if the table has more than N/2 records.
+func (p *Plan) RotateRows() bool {
+	return p.rotateRows
+}
+
+// NeedsRelinearizationKey returns true if the aggregations require a relinearization key, i.e. if the masks are
+// encrypted.
+func (p *Plan) NeedsRelinearizationKey() bool {
+	return p.encryptedMasks
+}
+
+// GaloisElements returns the Galois elements of the rotation keys required by the aggregations.
+func (p *Plan) GaloisElements() (galEls []uint64) {
+	for _, k := range p.rotations {
+		galEls = append(galEls, p.params.GaloisElementForColumnRotationBy(k))
+	}
+	if p.rotateRows {
+		galEls = append(galEls, p.params.GaloisElementForRowRotation())
+	}
+	return
+}
+
+// GenEvaluationKey generates with the secret key sk the evaluation key required by the aggregations, i.e. the
+// rotation keys of GaloisElements and, if needed, the relinearization key.
+func (p *Plan) GenEvaluationKey(kgen bfv.KeyGenerator, sk *rlwe.SecretKey) (evk rlwe.EvaluationKey) {
+	if p.encryptedMasks {
+		evk.Rlk = kgen.GenRelinearizationKey(sk, 1)
+	}
+	evk.Rtks = kgen.GenRotationKeys(p.GaloisElements(), sk)
+	return
+}
+
+// OutputSlot returns the slot storing the result of the group g in the ciphertexts returned by the aggregations.
+func (p *Plan) OutputSlot(g int) int {
+	return p.offset + g
+}
+
+// Results returns the results of the groups from the decoded slots of a ciphertext returned by the aggregations.
+func (p *Plan) Results(slots []uint64) (results []uint64) {
+	results = make([]uint64, p.groups)
+	for g := range results {
+		results[g] = slots[p.OutputSlot(g)]
+	}
+	return
+}
+
+// Averages returns the averages of the groups from their decrypted sums and counts (see Plan.Results). The
+// average of an empty group is NaN.
+func Averages(sums, counts []uint64) (avgs []float64) {
+	avgs = make([]float64, len(sums))
+	for g := range avgs {
+		if counts[g] == 0 {
+			avgs[g] = math.NaN()
+			continue
+		}
+		avgs[g] = float64(sums[g]) / float64(counts[g])
+	}
+	return
+}
+
+// EncodeColumn encodes the records values of a column, which must be smaller than the plaintext modulus, on a
+// plaintext to be encrypted. The slots beyond the records are set to zero.
+func EncodeColumn(encoder bfv.Encoder, params bfv.Parameters, values []uint64) (pt *bfv.Plaintext) {
+
+	if len(values) > params.N() {
+		panic(fmt.Sprintf("cannot EncodeColumn: the number of records (%d) is larger than the number of slots (%d)", len(values), params.N()))
+	}
+
+	pt = bfv.NewPlaintext(params)
+	encoder.EncodeUint(values, pt)
+	return
+}
+
+// EncodeSelection encodes the public mask of the selected records on a plaintext to be multiplied with the
+// columns.
+func EncodeSelection(encoder bfv.Encoder, params bfv.Parameters, selected []bool) (pt *bfv.PlaintextMul) {
+	pt = bfv.NewPlaintextMul(params)
+	encoder.EncodeUintMul(selectionSlots(params, selected), pt)
+	return
+}
+
+// EncodeSelectionForEncryption encodes the mask of the selected records on a plaintext to be encrypted, for
+// the masks that must be hidden from the evaluator.
+func EncodeSelectionForEncryption(encoder bfv.Encoder, params bfv.Parameters, selected []bool) (pt *bfv.Plaintext) {
+	pt = bfv.NewPlaintext(params)
+	encoder.EncodeUint(selectionSlots(params, selected), pt)
+	return
+}
+
+func selectionSlots(params bfv.Parameters, selected []bool) (slots []uint64) {
+
+	if len(selected) > params.N() {
+		panic(fmt.Sprintf("cannot encode the selection: the number of records (%d) is larger than the number of slots (%d)", len(selected), params.N()))
+	}
+
+	slots = make([]uint64, len(selected))
+	for i, s := range selected {
+		if s {
+			slots[i] = 1
+		}
+	}
+	return
+}
+
+// GroupSelections returns, for each key of keys, the selection of the records whose value in the column is
+// equal to the key, i.e. the masks of a GROUP BY on a column held in clear.
+func GroupSelections(column []uint64, keys []uint64) (selections [][]bool) {
+	selections = make([][]bool, len(keys))
+	for g, key := range keys {
+		selections[g] = make([]bool, len(column))
+		for i, v := range column {
+			selections[g][i] = v == key
+		}
+	}
+	return
+}
+
+// CountSelections returns the number of records selected by each of the selections, i.e. the COUNT of a GROUP BY
+// with public masks.
+func CountSelections(selections [][]bool) (counts []uint64) {
+	counts = make([]uint64, len(selections))
+	for g, selected := range selections {
+		for _, s := range selected {
+			if s {
+				counts[g]++
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package cryptodb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoDB(t *testing.T) {
+
+	params, err := bfv.NewParametersFromLiteral(bfv.PN13QP218)
+	require.NoError(t, err)
+
+	kgen := bfv.NewKeyGenerator(params)
+	sk, pk := kgen.GenKeyPair()
+	encoder := bfv.NewEncoder(params)
+	encryptor := bfv.NewEncryptorFromPk(params, pk)
+	decryptor := bfv.NewDecryptor(params, sk)
+
+	decrypt := func(plan *Plan, ct *bfv.Ciphertext) []uint64 {
+		slots := encoder.DecodeUintNew(decryptor.DecryptNew(ct))
+		// All the slots but the output slots are cleared
+		for i, v := range slots {
+			if i < plan.OutputSlot(0) || i >= plan.OutputSlot(plan.Groups()) {
+				require.Zero(t, v)
+			}
+		}
+		return plan.Results(slots)
+	}
+
+	// newTable returns a column of records values and the group of each record in [0, groups)
+	newTable := func(records, groups int) (values, keys []uint64, want []uint64) {
+		values, keys, want = make([]uint64, records), make([]uint64, records), make([]uint64, groups)
+		for i := range values {
+			values[i] = utils.RandUint64() % 1000
+			keys[i] = utils.RandUint64() % uint64(groups)
+			want[keys[i]] += values[i]
+		}
+		return
+	}
+
+	groupKeys := func(groups int) (keys []uint64) {
+		keys = make([]uint64, groups)
+		for g := range keys {
+			keys[g] = uint64(g)
+		}
+		return
+	}
+
+	t.Run("NewPlan", func(t *testing.T) {
+
+		_, err := NewPlan(params, 0, 1, false)
+		require.Error(t, err)
+		_, err = NewPlan(params, params.N()+1, 1, false)
+		require.Error(t, err)
+		_, err = NewPlan(params, 10, 0, false)
+		require.Error(t, err)
+		_, err = NewPlan(params, 10, params.N()/2+1, false)
+		require.Error(t, err)
+
+		// 100 records: windows of 2^8 slots
+		plan, err := NewPlan(params, 100, 3, false)
+		require.NoError(t, err)
+		require.Len(t, plan.Rotations(), 8)
+		require.False(t, plan.RotateRows())
+		require.False(t, plan.NeedsRelinearizationKey())
+		require.Equal(t, 127, plan.OutputSlot(0))
+
+		// The output slots of the groups widen the window
+		plan, err = NewPlan(params, 100, 200, false)
+		require.NoError(t, err)
+		require.Len(t, plan.Rotations(), 9)
+
+		// More than N/2 records: full rows
+		plan, err = NewPlan(params, params.N()/2+1, 1, true)
+		require.NoError(t, err)
+		require.Len(t, plan.Rotations(), params.LogN()-1)
+		require.True(t, plan.RotateRows())
+		require.True(t, plan.NeedsRelinearizationKey())
+		require.Len(t, plan.GaloisElements(), params.LogN())
+		require.Equal(t, 0, plan.OutputSlot(0))
+	})
+
+	t.Run("GroupBy/PublicMasks", func(t *testing.T) {
+
+		records, groups := 100, 3
+
+		plan, err := NewPlan(params, records, groups, false)
+		require.NoError(t, err)
+
+		aggregator := NewAggregator(plan, plan.GenEvaluationKey(kgen, sk))
+
+		values, keys, want := newTable(records, groups)
+		column := encryptor.EncryptNew(EncodeColumn(encoder, params, values))
+
+		selections := GroupSelections(keys, groupKeys(groups))
+		masks := make([]bfv.Operand, groups)
+		for g := range masks {
+			masks[g] = EncodeSelection(encoder, params, selections[g])
+		}
+
+		sums := decrypt(plan, aggregator.SumBy(column, masks))
+		require.Equal(t, want, sums)
+
+		counts := CountSelections(selections)
+		avgs := Averages(sums, counts)
+		for g := range avgs {
+			require.InDelta(t, float64(want[g])/float64(counts[g]), avgs[g], 1e-9)
+		}
+
+		// Sum with a single mask selecting the records of the group 1
+		require.Equal(t, want[1], decrypt(plan, aggregator.Sum(column, masks[1]))[0])
+	})
+
+	t.Run("GroupBy/EncryptedMasks", func(t *testing.T) {
+
+		records, groups := params.N()/2+100, 2
+
+		plan, err := NewPlan(params, records, groups, true)
+		require.NoError(t, err)
+
+		aggregator := NewAggregator(plan, plan.GenEvaluationKey(kgen, sk))
+
+		values, keys, want := newTable(records, groups)
+		column := encryptor.EncryptNew(EncodeColumn(encoder, params, values))
+
+		selections := GroupSelections(keys, groupKeys(groups))
+		masks := make([]*bfv.Ciphertext, groups)
+		operands := make([]bfv.Operand, groups)
+		for g := range masks {
+			masks[g] = encryptor.EncryptNew(EncodeSelectionForEncryption(encoder, params, selections[g]))
+			operands[g] = masks[g]
+		}
+
+		sums := decrypt(plan, aggregator.SumBy(column, operands))
+		counts := decrypt(plan, aggregator.CountBy(masks))
+
+		// The sums are computed modulo t
+		for g := range want {
+			want[g] %= params.T()
+		}
+
+		require.Equal(t, want, sums)
+		require.Equal(t, CountSelections(selections), counts)
+		require.Equal(t, counts[0], decrypt(plan, aggregator.Count(masks[0]))[0])
+	})
+
+	t.Run("Averages/EmptyGroup", func(t *testing.T) {
+		avgs := Averages([]uint64{6, 0}, []uint64{3, 0})
+		require.Equal(t, 2.0, avgs[0])
+		require.True(t, math.IsNaN(avgs[1]))
+	})
+}
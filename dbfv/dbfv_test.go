@@ -2,14 +2,17 @@ package dbfv
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"math/big"
+	"sync"
 	"testing"
 
 	"github.com/ldsec/lattigo/v2/bfv"
 	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/internal/prngtest"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -48,6 +51,10 @@ type testContext struct {
 	evaluator    bfv.Evaluator
 }
 
+func TestMain(m *testing.M) {
+	prngtest.Main(m)
+}
+
 func Test_DBFV(t *testing.T) {
 
 	defaultParams := bfv.DefaultParams // the default test runs for ring degree N=2^12, 2^13, 2^14, 2^15
@@ -75,8 +82,10 @@ func Test_DBFV(t *testing.T) {
 		testRelinKeyGen(testCtx, t)
 		testKeyswitching(testCtx, t)
 		testPublicKeySwitching(testCtx, t)
+		testThreshold(testCtx, t)
 		testRotKeyGenRotRows(testCtx, t)
 		testRotKeyGenRotCols(testCtx, t)
+		testRotKeyGenSet(testCtx, t)
 		testRefresh(testCtx, t)
 		testRefreshAndPermutation(testCtx, t)
 		testMarshalling(testCtx, t)
@@ -178,8 +187,111 @@ func testPublicKeyGen(testCtx *testContext, t *testing.T) {
 
 		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
 	})
+
+	t.Run(testString("PublicKeyGen/Verifiable/", parties, testCtx.params), func(t *testing.T) {
+
+		crp := ring.NewUniformSampler(testCtx.prng, testCtx.dbfvContext.ringQP).ReadNew()
+
+		ids := make([]drlwe.PartyID, parties)
+		for i := range ids {
+			ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+		}
+
+		ckg := NewCKGProtocol(testCtx.params)
+		digest, err := drlwe.CiphertextDigest([]*ring.Poly{crp})
+		require.NoError(t, err)
+
+		// commit generates the shares of the parties and their commitments, which are recorded by agg
+		commit := func(agg *drlwe.VerifiableAggregator, malformed int) (openings []*drlwe.ShareOpening) {
+			openings = make([]*drlwe.ShareOpening, parties)
+			for i, id := range ids {
+				share := ckg.AllocateShares()
+				ckg.GenShare(sk0Shards[i], crp, share)
+				if i == malformed {
+					share.Coeffs[0][0] = testCtx.params.Q()[0]
+				}
+
+				var commitment drlwe.Commitment
+				commitment, openings[i], err = drlwe.CommitShare(digest, id, share)
+				require.NoError(t, err)
+
+				_, err = agg.AddOpening(id, openings[i])
+				require.Error(t, err)
+				require.NoError(t, agg.AddCommitment(id, commitment))
+			}
+			require.True(t, agg.CommitmentsComplete())
+			return
+		}
+
+		// Honest parties
+		agg, err := ckg.NewVerifiableAggregator(ids, crp)
+		require.NoError(t, err)
+
+		for i, opening := range commit(agg, -1) {
+			added, err := agg.AddOpening(ids[i], opening)
+			require.NoError(t, err)
+			require.True(t, added)
+		}
+
+		require.True(t, agg.Complete())
+		require.Empty(t, agg.Cheaters())
+
+		pk := bfv.NewPublicKey(testCtx.params)
+		ckg.GenPublicKey(agg.Aggregated().(*drlwe.CKGShare), crp, pk)
+		coeffs, _, ciphertext := newTestVectors(testCtx, bfv.NewEncryptorFromPk(testCtx.params, pk), t)
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
+
+		// The party 1 reveals a malformed share and the party 2 reveals a share that does not match its commitment
+		agg, err = ckg.NewVerifiableAggregator(ids, crp)
+		require.NoError(t, err)
+
+		openings := commit(agg, 1)
+		tampered := &drlwe.ShareOpening{Share: append([]byte{}, openings[2].Share...), Nonce: openings[2].Nonce}
+		tampered.Share[len(tampered.Share)-1] ^= 1
+		openings[2] = tampered
+
+		_, err = agg.AddOpening(ids[0], openings[0])
+		require.NoError(t, err)
+
+		for _, i := range []int{1, 2} {
+			_, err = agg.AddOpening(ids[i], openings[i])
+			var cheating *drlwe.CheatingError
+			require.True(t, errors.As(err, &cheating))
+			require.Equal(t, ids[i], cheating.Party)
+		}
+
+		require.Equal(t, ids[1:], agg.Cheaters())
+		require.Empty(t, agg.Missing())
+		require.False(t, agg.Complete())
+
+		// The party 0 commits to an empty, truncated or inconsistent encoding of its share
+		for _, data := range [][]byte{{}, {1}, {63, 2}, openings[0].Share[:len(openings[0].Share)/2]} {
+			agg, err = ckg.NewVerifiableAggregator(ids, crp)
+			require.NoError(t, err)
+
+			commitment, opening, err := drlwe.CommitShare(digest, ids[0], rawShare(data))
+			require.NoError(t, err)
+			require.NoError(t, agg.AddCommitment(ids[0], commitment))
+			for _, id := range ids[1:] {
+				require.NoError(t, agg.AddCommitment(id, drlwe.Commitment{}))
+			}
+
+			_, err = agg.AddOpening(ids[0], opening)
+			var cheating *drlwe.CheatingError
+			require.True(t, errors.As(err, &cheating))
+			require.Equal(t, ids[0], cheating.Party)
+			require.Equal(t, ids[:1], agg.Cheaters())
+		}
+	})
 }
 
+// rawShare is a drlwe.Share whose encoding is the given bytes.
+type rawShare []byte
+
+func (share rawShare) MarshalBinary() ([]byte, error) { return share, nil }
+
+func (share rawShare) UnmarshalBinary(data []byte) error { return nil }
+
 func testRelinKeyGen(testCtx *testContext, t *testing.T) {
 
 	sk0Shards := testCtx.sk0Shards
@@ -303,6 +415,215 @@ func testKeyswitching(testCtx *testContext, t *testing.T) {
 		verifyTestVectors(testCtx, decryptorSk1, coeffs, ciphertext, t)
 
 	})
+
+	t.Run(testString("Keyswitching/DecryptionTranscript/", parties, testCtx.params), func(t *testing.T) {
+
+		ids := make([]drlwe.PartyID, parties)
+		for i := range ids {
+			ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		// Collective decryption: key-switching to the zero secret key, with the shares committed in a transcript
+		cks := NewCKSProtocol(testCtx.params, 6.36)
+
+		transcript, err := cks.NewDecryptionTranscript(ids, ciphertext)
+		require.NoError(t, err)
+
+		commitments := make([]drlwe.Commitment, parties)
+		openings := make([]*drlwe.ShareOpening, parties)
+
+		for i, id := range ids {
+			commitments[i], openings[i], err = cks.GenDecryptionShare(id, sk0Shards[i].Value, ciphertext, cks.AllocateShare())
+			require.NoError(t, err)
+
+			// The shares cannot be revealed before all the commitments are received.
+			require.Error(t, transcript.AddOpening(id, openings[i]))
+			require.NoError(t, transcript.AddCommitment(id, commitments[i]))
+		}
+
+		// A party cannot copy the commitment of another party and replay its opening
+		copied, err := cks.NewDecryptionTranscript(ids, ciphertext)
+		require.NoError(t, err)
+		require.NoError(t, copied.AddCommitment(ids[0], commitments[0]))
+		for _, id := range ids[1:] {
+			require.NoError(t, copied.AddCommitment(id, commitments[0]))
+		}
+		require.NoError(t, copied.AddOpening(ids[0], openings[0]))
+		require.Error(t, copied.AddOpening(ids[1], openings[0]))
+
+		require.Error(t, transcript.AddCommitment(ids[0], drlwe.Commitment{}))
+
+		tampered := &drlwe.ShareOpening{Share: append([]byte{}, openings[0].Share...), Nonce: openings[0].Nonce}
+		tampered.Share[len(tampered.Share)-1] ^= 1
+		require.Error(t, transcript.AddOpening(ids[0], tampered))
+
+		// The plaintext cannot be obtained from an incomplete transcript
+		_, err = cks.DecryptWithTranscript(transcript, ciphertext)
+		require.Error(t, err)
+
+		for i, id := range ids {
+			require.NoError(t, transcript.AddOpening(id, openings[i]))
+		}
+
+		plaintext, err := cks.DecryptWithTranscript(transcript, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, coeffs, testCtx.encoder.DecodeUintNew(plaintext))
+
+		// An auditor receiving the transcript obtains the same plaintext
+		data, err := transcript.MarshalBinary()
+		require.NoError(t, err)
+		decoded := new(drlwe.DecryptionTranscript)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.Equal(t, transcript.Parties(), decoded.Parties())
+		plaintext, err = cks.DecryptWithTranscript(decoded, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, coeffs, testCtx.encoder.DecodeUintNew(plaintext))
+		require.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]))
+
+		// Wrong ciphertext
+		_, _, otherCiphertext := newTestVectors(testCtx, encryptorPk0, t)
+		_, err = cks.DecryptWithTranscript(transcript, otherCiphertext)
+		require.Error(t, err)
+
+		// Aggregated share that is not the sum of the opened shares
+		aggregated := cks.AllocateShare()
+		require.NoError(t, aggregated.UnmarshalBinary(transcript.Aggregated))
+		share := cks.AllocateShare()
+		cks.GenShare(sk0Shards[0].Value, bfv.NewSecretKey(testCtx.params).Value, ciphertext, share)
+		cks.AggregateShares(aggregated, share, aggregated)
+		require.NoError(t, transcript.SetAggregated(&aggregated))
+		_, err = cks.DecryptWithTranscript(transcript, ciphertext)
+		require.Error(t, err)
+
+		// Opened share whose encoding is inconsistent
+		digest, err := drlwe.CiphertextDigest(ciphertext.Value)
+		require.NoError(t, err)
+		malformed, err := cks.NewDecryptionTranscript(ids[:1], ciphertext)
+		require.NoError(t, err)
+		commitment, opening, err := drlwe.CommitShare(digest, ids[0], rawShare{63, 2})
+		require.NoError(t, err)
+		require.NoError(t, malformed.AddCommitment(ids[0], commitment))
+		require.NoError(t, malformed.AddOpening(ids[0], opening))
+		_, err = cks.DecryptWithTranscript(malformed, ciphertext)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Keyswitching/DecryptionTranscript/SingleParty/", parties, testCtx.params), func(t *testing.T) {
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		cks := NewCKSProtocol(testCtx.params, 6.36)
+
+		plaintext, transcript, err := cks.DecryptNewWithTranscript("decryptor", testCtx.sk0, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, coeffs, testCtx.encoder.DecodeUintNew(plaintext))
+		require.Equal(t, []drlwe.PartyID{"decryptor"}, transcript.Parties())
+
+		plaintext, err = cks.DecryptWithTranscript(transcript, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, coeffs, testCtx.encoder.DecodeUintNew(plaintext))
+	})
+
+	t.Run(testString("Keyswitching/Verifiable/", parties, testCtx.params), func(t *testing.T) {
+
+		ids := make([]drlwe.PartyID, parties)
+		for i := range ids {
+			ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+		_, _, other := newTestVectors(testCtx, encryptorPk0, t)
+
+		digest, err := drlwe.CiphertextDigest(ciphertext.Value)
+		require.NoError(t, err)
+		otherDigest, err := drlwe.CiphertextDigest(other.Value)
+		require.NoError(t, err)
+
+		skZero := bfv.NewSecretKey(testCtx.params)
+		cks := NewCKSProtocol(testCtx.params, 6.36)
+
+		agg, err := cks.NewVerifiableAggregator(ids, ciphertext)
+		require.NoError(t, err)
+
+		openings := make([]*drlwe.ShareOpening, parties)
+		for i, id := range ids {
+			share := cks.AllocateShare()
+			var commitment drlwe.Commitment
+			if i == parties-1 {
+				// The last party replays its share of another key-switching
+				cks.GenShare(sk0Shards[i].Value, skZero.Value, other, share)
+				commitment, openings[i], err = drlwe.CommitShare(otherDigest, id, &share)
+			} else {
+				cks.GenShare(sk0Shards[i].Value, skZero.Value, ciphertext, share)
+				commitment, openings[i], err = drlwe.CommitShare(digest, id, &share)
+			}
+			require.NoError(t, err)
+			require.NoError(t, agg.AddCommitment(id, commitment))
+		}
+
+		for i, id := range ids[:parties-1] {
+			added, err := agg.AddOpening(id, openings[i])
+			require.NoError(t, err)
+			require.True(t, added)
+		}
+
+		// Duplicate openings are ignored
+		added, err := agg.AddOpening(ids[0], openings[0])
+		require.NoError(t, err)
+		require.False(t, added)
+
+		_, err = agg.AddOpening(ids[parties-1], openings[parties-1])
+		var cheating *drlwe.CheatingError
+		require.True(t, errors.As(err, &cheating))
+		require.Equal(t, ids[parties-1], cheating.Party)
+		require.Equal(t, ids[parties-1:], agg.Cheaters())
+		require.False(t, agg.Complete())
+
+		// The share of the cheater is not aggregated: the last party's honest share completes the decryption
+		share := cks.AllocateShare()
+		cks.GenShare(sk0Shards[parties-1].Value, skZero.Value, ciphertext, share)
+		aggregated := *agg.Aggregated().(*CKSShare)
+		cks.AggregateShares(aggregated, share, aggregated)
+
+		ptCiphertext := bfv.NewCiphertext(testCtx.params, 1)
+		cks.KeySwitch(aggregated, ciphertext, ptCiphertext)
+		verifyTestVectors(testCtx, bfv.NewDecryptor(testCtx.params, skZero), coeffs, ptCiphertext, t)
+	})
+
+	t.Run(testString("Keyswitching/Runner/", parties, testCtx.params), func(t *testing.T) {
+
+		ids := make([]drlwe.PartyID, parties)
+		for i := range ids {
+			ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+		}
+
+		transports := drlwe.NewLocalTransports(ids)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		errs := make([]error, parties)
+		ctOuts := make([]*bfv.Ciphertext, parties)
+
+		var wg sync.WaitGroup
+		for i := range ids {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer transports[ids[i]].Close()
+				runner := drlwe.NewRunner(ids[i], ids, ids[0], transports[ids[i]])
+				ctOuts[i] = bfv.NewCiphertext(testCtx.params, 1)
+				errs[i] = NewCKSProtocol(testCtx.params, 6.36).Run(runner, sk0Shards[i].Value, sk1Shards[i].Value, ciphertext, ctOuts[i])
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range ids {
+			require.NoError(t, errs[i])
+			verifyTestVectors(testCtx, decryptorSk1, coeffs, ctOuts[i], t)
+		}
+	})
 }
 
 func testPublicKeySwitching(testCtx *testContext, t *testing.T) {
@@ -347,6 +668,124 @@ func testPublicKeySwitching(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testThreshold(testCtx *testContext, t *testing.T) {
+
+	sk0Shards := testCtx.sk0Shards
+	sk1Shards := testCtx.sk1Shards
+	pk1 := testCtx.pk1
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk1 := testCtx.decryptorSk1
+	ringQP := testCtx.dbfvContext.ringQP
+
+	threshold := parties - 1
+
+	t.Run(testString(fmt.Sprintf("Threshold/t=%d/", threshold), parties, testCtx.params), func(t *testing.T) {
+
+		type Party struct {
+			*Thresholdizer
+			*Combiner
+			cks       *CKSProtocol
+			pcks      *PCKSProtocol
+			id        drlwe.ShamirPublicPoint
+			gen       *drlwe.ShamirPolynomial
+			tShare    *drlwe.ShamirSecretShare
+			s1        *ring.Poly
+			cksShare  CKSShare
+			pcksShare PCKSShare
+		}
+
+		thrParties := make([]*Party, parties)
+		for i := range thrParties {
+			p := new(Party)
+			p.Thresholdizer = NewThresholdizer(testCtx.params)
+			p.Combiner = NewCombiner(testCtx.params, threshold)
+			p.cks = NewCKSProtocol(testCtx.params, 6.36)
+			p.pcks = NewPCKSProtocol(testCtx.params, 6.36)
+			p.id = drlwe.ShamirPublicPoint(i + 1)
+			p.tShare = p.AllocateThresholdSecretShare()
+			p.cksShare = p.cks.AllocateShare()
+			p.pcksShare = p.pcks.AllocateShares()
+
+			var err error
+			p.gen, err = p.GenShamirPolynomial(threshold, sk0Shards[i])
+			require.NoError(t, err)
+			thrParties[i] = p
+		}
+
+		// Each party sends a share of its secret to every other party, which aggregate them
+		shamirShare := thrParties[0].AllocateThresholdSecretShare()
+		for _, pi := range thrParties {
+			for _, pj := range thrParties {
+				pi.GenShamirSecretShare(pj.id, pi.gen, shamirShare)
+				pj.Thresholdizer.AggregateShares(pj.tShare, shamirShare, pj.tShare)
+			}
+		}
+
+		// Only a threshold of the parties (here, all but the second) is active.
+		// The output key shares of the CKS are re-distributed among the active parties.
+		activeParties := append([]*Party{thrParties[0]}, thrParties[2:]...)
+		activePoints := make([]drlwe.ShamirPublicPoint, len(activeParties))
+		for i, p := range activeParties {
+			activePoints[i] = p.id
+		}
+
+		activeParties[0].s1 = ringQP.NewPoly()
+		ringQP.Add(sk1Shards[0].Value, sk1Shards[1].Value, activeParties[0].s1)
+		for i, p := range activeParties[1:] {
+			p.s1 = sk1Shards[i+2].Value
+		}
+
+		P0 := activeParties[0]
+
+		t.Run("PCKS", func(t *testing.T) {
+
+			coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+			for i, p := range activeParties {
+				p.pcks.GenShareThreshold(p.Combiner, activePoints, p.id, p.tShare, pk1, ciphertext, p.pcksShare)
+				if i > 0 {
+					P0.pcks.AggregateShares(p.pcksShare, P0.pcksShare, P0.pcksShare)
+				}
+			}
+
+			ciphertextSwitched := bfv.NewCiphertext(testCtx.params, 1)
+			P0.pcks.KeySwitch(P0.pcksShare, ciphertext, ciphertextSwitched)
+
+			verifyTestVectors(testCtx, decryptorSk1, coeffs, ciphertextSwitched, t)
+		})
+
+		t.Run("CKS", func(t *testing.T) {
+
+			coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+			for i, p := range activeParties {
+				p.cks.GenShareThreshold(p.Combiner, activePoints, p.id, p.tShare, p.s1, ciphertext, p.cksShare)
+				if i > 0 {
+					P0.cks.AggregateShares(p.cksShare, P0.cksShare, P0.cksShare)
+				}
+			}
+
+			ciphertextSwitched := bfv.NewCiphertext(testCtx.params, 1)
+			P0.cks.KeySwitch(P0.cksShare, ciphertext, ciphertextSwitched)
+
+			verifyTestVectors(testCtx, decryptorSk1, coeffs, ciphertextSwitched, t)
+		})
+
+		t.Run("InvalidActivePoints", func(t *testing.T) {
+			skOut := bfv.NewSecretKey(testCtx.params)
+			for _, points := range [][]drlwe.ShamirPublicPoint{
+				activePoints[:threshold-1],                // below the threshold
+				{P0.id, activePoints[1], activePoints[1]}, // duplicate point of another party
+				{P0.id, P0.id, activePoints[1]},           // duplicate own point
+				{P0.id, 0, activePoints[1]},               // zero point
+				{activePoints[1], thrParties[1].id},       // own point not active
+			} {
+				require.Panics(t, func() { P0.GenAdditiveShare(points, P0.id, P0.tShare, skOut) }, "%v", points)
+			}
+		})
+	})
+}
+
 func testRotKeyGenRotRows(testCtx *testContext, t *testing.T) {
 
 	encryptorPk0 := testCtx.encryptorPk0
@@ -465,6 +904,62 @@ func testRotKeyGenRotCols(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testRotKeyGenSet(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk0 := testCtx.decryptorSk0
+	sk0Shards := testCtx.sk0Shards
+
+	t.Run(testString("RotKeyGenSet/", parties, testCtx.params), func(t *testing.T) {
+
+		rots := []int{1, 5, -3}
+
+		type Party struct {
+			*RTGSetProtocol
+			s     *rlwe.SecretKey
+			share *drlwe.RTGSetShare
+		}
+
+		rtgParties := make([]*Party, parties)
+		for i := 0; i < parties; i++ {
+			p := new(Party)
+			p.RTGSetProtocol = NewRotKGSetProtocol(testCtx.params, rots, true)
+			p.s = sk0Shards[i]
+			p.share = p.AllocateShares()
+			rtgParties[i] = p
+		}
+
+		P0 := rtgParties[0]
+
+		crp := P0.SampleCRP(testCtx.prng)
+
+		rotKeySet := bfv.NewRotationKeySet(testCtx.params, P0.GaloisElements())
+
+		for i, p := range rtgParties {
+			p.GenShare(p.s, crp, p.share)
+			if i > 0 {
+				P0.Aggregate(p.share, P0.share, P0.share)
+			}
+		}
+
+		P0.GenRotationKeySet(P0.share, crp, rotKeySet)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		evaluator := testCtx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: nil, Rtks: rotKeySet})
+
+		for _, k := range rots {
+			result := evaluator.RotateColumnsNew(ciphertext, k)
+			coeffsWant := utils.RotateUint64Slots(coeffs, k)
+			verifyTestVectors(testCtx, decryptorSk0, coeffsWant, result, t)
+		}
+
+		result := evaluator.RotateRowsNew(ciphertext)
+		coeffsWant := append(coeffs[testCtx.params.N()>>1:], coeffs[:testCtx.params.N()>>1]...)
+		verifyTestVectors(testCtx, decryptorSk0, coeffsWant, result, t)
+	})
+}
+
 func testRefresh(testCtx *testContext, t *testing.T) {
 
 	encryptorPk0 := testCtx.encryptorPk0
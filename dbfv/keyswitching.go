@@ -1,8 +1,12 @@
 package dbfv
 
 import (
+	"errors"
+
 	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 )
 
@@ -18,6 +22,8 @@ type CKSProtocol struct {
 
 	baseconverter   *ring.FastBasisExtender
 	gaussianSampler *ring.GaussianSampler
+
+	additiveShare drlwe.AdditiveShare
 }
 
 // CKSShare is a type for the CKS protocol shares.
@@ -121,3 +127,140 @@ func (cks *CKSProtocol) KeySwitch(combined CKSShare, ct *bfv.Ciphertext, ctOut *
 	cks.context.ringQ.Add(ct.Value[0], combined.Poly, ctOut.Value[0])
 	cks.context.ringQ.Copy(ct.Value[1], ctOut.Value[1])
 }
+
+// NewVerifiableAggregator creates a new drlwe.VerifiableAggregator for the shares of the given parties in the
+// collective key-switching of ct, whose shares must be well-formed polynomials in the ring Q (see
+// drlwe.VerifiableAggregator). The commitments to the shares are bound to the ciphertext ct.
+func (cks *CKSProtocol) NewVerifiableAggregator(parties []drlwe.PartyID, ct *bfv.Ciphertext) (*drlwe.VerifiableAggregator, error) {
+
+	digest, err := drlwe.CiphertextDigest(ct.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregated := cks.AllocateShare()
+	agg := drlwe.NewAggregator(parties, &aggregated, func(share1, share2, shareOut drlwe.Share) {
+		cks.AggregateShares(*share1.(*CKSShare), *share2.(*CKSShare), *shareOut.(*CKSShare))
+	})
+
+	ringQ := cks.context.ringQ
+
+	return drlwe.NewVerifiableAggregator(agg, digest,
+		func(data []byte) (drlwe.Share, error) {
+			if err := drlwe.CheckPolyEncoding(len(ringQ.Modulus)-1, ringQ, data); err != nil {
+				return nil, err
+			}
+			share := new(CKSShare)
+			return share, share.UnmarshalBinary(data)
+		},
+		func(share drlwe.Share) error {
+			return drlwe.ValidatePoly(ringQ, share.(*CKSShare).Poly)
+		}), nil
+}
+
+// GenDecryptionShare generates the share of skInput, the secret key of the party id, in the collective decryption of
+// ct, i.e. in its key-switch to the zero secret key, and commits to it for the drlwe.DecryptionTranscript of ct (see
+// NewDecryptionTranscript).
+// The commitment is broadcast to the other parties, and the opening is added to the transcript only once the
+// commitments of all the parties are recorded.
+func (cks *CKSProtocol) GenDecryptionShare(id drlwe.PartyID, skInput *ring.Poly, ct *bfv.Ciphertext, shareOut CKSShare) (commitment drlwe.Commitment, opening *drlwe.ShareOpening, err error) {
+
+	cks.genShareDelta(skInput, ct, shareOut)
+
+	digest, err := drlwe.CiphertextDigest(ct.Value)
+	if err != nil {
+		return commitment, nil, err
+	}
+
+	return drlwe.CommitShare(digest, id, &shareOut)
+}
+
+// NewDecryptionTranscript creates a new drlwe.DecryptionTranscript for the collective decryption of ct by the given
+// parties, whose shares are generated with GenDecryptionShare.
+func (cks *CKSProtocol) NewDecryptionTranscript(parties []drlwe.PartyID, ct *bfv.Ciphertext) (*drlwe.DecryptionTranscript, error) {
+
+	digest, err := drlwe.CiphertextDigest(ct.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return drlwe.NewDecryptionTranscript(parties, digest), nil
+}
+
+// DecryptWithTranscript returns the plaintext of the collective decryption of ct recorded in the transcript tr. If
+// the aggregated share of tr is not yet recorded, it is first aggregated from the opened shares of tr. The transcript
+// is then verified against ct (see drlwe.DecryptionTranscript.Verify), so that a party or an external auditor that
+// receives a complete transcript obtains the plaintext only if the transcript is consistent with the commitments of
+// the parties. It does not prove that the committed shares are correct partial decryptions of ct.
+func (cks *CKSProtocol) DecryptWithTranscript(tr *drlwe.DecryptionTranscript, ct *bfv.Ciphertext) (pt *bfv.Plaintext, err error) {
+
+	if ct.Degree() != 1 || ct.Level() != cks.context.params.MaxLevel() {
+		return nil, errors.New("cannot DecryptWithTranscript: the ciphertext must be of degree 1 and at the maximum level")
+	}
+
+	digest, err := drlwe.CiphertextDigest(ct.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	ringQ := cks.context.ringQ
+
+	decode := func(data []byte) (drlwe.Share, error) {
+		if err := drlwe.CheckPolyEncoding(len(ringQ.Modulus)-1, ringQ, data); err != nil {
+			return nil, err
+		}
+		share := new(CKSShare)
+		return share, share.UnmarshalBinary(data)
+	}
+	validate := func(share drlwe.Share) error { return drlwe.ValidatePoly(ringQ, share.(*CKSShare).Poly) }
+	aggregate := func(share1, share2, shareOut drlwe.Share) {
+		cks.AggregateShares(*share1.(*CKSShare), *share2.(*CKSShare), *shareOut.(*CKSShare))
+	}
+
+	if len(tr.Aggregated) == 0 {
+		aggregated := cks.AllocateShare()
+		if err = tr.Aggregate(decode, validate, &aggregated, aggregate); err != nil {
+			return nil, err
+		}
+	}
+
+	zero := cks.AllocateShare()
+	if err = tr.Verify(digest, decode, validate, &zero, aggregate); err != nil {
+		return nil, err
+	}
+
+	// The aggregated share is the one of the verified aggregation
+	pt = bfv.NewPlaintext(cks.context.params)
+	ringQ.Add(ct.Value[0], zero.Poly, pt.Value[0])
+
+	return pt, nil
+}
+
+// DecryptNewWithTranscript is the single-party mode of the decryption with a transcript: it decrypts ct with sk, as
+// a bfv.Decryptor would, and returns the plaintext along with the transcript of the decryption by the single party
+// id, which the other parties can check with DecryptWithTranscript.
+func (cks *CKSProtocol) DecryptNewWithTranscript(id drlwe.PartyID, sk *rlwe.SecretKey, ct *bfv.Ciphertext) (pt *bfv.Plaintext, tr *drlwe.DecryptionTranscript, err error) {
+
+	if tr, err = cks.NewDecryptionTranscript([]drlwe.PartyID{id}, ct); err != nil {
+		return nil, nil, err
+	}
+
+	commitment, opening, err := cks.GenDecryptionShare(id, sk.Value, ct, cks.AllocateShare())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = tr.AddCommitment(id, commitment); err != nil {
+		return nil, nil, err
+	}
+
+	if err = tr.AddOpening(id, opening); err != nil {
+		return nil, nil, err
+	}
+
+	if pt, err = cks.DecryptWithTranscript(tr, ct); err != nil {
+		return nil, nil, err
+	}
+
+	return pt, tr, nil
+}
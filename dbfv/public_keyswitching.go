@@ -2,6 +2,7 @@ package dbfv
 
 import (
 	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -20,6 +21,8 @@ type PCKSProtocol struct {
 	baseconverter            *ring.FastBasisExtender
 	gaussianSampler          *ring.GaussianSampler
 	ternarySamplerMontgomery *ring.TernarySampler
+
+	additiveShare drlwe.AdditiveShare
 }
 
 // PCKSShare is a type for the PCKS protocol shares.
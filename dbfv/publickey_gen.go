@@ -4,6 +4,7 @@ package dbfv
 import (
 	"github.com/ldsec/lattigo/v2/bfv"
 	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
 )
 
 // CKGProtocol is the structure storing the parameters and state for a party in the collective key generation protocol.
@@ -17,3 +18,9 @@ func NewCKGProtocol(params bfv.Parameters) *CKGProtocol {
 	ckg.CKGProtocol = *drlwe.NewCKGProtocol(params.Parameters)
 	return ckg
 }
+
+// NewVerifiableAggregator creates a new drlwe.VerifiableAggregator for the shares of the given parties in the
+// collective key generation with the common reference polynomial crs (see drlwe.NewVerifiableCKGAggregator).
+func (ckg *CKGProtocol) NewVerifiableAggregator(parties []drlwe.PartyID, crs *ring.Poly) (*drlwe.VerifiableAggregator, error) {
+	return drlwe.NewVerifiableCKGAggregator(&ckg.CKGProtocol, parties, crs)
+}
@@ -14,3 +14,21 @@ type RTGProtocol struct {
 func NewRotKGProtocol(params bfv.Parameters) (rtg *RTGProtocol) {
 	return &RTGProtocol{*drlwe.NewRTGProtocol(params.Parameters)}
 }
+
+// RTGSetProtocol is the structure storing the parameters for the collective generation of a set of rotation keys in a single round.
+type RTGSetProtocol struct {
+	drlwe.RTGSetProtocol
+}
+
+// NewRotKGSetProtocol creates a new RTGSetProtocol generating collective rotation-keys for the column rotations ks,
+// and for the row rotation if includeSwapRow is true.
+func NewRotKGSetProtocol(params bfv.Parameters, ks []int, includeSwapRow bool) (rtg *RTGSetProtocol) {
+	galEls := make([]uint64, len(ks), len(ks)+1)
+	for i, k := range ks {
+		galEls[i] = params.GaloisElementForColumnRotationBy(k)
+	}
+	if includeSwapRow {
+		galEls = append(galEls, params.GaloisElementForRowRotation())
+	}
+	return &RTGSetProtocol{*drlwe.NewRTGSetProtocol(params.Parameters, galEls)}
+}
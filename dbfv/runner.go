@@ -0,0 +1,34 @@
+package dbfv
+
+import (
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// Run executes the collective key-switching protocol on ct over runner, with the party's shares skInput and
+// skOutput of the input and output secret keys, and writes the key-switched ciphertext on ctOut.
+// All the parties must hold the same ciphertext ct.
+func (cks *CKSProtocol) Run(runner *drlwe.Runner, skInput, skOutput *ring.Poly, ct, ctOut *bfv.Ciphertext) (err error) {
+
+	share, aggregated := cks.AllocateShare(), cks.AllocateShare()
+	cks.GenShare(skInput, skOutput, ct, share)
+
+	if err = runner.RunRound("CKS", &share, &aggregated,
+		func(data []byte) (drlwe.Share, error) {
+			if err := drlwe.CheckPolyEncoding(len(share.Coeffs)-1, cks.context.ringQ, data); err != nil {
+				return nil, err
+			}
+			received := new(CKSShare)
+			return received, received.UnmarshalBinary(data)
+		},
+		func(share1, share2, shareOut drlwe.Share) {
+			cks.AggregateShares(*share1.(*CKSShare), *share2.(*CKSShare), *shareOut.(*CKSShare))
+		}); err != nil {
+		return err
+	}
+
+	cks.KeySwitch(aggregated, ct, ctOut)
+
+	return nil
+}
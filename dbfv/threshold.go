@@ -0,0 +1,52 @@
+package dbfv
+
+import (
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Thresholdizer is the structure storing the parameters and state for a party in the protocol that turns the
+// N-out-of-N secret-shares of the collective secret key into t-out-of-N Shamir secret-shares.
+type Thresholdizer struct {
+	drlwe.Thresholdizer
+}
+
+// NewThresholdizer creates a new Thresholdizer instance.
+func NewThresholdizer(params bfv.Parameters) *Thresholdizer {
+	thr := new(Thresholdizer)
+	thr.Thresholdizer = *drlwe.NewThresholdizer(params.Parameters)
+	return thr
+}
+
+// Combiner is the structure storing the parameters for an active party to turn its t-out-of-N Shamir secret-share
+// into an additive secret-share of the collective secret key, which can then be used in the N-out-of-N protocols of
+// this package (e.g., CKSProtocol and PCKSProtocol) by the set of active parties.
+type Combiner struct {
+	drlwe.Combiner
+}
+
+// NewCombiner creates a new Combiner instance for the given threshold.
+func NewCombiner(params bfv.Parameters, threshold int) *Combiner {
+	cmb := new(Combiner)
+	cmb.Combiner = *drlwe.NewCombiner(params.Parameters, threshold)
+	return cmb
+}
+
+// GenShareThreshold is the GenShare of an active party of a t-out-of-N access structure, whose input secret key is
+// the ShamirSecretShare skInput of the party at ownPoint: the share is first combined by cmb into an additive share
+// among the active parties at activePoints, which then take the role of the N parties of the protocol. The output
+// shares skOutput must also be additive among the active parties. It panics in the cases of
+// Combiner.GenAdditiveShare, e.g. if less than threshold parties are active.
+func (cks *CKSProtocol) GenShareThreshold(cmb *Combiner, activePoints []drlwe.ShamirPublicPoint, ownPoint drlwe.ShamirPublicPoint, skInput *drlwe.ShamirSecretShare, skOutput *ring.Poly, ct *bfv.Ciphertext, shareOut CKSShare) {
+	cks.GenShare(cks.additiveShare.Combine(&cmb.Combiner, activePoints, ownPoint, skInput), skOutput, ct, shareOut)
+}
+
+// GenShareThreshold is the GenShare of an active party of a t-out-of-N access structure, whose secret key is the
+// ShamirSecretShare sk of the party at ownPoint: the share is first combined by cmb into an additive share among the
+// active parties at activePoints, which then take the role of the N parties of the protocol. It panics in the cases
+// of Combiner.GenAdditiveShare, e.g. if less than threshold parties are active.
+func (pcks *PCKSProtocol) GenShareThreshold(cmb *Combiner, activePoints []drlwe.ShamirPublicPoint, ownPoint drlwe.ShamirPublicPoint, sk *drlwe.ShamirSecretShare, pk *rlwe.PublicKey, ct *bfv.Ciphertext, shareOut PCKSShare) {
+	pcks.GenShare(pcks.additiveShare.Combine(&cmb.Combiner, activePoints, ownPoint, sk), pk, ct, shareOut)
+}
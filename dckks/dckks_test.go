@@ -1,17 +1,27 @@
 package dckks
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
+	"net"
+	"os"
+	"path/filepath"
 	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/internal/prngtest"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -58,6 +68,10 @@ type testContext struct {
 	sk1Shards []*rlwe.SecretKey
 }
 
+func TestMain(m *testing.M) {
+	prngtest.Main(m)
+}
+
 func TestDCKKS(t *testing.T) {
 
 	var defaultParams = ckks.DefaultParams[:4] // the default test runs for ring degree N=2^12, 2^13, 2^14, 2^15
@@ -89,10 +103,15 @@ func TestDCKKS(t *testing.T) {
 		testRelinKeyGen(testCtx, t)
 		testKeyswitching(testCtx, t)
 		testPublicKeySwitching(testCtx, t)
+		testThreshold(testCtx, t)
 		testRotKeyGenConjugate(testCtx, t)
 		testRotKeyGenCols(testCtx, t)
+		testRotKeyGenSet(testCtx, t)
 		testRefresh(testCtx, t)
 		testRefreshAndPermute(testCtx, t)
+		testRefreshAndSwitch(testCtx, t)
+		testE2SProtocol(testCtx, t)
+		testRunner(testCtx, t)
 	}
 }
 
@@ -190,6 +209,65 @@ func testPublicKeyGen(testCtx *testContext, t *testing.T) {
 		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
 	})
 
+	t.Run(testString("PublicKeyGen/Aggregator/", parties, testCtx.params), func(t *testing.T) {
+
+		crp := crpGenerator.ReadNew()
+
+		ckg := NewCKGProtocol(testCtx.params)
+
+		ids := make([]drlwe.PartyID, parties)
+		shares := make([]*drlwe.CKGShare, parties)
+		for i := range ids {
+			ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+			shares[i] = ckg.AllocateShares()
+			ckg.GenShare(sk0Shards[i], crp, shares[i])
+		}
+
+		dir, err := ioutil.TempDir("", "dckks-aggregator")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		checkpoint := filepath.Join(dir, "ckg")
+
+		// The aggregator receives the first shares, a duplicate and a share from an unknown party, then checkpoints
+		agg := drlwe.NewCKGAggregator(ckg, ids)
+		for i := 0; i < parties-1; i++ {
+			added, err := agg.Add(ids[i], shares[i])
+			require.NoError(t, err)
+			require.True(t, added)
+		}
+
+		added, err := agg.Add(ids[0], shares[0])
+		require.NoError(t, err)
+		require.False(t, added)
+
+		_, err = agg.Add("unknown", shares[0])
+		require.Error(t, err)
+
+		require.False(t, agg.Complete())
+		require.Equal(t, ids[parties-1:], agg.Missing())
+		require.NoError(t, agg.Checkpoint(checkpoint))
+
+		// A new aggregator resumes from the checkpoint and receives the remaining share
+		agg = drlwe.NewCKGAggregator(ckg, ids)
+		require.NoError(t, agg.Resume(checkpoint))
+		require.Equal(t, ids[:parties-1], agg.Received())
+
+		added, err = agg.Add(ids[1], shares[1])
+		require.NoError(t, err)
+		require.False(t, added)
+
+		added, err = agg.Add(ids[parties-1], shares[parties-1])
+		require.NoError(t, err)
+		require.True(t, added)
+		require.True(t, agg.Complete())
+
+		pk := ckks.NewPublicKey(testCtx.params)
+		ckg.GenPublicKey(agg.Aggregated().(*drlwe.CKGShare), crp, pk)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, ckks.NewEncryptorFromPk(testCtx.params, pk), 1, t)
+
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
+	})
 }
 
 func testRelinKeyGen(testCtx *testContext, t *testing.T) {
@@ -316,6 +394,88 @@ func testKeyswitching(testCtx *testContext, t *testing.T) {
 		verifyTestVectors(testCtx, decryptorSk1, coeffs, ksCiphertext, t)
 
 	})
+
+	t.Run(testString("Keyswitching/DecryptionTranscript/", parties, testCtx.params), func(t *testing.T) {
+
+		ids := make([]drlwe.PartyID, parties)
+		for i := range ids {
+			ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, 1, t)
+		testCtx.evaluator.DropLevel(ciphertext, 1)
+
+		// Collective decryption: key-switching to the zero secret key, with the shares committed in a transcript
+		cks := NewCKSProtocol(testCtx.params, 6.36)
+
+		transcript, err := cks.NewDecryptionTranscript(ids, ciphertext)
+		require.NoError(t, err)
+
+		openings := make([]*drlwe.ShareOpening, parties)
+
+		for i, id := range ids {
+			var commitment drlwe.Commitment
+			commitment, openings[i], err = cks.GenDecryptionShare(id, sk0Shards[i].Value, ciphertext, cks.AllocateShare())
+			require.NoError(t, err)
+
+			// The shares cannot be revealed before all the commitments are received.
+			require.Error(t, transcript.AddOpening(id, openings[i]))
+			require.NoError(t, transcript.AddCommitment(id, commitment))
+		}
+
+		// The plaintext cannot be obtained from an incomplete transcript
+		_, err = cks.DecryptWithTranscript(transcript, ciphertext)
+		require.Error(t, err)
+
+		for i, id := range ids {
+			require.NoError(t, transcript.AddOpening(id, openings[i]))
+		}
+
+		plaintext, err := cks.DecryptWithTranscript(transcript, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, ciphertext.Level(), plaintext.Level())
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, coeffs, plaintext, t)
+
+		// An auditor receiving the transcript obtains the same plaintext
+		data, err := transcript.MarshalBinary()
+		require.NoError(t, err)
+		decoded := new(drlwe.DecryptionTranscript)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		plaintext, err = cks.DecryptWithTranscript(decoded, ciphertext)
+		require.NoError(t, err)
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, coeffs, plaintext, t)
+
+		// Wrong ciphertext
+		_, _, otherCiphertext := newTestVectors(testCtx, encryptorPk0, 1, t)
+		_, err = cks.DecryptWithTranscript(transcript, otherCiphertext)
+		require.Error(t, err)
+
+		// Aggregated share that is not the sum of the opened shares
+		aggregated := new(ring.Poly)
+		require.NoError(t, aggregated.UnmarshalBinary(transcript.Aggregated))
+		share := cks.AllocateShare()
+		cks.GenShare(sk0Shards[0].Value, ckks.NewSecretKey(testCtx.params).Value, ciphertext, share)
+		cks.AggregateShares(aggregated, share, aggregated)
+		require.NoError(t, transcript.SetAggregated(aggregated))
+		_, err = cks.DecryptWithTranscript(transcript, ciphertext)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Keyswitching/DecryptionTranscript/SingleParty/", parties, testCtx.params), func(t *testing.T) {
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, 1, t)
+
+		cks := NewCKSProtocol(testCtx.params, 6.36)
+
+		plaintext, transcript, err := cks.DecryptNewWithTranscript("decryptor", testCtx.sk0, ciphertext)
+		require.NoError(t, err)
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, coeffs, plaintext, t)
+		require.Equal(t, []drlwe.PartyID{"decryptor"}, transcript.Parties())
+
+		plaintext, err = cks.DecryptWithTranscript(transcript, ciphertext)
+		require.NoError(t, err)
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, coeffs, plaintext, t)
+	})
 }
 
 func testPublicKeySwitching(testCtx *testContext, t *testing.T) {
@@ -362,6 +522,124 @@ func testPublicKeySwitching(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testThreshold(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk1 := testCtx.decryptorSk1
+	sk0Shards := testCtx.sk0Shards
+	sk1Shards := testCtx.sk1Shards
+	pk1 := testCtx.pk1
+	ringQP := testCtx.dckksContext.ringQP
+
+	threshold := parties - 1
+
+	t.Run(testString(fmt.Sprintf("Threshold/t=%d/", threshold), parties, testCtx.params), func(t *testing.T) {
+
+		type Party struct {
+			*Thresholdizer
+			*Combiner
+			cks       *CKSProtocol
+			pcks      *PCKSProtocol
+			id        drlwe.ShamirPublicPoint
+			gen       *drlwe.ShamirPolynomial
+			tShare    *drlwe.ShamirSecretShare
+			s1        *ring.Poly
+			cksShare  CKSShare
+			pcksShare PCKSShare
+		}
+
+		thrParties := make([]*Party, parties)
+		for i := range thrParties {
+			p := new(Party)
+			p.Thresholdizer = NewThresholdizer(testCtx.params)
+			p.Combiner = NewCombiner(testCtx.params, threshold)
+			p.cks = NewCKSProtocol(testCtx.params, 6.36)
+			p.pcks = NewPCKSProtocol(testCtx.params, 6.36)
+			p.id = drlwe.ShamirPublicPoint(i + 1)
+			p.tShare = p.AllocateThresholdSecretShare()
+			p.cksShare = p.cks.AllocateShare()
+			p.pcksShare = p.pcks.AllocateShares(testCtx.params.MaxLevel())
+
+			var err error
+			p.gen, err = p.GenShamirPolynomial(threshold, sk0Shards[i])
+			require.NoError(t, err)
+			thrParties[i] = p
+		}
+
+		// Each party sends a share of its secret to every other party, which aggregate them
+		shamirShare := thrParties[0].AllocateThresholdSecretShare()
+		for _, pi := range thrParties {
+			for _, pj := range thrParties {
+				pi.GenShamirSecretShare(pj.id, pi.gen, shamirShare)
+				pj.Thresholdizer.AggregateShares(pj.tShare, shamirShare, pj.tShare)
+			}
+		}
+
+		// Only a threshold of the parties (here, all but the second) is active.
+		// The output key shares of the CKS are re-distributed among the active parties.
+		activeParties := append([]*Party{thrParties[0]}, thrParties[2:]...)
+		activePoints := make([]drlwe.ShamirPublicPoint, len(activeParties))
+		for i, p := range activeParties {
+			activePoints[i] = p.id
+		}
+
+		activeParties[0].s1 = ringQP.NewPoly()
+		ringQP.Add(sk1Shards[0].Value, sk1Shards[1].Value, activeParties[0].s1)
+		for i, p := range activeParties[1:] {
+			p.s1 = sk1Shards[i+2].Value
+		}
+
+		P0 := activeParties[0]
+
+		t.Run("CKS", func(t *testing.T) {
+
+			coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, 1, t)
+
+			for i, p := range activeParties {
+				p.cks.GenShareThreshold(p.Combiner, activePoints, p.id, p.tShare, p.s1, ciphertext, p.cksShare)
+				if i > 0 {
+					P0.cks.AggregateShares(p.cksShare, P0.cksShare, P0.cksShare)
+				}
+			}
+
+			ksCiphertext := ckks.NewCiphertext(testCtx.params, 1, ciphertext.Level(), ciphertext.Scale())
+			P0.cks.KeySwitch(P0.cksShare, ciphertext, ksCiphertext)
+
+			verifyTestVectors(testCtx, decryptorSk1, coeffs, ksCiphertext, t)
+		})
+
+		t.Run("PCKS", func(t *testing.T) {
+
+			coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, 1, t)
+
+			for i, p := range activeParties {
+				p.pcks.GenShareThreshold(p.Combiner, activePoints, p.id, p.tShare, pk1, ciphertext, p.pcksShare)
+				if i > 0 {
+					P0.pcks.AggregateShares(p.pcksShare, P0.pcksShare, P0.pcksShare)
+				}
+			}
+
+			ksCiphertext := ckks.NewCiphertext(testCtx.params, 1, ciphertext.Level(), ciphertext.Scale())
+			P0.pcks.KeySwitch(P0.pcksShare, ciphertext, ksCiphertext)
+
+			verifyTestVectors(testCtx, decryptorSk1, coeffs, ksCiphertext, t)
+		})
+
+		t.Run("InvalidActivePoints", func(t *testing.T) {
+			skOut := ckks.NewSecretKey(testCtx.params)
+			for _, points := range [][]drlwe.ShamirPublicPoint{
+				activePoints[:threshold-1],                // below the threshold
+				{P0.id, activePoints[1], activePoints[1]}, // duplicate point of another party
+				{P0.id, P0.id, activePoints[1]},           // duplicate own point
+				{P0.id, 0, activePoints[1]},               // zero point
+				{activePoints[1], thrParties[1].id},       // own point not active
+			} {
+				require.Panics(t, func() { P0.GenAdditiveShare(points, P0.id, P0.tShare, skOut) }, "%v", points)
+			}
+		})
+	})
+}
+
 func testRotKeyGenConjugate(testCtx *testContext, t *testing.T) {
 
 	ringQP := testCtx.dckksContext.ringQP
@@ -487,6 +765,87 @@ func testRotKeyGenCols(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testRotKeyGenSet(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk0 := testCtx.decryptorSk0
+	sk0Shards := testCtx.sk0Shards
+
+	t.Run(testString("RotKeyGenSet/", parties, testCtx.params), func(t *testing.T) {
+
+		params := testCtx.params
+
+		diagMatrix := make(map[int][]complex128)
+		for _, k := range []int{-4, 0, 1, 4} {
+			diagMatrix[k] = make([]complex128, params.Slots())
+			for i := range diagMatrix[k] {
+				diagMatrix[k][i] = complex(1, 0)
+			}
+		}
+
+		ptDiagMatrix := testCtx.encoder.EncodeDiagMatrixBSGSAtLvl(params.MaxLevel(), diagMatrix, params.Scale(), 1.0, params.LogSlots())
+
+		type Party struct {
+			*RTGSetProtocol
+			s     *rlwe.SecretKey
+			share *drlwe.RTGSetShare
+		}
+
+		rtgParties := make([]*Party, parties)
+		for i := 0; i < parties; i++ {
+			p := new(Party)
+			p.RTGSetProtocol = NewRotKGSetProtocol(params, params.RotationsForDiagMatrixMult(ptDiagMatrix), true)
+			p.s = sk0Shards[i]
+			p.share = p.AllocateShares()
+			rtgParties[i] = p
+		}
+
+		P0 := rtgParties[0]
+
+		crp := P0.SampleCRP(testCtx.prng)
+
+		rotKeySet := ckks.NewRotationKeySet(params, P0.GaloisElements())
+
+		for i, p := range rtgParties {
+			p.GenShare(p.s, crp, p.share)
+			if i > 0 {
+				// The shares are sent to P0 in a single message
+				data, err := p.share.MarshalBinary()
+				require.NoError(t, err)
+				received := new(drlwe.RTGSetShare)
+				require.NoError(t, received.UnmarshalBinary(data))
+				require.Len(t, received.Shares, len(P0.GaloisElements()))
+
+				P0.Aggregate(received, P0.share, P0.share)
+			}
+		}
+
+		P0.GenRotationKeySet(P0.share, crp, rotKeySet)
+
+		values, _, ciphertext := newTestVectors(testCtx, encryptorPk0, 1, t)
+
+		evaluator := testCtx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: nil, Rtks: rotKeySet})
+
+		res := evaluator.LinearTransform(ciphertext, ptDiagMatrix)[0]
+
+		valuesWant := make([]complex128, params.Slots())
+		for k := range diagMatrix {
+			for i := range valuesWant {
+				valuesWant[i] += values[(i+k+params.Slots())%params.Slots()]
+			}
+		}
+
+		verifyTestVectors(testCtx, decryptorSk0, valuesWant, res, t)
+
+		evaluator.Conjugate(ciphertext, ciphertext)
+		for i := range values {
+			values[i] = complex(real(values[i]), -imag(values[i]))
+		}
+
+		verifyTestVectors(testCtx, decryptorSk0, values, ciphertext, t)
+	})
+}
+
 func testRefresh(testCtx *testContext, t *testing.T) {
 
 	evaluator := testCtx.evaluator
@@ -547,6 +906,110 @@ func testRefresh(testCtx *testContext, t *testing.T) {
 		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
 
 	})
+
+	t.Run(testString("Refresh/Verifiable/", parties, testCtx.params), func(t *testing.T) {
+
+		if testCtx.params.MaxLevel() < 3 {
+			t.Skip("skipping test for params max level < 3")
+		}
+
+		ids := make([]drlwe.PartyID, parties)
+		for i := range ids {
+			ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+		}
+
+		refresh := NewRefreshProtocol(testCtx.params)
+		crp := ring.NewUniformSampler(testCtx.prng, testCtx.dckksContext.ringQ).ReadNew()
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, 1.0, t)
+		for ciphertext.Level() != levelStart {
+			evaluator.DropLevel(ciphertext, 1)
+		}
+
+		// commit generates the shares of the parties for the session nonce and records their commitments in agg
+		commit := func(agg *drlwe.VerifiableAggregator, nonce []byte) (commitments []drlwe.Commitment, openings []*drlwe.ShareOpening) {
+
+			digest, err := RefreshSessionDigest(nonce, ciphertext, crp)
+			require.NoError(t, err)
+
+			commitments = make([]drlwe.Commitment, parties)
+			openings = make([]*drlwe.ShareOpening, parties)
+			for i := range ids {
+				shareDecrypt, shareRecrypt := refresh.AllocateShares(levelStart)
+				refresh.GenShares(sk0Shards[i].Value, levelStart, parties, ciphertext, testCtx.params.Scale(), crp, shareDecrypt, shareRecrypt)
+				commitments[i], openings[i], err = refresh.CommitShares(digest, ids[i], shareDecrypt, shareRecrypt)
+				require.NoError(t, err)
+			}
+			return
+		}
+
+		// First session, with honest parties
+		nonce1 := []byte("refresh-session-1")
+		agg, err := refresh.NewVerifiableAggregator(ids, nonce1, ciphertext, crp)
+		require.NoError(t, err)
+
+		commitments1, openings1 := commit(agg, nonce1)
+		for i, id := range ids {
+			require.NoError(t, agg.AddCommitment(id, commitments1[i]))
+		}
+		for i, id := range ids {
+			added, err := agg.AddOpening(id, openings1[i])
+			require.NoError(t, err)
+			require.True(t, added)
+		}
+		require.True(t, agg.Complete())
+
+		share := agg.Aggregated().(*RefreshShare)
+
+		ciphertextOut := ciphertext.CopyNew()
+		refresh.Decrypt(ciphertextOut, share.RefreshShareDecrypt)
+		refresh.Recode(ciphertextOut, testCtx.params.Scale())
+		refresh.Recrypt(ciphertextOut, crp.CopyNew(), share.RefreshShareRecrypt)
+
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertextOut, t)
+
+		// Second session on the same ciphertext: the party 1 replays its commitment and opening of the first session,
+		// and the party 2 copies the commitment of the party 0 and replays its opening
+		nonce2 := []byte("refresh-session-2")
+		agg, err = refresh.NewVerifiableAggregator(ids, nonce2, ciphertext, crp)
+		require.NoError(t, err)
+
+		commitments2, openings2 := commit(agg, nonce2)
+		commitments2[1], openings2[1] = commitments1[1], openings1[1]
+		commitments2[2], openings2[2] = commitments2[0], openings2[0]
+		for i, id := range ids {
+			require.NoError(t, agg.AddCommitment(id, commitments2[i]))
+		}
+
+		_, err = agg.AddOpening(ids[0], openings2[0])
+		require.NoError(t, err)
+
+		for _, i := range []int{1, 2} {
+			_, err = agg.AddOpening(ids[i], openings2[i])
+			var cheating *drlwe.CheatingError
+			require.True(t, errors.As(err, &cheating))
+			require.Equal(t, ids[i], cheating.Party)
+		}
+		require.Equal(t, ids[1:3], agg.Cheaters())
+		require.False(t, agg.Complete())
+
+		// The shares round-trip through their binary encoding
+		data, err := share.MarshalBinary()
+		require.NoError(t, err)
+		decoded := new(RefreshShare)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.True(t, testCtx.dckksContext.ringQ.EqualLvl(levelStart, share.RefreshShareDecrypt, decoded.RefreshShareDecrypt))
+		require.True(t, testCtx.dckksContext.ringQ.Equal(share.RefreshShareRecrypt, decoded.RefreshShareRecrypt))
+		require.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]))
+		require.Error(t, decoded.UnmarshalBinary(data[:16]))
+
+		// Lengths whose sum overflows to the size of the payload
+		malformed := append([]byte{}, data...)
+		lenPayload := uint64(len(data) - 16)
+		binary.BigEndian.PutUint64(malformed[0:8], math.MaxUint64-2)
+		binary.BigEndian.PutUint64(malformed[8:16], lenPayload+3)
+		require.Error(t, decoded.UnmarshalBinary(malformed))
+	})
 }
 
 func testRefreshAndPermute(testCtx *testContext, t *testing.T) {
@@ -623,6 +1086,329 @@ func testRefreshAndPermute(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testRefreshAndSwitch(testCtx *testContext, t *testing.T) {
+
+	evaluator := testCtx.evaluator
+	encryptorPk0 := testCtx.encryptorPk0
+	sk0Shards := testCtx.sk0Shards
+
+	levelStart := 3
+
+	// The arbitrary precision encoding makes the protocol slow for large parameters
+	if testCtx.params.MaxLevel() < levelStart || testCtx.params.LogN() > 14 {
+		return
+	}
+
+	// Switches to a sparser packing in the same ring, and to the next ring degree if available
+	paramsOutList := []ckks.Parameters{}
+
+	if params, err := ckks.NewParameters(testCtx.params.Parameters, testCtx.params.LogSlots()-1, testCtx.params.Scale()); err == nil {
+		paramsOutList = append(paramsOutList, params)
+	}
+
+	for _, pl := range ckks.DefaultParams {
+		if pl.LogN == testCtx.params.LogN()+1 && pl.LogN < 15 {
+			params, err := ckks.NewParametersFromLiteral(pl)
+			if err != nil {
+				panic(err)
+			}
+			paramsOutList = append(paramsOutList, params)
+			break
+		}
+	}
+
+	for _, paramsOut := range paramsOutList {
+
+		testCtxOut, err := genTestParams(paramsOut)
+		if err != nil {
+			panic(err)
+		}
+
+		t.Run(testString(fmt.Sprintf("RefreshAndSwitch/logNOut=%d/logSlotsOut=%d/", paramsOut.LogN(), paramsOut.LogSlots()), parties, testCtx.params), func(t *testing.T) {
+
+			type Party struct {
+				*RefreshAndSwitchProtocol
+				sIn    *ring.Poly
+				sOut   *ring.Poly
+				share1 RefreshShareDecrypt
+				share2 RefreshShareRecrypt
+			}
+
+			// The instance is shared by the parties as it precomputes the (costly) arbitrary precision encoders
+			protocol := NewRefreshAndSwitchProtocol(testCtx.params, paramsOut)
+
+			RefreshParties := make([]*Party, parties)
+			for i := 0; i < parties; i++ {
+				p := new(Party)
+				p.RefreshAndSwitchProtocol = protocol
+				p.sIn = sk0Shards[i].Value
+				p.sOut = testCtxOut.sk0Shards[i].Value
+				p.share1, p.share2 = p.AllocateShares(levelStart)
+				RefreshParties[i] = p
+			}
+
+			P0 := RefreshParties[0]
+
+			crpGenerator := ring.NewUniformSampler(testCtx.prng, testCtxOut.dckksContext.ringQ)
+			crp := crpGenerator.ReadNew()
+
+			coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, 1.0, t)
+
+			for ciphertext.Level() != levelStart {
+				evaluator.DropLevel(ciphertext, 1)
+			}
+
+			for i, p := range RefreshParties {
+				p.GenShares(p.sIn, p.sOut, levelStart, parties, ciphertext, paramsOut.Scale(), crp, p.share1, p.share2)
+				if i > 0 {
+					P0.AggregateDecrypt(p.share1, P0.share1, P0.share1)
+					P0.AggregateRecrypt(p.share2, P0.share2, P0.share2)
+				}
+			}
+
+			ciphertextOut := ckks.NewCiphertext(paramsOut, 1, paramsOut.MaxLevel(), paramsOut.Scale())
+
+			P0.Decrypt(ciphertext, P0.share1)                       // Masked decryption
+			P0.Recode(ciphertext, paramsOut.Scale(), ciphertextOut) // Masked re-encoding with the output parameters
+			P0.Recrypt(ciphertextOut, crp, P0.share2)               // Masked re-encryption under the output key
+
+			// The message is truncated or zero-padded to the output number of slots
+			coeffsWant := make([]complex128, paramsOut.Slots())
+			copy(coeffsWant, coeffs)
+
+			require.Equal(t, ciphertextOut.Level(), paramsOut.MaxLevel())
+
+			verifyTestVectors(testCtxOut, testCtxOut.decryptorSk0, coeffsWant, ciphertextOut, t)
+		})
+	}
+}
+
+func testE2SProtocol(testCtx *testContext, t *testing.T) {
+
+	ringQ := testCtx.dckksContext.ringQ
+	sk0Shards := testCtx.sk0Shards
+
+	t.Run(testString("E2SProtocol/", parties, testCtx.params), func(t *testing.T) {
+
+		type Party struct {
+			e2s          *E2SProtocol
+			s2e          *S2EProtocol
+			s            *ring.Poly
+			secretShare  *AdditiveShare
+			publicShareE E2SShare
+			publicShareS S2EShare
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, testCtx.encryptorPk0, 1.0, t)
+		level := ciphertext.Level()
+
+		P := make([]*Party, parties)
+		for i := range P {
+			p := new(Party)
+			p.e2s = NewE2SProtocol(testCtx.params, 3.2)
+			p.s2e = NewS2EProtocol(testCtx.params)
+			p.s = sk0Shards[i].Value
+			p.secretShare = NewAdditiveShare(testCtx.params, level)
+			p.publicShareE = p.e2s.AllocateShare(level)
+			p.publicShareS = p.s2e.AllocateShare(level)
+			P[i] = p
+		}
+
+		P0 := P[0]
+
+		// Encryption to shares
+		for i, p := range P {
+			p.e2s.GenShare(p.s, ciphertext, p.secretShare, p.publicShareE)
+			if i > 0 {
+				P0.e2s.AggregateShares(P0.publicShareE, p.publicShareE, P0.publicShareE)
+			}
+		}
+
+		P0.e2s.GetShare(P0.secretShare, P0.publicShareE, ciphertext, P0.secretShare)
+
+		// The shares sum to the plaintext
+		plaintext := ckks.NewPlaintext(testCtx.params, level, ciphertext.Scale())
+		for _, p := range P {
+			ringQ.AddLvl(level, plaintext.Value[0], p.secretShare.Value, plaintext.Value[0])
+		}
+		ringQ.NTTLvl(level, plaintext.Value[0], plaintext.Value[0])
+
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, coeffs, plaintext, t)
+
+		// Shares to encryption
+		crpGenerator := ring.NewUniformSampler(testCtx.prng, ringQ)
+		crp := crpGenerator.ReadNew()
+
+		for i, p := range P {
+			p.s2e.GenShare(p.s, crp, p.secretShare, p.publicShareS)
+			if i > 0 {
+				P0.s2e.AggregateShares(P0.publicShareS, p.publicShareS, P0.publicShareS)
+			}
+		}
+
+		ciphertextOut := ckks.NewCiphertext(testCtx.params, 1, testCtx.params.MaxLevel(), ciphertext.Scale())
+		P0.s2e.GetEncryption(P0.publicShareS, crp, ciphertextOut)
+
+		require.Equal(t, level, ciphertextOut.Level())
+
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, coeffs, ciphertextOut, t)
+	})
+}
+
+func testRunner(testCtx *testContext, t *testing.T) {
+
+	ids := make([]drlwe.PartyID, parties)
+	for i := range ids {
+		ids[i] = drlwe.PartyID(fmt.Sprintf("party-%d", i))
+	}
+
+	crpGenerator := ring.NewUniformSampler(testCtx.prng, testCtx.dckksContext.ringQP)
+	crs := crpGenerator.ReadNew()
+	crp := make([]*ring.Poly, testCtx.params.Beta())
+	for i := range crp {
+		crp[i] = crpGenerator.ReadNew()
+	}
+
+	crpRTG := NewRotKGSetProtocol(testCtx.params, []int{1}, false).SampleCRP(testCtx.prng)
+
+	// runParties executes CKG, RKG, RTGSet and CKS for all the parties concurrently, and checks the outputs of each party.
+	runParties := func(t *testing.T, transports []drlwe.Transport) {
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, testCtx.encryptorPk0, 1, t)
+
+		for i := range coeffs {
+			coeffs[i] *= coeffs[i]
+		}
+
+		errs := make([]error, parties)
+		pks := make([]*rlwe.PublicKey, parties)
+		rlks := make([]*rlwe.RelinearizationKey, parties)
+		rtks := make([]*rlwe.RotationKeySet, parties)
+		ctOuts := make([]*ckks.Ciphertext, parties)
+
+		var wg sync.WaitGroup
+		for i := range ids {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				runner := drlwe.NewRunner(ids[i], ids, ids[0], transports[i])
+
+				pks[i] = ckks.NewPublicKey(testCtx.params)
+				if errs[i] = runner.RunCKG(NewCKGProtocol(testCtx.params), testCtx.sk0Shards[i], crs, pks[i]); errs[i] != nil {
+					return
+				}
+
+				rlks[i] = ckks.NewRelinearizationKey(testCtx.params)
+				if errs[i] = runner.RunRKG(NewRKGProtocol(testCtx.params), testCtx.sk0Shards[i], crp, rlks[i]); errs[i] != nil {
+					return
+				}
+
+				rtg := NewRotKGSetProtocol(testCtx.params, []int{1}, false)
+				rtks[i] = ckks.NewRotationKeySet(testCtx.params, rtg.GaloisElements())
+				if errs[i] = runner.RunRTGSet(&rtg.RTGSetProtocol, testCtx.sk0Shards[i], crpRTG, rtks[i]); errs[i] != nil {
+					return
+				}
+
+				// The parties square the ciphertext with the collective relinearization key before switching its key
+				evaluator := ckks.NewEvaluator(testCtx.params, rlwe.EvaluationKey{Rlk: rlks[i]})
+				ct := evaluator.MulRelinNew(ciphertext, ciphertext)
+				evaluator.Rescale(ct, testCtx.params.Scale(), ct)
+
+				ctOuts[i] = ckks.NewCiphertext(testCtx.params, 1, ct.Level(), ct.Scale())
+				errs[i] = NewCKSProtocol(testCtx.params, 6.36).Run(runner, testCtx.sk0Shards[i].Value, testCtx.sk1Shards[i].Value, ct, ctOuts[i])
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range ids {
+			require.NoError(t, errs[i])
+			require.True(t, pks[i].Equals(pks[0]))
+			require.True(t, rtks[i].Equals(rtks[0]))
+			verifyTestVectors(testCtx, testCtx.decryptorSk1, coeffs, ctOuts[i], t)
+		}
+
+		values, _, ciphertext := newTestVectors(testCtx, ckks.NewEncryptorFromPk(testCtx.params, pks[parties-1]), 1, t)
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, values, ciphertext, t)
+
+		testCtx.evaluator.WithKey(rlwe.EvaluationKey{Rtks: rtks[parties-1]}).Rotate(ciphertext, 1, ciphertext)
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, utils.RotateComplex128Slice(values, 1), ciphertext, t)
+	}
+
+	t.Run(testString("Runner/LocalTransport/", parties, testCtx.params), func(t *testing.T) {
+
+		local := drlwe.NewLocalTransports(ids)
+
+		transports := make([]drlwe.Transport, parties)
+		for i, id := range ids {
+			transports[i] = local[id]
+			defer local[id].Close()
+		}
+
+		runParties(t, transports)
+	})
+
+	t.Run(testString("Runner/TCPTransport/", parties, testCtx.params), func(t *testing.T) {
+
+		tcp := make([]*drlwe.TCPTransport, parties)
+		for i, id := range ids {
+			var err error
+			tcp[i], err = drlwe.NewTCPTransport(id, "127.0.0.1:0")
+			require.NoError(t, err)
+			defer tcp[i].Close()
+		}
+
+		transports := make([]drlwe.Transport, parties)
+		for i := range ids {
+			for j, id := range ids {
+				if i != j {
+					tcp[i].AddPeer(id, tcp[j].Addr().String())
+				}
+			}
+			transports[i] = tcp[i]
+		}
+
+		runParties(t, transports)
+	})
+
+	t.Run(testString("Runner/Malformed/", parties, testCtx.params), func(t *testing.T) {
+
+		// The party 1 sends an empty, truncated or inconsistent CKG share to the leader
+		for _, payload := range [][]byte{{}, {1}, {4, 0, 0, 0, 1}, {63, 2}} {
+
+			local := drlwe.NewLocalTransports(ids)
+
+			require.NoError(t, local[ids[1]].Send(ids[0], append([]byte{3, 'C', 'K', 'G'}, payload...)))
+
+			runner := drlwe.NewRunner(ids[0], ids, ids[0], local[ids[0]])
+			err := runner.RunCKG(NewCKGProtocol(testCtx.params), testCtx.sk0Shards[0], crs, ckks.NewPublicKey(testCtx.params))
+			require.Error(t, err)
+
+			for _, id := range ids {
+				local[id].Close()
+			}
+		}
+
+		// A peer announcing a frame larger than the maximum message size is disconnected
+		tcp, err := drlwe.NewTCPTransport(ids[0], "127.0.0.1:0")
+		require.NoError(t, err)
+		defer tcp.Close()
+
+		conn, err := net.Dial("tcp", tcp.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write(append([]byte{0, 0, 0, byte(len(ids[1]))}, ids[1]...))
+		require.NoError(t, err)
+		_, err = conn.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Second)))
+		_, err = conn.Read(make([]byte, 1))
+		require.Equal(t, io.EOF, err)
+	})
+}
+
 func newTestVectors(testCtx *testContext, encryptor ckks.Encryptor, a float64, t *testing.T) (values []complex128, plaintext *ckks.Plaintext, ciphertext *ckks.Ciphertext) {
 
 	slots := testCtx.params.Slots()
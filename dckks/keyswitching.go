@@ -1,8 +1,12 @@
 package dckks
 
 import (
+	"errors"
+
 	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 )
 
@@ -19,6 +23,8 @@ type CKSProtocol struct {
 
 	baseconverter   *ring.FastBasisExtender
 	gaussianSampler *ring.GaussianSampler
+
+	additiveShare drlwe.AdditiveShare
 }
 
 // CKSShare is a struct holding a share of the CKS protocol.
@@ -107,3 +113,110 @@ func (cks *CKSProtocol) KeySwitch(combined CKSShare, ct *ckks.Ciphertext, ctOut
 	cks.dckksContext.ringQ.AddLvl(ct.Level(), ct.Value[0], combined, ctOut.Value[0])
 	cks.dckksContext.ringQ.CopyLvl(ct.Level(), ct.Value[1], ctOut.Value[1])
 }
+
+// GenDecryptionShare generates the share of skInput, the secret key of the party id, in the collective decryption of
+// ct, i.e. in its key-switch to the zero secret key, and commits to it for the drlwe.DecryptionTranscript of ct (see
+// NewDecryptionTranscript).
+// The commitment, which binds the share at the level of ct, is broadcast to the other parties, and the opening is
+// added to the transcript only once the commitments of all the parties are recorded.
+func (cks *CKSProtocol) GenDecryptionShare(id drlwe.PartyID, skInput *ring.Poly, ct *ckks.Ciphertext, shareOut CKSShare) (commitment drlwe.Commitment, opening *drlwe.ShareOpening, err error) {
+
+	cks.genShareDelta(skInput, ct, shareOut)
+
+	digest, err := drlwe.CiphertextDigest(ct.Value)
+	if err != nil {
+		return commitment, nil, err
+	}
+
+	return drlwe.CommitShare(digest, id, &ring.Poly{Coeffs: shareOut.Coeffs[:ct.Level()+1]})
+}
+
+// NewDecryptionTranscript creates a new drlwe.DecryptionTranscript for the collective decryption of ct by the given
+// parties, whose shares are generated with GenDecryptionShare.
+func (cks *CKSProtocol) NewDecryptionTranscript(parties []drlwe.PartyID, ct *ckks.Ciphertext) (*drlwe.DecryptionTranscript, error) {
+
+	digest, err := drlwe.CiphertextDigest(ct.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return drlwe.NewDecryptionTranscript(parties, digest), nil
+}
+
+// DecryptWithTranscript returns the plaintext of the collective decryption of ct recorded in the transcript tr. If
+// the aggregated share of tr is not yet recorded, it is first aggregated from the opened shares of tr. The transcript
+// is then verified against ct (see drlwe.DecryptionTranscript.Verify), so that a party or an external auditor that
+// receives a complete transcript obtains the plaintext only if the transcript is consistent with the commitments of
+// the parties. It does not prove that the committed shares are correct partial decryptions of ct.
+func (cks *CKSProtocol) DecryptWithTranscript(tr *drlwe.DecryptionTranscript, ct *ckks.Ciphertext) (pt *ckks.Plaintext, err error) {
+
+	if ct.Degree() != 1 {
+		return nil, errors.New("cannot DecryptWithTranscript: the ciphertext must be of degree 1")
+	}
+
+	digest, err := drlwe.CiphertextDigest(ct.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	ringQ := cks.dckksContext.ringQ
+	level := ct.Level()
+
+	decode := func(data []byte) (drlwe.Share, error) {
+		if err := drlwe.CheckPolyEncoding(level, ringQ, data); err != nil {
+			return nil, err
+		}
+		share := new(ring.Poly)
+		return share, share.UnmarshalBinary(data)
+	}
+	validate := func(share drlwe.Share) error { return drlwe.ValidatePolyLvl(level, ringQ, share.(*ring.Poly)) }
+	aggregate := func(share1, share2, shareOut drlwe.Share) {
+		cks.AggregateShares(share1.(*ring.Poly), share2.(*ring.Poly), shareOut.(*ring.Poly))
+	}
+
+	if len(tr.Aggregated) == 0 {
+		if err = tr.Aggregate(decode, validate, ringQ.NewPolyLvl(level), aggregate); err != nil {
+			return nil, err
+		}
+	}
+
+	zero := ringQ.NewPolyLvl(level)
+	if err = tr.Verify(digest, decode, validate, zero, aggregate); err != nil {
+		return nil, err
+	}
+
+	// The aggregated share is the one of the verified aggregation
+	pt = ckks.NewPlaintext(cks.dckksContext.params, level, ct.Scale())
+	ringQ.AddLvl(level, ct.Value[0], zero, pt.Value[0])
+
+	return pt, nil
+}
+
+// DecryptNewWithTranscript is the single-party mode of the decryption with a transcript: it decrypts ct with sk, as
+// a ckks.Decryptor would, and returns the plaintext along with the transcript of the decryption by the single party
+// id, which the other parties can check with DecryptWithTranscript.
+func (cks *CKSProtocol) DecryptNewWithTranscript(id drlwe.PartyID, sk *rlwe.SecretKey, ct *ckks.Ciphertext) (pt *ckks.Plaintext, tr *drlwe.DecryptionTranscript, err error) {
+
+	if tr, err = cks.NewDecryptionTranscript([]drlwe.PartyID{id}, ct); err != nil {
+		return nil, nil, err
+	}
+
+	commitment, opening, err := cks.GenDecryptionShare(id, sk.Value, ct, cks.AllocateShare())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = tr.AddCommitment(id, commitment); err != nil {
+		return nil, nil, err
+	}
+
+	if err = tr.AddOpening(id, opening); err != nil {
+		return nil, nil, err
+	}
+
+	if pt, err = cks.DecryptWithTranscript(tr, ct); err != nil {
+		return nil, nil, err
+	}
+
+	return pt, tr, nil
+}
@@ -2,6 +2,7 @@ package dckks
 
 import (
 	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -21,6 +22,8 @@ type PCKSProtocol struct {
 	baseconverter            *ring.FastBasisExtender
 	gaussianSampler          *ring.GaussianSampler
 	ternarySamplerMontgomery *ring.TernarySampler
+
+	additiveShare drlwe.AdditiveShare
 }
 
 // PCKSShare is a struct storing the share of the PCKS protocol.
@@ -1,9 +1,15 @@
 package dckks
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/utils"
 )
@@ -22,6 +28,167 @@ type RefreshShareDecrypt *ring.Poly
 // RefreshShareRecrypt is a struct storing the masked recryption share.
 type RefreshShareRecrypt *ring.Poly
 
+// RefreshShare is a struct storing the decryption and recryption shares of a party.
+type RefreshShare struct {
+	RefreshShareDecrypt RefreshShareDecrypt
+	RefreshShareRecrypt RefreshShareRecrypt
+}
+
+// MarshalBinary encodes a RefreshShare on a slice of bytes.
+func (share *RefreshShare) MarshalBinary() ([]byte, error) {
+
+	lenDecrypt := (*ring.Poly)(share.RefreshShareDecrypt).GetDataLen(true)
+	lenRecrypt := (*ring.Poly)(share.RefreshShareRecrypt).GetDataLen(true)
+
+	// Data is :
+	// 8 bytes : length of the decryption share
+	// 8 bytes : length of the recryption share
+	// followed by the decryption and recryption shares
+	data := make([]byte, lenDecrypt+lenRecrypt+16)
+	binary.BigEndian.PutUint64(data[0:8], uint64(lenDecrypt))
+	binary.BigEndian.PutUint64(data[8:16], uint64(lenRecrypt))
+
+	ptr := 16
+	if _, err := (*ring.Poly)(share.RefreshShareDecrypt).WriteTo(data[ptr : ptr+lenDecrypt]); err != nil {
+		return nil, err
+	}
+
+	ptr += lenDecrypt
+	if _, err := (*ring.Poly)(share.RefreshShareRecrypt).WriteTo(data[ptr : ptr+lenRecrypt]); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a marshaled RefreshShare on the target RefreshShare.
+func (share *RefreshShare) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 16 {
+		return errors.New("too small bytearray")
+	}
+
+	lenDecrypt := binary.BigEndian.Uint64(data[0:8])
+	lenRecrypt := binary.BigEndian.Uint64(data[8:16])
+
+	// Each length is checked against the payload before the sum, which could otherwise overflow
+	lenPayload := uint64(len(data) - 16)
+	if lenDecrypt < 2 || lenRecrypt < 2 || lenDecrypt > lenPayload || lenRecrypt > lenPayload || lenDecrypt+lenRecrypt != lenPayload {
+		return errors.New("invalid refresh share encoding")
+	}
+
+	share.RefreshShareDecrypt = new(ring.Poly)
+	share.RefreshShareRecrypt = new(ring.Poly)
+
+	ptr := 16 + lenDecrypt
+	if err = (*ring.Poly)(share.RefreshShareDecrypt).UnmarshalBinary(data[16:ptr]); err != nil {
+		return err
+	}
+
+	return (*ring.Poly)(share.RefreshShareRecrypt).UnmarshalBinary(data[ptr:])
+}
+
+// checkRefreshShareEncoding returns an error if data is not the encoding of a RefreshShare whose decryption share is
+// a polynomial of ringQ at levelStart and whose recryption share is a polynomial of ringQ (see
+// drlwe.CheckPolyEncoding).
+func checkRefreshShareEncoding(levelStart int, ringQ *ring.Ring, data []byte) error {
+
+	if len(data) < 16 {
+		return errors.New("truncated encoding")
+	}
+
+	lenDecrypt := binary.BigEndian.Uint64(data[0:8])
+	if lenDecrypt > uint64(len(data)-16) {
+		return errors.New("invalid refresh share encoding")
+	}
+
+	ptr := 16 + int(lenDecrypt)
+	if err := drlwe.CheckPolyEncoding(levelStart, ringQ, data[16:ptr]); err != nil {
+		return fmt.Errorf("decryption share: %w", err)
+	}
+
+	if err := drlwe.CheckPolyEncoding(len(ringQ.Modulus)-1, ringQ, data[ptr:]); err != nil {
+		return fmt.Errorf("recryption share: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshSessionDigest returns the digest binding the shares of a refresh to its session: the session nonce, which
+// must be unique to each refresh (e.g., a random value agreed upon by the parties), the refreshed ciphertext and the
+// common reference polynomial crs. The shares committed with this digest (see CommitShares) cannot be replayed into
+// the refresh of another session, even if it refreshes the same ciphertext.
+func RefreshSessionDigest(nonce []byte, ciphertext *ckks.Ciphertext, crs *ring.Poly) (digest [sha256.Size]byte, err error) {
+
+	var ctDigest [sha256.Size]byte
+	if ctDigest, err = drlwe.CiphertextDigest(append(append([]*ring.Poly{}, ciphertext.Value...), crs)); err != nil {
+		return digest, err
+	}
+
+	// SHA-256(len(nonce) || nonce || scale || digest of the ciphertext and crs)
+	var buff [8]byte
+	h := sha256.New()
+	binary.BigEndian.PutUint64(buff[:], uint64(len(nonce)))
+	h.Write(buff[:])
+	h.Write(nonce)
+	binary.BigEndian.PutUint64(buff[:], math.Float64bits(ciphertext.Scale()))
+	h.Write(buff[:])
+	h.Write(ctDigest[:])
+
+	copy(digest[:], h.Sum(nil))
+
+	return digest, nil
+}
+
+// CommitShares commits to the decryption and recryption shares of the party id in the refresh of the session of the
+// given digest (see RefreshSessionDigest). The commitment is broadcast to the other parties, and the opening is
+// revealed only once the commitments of all the parties have been recorded (see NewVerifiableAggregator).
+func (refreshProtocol *RefreshProtocol) CommitShares(digest [sha256.Size]byte, id drlwe.PartyID, shareDecrypt RefreshShareDecrypt, shareRecrypt RefreshShareRecrypt) (drlwe.Commitment, *drlwe.ShareOpening, error) {
+	return drlwe.CommitShare(digest, id, &RefreshShare{RefreshShareDecrypt: shareDecrypt, RefreshShareRecrypt: shareRecrypt})
+}
+
+// NewVerifiableAggregator creates a new drlwe.VerifiableAggregator for the shares of the given parties in the
+// refresh of ciphertext with the common reference polynomial crs, in the session of the given nonce (see
+// RefreshSessionDigest). The aggregator rejects the openings that were not committed for this session, such as the
+// shares replayed from the transcript of another refresh, and the shares that are not well-formed polynomials in the
+// ring Q, at the level of the ciphertext for the decryption share. The rejected shares are attributed to their party
+// with a drlwe.CheatingError, and the aggregated share is a *RefreshShare.
+func (refreshProtocol *RefreshProtocol) NewVerifiableAggregator(parties []drlwe.PartyID, nonce []byte, ciphertext *ckks.Ciphertext, crs *ring.Poly) (*drlwe.VerifiableAggregator, error) {
+
+	digest, err := RefreshSessionDigest(nonce, ciphertext, crs)
+	if err != nil {
+		return nil, err
+	}
+
+	levelStart := ciphertext.Level()
+	ringQ := refreshProtocol.dckksContext.ringQ
+
+	aggregated := new(RefreshShare)
+	aggregated.RefreshShareDecrypt, aggregated.RefreshShareRecrypt = refreshProtocol.AllocateShares(levelStart)
+
+	agg := drlwe.NewAggregator(parties, aggregated, func(share1, share2, shareOut drlwe.Share) {
+		s1, s2, sOut := share1.(*RefreshShare), share2.(*RefreshShare), shareOut.(*RefreshShare)
+		refreshProtocol.Aggregate(s1.RefreshShareDecrypt, s2.RefreshShareDecrypt, sOut.RefreshShareDecrypt)
+		refreshProtocol.Aggregate(s1.RefreshShareRecrypt, s2.RefreshShareRecrypt, sOut.RefreshShareRecrypt)
+	})
+
+	return drlwe.NewVerifiableAggregator(agg, digest,
+		func(data []byte) (drlwe.Share, error) {
+			if err := checkRefreshShareEncoding(levelStart, ringQ, data); err != nil {
+				return nil, err
+			}
+			share := new(RefreshShare)
+			return share, share.UnmarshalBinary(data)
+		},
+		func(share drlwe.Share) error {
+			s := share.(*RefreshShare)
+			if err := drlwe.ValidatePolyLvl(levelStart, ringQ, s.RefreshShareDecrypt); err != nil {
+				return err
+			}
+			return drlwe.ValidatePoly(ringQ, s.RefreshShareRecrypt)
+		}), nil
+}
+
 // NewRefreshProtocol creates a new instance of the Refresh protocol.
 func NewRefreshProtocol(params ckks.Parameters) (refreshProtocol *RefreshProtocol) {
 
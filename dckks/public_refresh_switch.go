@@ -0,0 +1,252 @@
+package dckks
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RefreshAndSwitchProtocol is a struct storing the parameters for the RefreshAndSwitch protocol.
+// The protocol refreshes a ciphertext of the input parameters and re-encrypts it under the output parameters,
+// which can have a different ring degree, modulus chain and number of slots, with a single round of communication.
+// The masked plaintext is decoded with the input number of slots and re-encoded with the output number of slots:
+// the message is zero-padded if the output has more slots, and truncated if it has less.
+type RefreshAndSwitchProtocol struct {
+	dckksContextIn  *dckksContext
+	dckksContextOut *dckksContext
+	encoderIn       ckks.EncoderBigComplex
+	encoderOut      ckks.EncoderBigComplex
+	tmpIn           *ring.Poly
+	tmpOut          *ring.Poly
+	maskBigintIn    []*big.Int
+	maskBigintOut   []*big.Int
+	maskComplex     []*ring.Complex
+	gaussianIn      *ring.GaussianSampler
+	gaussianOut     *ring.GaussianSampler
+}
+
+// NewRefreshAndSwitchProtocol creates a new instance of the RefreshAndSwitch protocol, from the parameters paramsIn
+// of the ciphertexts to refresh to the parameters paramsOut of the refreshed ciphertexts.
+func NewRefreshAndSwitchProtocol(paramsIn, paramsOut ckks.Parameters) (rfp *RefreshAndSwitchProtocol) {
+
+	// The masked plaintexts have up to log(Q) bits and their decoding must be exact up to a fraction of the scale
+	prec := paramsIn.LogQLvl(paramsIn.MaxLevel()) + 64
+
+	rfp = new(RefreshAndSwitchProtocol)
+	rfp.dckksContextIn = newDckksContext(paramsIn)
+	rfp.dckksContextOut = newDckksContext(paramsOut)
+	rfp.encoderIn = ckks.NewEncoderBigComplex(paramsIn, prec)
+	rfp.encoderOut = ckks.NewEncoderBigComplex(paramsOut, prec)
+	rfp.tmpIn = rfp.dckksContextIn.ringQ.NewPoly()
+	rfp.tmpOut = rfp.dckksContextOut.ringQ.NewPoly()
+
+	rfp.maskBigintIn = make([]*big.Int, paramsIn.N())
+	for i := range rfp.maskBigintIn {
+		rfp.maskBigintIn[i] = new(big.Int)
+	}
+
+	rfp.maskBigintOut = make([]*big.Int, paramsOut.N())
+	for i := range rfp.maskBigintOut {
+		rfp.maskBigintOut[i] = new(big.Int)
+	}
+
+	rfp.maskComplex = make([]*ring.Complex, utils.MaxInt(paramsIn.Slots(), paramsOut.Slots()))
+	for i := range rfp.maskComplex {
+		rfp.maskComplex[i] = ring.NewComplex(ring.NewFloat(0, prec), ring.NewFloat(0, prec))
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	rfp.gaussianIn = ring.NewGaussianSampler(prng, rfp.dckksContextIn.ringQ, paramsIn.Sigma(), int(6*paramsIn.Sigma()))
+	rfp.gaussianOut = ring.NewGaussianSampler(prng, rfp.dckksContextOut.ringQ, paramsOut.Sigma(), int(6*paramsOut.Sigma()))
+
+	return
+}
+
+// AllocateShares allocates the shares of the RefreshAndSwitch protocol. The decryption share is in the ring
+// of the input parameters, at level levelStart, and the recryption share is in the ring of the output parameters.
+func (rfp *RefreshAndSwitchProtocol) AllocateShares(levelStart int) (RefreshShareDecrypt, RefreshShareRecrypt) {
+	return rfp.dckksContextIn.ringQ.NewPolyLvl(levelStart), rfp.dckksContextOut.ringQ.NewPoly()
+}
+
+// GenShares generates the decryption and recryption shares of the RefreshAndSwitch protocol, where skIn is the share of
+// the secret key under the input parameters, skOut is the share of the secret key under the output parameters and crs is
+// a common reference polynomial of the ring of the output parameters.
+func (rfp *RefreshAndSwitchProtocol) GenShares(skIn, skOut *ring.Poly, levelStart, nParties int, ciphertext *ckks.Ciphertext, targetScale float64, crs *ring.Poly, shareDecrypt RefreshShareDecrypt, shareRecrypt RefreshShareRecrypt) {
+
+	ringQIn := rfp.dckksContextIn.ringQ
+	ringQOut := rfp.dckksContextOut.ringQ
+	sigmaIn := rfp.dckksContextIn.params.Sigma()
+	sigmaOut := rfp.dckksContextOut.params.Sigma()
+
+	bound := ring.NewUint(ringQIn.Modulus[0])
+	for i := 1; i < levelStart+1; i++ {
+		bound.Mul(bound, ring.NewUint(ringQIn.Modulus[i]))
+	}
+
+	bound.Quo(bound, ring.NewUint(uint64(2*nParties)))
+	boundHalf := new(big.Int).Rsh(bound, 1)
+
+	var sign int
+	for i := range rfp.maskBigintIn {
+		rfp.maskBigintIn[i] = ring.RandInt(bound)
+		sign = rfp.maskBigintIn[i].Cmp(boundHalf)
+		if sign == 1 || sign == 0 {
+			rfp.maskBigintIn[i].Sub(rfp.maskBigintIn[i], bound)
+		}
+	}
+
+	// h0 = mask (at level min)
+	ringQIn.SetCoefficientsBigintLvl(levelStart, rfp.maskBigintIn, shareDecrypt)
+
+	// h1 = T(mask) (at level max), where T decodes with the input parameters and re-encodes with the output parameters
+	rfp.switchEncoding(ciphertext.Scale(), targetScale)
+	ringQOut.SetCoefficientsBigint(rfp.maskBigintOut, shareRecrypt)
+
+	for i := range rfp.maskBigintIn {
+		rfp.maskBigintIn[i].SetUint64(0)
+	}
+
+	for i := range rfp.maskBigintOut {
+		rfp.maskBigintOut[i].SetUint64(0)
+	}
+
+	ringQIn.NTTLvl(levelStart, shareDecrypt, shareDecrypt)
+	ringQOut.NTT(shareRecrypt, shareRecrypt)
+
+	// h0 = sk*c1 + mask
+	ringQIn.MulCoeffsMontgomeryAndAddLvl(levelStart, skIn, ciphertext.Value[1], shareDecrypt)
+
+	// h1 = sk'*a + T(mask)
+	ringQOut.MulCoeffsMontgomeryAndAdd(skOut, crs, shareRecrypt)
+
+	// h0 = sk*c1 + mask + e0
+	rfp.gaussianIn.ReadFromDistLvl(levelStart, rfp.tmpIn, ringQIn, sigmaIn, int(6*sigmaIn))
+	ringQIn.NTTLvl(levelStart, rfp.tmpIn, rfp.tmpIn)
+	ringQIn.AddLvl(levelStart, shareDecrypt, rfp.tmpIn, shareDecrypt)
+
+	// h1 = sk'*a + T(mask) + e1
+	rfp.gaussianOut.ReadFromDistLvl(len(ringQOut.Modulus)-1, rfp.tmpOut, ringQOut, sigmaOut, int(6*sigmaOut))
+	ringQOut.NTT(rfp.tmpOut, rfp.tmpOut)
+	ringQOut.Add(shareRecrypt, rfp.tmpOut, shareRecrypt)
+
+	// h1 = -sk'*a - T(mask) - e1
+	ringQOut.Neg(shareRecrypt, shareRecrypt)
+
+	rfp.tmpIn.Zero()
+	rfp.tmpOut.Zero()
+}
+
+// AggregateDecrypt adds the decryption shares share1 and share2 on shareOut.
+func (rfp *RefreshAndSwitchProtocol) AggregateDecrypt(share1, share2, shareOut RefreshShareDecrypt) {
+	rfp.dckksContextIn.ringQ.AddLvl(len((*ring.Poly)(share1).Coeffs)-1, share1, share2, shareOut)
+}
+
+// AggregateRecrypt adds the recryption shares share1 and share2 on shareOut.
+func (rfp *RefreshAndSwitchProtocol) AggregateRecrypt(share1, share2, shareOut RefreshShareRecrypt) {
+	rfp.dckksContextOut.ringQ.Add(share1, share2, shareOut)
+}
+
+// Decrypt operates a masked decryption on the ciphertext with the given decryption share.
+func (rfp *RefreshAndSwitchProtocol) Decrypt(ciphertext *ckks.Ciphertext, shareDecrypt RefreshShareDecrypt) {
+	rfp.dckksContextIn.ringQ.AddLvl(ciphertext.Level(), ciphertext.Value[0], shareDecrypt, ciphertext.Value[0])
+}
+
+// Recode takes a masked decrypted ciphertext of the input parameters and writes on ciphertextOut the same masked
+// decrypted ciphertext re-encoded with the output parameters at the scale targetScale. ciphertextOut must be a
+// ciphertext of the output parameters at their maximum level.
+func (rfp *RefreshAndSwitchProtocol) Recode(ciphertext *ckks.Ciphertext, targetScale float64, ciphertextOut *ckks.Ciphertext) {
+
+	ringQIn := rfp.dckksContextIn.ringQ
+	ringQOut := rfp.dckksContextOut.ringQ
+
+	ringQIn.InvNTTLvl(ciphertext.Level(), ciphertext.Value[0], ciphertext.Value[0])
+
+	ringQIn.PolyToBigint(ciphertext.Value[0], rfp.maskBigintIn)
+
+	QStart := ring.NewUint(ringQIn.Modulus[0])
+	for i := 1; i < ciphertext.Level()+1; i++ {
+		QStart.Mul(QStart, ring.NewUint(ringQIn.Modulus[i]))
+	}
+
+	QHalf := new(big.Int).Rsh(QStart, 1)
+
+	// Centers the values around the current modulus
+	var sign int
+	for i := range rfp.maskBigintIn {
+		sign = rfp.maskBigintIn[i].Cmp(QHalf)
+		if sign == 1 || sign == 0 {
+			rfp.maskBigintIn[i].Sub(rfp.maskBigintIn[i], QStart)
+		}
+	}
+
+	rfp.switchEncoding(ciphertext.Scale(), targetScale)
+
+	ringQOut.SetCoefficientsBigint(rfp.maskBigintOut, ciphertextOut.Value[0])
+	ringQOut.NTT(ciphertextOut.Value[0], ciphertextOut.Value[0])
+
+	ciphertextOut.SetScale(targetScale)
+}
+
+// Recrypt operates a masked recryption on the masked decrypted ciphertext of the output parameters.
+func (rfp *RefreshAndSwitchProtocol) Recrypt(ciphertext *ckks.Ciphertext, crs *ring.Poly, shareRecrypt RefreshShareRecrypt) {
+	rfp.dckksContextOut.ringQ.Add(ciphertext.Value[0], shareRecrypt, ciphertext.Value[0])
+	ciphertext.Value[1] = crs.CopyNew()
+}
+
+// switchEncoding decodes maskBigintIn with the input parameters at scale inputScale, and
+// re-encodes the values on maskBigintOut with the output parameters at scale outputScale.
+func (rfp *RefreshAndSwitchProtocol) switchEncoding(inputScale, outputScale float64) {
+
+	paramsIn := rfp.dckksContextIn.params
+	paramsOut := rfp.dckksContextOut.params
+
+	slotsIn, slotsOut := paramsIn.Slots(), paramsOut.Slots()
+	maxSlotsIn, maxSlotsOut := paramsIn.N()>>1, paramsOut.N()>>1
+	gapIn, gapOut := maxSlotsIn/slotsIn, maxSlotsOut/slotsOut
+
+	for i, idx := 0, 0; i < slotsIn; i, idx = i+1, idx+gapIn {
+		rfp.maskComplex[i].Real().SetInt(rfp.maskBigintIn[idx])
+		rfp.maskComplex[i].Imag().SetInt(rfp.maskBigintIn[idx+maxSlotsIn])
+	}
+
+	rfp.encoderIn.FFT(rfp.maskComplex, slotsIn)
+
+	ratio := new(big.Float).SetPrec(rfp.maskComplex[0].Real().Prec())
+	ratio.Quo(ring.NewFloat(outputScale, int(ratio.Prec())), ring.NewFloat(inputScale, int(ratio.Prec())))
+
+	for i := 0; i < slotsIn; i++ {
+		rfp.maskComplex[i].Real().Mul(rfp.maskComplex[i].Real(), ratio)
+		rfp.maskComplex[i].Imag().Mul(rfp.maskComplex[i].Imag(), ratio)
+	}
+
+	// Zero-pads the message if the output has more slots
+	for i := slotsIn; i < slotsOut; i++ {
+		rfp.maskComplex[i].Real().SetInt64(0)
+		rfp.maskComplex[i].Imag().SetInt64(0)
+	}
+
+	rfp.encoderOut.InvFFT(rfp.maskComplex, slotsOut)
+
+	for i := range rfp.maskBigintOut {
+		rfp.maskBigintOut[i].SetUint64(0)
+	}
+
+	for i, idx := 0, 0; i < slotsOut; i, idx = i+1, idx+gapOut {
+		roundBigFloat(rfp.maskComplex[i].Real(), rfp.maskBigintOut[idx])
+		roundBigFloat(rfp.maskComplex[i].Imag(), rfp.maskBigintOut[idx+maxSlotsOut])
+	}
+}
+
+// roundBigFloat sets res to the integer nearest to x (rounding half away from zero).
+func roundBigFloat(x *big.Float, res *big.Int) {
+	half := new(big.Float).SetFloat64(0.5)
+	if x.Sign() < 0 {
+		half.Neg(half)
+	}
+	new(big.Float).SetPrec(x.Prec()).Add(x, half).Int(res)
+}
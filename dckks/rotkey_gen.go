@@ -14,3 +14,21 @@ type RTGProtocol struct {
 func NewRotKGProtocol(params ckks.Parameters) (rtg *RTGProtocol) {
 	return &RTGProtocol{*drlwe.NewRTGProtocol(params.Parameters)}
 }
+
+// RTGSetProtocol is the structure storing the parameters for the collective generation of a set of rotation keys in a single round.
+type RTGSetProtocol struct {
+	drlwe.RTGSetProtocol
+}
+
+// NewRotKGSetProtocol creates a new RTGSetProtocol generating collective rotation-keys for the rotations ks of the slots,
+// and for the conjugation of the slots if includeConjugate is true.
+func NewRotKGSetProtocol(params ckks.Parameters, ks []int, includeConjugate bool) (rtg *RTGSetProtocol) {
+	galEls := make([]uint64, len(ks), len(ks)+1)
+	for i, k := range ks {
+		galEls[i] = params.GaloisElementForColumnRotationBy(k)
+	}
+	if includeConjugate {
+		galEls = append(galEls, params.GaloisElementForRowRotation())
+	}
+	return &RTGSetProtocol{*drlwe.NewRTGSetProtocol(params.Parameters, galEls)}
+}
@@ -0,0 +1,34 @@
+package dckks
+
+import (
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// Run executes the collective key-switching protocol on ct over runner, with the party's shares skInput and
+// skOutput of the input and output secret keys, and writes the key-switched ciphertext on ctOut.
+// All the parties must hold the same ciphertext ct.
+func (cks *CKSProtocol) Run(runner *drlwe.Runner, skInput, skOutput *ring.Poly, ct, ctOut *ckks.Ciphertext) (err error) {
+
+	share, aggregated := cks.AllocateShare(), cks.AllocateShare()
+	cks.GenShare(skInput, skOutput, ct, share)
+
+	if err = runner.RunRound("CKS", (*ring.Poly)(share), (*ring.Poly)(aggregated),
+		func(data []byte) (drlwe.Share, error) {
+			if err := drlwe.CheckPolyEncoding(len(share.Coeffs)-1, cks.dckksContext.ringQ, data); err != nil {
+				return nil, err
+			}
+			received := new(ring.Poly)
+			return received, received.UnmarshalBinary(data)
+		},
+		func(share1, share2, shareOut drlwe.Share) {
+			cks.AggregateShares(share1.(*ring.Poly), share2.(*ring.Poly), shareOut.(*ring.Poly))
+		}); err != nil {
+		return err
+	}
+
+	cks.KeySwitch(aggregated, ct, ctOut)
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package dckks
+
+import (
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// AdditiveShare is an additive secret share of the plaintext of a ciphertext: the plaintexts of the parties sum
+// to the plaintext of the ciphertext, coefficient-wise modulo the moduli of its level. The value is in the
+// coefficient domain, and the message is scaled by the scale of the ciphertext.
+type AdditiveShare struct {
+	Value *ring.Poly
+}
+
+// NewAdditiveShare allocates an AdditiveShare of the plaintext of a ciphertext at the given level.
+func NewAdditiveShare(params ckks.Parameters, level int) *AdditiveShare {
+	return &AdditiveShare{Value: params.RingQ().NewPolyLvl(level)}
+}
+
+// Level returns the level of the share.
+func (share *AdditiveShare) Level() int {
+	return len(share.Value.Coeffs) - 1
+}
+
+// E2SProtocol is the structure storing the parameters and temporary buffers of the encryption-to-shares protocol,
+// which turns a ciphertext encrypted under a collective secret key into additive secret shares of its plaintext,
+// so that the next steps of a computation can be run under secret sharing.
+type E2SProtocol struct {
+	dckksContext  *dckksContext
+	sigmaSmudging float64
+
+	tmp             *ring.Poly
+	uniformSampler  *ring.UniformSampler
+	gaussianSampler *ring.GaussianSampler
+}
+
+// E2SShare is a public share of the E2S protocol.
+type E2SShare *ring.Poly
+
+// NewE2SProtocol creates a new E2SProtocol. The decryption shares are smudged with a Gaussian noise of standard
+// deviation sigmaSmudging, which must be large enough to hide the secret key.
+func NewE2SProtocol(params ckks.Parameters, sigmaSmudging float64) (e2s *E2SProtocol) {
+
+	e2s = new(E2SProtocol)
+	e2s.dckksContext = newDckksContext(params)
+	e2s.sigmaSmudging = sigmaSmudging
+	e2s.tmp = e2s.dckksContext.ringQ.NewPoly()
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	e2s.uniformSampler = ring.NewUniformSampler(prng, e2s.dckksContext.ringQ)
+	e2s.gaussianSampler = ring.NewGaussianSampler(prng, e2s.dckksContext.ringQ, sigmaSmudging, int(6*sigmaSmudging))
+
+	return e2s
+}
+
+// AllocateShare allocates a public share of the E2S protocol for a ciphertext at the given level.
+func (e2s *E2SProtocol) AllocateShare(level int) E2SShare {
+	return e2s.dckksContext.ringQ.NewPolyLvl(level)
+}
+
+// GenShare is the first and unique round of the E2S protocol. Each party samples a uniform mask M_i, which is its
+// secret share, and computes its public share:
+//
+// [sk_i * ct[1] - M_i + e_i]
+//
+// which is sent to the party receiving the masked plaintext.
+func (e2s *E2SProtocol) GenShare(sk *ring.Poly, ct *ckks.Ciphertext, secretShareOut *AdditiveShare, publicShareOut E2SShare) {
+
+	ringQ := e2s.dckksContext.ringQ
+	level := ct.Level()
+
+	// M_i
+	e2s.uniformSampler.Readlvl(level, secretShareOut.Value)
+
+	// sk_i * ct[1] - M_i
+	ringQ.NTTLvl(level, secretShareOut.Value, e2s.tmp)
+	ringQ.NegLvl(level, e2s.tmp, publicShareOut)
+	ringQ.MulCoeffsMontgomeryAndAddLvl(level, sk, ct.Value[1], publicShareOut)
+
+	// sk_i * ct[1] - M_i + e_i
+	e2s.gaussianSampler.ReadFromDistLvl(level, e2s.tmp, ringQ, e2s.sigmaSmudging, int(6*e2s.sigmaSmudging))
+	ringQ.NTTLvl(level, e2s.tmp, e2s.tmp)
+	ringQ.AddLvl(level, publicShareOut, e2s.tmp, publicShareOut)
+
+	e2s.tmp.Zero()
+}
+
+// AggregateShares adds share1 with share2 on shareOut.
+func (e2s *E2SProtocol) AggregateShares(share1, share2, shareOut E2SShare) {
+	e2s.dckksContext.ringQ.AddLvl(len(share1.Coeffs)-1, share1, share2, shareOut)
+}
+
+// GetShare is run by the party receiving the aggregated public shares of all the parties, including its own. It
+// unmasks the plaintext of ct with its own mask, and returns in secretShareOut its secret share:
+//
+// ct[0] + sum(sk_i * ct[1] - M_i + e_i) + M_self = m - sum_{i != self} M_i + e
+//
+// secretShare and secretShareOut can be the same element.
+func (e2s *E2SProtocol) GetShare(secretShare *AdditiveShare, aggregatePublicShare E2SShare, ct *ckks.Ciphertext, secretShareOut *AdditiveShare) {
+
+	ringQ := e2s.dckksContext.ringQ
+	level := ct.Level()
+
+	ringQ.AddLvl(level, ct.Value[0], aggregatePublicShare, e2s.tmp)
+	ringQ.InvNTTLvl(level, e2s.tmp, e2s.tmp)
+	ringQ.AddLvl(level, secretShare.Value, e2s.tmp, secretShareOut.Value)
+
+	e2s.tmp.Zero()
+}
+
+// S2EProtocol is the structure storing the parameters and temporary buffers of the shares-to-encryption protocol,
+// which turns additive secret shares of a plaintext into its encryption under a collective secret key.
+type S2EProtocol struct {
+	dckksContext *dckksContext
+
+	tmp             *ring.Poly
+	gaussianSampler *ring.GaussianSampler
+}
+
+// S2EShare is a public share of the S2E protocol.
+type S2EShare *ring.Poly
+
+// NewS2EProtocol creates a new S2EProtocol.
+func NewS2EProtocol(params ckks.Parameters) (s2e *S2EProtocol) {
+
+	s2e = new(S2EProtocol)
+	s2e.dckksContext = newDckksContext(params)
+	s2e.tmp = s2e.dckksContext.ringQ.NewPoly()
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	s2e.gaussianSampler = ring.NewGaussianSampler(prng, s2e.dckksContext.ringQ, params.Sigma(), int(6*params.Sigma()))
+
+	return s2e
+}
+
+// AllocateShare allocates a public share of the S2E protocol for a ciphertext at the given level.
+func (s2e *S2EProtocol) AllocateShare(level int) S2EShare {
+	return s2e.dckksContext.ringQ.NewPolyLvl(level)
+}
+
+// GenShare is the first and unique round of the S2E protocol. Given the common reference polynomial crs, each
+// party computes from its secret share M_i its public share:
+//
+// [-sk_i * crs + M_i + e_i]
+//
+// at the level of the secret share.
+func (s2e *S2EProtocol) GenShare(sk *ring.Poly, crs *ring.Poly, secretShare *AdditiveShare, publicShareOut S2EShare) {
+
+	ringQ := s2e.dckksContext.ringQ
+	sigma := s2e.dckksContext.params.Sigma()
+	level := secretShare.Level()
+
+	// M_i + e_i
+	s2e.gaussianSampler.ReadFromDistLvl(level, s2e.tmp, ringQ, sigma, int(6*sigma))
+	ringQ.AddLvl(level, secretShare.Value, s2e.tmp, s2e.tmp)
+	ringQ.NTTLvl(level, s2e.tmp, publicShareOut)
+
+	// -sk_i * crs + M_i + e_i
+	ringQ.MulCoeffsMontgomeryAndSubLvl(level, sk, crs, publicShareOut)
+
+	s2e.tmp.Zero()
+}
+
+// AggregateShares adds share1 with share2 on shareOut.
+func (s2e *S2EProtocol) AggregateShares(share1, share2, shareOut S2EShare) {
+	s2e.dckksContext.ringQ.AddLvl(len(share1.Coeffs)-1, share1, share2, shareOut)
+}
+
+// GetEncryption returns in ctOut the encryption [sum(-sk_i * crs + M_i + e_i), crs] of the sum of the secret
+// shares, at the level of the aggregated public share, to which ctOut is dropped if needed. The scale of ctOut is
+// left unchanged and must be set to the scale of the shared message.
+func (s2e *S2EProtocol) GetEncryption(aggregatePublicShare S2EShare, crs *ring.Poly, ctOut *ckks.Ciphertext) {
+
+	ringQ := s2e.dckksContext.ringQ
+	level := len(aggregatePublicShare.Coeffs) - 1
+
+	if ctOut.Level() < level {
+		panic("cannot GetEncryption: the level of ctOut is smaller than the level of the share")
+	}
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:level+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:level+1]
+
+	ringQ.CopyLvl(level, aggregatePublicShare, ctOut.Value[0])
+	ringQ.CopyLvl(level, crs, ctOut.Value[1])
+}
@@ -0,0 +1,286 @@
+package drlwe
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// PartyID is the identifier of a party in a multiparty protocol.
+type PartyID string
+
+// Share is the interface of the protocol shares that can be aggregated and checkpointed by an Aggregator.
+type Share interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// AggregateFunc is a function aggregating share1 and share2 into shareOut.
+type AggregateFunc func(share1, share2, shareOut Share)
+
+// Aggregator aggregates the shares of a protocol round as they are received, in any order, from a known set of
+// parties. Duplicate shares are detected by their party ID and ignored, and the partially aggregated share can be
+// checkpointed to the disk and resumed, so that a round does not need to be restarted when a party drops.
+// The methods of an Aggregator are safe for concurrent use.
+type Aggregator struct {
+	mutex sync.Mutex
+
+	parties   map[PartyID]bool
+	received  map[PartyID]bool
+	aggregate AggregateFunc
+
+	aggregated Share
+}
+
+// NewAggregator creates a new Aggregator for the given set of parties, aggregating the received shares on
+// aggregated with the aggregate function. aggregated must be a freshly allocated (zero) share.
+func NewAggregator(parties []PartyID, aggregated Share, aggregate AggregateFunc) *Aggregator {
+
+	if len(parties) == 0 {
+		panic("cannot NewAggregator: empty set of parties")
+	}
+
+	agg := &Aggregator{
+		parties:    make(map[PartyID]bool, len(parties)),
+		received:   make(map[PartyID]bool, len(parties)),
+		aggregate:  aggregate,
+		aggregated: aggregated,
+	}
+
+	for _, id := range parties {
+		if agg.parties[id] {
+			panic(fmt.Sprintf("cannot NewAggregator: duplicate party ID %q", id))
+		}
+		agg.parties[id] = true
+	}
+
+	return agg
+}
+
+// NewCKGAggregator creates a new Aggregator for the shares of the collective public key generation protocol.
+func NewCKGAggregator(ckg CollectivePublicKeyGenerator, parties []PartyID) *Aggregator {
+	return NewAggregator(parties, ckg.AllocateShares(), func(share1, share2, shareOut Share) {
+		ckg.AggregateShares(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare))
+	})
+}
+
+// NewRKGAggregator creates a new Aggregator for the shares of one of the two rounds of the collective
+// relinearization key generation protocol.
+func NewRKGAggregator(rkg RelinearizationKeyGenerator, parties []PartyID) *Aggregator {
+	_, share, _ := rkg.AllocateShares()
+	return NewAggregator(parties, share, func(share1, share2, shareOut Share) {
+		rkg.AggregateShares(share1.(*RKGShare), share2.(*RKGShare), shareOut.(*RKGShare))
+	})
+}
+
+// NewRTGAggregator creates a new Aggregator for the shares of the collective rotation key generation protocol.
+func NewRTGAggregator(rtg RotationKeyGenerator, parties []PartyID) *Aggregator {
+	return NewAggregator(parties, rtg.AllocateShares(), func(share1, share2, shareOut Share) {
+		rtg.Aggregate(share1.(*RTGShare), share2.(*RTGShare), shareOut.(*RTGShare))
+	})
+}
+
+// NewRTGSetAggregator creates a new Aggregator for the shares of the collective rotation key set generation protocol.
+func NewRTGSetAggregator(rtg *RTGSetProtocol, parties []PartyID) *Aggregator {
+	return NewAggregator(parties, rtg.AllocateShares(), func(share1, share2, shareOut Share) {
+		rtg.Aggregate(share1.(*RTGSetShare), share2.(*RTGSetShare), shareOut.(*RTGSetShare))
+	})
+}
+
+// Add aggregates the share of the party id. Returns false if the share of this party was already aggregated, in which
+// case the share is ignored, and an error if id is not one of the parties of the aggregator.
+func (agg *Aggregator) Add(id PartyID, share Share) (added bool, err error) {
+
+	agg.mutex.Lock()
+	defer agg.mutex.Unlock()
+
+	if !agg.parties[id] {
+		return false, fmt.Errorf("cannot Add: unknown party ID %q", id)
+	}
+
+	if agg.received[id] {
+		return false, nil
+	}
+
+	agg.aggregate(agg.aggregated, share, agg.aggregated)
+	agg.received[id] = true
+
+	return true, nil
+}
+
+// Received returns the sorted IDs of the parties whose share was aggregated.
+func (agg *Aggregator) Received() []PartyID {
+	agg.mutex.Lock()
+	defer agg.mutex.Unlock()
+	return sortedIDs(agg.received, true)
+}
+
+// Missing returns the sorted IDs of the parties whose share was not yet aggregated.
+func (agg *Aggregator) Missing() []PartyID {
+	agg.mutex.Lock()
+	defer agg.mutex.Unlock()
+
+	missing := make(map[PartyID]bool)
+	for id := range agg.parties {
+		missing[id] = !agg.received[id]
+	}
+
+	return sortedIDs(missing, true)
+}
+
+// Complete returns true if the shares of all the parties were aggregated.
+func (agg *Aggregator) Complete() bool {
+	agg.mutex.Lock()
+	defer agg.mutex.Unlock()
+	return len(agg.received) == len(agg.parties)
+}
+
+// Aggregated returns the current aggregation of the received shares. The result must not be modified while
+// shares are still being added.
+func (agg *Aggregator) Aggregated() Share {
+	return agg.aggregated
+}
+
+func sortedIDs(set map[PartyID]bool, value bool) (ids []PartyID) {
+	ids = []PartyID{}
+	for id, v := range set {
+		if v == value {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return
+}
+
+// MarshalBinary encodes the state of the aggregator, i.e. the IDs of the parties whose share was aggregated and
+// the current aggregated share, on a slice of bytes.
+func (agg *Aggregator) MarshalBinary() (data []byte, err error) {
+
+	agg.mutex.Lock()
+	defer agg.mutex.Unlock()
+
+	// Data is :
+	// 4 bytes : number of received parties
+	// for each party, 2 bytes : length of the ID, followed by the ID
+	// the remaining bytes : aggregated share
+	received := sortedIDs(agg.received, true)
+
+	data = make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(len(received)))
+
+	for _, id := range received {
+		if len(id) > 0xFFFF {
+			return nil, fmt.Errorf("cannot MarshalBinary: party ID is too long")
+		}
+		data = append(data, byte(len(id)>>8), byte(len(id)))
+		data = append(data, id...)
+	}
+
+	var share []byte
+	if share, err = agg.aggregated.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	return append(data, share...), nil
+}
+
+// UnmarshalBinary decodes a previously marshaled state on the target aggregator, replacing its current state.
+// Returns an error if the state contains a party that is not one of the parties of the aggregator.
+func (agg *Aggregator) UnmarshalBinary(data []byte) (err error) {
+
+	agg.mutex.Lock()
+	defer agg.mutex.Unlock()
+
+	if len(data) < 4 {
+		return errors.New("too small bytearray")
+	}
+
+	n := int(binary.BigEndian.Uint32(data))
+	ptr := 4
+
+	received := make(map[PartyID]bool, n)
+	for i := 0; i < n; i++ {
+
+		if len(data) < ptr+2 {
+			return errors.New("too small bytearray")
+		}
+
+		idLen := int(binary.BigEndian.Uint16(data[ptr:]))
+		ptr += 2
+
+		if len(data) < ptr+idLen {
+			return errors.New("too small bytearray")
+		}
+
+		id := PartyID(data[ptr : ptr+idLen])
+		ptr += idLen
+
+		if !agg.parties[id] {
+			return fmt.Errorf("cannot UnmarshalBinary: unknown party ID %q", id)
+		}
+
+		received[id] = true
+	}
+
+	if err = agg.aggregated.UnmarshalBinary(data[ptr:]); err != nil {
+		return err
+	}
+
+	agg.received = received
+
+	return nil
+}
+
+// Checkpoint writes the state of the aggregator to the file at path. The file is replaced atomically, so that
+// a previous checkpoint is never lost if the write fails.
+func (agg *Aggregator) Checkpoint(path string) (err error) {
+
+	var data []byte
+	if data, err = agg.MarshalBinary(); err != nil {
+		return err
+	}
+
+	var tmp *os.File
+	if tmp, err = ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp"); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Resume restores the state of the aggregator from a checkpoint previously written at path.
+func (agg *Aggregator) Resume(path string) (err error) {
+
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		return err
+	}
+
+	return agg.UnmarshalBinary(data)
+}
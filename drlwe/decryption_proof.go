@@ -0,0 +1,392 @@
+package drlwe
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// Commitment is a hash-based commitment to the share of a party in a collective decryption or in a round verified by
+// a VerifiableAggregator.
+type Commitment [sha256.Size]byte
+
+// ShareOpening is the opening of a Commitment: the marshaled share of a party and the random nonce with which it
+// was committed.
+type ShareOpening struct {
+	Share []byte
+	Nonce [32]byte
+}
+
+// CiphertextDigest returns the SHA-256 digest of the polynomials of a ciphertext. It binds the commitments of a
+// collective decryption to the ciphertext being decrypted.
+func CiphertextDigest(value []*ring.Poly) (digest [sha256.Size]byte, err error) {
+
+	h := sha256.New()
+
+	var data []byte
+	for _, pol := range value {
+		if data, err = pol.MarshalBinary(); err != nil {
+			return digest, err
+		}
+		if _, err = h.Write(data); err != nil {
+			return digest, err
+		}
+	}
+
+	copy(digest[:], h.Sum(nil))
+
+	return digest, nil
+}
+
+// CommitShare commits to the share of the party id in the collective decryption of the ciphertext of the given
+// digest, or in the round of a VerifiableAggregator for the session of the given digest. The commitment is bound to
+// id, so that another party cannot copy it and later replay the opening of id as its own.
+// The commitment is broadcast to the other parties, and the opening is kept secret until all the commitments
+// have been received.
+func CommitShare(digest [sha256.Size]byte, id PartyID, share Share) (commitment Commitment, opening *ShareOpening, err error) {
+
+	opening = new(ShareOpening)
+
+	if opening.Share, err = share.MarshalBinary(); err != nil {
+		return commitment, nil, err
+	}
+
+	if _, err = rand.Read(opening.Nonce[:]); err != nil {
+		return commitment, nil, err
+	}
+
+	return opening.commitment(digest, id), opening, nil
+}
+
+// Verify returns true if the opening matches the commitment of the party id for the ciphertext of the given digest.
+func (opening *ShareOpening) Verify(digest [sha256.Size]byte, id PartyID, commitment Commitment) bool {
+	c := opening.commitment(digest, id)
+	return bytes.Equal(c[:], commitment[:])
+}
+
+// commitment returns SHA-256(digest || len(id) || id || nonce || share).
+func (opening *ShareOpening) commitment(digest [sha256.Size]byte, id PartyID) (c Commitment) {
+	var buff [8]byte
+	binary.BigEndian.PutUint64(buff[:], uint64(len(id)))
+	h := sha256.New()
+	h.Write(digest[:])
+	h.Write(buff[:])
+	h.Write([]byte(id))
+	h.Write(opening.Nonce[:])
+	h.Write(opening.Share)
+	copy(c[:], h.Sum(nil))
+	return
+}
+
+// DecryptionTranscript is the auditable transcript of a collective decryption between a known set of parties.
+// Each party first commits to its decryption share (see CommitShare), and reveals its share only once the
+// commitments of all the parties have been recorded, so that no party can choose its share depending on the
+// shares of the others. The transcript can be checked by any party, or by an external auditor, with Verify. The
+// dbfv.CKSProtocol and the dckks.CKSProtocol run a collective decryption with a transcript (see their
+// DecryptWithTranscript), and a single-party decryption with a transcript (see their DecryptNewWithTranscript).
+type DecryptionTranscript struct {
+	Digest      [sha256.Size]byte
+	Commitments map[PartyID]Commitment
+	Openings    map[PartyID]*ShareOpening
+	Aggregated  []byte
+
+	parties []PartyID
+}
+
+// NewDecryptionTranscript creates a new transcript for the collective decryption, by the given parties, of the
+// ciphertext of the given digest (see CiphertextDigest).
+func NewDecryptionTranscript(parties []PartyID, digest [sha256.Size]byte) *DecryptionTranscript {
+
+	if len(parties) == 0 {
+		panic("cannot NewDecryptionTranscript: empty set of parties")
+	}
+
+	set := make(map[PartyID]bool, len(parties))
+	for _, id := range parties {
+		if set[id] {
+			panic(fmt.Sprintf("cannot NewDecryptionTranscript: duplicate party ID %q", id))
+		}
+		set[id] = true
+	}
+
+	return &DecryptionTranscript{
+		Digest:      digest,
+		Commitments: make(map[PartyID]Commitment, len(parties)),
+		Openings:    make(map[PartyID]*ShareOpening, len(parties)),
+		parties:     sortedIDs(set, true),
+	}
+}
+
+// Parties returns the sorted IDs of the parties of the transcript.
+func (tr *DecryptionTranscript) Parties() []PartyID {
+	return tr.parties
+}
+
+func (tr *DecryptionTranscript) isParty(id PartyID) bool {
+	for _, p := range tr.parties {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AddCommitment records the commitment of the party id. Returns an error if id is not one of the parties of the
+// transcript or if its commitment was already recorded.
+func (tr *DecryptionTranscript) AddCommitment(id PartyID, commitment Commitment) error {
+
+	if !tr.isParty(id) {
+		return fmt.Errorf("cannot AddCommitment: unknown party ID %q", id)
+	}
+
+	if _, ok := tr.Commitments[id]; ok {
+		return fmt.Errorf("cannot AddCommitment: the commitment of party %q was already recorded", id)
+	}
+
+	tr.Commitments[id] = commitment
+
+	return nil
+}
+
+// AddOpening records the opening of the party id. Returns an error if the commitments of all the parties were not
+// yet recorded, or if the opening does not match the commitment of the party.
+func (tr *DecryptionTranscript) AddOpening(id PartyID, opening *ShareOpening) error {
+
+	if len(tr.Commitments) != len(tr.parties) {
+		return errors.New("cannot AddOpening: the commitments of all the parties must be recorded first")
+	}
+
+	commitment, ok := tr.Commitments[id]
+	if !ok {
+		return fmt.Errorf("cannot AddOpening: unknown party ID %q", id)
+	}
+
+	if opening == nil || !opening.Verify(tr.Digest, id, commitment) {
+		return fmt.Errorf("cannot AddOpening: the opening of party %q does not match its commitment", id)
+	}
+
+	tr.Openings[id] = opening
+
+	return nil
+}
+
+// SetAggregated records the aggregated share from which the plaintext was obtained.
+func (tr *DecryptionTranscript) SetAggregated(aggregated Share) (err error) {
+	tr.Aggregated, err = aggregated.MarshalBinary()
+	return
+}
+
+// Aggregate aggregates the opened shares of all the parties on aggregated, a freshly allocated (zero) share, and
+// records the result as the aggregated share of the transcript (see SetAggregated). decode, validate and
+// aggregate are as in Verify. Returns an error if the opening of a party is missing or invalid.
+func (tr *DecryptionTranscript) Aggregate(decode ShareDecoder, validate func(Share) error, aggregated Share, aggregate AggregateFunc) (err error) {
+
+	for _, id := range tr.parties {
+		if _, ok := tr.Openings[id]; !ok {
+			return fmt.Errorf("cannot Aggregate: missing opening of party %q", id)
+		}
+	}
+
+	if err = tr.aggregateOpenings(decode, validate, aggregated, aggregate); err != nil {
+		return fmt.Errorf("cannot Aggregate: %w", err)
+	}
+
+	return tr.SetAggregated(aggregated)
+}
+
+// Verify checks the transcript against the ciphertext of the given digest: the transcript must contain a
+// commitment and a matching opening for each party, and the recorded aggregated share must be the aggregation,
+// with the aggregate function, of the opened shares. decode must decode the opened shares on freshly allocated
+// shares and return an error if an encoding is not the one of a share of the expected ring (e.g.
+// CheckPolyEncoding), validate, which can be nil, must return an error if a decoded share is not well formed (e.g.
+// ValidatePoly), and aggregated must be a freshly allocated (zero) share on which they are aggregated.
+//
+// Verify checks only the consistency of the transcript with the commitments: it does not check that a committed
+// share is a correct partial decryption of the ciphertext under the secret key of its party, and a party can
+// commit to an arbitrary share.
+func (tr *DecryptionTranscript) Verify(digest [sha256.Size]byte, decode ShareDecoder, validate func(Share) error, aggregated Share, aggregate AggregateFunc) (err error) {
+
+	if !bytes.Equal(digest[:], tr.Digest[:]) {
+		return errors.New("invalid transcript: the transcript is not the one of the ciphertext")
+	}
+
+	for _, id := range tr.parties {
+
+		commitment, ok := tr.Commitments[id]
+		if !ok {
+			return fmt.Errorf("invalid transcript: missing commitment of party %q", id)
+		}
+
+		opening, ok := tr.Openings[id]
+		if !ok {
+			return fmt.Errorf("invalid transcript: missing opening of party %q", id)
+		}
+
+		if !opening.Verify(tr.Digest, id, commitment) {
+			return fmt.Errorf("invalid transcript: the opening of party %q does not match its commitment", id)
+		}
+	}
+
+	if err = tr.aggregateOpenings(decode, validate, aggregated, aggregate); err != nil {
+		return fmt.Errorf("invalid transcript: %w", err)
+	}
+
+	var data []byte
+	if data, err = aggregated.MarshalBinary(); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(data, tr.Aggregated) {
+		return errors.New("invalid transcript: the aggregated share is not the aggregation of the opened shares")
+	}
+
+	return nil
+}
+
+// aggregateOpenings decodes, validates and aggregates on aggregated the opened shares of the parties.
+func (tr *DecryptionTranscript) aggregateOpenings(decode ShareDecoder, validate func(Share) error, aggregated Share, aggregate AggregateFunc) (err error) {
+
+	for _, id := range tr.parties {
+
+		var share Share
+		if share, err = decode(tr.Openings[id].Share); err != nil {
+			return fmt.Errorf("cannot decode the share of party %q: %w", id, err)
+		}
+
+		if validate != nil {
+			if err = validate(share); err != nil {
+				return fmt.Errorf("the share of party %q is not well formed: %w", id, err)
+			}
+		}
+
+		aggregate(aggregated, share, aggregated)
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the transcript on a slice of bytes.
+func (tr *DecryptionTranscript) MarshalBinary() (data []byte, err error) {
+
+	// Data is :
+	// 32 bytes : digest of the ciphertext
+	// 4 bytes : number of parties
+	// for each party, 2 bytes : length of the ID, followed by the ID, 1 byte : flags (1: commitment, 2: opening),
+	//     32 bytes : commitment, and if opened, 32 bytes : nonce, 4 bytes : length of the share, followed by the share
+	// 4 bytes : length of the aggregated share, followed by the aggregated share
+	data = append(data, tr.Digest[:]...)
+	data = appendUint32(data, len(tr.parties))
+
+	for _, id := range tr.parties {
+
+		if len(id) > 0xFFFF {
+			return nil, fmt.Errorf("cannot MarshalBinary: party ID is too long")
+		}
+
+		data = append(data, byte(len(id)>>8), byte(len(id)))
+		data = append(data, id...)
+
+		var flags byte
+		commitment, hasCommitment := tr.Commitments[id]
+		opening, hasOpening := tr.Openings[id]
+		if hasCommitment {
+			flags |= 1
+		}
+		if hasOpening {
+			flags |= 2
+		}
+
+		data = append(data, flags)
+		data = append(data, commitment[:]...)
+
+		if hasOpening {
+			data = append(data, opening.Nonce[:]...)
+			data = appendUint32(data, len(opening.Share))
+			data = append(data, opening.Share...)
+		}
+	}
+
+	data = appendUint32(data, len(tr.Aggregated))
+	data = append(data, tr.Aggregated...)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled transcript on the target transcript.
+func (tr *DecryptionTranscript) UnmarshalBinary(data []byte) (err error) {
+
+	r := &byteReader{data: data}
+
+	copy(tr.Digest[:], r.next(sha256.Size))
+
+	n := r.uint32()
+
+	tr.Commitments = make(map[PartyID]Commitment)
+	tr.Openings = make(map[PartyID]*ShareOpening)
+	tr.parties = []PartyID{}
+
+	for i := 0; i < n && r.err == nil; i++ {
+
+		idLen := int(binary.BigEndian.Uint16(r.next(2)))
+		id := PartyID(r.next(idLen))
+		flags := r.next(1)
+		commitment := r.next(sha256.Size)
+
+		if r.err != nil {
+			break
+		}
+
+		tr.parties = append(tr.parties, id)
+
+		if flags[0]&1 == 1 {
+			var c Commitment
+			copy(c[:], commitment)
+			tr.Commitments[id] = c
+		}
+
+		if flags[0]&2 == 2 {
+			opening := new(ShareOpening)
+			copy(opening.Nonce[:], r.next(32))
+			opening.Share = append([]byte{}, r.next(r.uint32())...)
+			tr.Openings[id] = opening
+		}
+	}
+
+	tr.Aggregated = append([]byte{}, r.next(r.uint32())...)
+
+	return r.err
+}
+
+func appendUint32(data []byte, v int) []byte {
+	var buff [4]byte
+	binary.BigEndian.PutUint32(buff[:], uint32(v))
+	return append(data, buff[:]...)
+}
+
+// byteReader reads consecutive fields from a slice of bytes, and records an error if the slice is too small.
+type byteReader struct {
+	data []byte
+	err  error
+}
+
+func (r *byteReader) next(n int) []byte {
+	if r.err != nil || n < 0 || len(r.data) < n {
+		r.err = errors.New("too small bytearray")
+		if n < 0 || n > sha256.Size {
+			return nil
+		}
+		return make([]byte, n)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *byteReader) uint32() int {
+	return int(binary.BigEndian.Uint32(r.next(4)))
+}
@@ -39,6 +39,12 @@ func (share *CKGShare) UnmarshalBinary(data []byte) error {
 	return err
 }
 
+// CheckEncoding returns an error if data is not the encoding of a CKG share with the dimensions of the target share,
+// without decoding it.
+func (share *CKGShare) CheckEncoding(data []byte) error {
+	return checkPolyEncodingLike(share.Poly, data)
+}
+
 // NewCKGProtocol creates a new CKGProtocol instance
 func NewCKGProtocol(params rlwe.Parameters) *CKGProtocol { // TODO drlwe.Params
 
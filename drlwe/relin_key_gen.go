@@ -230,3 +230,29 @@ func (share *RKGShare) UnmarshalBinary(data []byte) error {
 
 	return nil
 }
+
+// CheckEncoding returns an error if data is not the encoding of a RKG share with the dimensions of the target share,
+// without decoding it.
+func (share *RKGShare) CheckEncoding(data []byte) error {
+
+	if len(data) < 1 || int(data[0]) != len(share.value) {
+		return errors.New("invalid number of polynomials")
+	}
+
+	rLength := share.value[0][0].GetDataLen(true)
+	if len(data) != 1+2*rLength*len(share.value) {
+		return errors.New("invalid length")
+	}
+
+	ptr := 1
+	for i := range share.value {
+		for j := 0; j < 2; j++ {
+			if err := checkPolyEncodingLike(share.value[i][j], data[ptr:ptr+rLength]); err != nil {
+				return err
+			}
+			ptr += rLength
+		}
+	}
+
+	return nil
+}
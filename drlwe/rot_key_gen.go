@@ -162,3 +162,21 @@ func (share *RTGShare) UnmarshalBinary(data []byte) error {
 
 	return nil
 }
+
+// CheckEncoding returns an error if data is not the encoding of a RTG share with the dimensions of the target share,
+// without decoding it.
+func (share *RTGShare) CheckEncoding(data []byte) error {
+
+	lenRing := share.Value[0].GetDataLen(true)
+	if len(data) != 8+lenRing*len(share.Value) || binary.BigEndian.Uint64(data[:8]) != uint64(lenRing) {
+		return errors.New("invalid length")
+	}
+
+	for i, pol := range share.Value {
+		if err := checkPolyEncodingLike(pol, data[8+i*lenRing:8+(i+1)*lenRing]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RTGSetShare is a party's share in the RTGSet protocol. It stores an RTGShare per Galois element.
+type RTGSetShare struct {
+	Shares map[uint64]*RTGShare
+}
+
+// RTGSetProtocol is the structure storing the parameters for the collective generation of a set of
+// rotation keys in a single round. It runs an instance of the RTG protocol for each Galois element of
+// the set, with its own common reference polynomials, and sends all the shares in a single message.
+type RTGSetProtocol struct {
+	RTGProtocol
+	galEls []uint64
+}
+
+// NewRTGSetProtocol creates a RTGSetProtocol instance for the given Galois elements. Duplicate elements are ignored.
+func NewRTGSetProtocol(params rlwe.Parameters, galEls []uint64) *RTGSetProtocol {
+
+	if len(galEls) == 0 {
+		panic("cannot NewRTGSetProtocol: empty set of Galois elements")
+	}
+
+	set := make(map[uint64]bool, len(galEls))
+	for _, galEl := range galEls {
+		set[galEl] = true
+	}
+
+	rtg := &RTGSetProtocol{RTGProtocol: *NewRTGProtocol(params), galEls: make([]uint64, 0, len(set))}
+	for galEl := range set {
+		rtg.galEls = append(rtg.galEls, galEl)
+	}
+
+	sort.Slice(rtg.galEls, func(i, j int) bool { return rtg.galEls[i] < rtg.galEls[j] })
+
+	return rtg
+}
+
+// GaloisElements returns the sorted Galois elements of the rotation keys generated by the protocol.
+func (rtg *RTGSetProtocol) GaloisElements() []uint64 {
+	return append([]uint64{}, rtg.galEls...)
+}
+
+// SampleCRP samples the common reference polynomials of the protocol from prng. All the parties must sample
+// the CRP from PRNGs with the same seed.
+func (rtg *RTGSetProtocol) SampleCRP(prng utils.PRNG) (crp map[uint64][]*ring.Poly) {
+	crpGenerator := ring.NewUniformSampler(prng, rtg.ringQP)
+	crp = make(map[uint64][]*ring.Poly, len(rtg.galEls))
+	for _, galEl := range rtg.galEls {
+		crp[galEl] = make([]*ring.Poly, rtg.params.Beta())
+		for i := range crp[galEl] {
+			crp[galEl][i] = crpGenerator.ReadNew()
+		}
+	}
+	return
+}
+
+// AllocateShares allocates a party's share in the RTGSet protocol.
+func (rtg *RTGSetProtocol) AllocateShares() (rtgShare *RTGSetShare) {
+	rtgShare = &RTGSetShare{Shares: make(map[uint64]*RTGShare, len(rtg.galEls))}
+	for _, galEl := range rtg.galEls {
+		rtgShare.Shares[galEl] = rtg.RTGProtocol.AllocateShares()
+	}
+	return
+}
+
+// GenShare generates a party's share in the RTGSet protocol, with the common reference polynomials crp
+// indexed by Galois element.
+func (rtg *RTGSetProtocol) GenShare(sk *rlwe.SecretKey, crp map[uint64][]*ring.Poly, shareOut *RTGSetShare) {
+	for _, galEl := range rtg.galEls {
+		rtg.RTGProtocol.GenShare(sk, galEl, rtg.crpOf(crp, galEl), shareOut.Shares[galEl])
+	}
+}
+
+// Aggregate aggregates two shares in the RTGSet protocol.
+func (rtg *RTGSetProtocol) Aggregate(share1, share2, shareOut *RTGSetShare) {
+	for _, galEl := range rtg.galEls {
+		rtg.RTGProtocol.Aggregate(share1.Shares[galEl], share2.Shares[galEl], shareOut.Shares[galEl])
+	}
+}
+
+// GenRotationKeySet finalizes the RTGSet protocol and populates the input RotationKeySet, which must contain a
+// key for each Galois element of the protocol, with the computed collective SwitchingKeys.
+func (rtg *RTGSetProtocol) GenRotationKeySet(share *RTGSetShare, crp map[uint64][]*ring.Poly, rotKeySet *rlwe.RotationKeySet) {
+	for _, galEl := range rtg.galEls {
+		rotKey, ok := rotKeySet.Keys[galEl]
+		if !ok {
+			panic(fmt.Sprintf("cannot GenRotationKeySet: rotKeySet has no key for Galois element %d", galEl))
+		}
+		rtg.RTGProtocol.GenRotationKey(share.Shares[galEl], rtg.crpOf(crp, galEl), rotKey)
+	}
+}
+
+func (rtg *RTGSetProtocol) crpOf(crp map[uint64][]*ring.Poly, galEl uint64) []*ring.Poly {
+	c, ok := crp[galEl]
+	if !ok || len(c) != rtg.params.Beta() {
+		panic(fmt.Sprintf("cannot RTGSetProtocol: invalid CRP for Galois element %d", galEl))
+	}
+	return c
+}
+
+// MarshalBinary encodes the target element on a slice of bytes, with the shares sorted by Galois element.
+func (share *RTGSetShare) MarshalBinary() (data []byte, err error) {
+
+	galEls := make([]uint64, 0, len(share.Shares))
+	for galEl := range share.Shares {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	data = make([]byte, 8, 8+len(galEls)*16)
+	binary.BigEndian.PutUint64(data, uint64(len(galEls)))
+
+	for _, galEl := range galEls {
+
+		var shareData []byte
+		if shareData, err = share.Shares[galEl].MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+		var header [16]byte
+		binary.BigEndian.PutUint64(header[:8], galEl)
+		binary.BigEndian.PutUint64(header[8:], uint64(len(shareData)))
+
+		data = append(data, header[:]...)
+		data = append(data, shareData...)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (share *RTGSetShare) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 8 {
+		return errors.New("Unsufficient data length")
+	}
+
+	n := binary.BigEndian.Uint64(data[:8])
+	ptr := uint64(8)
+
+	share.Shares = make(map[uint64]*RTGShare)
+
+	for i := uint64(0); i < n; i++ {
+
+		if uint64(len(data)) < ptr+16 {
+			return errors.New("Unsufficient data length")
+		}
+
+		galEl := binary.BigEndian.Uint64(data[ptr : ptr+8])
+		length := binary.BigEndian.Uint64(data[ptr+8 : ptr+16])
+		ptr += 16
+
+		if uint64(len(data)) < ptr+length {
+			return errors.New("Unsufficient data length")
+		}
+
+		share.Shares[galEl] = new(RTGShare)
+		if err = share.Shares[galEl].UnmarshalBinary(data[ptr : ptr+length]); err != nil {
+			return err
+		}
+		ptr += length
+	}
+
+	return nil
+}
+
+// CheckEncoding returns an error if data is not the encoding of a RTGSet share with the Galois elements and the
+// dimensions of the target share, without decoding it.
+func (share *RTGSetShare) CheckEncoding(data []byte) error {
+
+	if len(data) < 8 || binary.BigEndian.Uint64(data[:8]) != uint64(len(share.Shares)) {
+		return errors.New("invalid number of Galois elements")
+	}
+
+	seen := make(map[uint64]bool, len(share.Shares))
+
+	ptr := 8
+	for range share.Shares {
+
+		if len(data) < ptr+16 {
+			return errors.New("Unsufficient data length")
+		}
+
+		galEl := binary.BigEndian.Uint64(data[ptr : ptr+8])
+		rtgShare, ok := share.Shares[galEl]
+		if !ok || seen[galEl] {
+			return fmt.Errorf("unexpected Galois element %d", galEl)
+		}
+		seen[galEl] = true
+
+		length := binary.BigEndian.Uint64(data[ptr+8 : ptr+16])
+		ptr += 16
+
+		if uint64(len(data)-ptr) < length {
+			return errors.New("Unsufficient data length")
+		}
+
+		if err := rtgShare.CheckEncoding(data[ptr : ptr+int(length)]); err != nil {
+			return err
+		}
+		ptr += int(length)
+	}
+
+	if len(data) != ptr {
+		return errors.New("invalid length")
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package drlwe
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Runner executes the rounds of the multiparty protocols for a party over a Transport, in a star topology:
+// in each round, the parties send their share to the leader, which aggregates them and sends the aggregated
+// share back to all the parties. All the parties of a protocol must execute the same sequence of rounds.
+type Runner struct {
+	id        PartyID
+	parties   []PartyID
+	leader    PartyID
+	transport Transport
+}
+
+// NewRunner creates a new Runner for the party id among the given parties, with the party leader aggregating
+// the shares, communicating over transport.
+func NewRunner(id PartyID, parties []PartyID, leader PartyID, transport Transport) *Runner {
+
+	var hasID, hasLeader bool
+	for _, p := range parties {
+		hasID = hasID || p == id
+		hasLeader = hasLeader || p == leader
+	}
+
+	if !hasID || !hasLeader {
+		panic("cannot NewRunner: id and leader must be parties")
+	}
+
+	return &Runner{id: id, parties: parties, leader: leader, transport: transport}
+}
+
+// ID returns the ID of the party of the runner.
+func (r *Runner) ID() PartyID {
+	return r.id
+}
+
+// IsLeader returns true if the party of the runner is the leader.
+func (r *Runner) IsLeader() bool {
+	return r.id == r.leader
+}
+
+// RunRound executes a round of a protocol identified by step, with the share of the party.
+// The aggregated share is written on aggregated, which must be a freshly allocated (zero) share.
+// decode decodes the received shares, and must return an error if an encoding is not the one of a share with the
+// dimensions of the protocol (see CKGShare.CheckEncoding), and aggregate aggregates them.
+// A received share that cannot be decoded makes the round fail with an error. The shares are not otherwise
+// verified: see VerifiableAggregator to attribute malformed shares to their parties.
+func (r *Runner) RunRound(step string, share, aggregated Share, decode ShareDecoder, aggregate AggregateFunc) (err error) {
+
+	if r.IsLeader() {
+
+		agg := NewAggregator(r.parties, aggregated, aggregate)
+
+		if _, err = agg.Add(r.id, share); err != nil {
+			return err
+		}
+
+		for !agg.Complete() {
+
+			var from PartyID
+			var payload []byte
+			if from, payload, err = r.receive(step); err != nil {
+				return err
+			}
+
+			var received Share
+			if received, err = decode(payload); err != nil {
+				return fmt.Errorf("cannot RunRound: malformed share from %q: %v", from, err)
+			}
+
+			if _, err = agg.Add(from, received); err != nil {
+				return err
+			}
+		}
+
+		var data []byte
+		if data, err = aggregated.MarshalBinary(); err != nil {
+			return err
+		}
+
+		for _, id := range r.parties {
+			if id != r.id {
+				if err = r.transport.Send(id, encodeStep(step, data)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	var data []byte
+	if data, err = share.MarshalBinary(); err != nil {
+		return err
+	}
+
+	if err = r.transport.Send(r.leader, encodeStep(step, data)); err != nil {
+		return err
+	}
+
+	var from PartyID
+	if from, data, err = r.receive(step); err != nil {
+		return err
+	}
+
+	if from != r.leader {
+		return fmt.Errorf("cannot RunRound: unexpected message from %q", from)
+	}
+
+	var received Share
+	if received, err = decode(data); err != nil {
+		return fmt.Errorf("cannot RunRound: malformed aggregated share: %v", err)
+	}
+
+	aggregate(aggregated, received, aggregated)
+
+	return nil
+}
+
+func (r *Runner) receive(step string) (from PartyID, payload []byte, err error) {
+
+	var msg []byte
+	if from, msg, err = r.transport.Receive(); err != nil {
+		return "", nil, err
+	}
+
+	var s string
+	if s, payload, err = decodeStep(msg); err != nil {
+		return "", nil, err
+	}
+
+	if s != step {
+		return "", nil, fmt.Errorf("cannot RunRound: received a message for step %q from %q while running step %q", s, from, step)
+	}
+
+	return
+}
+
+func encodeStep(step string, data []byte) []byte {
+	msg := make([]byte, 1+len(step), 1+len(step)+len(data))
+	msg[0] = uint8(len(step))
+	copy(msg[1:], step)
+	return append(msg, data...)
+}
+
+func decodeStep(msg []byte) (step string, data []byte, err error) {
+	if len(msg) == 0 || len(msg) < 1+int(msg[0]) {
+		return "", nil, errors.New("invalid message")
+	}
+	return string(msg[1 : 1+msg[0]]), msg[1+msg[0]:], nil
+}
+
+// RunCKG executes the collective public key generation protocol with the secret key share sk and the common
+// reference polynomial crs, and writes the collective public key on pk.
+func (r *Runner) RunCKG(ckg CollectivePublicKeyGenerator, sk *rlwe.SecretKey, crs *ring.Poly, pk *rlwe.PublicKey) (err error) {
+
+	share, aggregated := ckg.AllocateShares(), ckg.AllocateShares()
+	ckg.GenShare(sk, crs, share)
+
+	if err = r.RunRound("CKG", share, aggregated,
+		func(data []byte) (Share, error) {
+			if err := share.CheckEncoding(data); err != nil {
+				return nil, err
+			}
+			received := new(CKGShare)
+			return received, received.UnmarshalBinary(data)
+		},
+		func(share1, share2, shareOut Share) {
+			ckg.AggregateShares(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare))
+		}); err != nil {
+		return err
+	}
+
+	ckg.GenPublicKey(aggregated, crs, pk)
+
+	return nil
+}
+
+// RunRKG executes the two rounds of the collective relinearization key generation protocol with the secret key
+// share sk and the common reference polynomials crp, and writes the relinearization key on rlk.
+func (r *Runner) RunRKG(rkg RelinearizationKeyGenerator, sk *rlwe.SecretKey, crp []*ring.Poly, rlk *rlwe.RelinearizationKey) (err error) {
+
+	ephSk, share1, share2 := rkg.AllocateShares()
+	_, aggregated1, aggregated2 := rkg.AllocateShares()
+
+	decode := func(data []byte) (Share, error) {
+		if err := share1.CheckEncoding(data); err != nil {
+			return nil, err
+		}
+		received := new(RKGShare)
+		return received, received.UnmarshalBinary(data)
+	}
+	aggregate := func(share1, share2, shareOut Share) {
+		rkg.AggregateShares(share1.(*RKGShare), share2.(*RKGShare), shareOut.(*RKGShare))
+	}
+
+	rkg.GenShareRoundOne(sk, crp, ephSk, share1)
+
+	if err = r.RunRound("RKG-1", share1, aggregated1, decode, aggregate); err != nil {
+		return err
+	}
+
+	rkg.GenShareRoundTwo(ephSk, sk, aggregated1, crp, share2)
+
+	if err = r.RunRound("RKG-2", share2, aggregated2, decode, aggregate); err != nil {
+		return err
+	}
+
+	rkg.GenRelinearizationKey(aggregated1, aggregated2, rlk)
+
+	return nil
+}
+
+// RunRTGSet executes the collective rotation key set generation protocol with the secret key share sk and the
+// common reference polynomials crp, in a single round, and writes the rotation keys on rotKeySet.
+func (r *Runner) RunRTGSet(rtg *RTGSetProtocol, sk *rlwe.SecretKey, crp map[uint64][]*ring.Poly, rotKeySet *rlwe.RotationKeySet) (err error) {
+
+	share, aggregated := rtg.AllocateShares(), rtg.AllocateShares()
+	rtg.GenShare(sk, crp, share)
+
+	if err = r.RunRound("RTG", share, aggregated,
+		func(data []byte) (Share, error) {
+			if err := share.CheckEncoding(data); err != nil {
+				return nil, err
+			}
+			received := new(RTGSetShare)
+			return received, received.UnmarshalBinary(data)
+		},
+		func(share1, share2, shareOut Share) {
+			rtg.Aggregate(share1.(*RTGSetShare), share2.(*RTGSetShare), shareOut.(*RTGSetShare))
+		}); err != nil {
+		return err
+	}
+
+	rtg.GenRotationKeySet(aggregated, crp, rotKeySet)
+
+	return nil
+}
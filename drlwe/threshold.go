@@ -0,0 +1,200 @@
+package drlwe
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ShamirPublicPoint is a type for the public point associated with a party's identity in the t-out-of-N-threshold
+// secret-sharing scheme. The public points of the parties must be distinct and non-zero.
+type ShamirPublicPoint uint64
+
+// ShamirPolynomial represents a polynomial of degree t-1 with ring.Poly coefficients, whose constant coefficient
+// is a party's secret. It is used in the Thresholdizer protocol to privately generate the shares of a secret key.
+type ShamirPolynomial struct {
+	Coeffs []*ring.Poly
+}
+
+// ShamirSecretShare represents a t-out-of-N-threshold secret-share of a secret key.
+type ShamirSecretShare struct {
+	*ring.Poly
+}
+
+// UnmarshalBinary decodes a marshaled ShamirSecretShare on the target ShamirSecretShare.
+func (share *ShamirSecretShare) UnmarshalBinary(data []byte) error {
+	if share.Poly == nil {
+		share.Poly = new(ring.Poly)
+	}
+	return share.Poly.UnmarshalBinary(data)
+}
+
+// Thresholdizer is the structure storing the parameters for the protocol turning the N-out-of-N additive
+// secret-shares of a collective secret key into t-out-of-N Shamir secret-shares of the same key.
+//
+// Each party i samples a random ShamirPolynomial f_i such that f_i(0) = s_i, and privately sends f_i(x_j) to each
+// party j. Each party j then aggregates the received shares into its ShamirSecretShare sum_i f_i(x_j) of the collective
+// secret key s = sum_i s_i.
+type Thresholdizer struct {
+	ringQP         *ring.Ring
+	uniformSampler *ring.UniformSampler
+}
+
+// NewThresholdizer creates a new Thresholdizer instance.
+func NewThresholdizer(params rlwe.Parameters) *Thresholdizer {
+	thr := new(Thresholdizer)
+	thr.ringQP = params.RingQP()
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	thr.uniformSampler = ring.NewUniformSampler(prng, thr.ringQP)
+	return thr
+}
+
+// GenShamirPolynomial generates a new ShamirPolynomial of degree threshold-1 whose constant coefficient is the secret.
+// The output polynomial must be kept secret by the party.
+func (thr *Thresholdizer) GenShamirPolynomial(threshold int, secret *rlwe.SecretKey) (*ShamirPolynomial, error) {
+	if threshold < 1 {
+		return nil, errors.New("threshold should be >= 1")
+	}
+	gen := &ShamirPolynomial{Coeffs: make([]*ring.Poly, threshold)}
+	gen.Coeffs[0] = secret.Value.CopyNew()
+	for i := 1; i < threshold; i++ {
+		gen.Coeffs[i] = thr.uniformSampler.ReadNew()
+	}
+	return gen, nil
+}
+
+// AllocateThresholdSecretShare allocates a ShamirSecretShare struct.
+func (thr *Thresholdizer) AllocateThresholdSecretShare() *ShamirSecretShare {
+	return &ShamirSecretShare{thr.ringQP.NewPoly()}
+}
+
+// GenShamirSecretShare evaluates the secret ShamirPolynomial at the recipient's public point and writes the result in
+// shareOut. The share must be sent to the recipient over a private channel.
+func (thr *Thresholdizer) GenShamirSecretShare(recipient ShamirPublicPoint, secretPoly *ShamirPolynomial, shareOut *ShamirSecretShare) {
+	// Horner's evaluation of the polynomial at the recipient's point
+	deg := len(secretPoly.Coeffs) - 1
+	shareOut.Copy(secretPoly.Coeffs[deg])
+	for i := deg - 1; i >= 0; i-- {
+		thr.ringQP.MulScalar(shareOut.Poly, uint64(recipient), shareOut.Poly)
+		thr.ringQP.Add(shareOut.Poly, secretPoly.Coeffs[i], shareOut.Poly)
+	}
+}
+
+// AggregateShares aggregates two ShamirSecretShare and stores the result in outShare.
+func (thr *Thresholdizer) AggregateShares(share1, share2, outShare *ShamirSecretShare) {
+	thr.ringQP.Add(share1.Poly, share2.Poly, outShare.Poly)
+}
+
+// Combiner is the structure storing the parameters for the local operation that turns the ShamirSecretShare of an
+// active party into an additive share of the collective secret key among a set of at least threshold active parties.
+// The resulting additive share can be used as the secret-key input of the N-out-of-N protocols (e.g., key-switching and
+// public-key-switching), where the active parties take the role of the N parties.
+type Combiner struct {
+	ringQP    *ring.Ring
+	modulusQP *big.Int
+	threshold int
+	tmp       *big.Int
+	lagrange  *big.Int
+}
+
+// NewCombiner creates a new Combiner for the given threshold.
+func NewCombiner(params rlwe.Parameters, threshold int) *Combiner {
+	cmb := new(Combiner)
+	cmb.ringQP = params.RingQP()
+	cmb.modulusQP = cmb.ringQP.ModulusBigint
+	cmb.threshold = threshold
+	cmb.tmp = new(big.Int)
+	cmb.lagrange = new(big.Int)
+	return cmb
+}
+
+// GenAdditiveShare generates an additive share of the collective secret key from the party's ShamirSecretShare and the
+// public points of the active parties (including the party's own point), and writes it in skOut.
+// The procedure will panic if less than threshold parties are active, if ownPoint is not among the active points or if
+// the active points are not distinct and non-zero.
+func (cmb *Combiner) GenAdditiveShare(activePoints []ShamirPublicPoint, ownPoint ShamirPublicPoint, ownShare *ShamirSecretShare, skOut *rlwe.SecretKey) {
+
+	if len(activePoints) < cmb.threshold {
+		panic("cannot GenAdditiveShare: not enough active parties to reach the threshold")
+	}
+
+	cmb.lagrangeCoeff(activePoints, ownPoint)
+
+	cmb.ringQP.MulScalarBigint(ownShare.Poly, cmb.lagrange, skOut.Value)
+}
+
+// AdditiveShare is the additive share of the secret key of an active party, allocated on first use, which the
+// threshold entry points of the protocols of the schemes (e.g. the GenShareThreshold of the CKSProtocol of dbfv and
+// dckks) reuse across their calls.
+type AdditiveShare struct {
+	sk *rlwe.SecretKey
+}
+
+// Combine turns the ShamirSecretShare of the party at ownPoint into its additive share among the active parties at
+// activePoints with cmb (see Combiner.GenAdditiveShare) and returns it.
+func (add *AdditiveShare) Combine(cmb *Combiner, activePoints []ShamirPublicPoint, ownPoint ShamirPublicPoint, share *ShamirSecretShare) *ring.Poly {
+	if add.sk == nil {
+		add.sk = &rlwe.SecretKey{Value: cmb.ringQP.NewPoly()}
+	}
+	cmb.GenAdditiveShare(activePoints, ownPoint, share, add.sk)
+	return add.sk.Value
+}
+
+// lagrangeCoeff computes prod_{j != own} x_j / (x_j - x_own) mod QP.
+func (cmb *Combiner) lagrangeCoeff(activePoints []ShamirPublicPoint, ownPoint ShamirPublicPoint) {
+
+	if ownPoint == 0 {
+		panic("cannot GenAdditiveShare: ownPoint cannot be zero")
+	}
+
+	var found bool
+
+	cmb.lagrange.SetUint64(1)
+
+	den := new(big.Int).SetUint64(1)
+
+	for i, x := range activePoints {
+
+		if x == 0 {
+			panic("cannot GenAdditiveShare: active points cannot be zero")
+		}
+
+		for _, y := range activePoints[:i] {
+			if x == y {
+				panic("cannot GenAdditiveShare: active points must be distinct")
+			}
+		}
+
+		if x == ownPoint {
+			found = true
+			continue
+		}
+
+		cmb.lagrange.Mul(cmb.lagrange, cmb.tmp.SetUint64(uint64(x)))
+
+		cmb.tmp.SetUint64(uint64(x))
+		cmb.tmp.Sub(cmb.tmp, new(big.Int).SetUint64(uint64(ownPoint)))
+		den.Mul(den, cmb.tmp)
+	}
+
+	if !found {
+		panic("cannot GenAdditiveShare: ownPoint is not among the active points")
+	}
+
+	den.Mod(den, cmb.modulusQP)
+
+	if den.ModInverse(den, cmb.modulusQP) == nil {
+		panic("cannot GenAdditiveShare: the differences of the active points must be invertible modulo QP")
+	}
+
+	cmb.lagrange.Mul(cmb.lagrange, den)
+	cmb.lagrange.Mod(cmb.lagrange, cmb.modulusQP)
+}
@@ -0,0 +1,373 @@
+package drlwe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Transport is the interface of the communication channel of a party in a multiparty protocol.
+// A Transport must deliver the messages between two parties in the order in which they were sent.
+type Transport interface {
+	// Send sends msg to the party to.
+	Send(to PartyID, msg []byte) error
+	// Receive blocks until a message is received and returns it along with the ID of its sender.
+	Receive() (from PartyID, msg []byte, err error)
+}
+
+// ErrTransportClosed is returned by the reference transports when operated after being closed.
+var ErrTransportClosed = errors.New("transport is closed")
+
+type message struct {
+	from PartyID
+	msg  []byte
+}
+
+// inbox is an unbounded FIFO queue of received messages.
+type inbox struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	messages []message
+	closed   bool
+}
+
+func newInbox() *inbox {
+	in := new(inbox)
+	in.cond = sync.NewCond(&in.mutex)
+	return in
+}
+
+func (in *inbox) push(from PartyID, msg []byte) error {
+	in.mutex.Lock()
+	defer in.mutex.Unlock()
+
+	if in.closed {
+		return ErrTransportClosed
+	}
+
+	in.messages = append(in.messages, message{from, msg})
+	in.cond.Signal()
+	return nil
+}
+
+func (in *inbox) pop() (from PartyID, msg []byte, err error) {
+	in.mutex.Lock()
+	defer in.mutex.Unlock()
+
+	for len(in.messages) == 0 && !in.closed {
+		in.cond.Wait()
+	}
+
+	if len(in.messages) == 0 {
+		return "", nil, ErrTransportClosed
+	}
+
+	m := in.messages[0]
+	in.messages = in.messages[1:]
+	return m.from, m.msg, nil
+}
+
+func (in *inbox) close() {
+	in.mutex.Lock()
+	defer in.mutex.Unlock()
+	in.closed = true
+	in.cond.Broadcast()
+}
+
+// LocalTransport is an in-memory Transport connecting parties running in the same process.
+type LocalTransport struct {
+	id      PartyID
+	inboxes map[PartyID]*inbox
+}
+
+// NewLocalTransports creates a set of connected in-memory transports, one for each of the parties.
+func NewLocalTransports(parties []PartyID) map[PartyID]*LocalTransport {
+
+	inboxes := make(map[PartyID]*inbox, len(parties))
+	for _, id := range parties {
+		inboxes[id] = newInbox()
+	}
+
+	transports := make(map[PartyID]*LocalTransport, len(parties))
+	for _, id := range parties {
+		transports[id] = &LocalTransport{id: id, inboxes: inboxes}
+	}
+
+	return transports
+}
+
+// Send sends a copy of msg to the party to.
+func (tr *LocalTransport) Send(to PartyID, msg []byte) error {
+
+	in, ok := tr.inboxes[to]
+	if !ok {
+		return fmt.Errorf("cannot Send: unknown party ID %q", to)
+	}
+
+	return in.push(tr.id, append([]byte{}, msg...))
+}
+
+// Receive blocks until a message is received and returns it along with the ID of its sender.
+func (tr *LocalTransport) Receive() (from PartyID, msg []byte, err error) {
+	return tr.inboxes[tr.id].pop()
+}
+
+// Close closes the transport. Pending and subsequent calls to Receive return ErrTransportClosed once all the
+// messages already received have been consumed.
+func (tr *LocalTransport) Close() error {
+	tr.inboxes[tr.id].close()
+	return nil
+}
+
+// DefaultMaxMessageSize is the default maximum size in bytes of the messages received by a TCPTransport.
+const DefaultMaxMessageSize = 1 << 28
+
+// TCPTransport is a Transport over TCP connections. Each party listens on its own address and opens a single
+// connection to each of its peers when it first sends them a message. The messages are framed with their
+// length and the first frame of each connection carries the ID of the sender.
+//
+// TCPTransport is a reference implementation for trusted networks: the connections are neither authenticated
+// nor encrypted. The ID of the sender of a message is the one declared by the peer that opened the connection,
+// so any host that can reach the listening address can impersonate a party, and the shares are sent in
+// plaintext. Deployments must run it over an authenticated and encrypted channel (e.g., a VPN or mutually
+// authenticated TLS tunnels) or implement the Transport interface on top of such a channel.
+type TCPTransport struct {
+	id       PartyID
+	listener net.Listener
+	inbox    *inbox
+
+	// DialTimeout is the time during which Send retries to connect to a peer that is not yet listening.
+	DialTimeout time.Duration
+
+	// MaxMessageSize is the maximum size in bytes of a received message. A peer sending a larger message is
+	// disconnected. It must be set before the peers connect, and defaults to DefaultMaxMessageSize.
+	MaxMessageSize int
+
+	mutex sync.Mutex
+	peers map[PartyID]string
+	conns map[PartyID]*tcpConn
+	open  []net.Conn
+}
+
+type tcpConn struct {
+	sync.Mutex
+	conn   net.Conn
+	writer *bufio.Writer
+}
+
+// NewTCPTransport creates a new TCPTransport for the party id, listening on listenAddr (e.g. "127.0.0.1:0").
+// The addresses of the peers are registered with AddPeer.
+func NewTCPTransport(id PartyID, listenAddr string) (tr *TCPTransport, err error) {
+
+	tr = &TCPTransport{
+		id:             id,
+		inbox:          newInbox(),
+		DialTimeout:    10 * time.Second,
+		MaxMessageSize: DefaultMaxMessageSize,
+		peers:          make(map[PartyID]string),
+		conns:          make(map[PartyID]*tcpConn),
+	}
+
+	if tr.listener, err = net.Listen("tcp", listenAddr); err != nil {
+		return nil, err
+	}
+
+	go tr.accept()
+
+	return tr, nil
+}
+
+// Addr returns the address on which the transport is listening.
+func (tr *TCPTransport) Addr() net.Addr {
+	return tr.listener.Addr()
+}
+
+// AddPeer registers the address of the party id.
+func (tr *TCPTransport) AddPeer(id PartyID, addr string) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	tr.peers[id] = addr
+}
+
+// Send sends msg to the party to, connecting to it if needed.
+func (tr *TCPTransport) Send(to PartyID, msg []byte) (err error) {
+
+	var c *tcpConn
+	if c, err = tr.getConn(to); err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if err = writeFrame(c.writer, msg); err != nil {
+		return err
+	}
+
+	return c.writer.Flush()
+}
+
+// Receive blocks until a message is received and returns it along with the ID of its sender.
+func (tr *TCPTransport) Receive() (from PartyID, msg []byte, err error) {
+	return tr.inbox.pop()
+}
+
+// Close stops listening and closes all the connections of the transport.
+func (tr *TCPTransport) Close() (err error) {
+
+	tr.inbox.close()
+
+	err = tr.listener.Close()
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	for _, conn := range tr.open {
+		conn.Close()
+	}
+
+	return
+}
+
+func (tr *TCPTransport) getConn(to PartyID) (c *tcpConn, err error) {
+
+	tr.mutex.Lock()
+	c, ok := tr.conns[to]
+	addr, known := tr.peers[to]
+	tr.mutex.Unlock()
+
+	if ok {
+		return c, nil
+	}
+
+	if !known {
+		return nil, fmt.Errorf("cannot Send: unknown party ID %q", to)
+	}
+
+	// The peer is dialed without holding the mutex, so that a peer that is not yet listening does not block
+	// the messages sent to the other peers.
+	var conn net.Conn
+	deadline := time.Now().Add(tr.DialTimeout)
+	for {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	// Another Send to the same peer may have connected in the meantime
+	if c, ok = tr.conns[to]; ok {
+		conn.Close()
+		return c, nil
+	}
+
+	c = &tcpConn{conn: conn, writer: bufio.NewWriter(conn)}
+
+	if err = writeFrame(c.writer, []byte(tr.id)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	tr.conns[to] = c
+	tr.open = append(tr.open, conn)
+
+	return c, nil
+}
+
+func (tr *TCPTransport) accept() {
+	for {
+		conn, err := tr.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		tr.mutex.Lock()
+		tr.open = append(tr.open, conn)
+		tr.mutex.Unlock()
+
+		go tr.handle(conn)
+	}
+}
+
+func (tr *TCPTransport) handle(conn net.Conn) {
+
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	// The ID declared by the peer is not authenticated (see TCPTransport)
+	id, err := readFrame(reader, maxPartyIDSize)
+	if err != nil {
+		return
+	}
+
+	for {
+		msg, err := readFrame(reader, tr.MaxMessageSize)
+		if err != nil {
+			return
+		}
+
+		if tr.inbox.push(PartyID(id), msg) != nil {
+			return
+		}
+	}
+}
+
+func writeFrame(w io.Writer, data []byte) (err error) {
+
+	if uint64(len(data)) > 0xFFFFFFFF {
+		return errors.New("message is too long")
+	}
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+
+	if _, err = w.Write(header[:]); err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// maxPartyIDSize is the maximum size in bytes of the ID declared by a peer.
+const maxPartyIDSize = 1 << 10
+
+// readFrame reads a frame of at most maxSize bytes. The data is read as it is received instead of being
+// allocated from the length declared in the header.
+func readFrame(r io.Reader, maxSize int) (data []byte, err error) {
+
+	var header [4]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[:]))
+	if size > int64(maxSize) {
+		return nil, fmt.Errorf("frame of %d bytes exceeds the maximum size of %d bytes", size, maxSize)
+	}
+
+	var buff bytes.Buffer
+	if _, err = io.CopyN(&buff, r, size); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
@@ -0,0 +1,270 @@
+package drlwe
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// ShareDecoder decodes the marshaled share of a party. It must return an error, and not panic, if data is not the
+// encoding of a share of the expected ring (see CheckPolyEncoding).
+type ShareDecoder func(data []byte) (Share, error)
+
+// ShareValidator checks that a share is well formed, and returns an error describing the problem otherwise.
+type ShareValidator func(share Share) error
+
+// CheatingError is the error returned when the share of a party is rejected by a VerifiableAggregator. It attributes
+// the misbehavior to the party.
+type CheatingError struct {
+	Party  PartyID
+	Reason string
+}
+
+// Error returns the description of the misbehavior.
+func (e *CheatingError) Error() string {
+	return fmt.Sprintf("party %q cheated: %s", e.Party, e.Reason)
+}
+
+// VerifiableAggregator aggregates the shares of a protocol round with cheater detection. The round has two phases:
+// each party first broadcasts a commitment to its share (see CommitShare), and reveals the opening of its share only
+// once the commitments of all the parties have been recorded. The opening of each share is then verified before its
+// aggregation: a share that does not match the commitment of its party, whose encoding is malformed (e.g., empty or
+// truncated) or which fails the validation of the aggregator (see ValidatePoly) is rejected and its party is recorded
+// as a cheater, instead of silently corrupting the aggregated share or crashing the aggregator.
+//
+// The commitments are bound to a digest of the session (e.g., the common reference polynomial of a key generation or
+// the ciphertext of a key switching, see CiphertextDigest), so that a party cannot replay its share of another
+// session, and to the ID of their party, so that a party cannot copy the commitment of another party and replay its
+// opening. They prevent a party from choosing its share depending on the shares of the others or from sending
+// different shares to different parties. The verification does not prove that a share was computed with the secret
+// key of its party: a well-formed share computed with another secret is not detected.
+// The methods of a VerifiableAggregator are safe for concurrent use.
+type VerifiableAggregator struct {
+	mutex sync.Mutex
+
+	agg      *Aggregator
+	digest   [sha256.Size]byte
+	decode   ShareDecoder
+	validate ShareValidator
+
+	commitments map[PartyID]Commitment
+	cheaters    map[PartyID]bool
+}
+
+// NewVerifiableAggregator creates a new VerifiableAggregator aggregating the verified shares with agg, which must not
+// have received any share, for the session of the given digest. decode decodes the opened shares on freshly allocated
+// shares, and validate, which can be nil, checks the decoded shares.
+func NewVerifiableAggregator(agg *Aggregator, digest [sha256.Size]byte, decode ShareDecoder, validate ShareValidator) *VerifiableAggregator {
+
+	if len(agg.Received()) != 0 {
+		panic("cannot NewVerifiableAggregator: the aggregator has already received shares")
+	}
+
+	return &VerifiableAggregator{
+		agg:         agg,
+		digest:      digest,
+		decode:      decode,
+		validate:    validate,
+		commitments: make(map[PartyID]Commitment, len(agg.parties)),
+		cheaters:    make(map[PartyID]bool),
+	}
+}
+
+// NewVerifiableCKGAggregator creates a new VerifiableAggregator for the shares of the collective public key generation
+// protocol with the common reference polynomial crs, whose shares must be well-formed polynomials in the ring QP.
+func NewVerifiableCKGAggregator(ckg *CKGProtocol, parties []PartyID, crs *ring.Poly) (*VerifiableAggregator, error) {
+
+	digest, err := CiphertextDigest([]*ring.Poly{crs})
+	if err != nil {
+		return nil, err
+	}
+
+	ringQP := ckg.ringQP
+
+	return NewVerifiableAggregator(NewCKGAggregator(ckg, parties), digest,
+		func(data []byte) (Share, error) {
+			if err := CheckPolyEncoding(len(ringQP.Modulus)-1, ringQP, data); err != nil {
+				return nil, err
+			}
+			share := new(CKGShare)
+			return share, share.UnmarshalBinary(data)
+		},
+		func(share Share) error {
+			return ValidatePoly(ringQP, share.(*CKGShare).Poly)
+		}), nil
+}
+
+// CheckPolyEncoding returns an error if data is not the encoding (see ring.Poly.MarshalBinary) of a polynomial of r
+// at the given level, i.e. if its header does not record the degree of r and level+1 moduli, or if its length does
+// not match its header. It does not decode the coefficients, whose decoding cannot fail once data is checked.
+func CheckPolyEncoding(level int, r *ring.Ring, data []byte) error {
+	return checkPolyEncoding(r.N, level+1, data)
+}
+
+// checkPolyEncoding returns an error if data is not the encoding of a polynomial of degree N with the given number of
+// moduli.
+func checkPolyEncoding(N, moduli int, data []byte) error {
+
+	if len(data) < 2 {
+		return errors.New("truncated encoding")
+	}
+
+	if 1<<data[0] != N {
+		return fmt.Errorf("invalid degree: 2^%d instead of %d", data[0], N)
+	}
+
+	if int(data[1]) != moduli {
+		return fmt.Errorf("invalid number of moduli: %d instead of %d", data[1], moduli)
+	}
+
+	if len(data) != 2+(N*moduli)<<3 {
+		return fmt.Errorf("invalid length: %d bytes instead of %d", len(data), 2+(N*moduli)<<3)
+	}
+
+	return nil
+}
+
+// checkPolyEncodingLike returns an error if data is not the encoding of a polynomial with the dimensions of pol.
+func checkPolyEncodingLike(pol *ring.Poly, data []byte) error {
+	return checkPolyEncoding(pol.Degree(), pol.LenModuli(), data)
+}
+
+// ValidatePoly returns an error if pol is not a well-formed polynomial of r, i.e. if it does not have one vector of N
+// coefficients per modulus of r, or if one of its coefficients is not reduced modulo its modulus.
+func ValidatePoly(r *ring.Ring, pol *ring.Poly) error {
+	return ValidatePolyLvl(len(r.Modulus)-1, r, pol)
+}
+
+// ValidatePolyLvl returns an error if pol is not a well-formed polynomial of r at the given level, i.e. if it does
+// not have one vector of N coefficients per modulus of r up to the level, or if one of its coefficients is not
+// reduced modulo its modulus.
+func ValidatePolyLvl(level int, r *ring.Ring, pol *ring.Poly) error {
+
+	if pol == nil {
+		return errors.New("nil polynomial")
+	}
+
+	if len(pol.Coeffs) != level+1 {
+		return fmt.Errorf("invalid number of moduli: %d instead of %d", len(pol.Coeffs), level+1)
+	}
+
+	for i, qi := range r.Modulus[:level+1] {
+
+		if len(pol.Coeffs[i]) != r.N {
+			return fmt.Errorf("invalid number of coefficients: %d instead of %d", len(pol.Coeffs[i]), r.N)
+		}
+
+		for _, c := range pol.Coeffs[i] {
+			if c >= qi {
+				return fmt.Errorf("coefficient %d is not reduced modulo the %d-th modulus", c, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// AddCommitment records the commitment of the party id. Returns an error if id is not one of the parties of the
+// aggregator or if its commitment was already recorded.
+func (v *VerifiableAggregator) AddCommitment(id PartyID, commitment Commitment) error {
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if !v.agg.parties[id] {
+		return fmt.Errorf("cannot AddCommitment: unknown party ID %q", id)
+	}
+
+	if _, ok := v.commitments[id]; ok {
+		return fmt.Errorf("cannot AddCommitment: the commitment of party %q was already recorded", id)
+	}
+
+	v.commitments[id] = commitment
+
+	return nil
+}
+
+// CommitmentsComplete returns true if the commitments of all the parties were recorded, i.e. if the parties can
+// reveal their openings.
+func (v *VerifiableAggregator) CommitmentsComplete() bool {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return len(v.commitments) == len(v.agg.parties)
+}
+
+// AddOpening verifies the opening of the share of the party id and aggregates the share. Returns false if the share
+// of this party was already aggregated, in which case the opening is ignored, and an error if the commitments of all
+// the parties were not yet recorded or if id is not one of the parties. If the share is rejected, the party is
+// recorded as a cheater and the returned error is a *CheatingError.
+func (v *VerifiableAggregator) AddOpening(id PartyID, opening *ShareOpening) (added bool, err error) {
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if !v.agg.parties[id] {
+		return false, fmt.Errorf("cannot AddOpening: unknown party ID %q", id)
+	}
+
+	if len(v.commitments) != len(v.agg.parties) {
+		return false, errors.New("cannot AddOpening: the commitments of all the parties must be recorded first")
+	}
+
+	if v.cheaters[id] {
+		return false, &CheatingError{Party: id, Reason: "the party was already identified as a cheater"}
+	}
+
+	if opening == nil || !opening.Verify(v.digest, id, v.commitments[id]) {
+		return false, v.cheat(id, "the opening does not match the commitment")
+	}
+
+	share, err := v.decode(opening.Share)
+	if err != nil {
+		return false, v.cheat(id, fmt.Sprintf("malformed share: %v", err))
+	}
+
+	if v.validate != nil {
+		if err = v.validate(share); err != nil {
+			return false, v.cheat(id, fmt.Sprintf("malformed share: %v", err))
+		}
+	}
+
+	return v.agg.Add(id, share)
+}
+
+func (v *VerifiableAggregator) cheat(id PartyID, reason string) error {
+	v.cheaters[id] = true
+	return &CheatingError{Party: id, Reason: reason}
+}
+
+// Cheaters returns the sorted IDs of the parties whose share was rejected.
+func (v *VerifiableAggregator) Cheaters() []PartyID {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return sortedIDs(v.cheaters, true)
+}
+
+// Missing returns the sorted IDs of the honest parties whose share was not yet aggregated.
+func (v *VerifiableAggregator) Missing() (missing []PartyID) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	missing = []PartyID{}
+	for _, id := range v.agg.Missing() {
+		if !v.cheaters[id] {
+			missing = append(missing, id)
+		}
+	}
+	return
+}
+
+// Complete returns true if the shares of all the parties were aggregated, i.e. if no party cheated.
+func (v *VerifiableAggregator) Complete() bool {
+	return v.agg.Complete()
+}
+
+// Aggregated returns the current aggregation of the verified shares.
+func (v *VerifiableAggregator) Aggregated() Share {
+	return v.agg.Aggregated()
+}
@@ -0,0 +1,23 @@
+// Package prngtest provides the TestMain shared by the test suites of the library, which adds the -prng-seed flag
+// enabling the deterministic mode of the source of randomness (see package randsource).
+package prngtest
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/internal/randsource"
+)
+
+var flagPRNGSeed = flag.String("prng-seed", "", "run in the insecure deterministic mode, with all the samplers seeded from this string, for reproducible tests and benchmarks.")
+
+// Main parses the flags, enables the deterministic mode if the -prng-seed flag is set, and runs the tests.
+// It is meant to be called by the TestMain function of a test suite.
+func Main(m *testing.M) {
+	flag.Parse()
+	if *flagPRNGSeed != "" {
+		randsource.SetSeed([]byte(*flagPRNGSeed))
+	}
+	os.Exit(m.Run())
+}
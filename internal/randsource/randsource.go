@@ -0,0 +1,61 @@
+// Package randsource implements the source of randomness of the library, which reads from crypto/rand, and its
+// insecure deterministic mode, in which the bytes are instead drawn from a XOF keyed with a seed. Being internal, the
+// deterministic mode can only be enabled by the tests and benchmarks of the library (see package prngtest).
+package randsource
+
+import (
+	"crypto/rand"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+var deterministic struct {
+	enabled int32 // read atomically, so that Read does not take the lock if the deterministic mode is disabled
+	sync.Mutex
+	xof blake2b.XOF
+}
+
+// Read reads len(b) random bytes on b from crypto/rand, or from the seeded XOF if the deterministic mode is enabled.
+func Read(b []byte) (n int, err error) {
+	if atomic.LoadInt32(&deterministic.enabled) == 0 {
+		return rand.Read(b)
+	}
+	deterministic.Lock()
+	defer deterministic.Unlock()
+	if deterministic.xof != nil {
+		return deterministic.xof.Read(b)
+	}
+	return rand.Read(b)
+}
+
+// Reader is an io.Reader reading from Read.
+type Reader struct{}
+
+// Read reads len(b) random bytes on b (see Read).
+func (Reader) Read(b []byte) (n int, err error) {
+	return Read(b)
+}
+
+// SetSeed enables the deterministic mode with the given seed, or disables it if the seed is nil.
+// WARNING: THE DETERMINISTIC MODE IS INSECURE AND MUST ONLY BE USED FOR TESTING AND BENCHMARKING!
+func SetSeed(seed []byte) {
+	deterministic.Lock()
+	defer deterministic.Unlock()
+	if seed == nil {
+		deterministic.xof = nil
+		atomic.StoreInt32(&deterministic.enabled, 0)
+		return
+	}
+	var err error
+	if deterministic.xof, err = blake2b.NewXOF(blake2b.OutputLengthUnknown, seed); err != nil {
+		panic(err)
+	}
+	atomic.StoreInt32(&deterministic.enabled, 1)
+}
+
+// IsDeterministic returns true if the deterministic mode is enabled.
+func IsDeterministic() bool {
+	return atomic.LoadInt32(&deterministic.enabled) == 1
+}
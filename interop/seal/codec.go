@@ -0,0 +1,347 @@
+package seal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Codec converts the public keys and the ciphertexts of a lattigo parameter set from and to the serialization
+// format of SEAL, for the matching SEAL EncryptionParameters.
+//
+// The ciphertexts are exported at the SEAL level of their moduli: the BFV ciphertexts at the first data level,
+// in coefficient representation, and the CKKS ciphertexts at the level of their moduli, in NTT representation.
+// The public keys are exported at the key level, in NTT representation. The objects serialized by SEAL in their
+// seeded form (e.g. the Serializable objects returned by the symmetric Encryptor or by
+// KeyGenerator::create_public_key) cannot be imported, as their expansion requires the PRNG of SEAL: the objects
+// themselves must be serialized instead.
+type Codec struct {
+	// Version is the SEAL version of the exported objects, which must be supported by the importing SEAL
+	// library. It defaults to DefaultVersion.
+	Version Version
+
+	parms      EncryptionParameters
+	rlweParams rlwe.Parameters
+	ckksParams ckks.Parameters
+
+	// permutations[i][j] is the index in the NTT representation of SEAL of the j-th value of the NTT
+	// representation of lattigo, for the i-th modulus.
+	permutations [][]int
+}
+
+// NewBFVCodec creates a new Codec for the BFV parameters, which must have at most one modulus P.
+func NewBFVCodec(params bfv.Parameters) (codec *Codec, err error) {
+	codec = &Codec{Version: DefaultVersion, rlweParams: params.Parameters}
+	if codec.parms, err = NewEncryptionParametersFromBFV(params); err != nil {
+		return nil, err
+	}
+	codec.permutations = nttPermutations(params.RingQP())
+	return codec, nil
+}
+
+// NewCKKSCodec creates a new Codec for the CKKS parameters, which must have at most one modulus P.
+func NewCKKSCodec(params ckks.Parameters) (codec *Codec, err error) {
+	codec = &Codec{Version: DefaultVersion, rlweParams: params.Parameters, ckksParams: params}
+	if codec.parms, err = NewEncryptionParametersFromCKKS(params); err != nil {
+		return nil, err
+	}
+	codec.permutations = nttPermutations(params.RingQP())
+	return codec, nil
+}
+
+// EncryptionParameters returns the SEAL EncryptionParameters matching the parameters of the Codec.
+func (codec *Codec) EncryptionParameters() EncryptionParameters {
+	return codec.parms
+}
+
+// MarshalParameters encodes the SEAL EncryptionParameters matching the parameters of the Codec.
+func (codec *Codec) MarshalParameters() []byte {
+	return codec.parms.marshalBinary(codec.Version)
+}
+
+// MarshalPublicKey encodes the public key as a SEAL PublicKey.
+func (codec *Codec) MarshalPublicKey(pk *rlwe.PublicKey) ([]byte, error) {
+	return codec.marshalCiphertext(pk.Value[:], true, 1)
+}
+
+// UnmarshalPublicKey decodes a PublicKey serialized by SEAL.
+func (codec *Codec) UnmarshalPublicKey(data []byte) (pk *rlwe.PublicKey, err error) {
+
+	var value []*ring.Poly
+	if value, _, err = codec.unmarshalCiphertext(data, true); err != nil {
+		return nil, err
+	}
+
+	if len(value) != 2 || len(value[0].Coeffs) != codec.rlweParams.QPCount() {
+		return nil, errors.New("cannot UnmarshalPublicKey: the object is not a public key")
+	}
+
+	return &rlwe.PublicKey{Value: [2]*ring.Poly{value[0], value[1]}}, nil
+}
+
+// MarshalBFVCiphertext encodes the BFV ciphertext as a SEAL Ciphertext.
+func (codec *Codec) MarshalBFVCiphertext(ct *bfv.Ciphertext) ([]byte, error) {
+	if codec.parms.Scheme != SchemeBFV {
+		return nil, errors.New("cannot MarshalBFVCiphertext: the Codec is not a BFV Codec")
+	}
+	return codec.marshalCiphertext(ct.Value, false, 1)
+}
+
+// UnmarshalBFVCiphertext decodes a BFV Ciphertext serialized by SEAL.
+func (codec *Codec) UnmarshalBFVCiphertext(data []byte) (ct *bfv.Ciphertext, err error) {
+
+	if codec.parms.Scheme != SchemeBFV {
+		return nil, errors.New("cannot UnmarshalBFVCiphertext: the Codec is not a BFV Codec")
+	}
+
+	var value []*ring.Poly
+	if value, _, err = codec.unmarshalCiphertext(data, false); err != nil {
+		return nil, err
+	}
+
+	if len(value[0].Coeffs) != codec.rlweParams.QCount() {
+		return nil, errors.New("cannot UnmarshalBFVCiphertext: the ciphertext is not at the first data level")
+	}
+
+	return &bfv.Ciphertext{Ciphertext: &rlwe.Ciphertext{Element: &rlwe.Element{Value: value}, MetaData: rlwe.MetaData{Scheme: rlwe.SchemeBFV}}}, nil
+}
+
+// MarshalCKKSCiphertext encodes the CKKS ciphertext as a SEAL Ciphertext.
+func (codec *Codec) MarshalCKKSCiphertext(ct *ckks.Ciphertext) ([]byte, error) {
+
+	if codec.parms.Scheme != SchemeCKKS {
+		return nil, errors.New("cannot MarshalCKKSCiphertext: the Codec is not a CKKS Codec")
+	}
+
+	if !ct.IsNTT() {
+		return nil, errors.New("cannot MarshalCKKSCiphertext: the ciphertext is not in the NTT domain")
+	}
+
+	return codec.marshalCiphertext(ct.Value, true, ct.Scale())
+}
+
+// UnmarshalCKKSCiphertext decodes a CKKS Ciphertext serialized by SEAL.
+func (codec *Codec) UnmarshalCKKSCiphertext(data []byte) (ct *ckks.Ciphertext, err error) {
+
+	if codec.parms.Scheme != SchemeCKKS {
+		return nil, errors.New("cannot UnmarshalCKKSCiphertext: the Codec is not a CKKS Codec")
+	}
+
+	var value []*ring.Poly
+	var scale float64
+	if value, scale, err = codec.unmarshalCiphertext(data, true); err != nil {
+		return nil, err
+	}
+
+	if len(value[0].Coeffs) > codec.rlweParams.QCount() {
+		return nil, errors.New("cannot UnmarshalCKKSCiphertext: the ciphertext is at the key level")
+	}
+
+	ct = ckks.NewCiphertext(codec.ckksParams, len(value)-1, len(value[0].Coeffs)-1, scale)
+	ct.Value = value
+
+	return ct, nil
+}
+
+// marshalCiphertext encodes the polynomials of a SEAL Ciphertext. The layout is the one of Ciphertext::save:
+// parms_id, NTT flag, size, ring degree, number of moduli, scale, correction factor (from SEAL 4.0 on) and the
+// coefficients in a DynArray, polynomial by polynomial and modulus by modulus.
+func (codec *Codec) marshalCiphertext(value []*ring.Poly, isNTT bool, scale float64) ([]byte, error) {
+
+	N := codec.rlweParams.N()
+	moduliCount := len(value[0].Coeffs)
+
+	for _, pol := range value {
+		if len(pol.Coeffs) != moduliCount {
+			return nil, errors.New("cannot marshal: the polynomials have different levels")
+		}
+	}
+
+	parmsID := codec.parms.parmsIDForModuli(codec.parms.CoeffModulus[:moduliCount])
+
+	payload := make([]byte, 0, 57+8+headerSize+8+8*len(value)*moduliCount*N)
+	payload = append(payload, parmsID[:]...)
+
+	if isNTT {
+		payload = append(payload, 1)
+	} else {
+		payload = append(payload, 0)
+	}
+
+	payload = appendUint64(payload, uint64(len(value)))
+	payload = appendUint64(payload, uint64(N))
+	payload = appendUint64(payload, uint64(moduliCount))
+	payload = appendUint64(payload, math.Float64bits(scale))
+
+	if codec.Version.Major >= 4 {
+		payload = appendUint64(payload, 1)
+	}
+
+	coeffs := make([]byte, 8+8*len(value)*moduliCount*N)
+	binary.LittleEndian.PutUint64(coeffs, uint64(len(value)*moduliCount*N))
+
+	pointer := 8
+	for _, pol := range value {
+		for i, coeffsi := range pol.Coeffs {
+			for j, c := range coeffsi {
+				k := j
+				if isNTT {
+					k = codec.permutations[i][j]
+				}
+				binary.LittleEndian.PutUint64(coeffs[pointer+8*k:], c)
+			}
+			pointer += 8 * N
+		}
+	}
+
+	payload = append(payload, marshalObject(codec.Version, coeffs)...)
+
+	return marshalObject(codec.Version, payload), nil
+}
+
+// unmarshalCiphertext decodes the polynomials and the scale of a SEAL Ciphertext, whose NTT flag must be isNTT.
+func (codec *Codec) unmarshalCiphertext(data []byte, isNTT bool) (value []*ring.Poly, scale float64, err error) {
+
+	var version Version
+	var payload []byte
+	if version, payload, _, err = unmarshalObject(data); err != nil {
+		return nil, 0, err
+	}
+
+	metadataLen := 65
+	if version.Major >= 4 {
+		metadataLen += 8
+	}
+
+	if len(payload) < metadataLen {
+		return nil, 0, errors.New("invalid SEAL Ciphertext: data is too short")
+	}
+
+	var parmsID ParmsID
+	copy(parmsID[:], payload)
+
+	if (payload[32] == 1) != isNTT {
+		return nil, 0, fmt.Errorf("invalid SEAL Ciphertext: the NTT flag must be %t", isNTT)
+	}
+
+	size := binary.LittleEndian.Uint64(payload[33:])
+	N := binary.LittleEndian.Uint64(payload[41:])
+	moduliCount := binary.LittleEndian.Uint64(payload[49:])
+	scale = math.Float64frombits(binary.LittleEndian.Uint64(payload[57:]))
+
+	if version.Major >= 4 {
+		if correctionFactor := binary.LittleEndian.Uint64(payload[65:]); correctionFactor != 1 {
+			return nil, 0, fmt.Errorf("invalid SEAL Ciphertext: unsupported correction factor %d", correctionFactor)
+		}
+	}
+
+	if N != uint64(codec.rlweParams.N()) {
+		return nil, 0, fmt.Errorf("invalid SEAL Ciphertext: ring degree %d does not match the parameters", N)
+	}
+
+	if moduliCount == 0 || moduliCount > uint64(len(codec.parms.CoeffModulus)) {
+		return nil, 0, fmt.Errorf("invalid SEAL Ciphertext: invalid number of moduli %d", moduliCount)
+	}
+
+	if size < 2 || size > 0xFF {
+		return nil, 0, fmt.Errorf("invalid SEAL Ciphertext: invalid size %d", size)
+	}
+
+	if parmsID != codec.parms.parmsIDForModuli(codec.parms.CoeffModulus[:moduliCount]) {
+		return nil, 0, errors.New("invalid SEAL Ciphertext: the parms_id does not match the parameters")
+	}
+
+	var coeffs []byte
+	if _, coeffs, _, err = unmarshalObject(payload[metadataLen:]); err != nil {
+		return nil, 0, err
+	}
+
+	count := size * moduliCount * N
+	if len(coeffs) < 8 || binary.LittleEndian.Uint64(coeffs) != count || uint64(len(coeffs)) != 8+8*count {
+		return nil, 0, errors.New("invalid SEAL Ciphertext: invalid data length (seeded objects are not supported)")
+	}
+
+	value = make([]*ring.Poly, size)
+
+	pointer := 8
+	for l := range value {
+		value[l] = ring.NewPoly(int(N), int(moduliCount))
+		for i, coeffsi := range value[l].Coeffs {
+			qi := codec.parms.CoeffModulus[i]
+			for j := range coeffsi {
+				k := j
+				if isNTT {
+					k = codec.permutations[i][j]
+				}
+				if coeffsi[j] = binary.LittleEndian.Uint64(coeffs[pointer+8*k:]); coeffsi[j] >= qi {
+					return nil, 0, errors.New("invalid SEAL Ciphertext: coefficient larger than its modulus")
+				}
+			}
+			pointer += 8 * int(N)
+		}
+	}
+
+	return value, scale, nil
+}
+
+// nttPermutations returns, for each modulus of the ring, the permutation mapping the NTT representation of
+// lattigo to the one of SEAL. Both representations are the evaluations of the polynomial at the primitive 2N-th
+// roots of unity psi^(2*bitrev(k)+1), but SEAL takes psi as the smallest primitive 2N-th root of unity. The
+// NTT of the monomial X gives the root at which each value of the representation of lattigo is evaluated.
+func nttPermutations(r *ring.Ring) (permutations [][]int) {
+
+	N := r.N
+	logN := bits.Len64(uint64(N)) - 1
+
+	X := r.NewPoly()
+	for i := range r.Modulus {
+		X.Coeffs[i][1] = 1
+	}
+	r.NTT(X, X)
+
+	permutations = make([][]int, len(r.Modulus))
+	for i, qi := range r.Modulus {
+
+		roots := X.Coeffs[i]
+
+		psi := roots[0]
+		for _, root := range roots {
+			if root < psi {
+				psi = root
+			}
+		}
+
+		// index of the root psi^(2*bitrev(k)+1) in the representation of SEAL
+		psi2 := mulMod(psi, psi, qi)
+		index := make(map[uint64]int, N)
+		for e, power := 0, psi; e < N; e, power = e+1, mulMod(power, psi2, qi) {
+			index[power] = int(utils.BitReverse64(uint64(e), uint64(logN)))
+		}
+
+		permutations[i] = make([]int, N)
+		for j, root := range roots {
+			permutations[i][j] = index[root]
+		}
+	}
+
+	return
+}
+
+func mulMod(a, b, q uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, q)
+}
+
+func appendUint64(data []byte, x uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	return append(data, buf[:]...)
+}
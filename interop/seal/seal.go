@@ -0,0 +1,298 @@
+// Package seal implements the import and export of encryption parameters, public keys and ciphertexts in the
+// serialization format of Microsoft SEAL (versions 3.4 to 4.x), so that encrypted data can be exchanged between
+// lattigo and SEAL for matching parameter sets.
+//
+// A SEAL parameter set matches a lattigo parameter set if it has the same ring degree and if its coefficient
+// modulus is the concatenation of the moduli Q and of the single special modulus P of the lattigo parameters.
+// The ring elements are exchanged exactly, the NTT representations of the two libraries being converted into each
+// other. The plaintext encodings (batching and slot orders) of the two libraries differ, so that the exchanged
+// ciphertexts must encrypt messages encoded consistently on both sides.
+package seal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	headerMagic = 0xA15E
+	headerSize  = 0x10
+)
+
+// SchemeType is the scheme of a SEAL EncryptionParameters.
+type SchemeType uint8
+
+const (
+	// SchemeNone is the scheme of uninitialized parameters.
+	SchemeNone = SchemeType(0)
+	// SchemeBFV is the BFV scheme.
+	SchemeBFV = SchemeType(1)
+	// SchemeCKKS is the CKKS scheme.
+	SchemeCKKS = SchemeType(2)
+)
+
+// ComprMode is the compression mode of a serialized SEAL object.
+type ComprMode uint8
+
+const (
+	// ComprModeNone is the mode of the uncompressed objects.
+	ComprModeNone = ComprMode(0)
+	// ComprModeZLIB is the mode of the objects compressed with zlib, which are supported on import.
+	ComprModeZLIB = ComprMode(1)
+	// ComprModeZSTD is the mode of the objects compressed with Zstandard, which are not supported.
+	ComprModeZSTD = ComprMode(2)
+)
+
+// Version is the SEAL version written in the header of the serialized objects, which determines their layout.
+type Version struct {
+	Major, Minor uint8
+}
+
+// DefaultVersion is the version of the objects exported by a Codec by default.
+var DefaultVersion = Version{Major: 4, Minor: 0}
+
+func (v Version) supported() bool {
+	return (v.Major == 3 && v.Minor >= 4) || v.Major == 4
+}
+
+// ParmsID is the identifier of a SEAL EncryptionParameters, i.e. the BLAKE2b-256 hash of its scheme, ring degree,
+// coefficient modulus and plaintext modulus. SEAL identifies each level of its modulus chain by the ParmsID of
+// the parameters restricted to the moduli of the level.
+type ParmsID [32]byte
+
+// EncryptionParameters is a SEAL EncryptionParameters.
+type EncryptionParameters struct {
+	Scheme            SchemeType
+	PolyModulusDegree uint64
+	CoeffModulus      []uint64
+	PlainModulus      uint64
+}
+
+// NewEncryptionParametersFromBFV returns the SEAL EncryptionParameters matching the BFV parameters, which must
+// have at most one modulus P.
+func NewEncryptionParametersFromBFV(params bfv.Parameters) (EncryptionParameters, error) {
+	if params.PCount() > 1 {
+		return EncryptionParameters{}, errors.New("cannot NewEncryptionParametersFromBFV: SEAL supports a single special modulus P")
+	}
+	return EncryptionParameters{Scheme: SchemeBFV, PolyModulusDegree: uint64(params.N()), CoeffModulus: params.QP(), PlainModulus: params.T()}, nil
+}
+
+// NewEncryptionParametersFromCKKS returns the SEAL EncryptionParameters matching the CKKS parameters, which must
+// have at most one modulus P.
+func NewEncryptionParametersFromCKKS(params ckks.Parameters) (EncryptionParameters, error) {
+	if params.PCount() > 1 {
+		return EncryptionParameters{}, errors.New("cannot NewEncryptionParametersFromCKKS: SEAL supports a single special modulus P")
+	}
+	return EncryptionParameters{Scheme: SchemeCKKS, PolyModulusDegree: uint64(params.N()), CoeffModulus: params.QP()}, nil
+}
+
+// BFVParameters returns the lattigo BFV parameters matching the SEAL EncryptionParameters, with the default
+// standard deviation of the error.
+func (p EncryptionParameters) BFVParameters() (bfv.Parameters, error) {
+	if p.Scheme != SchemeBFV {
+		return bfv.Parameters{}, errors.New("cannot BFVParameters: the scheme is not BFV")
+	}
+	rlweParams, err := p.rlweParameters()
+	if err != nil {
+		return bfv.Parameters{}, err
+	}
+	return bfv.NewParameters(rlweParams, p.PlainModulus)
+}
+
+// CKKSParameters returns the lattigo CKKS parameters matching the SEAL EncryptionParameters, with the default
+// standard deviation of the error and the given number of slots and default scale, which are not part of the
+// SEAL parameters.
+func (p EncryptionParameters) CKKSParameters(logSlots int, scale float64) (ckks.Parameters, error) {
+	if p.Scheme != SchemeCKKS {
+		return ckks.Parameters{}, errors.New("cannot CKKSParameters: the scheme is not CKKS")
+	}
+	rlweParams, err := p.rlweParameters()
+	if err != nil {
+		return ckks.Parameters{}, err
+	}
+	return ckks.NewParameters(rlweParams, logSlots, scale)
+}
+
+func (p EncryptionParameters) rlweParameters() (rlwe.Parameters, error) {
+
+	if p.PolyModulusDegree == 0 || p.PolyModulusDegree&(p.PolyModulusDegree-1) != 0 {
+		return rlwe.Parameters{}, fmt.Errorf("invalid poly modulus degree %d", p.PolyModulusDegree)
+	}
+
+	logN := bits.Len64(p.PolyModulusDegree) - 1
+
+	// The last modulus of the chain is the special modulus, unless it is the only one
+	Q, P := p.CoeffModulus, []uint64(nil)
+	if len(Q) > 1 {
+		Q, P = Q[:len(Q)-1], Q[len(Q)-1:]
+	}
+
+	return rlwe.NewParameters(logN, Q, P, rlwe.DefaultSigma)
+}
+
+// ParmsID returns the ParmsID of the EncryptionParameters, i.e. of the first level of the SEAL modulus chain.
+func (p EncryptionParameters) ParmsID() ParmsID {
+	return p.parmsIDForModuli(p.CoeffModulus)
+}
+
+func (p EncryptionParameters) parmsIDForModuli(moduli []uint64) (id ParmsID) {
+
+	data := make([]byte, 8*(3+len(moduli)))
+	binary.LittleEndian.PutUint64(data, uint64(p.Scheme))
+	binary.LittleEndian.PutUint64(data[8:], p.PolyModulusDegree)
+	for i, qi := range moduli {
+		binary.LittleEndian.PutUint64(data[16+8*i:], qi)
+	}
+	binary.LittleEndian.PutUint64(data[16+8*len(moduli):], p.PlainModulus)
+
+	return blake2b.Sum256(data)
+}
+
+// MarshalBinary encodes the EncryptionParameters in the serialization format of SEAL 4.0.
+func (p EncryptionParameters) MarshalBinary() ([]byte, error) {
+	return p.marshalBinary(DefaultVersion), nil
+}
+
+func (p EncryptionParameters) marshalBinary(version Version) []byte {
+
+	payload := make([]byte, 17, 17+(len(p.CoeffModulus)+1)*(headerSize+8))
+	payload[0] = uint8(p.Scheme)
+	binary.LittleEndian.PutUint64(payload[1:], p.PolyModulusDegree)
+	binary.LittleEndian.PutUint64(payload[9:], uint64(len(p.CoeffModulus)))
+
+	for _, qi := range p.CoeffModulus {
+		payload = append(payload, marshalModulus(version, qi)...)
+	}
+	payload = append(payload, marshalModulus(version, p.PlainModulus)...)
+
+	return marshalObject(version, payload)
+}
+
+// UnmarshalBinary decodes EncryptionParameters serialized by SEAL.
+func (p *EncryptionParameters) UnmarshalBinary(data []byte) (err error) {
+
+	var payload []byte
+	if _, payload, _, err = unmarshalObject(data); err != nil {
+		return err
+	}
+
+	if len(payload) < 17 {
+		return errors.New("cannot UnmarshalBinary: invalid EncryptionParameters length")
+	}
+
+	p.Scheme = SchemeType(payload[0])
+	if p.Scheme != SchemeBFV && p.Scheme != SchemeCKKS {
+		return fmt.Errorf("cannot UnmarshalBinary: unsupported scheme %d", p.Scheme)
+	}
+
+	p.PolyModulusDegree = binary.LittleEndian.Uint64(payload[1:])
+	count := binary.LittleEndian.Uint64(payload[9:])
+	payload = payload[17:]
+
+	if count > uint64(len(payload)/(headerSize+8)) {
+		return errors.New("cannot UnmarshalBinary: invalid coefficient modulus size")
+	}
+
+	p.CoeffModulus = make([]uint64, count)
+	for i := range p.CoeffModulus {
+		if p.CoeffModulus[i], payload, err = unmarshalModulus(payload); err != nil {
+			return err
+		}
+	}
+
+	if p.PlainModulus, payload, err = unmarshalModulus(payload); err != nil {
+		return err
+	}
+
+	if len(payload) != 0 {
+		return errors.New("cannot UnmarshalBinary: invalid EncryptionParameters length")
+	}
+
+	return nil
+}
+
+// marshalObject returns the payload prefixed by an uncompressed SEAL header.
+func marshalObject(version Version, payload []byte) []byte {
+	data := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint16(data, headerMagic)
+	data[2] = headerSize
+	data[3] = version.Major
+	data[4] = version.Minor
+	data[5] = uint8(ComprModeNone)
+	binary.LittleEndian.PutUint64(data[8:], uint64(len(data)))
+	copy(data[headerSize:], payload)
+	return data
+}
+
+// unmarshalObject parses the SEAL header at the beginning of data and returns the version and the decompressed
+// payload of the object, as well as the data following it.
+func unmarshalObject(data []byte) (version Version, payload, rest []byte, err error) {
+
+	if len(data) < headerSize {
+		return version, nil, nil, errors.New("invalid SEAL object: data is too short")
+	}
+
+	if binary.LittleEndian.Uint16(data) != headerMagic || data[2] != headerSize {
+		return version, nil, nil, errors.New("invalid SEAL object: invalid header")
+	}
+
+	version = Version{Major: data[3], Minor: data[4]}
+	if !version.supported() {
+		return version, nil, nil, fmt.Errorf("invalid SEAL object: unsupported version %d.%d", version.Major, version.Minor)
+	}
+
+	size := binary.LittleEndian.Uint64(data[8:])
+	if size < headerSize || size > uint64(len(data)) {
+		return version, nil, nil, errors.New("invalid SEAL object: invalid size")
+	}
+
+	payload, rest = data[headerSize:size], data[size:]
+
+	switch ComprMode(data[5]) {
+	case ComprModeNone:
+	case ComprModeZLIB:
+		var r io.ReadCloser
+		if r, err = zlib.NewReader(bytes.NewReader(payload)); err != nil {
+			return version, nil, nil, fmt.Errorf("invalid SEAL object: %v", err)
+		}
+		defer r.Close()
+		if payload, err = ioutil.ReadAll(r); err != nil {
+			return version, nil, nil, fmt.Errorf("invalid SEAL object: %v", err)
+		}
+	default:
+		return version, nil, nil, fmt.Errorf("invalid SEAL object: unsupported compression mode %d", data[5])
+	}
+
+	return version, payload, rest, nil
+}
+
+func marshalModulus(version Version, qi uint64) []byte {
+	payload := make([]byte, 8)
+	binary.LittleEndian.PutUint64(payload, qi)
+	return marshalObject(version, payload)
+}
+
+func unmarshalModulus(data []byte) (qi uint64, rest []byte, err error) {
+
+	var payload []byte
+	if _, payload, rest, err = unmarshalObject(data); err != nil {
+		return
+	}
+
+	if len(payload) != 8 {
+		return 0, nil, errors.New("invalid SEAL object: invalid Modulus length")
+	}
+
+	return binary.LittleEndian.Uint64(payload), rest, nil
+}
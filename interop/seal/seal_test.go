@@ -0,0 +1,294 @@
+package seal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func testString(opname string, parms EncryptionParameters) string {
+	return fmt.Sprintf("%sscheme=%d/N=%d/moduli=%d", opname, parms.Scheme, parms.PolyModulusDegree, len(parms.CoeffModulus))
+}
+
+func TestSEAL(t *testing.T) {
+
+	bfvParams, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ckksParams, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testBFV(bfvParams, t)
+	testCKKS(ckksParams, t)
+}
+
+func testBFV(params bfv.Parameters, t *testing.T) {
+
+	codec, err := NewBFVCodec(params)
+	require.NoError(t, err)
+
+	parms := codec.EncryptionParameters()
+
+	kgen := bfv.NewKeyGenerator(params)
+	sk, pk := kgen.GenKeyPair()
+	encoder := bfv.NewEncoder(params)
+
+	t.Run(testString("Parameters/", parms), func(t *testing.T) {
+
+		data := codec.MarshalParameters()
+		require.Equal(t, uint16(headerMagic), binary.LittleEndian.Uint16(data))
+		require.Equal(t, uint64(len(data)), binary.LittleEndian.Uint64(data[8:]))
+
+		var parmsNew EncryptionParameters
+		require.NoError(t, parmsNew.UnmarshalBinary(data))
+		require.Equal(t, parms, parmsNew)
+		require.Equal(t, parms.ParmsID(), parmsNew.ParmsID())
+
+		paramsNew, err := parmsNew.BFVParameters()
+		require.NoError(t, err)
+		require.True(t, params.Equals(paramsNew))
+
+		_, err = parmsNew.CKKSParameters(params.LogN()-1, 1<<30)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("PublicKey/", parms), func(t *testing.T) {
+
+		data, err := codec.MarshalPublicKey(pk)
+		require.NoError(t, err)
+
+		pkNew, err := codec.UnmarshalPublicKey(data)
+		require.NoError(t, err)
+		require.True(t, pk.Equals(pkNew))
+
+		// The public key is at the key level of SEAL
+		require.Equal(t, parms.ParmsID(), parmsIDOf(data))
+	})
+
+	t.Run(testString("Ciphertext/", parms), func(t *testing.T) {
+
+		values := make([]uint64, params.N())
+		for i := range values {
+			values[i] = utils.RandUint64() % params.T()
+		}
+
+		pt := bfv.NewPlaintext(params)
+		encoder.EncodeUint(values, pt)
+		ct := bfv.NewEncryptorFromPk(params, pk).EncryptNew(pt)
+
+		data, err := codec.MarshalBFVCiphertext(ct)
+		require.NoError(t, err)
+
+		// The ciphertext is at the first data level of SEAL
+		require.Equal(t, parms.parmsIDForModuli(params.Q()), parmsIDOf(data))
+
+		ctNew, err := codec.UnmarshalBFVCiphertext(data)
+		require.NoError(t, err)
+		require.Equal(t, values, encoder.DecodeUintNew(bfv.NewDecryptor(params, sk).DecryptNew(ctNew)))
+
+		_, err = codec.UnmarshalCKKSCiphertext(data)
+		require.Error(t, err)
+	})
+}
+
+func testCKKS(params ckks.Parameters, t *testing.T) {
+
+	codec, err := NewCKKSCodec(params)
+	require.NoError(t, err)
+
+	parms := codec.EncryptionParameters()
+
+	kgen := ckks.NewKeyGenerator(params)
+	sk, pk := kgen.GenKeyPair()
+	encoder := ckks.NewEncoder(params)
+	encryptor := ckks.NewEncryptorFromPk(params, pk)
+	decryptor := ckks.NewDecryptor(params, sk)
+
+	newTestCiphertext := func() (values []complex128, ct *ckks.Ciphertext) {
+		values = make([]complex128, params.Slots())
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		}
+		ct = encryptor.EncryptNew(encoder.EncodeNTTNew(values, params.LogSlots()))
+		return
+	}
+
+	t.Run(testString("Parameters/", parms), func(t *testing.T) {
+
+		var parmsNew EncryptionParameters
+		require.NoError(t, parmsNew.UnmarshalBinary(codec.MarshalParameters()))
+		require.Equal(t, parms, parmsNew)
+
+		paramsNew, err := parmsNew.CKKSParameters(params.LogSlots(), params.Scale())
+		require.NoError(t, err)
+		require.True(t, params.Equals(paramsNew))
+	})
+
+	t.Run(testString("PublicKey/", parms), func(t *testing.T) {
+
+		data, err := codec.MarshalPublicKey(pk)
+		require.NoError(t, err)
+
+		pkNew, err := codec.UnmarshalPublicKey(data)
+		require.NoError(t, err)
+		require.True(t, pk.Equals(pkNew))
+	})
+
+	for _, version := range []Version{{Major: 3, Minor: 6}, DefaultVersion} {
+
+		t.Run(testString(fmt.Sprintf("Ciphertext/Version=%d.%d/", version.Major, version.Minor), parms), func(t *testing.T) {
+
+			values, ct := newTestCiphertext()
+
+			codecVersion := *codec
+			codecVersion.Version = version
+
+			data, err := codecVersion.MarshalCKKSCiphertext(ct)
+			require.NoError(t, err)
+			require.Equal(t, []byte{version.Major, version.Minor}, data[3:5])
+
+			ctNew, err := codec.UnmarshalCKKSCiphertext(data)
+			require.NoError(t, err)
+			require.Equal(t, ct.Level(), ctNew.Level())
+			require.Equal(t, ct.Scale(), ctNew.Scale())
+			require.True(t, params.RingQ().EqualLvl(ct.Level(), ct.Value[0], ctNew.Value[0]))
+			require.True(t, params.RingQ().EqualLvl(ct.Level(), ct.Value[1], ctNew.Value[1]))
+
+			precStats := ckks.GetPrecisionStats(params, encoder, decryptor, values, ctNew, params.LogSlots(), 0)
+			require.Greater(t, real(precStats.MinPrecision), 15.0)
+		})
+	}
+
+	t.Run(testString("Ciphertext/NTT/", parms), func(t *testing.T) {
+
+		// The exported NTT representation is checked against a direct evaluation of the polynomial at the roots
+		// psi^(2*bitrev(k)+1), with psi the smallest primitive 2N-th root of unity
+		_, ct := newTestCiphertext()
+		ckks.NewEvaluator(params, rlwe.EvaluationKey{}).DropLevel(ct, ct.Level())
+
+		data, err := codec.MarshalCKKSCiphertext(ct)
+		require.NoError(t, err)
+
+		q := params.Q()[0]
+		N := params.N()
+		logN := params.LogN()
+
+		pol := ct.Value[1].CopyNew()
+		ringQ := params.RingQ()
+		ringQ.InvNTTLvl(0, pol, pol)
+
+		psi := minimalPrimitiveRoot(q, uint64(2*N))
+
+		// Offset of the coefficients of the second polynomial in the serialized data
+		offset := len(data) - 8*N
+		for _, k := range []int{0, 1, 2, N/2 + 3, N - 1} {
+			root := powMod(psi, uint64(2*utils.BitReverse64(uint64(k), uint64(logN))+1), q)
+			var eval uint64
+			for i := N - 1; i >= 0; i-- {
+				eval = (mulMod(eval, root, q) + pol.Coeffs[0][i]) % q
+			}
+			require.Equal(t, eval, binary.LittleEndian.Uint64(data[offset+8*k:]))
+		}
+	})
+
+	t.Run(testString("Ciphertext/Compressed/", parms), func(t *testing.T) {
+
+		values, ct := newTestCiphertext()
+
+		data, err := codec.MarshalCKKSCiphertext(ct)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		_, err = w.Write(data[headerSize:])
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		compressed := append(append([]byte{}, data[:headerSize]...), buf.Bytes()...)
+		compressed[5] = uint8(ComprModeZLIB)
+		binary.LittleEndian.PutUint64(compressed[8:], uint64(len(compressed)))
+
+		ctNew, err := codec.UnmarshalCKKSCiphertext(compressed)
+		require.NoError(t, err)
+
+		precStats := ckks.GetPrecisionStats(params, encoder, decryptor, values, ctNew, params.LogSlots(), 0)
+		require.Greater(t, real(precStats.MinPrecision), 15.0)
+
+		compressed[5] = uint8(ComprModeZSTD)
+		_, err = codec.UnmarshalCKKSCiphertext(compressed)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Ciphertext/Invalid/", parms), func(t *testing.T) {
+
+		_, ct := newTestCiphertext()
+
+		data, err := codec.MarshalCKKSCiphertext(ct)
+		require.NoError(t, err)
+
+		// Wrong parms_id
+		invalid := append([]byte{}, data...)
+		invalid[headerSize] ^= 1
+		_, err = codec.UnmarshalCKKSCiphertext(invalid)
+		require.Error(t, err)
+
+		// Truncated data, e.g. a seeded ciphertext
+		invalid = append([]byte{}, data[:len(data)-8*params.N()]...)
+		binary.LittleEndian.PutUint64(invalid[8:], uint64(len(invalid)))
+		_, err = codec.UnmarshalCKKSCiphertext(invalid)
+		require.Error(t, err)
+
+		// Invalid header
+		_, err = codec.UnmarshalCKKSCiphertext(data[1:])
+		require.Error(t, err)
+	})
+}
+
+func parmsIDOf(data []byte) (id ParmsID) {
+	copy(id[:], data[headerSize:])
+	return
+}
+
+func powMod(x, e, q uint64) (r uint64) {
+	r = 1
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = mulMod(r, x, q)
+		}
+		x = mulMod(x, x, q)
+	}
+	return
+}
+
+// minimalPrimitiveRoot returns the smallest primitive m-th root of unity modulo q, for m a power of two.
+func minimalPrimitiveRoot(q, m uint64) uint64 {
+
+	var psi uint64
+	for g := uint64(2); ; g++ {
+		if psi = powMod(g, (q-1)/m, q); powMod(psi, m>>1, q) == q-1 {
+			break
+		}
+	}
+
+	min := psi
+	psi2 := mulMod(psi, psi, q)
+	for i, power := uint64(0), psi; i < m>>1; i, power = i+1, mulMod(power, psi2, q) {
+		if power < min {
+			min = power
+		}
+	}
+
+	return min
+}
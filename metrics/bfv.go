@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// BFVEvaluator is a bfv.Evaluator reporting the statistics of its operations to a Collector.
+// The methods that are not measured are the ones of the wrapped Evaluator.
+type BFVEvaluator struct {
+	bfv.Evaluator
+	collector *Collector
+}
+
+// NewBFVEvaluator creates a new BFVEvaluator wrapping eval and reporting to collector.
+func NewBFVEvaluator(eval bfv.Evaluator, collector *Collector) *BFVEvaluator {
+	return &BFVEvaluator{Evaluator: eval, collector: collector}
+}
+
+// Collector returns the Collector of the evaluator.
+func (eval *BFVEvaluator) Collector() *Collector {
+	return eval.collector
+}
+
+// Add adds op0 to op1 and returns the result in ctOut.
+func (eval *BFVEvaluator) Add(op0, op1 bfv.Operand, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Add", func() { eval.Evaluator.Add(op0, op1, ctOut) })
+}
+
+// AddNew adds op0 to op1 and returns the result in a newly created element.
+func (eval *BFVEvaluator) AddNew(op0, op1 bfv.Operand) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Add", func() { ctOut = eval.Evaluator.AddNew(op0, op1) })
+	return
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut.
+func (eval *BFVEvaluator) Sub(op0, op1 bfv.Operand, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Sub", func() { eval.Evaluator.Sub(op0, op1, ctOut) })
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a newly created element.
+func (eval *BFVEvaluator) SubNew(op0, op1 bfv.Operand) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Sub", func() { ctOut = eval.Evaluator.SubNew(op0, op1) })
+	return
+}
+
+// MulScalar multiplies op by a scalar and returns the result in ctOut.
+func (eval *BFVEvaluator) MulScalar(op bfv.Operand, scalar uint64, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("MulScalar", func() { eval.Evaluator.MulScalar(op, scalar, ctOut) })
+}
+
+// MulScalarNew multiplies op by a scalar and returns the result in a newly created element.
+func (eval *BFVEvaluator) MulScalarNew(op bfv.Operand, scalar uint64) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("MulScalar", func() { ctOut = eval.Evaluator.MulScalarNew(op, scalar) })
+	return
+}
+
+// Mul multiplies op0 by op1 and returns the result in ctOut.
+func (eval *BFVEvaluator) Mul(op0 *bfv.Ciphertext, op1 bfv.Operand, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Mul", func() { eval.Evaluator.Mul(op0, op1, ctOut) })
+}
+
+// MulNew multiplies op0 by op1 and returns the result in a newly created element.
+func (eval *BFVEvaluator) MulNew(op0 *bfv.Ciphertext, op1 bfv.Operand) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Mul", func() { ctOut = eval.Evaluator.MulNew(op0, op1) })
+	return
+}
+
+// SwitchModulus switches ct0 to the next level and returns the result in ctOut.
+func (eval *BFVEvaluator) SwitchModulus(ct0 *bfv.Ciphertext, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("SwitchModulus", func() { eval.Evaluator.SwitchModulus(ct0, ctOut) })
+}
+
+// SwitchModulusNew switches ct0 to the next level and returns the result in a newly created element.
+func (eval *BFVEvaluator) SwitchModulusNew(ct0 *bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("SwitchModulus", func() { ctOut = eval.Evaluator.SwitchModulusNew(ct0) })
+	return
+}
+
+// Relinearize relinearizes ct0 and returns the result in ctOut.
+func (eval *BFVEvaluator) Relinearize(ct0 *bfv.Ciphertext, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Relinearize", func() { eval.Evaluator.Relinearize(ct0, ctOut) })
+}
+
+// RelinearizeNew relinearizes ct0 and returns the result in a newly created element.
+func (eval *BFVEvaluator) RelinearizeNew(ct0 *bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("Relinearize", func() { ctOut = eval.Evaluator.RelinearizeNew(ct0) })
+	return
+}
+
+// SwitchKeys re-encrypts ct0 under a different key and returns the result in ctOut.
+func (eval *BFVEvaluator) SwitchKeys(ct0 *bfv.Ciphertext, switchKey *rlwe.SwitchingKey, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("SwitchKeys", func() { eval.Evaluator.SwitchKeys(ct0, switchKey, ctOut) })
+}
+
+// SwitchKeysNew re-encrypts ct0 under a different key and returns the result in a newly created element.
+func (eval *BFVEvaluator) SwitchKeysNew(ct0 *bfv.Ciphertext, switchKey *rlwe.SwitchingKey) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("SwitchKeys", func() { ctOut = eval.Evaluator.SwitchKeysNew(ct0, switchKey) })
+	return
+}
+
+// RotateColumns rotates the columns of ct0 by k positions to the left and returns the result in ctOut.
+func (eval *BFVEvaluator) RotateColumns(ct0 *bfv.Ciphertext, k int, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("RotateColumns", func() { eval.Evaluator.RotateColumns(ct0, k, ctOut) })
+}
+
+// RotateColumnsNew rotates the columns of ct0 by k positions to the left and returns the result in a newly created element.
+func (eval *BFVEvaluator) RotateColumnsNew(ct0 *bfv.Ciphertext, k int) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("RotateColumns", func() { ctOut = eval.Evaluator.RotateColumnsNew(ct0, k) })
+	return
+}
+
+// RotateRows swaps the rows of ct0 and returns the result in ctOut.
+func (eval *BFVEvaluator) RotateRows(ct0 *bfv.Ciphertext, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("RotateRows", func() { eval.Evaluator.RotateRows(ct0, ctOut) })
+}
+
+// RotateRowsNew swaps the rows of ct0 and returns the result in a newly created element.
+func (eval *BFVEvaluator) RotateRowsNew(ct0 *bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("RotateRows", func() { ctOut = eval.Evaluator.RotateRowsNew(ct0) })
+	return
+}
+
+// InnerSum sums all the slots of ct0 and returns the result in ctOut.
+func (eval *BFVEvaluator) InnerSum(ct0 *bfv.Ciphertext, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("InnerSum", func() { eval.Evaluator.InnerSum(ct0, ctOut) })
+}
+
+// InnerSumLog sums the n batches of batchSize slots of ct0 and returns the result in ctOut.
+func (eval *BFVEvaluator) InnerSumLog(ct0 *bfv.Ciphertext, batchSize, n int, ctOut *bfv.Ciphertext) {
+	eval.collector.Measure("InnerSumLog", func() { eval.Evaluator.InnerSumLog(ct0, batchSize, n, ctOut) })
+}
+
+// Expand expands ct0 into 2^logN ciphertexts (see bfv.Evaluator.Expand).
+func (eval *BFVEvaluator) Expand(ct0 *bfv.Ciphertext, logN int) (ctOut []*bfv.Ciphertext) {
+	eval.collector.Measure("Expand", func() { ctOut = eval.Evaluator.Expand(ct0, logN) })
+	return
+}
+
+// ShallowCopy creates a shallow copy of the evaluator reporting to the same Collector.
+func (eval *BFVEvaluator) ShallowCopy() bfv.Evaluator {
+	return NewBFVEvaluator(eval.Evaluator.ShallowCopy(), eval.collector)
+}
+
+// WithKey creates a shallow copy of the evaluator with the given evaluation key, reporting to the same Collector.
+func (eval *BFVEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) bfv.Evaluator {
+	return NewBFVEvaluator(eval.Evaluator.WithKey(evaluationKey), eval.collector)
+}
+
+// WithRotationKeyProvider creates a shallow copy of the evaluator fetching its rotation keys from rtkp, reporting to the same Collector.
+func (eval *BFVEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) bfv.Evaluator {
+	return NewBFVEvaluator(eval.Evaluator.WithRotationKeyProvider(rtkp), eval.collector)
+}
+
+// WithRelinearization creates a shallow copy of the evaluator with the given relinearization policy, reporting to the same Collector.
+func (eval *BFVEvaluator) WithRelinearization(auto bool) bfv.Evaluator {
+	return NewBFVEvaluator(eval.Evaluator.WithRelinearization(auto), eval.collector)
+}
@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// CKKSEvaluator is a ckks.Evaluator reporting the statistics of its operations to a Collector.
+// Composite operations (e.g. EvaluatePoly) are measured as a single operation. The methods that are
+// not measured are the ones of the wrapped Evaluator.
+type CKKSEvaluator struct {
+	ckks.Evaluator
+	collector *Collector
+}
+
+// NewCKKSEvaluator creates a new CKKSEvaluator wrapping eval and reporting to collector.
+func NewCKKSEvaluator(eval ckks.Evaluator, collector *Collector) *CKKSEvaluator {
+	return &CKKSEvaluator{Evaluator: eval, collector: collector}
+}
+
+// Collector returns the Collector of the evaluator.
+func (eval *CKKSEvaluator) Collector() *Collector {
+	return eval.collector
+}
+
+// Add adds op0 to op1 and returns the result in ctOut.
+func (eval *CKKSEvaluator) Add(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Add", func() { eval.Evaluator.Add(op0, op1, ctOut) })
+}
+
+// AddNew adds op0 to op1 and returns the result in a newly created element.
+func (eval *CKKSEvaluator) AddNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Add", func() { ctOut = eval.Evaluator.AddNew(op0, op1) })
+	return
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut.
+func (eval *CKKSEvaluator) Sub(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Sub", func() { eval.Evaluator.Sub(op0, op1, ctOut) })
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a newly created element.
+func (eval *CKKSEvaluator) SubNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Sub", func() { ctOut = eval.Evaluator.SubNew(op0, op1) })
+	return
+}
+
+// AddConst adds the input constant to ctIn and returns the result in ctOut.
+func (eval *CKKSEvaluator) AddConst(ctIn *ckks.Ciphertext, constant interface{}, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("AddConst", func() { eval.Evaluator.AddConst(ctIn, constant, ctOut) })
+}
+
+// MultByConst multiplies ctIn by the input constant and returns the result in ctOut.
+func (eval *CKKSEvaluator) MultByConst(ctIn *ckks.Ciphertext, constant interface{}, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("MultByConst", func() { eval.Evaluator.MultByConst(ctIn, constant, ctOut) })
+}
+
+// MultByConstNew multiplies ctIn by the input constant and returns the result in a newly created element.
+func (eval *CKKSEvaluator) MultByConstNew(ctIn *ckks.Ciphertext, constant interface{}) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("MultByConst", func() { ctOut = eval.Evaluator.MultByConstNew(ctIn, constant) })
+	return
+}
+
+// Mul multiplies op0 by op1 without relinearization and returns the result in ctOut.
+func (eval *CKKSEvaluator) Mul(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Mul", func() { eval.Evaluator.Mul(op0, op1, ctOut) })
+}
+
+// MulNew multiplies op0 by op1 without relinearization and returns the result in a newly created element.
+func (eval *CKKSEvaluator) MulNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Mul", func() { ctOut = eval.Evaluator.MulNew(op0, op1) })
+	return
+}
+
+// MulRelin multiplies op0 by op1, relinearizes the result and returns it in ctOut.
+func (eval *CKKSEvaluator) MulRelin(op0, op1 ckks.Operand, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("MulRelin", func() { eval.Evaluator.MulRelin(op0, op1, ctOut) })
+}
+
+// MulRelinNew multiplies op0 by op1, relinearizes the result and returns it in a newly created element.
+func (eval *CKKSEvaluator) MulRelinNew(op0, op1 ckks.Operand) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("MulRelin", func() { ctOut = eval.Evaluator.MulRelinNew(op0, op1) })
+	return
+}
+
+// Relinearize relinearizes ctIn and returns the result in ctOut.
+func (eval *CKKSEvaluator) Relinearize(ctIn *ckks.Ciphertext, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Relinearize", func() { eval.Evaluator.Relinearize(ctIn, ctOut) })
+}
+
+// RelinearizeNew relinearizes ctIn and returns the result in a newly created element.
+func (eval *CKKSEvaluator) RelinearizeNew(ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Relinearize", func() { ctOut = eval.Evaluator.RelinearizeNew(ctIn) })
+	return
+}
+
+// Rescale divides ctIn by the last moduli of the chain while its scale remains above minScale and returns the result in ctOut.
+func (eval *CKKSEvaluator) Rescale(ctIn *ckks.Ciphertext, minScale float64, ctOut *ckks.Ciphertext) (err error) {
+	eval.collector.Measure("Rescale", func() { err = eval.Evaluator.Rescale(ctIn, minScale, ctOut) })
+	return
+}
+
+// Rotate rotates the slots of ctIn by k positions to the left and returns the result in ctOut.
+func (eval *CKKSEvaluator) Rotate(ctIn *ckks.Ciphertext, k int, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Rotate", func() { eval.Evaluator.Rotate(ctIn, k, ctOut) })
+}
+
+// RotateNew rotates the slots of ctIn by k positions to the left and returns the result in a newly created element.
+func (eval *CKKSEvaluator) RotateNew(ctIn *ckks.Ciphertext, k int) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Rotate", func() { ctOut = eval.Evaluator.RotateNew(ctIn, k) })
+	return
+}
+
+// RotateHoisted rotates the slots of ctIn by each of the given rotations, sharing the decomposition of ctIn.
+func (eval *CKKSEvaluator) RotateHoisted(ctIn *ckks.Ciphertext, rotations []int) (ctOut map[int]*ckks.Ciphertext) {
+	eval.collector.Measure("RotateHoisted", func() { ctOut = eval.Evaluator.RotateHoisted(ctIn, rotations) })
+	return
+}
+
+// Conjugate conjugates the slots of ctIn and returns the result in ctOut.
+func (eval *CKKSEvaluator) Conjugate(ctIn *ckks.Ciphertext, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Conjugate", func() { eval.Evaluator.Conjugate(ctIn, ctOut) })
+}
+
+// ConjugateNew conjugates the slots of ctIn and returns the result in a newly created element.
+func (eval *CKKSEvaluator) ConjugateNew(ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Conjugate", func() { ctOut = eval.Evaluator.ConjugateNew(ctIn) })
+	return
+}
+
+// SwitchKeys re-encrypts ctIn under a different key and returns the result in ctOut.
+func (eval *CKKSEvaluator) SwitchKeys(ctIn *ckks.Ciphertext, switchingKey *rlwe.SwitchingKey, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("SwitchKeys", func() { eval.Evaluator.SwitchKeys(ctIn, switchingKey, ctOut) })
+}
+
+// SwitchKeysNew re-encrypts ctIn under a different key and returns the result in a newly created element.
+func (eval *CKKSEvaluator) SwitchKeysNew(ctIn *ckks.Ciphertext, switchingKey *rlwe.SwitchingKey) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("SwitchKeys", func() { ctOut = eval.Evaluator.SwitchKeysNew(ctIn, switchingKey) })
+	return
+}
+
+// InnerSum sums the n batches of batch slots of ctIn and returns the result in ctOut.
+func (eval *CKKSEvaluator) InnerSum(ctIn *ckks.Ciphertext, batch, n int, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("InnerSum", func() { eval.Evaluator.InnerSum(ctIn, batch, n, ctOut) })
+}
+
+// InnerSumLog sums the n batches of batch slots of ctIn with a logarithmic number of rotations and returns the result in ctOut.
+func (eval *CKKSEvaluator) InnerSumLog(ctIn *ckks.Ciphertext, batch, n int, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("InnerSumLog", func() { eval.Evaluator.InnerSumLog(ctIn, batch, n, ctOut) })
+}
+
+// Replicate replicates the first batch slots of ctIn n times and returns the result in ctOut.
+func (eval *CKKSEvaluator) Replicate(ctIn *ckks.Ciphertext, batch, n int, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Replicate", func() { eval.Evaluator.Replicate(ctIn, batch, n, ctOut) })
+}
+
+// ReplicateLog replicates the first batch slots of ctIn n times with a logarithmic number of rotations and returns the result in ctOut.
+func (eval *CKKSEvaluator) ReplicateLog(ctIn *ckks.Ciphertext, batch, n int, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("ReplicateLog", func() { eval.Evaluator.ReplicateLog(ctIn, batch, n, ctOut) })
+}
+
+// LinearTransform evaluates the linear transformations on ctIn and returns the results in newly created elements.
+func (eval *CKKSEvaluator) LinearTransform(ctIn *ckks.Ciphertext, linearTransform interface{}) (ctOut []*ckks.Ciphertext) {
+	eval.collector.Measure("LinearTransform", func() { ctOut = eval.Evaluator.LinearTransform(ctIn, linearTransform) })
+	return
+}
+
+// Power computes ctIn^degree and returns the result in ctOut.
+func (eval *CKKSEvaluator) Power(ctIn *ckks.Ciphertext, degree int, ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Power", func() { eval.Evaluator.Power(ctIn, degree, ctOut) })
+}
+
+// PowerNew computes ctIn^degree and returns the result in a newly created element.
+func (eval *CKKSEvaluator) PowerNew(ctIn *ckks.Ciphertext, degree int) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("Power", func() { ctOut = eval.Evaluator.PowerNew(ctIn, degree) })
+	return
+}
+
+// EvaluatePoly evaluates the polynomial coeffs on ctIn and returns the result in a newly created element.
+func (eval *CKKSEvaluator) EvaluatePoly(ctIn *ckks.Ciphertext, coeffs *ckks.Poly, targetScale float64) (ctOut *ckks.Ciphertext, err error) {
+	eval.collector.Measure("EvaluatePoly", func() { ctOut, err = eval.Evaluator.EvaluatePoly(ctIn, coeffs, targetScale) })
+	return
+}
+
+// EvaluateCheby evaluates the Chebyshev interpolant cheby on ctIn and returns the result in a newly created element.
+func (eval *CKKSEvaluator) EvaluateCheby(ctIn *ckks.Ciphertext, cheby *ckks.ChebyshevInterpolation, targetScale float64) (ctOut *ckks.Ciphertext, err error) {
+	eval.collector.Measure("EvaluateCheby", func() { ctOut, err = eval.Evaluator.EvaluateCheby(ctIn, cheby, targetScale) })
+	return
+}
+
+// MaskSlots zeroes the slots of ctIn for which mask is false and returns the result in ctOut.
+func (eval *CKKSEvaluator) MaskSlots(ctIn *ckks.Ciphertext, mask []bool, ctOut *ckks.Ciphertext) (err error) {
+	eval.collector.Measure("MaskSlots", func() { err = eval.Evaluator.MaskSlots(ctIn, mask, ctOut) })
+	return
+}
+
+// MaskSlotsNew zeroes the slots of ctIn for which mask is false and returns the result in a newly created element.
+func (eval *CKKSEvaluator) MaskSlotsNew(ctIn *ckks.Ciphertext, mask []bool) (ctOut *ckks.Ciphertext, err error) {
+	eval.collector.Measure("MaskSlots", func() { ctOut, err = eval.Evaluator.MaskSlotsNew(ctIn, mask) })
+	return
+}
+
+// ExtractSlotRange keeps the slots [start, end) of ctIn, rotated to start at the slot 0, and returns the result in ctOut.
+func (eval *CKKSEvaluator) ExtractSlotRange(ctIn *ckks.Ciphertext, start, end int, ctOut *ckks.Ciphertext) (err error) {
+	eval.collector.Measure("ExtractSlotRange", func() { err = eval.Evaluator.ExtractSlotRange(ctIn, start, end, ctOut) })
+	return
+}
+
+// ExtractSlotRangeNew keeps the slots [start, end) of ctIn, rotated to start at the slot 0, and returns the result in
+// a newly created element.
+func (eval *CKKSEvaluator) ExtractSlotRangeNew(ctIn *ckks.Ciphertext, start, end int) (ctOut *ckks.Ciphertext, err error) {
+	eval.collector.Measure("ExtractSlotRange", func() { ctOut, err = eval.Evaluator.ExtractSlotRangeNew(ctIn, start, end) })
+	return
+}
+
+// InverseNew computes 1/ctIn with the given number of iterations and returns the result in a newly created element.
+func (eval *CKKSEvaluator) InverseNew(ctIn *ckks.Ciphertext, steps int) (ctOut *ckks.Ciphertext) {
+	eval.collector.Measure("InverseNew", func() { ctOut = eval.Evaluator.InverseNew(ctIn, steps) })
+	return
+}
+
+// ShallowCopy creates a shallow copy of the evaluator reporting to the same Collector.
+func (eval *CKKSEvaluator) ShallowCopy() ckks.Evaluator {
+	return NewCKKSEvaluator(eval.Evaluator.ShallowCopy(), eval.collector)
+}
+
+// WithKey creates a shallow copy of the evaluator with the given evaluation key, reporting to the same Collector.
+func (eval *CKKSEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) ckks.Evaluator {
+	return NewCKKSEvaluator(eval.Evaluator.WithKey(evaluationKey), eval.collector)
+}
+
+// WithRotationKeyProvider creates a shallow copy of the evaluator fetching its rotation keys from rtkp, reporting to the same Collector.
+func (eval *CKKSEvaluator) WithRotationKeyProvider(rtkp rlwe.RotationKeyProvider) ckks.Evaluator {
+	return NewCKKSEvaluator(eval.Evaluator.WithRotationKeyProvider(rtkp), eval.collector)
+}
@@ -0,0 +1,167 @@
+// Package metrics implements an instrumentation layer for the evaluators of the bfv and ckks packages.
+// An evaluator wrapped with NewBFVEvaluator or NewCKKSEvaluator reports, for each operation, the number of
+// calls, the wall time and the number of NTTs, basis extensions and key-switchings to a Collector, whose
+// statistics can be exported as JSON or in the Prometheus text exposition format.
+//
+// The wall time is always measured. The operations counts are the ones of the ring package, which are only
+// maintained if the library is built with the lattigo_profiling build tag (see ring.ProfilingEnabled), and
+// are otherwise zero.
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// OperationStats stores the statistics collected for an operation.
+type OperationStats struct {
+	// Operation is the name of the operation.
+	Operation string
+	// Calls is the number of times the operation was evaluated.
+	Calls uint64
+	// WallTime is the total time spent evaluating the operation.
+	WallTime time.Duration
+	// OperationCounts are the NTTs, basis extensions and key-switchings performed by the operation.
+	ring.OperationCounts
+}
+
+// Collector aggregates the statistics of the operations evaluated with a set of parameters. The operation
+// counts are read from the process-wide counters of the rings of the parameters, so that the operations
+// evaluated concurrently with the same parameters, instrumented or not, are attributed to each other: the
+// counts of an operation are only exact if no other operation is evaluated at the same time.
+// The methods of a Collector are safe for concurrent use.
+type Collector struct {
+	mutex  sync.Mutex
+	params rlwe.Parameters
+	stats  map[string]*OperationStats
+}
+
+// NewCollector creates a new Collector for the operations evaluated with the parameters params.
+func NewCollector(params rlwe.Parameters) *Collector {
+	return &Collector{params: params, stats: make(map[string]*OperationStats)}
+}
+
+// Measure evaluates f and records its wall time and operation counts as a call of the operation.
+func (c *Collector) Measure(operation string, f func()) {
+
+	countsBefore := c.params.OperationCounts()
+	start := time.Now()
+
+	f()
+
+	wallTime := time.Since(start)
+	countsAfter := c.params.OperationCounts()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats, ok := c.stats[operation]
+	if !ok {
+		stats = &OperationStats{Operation: operation}
+		c.stats[operation] = stats
+	}
+
+	stats.Calls++
+	stats.WallTime += wallTime
+	stats.OperationCounts = stats.OperationCounts.Add(ring.OperationCounts{
+		NTT:            countsAfter.NTT - countsBefore.NTT,
+		InvNTT:         countsAfter.InvNTT - countsBefore.InvNTT,
+		BasisExtension: countsAfter.BasisExtension - countsBefore.BasisExtension,
+		KeySwitch:      countsAfter.KeySwitch - countsBefore.KeySwitch,
+	})
+}
+
+// Stats returns the statistics of the operations measured so far, sorted by decreasing wall time.
+func (c *Collector) Stats() (stats []OperationStats) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats = make([]OperationStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].WallTime != stats[j].WallTime {
+			return stats[i].WallTime > stats[j].WallTime
+		}
+		return stats[i].Operation < stats[j].Operation
+	})
+
+	return
+}
+
+// Total returns the sum of the statistics of all the operations measured so far.
+func (c *Collector) Total() (total OperationStats) {
+	total.Operation = "Total"
+	for _, s := range c.Stats() {
+		total.Calls += s.Calls
+		total.WallTime += s.WallTime
+		total.OperationCounts = total.OperationCounts.Add(s.OperationCounts)
+	}
+	return
+}
+
+// Reset clears the statistics of the collector.
+func (c *Collector) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stats = make(map[string]*OperationStats)
+}
+
+// report is the JSON document written by WriteJSON.
+type report struct {
+	ProfilingEnabled bool
+	Operations       []OperationStats
+	Total            OperationStats
+}
+
+// WriteJSON writes the statistics of the operations on w as a JSON document. The wall times are in nanoseconds.
+func (c *Collector) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(report{ProfilingEnabled: ring.ProfilingEnabled(), Operations: c.Stats(), Total: c.Total()})
+}
+
+// WritePrometheus writes the statistics of the operations on w in the Prometheus text exposition format,
+// as counters labeled by operation.
+func (c *Collector) WritePrometheus(w io.Writer) (err error) {
+
+	stats := c.Stats()
+
+	metrics := []struct {
+		name  string
+		help  string
+		value func(s OperationStats) string
+	}{
+		{"lattigo_operation_calls_total", "Number of evaluations of the operation.", func(s OperationStats) string { return fmt.Sprint(s.Calls) }},
+		{"lattigo_operation_seconds_total", "Wall time spent evaluating the operation.", func(s OperationStats) string { return fmt.Sprint(s.WallTime.Seconds()) }},
+		{"lattigo_operation_ntt_total", "Number of forward NTTs (per RNS modulus) performed by the operation.", func(s OperationStats) string { return fmt.Sprint(s.NTT) }},
+		{"lattigo_operation_inv_ntt_total", "Number of inverse NTTs (per RNS modulus) performed by the operation.", func(s OperationStats) string { return fmt.Sprint(s.InvNTT) }},
+		{"lattigo_operation_basis_extensions_total", "Number of RNS basis extensions performed by the operation.", func(s OperationStats) string { return fmt.Sprint(s.BasisExtension) }},
+		{"lattigo_operation_key_switches_total", "Number of key-switchings performed by the operation.", func(s OperationStats) string { return fmt.Sprint(s.KeySwitch) }},
+	}
+
+	for _, m := range metrics {
+
+		if _, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", m.name, m.help, m.name); err != nil {
+			return err
+		}
+
+		for _, s := range stats {
+			if _, err = fmt.Fprintf(w, "%s{operation=%q} %s\n", m.name, s.Operation, m.value(s)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+func statsOf(c *Collector, operation string) (stats OperationStats, ok bool) {
+	for _, s := range c.Stats() {
+		if s.Operation == operation {
+			return s, true
+		}
+	}
+	return
+}
+
+func TestMetrics(t *testing.T) {
+
+	t.Run("CKKS", func(t *testing.T) {
+
+		params, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+		require.NoError(t, err)
+
+		kgen := ckks.NewKeyGenerator(params)
+		sk := kgen.GenSecretKey()
+		rlk := kgen.GenRelinearizationKey(sk)
+		rtks := kgen.GenRotationKeysForRotations([]int{1}, false, sk)
+
+		collector := NewCollector(params.Parameters)
+		eval := NewCKKSEvaluator(ckks.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks}), collector)
+
+		// Checks that the wrapper complies to the ckks.Evaluator interface
+		var _ ckks.Evaluator = eval
+
+		ct := ckks.NewEncryptorFromSk(params, sk).EncryptNew(ckks.NewEncoder(params).EncodeNTTNew([]complex128{1, 2, 3}, params.LogSlots()))
+
+		ct = eval.MulRelinNew(ct, ct)
+		require.NoError(t, eval.Rescale(ct, params.Scale(), ct))
+		eval.Add(ct, ct, ct)
+		eval.ShallowCopy().Rotate(ct, 1, ct)
+
+		for _, op := range []string{"MulRelin", "Rescale", "Add", "Rotate"} {
+			stats, ok := statsOf(collector, op)
+			require.True(t, ok, op)
+			require.Equal(t, uint64(1), stats.Calls, op)
+			require.Greater(t, int64(stats.WallTime), int64(0), op)
+		}
+
+		total := collector.Total()
+		require.Equal(t, uint64(4), total.Calls)
+
+		if ring.ProfilingEnabled() {
+			relin, _ := statsOf(collector, "MulRelin")
+			rotate, _ := statsOf(collector, "Rotate")
+			add, _ := statsOf(collector, "Add")
+			require.Equal(t, uint64(1), relin.KeySwitch)
+			require.Equal(t, uint64(1), rotate.KeySwitch)
+			require.Greater(t, rotate.BasisExtension, uint64(0))
+			require.Greater(t, rotate.NTT+rotate.InvNTT, uint64(0))
+			require.Equal(t, ring.OperationCounts{}, add.OperationCounts)
+			require.Equal(t, uint64(2), total.KeySwitch)
+		}
+
+		collector.Reset()
+		require.Empty(t, collector.Stats())
+	})
+
+	t.Run("CKKS/Gadget", func(t *testing.T) {
+
+		params, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+		require.NoError(t, err)
+
+		kgen := ckks.NewKeyGenerator(params)
+		sk := kgen.GenSecretKey()
+		gadget := rlwe.NewHybridGadget(params.Parameters, 20)
+		galEl := params.GaloisElementForColumnRotationBy(1)
+
+		collector := NewCollector(params.Parameters)
+		eval := NewCKKSEvaluator(ckks.NewEvaluatorWithGadget(params, gadget, rlwe.EvaluationKey{
+			Rlk:  rlwe.GenRelinearizationKeyWithGadget(params.Parameters, gadget, sk, 1),
+			Rtks: rlwe.GenRotationKeysWithGadget(params.Parameters, gadget, []uint64{galEl}, sk),
+		}), collector)
+
+		ct := ckks.NewEncryptorFromSk(params, sk).EncryptNew(ckks.NewEncoder(params).EncodeNTTNew([]complex128{1, 2, 3}, params.LogSlots()))
+
+		ct = eval.MulRelinNew(ct, ct)
+		eval.Rotate(ct, 1, ct)
+		eval.RotateHoisted(ct, []int{0, 1})
+
+		if ring.ProfilingEnabled() {
+			// The key-switches of the gadget are counted as the ones of the RNS decomposition
+			for _, op := range []string{"MulRelin", "Rotate", "RotateHoisted"} {
+				stats, ok := statsOf(collector, op)
+				require.True(t, ok, op)
+				require.Equal(t, uint64(1), stats.KeySwitch, op)
+			}
+		}
+	})
+
+	t.Run("BFV", func(t *testing.T) {
+
+		params, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+		require.NoError(t, err)
+
+		kgen := bfv.NewKeyGenerator(params)
+		sk := kgen.GenSecretKey()
+		rlk := kgen.GenRelinearizationKey(sk, 1)
+
+		collector := NewCollector(params.Parameters)
+		eval := NewBFVEvaluator(bfv.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk}), collector)
+
+		// Checks that the wrapper complies to the bfv.Evaluator interface
+		var _ bfv.Evaluator = eval
+
+		pt := bfv.NewPlaintext(params)
+		bfv.NewEncoder(params).EncodeUint([]uint64{1, 2, 3}, pt)
+		ct := bfv.NewEncryptorFromSk(params, sk).EncryptNew(pt)
+
+		ct = eval.MulNew(ct, ct)
+		eval.Relinearize(ct, ct)
+		eval.Add(ct, ct, ct)
+
+		for _, op := range []string{"Mul", "Relinearize", "Add"} {
+			stats, ok := statsOf(collector, op)
+			require.True(t, ok, op)
+			require.Equal(t, uint64(1), stats.Calls, op)
+		}
+
+		if ring.ProfilingEnabled() {
+			relin, _ := statsOf(collector, "Relinearize")
+			require.Equal(t, uint64(1), relin.KeySwitch)
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+
+		params, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+		require.NoError(t, err)
+
+		collector := NewCollector(params.Parameters)
+		collector.Measure("Foo", func() {})
+		collector.Measure("Foo", func() {})
+		collector.Measure("Bar", func() {})
+
+		var buf bytes.Buffer
+		require.NoError(t, collector.WriteJSON(&buf))
+
+		var r report
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &r))
+		require.Equal(t, ring.ProfilingEnabled(), r.ProfilingEnabled)
+		require.Len(t, r.Operations, 2)
+		require.Equal(t, uint64(3), r.Total.Calls)
+
+		buf.Reset()
+		require.NoError(t, collector.WritePrometheus(&buf))
+		out := buf.String()
+		require.Contains(t, out, "# TYPE lattigo_operation_calls_total counter\n")
+		require.Contains(t, out, "lattigo_operation_calls_total{operation=\"Foo\"} 2\n")
+		require.Contains(t, out, "lattigo_operation_key_switches_total{operation=\"Bar\"} 0\n")
+		require.Equal(t, 6*(2+2), strings.Count(out, "\n"))
+	})
+}
@@ -0,0 +1,122 @@
+package planner
+
+import (
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// CostModel is the cost, on a given host, of the elementary operations on the RNS residues of a polynomial.
+type CostModel struct {
+	// NanosecondsPerButterfly is the cost of a butterfly of the NTT: the NTT of a residue of degree N costs
+	// N/2 * log2(N) butterflies.
+	NanosecondsPerButterfly float64
+	// NanosecondsPerCoefficient is the cost of a coefficient-wise modular multiplication-accumulation: a
+	// coefficient-wise operation on a residue of degree N costs N of them.
+	NanosecondsPerCoefficient float64
+}
+
+// DefaultCostModel is a cost model of a recent single x86-64 core.
+var DefaultCostModel = CostModel{NanosecondsPerButterfly: 1, NanosecondsPerCoefficient: 1.5}
+
+// MeasureCostModel returns the cost model of the host, measured on a single core with a ring of degree 2^logN
+// and a single 55-bit modulus.
+func MeasureCostModel(logN int) CostModel {
+
+	N := 1 << logN
+
+	ringQ, err := ring.NewRing(N, ring.GenerateNTTPrimes(55, 2*N, 1))
+	if err != nil {
+		panic(err)
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	sampler := ring.NewUniformSampler(prng, ringQ)
+	p0, p1 := sampler.ReadNew(), sampler.ReadNew()
+
+	iterations := utils.MaxInt(1, (1<<20)/N)
+
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		ringQ.NTT(p0, p0)
+	}
+	ntt := float64(time.Since(start).Nanoseconds()) / float64(iterations)
+
+	start = time.Now()
+	for i := 0; i < iterations; i++ {
+		ringQ.MulCoeffsMontgomeryAndAdd(p0, p1, p1)
+	}
+	coeffWise := float64(time.Since(start).Nanoseconds()) / float64(iterations)
+
+	return CostModel{
+		NanosecondsPerButterfly:   ntt / float64(N/2*logN),
+		NanosecondsPerCoefficient: coeffWise / float64(N),
+	}
+}
+
+// opCount is a number of NTTs and of coefficient-wise operations on single residues.
+type opCount struct {
+	ntt, coeffWise float64
+}
+
+func (c opCount) add(other opCount) opCount {
+	return opCount{ntt: c.ntt + other.ntt, coeffWise: c.coeffWise + other.coeffWise}
+}
+
+func (c opCount) scale(k int) opCount {
+	return opCount{ntt: c.ntt * float64(k), coeffWise: c.coeffWise * float64(k)}
+}
+
+func (m CostModel) latency(N int, c opCount) time.Duration {
+	logN := bits.Len64(uint64(N)) - 1
+	ns := c.ntt*float64(N/2*logN)*m.NanosecondsPerButterfly + c.coeffWise*float64(N)*m.NanosecondsPerCoefficient
+	return time.Duration(math.Round(ns))
+}
+
+// addition is the cost of the addition of two ciphertexts at the given level.
+func (m CostModel) addition(level int) opCount {
+	return opCount{coeffWise: float64(2 * (level + 1))}
+}
+
+// keySwitch is the cost of a key-switching of a residue polynomial at the given level, with alpha special moduli:
+// the decomposition in beta digits extended to QP (ModUp), the inner product with the key and the division by P
+// (ModDown).
+func (m CostModel) keySwitch(level, alpha int) opCount {
+	L := float64(level + 1)
+	a := float64(alpha)
+	beta := math.Ceil(L / a)
+	return opCount{
+		ntt:       L + beta*(L+a) + 2*a + 2*L,
+		coeffWise: beta*a*(L+a) + 2*beta*(L+a) + 2*a*L,
+	}
+}
+
+// multiplication is the cost of the multiplication of two ciphertexts at the given level followed by the
+// relinearization, and by the rescaling for CKKS.
+func (m CostModel) multiplication(scheme Scheme, level, alpha int) (c opCount) {
+
+	L := float64(level + 1)
+
+	if scheme == BFV {
+		// Tensoring in the extended basis Q*QMul: extension of the 4 input polynomials, NTTs and products, and
+		// scaling of the 3 output polynomials back to Q.
+		c = opCount{ntt: 14 * L, coeffWise: 7*L*L + 8*L}
+	} else {
+		// Tensoring and rescaling
+		c = opCount{ntt: 4 * L, coeffWise: 6 * L}
+	}
+
+	return c.add(m.keySwitch(level, alpha))
+}
+
+// rotation is the cost of the rotation of a ciphertext at the given level.
+func (m CostModel) rotation(level, alpha int) opCount {
+	return m.keySwitch(level, alpha).add(opCount{coeffWise: float64(2 * (level + 1))})
+}
@@ -0,0 +1,285 @@
+// Package planner implements a parameter planner, which estimates for a described workload the latency, the
+// bandwidth, the size of the keys and the security level of candidate parameter sets of the BFV and CKKS schemes,
+// and ranks them.
+//
+// The estimates are analytic: the sizes are those of the serialized objects without metadata, and the latency is
+// derived from the number of NTTs and of coefficient-wise operations of each homomorphic operation, weighted by a
+// CostModel that can be calibrated on the host (see MeasureCostModel).
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/security"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Scheme is a homomorphic encryption scheme.
+type Scheme int
+
+const (
+	// AnyScheme matches all the schemes.
+	AnyScheme Scheme = iota
+	// BFV is the BFV scheme, with the batch encoding.
+	BFV
+	// CKKS is the CKKS scheme.
+	CKKS
+)
+
+// String returns the name of the scheme.
+func (s Scheme) String() string {
+	switch s {
+	case AnyScheme:
+		return "any"
+	case BFV:
+		return "BFV"
+	case CKKS:
+		return "CKKS"
+	default:
+		return fmt.Sprintf("Scheme(%d)", int(s))
+	}
+}
+
+// Workload describes the homomorphic computation performed per request.
+type Workload struct {
+	// Scheme restricts the candidates to a scheme, AnyScheme for no restriction.
+	Scheme Scheme
+	// VectorSize is the number of values of each input vector.
+	VectorSize int
+	// Inputs is the number of input vectors uploaded per request.
+	Inputs int
+	// Outputs is the number of output vectors downloaded per request.
+	Outputs int
+	// Depth is the multiplicative depth of the computation.
+	Depth int
+	// Additions, Multiplications and Rotations are the number of operations performed per input vector.
+	Additions, Multiplications, Rotations int
+	// RotationKeys is the number of distinct rotations, i.e. of rotation keys, used by the computation.
+	RotationKeys int
+	// LatencyTarget is the maximum evaluation latency of a request, 0 for no target.
+	LatencyTarget time.Duration
+	// BandwidthBudget is the maximum number of bytes of ciphertexts exchanged per request, 0 for no budget.
+	BandwidthBudget int
+	// MinSecurity is the minimum classical security level in bits, 128 if 0.
+	MinSecurity float64
+}
+
+func (w Workload) validate() error {
+	if w.Scheme < AnyScheme || w.Scheme > CKKS {
+		return fmt.Errorf("invalid scheme: %d", w.Scheme)
+	}
+	if w.VectorSize <= 0 {
+		return errors.New("the vector size must be positive")
+	}
+	if w.Inputs < 0 || w.Outputs < 0 || w.Depth < 0 || w.Additions < 0 || w.Multiplications < 0 || w.Rotations < 0 || w.RotationKeys < 0 {
+		return errors.New("the number of vectors, the depth and the number of operations cannot be negative")
+	}
+	if w.LatencyTarget < 0 || w.BandwidthBudget < 0 || w.MinSecurity < 0 {
+		return errors.New("the latency target, the bandwidth budget and the minimum security cannot be negative")
+	}
+	return nil
+}
+
+// Candidate is a parameter set considered by the planner.
+type Candidate struct {
+	Name       string
+	Scheme     Scheme
+	Parameters rlwe.Parameters
+	// Slots is the number of values packed in a ciphertext.
+	Slots int
+	// MaxDepth is the multiplicative depth supported by the parameters: the number of levels for CKKS, and an
+	// estimate from the noise growth for BFV.
+	MaxDepth int
+}
+
+// NewBFVCandidate returns the Candidate of the BFV parameters.
+func NewBFVCandidate(name string, params bfv.Parameters) Candidate {
+
+	// Heuristic noise growth: a fresh ciphertext has a noise of about logN/2+5 bits and each multiplication
+	// followed by a relinearization adds about logT+logN bits, while the noise must remain below Q/(2T).
+	logT := math.Log2(float64(params.T()))
+	logQ := float64(params.QBigInt().BitLen())
+	budget := logQ - logT - 1 - (float64(params.LogN())/2 + 5)
+
+	maxDepth := -1
+	if budget >= 0 {
+		maxDepth = int(budget / (logT + float64(params.LogN())))
+	}
+
+	return Candidate{Name: name, Scheme: BFV, Parameters: params.Parameters, Slots: params.N(), MaxDepth: maxDepth}
+}
+
+// NewCKKSCandidate returns the Candidate of the CKKS parameters.
+func NewCKKSCandidate(name string, params ckks.Parameters) Candidate {
+	return Candidate{Name: name, Scheme: CKKS, Parameters: params.Parameters, Slots: params.Slots(), MaxDepth: params.MaxLevel()}
+}
+
+// DefaultCandidates returns the candidates of the default BFV and CKKS parameters, classical and post-quantum.
+func DefaultCandidates() (candidates []Candidate) {
+
+	bfvNames := []string{"PN12QP109", "PN13QP218", "PN14QP438", "PN15QP880", "PN12QP101pq", "PN13QP202pq", "PN14QP411pq", "PN15QP827pq"}
+	for i, pl := range append(bfv.DefaultParams[:len(bfv.DefaultParams):len(bfv.DefaultParams)], bfv.DefaultPostQuantumParams...) {
+		params, err := bfv.NewParametersFromLiteral(pl)
+		if err != nil {
+			panic(err) // Default parameters are valid
+		}
+		candidates = append(candidates, NewBFVCandidate(bfvNames[i], params))
+	}
+
+	ckksNames := []string{"PN12QP109", "PN13QP218", "PN14QP438", "PN15QP880", "PN16QP1761", "PN12QP101pq", "PN13QP202pq", "PN14QP411pq", "PN15QP827pq", "PN16QP1654pq"}
+	for i, pl := range append(ckks.DefaultParams[:len(ckks.DefaultParams):len(ckks.DefaultParams)], ckks.DefaultPostQuantumParams...) {
+		params, err := ckks.NewParametersFromLiteral(pl)
+		if err != nil {
+			panic(err) // Default parameters are valid
+		}
+		candidates = append(candidates, NewCKKSCandidate(ckksNames[i], params))
+	}
+
+	return
+}
+
+// Configuration is the evaluation of a Candidate for a Workload.
+type Configuration struct {
+	Candidate
+	// Security is the estimated security level of the parameters.
+	Security security.Estimate
+	// CiphertextsPerVector is the number of ciphertexts needed to encrypt a vector of the workload.
+	CiphertextsPerVector int
+	// CiphertextSize is the size in bytes of a fresh ciphertext.
+	CiphertextSize int
+	// Bandwidth is the number of bytes of ciphertexts uploaded and downloaded per request.
+	Bandwidth int
+	// Latency is the estimated evaluation latency of a request.
+	Latency time.Duration
+	// PublicKeySize, RelinearizationKeySize and RotationKeysSize are the sizes in bytes of the keys.
+	PublicKeySize, RelinearizationKeySize, RotationKeysSize int
+	// Violations lists the constraints of the workload that are not met, empty if the configuration is feasible.
+	Violations []string
+}
+
+// Feasible returns true if the configuration meets all the constraints of the workload.
+func (c Configuration) Feasible() bool {
+	return len(c.Violations) == 0
+}
+
+// KeysSize returns the total size in bytes of the public and evaluation keys.
+func (c Configuration) KeysSize() int {
+	return c.PublicKeySize + c.RelinearizationKeySize + c.RotationKeysSize
+}
+
+// Plan evaluates the candidates matching the scheme of the workload and returns the resulting configurations,
+// ranked with the feasible ones first, then by increasing latency and by increasing size of the keys and of the
+// exchanged ciphertexts, and by decreasing security. Returns an error if the workload is invalid.
+func Plan(w Workload, candidates []Candidate, costModel CostModel) (configurations []Configuration, err error) {
+
+	if err = w.validate(); err != nil {
+		return nil, fmt.Errorf("cannot Plan: %w", err)
+	}
+
+	for _, candidate := range candidates {
+		if w.Scheme == AnyScheme || w.Scheme == candidate.Scheme {
+			configurations = append(configurations, evaluate(w, candidate, costModel))
+		}
+	}
+
+	sort.SliceStable(configurations, func(i, j int) bool {
+		ci, cj := configurations[i], configurations[j]
+		if ci.Feasible() != cj.Feasible() {
+			return ci.Feasible()
+		}
+		if ci.Latency != cj.Latency {
+			return ci.Latency < cj.Latency
+		}
+		if ci.KeysSize()+ci.Bandwidth != cj.KeysSize()+cj.Bandwidth {
+			return ci.KeysSize()+ci.Bandwidth < cj.KeysSize()+cj.Bandwidth
+		}
+		return ci.Security.Classical > cj.Security.Classical
+	})
+
+	return configurations, nil
+}
+
+func evaluate(w Workload, candidate Candidate, costModel CostModel) (c Configuration) {
+
+	params := candidate.Parameters
+
+	c.Candidate = candidate
+
+	minSecurity := w.MinSecurity
+	if minSecurity == 0 {
+		minSecurity = 128
+	}
+
+	var err error
+	if c.Security, err = params.SecurityEstimate(); err != nil {
+		c.Violations = append(c.Violations, fmt.Sprintf("security: %v", err))
+	} else if c.Security.Classical < minSecurity {
+		c.Violations = append(c.Violations, fmt.Sprintf("security: %.1f < %v bits", c.Security.Classical, minSecurity))
+	}
+
+	if w.Depth > candidate.MaxDepth {
+		c.Violations = append(c.Violations, fmt.Sprintf("depth: %d > %d", w.Depth, candidate.MaxDepth))
+	}
+
+	N, levels, alpha := params.N(), params.QCount(), params.PCount()
+
+	c.CiphertextsPerVector = (w.VectorSize + candidate.Slots - 1) / candidate.Slots
+
+	// The CKKS ciphertexts are downloaded at the level reached after the computation
+	c.CiphertextSize = ciphertextSize(N, levels)
+	outputSize := c.CiphertextSize
+	if candidate.Scheme == CKKS && w.Depth <= candidate.MaxDepth {
+		outputSize = ciphertextSize(N, levels-w.Depth)
+	}
+
+	c.Bandwidth = c.CiphertextsPerVector * (w.Inputs*c.CiphertextSize + w.Outputs*outputSize)
+
+	if w.BandwidthBudget > 0 && c.Bandwidth > w.BandwidthBudget {
+		c.Violations = append(c.Violations, fmt.Sprintf("bandwidth: %d > %d bytes", c.Bandwidth, w.BandwidthBudget))
+	}
+
+	c.PublicKeySize = 2 * N * (levels + alpha) * 8
+	if w.Multiplications > 0 {
+		c.RelinearizationKeySize = switchingKeySize(N, levels, alpha)
+	}
+	c.RotationKeysSize = w.RotationKeys * switchingKeySize(N, levels, alpha)
+
+	// The CKKS operations are estimated at the average level of the computation
+	level := levels - 1
+	if candidate.Scheme == CKKS {
+		level = utils.MaxInt(level-w.Depth/2, 0)
+	}
+
+	var cost opCount
+	cost = cost.add(costModel.addition(level).scale(w.Additions))
+	cost = cost.add(costModel.multiplication(candidate.Scheme, level, alpha).scale(w.Multiplications))
+	cost = cost.add(costModel.rotation(level, alpha).scale(w.Rotations))
+	cost = cost.scale(w.Inputs * c.CiphertextsPerVector)
+
+	c.Latency = costModel.latency(N, cost)
+
+	if w.LatencyTarget > 0 && c.Latency > w.LatencyTarget {
+		c.Violations = append(c.Violations, fmt.Sprintf("latency: %v > %v", c.Latency, w.LatencyTarget))
+	}
+
+	return
+}
+
+func ciphertextSize(N, levels int) int {
+	return 2 * N * levels * 8
+}
+
+func switchingKeySize(N, levels, alpha int) int {
+	if alpha == 0 {
+		return 0
+	}
+	beta := (levels + alpha - 1) / alpha
+	return beta * 2 * N * (levels + alpha) * 8
+}
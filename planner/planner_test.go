@@ -0,0 +1,104 @@
+package planner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/stretchr/testify/require"
+)
+
+func testString(opname string, w Workload) string {
+	return fmt.Sprintf("%sscheme=%s/size=%d/depth=%d", opname, w.Scheme, w.VectorSize, w.Depth)
+}
+
+func TestPlanner(t *testing.T) {
+
+	candidates := DefaultCandidates()
+
+	w := Workload{VectorSize: 1 << 14, Inputs: 2, Outputs: 1, Depth: 3, Additions: 4, Multiplications: 2, Rotations: 3, RotationKeys: 3}
+
+	t.Run(testString("Ranking/", w), func(t *testing.T) {
+
+		configurations, err := Plan(w, candidates, DefaultCostModel)
+		require.NoError(t, err)
+		require.Len(t, configurations, len(candidates))
+
+		for i := 1; i < len(configurations); i++ {
+			prev, c := configurations[i-1], configurations[i]
+			require.False(t, !prev.Feasible() && c.Feasible())
+			if prev.Feasible() == c.Feasible() {
+				require.LessOrEqual(t, int64(prev.Latency), int64(c.Latency))
+			}
+		}
+
+		for _, c := range configurations {
+			require.Equal(t, c.MaxDepth < w.Depth, !c.Feasible(), c.Name)
+			require.Equal(t, (w.VectorSize+c.Slots-1)/c.Slots, c.CiphertextsPerVector)
+			require.Greater(t, int64(c.Latency), int64(0))
+		}
+	})
+
+	t.Run(testString("Scheme/", w), func(t *testing.T) {
+		for _, scheme := range []Scheme{BFV, CKKS} {
+			ws := w
+			ws.Scheme = scheme
+			configurations, err := Plan(ws, candidates, DefaultCostModel)
+			require.NoError(t, err)
+			require.NotEmpty(t, configurations)
+			for _, c := range configurations {
+				require.Equal(t, scheme, c.Scheme)
+			}
+		}
+	})
+
+	t.Run(testString("Constraints/", w), func(t *testing.T) {
+
+		wc := w
+		wc.LatencyTarget = time.Nanosecond
+		wc.BandwidthBudget = 1
+		wc.MinSecurity = 256
+
+		configurations, err := Plan(wc, candidates, DefaultCostModel)
+		require.NoError(t, err)
+		for _, c := range configurations {
+			require.False(t, c.Feasible())
+			require.GreaterOrEqual(t, len(c.Violations), 3)
+		}
+
+		wc.Depth = -1
+		_, err = Plan(wc, candidates, DefaultCostModel)
+		require.Error(t, err)
+
+		_, err = Plan(Workload{}, candidates, DefaultCostModel)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("KeySizes/", w), func(t *testing.T) {
+
+		params, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+		require.NoError(t, err)
+
+		kgen := ckks.NewKeyGenerator(params)
+		sk, pk := kgen.GenKeyPair()
+		rlk := kgen.GenRelinearizationKey(sk)
+		rtks := kgen.GenRotationKeysForRotations([]int{1, 2, 3}, false, sk)
+
+		configurations, err := Plan(w, []Candidate{NewCKKSCandidate("PN12QP109", params)}, DefaultCostModel)
+		require.NoError(t, err)
+		require.Len(t, configurations, 1)
+
+		c := configurations[0]
+		require.Equal(t, pk.GetDataLen(false), c.PublicKeySize)
+		require.Equal(t, rlk.Keys[0].GetDataLen(false), c.RelinearizationKeySize)
+		require.Equal(t, 3*rtks.Keys[params.GaloisElementForColumnRotationBy(1)].GetDataLen(false), c.RotationKeysSize)
+		require.Equal(t, ckks.NewCiphertext(params, 1, params.MaxLevel(), params.Scale()).GetDataLen(false), c.CiphertextSize)
+	})
+
+	t.Run("CostModel", func(t *testing.T) {
+		costModel := MeasureCostModel(10)
+		require.Greater(t, costModel.NanosecondsPerButterfly, 0.0)
+		require.Greater(t, costModel.NanosecondsPerCoefficient, 0.0)
+	})
+}
@@ -0,0 +1,394 @@
+package proto
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/dbfv"
+	"github.com/ldsec/lattigo/v2/dckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// NewParametersFromRLWE returns the protobuf message of the given rlwe.Parameters.
+func NewParametersFromRLWE(params rlwe.Parameters) *Parameters {
+	return &Parameters{
+		LogN:  uint32(params.LogN()),
+		Q:     params.Q(),
+		P:     params.P(),
+		Sigma: params.Sigma(),
+	}
+}
+
+// NewParametersFromBFV returns the protobuf message of the given bfv.Parameters.
+func NewParametersFromBFV(params bfv.Parameters) *Parameters {
+	m := NewParametersFromRLWE(params.Parameters)
+	m.T = params.T()
+	return m
+}
+
+// NewParametersFromCKKS returns the protobuf message of the given ckks.Parameters.
+func NewParametersFromCKKS(params ckks.Parameters) *Parameters {
+	m := NewParametersFromRLWE(params.Parameters)
+	m.LogSlots = uint32(params.LogSlots())
+	m.Scale = params.Scale()
+	return m
+}
+
+// RLWE returns the rlwe.Parameters stored in the message.
+func (m *Parameters) RLWE() (rlwe.Parameters, error) {
+	return rlwe.NewParameters(int(m.LogN), m.Q, m.P, m.Sigma)
+}
+
+// BFV returns the bfv.Parameters stored in the message.
+func (m *Parameters) BFV() (bfv.Parameters, error) {
+	rlweParams, err := m.RLWE()
+	if err != nil {
+		return bfv.Parameters{}, err
+	}
+	return bfv.NewParameters(rlweParams, m.T)
+}
+
+// CKKS returns the ckks.Parameters stored in the message.
+func (m *Parameters) CKKS() (ckks.Parameters, error) {
+	rlweParams, err := m.RLWE()
+	if err != nil {
+		return ckks.Parameters{}, err
+	}
+	return ckks.NewParameters(rlweParams, int(m.LogSlots), m.Scale)
+}
+
+// NewPoly returns the protobuf message of the given ring.Poly.
+func NewPoly(pol *ring.Poly) *Poly {
+	if pol == nil {
+		return nil
+	}
+	m := &Poly{N: uint32(pol.Degree()), Coeffs: make([]uint64, 0, pol.Degree()*pol.LenModuli())}
+	for _, coeffs := range pol.Coeffs {
+		m.Coeffs = append(m.Coeffs, coeffs...)
+	}
+	return m
+}
+
+// Ring returns the ring.Poly stored in the message.
+func (m *Poly) Ring() (*ring.Poly, error) {
+	if m == nil {
+		return nil, errors.New("proto: missing polynomial")
+	}
+	if m.N == 0 || len(m.Coeffs)%int(m.N) != 0 {
+		return nil, errors.New("proto: invalid polynomial dimensions")
+	}
+	N := int(m.N)
+	pol := ring.NewPoly(N, len(m.Coeffs)/N)
+	for i := range pol.Coeffs {
+		copy(pol.Coeffs[i], m.Coeffs[i*N:(i+1)*N])
+	}
+	return pol, nil
+}
+
+// NewPolyPair returns the protobuf message of the given pair of ring.Poly.
+func NewPolyPair(pair [2]*ring.Poly) *PolyPair {
+	return &PolyPair{NewPoly(pair[0]), NewPoly(pair[1])}
+}
+
+// Ring returns the pair of ring.Poly stored in the message.
+func (m *PolyPair) Ring() (pair [2]*ring.Poly, err error) {
+	if m == nil {
+		return pair, errors.New("proto: missing polynomial pair")
+	}
+	if pair[0], err = m.P0.Ring(); err != nil {
+		return
+	}
+	pair[1], err = m.P1.Ring()
+	return
+}
+
+func newPolyPairs(pairs [][2]*ring.Poly) []*PolyPair {
+	m := make([]*PolyPair, len(pairs))
+	for i := range pairs {
+		m[i] = NewPolyPair(pairs[i])
+	}
+	return m
+}
+
+func ringPolyPairs(m []*PolyPair) (pairs [][2]*ring.Poly, err error) {
+	pairs = make([][2]*ring.Poly, len(m))
+	for i := range m {
+		if pairs[i], err = m[i].Ring(); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func newPolys(polys []*ring.Poly) []*Poly {
+	m := make([]*Poly, len(polys))
+	for i := range polys {
+		m[i] = NewPoly(polys[i])
+	}
+	return m
+}
+
+func ringPolys(m []*Poly) (polys []*ring.Poly, err error) {
+	polys = make([]*ring.Poly, len(m))
+	for i := range m {
+		if polys[i], err = m[i].Ring(); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// NewCiphertextFromBFV returns the protobuf message of the given bfv.Ciphertext.
+func NewCiphertextFromBFV(ct *bfv.Ciphertext) *Ciphertext {
+	return &Ciphertext{Value: newPolys(ct.Value), IsNTT: ct.IsNTT}
+}
+
+// NewCiphertextFromCKKS returns the protobuf message of the given ckks.Ciphertext.
+func NewCiphertextFromCKKS(ct *ckks.Ciphertext) *Ciphertext {
+	return &Ciphertext{Value: newPolys(ct.Value), IsNTT: ct.IsNTT(), Scale: ct.Scale()}
+}
+
+// BFV returns the bfv.Ciphertext stored in the message.
+func (m *Ciphertext) BFV() (*bfv.Ciphertext, error) {
+	value, err := ringPolys(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &bfv.Ciphertext{Ciphertext: &rlwe.Ciphertext{Element: &rlwe.Element{Value: value, IsNTT: m.IsNTT}, MetaData: rlwe.MetaData{Scheme: rlwe.SchemeBFV}}}, nil
+}
+
+// CKKS returns the ckks.Ciphertext stored in the message.
+func (m *Ciphertext) CKKS() (*ckks.Ciphertext, error) {
+	value, err := ringPolys(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &ckks.Ciphertext{Ciphertext: &rlwe.Ciphertext{Element: &rlwe.Element{Value: value, IsNTT: m.IsNTT}, MetaData: rlwe.MetaData{Scheme: rlwe.SchemeCKKS, Scale: m.Scale}}}, nil
+}
+
+// NewSecretKey returns the protobuf message of the given rlwe.SecretKey.
+func NewSecretKey(sk *rlwe.SecretKey) *SecretKey {
+	return &SecretKey{NewPoly(sk.Value)}
+}
+
+// RLWE returns the rlwe.SecretKey stored in the message.
+func (m *SecretKey) RLWE() (*rlwe.SecretKey, error) {
+	value, err := m.Value.Ring()
+	if err != nil {
+		return nil, err
+	}
+	return &rlwe.SecretKey{Value: value}, nil
+}
+
+// NewPublicKey returns the protobuf message of the given rlwe.PublicKey.
+func NewPublicKey(pk *rlwe.PublicKey) *PublicKey {
+	return &PublicKey{NewPolyPair(pk.Value)}
+}
+
+// RLWE returns the rlwe.PublicKey stored in the message.
+func (m *PublicKey) RLWE() (*rlwe.PublicKey, error) {
+	value, err := m.Value.Ring()
+	if err != nil {
+		return nil, err
+	}
+	return &rlwe.PublicKey{Value: value}, nil
+}
+
+// NewSwitchingKey returns the protobuf message of the given rlwe.SwitchingKey.
+func NewSwitchingKey(swk *rlwe.SwitchingKey) *SwitchingKey {
+	return &SwitchingKey{newPolyPairs(swk.Value)}
+}
+
+// RLWE returns the rlwe.SwitchingKey stored in the message.
+func (m *SwitchingKey) RLWE() (*rlwe.SwitchingKey, error) {
+	value, err := ringPolyPairs(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &rlwe.SwitchingKey{Value: value}, nil
+}
+
+// NewRelinearizationKey returns the protobuf message of the given rlwe.RelinearizationKey.
+func NewRelinearizationKey(rlk *rlwe.RelinearizationKey) *RelinearizationKey {
+	m := &RelinearizationKey{Keys: make([]*SwitchingKey, len(rlk.Keys))}
+	for i := range rlk.Keys {
+		m.Keys[i] = NewSwitchingKey(rlk.Keys[i])
+	}
+	return m
+}
+
+// RLWE returns the rlwe.RelinearizationKey stored in the message.
+func (m *RelinearizationKey) RLWE() (rlk *rlwe.RelinearizationKey, err error) {
+	rlk = &rlwe.RelinearizationKey{Keys: make([]*rlwe.SwitchingKey, len(m.Keys))}
+	for i := range m.Keys {
+		if rlk.Keys[i], err = m.Keys[i].RLWE(); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// NewRotationKeySet returns the protobuf message of the given rlwe.RotationKeySet.
+// The keys are sorted by Galois element so that the encoding is deterministic.
+func NewRotationKeySet(rtks *rlwe.RotationKeySet) *RotationKeySet {
+	galEls := make([]uint64, 0, len(rtks.Keys))
+	for galEl := range rtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	m := &RotationKeySet{Keys: make([]*RotationKey, len(galEls))}
+	for i, galEl := range galEls {
+		m.Keys[i] = &RotationKey{GaloisElement: galEl, Key: NewSwitchingKey(rtks.Keys[galEl])}
+	}
+	return m
+}
+
+// RLWE returns the rlwe.RotationKeySet stored in the message.
+func (m *RotationKeySet) RLWE() (rtks *rlwe.RotationKeySet, err error) {
+	rtks = &rlwe.RotationKeySet{Keys: make(map[uint64]*rlwe.SwitchingKey, len(m.Keys))}
+	for _, rtk := range m.Keys {
+		if rtks.Keys[rtk.GaloisElement], err = rtk.Key.RLWE(); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// NewCKGShare returns the protobuf message of the given drlwe.CKGShare.
+func NewCKGShare(share *drlwe.CKGShare) *CKGShare {
+	return &CKGShare{NewPoly(share.Poly)}
+}
+
+// DRLWECKGShare returns the drlwe.CKGShare stored in the message.
+func (m *PolyShare) DRLWECKGShare() (*drlwe.CKGShare, error) {
+	pol, err := m.Value.Ring()
+	if err != nil {
+		return nil, err
+	}
+	return &drlwe.CKGShare{Poly: pol}, nil
+}
+
+// NewRKGShare returns the protobuf message of the given drlwe.RKGShare.
+func NewRKGShare(share *drlwe.RKGShare) (*RKGShare, error) {
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &RKGShare{data}, nil
+}
+
+// DRLWE returns the drlwe.RKGShare stored in the message.
+func (m *RKGShare) DRLWE() (*drlwe.RKGShare, error) {
+	if len(m.Data) < 1 || m.Data[0] == 0 {
+		return nil, errors.New("proto: invalid RKGShare encoding")
+	}
+	share := new(drlwe.RKGShare)
+	if err := share.UnmarshalBinary(m.Data); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// NewRTGShare returns the protobuf message of the given drlwe.RTGShare.
+func NewRTGShare(share *drlwe.RTGShare) *RTGShare {
+	return &RTGShare{newPolys(share.Value)}
+}
+
+// DRLWE returns the drlwe.RTGShare stored in the message.
+func (m *RTGShare) DRLWE() (*drlwe.RTGShare, error) {
+	value, err := ringPolys(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &drlwe.RTGShare{Value: value}, nil
+}
+
+// NewShamirSecretShare returns the protobuf message of the given drlwe.ShamirSecretShare.
+func NewShamirSecretShare(share *drlwe.ShamirSecretShare) *ShamirSecretShare {
+	return &ShamirSecretShare{NewPoly(share.Poly)}
+}
+
+// DRLWEShamirSecretShare returns the drlwe.ShamirSecretShare stored in the message.
+func (m *PolyShare) DRLWEShamirSecretShare() (*drlwe.ShamirSecretShare, error) {
+	pol, err := m.Value.Ring()
+	if err != nil {
+		return nil, err
+	}
+	return &drlwe.ShamirSecretShare{Poly: pol}, nil
+}
+
+// NewCKSShareFromDBFV returns the protobuf message of the given dbfv.CKSShare.
+func NewCKSShareFromDBFV(share dbfv.CKSShare) *CKSShare {
+	return &CKSShare{NewPoly(share.Poly)}
+}
+
+// NewCKSShareFromDCKKS returns the protobuf message of the given dckks.CKSShare.
+func NewCKSShareFromDCKKS(share dckks.CKSShare) *CKSShare {
+	return &CKSShare{NewPoly(share)}
+}
+
+// DBFVCKSShare returns the dbfv.CKSShare stored in the message.
+func (m *PolyShare) DBFVCKSShare() (dbfv.CKSShare, error) {
+	pol, err := m.Value.Ring()
+	return dbfv.CKSShare{Poly: pol}, err
+}
+
+// DCKKSCKSShare returns the dckks.CKSShare stored in the message.
+func (m *PolyShare) DCKKSCKSShare() (dckks.CKSShare, error) {
+	return m.Value.Ring()
+}
+
+// NewPCKSShareFromDBFV returns the protobuf message of the given dbfv.PCKSShare.
+func NewPCKSShareFromDBFV(share dbfv.PCKSShare) *PCKSShare {
+	return &PCKSShare{NewPolyPair(share)}
+}
+
+// NewPCKSShareFromDCKKS returns the protobuf message of the given dckks.PCKSShare.
+func NewPCKSShareFromDCKKS(share dckks.PCKSShare) *PCKSShare {
+	return &PCKSShare{NewPolyPair(share)}
+}
+
+// DBFVPCKSShare returns the dbfv.PCKSShare stored in the message.
+func (m *PublicKey) DBFVPCKSShare() (dbfv.PCKSShare, error) {
+	return m.Value.Ring()
+}
+
+// DCKKSPCKSShare returns the dckks.PCKSShare stored in the message.
+func (m *PublicKey) DCKKSPCKSShare() (dckks.PCKSShare, error) {
+	return m.Value.Ring()
+}
+
+// NewRefreshShareFromDBFV returns the protobuf message of the given dbfv.RefreshShare.
+func NewRefreshShareFromDBFV(share dbfv.RefreshShare) *RefreshShare {
+	return &RefreshShare{NewPoly(share.RefreshShareDecrypt), NewPoly(share.RefreshShareRecrypt)}
+}
+
+// NewRefreshShareFromDCKKS returns the protobuf message of the given dckks refresh shares.
+func NewRefreshShareFromDCKKS(shareDecrypt dckks.RefreshShareDecrypt, shareRecrypt dckks.RefreshShareRecrypt) *RefreshShare {
+	return &RefreshShare{NewPoly(shareDecrypt), NewPoly(shareRecrypt)}
+}
+
+// DBFV returns the dbfv.RefreshShare stored in the message.
+func (m *RefreshShare) DBFV() (share dbfv.RefreshShare, err error) {
+	dec, rec, err := m.polys()
+	return dbfv.RefreshShare{RefreshShareDecrypt: dec, RefreshShareRecrypt: rec}, err
+}
+
+// DCKKS returns the dckks refresh shares stored in the message.
+func (m *RefreshShare) DCKKS() (dckks.RefreshShareDecrypt, dckks.RefreshShareRecrypt, error) {
+	dec, rec, err := m.polys()
+	return dec, rec, err
+}
+
+func (m *RefreshShare) polys() (dec, rec *ring.Poly, err error) {
+	if dec, err = m.Decrypt.Ring(); err != nil {
+		return
+	}
+	rec, err = m.Recrypt.Ring()
+	return
+}
@@ -0,0 +1,469 @@
+package proto
+
+import (
+	"math"
+)
+
+func decodeMessage(wireType int, b []byte, m message) error {
+	if err := checkWireType(wireType, wireBytes); err != nil {
+		return err
+	}
+	return unmarshal(b, m)
+}
+
+func decodeDouble(wireType int, v uint64) (float64, error) {
+	if err := checkWireType(wireType, wireFixed64); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(v), nil
+}
+
+// Parameters is the protobuf message storing the parameters of the RLWE, BFV and CKKS schemes.
+type Parameters struct {
+	LogN     uint32
+	Q        []uint64
+	P        []uint64
+	Sigma    float64
+	T        uint64
+	LogSlots uint32
+	Scale    float64
+}
+
+func (m *Parameters) isNil() bool { return m == nil }
+
+func (m *Parameters) encode(e *encoder) {
+	e.uint64(1, uint64(m.LogN))
+	e.packedFixed64(2, m.Q)
+	e.packedFixed64(3, m.P)
+	e.double(4, m.Sigma)
+	e.uint64(5, m.T)
+	e.uint64(6, uint64(m.LogSlots))
+	e.double(7, m.Scale)
+}
+
+func (m *Parameters) decodeField(field, wireType int, v uint64, b []byte) (err error) {
+	switch field {
+	case 1:
+		err = checkWireType(wireType, wireVarint)
+		m.LogN = uint32(v)
+	case 2:
+		m.Q, err = appendFixed64(m.Q, wireType, v, b)
+	case 3:
+		m.P, err = appendFixed64(m.P, wireType, v, b)
+	case 4:
+		m.Sigma, err = decodeDouble(wireType, v)
+	case 5:
+		err = checkWireType(wireType, wireVarint)
+		m.T = v
+	case 6:
+		err = checkWireType(wireType, wireVarint)
+		m.LogSlots = uint32(v)
+	case 7:
+		m.Scale, err = decodeDouble(wireType, v)
+	}
+	return
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *Parameters) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *Parameters) Unmarshal(data []byte) error { *m = Parameters{}; return unmarshal(data, m) }
+
+// Poly is the protobuf message storing a polynomial in RNS representation. The coefficients
+// are stored modulus by modulus, each block being of size N.
+type Poly struct {
+	N      uint32
+	Coeffs []uint64
+}
+
+func (m *Poly) isNil() bool { return m == nil }
+
+func (m *Poly) encode(e *encoder) {
+	e.uint64(1, uint64(m.N))
+	e.packedFixed64(2, m.Coeffs)
+}
+
+func (m *Poly) decodeField(field, wireType int, v uint64, b []byte) (err error) {
+	switch field {
+	case 1:
+		err = checkWireType(wireType, wireVarint)
+		m.N = uint32(v)
+	case 2:
+		m.Coeffs, err = appendFixed64(m.Coeffs, wireType, v, b)
+	}
+	return
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *Poly) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *Poly) Unmarshal(data []byte) error { *m = Poly{}; return unmarshal(data, m) }
+
+// PolyPair is the protobuf message storing a pair of polynomials.
+type PolyPair struct {
+	P0 *Poly
+	P1 *Poly
+}
+
+func (m *PolyPair) isNil() bool { return m == nil }
+
+func (m *PolyPair) encode(e *encoder) {
+	e.message(1, m.P0)
+	e.message(2, m.P1)
+}
+
+func (m *PolyPair) decodeField(field, wireType int, v uint64, b []byte) error {
+	switch field {
+	case 1:
+		m.P0 = new(Poly)
+		return decodeMessage(wireType, b, m.P0)
+	case 2:
+		m.P1 = new(Poly)
+		return decodeMessage(wireType, b, m.P1)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *PolyPair) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *PolyPair) Unmarshal(data []byte) error { *m = PolyPair{}; return unmarshal(data, m) }
+
+// Ciphertext is the protobuf message storing a BFV or CKKS ciphertext. The scale is zero for BFV.
+type Ciphertext struct {
+	Value []*Poly
+	IsNTT bool
+	Scale float64
+}
+
+func (m *Ciphertext) isNil() bool { return m == nil }
+
+func (m *Ciphertext) encode(e *encoder) {
+	for _, pol := range m.Value {
+		e.message(1, pol)
+	}
+	e.bool(2, m.IsNTT)
+	e.double(3, m.Scale)
+}
+
+func (m *Ciphertext) decodeField(field, wireType int, v uint64, b []byte) (err error) {
+	switch field {
+	case 1:
+		pol := new(Poly)
+		m.Value = append(m.Value, pol)
+		err = decodeMessage(wireType, b, pol)
+	case 2:
+		err = checkWireType(wireType, wireVarint)
+		m.IsNTT = v != 0
+	case 3:
+		m.Scale, err = decodeDouble(wireType, v)
+	}
+	return
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *Ciphertext) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *Ciphertext) Unmarshal(data []byte) error { *m = Ciphertext{}; return unmarshal(data, m) }
+
+// SecretKey is the protobuf message storing a secret key.
+type SecretKey struct {
+	Value *Poly
+}
+
+func (m *SecretKey) isNil() bool { return m == nil }
+
+func (m *SecretKey) encode(e *encoder) {
+	e.message(1, m.Value)
+}
+
+func (m *SecretKey) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		m.Value = new(Poly)
+		return decodeMessage(wireType, b, m.Value)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *SecretKey) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *SecretKey) Unmarshal(data []byte) error { *m = SecretKey{}; return unmarshal(data, m) }
+
+// PublicKey is the protobuf message storing a public key.
+type PublicKey struct {
+	Value *PolyPair
+}
+
+func (m *PublicKey) isNil() bool { return m == nil }
+
+func (m *PublicKey) encode(e *encoder) {
+	e.message(1, m.Value)
+}
+
+func (m *PublicKey) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		m.Value = new(PolyPair)
+		return decodeMessage(wireType, b, m.Value)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *PublicKey) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *PublicKey) Unmarshal(data []byte) error { *m = PublicKey{}; return unmarshal(data, m) }
+
+// SwitchingKey is the protobuf message storing a switching key.
+type SwitchingKey struct {
+	Value []*PolyPair
+}
+
+func (m *SwitchingKey) isNil() bool { return m == nil }
+
+func (m *SwitchingKey) encode(e *encoder) {
+	for _, pair := range m.Value {
+		e.message(1, pair)
+	}
+}
+
+func (m *SwitchingKey) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		pair := new(PolyPair)
+		m.Value = append(m.Value, pair)
+		return decodeMessage(wireType, b, pair)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *SwitchingKey) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *SwitchingKey) Unmarshal(data []byte) error { *m = SwitchingKey{}; return unmarshal(data, m) }
+
+// RelinearizationKey is the protobuf message storing a relinearization key.
+type RelinearizationKey struct {
+	Keys []*SwitchingKey
+}
+
+func (m *RelinearizationKey) isNil() bool { return m == nil }
+
+func (m *RelinearizationKey) encode(e *encoder) {
+	for _, swk := range m.Keys {
+		e.message(1, swk)
+	}
+}
+
+func (m *RelinearizationKey) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		swk := new(SwitchingKey)
+		m.Keys = append(m.Keys, swk)
+		return decodeMessage(wireType, b, swk)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *RelinearizationKey) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *RelinearizationKey) Unmarshal(data []byte) error {
+	*m = RelinearizationKey{}
+	return unmarshal(data, m)
+}
+
+// RotationKey is the protobuf message storing a rotation key along with its Galois element.
+type RotationKey struct {
+	GaloisElement uint64
+	Key           *SwitchingKey
+}
+
+func (m *RotationKey) isNil() bool { return m == nil }
+
+func (m *RotationKey) encode(e *encoder) {
+	e.uint64(1, m.GaloisElement)
+	e.message(2, m.Key)
+}
+
+func (m *RotationKey) decodeField(field, wireType int, v uint64, b []byte) (err error) {
+	switch field {
+	case 1:
+		err = checkWireType(wireType, wireVarint)
+		m.GaloisElement = v
+	case 2:
+		m.Key = new(SwitchingKey)
+		err = decodeMessage(wireType, b, m.Key)
+	}
+	return
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *RotationKey) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *RotationKey) Unmarshal(data []byte) error { *m = RotationKey{}; return unmarshal(data, m) }
+
+// RotationKeySet is the protobuf message storing a set of rotation keys.
+type RotationKeySet struct {
+	Keys []*RotationKey
+}
+
+func (m *RotationKeySet) isNil() bool { return m == nil }
+
+func (m *RotationKeySet) encode(e *encoder) {
+	for _, rtk := range m.Keys {
+		e.message(1, rtk)
+	}
+}
+
+func (m *RotationKeySet) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		rtk := new(RotationKey)
+		m.Keys = append(m.Keys, rtk)
+		return decodeMessage(wireType, b, rtk)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *RotationKeySet) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *RotationKeySet) Unmarshal(data []byte) error {
+	*m = RotationKeySet{}
+	return unmarshal(data, m)
+}
+
+// PolyShare is the protobuf message storing a multiparty protocol share made of a single polynomial.
+// It is used for the CKGShare, CKSShare and ShamirSecretShare messages.
+type PolyShare struct {
+	Value *Poly
+}
+
+func (m *PolyShare) isNil() bool { return m == nil }
+
+func (m *PolyShare) encode(e *encoder) {
+	e.message(1, m.Value)
+}
+
+func (m *PolyShare) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		m.Value = new(Poly)
+		return decodeMessage(wireType, b, m.Value)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *PolyShare) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *PolyShare) Unmarshal(data []byte) error { *m = PolyShare{}; return unmarshal(data, m) }
+
+// CKGShare is the protobuf message storing a share of the collective public-key generation protocol.
+type CKGShare = PolyShare
+
+// CKSShare is the protobuf message storing a share of the collective key-switching protocol.
+type CKSShare = PolyShare
+
+// ShamirSecretShare is the protobuf message storing a t-out-of-N-threshold secret-share.
+type ShamirSecretShare = PolyShare
+
+// RKGShare is the protobuf message storing a share of the relinearization-key generation protocol.
+// The share is stored in the binary encoding of drlwe.RKGShare.
+type RKGShare struct {
+	Data []byte
+}
+
+func (m *RKGShare) isNil() bool { return m == nil }
+
+func (m *RKGShare) encode(e *encoder) {
+	if len(m.Data) > 0 {
+		e.bytes(1, m.Data)
+	}
+}
+
+func (m *RKGShare) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		if err := checkWireType(wireType, wireBytes); err != nil {
+			return err
+		}
+		m.Data = append([]byte{}, b...)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *RKGShare) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *RKGShare) Unmarshal(data []byte) error { *m = RKGShare{}; return unmarshal(data, m) }
+
+// RTGShare is the protobuf message storing a share of the rotation-key generation protocol.
+type RTGShare struct {
+	Value []*Poly
+}
+
+func (m *RTGShare) isNil() bool { return m == nil }
+
+func (m *RTGShare) encode(e *encoder) {
+	for _, pol := range m.Value {
+		e.message(1, pol)
+	}
+}
+
+func (m *RTGShare) decodeField(field, wireType int, v uint64, b []byte) error {
+	if field == 1 {
+		pol := new(Poly)
+		m.Value = append(m.Value, pol)
+		return decodeMessage(wireType, b, pol)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *RTGShare) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *RTGShare) Unmarshal(data []byte) error { *m = RTGShare{}; return unmarshal(data, m) }
+
+// PCKSShare is the protobuf message storing a share of the collective public-key-switching protocol.
+type PCKSShare = PublicKey
+
+// RefreshShare is the protobuf message storing a share of the refresh and permute protocols.
+type RefreshShare struct {
+	Decrypt *Poly
+	Recrypt *Poly
+}
+
+func (m *RefreshShare) isNil() bool { return m == nil }
+
+func (m *RefreshShare) encode(e *encoder) {
+	e.message(1, m.Decrypt)
+	e.message(2, m.Recrypt)
+}
+
+func (m *RefreshShare) decodeField(field, wireType int, v uint64, b []byte) error {
+	switch field {
+	case 1:
+		m.Decrypt = new(Poly)
+		return decodeMessage(wireType, b, m.Decrypt)
+	case 2:
+		m.Recrypt = new(Poly)
+		return decodeMessage(wireType, b, m.Recrypt)
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the message.
+func (m *RefreshShare) Marshal() ([]byte, error) { return marshal(m) }
+
+// Unmarshal decodes a protobuf encoded message on the target message.
+func (m *RefreshShare) Unmarshal(data []byte) error { *m = RefreshShare{}; return unmarshal(data, m) }
@@ -0,0 +1,161 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/dbfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type protoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+func marshalUnmarshal(t *testing.T, in, out protoMessage) {
+	data, err := in.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, out.Unmarshal(data))
+}
+
+func TestProto(t *testing.T) {
+
+	bfvParams, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+	ckksParams, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+	require.NoError(t, err)
+
+	prng, err := utils.NewPRNG()
+	require.NoError(t, err)
+
+	kgen := bfv.NewKeyGenerator(bfvParams)
+	sk, pk := kgen.GenKeyPair()
+
+	t.Run("Parameters", func(t *testing.T) {
+		m := new(Parameters)
+
+		marshalUnmarshal(t, NewParametersFromBFV(bfvParams), m)
+		params, err := m.BFV()
+		require.NoError(t, err)
+		assert.True(t, bfvParams.Equals(params))
+
+		marshalUnmarshal(t, NewParametersFromCKKS(ckksParams), m)
+		paramsCKKS, err := m.CKKS()
+		require.NoError(t, err)
+		assert.True(t, ckksParams.Equals(paramsCKKS))
+	})
+
+	t.Run("Ciphertext", func(t *testing.T) {
+		m := new(Ciphertext)
+
+		ctBFV := bfv.NewCiphertextRandom(prng, bfvParams, 2)
+		marshalUnmarshal(t, NewCiphertextFromBFV(ctBFV), m)
+		ctBFVOut, err := m.BFV()
+		require.NoError(t, err)
+		assert.Equal(t, ctBFV.Degree(), ctBFVOut.Degree())
+		assert.Equal(t, ctBFV.IsNTT, ctBFVOut.IsNTT)
+		for i := range ctBFV.Value {
+			assert.True(t, ctBFV.Value[i].Equals(ctBFVOut.Value[i]))
+		}
+
+		ctCKKS := ckks.NewCiphertextRandom(prng, ckksParams, 1, 1, ckksParams.Scale())
+		marshalUnmarshal(t, NewCiphertextFromCKKS(ctCKKS), m)
+		ctCKKSOut, err := m.CKKS()
+		require.NoError(t, err)
+		assert.Equal(t, ctCKKS.Level(), ctCKKSOut.Level())
+		assert.Equal(t, ctCKKS.Scale(), ctCKKSOut.Scale())
+		assert.Equal(t, ctCKKS.IsNTT(), ctCKKSOut.IsNTT())
+		for i := range ctCKKS.Value {
+			assert.True(t, ctCKKS.Value[i].Equals(ctCKKSOut.Value[i]))
+		}
+	})
+
+	t.Run("Keys", func(t *testing.T) {
+
+		mSk := new(SecretKey)
+		marshalUnmarshal(t, NewSecretKey(sk), mSk)
+		skOut, err := mSk.RLWE()
+		require.NoError(t, err)
+		assert.True(t, sk.Value.Equals(skOut.Value))
+
+		mPk := new(PublicKey)
+		marshalUnmarshal(t, NewPublicKey(pk), mPk)
+		pkOut, err := mPk.RLWE()
+		require.NoError(t, err)
+		assert.True(t, pk.Equals(pkOut))
+
+		rlk := kgen.GenRelinearizationKey(sk, 2)
+		mRlk := new(RelinearizationKey)
+		marshalUnmarshal(t, NewRelinearizationKey(rlk), mRlk)
+		rlkOut, err := mRlk.RLWE()
+		require.NoError(t, err)
+		assert.True(t, rlk.Equals(rlkOut))
+
+		rtks := kgen.GenRotationKeysForRotations([]int{1, 2}, true, sk)
+		mRtks := new(RotationKeySet)
+		marshalUnmarshal(t, NewRotationKeySet(rtks), mRtks)
+		rtksOut, err := mRtks.RLWE()
+		require.NoError(t, err)
+		assert.True(t, rtks.Equals(rtksOut))
+	})
+
+	t.Run("Shares", func(t *testing.T) {
+
+		crs := ring.NewUniformSampler(prng, bfvParams.RingQP())
+
+		ckg := drlwe.NewCKGProtocol(bfvParams.Parameters)
+		ckgShare := ckg.AllocateShares()
+		ckg.GenShare(sk, crs.ReadNew(), ckgShare)
+		mCkg := new(CKGShare)
+		marshalUnmarshal(t, NewCKGShare(ckgShare), mCkg)
+		ckgShareOut, err := mCkg.DRLWECKGShare()
+		require.NoError(t, err)
+		assert.True(t, ckgShare.Equals(ckgShareOut.Poly))
+
+		rkg := drlwe.NewRKGProtocol(bfvParams.Parameters, 0.5)
+		crp := make([]*ring.Poly, bfvParams.Beta())
+		for i := range crp {
+			crp[i] = crs.ReadNew()
+		}
+		ephSk, rkgShare, _ := rkg.AllocateShares()
+		rkg.GenShareRoundOne(sk, crp, ephSk, rkgShare)
+		mRkg := new(RKGShare)
+		rkgMsg, err := NewRKGShare(rkgShare)
+		require.NoError(t, err)
+		marshalUnmarshal(t, rkgMsg, mRkg)
+		rkgShareOut, err := mRkg.DRLWE()
+		require.NoError(t, err)
+		rkgData, err := rkgShare.MarshalBinary()
+		require.NoError(t, err)
+		rkgDataOut, err := rkgShareOut.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, rkgData, rkgDataOut)
+		_, err = new(RKGShare).DRLWE()
+		assert.Error(t, err)
+
+		pcks := dbfv.NewPCKSProtocol(bfvParams, 3.19)
+		pcksShare := pcks.AllocateShares()
+		pcks.GenShare(sk.Value, pk, bfv.NewCiphertextRandom(prng, bfvParams, 1), pcksShare)
+		mPcks := new(PCKSShare)
+		marshalUnmarshal(t, NewPCKSShareFromDBFV(pcksShare), mPcks)
+		pcksShareOut, err := mPcks.DBFVPCKSShare()
+		require.NoError(t, err)
+		assert.True(t, pcksShare[0].Equals(pcksShareOut[0]))
+		assert.True(t, pcksShare[1].Equals(pcksShareOut[1]))
+	})
+
+	t.Run("Unmarshal/Invalid", func(t *testing.T) {
+		data, err := NewSecretKey(sk).Marshal()
+		require.NoError(t, err)
+		assert.Error(t, new(SecretKey).Unmarshal(data[:len(data)-1]))
+
+		_, err = new(Poly).Ring()
+		assert.Error(t, err)
+	})
+}
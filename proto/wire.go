@@ -0,0 +1,196 @@
+package proto
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("proto: truncated message")
+
+// encoder appends protobuf encoded fields to a buffer. Following the proto3 semantic,
+// fields with default values are not written.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) varint(v uint64) {
+	for v >= 0x80 {
+		e.buf = append(e.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	e.buf = append(e.buf, byte(v))
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (e *encoder) uint64(field int, v uint64) {
+	if v != 0 {
+		e.tag(field, wireVarint)
+		e.varint(v)
+	}
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if v {
+		e.tag(field, wireVarint)
+		e.varint(1)
+	}
+}
+
+func (e *encoder) double(field int, v float64) {
+	if v != 0 {
+		e.tag(field, wireFixed64)
+		e.buf = append(e.buf, make([]byte, 8)...)
+		binary.LittleEndian.PutUint64(e.buf[len(e.buf)-8:], math.Float64bits(v))
+	}
+}
+
+func (e *encoder) bytes(field int, b []byte) {
+	e.tag(field, wireBytes)
+	e.varint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) packedFixed64(field int, vs []uint64) {
+	if len(vs) == 0 {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.varint(uint64(8 * len(vs)))
+	ptr := len(e.buf)
+	e.buf = append(e.buf, make([]byte, 8*len(vs))...)
+	for _, v := range vs {
+		binary.LittleEndian.PutUint64(e.buf[ptr:], v)
+		ptr += 8
+	}
+}
+
+// message writes a nested message. Nil messages are not written.
+func (e *encoder) message(field int, m message) {
+	if m == nil || m.isNil() {
+		return
+	}
+	sub := encoder{}
+	m.encode(&sub)
+	e.bytes(field, sub.buf)
+}
+
+// message is the interface implemented by all the messages of the package.
+type message interface {
+	encode(e *encoder)
+	decodeField(field, wireType int, v uint64, b []byte) error
+	isNil() bool
+}
+
+func marshal(m message) ([]byte, error) {
+	e := encoder{}
+	m.encode(&e)
+	return e.buf, nil
+}
+
+func readVarint(data []byte) (v uint64, n int, err error) {
+	for shift := uint(0); shift < 64; shift += 7 {
+		if n >= len(data) {
+			return 0, 0, errTruncated
+		}
+		b := data[n]
+		n++
+		v |= uint64(b&0x7F) << shift
+		if b < 0x80 {
+			return v, n, nil
+		}
+	}
+	return 0, 0, errors.New("proto: varint overflow")
+}
+
+// unmarshal decodes data field by field on m. For varint and fixed-size fields, the value is
+// passed in v, for length-delimited fields the payload is passed in b. Unknown fields must be
+// ignored by the messages to ensure forward compatibility.
+func unmarshal(data []byte, m message) error {
+	for len(data) > 0 {
+		key, n, err := readVarint(data)
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+
+		field, wireType := int(key>>3), int(key&7)
+		if field == 0 {
+			return errors.New("proto: invalid field number 0")
+		}
+
+		var v uint64
+		var b []byte
+
+		switch wireType {
+		case wireVarint:
+			if v, n, err = readVarint(data); err != nil {
+				return err
+			}
+		case wireFixed64:
+			if n = 8; len(data) < n {
+				return errTruncated
+			}
+			v = binary.LittleEndian.Uint64(data)
+		case wireFixed32:
+			if n = 4; len(data) < n {
+				return errTruncated
+			}
+			v = uint64(binary.LittleEndian.Uint32(data))
+		case wireBytes:
+			var l uint64
+			if l, n, err = readVarint(data); err != nil {
+				return err
+			}
+			if uint64(len(data)-n) < l {
+				return errTruncated
+			}
+			b = data[n : n+int(l)]
+			n += int(l)
+		default:
+			return errors.New("proto: unsupported wire type")
+		}
+
+		if err = m.decodeField(field, wireType, v, b); err != nil {
+			return err
+		}
+
+		data = data[n:]
+	}
+	return nil
+}
+
+// appendFixed64 appends to vs a repeated fixed64 field, which can be either packed or not.
+func appendFixed64(vs []uint64, wireType int, v uint64, b []byte) ([]uint64, error) {
+	switch wireType {
+	case wireFixed64:
+		return append(vs, v), nil
+	case wireBytes:
+		if len(b)&7 != 0 {
+			return nil, errors.New("proto: invalid packed fixed64 length")
+		}
+		for i := 0; i < len(b); i += 8 {
+			vs = append(vs, binary.LittleEndian.Uint64(b[i:]))
+		}
+		return vs, nil
+	}
+	return nil, errors.New("proto: invalid wire type for fixed64 field")
+}
+
+func checkWireType(wireType, expected int) error {
+	if wireType != expected {
+		return errors.New("proto: invalid wire type")
+	}
+	return nil
+}
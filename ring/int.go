@@ -3,6 +3,8 @@ package ring
 import (
 	"crypto/rand"
 	"math/big"
+
+	"github.com/ldsec/lattigo/v2/internal/randsource"
 )
 
 // NewInt creates a new Int with a given int64 value.
@@ -28,7 +30,7 @@ func NewIntFromString(s string) *big.Int {
 // RandInt generates a random Int in [0, max-1].
 func RandInt(max *big.Int) (n *big.Int) {
 	var err error
-	if n, err = rand.Int(rand.Reader, max); err != nil {
+	if n, err = rand.Int(randsource.Reader{}, max); err != nil {
 		panic("error: crypto/rand/bigint")
 	}
 	return
@@ -45,6 +45,13 @@ type Ring struct {
 	NttPsiInv [][]uint64 //powers of the inverse of the 2N-th primitive root in Montgomery form (in bit-reversed order)
 	NttNInv   []uint64   //[N^-1] mod Qi in Montgomery form
 
+	// Twiddle factors of the AVX-512 IFMA kernels of the NTT (nil if the CPU does not support them, and nil
+	// entries for the moduli they do not support)
+	nttIFMA []*nttIFMAParams
+
+	// Identifies the operation counts of the ring (see Fingerprint)
+	fingerprint uint64
+
 	polypool *Poly
 }
 
@@ -111,6 +118,8 @@ func (r *Ring) setParameters(N int, Modulus []uint64) error {
 		}
 	}
 
+	r.fingerprint = Fingerprint(N, r.Modulus)
+
 	r.polypool = r.NewPoly()
 
 	return nil
@@ -199,6 +208,15 @@ func (r *Ring) genNTTParams() error {
 		}
 	}
 
+	if hasIFMA {
+		r.nttIFMA = make([]*nttIFMAParams, len(r.Modulus))
+		for i, qi := range r.Modulus {
+			if qi < nttIFMAMaxModulus {
+				r.nttIFMA[i] = newNTTIFMAParams(qi, r.MredParams[i], r.NttPsi[i], r.NttPsiInv[i], r.NttNInv[i])
+			}
+		}
+	}
+
 	r.AllowsNTT = true
 
 	return nil
@@ -174,6 +174,13 @@ func PermuteNTTWithIndexAndAddNoModLvl(level int, polIn *Poly, index []uint64, p
 // It maps the coefficients x^i to x^(gen*i)
 // It must be noted that the result cannot be in-place.
 func (r *Ring) Permute(polIn *Poly, gen uint64, polOut *Poly) {
+	r.PermuteLvl(len(r.Modulus)-1, polIn, gen, polOut)
+}
+
+// PermuteLvl applies the Galois transform on a polynomial outside of the NTT domain, on the first level+1 moduli.
+// It maps the coefficients x^i to x^(gen*i)
+// It must be noted that the result cannot be in-place.
+func (r *Ring) PermuteLvl(level int, polIn *Poly, gen uint64, polOut *Poly) {
 
 	var mask, index, indexRaw, logN, tmp uint64
 
@@ -189,7 +196,7 @@ func (r *Ring) Permute(polIn *Poly, gen uint64, polOut *Poly) {
 
 		tmp = (indexRaw >> logN) & 1
 
-		for j, qi := range r.Modulus {
+		for j, qi := range r.Modulus[:level+1] {
 
 			polOut.Coeffs[j][index] = polIn.Coeffs[j][i]*(tmp^1) | (qi-polIn.Coeffs[j][i])*tmp
 		}
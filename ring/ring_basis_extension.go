@@ -9,13 +9,22 @@ import (
 
 // FastBasisExtender stores the necessary parameters for RNS basis extension.
 // The used algorithm is from https://eprint.iacr.org/2018/117.pdf.
+// The constants of the basis extensions and of the basis reductions are precomputed for each level of the source
+// basis, so that a single FastBasisExtender serves all the pairs of levels up to the levels it was created for.
 type FastBasisExtender struct {
-	ringQ           *Ring
-	ringP           *Ring
-	paramsQP        *modupParams
-	paramsPQ        *modupParams
-	modDownParamsPQ []uint64
-	modDownParamsQP []uint64
+	ringQ  *Ring
+	ringP  *Ring
+	levelQ int
+	levelP int
+
+	// paramsQP[l] are the parameters of the basis extension from {Q0,...,Ql} to {P0,...,PlevelP}
+	paramsQP []*modupParams
+	// paramsPQ[l] are the parameters of the basis extension from {P0,...,Pl} to {Q0,...,QlevelQ}
+	paramsPQ []*modupParams
+	// modDownParamsPQ[l][i] = (P0*...*Pl)^-1 mod Qi (in Montgomery form)
+	modDownParamsPQ [][]uint64
+	// modDownParamsQP[l][j] = (Q0*...*Ql)^-1 mod Pj (in Montgomery form)
+	modDownParamsQP [][]uint64
 
 	polypoolQ *Poly
 	polypoolP *Poly
@@ -40,15 +49,20 @@ type modupParams struct {
 	mredParamsP []uint64
 }
 
-func genModDownParams(ringP, ringQ *Ring) (params []uint64) {
+func genModDownParams(levelP, levelQ int, ringP, ringQ *Ring) (params []uint64) {
 
-	params = make([]uint64, len(ringP.Modulus))
+	params = make([]uint64, levelP+1)
+
+	Q := NewUint(1)
+	for _, qi := range ringQ.Modulus[:levelQ+1] {
+		Q.Mul(Q, NewUint(qi))
+	}
 
 	bredParams := ringP.BredParams
 	tmp := new(big.Int)
-	for i, Qi := range ringP.Modulus {
+	for i, Qi := range ringP.Modulus[:levelP+1] {
 
-		params[i] = tmp.Mod(ringQ.ModulusBigint, NewUint(Qi)).Uint64()
+		params[i] = tmp.Mod(Q, NewUint(Qi)).Uint64()
 		params[i] = ModExp(params[i], int(Qi-2), Qi)
 		params[i] = MForm(params[i], Qi, bredParams[i])
 	}
@@ -58,20 +72,36 @@ func genModDownParams(ringP, ringQ *Ring) (params []uint64) {
 
 // NewFastBasisExtender creates a new FastBasisExtender, enabling RNS basis extension from Q to P and P to Q.
 func NewFastBasisExtender(ringQ, ringP *Ring) *FastBasisExtender {
+	return NewFastBasisExtenderLvl(len(ringQ.Modulus)-1, len(ringP.Modulus)-1, ringQ, ringP)
+}
+
+// NewFastBasisExtenderLvl creates a new FastBasisExtender, enabling RNS basis extension between the first
+// levelQ+1 moduli of ringQ and the first levelP+1 moduli of ringP, and between any of their smaller levels.
+func NewFastBasisExtenderLvl(levelQ, levelP int, ringQ, ringP *Ring) *FastBasisExtender {
 
 	newParams := new(FastBasisExtender)
 
 	newParams.ringQ = ringQ
 	newParams.ringP = ringP
+	newParams.levelQ = levelQ
+	newParams.levelP = levelP
+
+	newParams.paramsQP = make([]*modupParams, levelQ+1)
+	newParams.modDownParamsQP = make([][]uint64, levelQ+1)
+	for l := range newParams.paramsQP {
+		newParams.paramsQP[l] = basisextenderparameters(ringQ.Modulus[:l+1], ringP.Modulus[:levelP+1])
+		newParams.modDownParamsQP[l] = genModDownParams(levelP, l, ringP, ringQ)
+	}
 
-	newParams.paramsQP = basisextenderparameters(ringQ.Modulus, ringP.Modulus)
-	newParams.paramsPQ = basisextenderparameters(ringP.Modulus, ringQ.Modulus)
-
-	newParams.modDownParamsPQ = genModDownParams(ringQ, ringP)
-	newParams.modDownParamsQP = genModDownParams(ringP, ringQ)
+	newParams.paramsPQ = make([]*modupParams, levelP+1)
+	newParams.modDownParamsPQ = make([][]uint64, levelP+1)
+	for l := range newParams.paramsPQ {
+		newParams.paramsPQ[l] = basisextenderparameters(ringP.Modulus[:l+1], ringQ.Modulus[:levelQ+1])
+		newParams.modDownParamsPQ[l] = genModDownParams(levelQ, l, ringQ, ringP)
+	}
 
-	newParams.polypoolQ = ringQ.NewPoly()
-	newParams.polypoolP = ringP.NewPoly()
+	newParams.polypoolQ = ringQ.NewPolyLvl(levelQ)
+	newParams.polypoolP = ringP.NewPolyLvl(levelP)
 
 	return newParams
 }
@@ -154,13 +184,15 @@ func (basisextender *FastBasisExtender) ShallowCopy() *FastBasisExtender {
 	return &FastBasisExtender{
 		ringQ:           basisextender.ringQ,
 		ringP:           basisextender.ringP,
+		levelQ:          basisextender.levelQ,
+		levelP:          basisextender.levelP,
 		paramsQP:        basisextender.paramsQP,
 		paramsPQ:        basisextender.paramsPQ,
 		modDownParamsQP: basisextender.modDownParamsQP,
 		modDownParamsPQ: basisextender.modDownParamsPQ,
 
-		polypoolQ: basisextender.ringQ.NewPoly(),
-		polypoolP: basisextender.ringP.NewPoly(),
+		polypoolQ: basisextender.ringQ.NewPolyLvl(basisextender.levelQ),
+		polypoolP: basisextender.ringP.NewPolyLvl(basisextender.levelP),
 	}
 }
 
@@ -168,14 +200,41 @@ func (basisextender *FastBasisExtender) ShallowCopy() *FastBasisExtender {
 // Given a polynomial with coefficients in basis {Q0,Q1....Qlevel},
 // it extends its basis from {Q0,Q1....Qlevel} to {Q0,Q1....Qlevel,P0,P1...Pj}
 func (basisextender *FastBasisExtender) ModUpSplitQP(level int, p1, p2 *Poly) {
-	modUpExact(p1.Coeffs[:level+1], p2.Coeffs[:len(basisextender.paramsQP.P)], basisextender.paramsQP)
+	basisextender.ModUpSplitQPLvl(level, basisextender.levelP, p1, p2)
+}
+
+// ModUpSplitQPLvl extends the RNS basis of a polynomial from Q to QP.
+// Given a polynomial with coefficients in basis {Q0,Q1....QlevelQ},
+// it extends its basis from {Q0,Q1....QlevelQ} to {Q0,Q1....QlevelQ,P0,P1...PlevelP}
+func (basisextender *FastBasisExtender) ModUpSplitQPLvl(levelQ, levelP int, p1, p2 *Poly) {
+	basisextender.ringQ.countBasisExtension()
+	modUpExact(p1.Coeffs[:levelQ+1], p2.Coeffs[:levelP+1], basisextender.paramsQP[levelQ])
+}
+
+// ModUpSplitQPMany extends the RNS basis of each polynomial of p1 from {Q0,Q1....QlevelQ} to
+// {Q0,Q1....QlevelQ,P0,P1...PlevelP} and returns the result in the polynomial of p2 with the same index.
+// It amortizes the lookup of the constants of the levels over the polynomials, e.g., of a ciphertext.
+func (basisextender *FastBasisExtender) ModUpSplitQPMany(levelQ, levelP int, p1, p2 []*Poly) {
+	params := basisextender.paramsQP[levelQ]
+	for i := range p1 {
+		basisextender.ringQ.countBasisExtension()
+		modUpExact(p1[i].Coeffs[:levelQ+1], p2[i].Coeffs[:levelP+1], params)
+	}
 }
 
 // ModUpSplitPQ extends the RNS basis of a polynomial from P to PQ.
 // Given a polynomial with coefficients in basis {P0,P1....Plevel},
 // it extends its basis from {P0,P1....Plevel} to {Q0,Q1...Qj}
 func (basisextender *FastBasisExtender) ModUpSplitPQ(level int, p1, p2 *Poly) {
-	modUpExact(p1.Coeffs[:level+1], p2.Coeffs[:len(basisextender.paramsPQ.P)], basisextender.paramsPQ)
+	basisextender.ModUpSplitPQLvl(level, basisextender.levelQ, p1, p2)
+}
+
+// ModUpSplitPQLvl extends the RNS basis of a polynomial from P to PQ.
+// Given a polynomial with coefficients in basis {P0,P1....PlevelP},
+// it extends its basis from {P0,P1....PlevelP} to {Q0,Q1...QlevelQ}
+func (basisextender *FastBasisExtender) ModUpSplitPQLvl(levelP, levelQ int, p1, p2 *Poly) {
+	basisextender.ringQ.countBasisExtension()
+	modUpExact(p1.Coeffs[:levelP+1], p2.Coeffs[:levelQ+1], basisextender.paramsPQ[levelP])
 }
 
 // ModDownNTTPQ reduces the basis RNS of a polynomial in the NTT domain
@@ -186,9 +245,11 @@ func (basisextender *FastBasisExtender) ModUpSplitPQ(level int, p1, p2 *Poly) {
 // Inputs must be in the NTT domain.
 func (basisextender *FastBasisExtender) ModDownNTTPQ(level int, p1, p2 *Poly) {
 
+	basisextender.ringQ.countBasisExtension()
+
 	ringQ := basisextender.ringQ
 	ringP := basisextender.ringP
-	modDownParams := basisextender.modDownParamsPQ
+	modDownParams := basisextender.modDownParamsPQ[basisextender.levelP]
 	polypool := basisextender.polypoolQ
 	nQi := len(ringQ.Modulus)
 	nPj := len(ringP.Modulus)
@@ -200,7 +261,7 @@ func (basisextender *FastBasisExtender) ModDownNTTPQ(level int, p1, p2 *Poly) {
 
 	// Then we target this P basis of p1 and convert it to a Q basis (at the "level" of p1) and copy it on polypool
 	// polypool is now the representation of the P basis of p1 but in basis Q (at the "level" of p1)
-	modUpExact(p1.Coeffs[nQi:nQi+nPj], polypool.Coeffs[:level+1], basisextender.paramsPQ)
+	modUpExact(p1.Coeffs[nQi:nQi+nPj], polypool.Coeffs[:level+1], basisextender.paramsPQ[basisextender.levelP])
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
 	for i := 0; i < level+1; i++ {
@@ -246,9 +307,11 @@ func (basisextender *FastBasisExtender) ModDownNTTPQ(level int, p1, p2 *Poly) {
 // Inputs must be in the NTT domain.
 func (basisextender *FastBasisExtender) ModDownSplitNTTPQ(level int, p1Q, p1P, p2 *Poly) {
 
+	basisextender.ringQ.countBasisExtension()
+
 	ringQ := basisextender.ringQ
 	ringP := basisextender.ringP
-	modDownParams := basisextender.modDownParamsPQ
+	modDownParams := basisextender.modDownParamsPQ[basisextender.levelP]
 	polypool := basisextender.polypoolQ
 
 	// First we get the P basis part of p1 out of the NTT domain
@@ -256,7 +319,7 @@ func (basisextender *FastBasisExtender) ModDownSplitNTTPQ(level int, p1Q, p1P, p
 
 	// Then we target this P basis of p1 and convert it to a Q basis (at the "level" of p1) and copy it on polypool
 	// polypool is now the representation of the P basis of p1 but in basis Q (at the "level" of p1)
-	modUpExact(p1P.Coeffs, polypool.Coeffs[:level+1], basisextender.paramsPQ)
+	modUpExact(p1P.Coeffs, polypool.Coeffs[:level+1], basisextender.paramsPQ[basisextender.levelP])
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
 	for i := 0; i < level+1; i++ {
@@ -301,14 +364,16 @@ func (basisextender *FastBasisExtender) ModDownSplitNTTPQ(level int, p1Q, p1P, p
 // and does a rounded integer division of the result by P.
 func (basisextender *FastBasisExtender) ModDownPQ(level int, p1, p2 *Poly) {
 
+	basisextender.ringQ.countBasisExtension()
+
 	ringQ := basisextender.ringQ
-	modDownParams := basisextender.modDownParamsPQ
+	modDownParams := basisextender.modDownParamsPQ[basisextender.levelP]
 	polypool := basisextender.polypoolQ
-	nPi := len(basisextender.paramsQP.P)
+	nPi := basisextender.levelP + 1
 
 	// We target this P basis of p1 and convert it to a Q basis (at the "level" of p1) and copy it on polypool
 	// polypool is now the representation of the P basis of p1 but in basis Q (at the "level" of p1)
-	modUpExact(p1.Coeffs[level+1:level+1+nPi], polypool.Coeffs[:level+1], basisextender.paramsPQ)
+	modUpExact(p1.Coeffs[level+1:level+1+nPi], polypool.Coeffs[:level+1], basisextender.paramsPQ[basisextender.levelP])
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
 	for i := 0; i < level+1; i++ {
@@ -348,13 +413,15 @@ func (basisextender *FastBasisExtender) ModDownPQ(level int, p1, p2 *Poly) {
 // and does a rounded integer division of the result by P.
 func (basisextender *FastBasisExtender) ModDownSplitPQ(level int, p1Q, p1P, p2 *Poly) {
 
+	basisextender.ringQ.countBasisExtension()
+
 	ringQ := basisextender.ringQ
-	modDownParams := basisextender.modDownParamsPQ
+	modDownParams := basisextender.modDownParamsPQ[basisextender.levelP]
 	polypool := basisextender.polypoolQ
 
 	// Then we target this P basis of p1 and convert it to a Q basis (at the "level" of p1) and copy it on polypool
 	// polypool is now the representation of the P basis of p1 but in basis Q (at the "level" of p1)
-	modUpExact(p1P.Coeffs, polypool.Coeffs[:level+1], basisextender.paramsPQ)
+	modUpExact(p1P.Coeffs, polypool.Coeffs[:level+1], basisextender.paramsPQ[basisextender.levelP])
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
 	for i := 0; i < level+1; i++ {
@@ -394,13 +461,14 @@ func (basisextender *FastBasisExtender) ModDownSplitPQ(level int, p1Q, p1P, p2 *
 // and does a floored integer division of the result by Q.
 func (basisextender *FastBasisExtender) ModDownSplitQP(levelQ, levelP int, p1Q, p1P, p2 *Poly) {
 
+	// Counted as one basis extension by ModUpSplitQP
 	ringP := basisextender.ringP
-	modDownParams := basisextender.modDownParamsQP
+	modDownParams := basisextender.modDownParamsQP[levelQ]
 	polypool := basisextender.polypoolP
 
 	// Then we target this P basis of p1 and convert it to a Q basis (at the "level" of p1) and copy it on polypool
 	// polypool is now the representation of the P basis of p1 but in basis Q (at the "level" of p1)
-	basisextender.ModUpSplitQP(levelQ, p1Q, polypool)
+	basisextender.ModUpSplitQPLvl(levelQ, levelP, p1Q, polypool)
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
 	for i := 0; i < levelP+1; i++ {
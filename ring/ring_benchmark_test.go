@@ -5,6 +5,8 @@ import (
 	"math/big"
 	"math/bits"
 	"testing"
+
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
 func BenchmarkRing(b *testing.B) {
@@ -153,6 +155,25 @@ func benchNTT(testContext *testParams, b *testing.B) {
 		}
 	})
 
+	polys := make([]*Poly, 8)
+	for i := range polys {
+		polys[i] = testContext.uniformSamplerQ.ReadNew()
+	}
+
+	b.Run(testString("NTT/NTT/Loop/polys=8/", testContext.ringQ), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, p := range polys {
+				testContext.ringQ.NTT(p, p)
+			}
+		}
+	})
+
+	b.Run(testString("NTT/NTTBatch/polys=8/", testContext.ringQ), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			testContext.ringQ.NTTBatch(polys)
+		}
+	})
+
 	b.Run(testString("NTT/NTT/Barrett/", testContext.ringQ), func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			testContext.ringQ.NTTBarrett(p, p)
@@ -166,6 +187,60 @@ func benchNTT(testContext *testParams, b *testing.B) {
 	})
 }
 
+// BenchmarkNTTIFMA compares the AVX-512 IFMA kernels of the NTT and inverse NTT with the pure Go kernels, on 8
+// moduli of 40 to 50 bits. It is skipped if the CPU does not support AVX-512 IFMA.
+func BenchmarkNTTIFMA(b *testing.B) {
+
+	if !hasIFMA {
+		b.Skip("AVX-512 IFMA is not supported by the CPU")
+	}
+
+	prng, _ := utils.NewPRNG()
+
+	for _, logN := range []int{12, 13, 14, 15} {
+
+		var moduli []uint64
+		for _, logQ := range []int{40, 45, 49, 50} {
+			moduli = append(moduli, GenerateNTTPrimes(logQ, 2<<logN, 2)...)
+		}
+
+		ringQ, err := NewRing(1<<logN, moduli)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		p := NewUniformSampler(prng, ringQ).ReadNew()
+
+		b.Run(testString("PureGo/NTT/", ringQ), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for x := range ringQ.Modulus {
+					NTT(p.Coeffs[x], p.Coeffs[x], ringQ.N, ringQ.NttPsi[x], ringQ.Modulus[x], ringQ.MredParams[x], ringQ.BredParams[x])
+				}
+			}
+		})
+
+		b.Run(testString("IFMA/NTT/", ringQ), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ringQ.NTT(p, p)
+			}
+		})
+
+		b.Run(testString("PureGo/InvNTT/", ringQ), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for x := range ringQ.Modulus {
+					InvNTT(p.Coeffs[x], p.Coeffs[x], ringQ.N, ringQ.NttPsiInv[x], ringQ.NttNInv[x], ringQ.Modulus[x], ringQ.MredParams[x])
+				}
+			}
+		})
+
+		b.Run(testString("IFMA/InvNTT/", ringQ), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ringQ.InvNTT(p, p)
+			}
+		})
+	}
+}
+
 func benchMulCoeffs(testContext *testParams, b *testing.B) {
 
 	p0 := testContext.uniformSamplerQ.ReadNew()
@@ -287,6 +362,15 @@ func benchExtendBasis(testContext *testParams, b *testing.B) {
 		}
 	})
 
+	b.Run(fmt.Sprintf("ExtendBasis/ModUpMany/N=%d/limbsQ=%d/limbsP=%d", testContext.ringQ.N, len(testContext.ringQ.Modulus), len(testContext.ringP.Modulus)), func(b *testing.B) {
+		polsQ := []*Poly{p0, p0.CopyNew()}
+		polsP := []*Poly{p1, p1.CopyNew()}
+		levelP := len(testContext.ringP.Modulus) - 1
+		for i := 0; i < b.N; i++ {
+			basisExtender.ModUpSplitQPMany(level, levelP, polsQ, polsP)
+		}
+	})
+
 	b.Run(fmt.Sprintf("ExtendBasis/ModDown/N=%d/limbsQ=%d/limbsP=%d", testContext.ringQ.N, len(testContext.ringQ.Modulus), len(testContext.ringP.Modulus)), func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			basisExtender.ModDownSplitPQ(level, p0, p1, p0)
@@ -5,68 +5,145 @@ import (
 	"unsafe"
 )
 
+// NTTOrdering specifies the order in which the coefficients of a polynomial in the NTT domain are laid out.
+type NTTOrdering uint8
+
+const (
+	// NTTBitReversed is the native ordering of the package: the j-th coefficient is the evaluation of the
+	// polynomial at psi^(2*bitrev(j)+1), where psi is the 2N-th primitive root of unity of the Ring.
+	NTTBitReversed NTTOrdering = iota
+	// NTTNatural is the ordering in which the j-th coefficient is the evaluation of the polynomial
+	// at psi^(2j+1), as produced by NTT implementations that bit-reverse their output.
+	NTTNatural
+)
+
+// IsValid returns true if the ordering is a known NTTOrdering.
+func (ordering NTTOrdering) IsValid() bool {
+	return ordering == NTTBitReversed || ordering == NTTNatural
+}
+
+// PermuteNTTOrdering permutes the coefficients of p1, which is in the NTT domain with the ordering from,
+// into the ordering to and writes the result on p2. It can safely be used in place.
+func (r *Ring) PermuteNTTOrdering(p1 *Poly, from, to NTTOrdering, p2 *Poly) {
+	if from == to {
+		if p1 != p2 {
+			r.Copy(p1, p2)
+		}
+		return
+	}
+	r.BitReverse(p1, p2)
+}
+
 // NTT computes the NTT of p1 and returns the result on p2.
 func (r *Ring) NTT(p1, p2 *Poly) {
-	for x := range r.Modulus {
-		NTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
-	}
+	r.NTTLvl(len(r.Modulus)-1, p1, p2)
 }
 
 // NTTLvl computes the NTT of p1 and returns the result on p2.
 // The value level defines the number of moduli of the input polynomials.
 func (r *Ring) NTTLvl(level int, p1, p2 *Poly) {
+	r.countNTT(level + 1)
 	for x := 0; x < level+1; x++ {
-		NTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+		r.ntt(x, p1.Coeffs[x], p2.Coeffs[x])
 	}
 }
 
 // InvNTT computes the inverse-NTT of p1 and returns the result on p2.
 func (r *Ring) InvNTT(p1, p2 *Poly) {
-	for x := range r.Modulus {
-		InvNTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
-	}
+	r.InvNTTLvl(len(r.Modulus)-1, p1, p2)
 }
 
 // InvNTTLvl computes the inverse-NTT of p1 and returns the result on p2.
 // The value level defines the number of moduli of the input polynomials.
 func (r *Ring) InvNTTLvl(level int, p1, p2 *Poly) {
+	r.countInvNTT(level + 1)
 	for x := 0; x < level+1; x++ {
-		InvNTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+		r.invNTT(x, p1.Coeffs[x], p2.Coeffs[x])
 	}
 }
 
-// NTTLazy computes the NTT of p1 and returns the result on p2.
-// Output values are in the range [0, 2q-1]
+// NTTLazy computes the NTT of p1 and returns the result on p2, in the lazy representation.
+//
+// In the lazy representation, a coefficient modulo q is stored as any value of the range [0, 2q-1] congruent to it
+// modulo q. The *Lazy methods (NTTLazy, InvNTTLazy, AddLazy, SubLazy, MulCoeffsMontgomeryLazy and
+// MulCoeffsMontgomeryAndAddLazy, and their Lvl variants) accept inputs in this representation and return their
+// outputs in it, so that they can be chained in any number without an intermediate Reduce. The exact
+// representation in [0, q-1] is recovered with Reduce. The moduli must be at most 61 bits.
+//
+// Input values must be in the range [0, 2q-1] and output values are in the range [0, 2q-1].
 func (r *Ring) NTTLazy(p1, p2 *Poly) {
+	r.countNTT(len(r.Modulus))
 	for x := range r.Modulus {
-		NTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+		r.nttLazy(x, p1.Coeffs[x], p2.Coeffs[x])
 	}
 }
 
-// NTTLazyLvl computes the NTT of p1 and returns the result on p2.
+// NTTLazyLvl computes the NTT of p1 and returns the result on p2, in the lazy representation (see NTTLazy).
 // The value level defines the number of moduli of the input polynomials.
-// Output values are in the range [0, 2q-1]
+// Input values must be in the range [0, 2q-1] and output values are in the range [0, 2q-1].
 func (r *Ring) NTTLazyLvl(level int, p1, p2 *Poly) {
+	r.countNTT(level + 1)
 	for x := 0; x < level+1; x++ {
-		NTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+		r.nttLazy(x, p1.Coeffs[x], p2.Coeffs[x])
 	}
 }
 
-// InvNTTLazy computes the inverse-NTT of p1 and returns the result on p2.
-// Output values are in the range [0, 2q-1]
+// InvNTTLazy computes the inverse-NTT of p1 and returns the result on p2, in the lazy representation (see NTTLazy).
+// Input values must be in the range [0, 2q-1] and output values are in the range [0, 2q-1].
 func (r *Ring) InvNTTLazy(p1, p2 *Poly) {
+	r.countInvNTT(len(r.Modulus))
 	for x := range r.Modulus {
-		InvNTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+		r.invNTTLazy(x, p1.Coeffs[x], p2.Coeffs[x])
 	}
 }
 
-// InvNTTLazyLvl computes the inverse-NTT of p1 and returns the result on p2.
-// The value level defines the number of moduli of the input polynomials.
-// Output values are in the range [0, 2q-1]
+// InvNTTLazyLvl computes the inverse-NTT of p1 and returns the result on p2, in the lazy representation (see
+// NTTLazy). The value level defines the number of moduli of the input polynomials.
+// Input values must be in the range [0, 2q-1] and output values are in the range [0, 2q-1].
 func (r *Ring) InvNTTLazyLvl(level int, p1, p2 *Poly) {
+	r.countInvNTT(level + 1)
 	for x := 0; x < level+1; x++ {
-		InvNTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+		r.invNTTLazy(x, p1.Coeffs[x], p2.Coeffs[x])
+	}
+}
+
+// ntt computes the NTT of the coefficients modulo the x-th modulus, with the AVX-512 IFMA kernels if they support it.
+func (r *Ring) ntt(x int, coeffsIn, coeffsOut []uint64) {
+	if r.nttIFMA != nil && r.nttIFMA[x] != nil {
+		nttIFMA(coeffsIn, coeffsOut, r.N, r.nttIFMA[x], r.Modulus[x])
+		return
+	}
+	NTT(coeffsIn, coeffsOut, r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+}
+
+// nttLazy computes the NTT of the coefficients modulo the x-th modulus in the lazy representation, with the AVX-512
+// IFMA kernels if they support it (their output is in [0, q-1]).
+func (r *Ring) nttLazy(x int, coeffsIn, coeffsOut []uint64) {
+	if r.nttIFMA != nil && r.nttIFMA[x] != nil {
+		nttIFMA(coeffsIn, coeffsOut, r.N, r.nttIFMA[x], r.Modulus[x])
+		return
 	}
+	NTTLazy(coeffsIn, coeffsOut, r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+}
+
+// invNTT computes the inverse-NTT of the coefficients modulo the x-th modulus, with the AVX-512 IFMA kernels if they
+// support it.
+func (r *Ring) invNTT(x int, coeffsIn, coeffsOut []uint64) {
+	if r.nttIFMA != nil && r.nttIFMA[x] != nil {
+		invNTTIFMA(coeffsIn, coeffsOut, r.N, r.nttIFMA[x], r.Modulus[x])
+		return
+	}
+	InvNTT(coeffsIn, coeffsOut, r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+}
+
+// invNTTLazy computes the inverse-NTT of the coefficients modulo the x-th modulus in the lazy representation, with
+// the AVX-512 IFMA kernels if they support it (their output is in [0, q-1]).
+func (r *Ring) invNTTLazy(x int, coeffsIn, coeffsOut []uint64) {
+	if r.nttIFMA != nil && r.nttIFMA[x] != nil {
+		invNTTIFMA(coeffsIn, coeffsOut, r.N, r.nttIFMA[x], r.Modulus[x])
+		return
+	}
+	InvNTTLazy(coeffsIn, coeffsOut, r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
 }
 
 // butterfly computes X, Y = U + V*Psi, U - V*Psi mod Q.
@@ -81,7 +158,7 @@ func butterfly(U, V, Psi, twoQ, fourQ, Q, Qinv uint64) (uint64, uint64) {
 // NTT computes the NTT on the input coefficients using the input parameters.
 func NTT(coeffsIn, coeffsOut []uint64, N int, nttPsi []uint64, Q, mredParams uint64, bredParams []uint64) {
 
-	NTTLazy(coeffsIn, coeffsOut, N, nttPsi, Q, mredParams, bredParams)
+	nttCore(coeffsIn, coeffsOut, N, nttPsi, Q, mredParams)
 	// Finish with an exact reduction
 	for i := 0; i < N; i = i + 8 {
 
@@ -98,8 +175,9 @@ func NTT(coeffsIn, coeffsOut []uint64, N int, nttPsi []uint64, Q, mredParams uin
 	}
 }
 
-// NTTLazy computes the NTT on the input coefficients using the input parameters with output values in the range [0, 2q-1].
-func NTTLazy(coeffsIn, coeffsOut []uint64, N int, nttPsi []uint64, Q, QInv uint64, bredParams []uint64) {
+// nttCore computes the butterflies of the NTT on the input coefficients, with input values in the range [0, 2q-1]
+// and output values in the range [0, 6q-1].
+func nttCore(coeffsIn, coeffsOut []uint64, N int, nttPsi []uint64, Q, QInv uint64) {
 	var j1, j2, t int
 	var F, V uint64
 
@@ -378,6 +456,29 @@ func NTTLazy(coeffsIn, coeffsOut []uint64, N int, nttPsi []uint64, Q, QInv uint6
 	}
 }
 
+// NTTLazy computes the NTT on the input coefficients using the input parameters with input and output values in the range [0, 2q-1].
+func NTTLazy(coeffsIn, coeffsOut []uint64, N int, nttPsi []uint64, Q, QInv uint64, bredParams []uint64) {
+
+	nttCore(coeffsIn, coeffsOut, N, nttPsi, Q, QInv)
+
+	// Finish with a lazy reduction from [0, 6q-1] to [0, 2q-1]
+	fourQ := 4 * Q
+	twoQ := 2 * Q
+	for i := 0; i < N; i = i + 8 {
+
+		x := (*[8]uint64)(unsafe.Pointer(&coeffsOut[i]))
+
+		x[0] = CRed(CRed(x[0], fourQ), twoQ)
+		x[1] = CRed(CRed(x[1], fourQ), twoQ)
+		x[2] = CRed(CRed(x[2], fourQ), twoQ)
+		x[3] = CRed(CRed(x[3], fourQ), twoQ)
+		x[4] = CRed(CRed(x[4], fourQ), twoQ)
+		x[5] = CRed(CRed(x[5], fourQ), twoQ)
+		x[6] = CRed(CRed(x[6], fourQ), twoQ)
+		x[7] = CRed(CRed(x[7], fourQ), twoQ)
+	}
+}
+
 // invbutterfly computes X, Y = U + V, (U - V) * Psi mod Q.
 func invbutterfly(U, V, Psi, twoQ, fourQ, Q, Qinv uint64) (X, Y uint64) {
 	X = U + V
@@ -507,7 +608,7 @@ func InvNTT(coeffsIn, coeffsOut []uint64, N int, nttPsiInv []uint64, nttNInv, Q,
 	}
 }
 
-// InvNTTLazy computes the InvNTT transformation on the input coefficients using the input parameters with output values in the range [0, 2q-1].
+// InvNTTLazy computes the InvNTT transformation on the input coefficients using the input parameters with input and output values in the range [0, 2q-1].
 func InvNTTLazy(coeffsIn, coeffsOut []uint64, N int, nttPsiInv []uint64, nttNInv, Q, mredParams uint64) {
 
 	var j1, j2, h, t int
@@ -0,0 +1,41 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package ring
+
+import (
+	"golang.org/x/sys/cpu"
+)
+
+// hasIFMA enables the AVX-512 IFMA kernels of the NTT and inverse NTT for the moduli smaller than nttIFMAMaxModulus.
+// They require the AVX-512 Foundation and Integer Fused Multiply-Add instructions.
+var hasIFMA = cpu.X86.HasAVX512F && cpu.X86.HasAVX512IFMA
+
+// nttIFMAStage evaluates the butterflies of the stage of the NTT with m groups of t >= 8 butterflies, reading
+// the coefficients from in and writing them on out, with the twiddle factors w[0:m] and their Shoup quotients.
+//
+//go:noescape
+func nttIFMAStage(in, out *uint64, m, t int, w, wShoup *uint64, q uint64)
+
+// nttIFMATail evaluates in place the three last stages of the NTT (t = 4, 2, 1) on n blocks of 16 coefficients
+// and reduces the result to [0, q-1].
+//
+//go:noescape
+func nttIFMATail(a *uint64, n int, w4, w4Shoup, w2, w2Shoup, w1, w1Shoup *uint64, q uint64)
+
+// invNTTIFMAHead evaluates the three first stages of the inverse NTT (t = 1, 2, 4) on n blocks of 16 coefficients,
+// reading the coefficients from in and writing them on out.
+//
+//go:noescape
+func invNTTIFMAHead(in, out *uint64, n int, w1, w1Shoup, w2, w2Shoup, w4, w4Shoup *uint64, q uint64)
+
+// invNTTIFMAStage evaluates in place the butterflies of the stage of the inverse NTT with h groups of t >= 8
+// butterflies, with the twiddle factors w[0:h] and their Shoup quotients.
+//
+//go:noescape
+func invNTTIFMAStage(a *uint64, h, t int, w, wShoup *uint64, q uint64)
+
+// invNTTIFMAScale multiplies in place the n coefficients of a by N^-1 and reduces them to [0, q-1].
+//
+//go:noescape
+func invNTTIFMAScale(a *uint64, n int, nInv, nInvShoup, q uint64)
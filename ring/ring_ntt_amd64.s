@@ -0,0 +1,425 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+#include "textflag.h"
+
+// The kernels of this file evaluate the butterflies of the NTT and inverse NTT with the AVX-512 IFMA instructions
+// VPMADD52LUQ and VPMADD52HUQ, which multiply the 52 low bits of each lane. A product W*Y mod q is computed with
+// the Shoup quotient W' = floor(W*2^52/q) as W*Y - floor(W'*Y/2^52)*q in [0, 2q-1], which requires Y < 2^52.
+// The values are kept in [0, 4q-1], hence q < 2^50.
+//
+// Registers used by all the kernels:
+// Z0 = q, Z1 = 2q, Z2 = 2^52 - q, Z3 = 2^52 - 1.
+
+#define IFMA_CONSTANTS(Q) \
+	VPBROADCASTQ Q, Z0; \
+	LEAQ         (Q)(Q*1), BX; \
+	VPBROADCASTQ BX, Z1; \
+	MOVQ         $0x10000000000000, BX; \
+	SUBQ         Q, BX; \
+	VPBROADCASTQ BX, Z2; \
+	MOVQ         $0xfffffffffffff, BX; \
+	VPBROADCASTQ BX, Z3
+
+// MULSHOUP sets R = A * W mod q in [0, 2q-1] for A < 2^52, with WS the Shoup quotient of W. T is clobbered.
+#define MULSHOUP(A, W, WS, R, T) \
+	VPXORQ      T, T, T; \
+	VPMADD52HUQ A, WS, T; \
+	VPXORQ      R, R, R; \
+	VPMADD52LUQ A, W, R; \
+	VPMADD52LUQ T, Z2, R; \
+	VPANDQ      Z3, R, R
+
+// FWD_BUTTERFLY sets X, Y = X + Y*W, X - Y*W + 2q mod q for X, Y in [0, 4q-1], with outputs in [0, 4q-1].
+#define FWD_BUTTERFLY(X, Y, W, WS, T0, T1) \
+	VPSUBQ  Z1, X, T0; \
+	VPMINUQ T0, X, X; \
+	MULSHOUP(Y, W, WS, T1, T0); \
+	VPSUBQ  T1, X, Y; \
+	VPADDQ  Z1, Y, Y; \
+	VPADDQ  T1, X, X
+
+// INV_BUTTERFLY sets X, Y = X + Y, (X - Y + 2q)*W mod q for X, Y in [0, 2q-1], with outputs in [0, 2q-1].
+#define INV_BUTTERFLY(X, Y, W, WS, T0, T1) \
+	VPADDQ  Z1, X, T0; \
+	VPSUBQ  Y, T0, T0; \
+	VPADDQ  Y, X, X; \
+	VPSUBQ  Z1, X, Y; \
+	VPMINUQ Y, X, X; \
+	MULSHOUP(T0, W, WS, Y, T1)
+
+// REDUCE4Q reduces X from [0, 4q-1] to [0, q-1]. T is clobbered.
+#define REDUCE4Q(X, T) \
+	VPSUBQ  Z1, X, T; \
+	VPMINUQ T, X, X; \
+	VPSUBQ  Z0, X, T; \
+	VPMINUQ T, X, X
+
+// PERMUTE sets X, Y to the permutations of the lanes of A (indices 0 to 7) and B (indices 8 to 15) given by
+// the indices IX and IY.
+#define PERMUTE(A, B, IX, IY, X, Y) \
+	VMOVDQA64 A, X; \
+	VPERMT2Q  B, IX, X; \
+	VMOVDQA64 A, Y; \
+	VPERMT2Q  B, IY, Y
+
+// Indices of VPERMT2Q that split two vectors A = a[0:8] and B = a[8:16] in the first and second halves of
+// the butterflies of the stages t = 4, 2, 1 of the NTT, and to go from one stage to the next. Because these
+// permutations are involutions up to the stage order, the inverse NTT uses the same indices in reverse order.
+DATA permT4<>+0x00(SB)/8, $0
+DATA permT4<>+0x08(SB)/8, $1
+DATA permT4<>+0x10(SB)/8, $2
+DATA permT4<>+0x18(SB)/8, $3
+DATA permT4<>+0x20(SB)/8, $8
+DATA permT4<>+0x28(SB)/8, $9
+DATA permT4<>+0x30(SB)/8, $10
+DATA permT4<>+0x38(SB)/8, $11
+DATA permT4<>+0x40(SB)/8, $4
+DATA permT4<>+0x48(SB)/8, $5
+DATA permT4<>+0x50(SB)/8, $6
+DATA permT4<>+0x58(SB)/8, $7
+DATA permT4<>+0x60(SB)/8, $12
+DATA permT4<>+0x68(SB)/8, $13
+DATA permT4<>+0x70(SB)/8, $14
+DATA permT4<>+0x78(SB)/8, $15
+GLOBL permT4<>(SB), RODATA|NOPTR, $128
+
+DATA permT4T2<>+0x00(SB)/8, $0
+DATA permT4T2<>+0x08(SB)/8, $1
+DATA permT4T2<>+0x10(SB)/8, $8
+DATA permT4T2<>+0x18(SB)/8, $9
+DATA permT4T2<>+0x20(SB)/8, $4
+DATA permT4T2<>+0x28(SB)/8, $5
+DATA permT4T2<>+0x30(SB)/8, $12
+DATA permT4T2<>+0x38(SB)/8, $13
+DATA permT4T2<>+0x40(SB)/8, $2
+DATA permT4T2<>+0x48(SB)/8, $3
+DATA permT4T2<>+0x50(SB)/8, $10
+DATA permT4T2<>+0x58(SB)/8, $11
+DATA permT4T2<>+0x60(SB)/8, $6
+DATA permT4T2<>+0x68(SB)/8, $7
+DATA permT4T2<>+0x70(SB)/8, $14
+DATA permT4T2<>+0x78(SB)/8, $15
+GLOBL permT4T2<>(SB), RODATA|NOPTR, $128
+
+DATA permT2T1<>+0x00(SB)/8, $0
+DATA permT2T1<>+0x08(SB)/8, $8
+DATA permT2T1<>+0x10(SB)/8, $2
+DATA permT2T1<>+0x18(SB)/8, $10
+DATA permT2T1<>+0x20(SB)/8, $4
+DATA permT2T1<>+0x28(SB)/8, $12
+DATA permT2T1<>+0x30(SB)/8, $6
+DATA permT2T1<>+0x38(SB)/8, $14
+DATA permT2T1<>+0x40(SB)/8, $1
+DATA permT2T1<>+0x48(SB)/8, $9
+DATA permT2T1<>+0x50(SB)/8, $3
+DATA permT2T1<>+0x58(SB)/8, $11
+DATA permT2T1<>+0x60(SB)/8, $5
+DATA permT2T1<>+0x68(SB)/8, $13
+DATA permT2T1<>+0x70(SB)/8, $7
+DATA permT2T1<>+0x78(SB)/8, $15
+GLOBL permT2T1<>(SB), RODATA|NOPTR, $128
+
+DATA permT1<>+0x00(SB)/8, $0
+DATA permT1<>+0x08(SB)/8, $2
+DATA permT1<>+0x10(SB)/8, $4
+DATA permT1<>+0x18(SB)/8, $6
+DATA permT1<>+0x20(SB)/8, $8
+DATA permT1<>+0x28(SB)/8, $10
+DATA permT1<>+0x30(SB)/8, $12
+DATA permT1<>+0x38(SB)/8, $14
+DATA permT1<>+0x40(SB)/8, $1
+DATA permT1<>+0x48(SB)/8, $3
+DATA permT1<>+0x50(SB)/8, $5
+DATA permT1<>+0x58(SB)/8, $7
+DATA permT1<>+0x60(SB)/8, $9
+DATA permT1<>+0x68(SB)/8, $11
+DATA permT1<>+0x70(SB)/8, $13
+DATA permT1<>+0x78(SB)/8, $15
+GLOBL permT1<>(SB), RODATA|NOPTR, $128
+
+DATA permT1Merge<>+0x00(SB)/8, $0
+DATA permT1Merge<>+0x08(SB)/8, $8
+DATA permT1Merge<>+0x10(SB)/8, $1
+DATA permT1Merge<>+0x18(SB)/8, $9
+DATA permT1Merge<>+0x20(SB)/8, $2
+DATA permT1Merge<>+0x28(SB)/8, $10
+DATA permT1Merge<>+0x30(SB)/8, $3
+DATA permT1Merge<>+0x38(SB)/8, $11
+DATA permT1Merge<>+0x40(SB)/8, $4
+DATA permT1Merge<>+0x48(SB)/8, $12
+DATA permT1Merge<>+0x50(SB)/8, $5
+DATA permT1Merge<>+0x58(SB)/8, $13
+DATA permT1Merge<>+0x60(SB)/8, $6
+DATA permT1Merge<>+0x68(SB)/8, $14
+DATA permT1Merge<>+0x70(SB)/8, $7
+DATA permT1Merge<>+0x78(SB)/8, $15
+GLOBL permT1Merge<>(SB), RODATA|NOPTR, $128
+
+// Indices of VPERMQ that expand the twiddle factors of the stages t = 4 and t = 2 to one per lane.
+DATA expandT4<>+0x00(SB)/8, $0
+DATA expandT4<>+0x08(SB)/8, $0
+DATA expandT4<>+0x10(SB)/8, $0
+DATA expandT4<>+0x18(SB)/8, $0
+DATA expandT4<>+0x20(SB)/8, $1
+DATA expandT4<>+0x28(SB)/8, $1
+DATA expandT4<>+0x30(SB)/8, $1
+DATA expandT4<>+0x38(SB)/8, $1
+GLOBL expandT4<>(SB), RODATA|NOPTR, $64
+
+DATA expandT2<>+0x00(SB)/8, $0
+DATA expandT2<>+0x08(SB)/8, $0
+DATA expandT2<>+0x10(SB)/8, $1
+DATA expandT2<>+0x18(SB)/8, $1
+DATA expandT2<>+0x20(SB)/8, $2
+DATA expandT2<>+0x28(SB)/8, $2
+DATA expandT2<>+0x30(SB)/8, $3
+DATA expandT2<>+0x38(SB)/8, $3
+GLOBL expandT2<>(SB), RODATA|NOPTR, $64
+
+// func nttIFMAStage(in, out *uint64, m, t int, w, wShoup *uint64, q uint64)
+TEXT ·nttIFMAStage(SB), NOSPLIT, $0-56
+	MOVQ in+0(FP), SI
+	MOVQ out+8(FP), DI
+	MOVQ m+16(FP), CX
+	MOVQ t+24(FP), DX
+	MOVQ w+32(FP), R8
+	MOVQ wShoup+40(FP), R9
+	MOVQ q+48(FP), AX
+
+	IFMA_CONSTANTS(AX)
+
+	SHLQ $3, DX
+
+nttStageGroup:
+	VPBROADCASTQ (R8), Z4
+	VPBROADCASTQ (R9), Z5
+	LEAQ         (SI)(DX*1), R10
+	LEAQ         (DI)(DX*1), R11
+	MOVQ         DX, BX
+
+nttStageLoop:
+	VMOVDQU64 (SI), Z6
+	VMOVDQU64 (R10), Z7
+	FWD_BUTTERFLY(Z6, Z7, Z4, Z5, Z8, Z9)
+	VMOVDQU64 Z6, (DI)
+	VMOVDQU64 Z7, (R11)
+	ADDQ      $64, SI
+	ADDQ      $64, DI
+	ADDQ      $64, R10
+	ADDQ      $64, R11
+	SUBQ      $64, BX
+	JNZ       nttStageLoop
+
+	ADDQ DX, SI
+	ADDQ DX, DI
+	ADDQ $8, R8
+	ADDQ $8, R9
+	DECQ CX
+	JNZ  nttStageGroup
+
+	VZEROUPPER
+	RET
+
+// func nttIFMATail(a *uint64, n int, w4, w4Shoup, w2, w2Shoup, w1, w1Shoup *uint64, q uint64)
+TEXT ·nttIFMATail(SB), NOSPLIT, $0-72
+	MOVQ a+0(FP), DI
+	MOVQ n+8(FP), CX
+	MOVQ w4+16(FP), R8
+	MOVQ w4Shoup+24(FP), R9
+	MOVQ w2+32(FP), R10
+	MOVQ w2Shoup+40(FP), R11
+	MOVQ w1+48(FP), R12
+	MOVQ w1Shoup+56(FP), R13
+	MOVQ q+64(FP), AX
+
+	IFMA_CONSTANTS(AX)
+
+	VMOVDQU64 permT4<>+0x00(SB), Z10
+	VMOVDQU64 permT4<>+0x40(SB), Z11
+	VMOVDQU64 permT4T2<>+0x00(SB), Z12
+	VMOVDQU64 permT4T2<>+0x40(SB), Z13
+	VMOVDQU64 permT2T1<>+0x00(SB), Z14
+	VMOVDQU64 permT2T1<>+0x40(SB), Z15
+	VMOVDQU64 permT1Merge<>+0x00(SB), Z16
+	VMOVDQU64 permT1Merge<>+0x40(SB), Z17
+	VMOVDQU64 expandT4<>(SB), Z18
+	VMOVDQU64 expandT2<>(SB), Z19
+
+nttTailLoop:
+	VMOVDQU64 (DI), Z4
+	VMOVDQU64 64(DI), Z5
+
+	// t = 4
+	PERMUTE(Z4, Z5, Z10, Z11, Z6, Z7)
+	VPERMQ        (R8), Z18, Z8
+	VPERMQ        (R9), Z18, Z9
+	FWD_BUTTERFLY(Z6, Z7, Z8, Z9, Z20, Z21)
+
+	// t = 2
+	PERMUTE(Z6, Z7, Z12, Z13, Z4, Z5)
+	VPERMQ        (R10), Z19, Z8
+	VPERMQ        (R11), Z19, Z9
+	FWD_BUTTERFLY(Z4, Z5, Z8, Z9, Z20, Z21)
+
+	// t = 1
+	PERMUTE(Z4, Z5, Z14, Z15, Z6, Z7)
+	VMOVDQU64     (R12), Z8
+	VMOVDQU64     (R13), Z9
+	FWD_BUTTERFLY(Z6, Z7, Z8, Z9, Z20, Z21)
+
+	REDUCE4Q(Z6, Z20)
+	REDUCE4Q(Z7, Z21)
+
+	PERMUTE(Z6, Z7, Z16, Z17, Z4, Z5)
+	VMOVDQU64 Z4, (DI)
+	VMOVDQU64 Z5, 64(DI)
+
+	ADDQ $128, DI
+	ADDQ $16, R8
+	ADDQ $16, R9
+	ADDQ $32, R10
+	ADDQ $32, R11
+	ADDQ $64, R12
+	ADDQ $64, R13
+	DECQ CX
+	JNZ  nttTailLoop
+
+	VZEROUPPER
+	RET
+
+// func invNTTIFMAHead(in, out *uint64, n int, w1, w1Shoup, w2, w2Shoup, w4, w4Shoup *uint64, q uint64)
+TEXT ·invNTTIFMAHead(SB), NOSPLIT, $0-80
+	MOVQ in+0(FP), SI
+	MOVQ out+8(FP), DI
+	MOVQ n+16(FP), CX
+	MOVQ w1+24(FP), R8
+	MOVQ w1Shoup+32(FP), R9
+	MOVQ w2+40(FP), R10
+	MOVQ w2Shoup+48(FP), R11
+	MOVQ w4+56(FP), R12
+	MOVQ w4Shoup+64(FP), R13
+	MOVQ q+72(FP), AX
+
+	IFMA_CONSTANTS(AX)
+
+	VMOVDQU64 permT1<>+0x00(SB), Z10
+	VMOVDQU64 permT1<>+0x40(SB), Z11
+	VMOVDQU64 permT2T1<>+0x00(SB), Z12
+	VMOVDQU64 permT2T1<>+0x40(SB), Z13
+	VMOVDQU64 permT4T2<>+0x00(SB), Z14
+	VMOVDQU64 permT4T2<>+0x40(SB), Z15
+	VMOVDQU64 permT4<>+0x00(SB), Z16
+	VMOVDQU64 permT4<>+0x40(SB), Z17
+	VMOVDQU64 expandT2<>(SB), Z18
+	VMOVDQU64 expandT4<>(SB), Z19
+
+invNTTHeadLoop:
+	VMOVDQU64 (SI), Z4
+	VMOVDQU64 64(SI), Z5
+
+	// t = 1
+	PERMUTE(Z4, Z5, Z10, Z11, Z6, Z7)
+	VMOVDQU64     (R8), Z8
+	VMOVDQU64     (R9), Z9
+	INV_BUTTERFLY(Z6, Z7, Z8, Z9, Z20, Z21)
+
+	// t = 2
+	PERMUTE(Z6, Z7, Z12, Z13, Z4, Z5)
+	VPERMQ        (R10), Z18, Z8
+	VPERMQ        (R11), Z18, Z9
+	INV_BUTTERFLY(Z4, Z5, Z8, Z9, Z20, Z21)
+
+	// t = 4
+	PERMUTE(Z4, Z5, Z14, Z15, Z6, Z7)
+	VPERMQ        (R12), Z19, Z8
+	VPERMQ        (R13), Z19, Z9
+	INV_BUTTERFLY(Z6, Z7, Z8, Z9, Z20, Z21)
+
+	PERMUTE(Z6, Z7, Z16, Z17, Z4, Z5)
+	VMOVDQU64 Z4, (DI)
+	VMOVDQU64 Z5, 64(DI)
+
+	ADDQ $128, SI
+	ADDQ $128, DI
+	ADDQ $64, R8
+	ADDQ $64, R9
+	ADDQ $32, R10
+	ADDQ $32, R11
+	ADDQ $16, R12
+	ADDQ $16, R13
+	DECQ CX
+	JNZ  invNTTHeadLoop
+
+	VZEROUPPER
+	RET
+
+// func invNTTIFMAStage(a *uint64, h, t int, w, wShoup *uint64, q uint64)
+TEXT ·invNTTIFMAStage(SB), NOSPLIT, $0-48
+	MOVQ a+0(FP), DI
+	MOVQ h+8(FP), CX
+	MOVQ t+16(FP), DX
+	MOVQ w+24(FP), R8
+	MOVQ wShoup+32(FP), R9
+	MOVQ q+40(FP), AX
+
+	IFMA_CONSTANTS(AX)
+
+	SHLQ $3, DX
+
+invNTTStageGroup:
+	VPBROADCASTQ (R8), Z4
+	VPBROADCASTQ (R9), Z5
+	LEAQ         (DI)(DX*1), R10
+	MOVQ         DX, BX
+
+invNTTStageLoop:
+	VMOVDQU64 (DI), Z6
+	VMOVDQU64 (R10), Z7
+	INV_BUTTERFLY(Z6, Z7, Z4, Z5, Z8, Z9)
+	VMOVDQU64 Z6, (DI)
+	VMOVDQU64 Z7, (R10)
+	ADDQ      $64, DI
+	ADDQ      $64, R10
+	SUBQ      $64, BX
+	JNZ       invNTTStageLoop
+
+	ADDQ DX, DI
+	ADDQ $8, R8
+	ADDQ $8, R9
+	DECQ CX
+	JNZ  invNTTStageGroup
+
+	VZEROUPPER
+	RET
+
+// func invNTTIFMAScale(a *uint64, n int, nInv, nInvShoup, q uint64)
+TEXT ·invNTTIFMAScale(SB), NOSPLIT, $0-40
+	MOVQ a+0(FP), DI
+	MOVQ n+8(FP), CX
+	MOVQ nInv+16(FP), R8
+	MOVQ nInvShoup+24(FP), R9
+	MOVQ q+32(FP), AX
+
+	IFMA_CONSTANTS(AX)
+
+	VPBROADCASTQ R8, Z4
+	VPBROADCASTQ R9, Z5
+
+invNTTScaleLoop:
+	VMOVDQU64 (DI), Z6
+	VMOVDQU64 64(DI), Z10
+	MULSHOUP(Z6, Z4, Z5, Z7, Z8)
+	MULSHOUP(Z10, Z4, Z5, Z11, Z12)
+	VPSUBQ    Z0, Z7, Z8
+	VPMINUQ   Z8, Z7, Z7
+	VPSUBQ    Z0, Z11, Z12
+	VPMINUQ   Z12, Z11, Z11
+	VMOVDQU64 Z7, (DI)
+	VMOVDQU64 Z11, 64(DI)
+	ADDQ      $128, DI
+	SUBQ      $16, CX
+	JNZ       invNTTScaleLoop
+
+	VZEROUPPER
+	RET
@@ -0,0 +1,87 @@
+package ring
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// nttBatchMinCoeffs is the number of coefficients per goroutine below which NTTBatchLvl and InvNTTBatchLvl do not
+// spawn goroutines, since the transforms would not amortize their cost.
+const nttBatchMinCoeffs = 1 << 15
+
+// nttBatchMaxWorkers is the maximum number of goroutines spawned by NTTBatchLvl and InvNTTBatchLvl.
+var nttBatchMaxWorkers = runtime.NumCPU()
+
+// NTTBatch computes in place the NTT of each polynomial of polys. See NTTBatchLvl.
+func (r *Ring) NTTBatch(polys []*Poly) {
+	r.NTTBatchLvl(len(r.Modulus)-1, polys)
+}
+
+// NTTBatchLvl computes in place the NTT of each polynomial of polys, e.g. of the polynomials of a ciphertext or of a
+// key. The value level defines the number of moduli of the input polynomials.
+// The transforms are carried out modulus by modulus, i.e. the residues of all the polynomials modulo a given modulus
+// are transformed one after the other while its twiddle factors are in cache, and are distributed among up to
+// runtime.NumCPU() goroutines if there are enough of them. The polynomials must not share their coefficients: the
+// method panics if two of them do.
+// The result is the same as calling NTTLvl on each polynomial.
+func (r *Ring) NTTBatchLvl(level int, polys []*Poly) {
+	r.countNTT((level + 1) * len(polys))
+	r.batchLvl(level, polys, func(x int, coeffs []uint64) {
+		r.ntt(x, coeffs, coeffs)
+	})
+}
+
+// InvNTTBatch computes in place the inverse-NTT of each polynomial of polys. See NTTBatchLvl.
+func (r *Ring) InvNTTBatch(polys []*Poly) {
+	r.InvNTTBatchLvl(len(r.Modulus)-1, polys)
+}
+
+// InvNTTBatchLvl computes in place the inverse-NTT of each polynomial of polys. The value level defines the number of
+// moduli of the input polynomials. See NTTBatchLvl.
+func (r *Ring) InvNTTBatchLvl(level int, polys []*Poly) {
+	r.countInvNTT((level + 1) * len(polys))
+	r.batchLvl(level, polys, func(x int, coeffs []uint64) {
+		r.invNTT(x, coeffs, coeffs)
+	})
+}
+
+// batchLvl applies transform to the residues modulo each of the level+1 first moduli of each polynomial of polys.
+// The (modulus, polynomial) pairs are enumerated modulus by modulus and split in contiguous ranges among the
+// goroutines, so that each goroutine processes as few moduli as possible.
+func (r *Ring) batchLvl(level int, polys []*Poly, transform func(x int, coeffs []uint64)) {
+
+	for i := range polys {
+		for j := 0; j < i; j++ {
+			if &polys[i].Coeffs[0][0] == &polys[j].Coeffs[0][0] {
+				panic("cannot batch the NTT: the polynomials share their coefficients")
+			}
+		}
+	}
+
+	jobs := (level + 1) * len(polys)
+
+	workers := utils.MinInt(nttBatchMaxWorkers, jobs*r.N/nttBatchMinCoeffs)
+
+	if workers < 2 {
+		for x := 0; x < level+1; x++ {
+			for _, p := range polys {
+				transform(x, p.Coeffs[x])
+			}
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(start, end int) {
+			defer wg.Done()
+			for job := start; job < end; job++ {
+				transform(job/len(polys), polys[job%len(polys)].Coeffs[job/len(polys)])
+			}
+		}(w*jobs/workers, (w+1)*jobs/workers)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,72 @@
+package ring
+
+import (
+	"math/bits"
+)
+
+// nttIFMAMaxModulus is the bound on the moduli of the AVX-512 IFMA kernels of the NTT and inverse NTT: their
+// butterflies keep the coefficients in [0, 4q-1], which must fit on the 52 bits of the IFMA multiplications.
+const nttIFMAMaxModulus = 1 << 50
+
+// nttIFMAParams stores the twiddle factors of the AVX-512 IFMA kernels of the NTT and inverse NTT for one modulus.
+type nttIFMAParams struct {
+	psi, psiShoup       []uint64 // powers of the 2N-th primitive root (in bit-reversed order) and their Shoup quotients
+	psiInv, psiInvShoup []uint64 // powers of the inverse of the 2N-th primitive root (in bit-reversed order) and their Shoup quotients
+	nInv, nInvShoup     uint64   // N^-1 mod q and its Shoup quotient
+}
+
+// newNTTIFMAParams converts the twiddle factors of the NTT and inverse NTT, which are in Montgomery form, to the
+// standard form and computes their Shoup quotients.
+func newNTTIFMAParams(q, qInv uint64, nttPsi, nttPsiInv []uint64, nttNInv uint64) (p *nttIFMAParams) {
+
+	p = new(nttIFMAParams)
+	p.psi = make([]uint64, len(nttPsi))
+	p.psiShoup = make([]uint64, len(nttPsi))
+	p.psiInv = make([]uint64, len(nttPsiInv))
+	p.psiInvShoup = make([]uint64, len(nttPsiInv))
+
+	for i := range nttPsi {
+		p.psi[i] = InvMForm(nttPsi[i], q, qInv)
+		p.psiShoup[i] = shoupQuotient52(p.psi[i], q)
+		p.psiInv[i] = InvMForm(nttPsiInv[i], q, qInv)
+		p.psiInvShoup[i] = shoupQuotient52(p.psiInv[i], q)
+	}
+
+	p.nInv = InvMForm(nttNInv, q, qInv)
+	p.nInvShoup = shoupQuotient52(p.nInv, q)
+
+	return
+}
+
+// shoupQuotient52 returns floor(w * 2^52 / q) for w < q.
+func shoupQuotient52(w, q uint64) (quo uint64) {
+	quo, _ = bits.Div64(w>>12, w<<52, q)
+	return
+}
+
+// nttIFMA computes the NTT on the input coefficients with the AVX-512 IFMA kernels. Input values must be in the
+// range [0, 2q-1] and output values are in the range [0, q-1], the same as returned by NTT.
+func nttIFMA(coeffsIn, coeffsOut []uint64, N int, p *nttIFMAParams, Q uint64) {
+
+	in := &coeffsIn[0]
+
+	for m, t := 1, N>>1; t >= 8; m, t = m<<1, t>>1 {
+		nttIFMAStage(in, &coeffsOut[0], m, t, &p.psi[m], &p.psiShoup[m], Q)
+		in = &coeffsOut[0]
+	}
+
+	nttIFMATail(&coeffsOut[0], N>>4, &p.psi[N>>3], &p.psiShoup[N>>3], &p.psi[N>>2], &p.psiShoup[N>>2], &p.psi[N>>1], &p.psiShoup[N>>1], Q)
+}
+
+// invNTTIFMA computes the inverse NTT on the input coefficients with the AVX-512 IFMA kernels. Input values must
+// be in the range [0, 2q-1] and output values are in the range [0, q-1], the same as returned by InvNTT.
+func invNTTIFMA(coeffsIn, coeffsOut []uint64, N int, p *nttIFMAParams, Q uint64) {
+
+	invNTTIFMAHead(&coeffsIn[0], &coeffsOut[0], N>>4, &p.psiInv[N>>1], &p.psiInvShoup[N>>1], &p.psiInv[N>>2], &p.psiInvShoup[N>>2], &p.psiInv[N>>3], &p.psiInvShoup[N>>3], Q)
+
+	for h, t := N>>4, 8; h > 0; h, t = h>>1, t<<1 {
+		invNTTIFMAStage(&coeffsOut[0], h, t, &p.psiInv[h], &p.psiInvShoup[h], Q)
+	}
+
+	invNTTIFMAScale(&coeffsOut[0], N, p.nInv, p.nInvShoup, Q)
+}
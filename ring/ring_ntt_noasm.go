@@ -0,0 +1,27 @@
+//go:build !amd64 || purego
+// +build !amd64 purego
+
+package ring
+
+// hasIFMA is always false on this architecture: the NTT and inverse NTT use the pure Go kernels.
+const hasIFMA = false
+
+func nttIFMAStage(in, out *uint64, m, t int, w, wShoup *uint64, q uint64) {
+	panic("the AVX-512 IFMA kernels are not available on this architecture")
+}
+
+func nttIFMATail(a *uint64, n int, w4, w4Shoup, w2, w2Shoup, w1, w1Shoup *uint64, q uint64) {
+	panic("the AVX-512 IFMA kernels are not available on this architecture")
+}
+
+func invNTTIFMAHead(in, out *uint64, n int, w1, w1Shoup, w2, w2Shoup, w4, w4Shoup *uint64, q uint64) {
+	panic("the AVX-512 IFMA kernels are not available on this architecture")
+}
+
+func invNTTIFMAStage(a *uint64, h, t int, w, wShoup *uint64, q uint64) {
+	panic("the AVX-512 IFMA kernels are not available on this architecture")
+}
+
+func invNTTIFMAScale(a *uint64, n int, nInv, nInvShoup, q uint64) {
+	panic("the AVX-512 IFMA kernels are not available on this architecture")
+}
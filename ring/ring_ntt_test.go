@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/ldsec/lattigo/v2/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -118,3 +119,128 @@ func TestNTT(t *testing.T) {
 		})
 	}
 }
+
+func TestNTTBatch(t *testing.T) {
+
+	prng, _ := utils.NewPRNG()
+
+	// N=2^12 with 4 moduli and 8 polynomials is enough work to spawn the goroutines, also on a single CPU
+	defer func(maxWorkers int) { nttBatchMaxWorkers = maxWorkers }(nttBatchMaxWorkers)
+	nttBatchMaxWorkers = 3
+
+	for _, logN := range []int{4, 12} {
+
+		ringQ, err := NewRing(1<<logN, GenerateNTTPrimes(55, 2<<logN, 4))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		uniformSampler := NewUniformSampler(prng, ringQ)
+
+		for _, level := range []int{0, len(ringQ.Modulus) - 1} {
+
+			t.Run(fmt.Sprintf("N=%d/level=%d", ringQ.N, level), func(t *testing.T) {
+
+				polys := make([]*Poly, 8)
+				want := make([]*Poly, len(polys))
+				for i := range polys {
+					polys[i] = uniformSampler.ReadLvlNew(level)
+					want[i] = ringQ.NewPolyLvl(level)
+					ringQ.NTTLvl(level, polys[i], want[i])
+				}
+
+				have := make([]*Poly, len(polys))
+				for i := range polys {
+					have[i] = polys[i].CopyNew()
+				}
+
+				ringQ.NTTBatchLvl(level, have)
+				for i := range have {
+					assert.True(t, ringQ.EqualLvl(level, want[i], have[i]))
+				}
+
+				ringQ.InvNTTBatchLvl(level, have)
+				for i := range have {
+					assert.True(t, ringQ.EqualLvl(level, polys[i], have[i]))
+				}
+
+				// Aliased polynomials would be transformed twice, possibly concurrently
+				assert.Panics(t, func() { ringQ.NTTBatchLvl(level, []*Poly{have[0], have[1], have[0]}) })
+			})
+		}
+	}
+}
+
+func TestNTTIFMA(t *testing.T) {
+
+	if !hasIFMA {
+		t.Skip("AVX-512 IFMA is not supported by the CPU")
+	}
+
+	prng, _ := utils.NewPRNG()
+
+	for _, logN := range []int{4, 5, 10, 14} {
+
+		// The 55-bit modulus is not supported by the IFMA kernels and must fall back to the pure Go kernels.
+		var moduli []uint64
+		for _, logQ := range []int{30, 40, 49, 50, 55} {
+			moduli = append(moduli, GenerateNTTPrimes(logQ, 2<<logN, 1)...)
+		}
+
+		ringQ, err := NewRing(1<<logN, moduli)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(fmt.Sprintf("N=%d/limbs=%d", ringQ.N, len(ringQ.Modulus)), func(t *testing.T) {
+
+			for x, qi := range ringQ.Modulus {
+				assert.Equal(t, qi < nttIFMAMaxModulus, ringQ.nttIFMA[x] != nil, "modulus %d", x)
+			}
+
+			// Inputs in [0, 2q-1], the range accepted by the transforms
+			p := NewUniformSampler(prng, ringQ).ReadNew()
+			for x, qi := range ringQ.Modulus {
+				for j := 0; j < ringQ.N; j += 3 {
+					p.Coeffs[x][j] += qi
+				}
+			}
+
+			want, have := ringQ.NewPoly(), ringQ.NewPoly()
+
+			// The IFMA and pure Go kernels must return exactly the same values.
+			for x := range ringQ.Modulus {
+				NTT(p.Coeffs[x], want.Coeffs[x], ringQ.N, ringQ.NttPsi[x], ringQ.Modulus[x], ringQ.MredParams[x], ringQ.BredParams[x])
+			}
+			ringQ.NTT(p, have)
+			assert.True(t, ringQ.Equal(want, have), "NTT")
+
+			for x := range ringQ.Modulus {
+				InvNTT(p.Coeffs[x], want.Coeffs[x], ringQ.N, ringQ.NttPsiInv[x], ringQ.NttNInv[x], ringQ.Modulus[x], ringQ.MredParams[x])
+			}
+			ringQ.InvNTT(p, have)
+			assert.True(t, ringQ.Equal(want, have), "InvNTT")
+
+			// The lazy transforms return congruent values in [0, 2q-1].
+			for _, lazy := range []func(p1, p2 *Poly){ringQ.NTTLazy, ringQ.InvNTTLazy} {
+				lazy(p, have)
+				for x, qi := range ringQ.Modulus {
+					for j := range have.Coeffs[x] {
+						assert.Less(t, have.Coeffs[x][j], 2*qi)
+					}
+				}
+			}
+			ringQ.NTTLazy(p, have)
+			ringQ.NTT(p, want)
+			ringQ.Reduce(have, have)
+			assert.True(t, ringQ.Equal(want, have), "NTTLazy")
+
+			// In place
+			have = p.CopyNew()
+			ringQ.NTT(have, have)
+			ringQ.InvNTT(have, have)
+			ringQ.Reduce(p, want)
+			assert.True(t, ringQ.Equal(want, have), "InvNTT(NTT(p))")
+		})
+	}
+}
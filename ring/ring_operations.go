@@ -192,6 +192,68 @@ func (r *Ring) SubNoModLvl(level int, p1, p2, p3 *Poly) {
 	}
 }
 
+// AddLazy adds p1 to p2 coefficient-wise and writes the result on p3, in the lazy representation:
+// the input values must be in the range [0, 2q-1] and the output values are in the range [0, 2q-1].
+func (r *Ring) AddLazy(p1, p2, p3 *Poly) {
+	r.AddLazyLvl(len(r.Modulus)-1, p1, p2, p3)
+}
+
+// AddLazyLvl adds p1 to p2 coefficient-wise for the moduli from q_0 up to q_level and writes the result on p3,
+// in the lazy representation: the input values must be in the range [0, 2q-1] and the output values are in the
+// range [0, 2q-1].
+func (r *Ring) AddLazyLvl(level int, p1, p2, p3 *Poly) {
+	for i := 0; i < level+1; i++ {
+		twoQi := r.Modulus[i] << 1
+		p1tmp, p2tmp, p3tmp := p1.Coeffs[i], p2.Coeffs[i], p3.Coeffs[i]
+		for j := 0; j < r.N; j = j + 8 {
+
+			x := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
+			y := (*[8]uint64)(unsafe.Pointer(&p2tmp[j]))
+			z := (*[8]uint64)(unsafe.Pointer(&p3tmp[j]))
+
+			z[0] = CRed(x[0]+y[0], twoQi)
+			z[1] = CRed(x[1]+y[1], twoQi)
+			z[2] = CRed(x[2]+y[2], twoQi)
+			z[3] = CRed(x[3]+y[3], twoQi)
+			z[4] = CRed(x[4]+y[4], twoQi)
+			z[5] = CRed(x[5]+y[5], twoQi)
+			z[6] = CRed(x[6]+y[6], twoQi)
+			z[7] = CRed(x[7]+y[7], twoQi)
+		}
+	}
+}
+
+// SubLazy subtracts p2 to p1 coefficient-wise and writes the result on p3, in the lazy representation:
+// the input values must be in the range [0, 2q-1] and the output values are in the range [0, 2q-1].
+func (r *Ring) SubLazy(p1, p2, p3 *Poly) {
+	r.SubLazyLvl(len(r.Modulus)-1, p1, p2, p3)
+}
+
+// SubLazyLvl subtracts p2 to p1 coefficient-wise for the moduli from q_0 up to q_level and writes the result on
+// p3, in the lazy representation: the input values must be in the range [0, 2q-1] and the output values are in the
+// range [0, 2q-1].
+func (r *Ring) SubLazyLvl(level int, p1, p2, p3 *Poly) {
+	for i := 0; i < level+1; i++ {
+		twoQi := r.Modulus[i] << 1
+		p1tmp, p2tmp, p3tmp := p1.Coeffs[i], p2.Coeffs[i], p3.Coeffs[i]
+		for j := 0; j < r.N; j = j + 8 {
+
+			x := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
+			y := (*[8]uint64)(unsafe.Pointer(&p2tmp[j]))
+			z := (*[8]uint64)(unsafe.Pointer(&p3tmp[j]))
+
+			z[0] = CRed(x[0]+twoQi-y[0], twoQi)
+			z[1] = CRed(x[1]+twoQi-y[1], twoQi)
+			z[2] = CRed(x[2]+twoQi-y[2], twoQi)
+			z[3] = CRed(x[3]+twoQi-y[3], twoQi)
+			z[4] = CRed(x[4]+twoQi-y[4], twoQi)
+			z[5] = CRed(x[5]+twoQi-y[5], twoQi)
+			z[6] = CRed(x[6]+twoQi-y[6], twoQi)
+			z[7] = CRed(x[7]+twoQi-y[7], twoQi)
+		}
+	}
+}
+
 // Neg sets all coefficients of p1 to their additive inverse and writes the result on p2.
 func (r *Ring) Neg(p1, p2 *Poly) {
 	for i, qi := range r.Modulus {
@@ -751,6 +813,55 @@ func (r *Ring) MulCoeffsMontgomeryAndSubNoMod(p1, p2, p3 *Poly) {
 	}
 }
 
+// MulCoeffsMontgomeryLazy multiplies p1 by p2 coefficient-wise with a constant-time Montgomery modular reduction
+// and writes the result on p3, in the lazy representation: the input values must be in the range [0, 2q-1] and the
+// output values are in the range [0, 2q-1].
+func (r *Ring) MulCoeffsMontgomeryLazy(p1, p2, p3 *Poly) {
+	r.MulCoeffsMontgomeryLazyLvl(len(r.Modulus)-1, p1, p2, p3)
+}
+
+// MulCoeffsMontgomeryLazyLvl multiplies p1 by p2 coefficient-wise with a constant-time Montgomery modular reduction
+// for the moduli from q_0 up to q_level and writes the result on p3, in the lazy representation: the input values
+// must be in the range [0, 2q-1] and the output values are in the range [0, 2q-1].
+func (r *Ring) MulCoeffsMontgomeryLazyLvl(level int, p1, p2, p3 *Poly) {
+	r.MulCoeffsMontgomeryConstantLvl(level, p1, p2, p3)
+}
+
+// MulCoeffsMontgomeryAndAddLazy multiplies p1 by p2 coefficient-wise with a constant-time Montgomery modular
+// reduction and adds the result to p3, in the lazy representation: the input values must be in the range
+// [0, 2q-1] and the output values are in the range [0, 2q-1]. Unlike MulCoeffsMontgomeryAndAddNoMod, the output
+// stays in the range of the inputs, so that any number of products can be accumulated without a Reduce.
+func (r *Ring) MulCoeffsMontgomeryAndAddLazy(p1, p2, p3 *Poly) {
+	r.MulCoeffsMontgomeryAndAddLazyLvl(len(r.Modulus)-1, p1, p2, p3)
+}
+
+// MulCoeffsMontgomeryAndAddLazyLvl multiplies p1 by p2 coefficient-wise with a constant-time Montgomery modular
+// reduction for the moduli from q_0 up to q_level and adds the result to p3, in the lazy representation (see
+// MulCoeffsMontgomeryAndAddLazy).
+func (r *Ring) MulCoeffsMontgomeryAndAddLazyLvl(level int, p1, p2, p3 *Poly) {
+	for i := 0; i < level+1; i++ {
+		qi := r.Modulus[i]
+		twoQi := qi << 1
+		p1tmp, p2tmp, p3tmp := p1.Coeffs[i], p2.Coeffs[i], p3.Coeffs[i]
+		mredParams := r.MredParams[i]
+		for j := 0; j < r.N; j = j + 8 {
+
+			x := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
+			y := (*[8]uint64)(unsafe.Pointer(&p2tmp[j]))
+			z := (*[8]uint64)(unsafe.Pointer(&p3tmp[j]))
+
+			z[0] = CRed(z[0]+MRedConstant(x[0], y[0], qi, mredParams), twoQi)
+			z[1] = CRed(z[1]+MRedConstant(x[1], y[1], qi, mredParams), twoQi)
+			z[2] = CRed(z[2]+MRedConstant(x[2], y[2], qi, mredParams), twoQi)
+			z[3] = CRed(z[3]+MRedConstant(x[3], y[3], qi, mredParams), twoQi)
+			z[4] = CRed(z[4]+MRedConstant(x[4], y[4], qi, mredParams), twoQi)
+			z[5] = CRed(z[5]+MRedConstant(x[5], y[5], qi, mredParams), twoQi)
+			z[6] = CRed(z[6]+MRedConstant(x[6], y[6], qi, mredParams), twoQi)
+			z[7] = CRed(z[7]+MRedConstant(x[7], y[7], qi, mredParams), twoQi)
+		}
+	}
+}
+
 // MulCoeffsConstant multiplies p1 by p2 coefficient-wise with a constant-time
 // Barrett modular reduction and writes the result on p3.
 func (r *Ring) MulCoeffsConstant(p1, p2, p3 *Poly) {
@@ -929,10 +1040,15 @@ func (r *Ring) AddScalar(p1 *Poly, scalar uint64, p2 *Poly) {
 
 // AddScalarBigint adds a big.Int scalar to each coefficient of p1 and writes the result on p2.
 func (r *Ring) AddScalarBigint(p1 *Poly, scalar *big.Int, p2 *Poly) {
+	r.AddScalarBigintLvl(len(r.Modulus)-1, p1, scalar, p2)
+}
+
+// AddScalarBigintLvl adds a big.Int scalar to each coefficient of p1 and writes the result on p2, on the first level+1 moduli.
+func (r *Ring) AddScalarBigintLvl(level int, p1 *Poly, scalar *big.Int, p2 *Poly) {
 	tmp := new(big.Int)
-	for i, Qi := range r.Modulus {
+	for i, Qi := range r.Modulus[:level+1] {
 		scalarQi := tmp.Mod(scalar, NewUint(Qi)).Uint64()
-		p1tmp, p2tmp := p1.Coeffs[i], p1.Coeffs[i]
+		p1tmp, p2tmp := p1.Coeffs[i], p2.Coeffs[i]
 		for j := 0; j < r.N; j = j + 8 {
 
 			x := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
@@ -973,10 +1089,15 @@ func (r *Ring) SubScalar(p1 *Poly, scalar uint64, p2 *Poly) {
 
 // SubScalarBigint subtracts a big.Int scalar from each coefficient of p1 and writes the result on p2.
 func (r *Ring) SubScalarBigint(p1 *Poly, scalar *big.Int, p2 *Poly) {
+	r.SubScalarBigintLvl(len(r.Modulus)-1, p1, scalar, p2)
+}
+
+// SubScalarBigintLvl subtracts a big.Int scalar from each coefficient of p1 and writes the result on p2, on the first level+1 moduli.
+func (r *Ring) SubScalarBigintLvl(level int, p1 *Poly, scalar *big.Int, p2 *Poly) {
 	tmp := new(big.Int)
-	for i, Qi := range r.Modulus {
+	for i, Qi := range r.Modulus[:level+1] {
 		scalarQi := tmp.Mod(scalar, NewUint(Qi)).Uint64()
-		p1tmp, p2tmp := p1.Coeffs[i], p1.Coeffs[i]
+		p1tmp, p2tmp := p1.Coeffs[i], p2.Coeffs[i]
 		for j := 0; j < r.N; j = j + 8 {
 
 			x := (*[8]uint64)(unsafe.Pointer(&p1tmp[j]))
@@ -1253,12 +1374,17 @@ func (r *Ring) MultByMonomialNew(p1 *Poly, monomialDeg int) (p2 *Poly) {
 
 // MultByMonomial multiplies p1 by x^monomialDeg and writes the result on p2.
 func (r *Ring) MultByMonomial(p1 *Poly, monomialDeg int, p2 *Poly) {
+	r.MultByMonomialLvl(len(r.Modulus)-1, p1, monomialDeg, p2)
+}
+
+// MultByMonomialLvl multiplies p1 by x^monomialDeg and writes the result on p2, on the first level+1 moduli.
+func (r *Ring) MultByMonomialLvl(level int, p1 *Poly, monomialDeg int, p2 *Poly) {
 
 	shift := monomialDeg % (r.N << 1)
 
 	if shift == 0 {
 
-		for i := range r.Modulus {
+		for i := range r.Modulus[:level+1] {
 			p1tmp, p2tmp := p1.Coeffs[i], p2.Coeffs[i]
 			for j := 0; j < r.N; j++ {
 				p2tmp[j] = p1tmp[j]
@@ -1267,11 +1393,11 @@ func (r *Ring) MultByMonomial(p1 *Poly, monomialDeg int, p2 *Poly) {
 
 	} else {
 
-		tmpx := r.NewPoly()
+		tmpx := r.NewPolyLvl(level)
 
 		if shift < r.N {
 
-			for i := range r.Modulus {
+			for i := range r.Modulus[:level+1] {
 				p1tmp, tmpxT := p1.Coeffs[i], tmpx.Coeffs[i]
 				for j := 0; j < r.N; j++ {
 					tmpxT[j] = p1tmp[j]
@@ -1280,7 +1406,7 @@ func (r *Ring) MultByMonomial(p1 *Poly, monomialDeg int, p2 *Poly) {
 
 		} else {
 
-			for i, qi := range r.Modulus {
+			for i, qi := range r.Modulus[:level+1] {
 				p1tmp, tmpxT := p1.Coeffs[i], tmpx.Coeffs[i]
 				for j := 0; j < r.N; j++ {
 					tmpxT[j] = qi - p1tmp[j]
@@ -1290,14 +1416,14 @@ func (r *Ring) MultByMonomial(p1 *Poly, monomialDeg int, p2 *Poly) {
 
 		shift %= r.N
 
-		for i, qi := range r.Modulus {
+		for i, qi := range r.Modulus[:level+1] {
 			p2tmp, tmpxT := p2.Coeffs[i], tmpx.Coeffs[i]
 			for j := 0; j < shift; j++ {
 				p2tmp[j] = qi - tmpxT[r.N-shift+j]
 			}
 		}
 
-		for i := range r.Modulus {
+		for i := range r.Modulus[:level+1] {
 			p2tmp, tmpxT := p2.Coeffs[i], tmpx.Coeffs[i]
 			for j := shift; j < r.N; j++ {
 				p2tmp[j] = tmpxT[j-shift]
@@ -4,8 +4,13 @@ import (
 	"encoding/binary"
 	"errors"
 	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
+// maxLogN is the log2 of the largest polynomial degree accepted by the decoding methods of Poly.
+const maxLogN = 16
+
 // Poly is the structure that contains the coefficients of a polynomial.
 type Poly struct {
 	Coeffs [][]uint64 // Coefficients in CRT representation
@@ -148,6 +153,45 @@ func (pol *Poly) GetCoefficients() (coeffs [][]uint64) {
 	return
 }
 
+// GetCoefficientsNTT returns a new double slice that contains the coefficients of the polynomial, which is assumed to be
+// in the NTT domain (with the native NTTBitReversed ordering), laid out in the given ordering.
+func (pol *Poly) GetCoefficientsNTT(ordering NTTOrdering) (coeffs [][]uint64) {
+
+	if ordering == NTTBitReversed {
+		return pol.GetCoefficients()
+	}
+
+	logN := uint64(bits.Len64(uint64(pol.Degree())) - 1)
+
+	coeffs = make([][]uint64, len(pol.Coeffs))
+	for i := range pol.Coeffs {
+		coeffs[i] = make([]uint64, len(pol.Coeffs[i]))
+		for j := range pol.Coeffs[i] {
+			coeffs[i][utils.BitReverse64(uint64(j), logN)] = pol.Coeffs[i][j]
+		}
+	}
+
+	return
+}
+
+// SetCoefficientsNTT sets the coefficients of the polynomial from a CRT format (double slice) of values in the NTT domain
+// laid out in the given ordering. The polynomial stores them in the native NTTBitReversed ordering.
+func (pol *Poly) SetCoefficientsNTT(coeffs [][]uint64, ordering NTTOrdering) {
+
+	if ordering == NTTBitReversed {
+		pol.SetCoefficients(coeffs)
+		return
+	}
+
+	logN := uint64(bits.Len64(uint64(pol.Degree())) - 1)
+
+	for i := range coeffs {
+		for j := range coeffs[i] {
+			pol.Coeffs[i][utils.BitReverse64(uint64(j), logN)] = coeffs[i][j]
+		}
+	}
+}
+
 // WriteCoeffsTo converts a matrix of coefficients to a byte array.
 func WriteCoeffsTo(pointer, N, numberModuli int, coeffs [][]uint64, data []byte) (int, error) {
 	tmp := N << 3
@@ -180,6 +224,54 @@ func (pol *Poly) WriteTo(data []byte) (int, error) {
 	return cnt, err
 }
 
+// WriteToNTT writes the given poly, which is assumed to be in the NTT domain, to the data array with its coefficients
+// laid out in the given ordering. The ordering is recorded in the metadata so that UnmarshalBinaryNTT can restore
+// the native ordering. It returns the number of written bytes, and the corresponding error, if it occurred.
+func (pol *Poly) WriteToNTT(data []byte, ordering NTTOrdering) (int, error) {
+
+	if !ordering.IsValid() {
+		return 0, errors.New("invalid NTT ordering")
+	}
+
+	N := pol.Degree()
+	numberModuli := pol.LenModuli()
+
+	if len(data) < pol.GetDataLenNTT(true) {
+		// The data is not big enough to write all the information
+		return 0, errors.New("data array is too small to write ring.Poly")
+	}
+	data[0] = uint8(bits.Len64(uint64(N)) - 1)
+	data[1] = uint8(numberModuli)
+	data[2] = uint8(ordering)
+
+	if ordering == NTTBitReversed {
+		return WriteCoeffsTo(3, N, numberModuli, pol.Coeffs, data)
+	}
+
+	logN := uint64(data[0])
+	pointer := 3
+	for i := 0; i < numberModuli; i++ {
+		for j := 0; j < N; j++ {
+			k := pointer + int(utils.BitReverse64(uint64(j), logN)<<3)
+			binary.BigEndian.PutUint64(data[k:k+8], pol.Coeffs[i][j])
+		}
+		pointer += N << 3
+	}
+
+	return pointer, nil
+}
+
+// GetDataLenNTT returns the number of bytes the polynomial will take when written to data with WriteToNTT.
+// It can take into account meta data if necessary.
+func (pol *Poly) GetDataLenNTT(WithMetadata bool) (cnt int) {
+	cnt = pol.GetDataLen(false)
+
+	if WithMetadata {
+		cnt += 3
+	}
+	return
+}
+
 // WriteTo32 writes the given poly to the data array.
 // It returns the number of written bytes, and the corresponding error, if it occurred.
 func (pol *Poly) WriteTo32(data []byte) (int, error) {
@@ -293,6 +385,59 @@ func (pol *Poly) UnmarshalBinary(data []byte) (err error) {
 	return nil
 }
 
+// MarshalBinaryNTT encodes the target polynomial, which is assumed to be in the NTT domain, on a slice of bytes
+// with its coefficients laid out in the given ordering.
+func (pol *Poly) MarshalBinaryNTT(ordering NTTOrdering) (data []byte, err error) {
+	data = make([]byte, pol.GetDataLenNTT(true))
+	_, err = pol.WriteToNTT(data, ordering)
+	return
+}
+
+// UnmarshalBinaryNTT decodes a slice of bytes generated by MarshalBinaryNTT on the target polynomial.
+// The coefficients are permuted from the recorded ordering into the native NTTBitReversed ordering.
+func (pol *Poly) UnmarshalBinaryNTT(data []byte) (err error) {
+
+	if len(data) < 3 {
+		return errors.New("invalid polynomial encoding")
+	}
+
+	if data[0] > maxLogN || data[1] == 0 {
+		return errors.New("invalid polynomial encoding")
+	}
+
+	N := 1 << data[0]
+	numberModuli := int(data[1])
+	ordering := NTTOrdering(data[2])
+
+	if !ordering.IsValid() {
+		return errors.New("invalid NTT ordering")
+	}
+
+	if (len(data)-3)>>3 != N*numberModuli {
+		return errors.New("invalid polynomial encoding")
+	}
+
+	pol.Coeffs = make([][]uint64, numberModuli)
+
+	if ordering == NTTBitReversed {
+		_, err = DecodeCoeffsNew(3, N, numberModuli, pol.Coeffs, data)
+		return
+	}
+
+	logN := uint64(data[0])
+	pointer := 3
+	for i := 0; i < numberModuli; i++ {
+		pol.Coeffs[i] = make([]uint64, N)
+		for j := 0; j < N; j++ {
+			k := pointer + int(utils.BitReverse64(uint64(j), logN)<<3)
+			pol.Coeffs[i][j] = binary.BigEndian.Uint64(data[k : k+8])
+		}
+		pointer += N << 3
+	}
+
+	return nil
+}
+
 // DecodePolyNew decodes a slice of bytes in the target polynomial returns the number of bytes
 // decoded.
 func (pol *Poly) DecodePolyNew(data []byte) (pointer int, err error) {
@@ -0,0 +1,82 @@
+package ring
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// PolyPool is a pool of polynomials, backed by a sync.Pool per ring degree and level, from which the
+// temporary polynomials of hot loops can be drawn to reduce the pressure on the garbage collector.
+// The methods of a PolyPool are safe for concurrent use.
+type PolyPool struct {
+	pools    sync.Map // map[polyPoolKey]*sync.Pool
+	disabled uint32
+}
+
+type polyPoolKey struct {
+	N     int
+	level int
+}
+
+// DefaultPolyPool is the package-level PolyPool used by the evaluators of the schemes for their temporary elements.
+var DefaultPolyPool = NewPolyPool()
+
+// NewPolyPool creates a new enabled PolyPool.
+func NewPolyPool() *PolyPool {
+	return new(PolyPool)
+}
+
+// SetEnabled enables or disables the pool. A disabled pool allocates a new polynomial on each call to Get and
+// discards the polynomials given to Put, which gives deterministic memory profiles.
+func (pool *PolyPool) SetEnabled(enabled bool) {
+	if enabled {
+		atomic.StoreUint32(&pool.disabled, 0)
+	} else {
+		atomic.StoreUint32(&pool.disabled, 1)
+	}
+}
+
+// Enabled returns true if the pool is enabled.
+func (pool *PolyPool) Enabled() bool {
+	return atomic.LoadUint32(&pool.disabled) == 0
+}
+
+// Get returns a polynomial of degree N with level+1 moduli, whose coefficients are all set to zero.
+func (pool *PolyPool) Get(N, level int) (pol *Poly) {
+
+	if !pool.Enabled() {
+		return NewPoly(N, level+1)
+	}
+
+	pol = pool.getPool(N, level).Get().(*Poly)
+	pol.Zero()
+	return
+}
+
+// Put returns pol to the pool, for a later call to Get with the same degree and level. pol must not be used
+// after the call.
+func (pool *PolyPool) Put(pol *Poly) {
+
+	if !pool.Enabled() || pol == nil || len(pol.Coeffs) == 0 {
+		return
+	}
+
+	pool.getPool(len(pol.Coeffs[0]), len(pol.Coeffs)-1).Put(pol)
+}
+
+func (pool *PolyPool) getPool(N, level int) *sync.Pool {
+
+	key := polyPoolKey{N, level}
+
+	if p, ok := pool.pools.Load(key); ok {
+		return p.(*sync.Pool)
+	}
+
+	p, _ := pool.pools.LoadOrStore(key, &sync.Pool{
+		New: func() interface{} {
+			return NewPoly(N, level+1)
+		},
+	})
+
+	return p.(*sync.Pool)
+}
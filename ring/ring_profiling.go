@@ -0,0 +1,140 @@
+package ring
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// OperationCounts stores the number of operations performed, process-wide, on the rings of a given fingerprint.
+// The counters are only maintained if the package is built with the lattigo_profiling build tag (see ProfilingEnabled).
+type OperationCounts struct {
+	// NTT is the number of forward NTTs performed through the methods of a Ring, counted per RNS modulus.
+	NTT uint64
+	// InvNTT is the number of inverse NTTs performed through the methods of a Ring, counted per RNS modulus.
+	InvNTT uint64
+	// BasisExtension is the number of RNS basis extensions (ModUp) and reductions (ModDown) performed
+	// by a FastBasisExtender, attributed to its ring Q.
+	BasisExtension uint64
+	// KeySwitch is the number of key-switching operations (including relinearizations and rotations)
+	// reported by the schemes with Ring.CountKeySwitch, attributed to their ring Q.
+	KeySwitch uint64
+}
+
+// Add returns the sum of the operation counts c and other.
+func (c OperationCounts) Add(other OperationCounts) OperationCounts {
+	return OperationCounts{
+		NTT:            c.NTT + other.NTT,
+		InvNTT:         c.InvNTT + other.InvNTT,
+		BasisExtension: c.BasisExtension + other.BasisExtension,
+		KeySwitch:      c.KeySwitch + other.KeySwitch,
+	}
+}
+
+type operationCounters struct {
+	ntt            uint64
+	invNTT         uint64
+	basisExtension uint64
+	keySwitch      uint64
+}
+
+// profiles maps the ring fingerprints to their *operationCounters.
+var profiles sync.Map
+
+// ProfilingEnabled returns true if the package was built with the lattigo_profiling build tag,
+// in which case the operations are counted per ring fingerprint.
+func ProfilingEnabled() bool {
+	return profilingEnabled
+}
+
+// Fingerprint returns the fingerprint of the ring of degree N and moduli Modulus, which identifies
+// the operation counts of all the rings instantiated with these parameters.
+func Fingerprint(N int, Modulus []uint64) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(N))
+	h.Write(buf[:])
+	for _, qi := range Modulus {
+		binary.LittleEndian.PutUint64(buf[:], qi)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// Fingerprint returns the fingerprint of the ring (see the function Fingerprint).
+func (r *Ring) Fingerprint() uint64 {
+	return r.fingerprint
+}
+
+// GetOperationCounts returns a snapshot of the operation counts of all the ring fingerprints
+// for which at least one operation was counted.
+func GetOperationCounts() (counts map[uint64]OperationCounts) {
+	counts = make(map[uint64]OperationCounts)
+	profiles.Range(func(key, value interface{}) bool {
+		counts[key.(uint64)] = value.(*operationCounters).snapshot()
+		return true
+	})
+	return
+}
+
+// GetOperationCountsFor returns the sum of the operation counts of the given ring fingerprints.
+func GetOperationCountsFor(fingerprints ...uint64) (counts OperationCounts) {
+	for _, fp := range fingerprints {
+		if value, ok := profiles.Load(fp); ok {
+			counts = counts.Add(value.(*operationCounters).snapshot())
+		}
+	}
+	return
+}
+
+// ResetOperationCounts sets all the operation counts to zero.
+func ResetOperationCounts() {
+	profiles.Range(func(key, value interface{}) bool {
+		profiles.Delete(key)
+		return true
+	})
+}
+
+// CountKeySwitch records a key-switching operation on the ring. It is meant to be called by the
+// schemes and is a no-op unless ProfilingEnabled returns true.
+func (r *Ring) CountKeySwitch() {
+	if profilingEnabled {
+		atomic.AddUint64(&getOperationCounters(r.fingerprint).keySwitch, 1)
+	}
+}
+
+func (r *Ring) countNTT(n int) {
+	if profilingEnabled {
+		atomic.AddUint64(&getOperationCounters(r.fingerprint).ntt, uint64(n))
+	}
+}
+
+func (r *Ring) countInvNTT(n int) {
+	if profilingEnabled {
+		atomic.AddUint64(&getOperationCounters(r.fingerprint).invNTT, uint64(n))
+	}
+}
+
+func (r *Ring) countBasisExtension() {
+	if profilingEnabled {
+		atomic.AddUint64(&getOperationCounters(r.fingerprint).basisExtension, 1)
+	}
+}
+
+func getOperationCounters(fingerprint uint64) *operationCounters {
+	if value, ok := profiles.Load(fingerprint); ok {
+		return value.(*operationCounters)
+	}
+	value, _ := profiles.LoadOrStore(fingerprint, new(operationCounters))
+	return value.(*operationCounters)
+}
+
+func (c *operationCounters) snapshot() OperationCounts {
+	return OperationCounts{
+		NTT:            atomic.LoadUint64(&c.ntt),
+		InvNTT:         atomic.LoadUint64(&c.invNTT),
+		BasisExtension: atomic.LoadUint64(&c.basisExtension),
+		KeySwitch:      atomic.LoadUint64(&c.keySwitch),
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !lattigo_profiling
+// +build !lattigo_profiling
+
+package ring
+
+const profilingEnabled = false
@@ -0,0 +1,254 @@
+package ring
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// MaxDiscreteGaussianSigma is the largest standard deviation supported by the DiscreteGaussianSampler.
+const MaxDiscreteGaussianSigma = float64(1 << 60)
+
+// discreteGaussianTailCut is the tail-cut, in number of standard deviations, of the base sampler: the probability
+// mass beyond it is smaller than the 2^-63 precision of the cumulative distribution table.
+const discreteGaussianTailCut = 10
+
+// discreteGaussianMinBaseSigma is the minimum standard deviation of the base sampler once the convolution is used.
+const discreteGaussianMinBaseSigma = 32
+
+// DiscreteGaussianSampler keeps the state of a discrete Gaussian polynomial sampler supporting standard deviations
+// up to MaxDiscreteGaussianSigma, e.g. for the flooding (smudging) noise of the multiparty protocols.
+//
+// The coefficients are the combination x = sum_j c_j * y_j of samples y_j of a base discrete Gaussian of small
+// standard deviation sigma0, sampled by inversion of its cumulative distribution table (CDT). The multipliers c_j
+// follow the convolution of Micciancio and Walter ("Gaussian Sampling over the Integers: Efficient, Generic,
+// Constant-Time", CRYPTO 2017), x_i = x_{i-1} + k_i * x'_{i-1}, which multiplies the standard deviation by
+// sqrt(1+k_i^2) at each step while keeping the distribution statistically close to a discrete Gaussian. The
+// coefficients are never computed over the integers: their residues are accumulated modulo each qi, so that they
+// are not truncated to the precision of a float64 and can be larger than the moduli.
+//
+// If constantTime is true, the sampling time does not depend on the sampled values: the whole CDT is scanned for
+// each base sample and the number of base samples per coefficient is fixed. Otherwise the CDT is searched by
+// dichotomy, which is faster.
+type DiscreteGaussianSampler struct {
+	baseSampler
+	sigma        float64
+	constantTime bool
+
+	// cdt[k] = 2^63 * P(|y| > k) for the base distribution
+	cdt []uint64
+
+	// multipliers[i][j] = c_j mod qi
+	multipliers [][]uint64
+
+	mags, signs   []uint64
+	randomBufferN []byte
+	ptr           int
+}
+
+// NewDiscreteGaussianSampler creates a new instance of DiscreteGaussianSampler from a PRNG, a ring definition, the
+// standard deviation sigma of the distribution, which must be in (0, MaxDiscreteGaussianSigma], and whether the
+// sampling must be constant-time.
+func NewDiscreteGaussianSampler(prng utils.PRNG, baseRing *Ring, sigma float64, constantTime bool) *DiscreteGaussianSampler {
+
+	if sigma <= 0 || sigma > MaxDiscreteGaussianSigma {
+		panic("cannot NewDiscreteGaussianSampler: sigma must be in (0, 2^60]")
+	}
+
+	gaussianSampler := new(DiscreteGaussianSampler)
+	gaussianSampler.prng = prng
+	gaussianSampler.baseRing = baseRing
+	gaussianSampler.sigma = sigma
+	gaussianSampler.constantTime = constantTime
+	gaussianSampler.randomBufferN = make([]byte, 1024)
+	gaussianSampler.ptr = len(gaussianSampler.randomBufferN)
+
+	ks, sigma0 := discreteGaussianConvolution(sigma)
+
+	gaussianSampler.cdt = discreteGaussianCDT(sigma0)
+
+	// The multiplier of the j-th base sample is the product of the k_i for the bits i set in j
+	leaves := 1 << len(ks)
+	coeffs := make([]uint64, leaves)
+	for j := range coeffs {
+		coeffs[j] = 1
+		for i, k := range ks {
+			if j>>i&1 == 1 {
+				coeffs[j] *= k
+			}
+		}
+	}
+
+	gaussianSampler.multipliers = make([][]uint64, len(baseRing.Modulus))
+	for i, qi := range baseRing.Modulus {
+		gaussianSampler.multipliers[i] = make([]uint64, leaves)
+		for j, c := range coeffs {
+			gaussianSampler.multipliers[i][j] = c % qi
+		}
+	}
+
+	gaussianSampler.mags = make([]uint64, leaves)
+	gaussianSampler.signs = make([]uint64, leaves)
+
+	return gaussianSampler
+}
+
+// Sigma returns the standard deviation of the sampler.
+func (gaussianSampler *DiscreteGaussianSampler) Sigma() float64 {
+	return gaussianSampler.sigma
+}
+
+// ConstantTime returns true if the sampler is constant-time.
+func (gaussianSampler *DiscreteGaussianSampler) ConstantTime() bool {
+	return gaussianSampler.constantTime
+}
+
+// Read samples a discrete Gaussian polynomial on "pol" at the maximum level in the default ring and standard deviation.
+func (gaussianSampler *DiscreteGaussianSampler) Read(pol *Poly) {
+	gaussianSampler.ReadLvl(len(gaussianSampler.baseRing.Modulus)-1, pol)
+}
+
+// ReadLvl samples a discrete Gaussian polynomial at the provided level, in the default ring and standard deviation.
+func (gaussianSampler *DiscreteGaussianSampler) ReadLvl(level int, pol *Poly) {
+	gaussianSampler.readLvl(level, pol, false)
+}
+
+// ReadNew samples a new discrete Gaussian polynomial at the maximum level in the default ring and standard deviation.
+func (gaussianSampler *DiscreteGaussianSampler) ReadNew() (pol *Poly) {
+	pol = gaussianSampler.baseRing.NewPoly()
+	gaussianSampler.Read(pol)
+	return pol
+}
+
+// ReadLvlNew samples a new discrete Gaussian polynomial at the provided level, in the default ring and standard deviation.
+func (gaussianSampler *DiscreteGaussianSampler) ReadLvlNew(level int) (pol *Poly) {
+	pol = gaussianSampler.baseRing.NewPolyLvl(level)
+	gaussianSampler.ReadLvl(level, pol)
+	return pol
+}
+
+// ReadAndAddLvl samples a discrete Gaussian polynomial at the given level for the receiver's default standard deviation and adds it on "pol".
+func (gaussianSampler *DiscreteGaussianSampler) ReadAndAddLvl(level int, pol *Poly) {
+	gaussianSampler.readLvl(level, pol, true)
+}
+
+func (gaussianSampler *DiscreteGaussianSampler) readLvl(level int, pol *Poly, add bool) {
+
+	modulus := gaussianSampler.baseRing.Modulus[:level+1]
+	bredParams := gaussianSampler.baseRing.BredParams
+	mags, signs := gaussianSampler.mags, gaussianSampler.signs
+
+	for i := 0; i < gaussianSampler.baseRing.N; i++ {
+
+		for j := range mags {
+			mags[j], signs[j] = gaussianSampler.sampleBase()
+		}
+
+		for k, qi := range modulus {
+
+			var acc uint64
+			for j, c := range gaussianSampler.multipliers[k] {
+				v := BRed(c, mags[j], qi, bredParams[k])
+				acc = CRed(acc+(v*(signs[j]^1)|(qi-v)*signs[j]), qi)
+			}
+
+			if add {
+				pol.Coeffs[k][i] = CRed(pol.Coeffs[k][i]+acc, qi)
+			} else {
+				pol.Coeffs[k][i] = acc
+			}
+		}
+	}
+}
+
+// sampleBase returns the magnitude and the sign bit of a sample of the base distribution.
+func (gaussianSampler *DiscreteGaussianSampler) sampleBase() (mag, sign uint64) {
+
+	if gaussianSampler.ptr == len(gaussianSampler.randomBufferN) {
+		gaussianSampler.prng.Clock(gaussianSampler.randomBufferN)
+		gaussianSampler.ptr = 0
+	}
+
+	r := binary.BigEndian.Uint64(gaussianSampler.randomBufferN[gaussianSampler.ptr:])
+	gaussianSampler.ptr += 8
+
+	sign, r = r&1, r>>1
+
+	cdt := gaussianSampler.cdt
+
+	// |y| is the number of entries of the table greater than r
+	if gaussianSampler.constantTime {
+		for _, c := range cdt {
+			_, borrow := bits.Sub64(r, c, 0)
+			mag += borrow
+		}
+	} else {
+		mag = uint64(sort.Search(len(cdt), func(k int) bool { return r >= cdt[k] }))
+	}
+
+	return
+}
+
+// discreteGaussianConvolution returns the multipliers k_i of the convolution and the standard deviation sigma0 of
+// the base distribution such that sigma0 * prod_i sqrt(1+k_i^2) = sigma.
+func discreteGaussianConvolution(sigma float64) (ks []uint64, sigma0 float64) {
+
+	// Smoothing parameter of the integers for a statistical distance of 2^-128
+	eta := math.Sqrt(math.Log(2+2*math.Exp2(128)) / math.Pi)
+
+	// Lower bound on the standard deviation of the current step, used to bound the next multiplier
+	current := float64(discreteGaussianMinBaseSigma)
+	prod := 1.0
+
+	for {
+
+		ratio := sigma / (discreteGaussianMinBaseSigma * prod)
+		if ratio < math.Sqrt2 {
+			break
+		}
+
+		k := uint64(current / (math.Sqrt2 * eta))
+
+		if ratio < math.Sqrt(float64(1+k*k)) {
+			// Last step, with the largest multiplier not exceeding the target
+			k = uint64(math.Sqrt(ratio*ratio - 1))
+			ks = append(ks, k)
+			prod *= math.Sqrt(float64(1 + k*k))
+			break
+		}
+
+		ks = append(ks, k)
+		prod *= math.Sqrt(float64(1 + k*k))
+		current *= math.Sqrt(float64(1 + k*k))
+	}
+
+	return ks, sigma / prod
+}
+
+// discreteGaussianCDT returns the table cdt[k] = 2^63 * P(|y| > k) for y following the discrete Gaussian of standard
+// deviation sigma, tail-cut at discreteGaussianTailCut * sigma.
+func discreteGaussianCDT(sigma float64) (cdt []uint64) {
+
+	bound := int(math.Ceil(discreteGaussianTailCut * sigma))
+
+	rho := make([]float64, bound+1)
+	sum := 1.0
+	rho[0] = 1
+	for k := 1; k <= bound; k++ {
+		rho[k] = 2 * math.Exp(-float64(k*k)/(2*sigma*sigma))
+		sum += rho[k]
+	}
+
+	// The tail probabilities are summed from the smallest to keep their relative precision
+	cdt = make([]uint64, bound)
+	var tail float64
+	for k := bound - 1; k >= 0; k-- {
+		tail += rho[k+1]
+		cdt[k] = uint64(math.Round(math.Exp2(63) * tail / sum))
+	}
+
+	return
+}
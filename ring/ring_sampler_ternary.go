@@ -40,12 +40,36 @@ func NewTernarySampler(prng utils.PRNG, baseRing *Ring, p float64, montgomery bo
 // hamming weight for the output polynomials. If "montgomery" is set to true, polynomials read from this sampler
 // are in Montgomery form.
 func NewTernarySamplerSparse(prng utils.PRNG, baseRing *Ring, hw int, montgomery bool) *TernarySampler {
+	if hw > baseRing.N {
+		hw = baseRing.N
+	}
+	return NewTernarySamplerWithHammingWeight(prng, baseRing, hw, montgomery, false)
+}
+
+// NewTernarySamplerWithHammingWeight creates a new instance of a fixed-hamming-weight TernarySampler from a PRNG, the ring
+// definition and the desired hamming weight for the output polynomials: the polynomials are sampled uniformly among the
+// polynomials with exactly hw coefficients in {-1, 1} and all other coefficients equal to 0 (e.g. the sparse secrets of the
+// HE standard). If "montgomery" is set to true, polynomials read from this sampler are in Montgomery form, and if "ntt" is
+// set to true, they are in the NTT domain. Panics if hw is not in [0, N].
+func NewTernarySamplerWithHammingWeight(prng utils.PRNG, baseRing *Ring, hw int, montgomery, ntt bool) *TernarySampler {
+
+	if hw < 0 || hw > baseRing.N {
+		panic("cannot NewTernarySamplerWithHammingWeight: hw must be in [0, N]")
+	}
+
 	ternarySampler := new(TernarySampler)
 	ternarySampler.baseRing = baseRing
 	ternarySampler.prng = prng
 	ternarySampler.hw = hw
 	ternarySampler.sample = ternarySampler.sampleSparse
 
+	if ntt {
+		ternarySampler.sample = func(lvl int, pol *Poly) {
+			ternarySampler.sampleSparse(lvl, pol)
+			baseRing.NTTLvl(lvl, pol, pol)
+		}
+	}
+
 	ternarySampler.initializeMatrix(montgomery)
 
 	return ternarySampler
@@ -172,13 +196,16 @@ func (ts *TernarySampler) sampleProba(lvl int, pol *Poly) {
 
 func (ts *TernarySampler) sampleSparse(lvl int, pol *Poly) {
 
-	if ts.hw > ts.baseRing.N {
-		ts.hw = ts.baseRing.N
-	}
-
 	var mask, j uint64
 	var coeff uint8
 
+	for k := 0; k < lvl+1; k++ {
+		coeffs := pol.Coeffs[k]
+		for i := range coeffs {
+			coeffs[i] = 0
+		}
+	}
+
 	index := make([]int, ts.baseRing.N)
 	for i := 0; i < ts.baseRing.N; i++ {
 		index[i] = i
@@ -16,6 +16,7 @@ type Scaler interface {
 // This implementation of the Scaler interface is preferred over the SimpleScaler implementation.
 type RNSScaler struct {
 	ringQ     *Ring
+	polypoolQ *Poly
 	polypoolT *Poly
 
 	qHalf     *big.Int // (q-1)/2
@@ -38,6 +39,7 @@ func NewRNSScaler(t uint64, ringQ *Ring) (rnss *RNSScaler) {
 
 	rnss.mredParamsT = MRedParams(t)
 
+	rnss.polypoolQ = ringQ.NewPoly()
 	rnss.polypoolT = NewPoly(ringQ.N, 1)
 
 	rnss.t = t
@@ -73,13 +75,14 @@ func (rnss *RNSScaler) DivByQOverTRounded(p1Q, p2T *Poly) {
 	// Multiply P_{Q} by t and extend the basis from P_{Q} to t*(P_{Q}||P_{t})
 	// Since the coefficients of P_{t} are multiplied by t, they are all zero,
 	// hence the basis extension can be omitted
-	ringQ.MulScalar(p1Q, T, p1Q)
+	// The computation is done on a buffer so that p1Q is left unmodified
+	ringQ.MulScalar(p1Q, T, rnss.polypoolQ)
 
 	// Center t*P_{Q} around (Q-1)/2 to round instead of floor during the division
-	ringQ.AddScalarBigint(p1Q, rnss.qHalf, p1Q)
+	ringQ.AddScalarBigint(rnss.polypoolQ, rnss.qHalf, rnss.polypoolQ)
 
 	// Extend the basis of (t*P_{Q} + (Q-1)/2) to (t*P_{t} + (Q-1)/2)
-	modUpExact(p1Q.Coeffs, rnss.polypoolT.Coeffs, rnss.paramsQP)
+	modUpExact(rnss.polypoolQ.Coeffs, rnss.polypoolT.Coeffs, rnss.paramsQP)
 
 	// Compute [Q^{-1} * (t*P_{t} -   (t*P_{Q} - ((Q-1)/2 mod t)))] mod t which returns round(t/Q * P_{Q}) mod t
 	for j := 0; j < ringQ.N; j = j + 8 {
@@ -250,6 +253,11 @@ func (ss *SimpleScaler) reconstructThenScale(p1, p2 *Poly) {
 			coeff.Add(coeff, ss.one)
 		}
 
+		// The rounding can yield t, which must be reduced to zero
+		if coeff.Cmp(ss.tBI) == 0 {
+			coeff.SetUint64(0)
+		}
+
 		for j := range p2.Coeffs {
 			p2.Coeffs[j][i] = coeff.Uint64()
 		}
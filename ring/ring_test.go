@@ -3,9 +3,11 @@ package ring
 import (
 	"flag"
 	"fmt"
+	"math"
 	"math/big"
 	"testing"
 
+	"github.com/ldsec/lattigo/v2/internal/prngtest"
 	"github.com/ldsec/lattigo/v2/utils"
 
 	"github.com/stretchr/testify/require"
@@ -47,6 +49,10 @@ func genTestParams(defaultParams Parameters) (testContext *testParams, err error
 	return
 }
 
+func TestMain(m *testing.M) {
+	prngtest.Main(m)
+}
+
 func TestRing(t *testing.T) {
 
 	var err error
@@ -74,15 +80,19 @@ func TestRing(t *testing.T) {
 		testMarshalBinary(testContext, t)
 		testUniformSampler(testContext, t)
 		testGaussianSampler(testContext, t)
+		testDiscreteGaussianSampler(testContext, t)
 		testTernarySampler(testContext, t)
 		testGaloisShift(testContext, t)
 		testModularReduction(testContext, t)
 		testMForm(testContext, t)
+		testLazyArithmetic(testContext, t)
 		testMulScalarBigint(testContext, t)
 		testMulPoly(testContext, t)
 		testExtendBasis(testContext, t)
 		testScaling(testContext, t)
 		testMultByMonomial(testContext, t)
+		testProfiling(testContext, t)
+		testPolyPool(testContext, t)
 	}
 }
 
@@ -278,6 +288,56 @@ func testMarshalBinary(testContext *testParams, t *testing.T) {
 			require.Equal(t, p.Coeffs[i][:testContext.ringQ.N], pTest.Coeffs[i][:testContext.ringQ.N])
 		}
 	})
+
+	t.Run(testString("MarshalBinary/PolyNTT/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ := testContext.ringQ
+
+		p := testContext.uniformSamplerQ.ReadNew()
+		pNTT := ringQ.NewPoly()
+		ringQ.NTT(p, pNTT)
+
+		for _, ordering := range []NTTOrdering{NTTBitReversed, NTTNatural} {
+
+			data, err := pNTT.MarshalBinaryNTT(ordering)
+			require.NoError(t, err)
+
+			pTest := new(Poly)
+			require.NoError(t, pTest.UnmarshalBinaryNTT(data))
+			require.True(t, ringQ.Equal(pNTT, pTest))
+
+			coeffs := pNTT.GetCoefficientsNTT(ordering)
+			pTest.Zero()
+			pTest.SetCoefficientsNTT(coeffs, ordering)
+			require.True(t, ringQ.Equal(pNTT, pTest))
+		}
+
+		// In the natural ordering, the j-th coefficient is the evaluation of p at psi^(2j+1)
+		coeffs := pNTT.GetCoefficientsNTT(NTTNatural)
+		q := ringQ.Modulus[0]
+		bredParams := ringQ.BredParams[0]
+		psi := InvMForm(ringQ.PsiMont[0], q, ringQ.MredParams[0])
+		for j := 0; j < 4; j++ {
+			x := ModExp(psi, 2*j+1, q)
+			var eval, xPow uint64 = 0, 1
+			for _, c := range p.Coeffs[0] {
+				eval = CRed(eval+BRed(c, xPow, q, bredParams), q)
+				xPow = BRed(xPow, x, q, bredParams)
+			}
+			require.Equal(t, eval, coeffs[0][j])
+		}
+
+		pTest := ringQ.NewPoly()
+		ringQ.PermuteNTTOrdering(pNTT, NTTBitReversed, NTTNatural, pTest)
+		require.Equal(t, coeffs[0], pTest.Coeffs[0])
+		ringQ.PermuteNTTOrdering(pTest, NTTNatural, NTTBitReversed, pTest)
+		require.True(t, ringQ.Equal(pNTT, pTest))
+
+		// Malformed headers are rejected before any allocation
+		require.Error(t, new(Poly).UnmarshalBinaryNTT([]byte{64, 1, 0}))
+		require.Error(t, new(Poly).UnmarshalBinaryNTT([]byte{maxLogN + 1, 1, 0}))
+		require.Error(t, new(Poly).UnmarshalBinaryNTT([]byte{4, 0, 0}))
+	})
 }
 
 func testUniformSampler(testContext *testParams, t *testing.T) {
@@ -316,6 +376,51 @@ func testGaussianSampler(testContext *testParams, t *testing.T) {
 	})
 }
 
+func testDiscreteGaussianSampler(testContext *testParams, t *testing.T) {
+
+	ringQ := testContext.ringQ
+
+	for _, constantTime := range []bool{false, true} {
+		for _, logSigma := range []float64{math.Log2(DefaultSigma), 40, 60} {
+
+			sigma := math.Exp2(logSigma)
+
+			t.Run(testString(fmt.Sprintf("DiscreteGaussianSampler/ConstantTime=%t/logSigma=%.2f/", constantTime, logSigma), ringQ), func(t *testing.T) {
+
+				gaussianSampler := NewDiscreteGaussianSampler(testContext.prng, ringQ, sigma, constantTime)
+				pol := gaussianSampler.ReadNew()
+
+				coeffsBigint := make([]*big.Int, ringQ.N)
+				ringQ.PolyToBigint(pol, coeffsBigint)
+
+				Q := ringQ.ModulusBigint
+				QHalf := new(big.Int).Rsh(Q, 1)
+
+				var sum, sumSquares float64
+				for _, c := range coeffsBigint {
+					if c.Cmp(QHalf) > 0 {
+						c.Sub(c, Q)
+					}
+					f, _ := new(big.Float).SetInt(c).Float64()
+					sum += f
+					sumSquares += f * f
+				}
+
+				N := float64(ringQ.N)
+				mean := sum / N
+				std := math.Sqrt(sumSquares/N - mean*mean)
+
+				require.Less(t, math.Abs(mean), 6*sigma/math.Sqrt(N))
+				require.InDelta(t, 1, std/sigma, 0.1)
+
+				// ReadAndAddLvl adds a sample on the polynomial
+				gaussianSampler.ReadAndAddLvl(len(ringQ.Modulus)-1, pol)
+				require.False(t, ringQ.Equal(pol, gaussianSampler.ReadNew()))
+			})
+		}
+	}
+}
+
 func testTernarySampler(testContext *testParams, t *testing.T) {
 
 	for _, p := range []float64{.5, 1. / 3., 128. / 65536.} {
@@ -360,6 +465,47 @@ func testTernarySampler(testContext *testParams, t *testing.T) {
 			}
 		})
 	}
+
+	t.Run(testString("TernarySampler/HammingWeight/Lvl/NTT/", testContext.ringQ), func(t *testing.T) {
+
+		prng, err := utils.NewPRNG()
+		if err != nil {
+			panic(err)
+		}
+
+		ringQ := testContext.ringQ
+		level := len(ringQ.Modulus) - 1
+		if level > 0 {
+			level--
+		}
+
+		hw := 64
+
+		ternarySampler := NewTernarySamplerWithHammingWeight(prng, ringQ, hw, true, true)
+
+		pol := ringQ.NewPolyLvl(level)
+
+		// Samples twice in the same polynomial to check that the previous coefficients are overwritten
+		for i := 0; i < 2; i++ {
+
+			ternarySampler.ReadLvl(level, pol)
+
+			polCoeffs := pol.CopyNew()
+			ringQ.InvNTTLvl(level, polCoeffs, polCoeffs)
+			ringQ.InvMFormLvl(level, polCoeffs, polCoeffs)
+
+			for j := 0; j < level+1; j++ {
+				count := 0
+				for _, c := range polCoeffs.Coeffs[j] {
+					require.True(t, c == 0 || c == 1 || c == ringQ.Modulus[j]-1)
+					if c != 0 {
+						count++
+					}
+				}
+				require.Equal(t, hw, count)
+			}
+		}
+	})
 }
 
 func testModularReduction(testContext *testParams, t *testing.T) {
@@ -536,6 +682,54 @@ func testMForm(testContext *testParams, t *testing.T) {
 	})
 }
 
+func testLazyArithmetic(testContext *testParams, t *testing.T) {
+
+	t.Run(testString("LazyArithmetic/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ := testContext.ringQ
+
+		requireLazy := func(pol *Poly) {
+			for i, qi := range ringQ.Modulus {
+				for _, c := range pol.Coeffs[i] {
+					require.Less(t, c, 2*qi)
+				}
+			}
+		}
+
+		p0 := testContext.uniformSamplerQ.ReadNew()
+		p1 := testContext.uniformSamplerQ.ReadNew()
+		ringQ.MForm(p1, p1)
+
+		// Exact evaluation of ((p0 * p1) * 18) - p0 in the NTT domain
+		polWant := ringQ.NewPoly()
+		tmp := ringQ.NewPoly()
+		ringQ.NTT(p0, tmp)
+		ringQ.MulCoeffsMontgomery(tmp, p1, polWant)
+		ringQ.MulScalar(polWant, 18, polWant)
+		ringQ.Sub(polWant, tmp, polWant)
+		ringQ.InvNTT(polWant, polWant)
+
+		// Lazy evaluation, with a single Reduce at the end
+		polTest := ringQ.NewPoly()
+		ringQ.NTTLazy(p0, tmp)
+		requireLazy(tmp)
+		ringQ.MulCoeffsMontgomeryLazy(tmp, p1, polTest)
+		requireLazy(polTest)
+		for i := 0; i < 8; i++ {
+			ringQ.MulCoeffsMontgomeryAndAddLazy(tmp, p1, polTest)
+			requireLazy(polTest)
+		}
+		ringQ.AddLazy(polTest, polTest, polTest) // 18 * p0 * p1
+		requireLazy(polTest)
+		ringQ.SubLazy(polTest, tmp, polTest)
+		requireLazy(polTest)
+		ringQ.InvNTTLazy(polTest, polTest)
+		requireLazy(polTest)
+
+		require.True(t, ringQ.Equal(polWant, polTest))
+	})
+}
+
 func testMulScalarBigint(testContext *testParams, t *testing.T) {
 
 	t.Run(testString("MulScalarBigint/", testContext.ringQ), func(t *testing.T) {
@@ -615,6 +809,101 @@ func testExtendBasis(testContext *testParams, t *testing.T) {
 			require.Equal(t, PolTest.Coeffs[i][:testContext.ringQ.N], PolWant.Coeffs[i][:testContext.ringQ.N])
 		}
 	})
+
+	t.Run(testString("ExtendBasis/Lvl/", testContext.ringQ), func(t *testing.T) {
+
+		levelQ, levelP := len(testContext.ringQ.Modulus)-2, len(testContext.ringP.Modulus)-1
+
+		if levelQ < 0 {
+			t.Skip("#Qi is 1")
+		}
+
+		basisextender := NewFastBasisExtenderLvl(levelQ, levelP, testContext.ringQ, testContext.ringP)
+
+		QLvl := NewUint(1)
+		for _, qi := range testContext.ringQ.Modulus[:levelQ+1] {
+			QLvl.Mul(QLvl, NewUint(qi))
+		}
+
+		coeffs := make([]*big.Int, testContext.ringQ.N)
+		for i := 0; i < testContext.ringQ.N; i++ {
+			coeffs[i] = RandInt(QLvl)
+		}
+
+		Pol := testContext.ringQ.NewPolyLvl(levelQ)
+		PolTest := testContext.ringP.NewPoly()
+		PolWant := testContext.ringP.NewPoly()
+
+		testContext.ringQ.SetCoefficientsBigintLvl(levelQ, coeffs, Pol)
+		testContext.ringP.SetCoefficientsBigint(coeffs, PolWant)
+
+		basisextender.ModUpSplitQP(levelQ, Pol, PolTest)
+
+		testContext.ringP.Reduce(PolTest, PolTest)
+
+		for i := range testContext.ringP.Modulus {
+			require.Equal(t, PolTest.Coeffs[i][:testContext.ringQ.N], PolWant.Coeffs[i][:testContext.ringQ.N])
+		}
+	})
+
+	t.Run(testString("ExtendBasis/Many/", testContext.ringQ), func(t *testing.T) {
+
+		levelQ, levelP := len(testContext.ringQ.Modulus)-2, len(testContext.ringP.Modulus)-2
+
+		if levelQ < 0 || levelP < 0 {
+			t.Skip("#Qi or #Pi is 1")
+		}
+
+		// A basis extender created for the maximum levels also extends the bases of the smaller levels
+		basisextender := NewFastBasisExtender(testContext.ringQ, testContext.ringP)
+
+		QLvl := NewUint(1)
+		for _, qi := range testContext.ringQ.Modulus[:levelQ+1] {
+			QLvl.Mul(QLvl, NewUint(qi))
+		}
+
+		PLvl := NewUint(1)
+		for _, pj := range testContext.ringP.Modulus[:levelP+1] {
+			PLvl.Mul(PLvl, NewUint(pj))
+		}
+
+		polsQ := []*Poly{testContext.ringQ.NewPolyLvl(levelQ), testContext.ringQ.NewPolyLvl(levelQ)}
+		polsP := []*Poly{testContext.ringP.NewPolyLvl(levelP), testContext.ringP.NewPolyLvl(levelP)}
+
+		polsWant := []*Poly{testContext.ringP.NewPolyLvl(levelP), testContext.ringP.NewPolyLvl(levelP)}
+
+		coeffs := make([]*big.Int, testContext.ringQ.N)
+		for k := range polsQ {
+			for i := range coeffs {
+				coeffs[i] = RandInt(QLvl)
+			}
+			testContext.ringQ.SetCoefficientsBigintLvl(levelQ, coeffs, polsQ[k])
+			testContext.ringP.SetCoefficientsBigintLvl(levelP, coeffs, polsWant[k])
+		}
+
+		basisextender.ModUpSplitQPMany(levelQ, levelP, polsQ, polsP)
+
+		for k := range polsP {
+			testContext.ringP.ReduceLvl(levelP, polsP[k], polsP[k])
+			require.True(t, testContext.ringP.EqualLvl(levelP, polsWant[k], polsP[k]))
+		}
+
+		for i := range coeffs {
+			coeffs[i] = RandInt(PLvl)
+		}
+
+		polP := testContext.ringP.NewPolyLvl(levelP)
+		polQ := testContext.ringQ.NewPolyLvl(levelQ)
+		polWant := testContext.ringQ.NewPolyLvl(levelQ)
+
+		testContext.ringP.SetCoefficientsBigintLvl(levelP, coeffs, polP)
+		testContext.ringQ.SetCoefficientsBigintLvl(levelQ, coeffs, polWant)
+
+		basisextender.ModUpSplitPQLvl(levelP, levelQ, polP, polQ)
+		testContext.ringQ.ReduceLvl(levelQ, polQ, polQ)
+
+		require.True(t, testContext.ringQ.EqualLvl(levelQ, polWant, polQ))
+	})
 }
 
 func testScaling(testContext *testParams, t *testing.T) {
@@ -693,3 +982,71 @@ func testMultByMonomial(testContext *testParams, t *testing.T) {
 		require.Equal(t, p3Want.Coeffs[0][:testContext.ringQ.N], p3Test.Coeffs[0][:testContext.ringQ.N])
 	})
 }
+
+func testProfiling(testContext *testParams, t *testing.T) {
+
+	t.Run(testString("Profiling/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ := testContext.ringQ
+		ringP := testContext.ringP
+
+		require.Equal(t, Fingerprint(ringQ.N, ringQ.Modulus), ringQ.Fingerprint())
+		require.NotEqual(t, ringQ.Fingerprint(), ringP.Fingerprint())
+
+		data, err := ringQ.MarshalBinary()
+		require.NoError(t, err)
+		ringQTest := new(Ring)
+		require.NoError(t, ringQTest.UnmarshalBinary(data))
+		require.Equal(t, ringQ.Fingerprint(), ringQTest.Fingerprint())
+
+		before := GetOperationCountsFor(ringQ.Fingerprint())
+
+		pol := testContext.uniformSamplerQ.ReadNew()
+		polP := ringP.NewPoly()
+		ringQ.NTTLvl(0, pol, pol)
+		ringQ.InvNTT(pol, pol)
+		NewFastBasisExtender(ringQ, ringP).ModUpSplitQP(len(ringQ.Modulus)-1, pol, polP)
+		ringQTest.CountKeySwitch()
+
+		after := GetOperationCountsFor(ringQ.Fingerprint())
+
+		expected := OperationCounts{}
+		if ProfilingEnabled() {
+			expected = OperationCounts{NTT: 1, InvNTT: uint64(len(ringQ.Modulus)), BasisExtension: 1, KeySwitch: 1}
+		}
+
+		require.Equal(t, before.Add(expected), after)
+	})
+}
+
+func testPolyPool(testContext *testParams, t *testing.T) {
+
+	t.Run(testString("PolyPool/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ := testContext.ringQ
+		level := len(ringQ.Modulus) - 1
+
+		pool := NewPolyPool()
+		require.True(t, pool.Enabled())
+
+		// Polynomials drawn from the pool are zero, even after being recycled dirty
+		for i := 0; i < 4; i++ {
+			pol := pool.Get(ringQ.N, level)
+			require.Equal(t, ringQ.N, len(pol.Coeffs[0]))
+			require.Equal(t, level, pol.Level())
+			require.True(t, ringQ.Equal(pol, ringQ.NewPoly()))
+			testContext.uniformSamplerQ.Read(pol)
+			pool.Put(pol)
+		}
+
+		pol := pool.Get(ringQ.N, 0)
+		require.Equal(t, 0, pol.Level())
+		pool.Put(pol)
+
+		pool.SetEnabled(false)
+		require.False(t, pool.Enabled())
+		pol = pool.Get(ringQ.N, level)
+		require.True(t, ringQ.Equal(pol, ringQ.NewPoly()))
+		pool.Put(pol)
+	})
+}
@@ -0,0 +1,295 @@
+package rlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// Operand is the interface of the ciphertexts and plaintexts of the RLWE schemes whose polynomials are stored in an
+// Element. It is implemented by Element, Ciphertext and Plaintext, and by the ciphertexts and plaintexts of the bfv
+// package, whose Operand is an alias of this interface.
+type Operand interface {
+	El() *Element
+	Degree() int
+	Level() int
+}
+
+// MetaData is the scheme-specific metadata of a Ciphertext or of a Plaintext. The metadata of a scheme which does not
+// use a field are set to their zero value, e.g. the BFV ciphertexts have no scale.
+type MetaData struct {
+	Scheme     Scheme    // Scheme of the element
+	Scale      float64   // Scale of the CKKS elements
+	IsReal     bool      // True if the slots of a CKKS element are flagged as purely real
+	SlotScales []float64 // Per-slot scaling factors of a CKKS element, nil if the slots have the scale only
+	Tags       Tags      // Tags of the element, nil if it has none
+}
+
+// CopyNew returns a deep copy of the receiver MetaData.
+func (md MetaData) CopyNew() MetaData {
+	if md.SlotScales != nil {
+		md.SlotScales = append([]float64(nil), md.SlotScales...)
+	}
+	md.Tags = md.Tags.CopyNew()
+	return md
+}
+
+// Ciphertext is a ciphertext of any of the RLWE schemes: its polynomials are stored in an Element and its
+// scheme-specific metadata in a MetaData, so that the utilities that do not depend on the scheme (e.g.
+// serialization, key-switching with a ReEncryptor or ring switching with a RingSwitcher) can be written once for
+// all the schemes. The bfv.Ciphertext and the ckks.Ciphertext embed a Ciphertext.
+type Ciphertext struct {
+	*Element
+	MetaData
+}
+
+// NewCiphertext returns a new Ciphertext of the given scheme, degree and level with zero values.
+func NewCiphertext(params Parameters, scheme Scheme, degree, level int) *Ciphertext {
+	return &Ciphertext{Element: NewElementAtLevel(params, degree, level), MetaData: MetaData{Scheme: scheme}}
+}
+
+// CopyNew creates a deep copy of the receiver Ciphertext and returns it.
+func (ct *Ciphertext) CopyNew() *Ciphertext {
+	return &Ciphertext{Element: ct.Element.CopyNew(), MetaData: ct.MetaData.CopyNew()}
+}
+
+// Plaintext is a plaintext of any of the RLWE schemes, i.e. an Element of degree 0 with scheme-specific metadata.
+// See Ciphertext.
+type Plaintext struct {
+	*Element
+	MetaData
+}
+
+// NewPlaintext returns a new Plaintext of the given scheme and level with zero values.
+func NewPlaintext(params Parameters, scheme Scheme, level int) *Plaintext {
+	return &Plaintext{Element: NewElementAtLevel(params, 0, level), MetaData: MetaData{Scheme: scheme}}
+}
+
+// CopyNew creates a deep copy of the receiver Plaintext and returns it.
+func (pt *Plaintext) CopyNew() *Plaintext {
+	return &Plaintext{Element: pt.Element.CopyNew(), MetaData: pt.MetaData.CopyNew()}
+}
+
+// metaDataLen is the length in bytes of the header of the encoding of an Element with its MetaData:
+// 1 byte : Scheme
+// 8 byte : Scale
+// 1 byte : flags (IsReal, has SlotScales, has Tags)
+// 1 byte : IsNTT
+// 1 byte : Degree + 1
+// The polynomials follow the header and, if any, the SlotScales (4 byte : length, 8 byte per scale) and the Tags
+// (see Tags.WriteTo).
+const metaDataLen = 12
+
+const (
+	flagIsReal = 1 << iota
+	flagSlotScales
+	flagTags
+)
+
+// GetDataLen returns the length in bytes of the target Ciphertext.
+func (ct *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
+	return getDataLen(ct.Element, ct.MetaData, WithMetaData)
+}
+
+// MarshalBinary encodes a Ciphertext and its MetaData on a byte slice.
+func (ct *Ciphertext) MarshalBinary() (data []byte, err error) {
+	return marshalElement(ct.Element, ct.MetaData)
+}
+
+// UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
+func (ct *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+	ct.Element, ct.MetaData, err = unmarshalElement(data)
+	return
+}
+
+// GetDataLen returns the length in bytes of the target Plaintext.
+func (pt *Plaintext) GetDataLen(WithMetaData bool) (dataLen int) {
+	return getDataLen(pt.Element, pt.MetaData, WithMetaData)
+}
+
+// MarshalBinary encodes a Plaintext and its MetaData on a byte slice.
+func (pt *Plaintext) MarshalBinary() (data []byte, err error) {
+	return marshalElement(pt.Element, pt.MetaData)
+}
+
+// UnmarshalBinary decodes a previously marshaled Plaintext on the target Plaintext.
+func (pt *Plaintext) UnmarshalBinary(data []byte) (err error) {
+	if pt.Element, pt.MetaData, err = unmarshalElement(data); err == nil && pt.Degree() != 0 {
+		return fmt.Errorf("cannot UnmarshalBinary: the element has degree %d instead of 0", pt.Degree())
+	}
+	return
+}
+
+func getDataLen(el *Element, md MetaData, WithMetaData bool) (dataLen int) {
+
+	if WithMetaData {
+		dataLen += metaDataLen
+		if md.SlotScales != nil {
+			dataLen += 4 + 8*len(md.SlotScales)
+		}
+		if len(md.Tags) != 0 {
+			dataLen += md.Tags.GetDataLen()
+		}
+	}
+
+	for _, p := range el.Value {
+		dataLen += p.GetDataLen(WithMetaData)
+	}
+
+	return dataLen
+}
+
+func marshalElement(el *Element, md MetaData) (data []byte, err error) {
+
+	data = make([]byte, getDataLen(el, md, true))
+
+	data[0] = uint8(md.Scheme)
+	binary.LittleEndian.PutUint64(data[1:9], math.Float64bits(md.Scale))
+	if md.IsReal {
+		data[9] |= flagIsReal
+	}
+	if md.SlotScales != nil {
+		data[9] |= flagSlotScales
+	}
+	if len(md.Tags) != 0 {
+		data[9] |= flagTags
+	}
+	if el.IsNTT {
+		data[10] = 1
+	}
+	data[11] = uint8(len(el.Value))
+
+	var pointer, inc int
+
+	pointer = metaDataLen
+
+	for _, p := range el.Value {
+
+		if inc, err = p.WriteTo(data[pointer:]); err != nil {
+			return nil, err
+		}
+
+		pointer += inc
+	}
+
+	if md.SlotScales != nil {
+		binary.LittleEndian.PutUint32(data[pointer:], uint32(len(md.SlotScales)))
+		pointer += 4
+		for _, scale := range md.SlotScales {
+			binary.LittleEndian.PutUint64(data[pointer:], math.Float64bits(scale))
+			pointer += 8
+		}
+	}
+
+	if len(md.Tags) != 0 {
+		if _, err = md.Tags.WriteTo(data[pointer:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func unmarshalElement(data []byte) (el *Element, md MetaData, err error) {
+
+	if len(data) < metaDataLen {
+		return nil, md, errors.New("too small bytearray")
+	}
+
+	if data[11] == 0 {
+		return nil, md, errors.New("invalid element: the degree+1 is zero")
+	}
+
+	md.Scheme = Scheme(data[0])
+	md.Scale = math.Float64frombits(binary.LittleEndian.Uint64(data[1:9]))
+	if data[9]&^(flagIsReal|flagSlotScales|flagTags) != 0 {
+		return nil, md, fmt.Errorf("invalid element: unknown flags %d", data[9])
+	}
+
+	md.IsReal = data[9]&flagIsReal != 0
+
+	el = &Element{IsNTT: data[10] == 1, Value: make([]*ring.Poly, data[11])}
+
+	var pointer, inc, polyLen int
+	pointer = metaDataLen
+
+	for i := range el.Value {
+
+		if polyLen, err = polyDataLen(data[pointer:]); err != nil {
+			return nil, md, err
+		}
+
+		if i > 0 && (data[pointer] != data[metaDataLen] || data[pointer+1] != data[metaDataLen+1]) {
+			return nil, md, errors.New("invalid element: the polynomials have different degrees or levels")
+		}
+
+		el.Value[i] = new(ring.Poly)
+
+		if inc, err = el.Value[i].DecodePolyNew(data[pointer : pointer+polyLen]); err != nil {
+			return nil, md, err
+		}
+
+		pointer += inc
+	}
+
+	if data[9]&flagSlotScales != 0 {
+
+		if len(data[pointer:]) < 4 {
+			return nil, md, errors.New("too small bytearray for the slot scales")
+		}
+
+		n := int(binary.LittleEndian.Uint32(data[pointer:]))
+		pointer += 4
+
+		if n > len(data[pointer:])/8 {
+			return nil, md, errors.New("too small bytearray for the slot scales")
+		}
+
+		md.SlotScales = make([]float64, n)
+		for i := range md.SlotScales {
+			md.SlotScales[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[pointer:]))
+			pointer += 8
+		}
+	}
+
+	if data[9]&flagTags != 0 {
+		if inc, err = md.Tags.Decode(data[pointer:]); err != nil {
+			return nil, md, err
+		}
+		pointer += inc
+	}
+
+	if pointer != len(data) {
+		return nil, md, errors.New("remaining unparsed data")
+	}
+
+	return el, md, nil
+}
+
+// polyDataLen returns the length in bytes of the encoding of the polynomial at the start of data, as written by
+// ring.Poly.WriteTo, and an error if data is too short to hold it or if its header is invalid.
+func polyDataLen(data []byte) (dataLen int, err error) {
+
+	if len(data) < 2 {
+		return 0, errors.New("too small bytearray: truncated polynomial header")
+	}
+
+	logN, nbModuli := int(data[0]), int(data[1])
+
+	if logN > MaxLogN {
+		return 0, fmt.Errorf("invalid polynomial: logN=%d is larger than MaxLogN=%d", logN, MaxLogN)
+	}
+
+	if nbModuli == 0 {
+		return 0, errors.New("invalid polynomial: no modulus")
+	}
+
+	if dataLen = 2 + (1<<logN)*nbModuli*8; len(data) < dataLen {
+		return 0, fmt.Errorf("too small bytearray: the polynomial needs %d bytes but %d remain", dataLen, len(data))
+	}
+
+	return dataLen, nil
+}
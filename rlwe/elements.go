@@ -28,6 +28,27 @@ func NewElementAtLevel(params Parameters, degree, level int) *Element {
 	return el
 }
 
+// NewElementAtLevelFromPool returns a new Element with zero values, whose polynomials are drawn from
+// ring.DefaultPolyPool. The element can be given back to the pool with Release once it is not used anymore.
+func NewElementAtLevelFromPool(params Parameters, degree, level int) *Element {
+	el := new(Element)
+	el.Value = make([]*ring.Poly, degree+1)
+	for i := 0; i < degree+1; i++ {
+		el.Value[i] = ring.DefaultPolyPool.Get(params.N(), level)
+	}
+	return el
+}
+
+// Release gives the polynomials of the target element back to ring.DefaultPolyPool. The element must not be used
+// after the call.
+func (el *Element) Release() {
+	for i := range el.Value {
+		ring.DefaultPolyPool.Put(el.Value[i])
+		el.Value[i] = nil
+	}
+	el.Value = nil
+}
+
 // SetValue sets the input slice of polynomials as the value of the target element.
 func (el *Element) SetValue(value []*ring.Poly) {
 	el.Value = value
@@ -84,6 +105,27 @@ func (el *Element) InvNTT(ringQ *ring.Ring, c *Element) {
 	}
 }
 
+// NTTBatch puts the target element in the NTT domain in place and sets its isNTT flag to true, transforming its
+// polynomials in parallel with ring.NTTBatchLvl. Unlike NTT, it can spawn up to runtime.NumCPU() goroutines, and
+// the polynomials of the element must not share their coefficients. If it is already in the NTT domain, it does
+// nothing.
+func (el *Element) NTTBatch(ringQ *ring.Ring) {
+	if !el.IsNTT {
+		ringQ.NTTBatchLvl(el.Level(), el.Value)
+		el.IsNTT = true
+	}
+}
+
+// InvNTTBatch puts the target element outside of the NTT domain in place and sets its isNTT flag to false,
+// transforming its polynomials in parallel with ring.InvNTTBatchLvl (see NTTBatch). If it is not in the NTT domain,
+// it does nothing.
+func (el *Element) InvNTTBatch(ringQ *ring.Ring) {
+	if el.IsNTT {
+		ringQ.InvNTTBatchLvl(el.Level(), el.Value)
+		el.IsNTT = false
+	}
+}
+
 // CopyNew creates a new element as a copy of the target element.
 func (el *Element) CopyNew() *Element {
 
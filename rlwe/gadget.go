@@ -0,0 +1,492 @@
+package rlwe
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Gadget is the interface of the gadget decompositions of the key-switching. A switching key from skIn to skOut for
+// a gadget g = (g_0, ..., g_{d-1}) is the vector of the RLWE encryptions under skOut of g_i * skIn, and the key-switch
+// of a polynomial c computes the gadget product sum_i h_i(c) * swk_i, where the digits h_i(c) are small polynomials
+// such that sum_i h_i(c) * g_i = F * c, for F = P if the gadget is scaled by the special modulus P and else F = 1.
+// The number and the size of the digits trade the size of the switching keys and the cost of the key-switch for the
+// noise of the key-switch, which is proportional to sum_i |h_i(c)| / F.
+//
+// The gadgets of the package are the RNSGadget, which is the decomposition used by the evaluators of the schemes, and
+// the DigitGadget, which decomposes each modulus of Q in digits of LogBase bits, with or without the special modulus
+// P (see NewBitGadget and NewHybridGadget). The switching keys of a gadget are generated by GenSwitchingKeyWithGadget,
+// GenRelinearizationKeyWithGadget and GenRotationKeysWithGadget, and are used by a KeySwitcher, which computes the
+// key-switch of a ReEncryptor created with NewReEncryptorWithGadget and of the bfv and ckks evaluators created with
+// their NewEvaluatorWithGadget.
+type Gadget interface {
+	// Digits returns the number of digits of the decomposition of a polynomial at the given level of Q. The digits at
+	// a lower level are a prefix of the digits at the maximum level, so that a switching key generated at the maximum
+	// level switches the ciphertexts of any level.
+	Digits(level int) int
+
+	// WithP returns true if the gadget is scaled by the special modulus P, in which case the switching keys and the
+	// gadget product are in the ring QP and the gadget product must be divided by P. Otherwise, the switching keys
+	// and the gadget product are in the ring Q.
+	WithP() bool
+
+	// AddGadget adds g_i * sk to p on the moduli of Q, where sk and p are in the NTT and Montgomery domain. The
+	// moduli of P are not modified, since g_i is zero modulo P.
+	AddGadget(i int, sk, p *ring.Poly)
+
+	// Decompose writes the i-th digit of the polynomial cx at the given level on c2QiQ and, if the gadget is scaled
+	// by P, on c2QiP, in the NTT domain. cxNTT and cx are cx in and out of the NTT domain.
+	Decompose(level, i int, cxNTT, cx, c2QiQ, c2QiP *ring.Poly)
+}
+
+// RNSGadget is the gadget decomposition of the RNS representation of Q in groups of #P moduli, scaled by P: the i-th
+// digit of c is the basis extension of c mod Q[i*#P:(i+1)*#P]. It has few large digits, whose noise is removed by
+// the division by P.
+type RNSGadget struct {
+	params     Parameters
+	ringQ      *ring.Ring
+	ringP      *ring.Ring
+	decomposer *ring.Decomposer
+	pModQi     []uint64
+}
+
+// NewRNSGadget creates a new RNSGadget for the parameters, which must have a non-empty modulus P.
+func NewRNSGadget(params Parameters) *RNSGadget {
+
+	if params.PCount() == 0 {
+		panic("cannot NewRNSGadget: modulus P is empty")
+	}
+
+	g := &RNSGadget{
+		params: params,
+		ringQ:  params.RingQ(),
+		ringP:  params.RingP(),
+	}
+
+	g.decomposer = ring.NewDecomposer(g.ringQ.Modulus, g.ringP.Modulus)
+	g.pModQi = bigIntModQi(params.PBigInt(), g.ringQ.Modulus)
+
+	return g
+}
+
+// Digits returns ceil((level+1)/#P).
+func (g *RNSGadget) Digits(level int) int {
+	return int(math.Ceil(float64(level+1) / float64(g.params.PCount())))
+}
+
+// WithP returns true.
+func (g *RNSGadget) WithP() bool {
+	return true
+}
+
+// AddGadget adds P * sk to p on the moduli Q[i*#P:(i+1)*#P].
+func (g *RNSGadget) AddGadget(i int, sk, p *ring.Poly) {
+	alpha := g.params.PCount()
+	for j := i * alpha; j < utils.MinInt((i+1)*alpha, g.params.QCount()); j++ {
+		addScaledLimb(g.ringQ, j, g.pModQi[j], sk, p)
+	}
+}
+
+// Decompose writes the basis extension of cx mod Q[i*#P:(i+1)*#P] to QP on c2QiQ and c2QiP.
+func (g *RNSGadget) Decompose(level, i int, cxNTT, cx, c2QiQ, c2QiP *ring.Poly) {
+
+	ringQ := g.ringQ
+
+	g.decomposer.DecomposeAndSplit(level, i, cx, c2QiQ, c2QiP)
+
+	// The limbs of the i-th decomposition are those of cx in the NTT domain
+	p0idxst := i * g.params.PCount()
+	p0idxed := p0idxst + g.decomposer.Xalpha()[i]
+	for x := 0; x < level+1; x++ {
+		if p0idxst <= x && x < p0idxed {
+			copy(c2QiQ.Coeffs[x], cxNTT.Coeffs[x])
+		} else {
+			ring.NTTLazy(c2QiQ.Coeffs[x], c2QiQ.Coeffs[x], ringQ.N, ringQ.NttPsi[x], ringQ.Modulus[x], ringQ.MredParams[x], ringQ.BredParams[x])
+		}
+	}
+	g.ringP.NTTLazy(c2QiP, c2QiP)
+}
+
+// DigitGadget is the gadget decomposition of each modulus of Q in digits of LogBase bits: the (i*d+j)-th digit of c,
+// for d = ceil(log2(max(Q_i))/LogBase), is the j-th digit in base 2^LogBase of c mod Q_i, and its gadget element is
+// F * 2^(j*LogBase) modulo Q_i and zero modulo the other moduli. A smaller base gives more digits, and thus larger
+// switching keys and a slower key-switch, but a smaller noise.
+//
+// Without the special modulus P (NewBitGadget), the noise of the key-switch, about sqrt(N) * #digits * 2^LogBase *
+// sigma, is not divided, but the key-switch needs neither P nor basis extension, which suits the small parameters
+// without P. With P (NewHybridGadget), the noise is divided by P, so that a P smaller than the RNS decomposition
+// requires suffices.
+type DigitGadget struct {
+	ringQ   *ring.Ring
+	ringP   *ring.Ring
+	logBase int
+	digits  int
+	withP   bool
+	factors [][]uint64
+}
+
+// NewBitGadget creates a new DigitGadget decomposing the moduli of Q in digits of logBase bits, without the special
+// modulus P. logBase must be positive and smaller than the bit-size of the smallest modulus.
+func NewBitGadget(params Parameters, logBase int) *DigitGadget {
+	return newDigitGadget(params, logBase, false, "NewBitGadget")
+}
+
+// NewHybridGadget creates a new DigitGadget decomposing the moduli of Q in digits of logBase bits and scaled by the
+// special modulus P, which must be non-empty. logBase must be positive and smaller than the bit-size of the smallest
+// modulus of Q and P.
+func NewHybridGadget(params Parameters, logBase int) *DigitGadget {
+	if params.PCount() == 0 {
+		panic("cannot NewHybridGadget: modulus P is empty")
+	}
+	return newDigitGadget(params, logBase, true, "NewHybridGadget")
+}
+
+func newDigitGadget(params Parameters, logBase int, withP bool, operation string) *DigitGadget {
+
+	g := &DigitGadget{
+		ringQ:   params.RingQ(),
+		logBase: logBase,
+		withP:   withP,
+	}
+
+	moduli := g.ringQ.Modulus
+	if withP {
+		g.ringP = params.RingP()
+		moduli = append(append([]uint64{}, moduli...), g.ringP.Modulus...)
+	}
+
+	minBits := 64
+	for _, qi := range moduli {
+		minBits = utils.MinInt(minBits, bits.Len64(qi))
+	}
+
+	if logBase < 1 || logBase >= minBits {
+		panic(fmt.Sprintf("cannot %s: logBase = %d must be in [1, %d)", operation, logBase, minBits))
+	}
+
+	g.digits = (bits.Len64(utils.MaxSliceUint64(g.ringQ.Modulus)) + logBase - 1) / logBase
+
+	F := big.NewInt(1)
+	if withP {
+		F = params.PBigInt()
+	}
+
+	// factors[j][i] = F * 2^(j*logBase) mod Q_i
+	g.factors = make([][]uint64, g.digits)
+	for j := range g.factors {
+		g.factors[j] = bigIntModQi(new(big.Int).Lsh(F, uint(j*logBase)), g.ringQ.Modulus)
+	}
+
+	return g
+}
+
+// LogBase returns the bit-size of the digits of the decomposition.
+func (g *DigitGadget) LogBase() int {
+	return g.logBase
+}
+
+// Digits returns (level+1) * ceil(log2(max(Q_i))/LogBase).
+func (g *DigitGadget) Digits(level int) int {
+	return (level + 1) * g.digits
+}
+
+// WithP returns true if the gadget is scaled by the special modulus P.
+func (g *DigitGadget) WithP() bool {
+	return g.withP
+}
+
+// AddGadget adds F * 2^(j*LogBase) * sk to p on the modulus Q_k, for i = k*d+j.
+func (g *DigitGadget) AddGadget(i int, sk, p *ring.Poly) {
+	k, j := i/g.digits, i%g.digits
+	addScaledLimb(g.ringQ, k, g.factors[j][k], sk, p)
+}
+
+// Decompose writes the j-th digit in base 2^LogBase of cx mod Q_k, for i = k*d+j, on c2QiQ and, if the gadget is
+// scaled by P, on c2QiP.
+func (g *DigitGadget) Decompose(level, i int, cxNTT, cx, c2QiQ, c2QiP *ring.Poly) {
+
+	k, j := i/g.digits, i%g.digits
+	shift, mask := uint64(j*g.logBase), uint64(1)<<uint64(g.logBase)-1
+
+	digit := c2QiQ.Coeffs[0]
+	for w, c := range cx.Coeffs[k] {
+		digit[w] = (c >> shift) & mask
+	}
+
+	// The digit is smaller than all the moduli
+	ringQ := g.ringQ
+	for x := 1; x < level+1; x++ {
+		copy(c2QiQ.Coeffs[x], digit)
+	}
+
+	if g.withP {
+		for x := range c2QiP.Coeffs {
+			copy(c2QiP.Coeffs[x], digit)
+		}
+		g.ringP.NTTLazy(c2QiP, c2QiP)
+	}
+
+	for x := 0; x < level+1; x++ {
+		ring.NTTLazy(c2QiQ.Coeffs[x], c2QiQ.Coeffs[x], ringQ.N, ringQ.NttPsi[x], ringQ.Modulus[x], ringQ.MredParams[x], ringQ.BredParams[x])
+	}
+}
+
+// bigIntModQi returns the residues of x modulo the moduli.
+func bigIntModQi(x *big.Int, moduli []uint64) (res []uint64) {
+	res = make([]uint64, len(moduli))
+	tmp := new(big.Int)
+	for i, qi := range moduli {
+		res[i] = tmp.Mod(x, ring.NewUint(qi)).Uint64()
+	}
+	return
+}
+
+// addScaledLimb adds scalar * sk to p on the k-th modulus of ringQ, where sk and p are in the Montgomery domain.
+func addScaledLimb(ringQ *ring.Ring, k int, scalar uint64, sk, p *ring.Poly) {
+
+	qi, bredParams, mredParams := ringQ.Modulus[k], ringQ.BredParams[k], ringQ.MredParams[k]
+	scalarMont := ring.MForm(scalar, qi, bredParams)
+
+	p0tmp, p1tmp := sk.Coeffs[k], p.Coeffs[k]
+	for w := 0; w < ringQ.N; w++ {
+		p1tmp[w] = ring.CRed(p1tmp[w]+ring.MRed(p0tmp[w], scalarMont, qi, mredParams), qi)
+	}
+}
+
+// GenSwitchingKeyWithGadget generates a switching key from skIn to skOut for the gadget decomposition gadget, which
+// is in the ring QP if the gadget is scaled by P and else in the ring Q. The switching keys of the RNSGadget are
+// those generated by the key generators of the schemes, up to the sampling.
+func GenSwitchingKeyWithGadget(params Parameters, gadget Gadget, skIn, skOut *SecretKey) (swk *SwitchingKey) {
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	ringQP := params.RingQ()
+	if gadget.WithP() {
+		ringQP = params.RingQP()
+	}
+
+	gaussianSampler := ring.NewGaussianSampler(prng, ringQP, params.Sigma(), int(6*params.Sigma()))
+	uniformSampler := ring.NewUniformSampler(prng, ringQP)
+
+	swk = &SwitchingKey{Value: make([][2]*ring.Poly, gadget.Digits(params.QCount()-1))}
+
+	for i := range swk.Value {
+
+		b, a := ringQP.NewPoly(), ringQP.NewPoly()
+
+		// e, in the NTT and Montgomery domain
+		gaussianSampler.Read(b)
+		ringQP.NTTLazy(b, b)
+		ringQP.MForm(b, b)
+
+		// a, which is uniform and is therefore considered already in the NTT and Montgomery domain
+		uniformSampler.Read(a)
+
+		// e + g_i * skIn - a * skOut
+		gadget.AddGadget(i, skIn.Value, b)
+		ringQP.MulCoeffsMontgomeryAndSub(a, skOut.Value, b)
+
+		swk.Value[i] = [2]*ring.Poly{b, a}
+	}
+
+	return
+}
+
+// GenRelinearizationKeyWithGadget generates a relinearization key of the given maximum degree for the secret key sk
+// and the gadget decomposition gadget, i.e. the switching keys from sk^(i+2) to sk for 0 <= i < maxDegree.
+func GenRelinearizationKeyWithGadget(params Parameters, gadget Gadget, sk *SecretKey, maxDegree int) (rlk *RelinearizationKey) {
+
+	ringQP := params.RingQP()
+
+	skPow := &SecretKey{Value: sk.Value.CopyNew()}
+
+	rlk = &RelinearizationKey{Keys: make([]*SwitchingKey, maxDegree)}
+	for i := range rlk.Keys {
+		ringQP.MulCoeffsMontgomery(skPow.Value, sk.Value, skPow.Value)
+		rlk.Keys[i] = GenSwitchingKeyWithGadget(params, gadget, skPow, sk)
+	}
+
+	return
+}
+
+// GenRotationKeysWithGadget generates a RotationKeySet for the Galois elements galEls, the secret key sk and the
+// gadget decomposition gadget. As for the key generators of the schemes, the key of the Galois element galEl switches
+// from sk to the automorphism of sk by the inverse of galEl.
+func GenRotationKeysWithGadget(params Parameters, gadget Gadget, galEls []uint64, sk *SecretKey) (rks *RotationKeySet) {
+
+	skOut := NewSecretKey(params)
+
+	rks = &RotationKeySet{Keys: make(map[uint64]*SwitchingKey, len(galEls))}
+	for _, galEl := range galEls {
+		ring.PermuteNTT(sk.Value, params.InverseGaloisElement(galEl), skOut.Value)
+		rks.Keys[galEl] = GenSwitchingKeyWithGadget(params, gadget, sk, skOut)
+	}
+
+	return
+}
+
+// KeySwitcher computes the key-switch of polynomials with the switching keys of a Gadget (see
+// GenSwitchingKeyWithGadget). With the RNSGadget, its result is the key-switch of the evaluators of the schemes.
+// A KeySwitcher is not safe for concurrent use: use ShallowCopy to obtain a KeySwitcher per goroutine.
+type KeySwitcher struct {
+	params Parameters
+	gadget Gadget
+
+	ringQ         *ring.Ring
+	ringP         *ring.Ring
+	baseconverter *ring.FastBasisExtender
+	pBigInt       *big.Int // the special modulus P, nil if the parameters have no P
+
+	poolQ *ring.Poly
+	poolP [3]*ring.Poly
+}
+
+// NewKeySwitcher creates a new KeySwitcher for the gadget decomposition gadget.
+func NewKeySwitcher(params Parameters, gadget Gadget) *KeySwitcher {
+
+	ks := &KeySwitcher{
+		params: params,
+		gadget: gadget,
+		ringQ:  params.RingQ(),
+	}
+
+	if gadget.WithP() {
+		ks.ringP = params.RingP()
+		ks.baseconverter = ring.NewFastBasisExtender(ks.ringQ, ks.ringP)
+	}
+
+	if params.PCount() != 0 {
+		ks.pBigInt = params.PBigInt()
+	}
+
+	ks.allocatePools()
+
+	return ks
+}
+
+func (ks *KeySwitcher) allocatePools() {
+
+	ks.poolQ = ks.ringQ.NewPoly()
+
+	if ks.ringP != nil {
+		for i := range ks.poolP {
+			ks.poolP[i] = ks.ringP.NewPoly()
+		}
+	}
+}
+
+// ShallowCopy creates a shallow copy of this KeySwitcher in which all the read-only data-structures are shared with
+// the receiver and the temporary buffers are reallocated. The receiver and the returned KeySwitcher can be used
+// concurrently. Returns nil if the receiver is nil.
+func (ks *KeySwitcher) ShallowCopy() *KeySwitcher {
+
+	if ks == nil {
+		return nil
+	}
+
+	ksCopy := &KeySwitcher{
+		params:  ks.params,
+		gadget:  ks.gadget,
+		ringQ:   ks.ringQ,
+		ringP:   ks.ringP,
+		pBigInt: ks.pBigInt,
+	}
+
+	if ks.baseconverter != nil {
+		ksCopy.baseconverter = ks.baseconverter.ShallowCopy()
+	}
+
+	ksCopy.allocatePools()
+
+	return ksCopy
+}
+
+// Gadget returns the gadget decomposition of the KeySwitcher.
+func (ks *KeySwitcher) Gadget() Gadget {
+	return ks.gadget
+}
+
+// SwitchKeys writes on p0 and p1, in the NTT domain, the key-switch of cx at the given level with the switching key
+// swk, i.e. the gadget product of cx with swk, divided by P if the gadget is scaled by P. cxNTT and cx are cx in and
+// out of the NTT domain, and p0 and p1 must be distinct from them.
+func (ks *KeySwitcher) SwitchKeys(level int, cxNTT, cx *ring.Poly, swk *SwitchingKey, p0, p1 *ring.Poly) {
+
+	if digits := ks.gadget.Digits(level); len(swk.Value) < digits {
+		panic(fmt.Sprintf("cannot SwitchKeys: the switching key has %d components but the gadget has %d digits", len(swk.Value), digits))
+	}
+
+	ks.ringQ.CountKeySwitch()
+
+	gadgetProductNoModDown(ks.params, ks.ringQ, ks.ringP, ks.gadget, level, cxNTT, cx, swk,
+		ks.poolQ, ks.poolP[2], [2]*ring.Poly{p0, p1}, [2]*ring.Poly{ks.poolP[0], ks.poolP[1]}, false)
+
+	if ks.gadget.WithP() {
+		ks.baseconverter.ModDownSplitNTTPQ(level, p0, ks.poolP[0], p0)
+		ks.baseconverter.ModDownSplitNTTPQ(level, p1, ks.poolP[1], p1)
+	}
+}
+
+// SwitchKeysNoModDown writes on (p0Q, p0P) and (p1Q, p1P), in the NTT domain and in basis QP, P times the key-switch
+// of cx at the given level with the switching key swk, whose division by P (e.g. with
+// ring.FastBasisExtender.ModDownSplitNTTPQ) gives the result of SwitchKeys. For a gadget scaled by P, it is the gadget
+// product before the division by P. Otherwise, it is the gadget product multiplied by P modulo Q and zero modulo P,
+// whose division by P is exact. The parameters must have a non-empty modulus P.
+func (ks *KeySwitcher) SwitchKeysNoModDown(level int, cxNTT, cx *ring.Poly, swk *SwitchingKey, p0Q, p1Q, p0P, p1P *ring.Poly) {
+	ks.switchKeysNoModDown(level, func(i int) (*ring.Poly, *ring.Poly) {
+		ks.gadget.Decompose(level, i, cxNTT, cx, ks.poolQ, ks.poolP[2])
+		return ks.poolQ, ks.poolP[2]
+	}, swk, p0Q, p1Q, p0P, p1P)
+}
+
+// Decompose writes the Digits(level) digits of cx at the given level on c2QiQ and, if the gadget is scaled by P, on
+// c2QiP, in the NTT domain, for the hoisted key-switches of SwitchKeysHoistedNoModDown. cxNTT and cx are cx in and out
+// of the NTT domain.
+func (ks *KeySwitcher) Decompose(level int, cxNTT, cx *ring.Poly, c2QiQ, c2QiP []*ring.Poly) {
+	for i := 0; i < ks.gadget.Digits(level); i++ {
+		var c2P *ring.Poly
+		if ks.gadget.WithP() {
+			c2P = c2QiP[i]
+		}
+		ks.gadget.Decompose(level, i, cxNTT, cx, c2QiQ[i], c2P)
+	}
+}
+
+// SwitchKeysHoistedNoModDown is SwitchKeysNoModDown for a polynomial decomposed by Decompose, whose decomposition is
+// shared by the key-switches with several switching keys (e.g. the rotations of a linear transformation).
+func (ks *KeySwitcher) SwitchKeysHoistedNoModDown(level int, c2QiQ, c2QiP []*ring.Poly, swk *SwitchingKey, p0Q, p1Q, p0P, p1P *ring.Poly) {
+	ks.switchKeysNoModDown(level, func(i int) (*ring.Poly, *ring.Poly) {
+		if ks.gadget.WithP() {
+			return c2QiQ[i], c2QiP[i]
+		}
+		return c2QiQ[i], nil
+	}, swk, p0Q, p1Q, p0P, p1P)
+}
+
+func (ks *KeySwitcher) switchKeysNoModDown(level int, digit func(i int) (*ring.Poly, *ring.Poly), swk *SwitchingKey, p0Q, p1Q, p0P, p1P *ring.Poly) {
+
+	if ks.pBigInt == nil {
+		panic("cannot SwitchKeysNoModDown: modulus P is empty")
+	}
+
+	if digits := ks.gadget.Digits(level); len(swk.Value) < digits {
+		panic(fmt.Sprintf("cannot SwitchKeysNoModDown: the switching key has %d components but the gadget has %d digits", len(swk.Value), digits))
+	}
+
+	ks.ringQ.CountKeySwitch()
+
+	gadgetProductDigitsNoModDown(ks.params, ks.ringQ, ks.ringP, ks.gadget, level, digit, swk, [2]*ring.Poly{p0Q, p1Q}, [2]*ring.Poly{p0P, p1P}, false)
+
+	if !ks.gadget.WithP() {
+		ks.ringQ.MulScalarBigintLvl(level, p0Q, ks.pBigInt, p0Q)
+		ks.ringQ.MulScalarBigintLvl(level, p1Q, ks.pBigInt, p1Q)
+		p0P.Zero()
+		p1P.Zero()
+	}
+}
@@ -0,0 +1,323 @@
+package rlwe
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// FormatVersion is the version of the binary format of the objects marshaled with a Header. It is incremented at
+// each incompatible change of the format of the header or of the marshaled objects.
+const FormatVersion uint8 = 1
+
+// HeaderSize is the size in bytes of a marshaled Header.
+const HeaderSize = 26
+
+// headerMagic are the first bytes of a marshaled Header, which distinguish the objects marshaled with a Header
+// from the raw output of their MarshalBinary method.
+var headerMagic = [4]byte{'L', 'T', 'G', 'O'}
+
+// ErrNoHeader is returned by Peek if the data do not start with a Header.
+var ErrNoHeader = errors.New("data do not start with a header")
+
+// ErrUnsupportedVersion is returned when the data were marshaled with a more recent FormatVersion.
+var ErrUnsupportedVersion = errors.New("unsupported format version")
+
+// ErrIncompatible is returned when the header of the data does not match the expected scheme, type or parameters.
+var ErrIncompatible = errors.New("incompatible object")
+
+// Scheme is the scheme of a marshaled object.
+type Scheme uint8
+
+const (
+	// SchemeRLWE is the scheme of the objects common to all the RLWE schemes.
+	SchemeRLWE Scheme = iota
+	// SchemeBFV is the scheme of the objects of the bfv package.
+	SchemeBFV
+	// SchemeCKKS is the scheme of the objects of the ckks package.
+	SchemeCKKS
+)
+
+// String returns the name of the scheme.
+func (s Scheme) String() string {
+	switch s {
+	case SchemeRLWE:
+		return "RLWE"
+	case SchemeBFV:
+		return "BFV"
+	case SchemeCKKS:
+		return "CKKS"
+	}
+	return fmt.Sprintf("Scheme(%d)", uint8(s))
+}
+
+// ObjectType is the type of a marshaled object.
+type ObjectType uint8
+
+const (
+	// ObjectUnknown is the type of the objects not described by the other types.
+	ObjectUnknown ObjectType = iota
+	// ObjectCiphertext is the type of the ciphertexts.
+	ObjectCiphertext
+	// ObjectPlaintext is the type of the plaintexts.
+	ObjectPlaintext
+	// ObjectSecretKey is the type of the SecretKey.
+	ObjectSecretKey
+	// ObjectPublicKey is the type of the PublicKey.
+	ObjectPublicKey
+	// ObjectSwitchingKey is the type of the SwitchingKey.
+	ObjectSwitchingKey
+	// ObjectRelinearizationKey is the type of the RelinearizationKey.
+	ObjectRelinearizationKey
+	// ObjectRotationKeySet is the type of the RotationKeySet.
+	ObjectRotationKeySet
+)
+
+// String returns the name of the object type.
+func (t ObjectType) String() string {
+	switch t {
+	case ObjectUnknown:
+		return "Unknown"
+	case ObjectCiphertext:
+		return "Ciphertext"
+	case ObjectPlaintext:
+		return "Plaintext"
+	case ObjectSecretKey:
+		return "SecretKey"
+	case ObjectPublicKey:
+		return "PublicKey"
+	case ObjectSwitchingKey:
+		return "SwitchingKey"
+	case ObjectRelinearizationKey:
+		return "RelinearizationKey"
+	case ObjectRotationKeySet:
+		return "RotationKeySet"
+	}
+	return fmt.Sprintf("ObjectType(%d)", uint8(t))
+}
+
+// Header is the self-describing header of a marshaled object. It identifies the version of the format, the
+// scheme and the type of the object, the parameters with which it was created (by a hash of their binary
+// encoding) and the metadata of the ciphertexts and plaintexts, so that a receiver can check that it can
+// unmarshal the object before decoding it.
+//
+// The Header is encoded on HeaderSize bytes as follows:
+// 4 bytes : magic "LTGO"
+// 1 byte  : Version
+// 1 byte  : Scheme
+// 1 byte  : Type
+// 8 bytes : ParamsHash
+// 1 byte  : Level
+// 1 byte  : Degree
+// 8 bytes : Scale
+// 1 byte  : IsNTT
+type Header struct {
+	Version    uint8
+	Scheme     Scheme
+	Type       ObjectType
+	ParamsHash [8]byte
+	Level      int
+	Degree     int
+	Scale      float64
+	IsNTT      bool
+}
+
+// ParametersHash returns the hash of the parameters identifying them in a Header: the first 8 bytes of the
+// SHA-256 digest of their binary encoding.
+func ParametersHash(params encoding.BinaryMarshaler) (hash [8]byte, err error) {
+
+	var data []byte
+	if data, err = params.MarshalBinary(); err != nil {
+		return hash, err
+	}
+
+	digest := sha256.Sum256(data)
+	copy(hash[:], digest[:])
+
+	return hash, nil
+}
+
+// NewHeader returns the Header of the SecretKey, PublicKey, SwitchingKey, RelinearizationKey, RotationKeySet, Element,
+// Ciphertext or Plaintext obj, of the given scheme and parameters hash, at the current FormatVersion. The level is the
+// level of the modulus Q, the keys, which are in the ring QP, have the level of their last modulus, and the degree is
+// the number of relinearizable degrees of a RelinearizationKey and the degree of an Element.
+// Returns an error if obj is of another type.
+func NewHeader(scheme Scheme, paramsHash [8]byte, obj interface{}) (h Header, err error) {
+
+	h = Header{Version: FormatVersion, Scheme: scheme, ParamsHash: paramsHash}
+
+	switch obj := obj.(type) {
+	case *SecretKey:
+		h.Type = ObjectSecretKey
+		h.IsNTT = true
+		if obj.Value != nil {
+			h.Level = obj.Value.Level()
+		}
+	case *PublicKey:
+		h.Type = ObjectPublicKey
+		h.Degree = 1
+		h.IsNTT = true
+		if obj.Value[0] != nil {
+			h.Level = obj.Value[0].Level()
+		}
+	case *SwitchingKey:
+		h.Type = ObjectSwitchingKey
+		h.IsNTT = true
+		if len(obj.Value) > 0 && obj.Value[0][0] != nil {
+			h.Level = obj.Value[0][0].Level()
+		}
+	case *RelinearizationKey:
+		h.Type = ObjectRelinearizationKey
+		h.Degree = len(obj.Keys)
+		h.IsNTT = true
+	case *RotationKeySet:
+		h.Type = ObjectRotationKeySet
+		h.IsNTT = true
+	case *Element:
+		h.Type = ObjectCiphertext
+		if len(obj.Value) > 0 {
+			h.Level = obj.Level()
+			h.Degree = obj.Degree()
+			h.IsNTT = obj.IsNTT
+		}
+	case *Ciphertext:
+		if obj.Element == nil {
+			return NewHeader(scheme, paramsHash, new(Element))
+		}
+		if h, err = NewHeader(scheme, paramsHash, obj.Element); err != nil {
+			return h, err
+		}
+		h.Scale = obj.Scale
+	case *Plaintext:
+		if obj.Element != nil {
+			if h, err = NewHeader(scheme, paramsHash, obj.Element); err != nil {
+				return h, err
+			}
+		}
+		h.Type = ObjectPlaintext
+		h.Scale = obj.Scale
+	default:
+		return h, fmt.Errorf("cannot NewHeader: unsupported type %T", obj)
+	}
+
+	return h, nil
+}
+
+// MarshalBinary encodes the Header on HeaderSize bytes.
+func (h Header) MarshalBinary() (data []byte, err error) {
+
+	if h.Level < 0 || h.Level > math.MaxUint8 || h.Degree < 0 || h.Degree > math.MaxUint8 {
+		return nil, fmt.Errorf("cannot MarshalBinary: level %d or degree %d does not fit on a byte", h.Level, h.Degree)
+	}
+
+	data = make([]byte, HeaderSize)
+	copy(data[:4], headerMagic[:])
+	data[4] = h.Version
+	data[5] = uint8(h.Scheme)
+	data[6] = uint8(h.Type)
+	copy(data[7:15], h.ParamsHash[:])
+	data[15] = uint8(h.Level)
+	data[16] = uint8(h.Degree)
+	binary.LittleEndian.PutUint64(data[17:25], math.Float64bits(h.Scale))
+	if h.IsNTT {
+		data[25] = 1
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a Header from the first HeaderSize bytes of data. It does not check the version.
+func (h *Header) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < HeaderSize || string(data[:4]) != string(headerMagic[:]) {
+		return ErrNoHeader
+	}
+
+	h.Version = data[4]
+	h.Scheme = Scheme(data[5])
+	h.Type = ObjectType(data[6])
+	copy(h.ParamsHash[:], data[7:15])
+	h.Level = int(data[15])
+	h.Degree = int(data[16])
+	h.Scale = math.Float64frombits(binary.LittleEndian.Uint64(data[17:25]))
+	h.IsNTT = data[25] == 1
+
+	return nil
+}
+
+// Peek returns the Header of data, without decoding the object. Returns ErrNoHeader if data do not start with a
+// Header, and an error wrapping ErrUnsupportedVersion, along with the Header, if data were marshaled with a
+// more recent FormatVersion.
+func Peek(data []byte) (h Header, err error) {
+
+	if err = h.UnmarshalBinary(data); err != nil {
+		return h, err
+	}
+
+	if h.Version == 0 || h.Version > FormatVersion {
+		return h, fmt.Errorf("%w: version %d, supported versions are 1 to %d", ErrUnsupportedVersion, h.Version, FormatVersion)
+	}
+
+	return h, nil
+}
+
+// CheckCompatible returns an error wrapping ErrIncompatible if the scheme, the type or the parameters hash of the
+// Header do not match the ones of want.
+func (h Header) CheckCompatible(want Header) error {
+
+	if h.Scheme != want.Scheme {
+		return fmt.Errorf("%w: scheme is %s but %s is expected", ErrIncompatible, h.Scheme, want.Scheme)
+	}
+
+	if h.Type != want.Type {
+		return fmt.Errorf("%w: type is %s but %s is expected", ErrIncompatible, h.Type, want.Type)
+	}
+
+	if h.ParamsHash != want.ParamsHash {
+		return fmt.Errorf("%w: parameters hash is %x but %x is expected", ErrIncompatible, h.ParamsHash, want.ParamsHash)
+	}
+
+	return nil
+}
+
+// MarshalWithHeader encodes obj on a slice of bytes prefixed by the Header h.
+func MarshalWithHeader(h Header, obj encoding.BinaryMarshaler) (data []byte, err error) {
+
+	var header, payload []byte
+
+	if header, err = h.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	if payload, err = obj.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	return append(header, payload...), nil
+}
+
+// UnmarshalWithHeader decodes data, previously marshaled with MarshalWithHeader, on obj and returns its Header.
+// Returns an error, without decoding the object, if data do not start with a Header, if the version is not
+// supported, or if the Header is not compatible with want (see CheckCompatible). A panic of the decoding of a
+// malformed object is recovered and returned as an error.
+func UnmarshalWithHeader(data []byte, want Header, obj encoding.BinaryUnmarshaler) (h Header, err error) {
+
+	if h, err = Peek(data); err != nil {
+		return h, err
+	}
+
+	if err = h.CheckCompatible(want); err != nil {
+		return h, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot UnmarshalWithHeader: malformed %s: %v", h.Type, r)
+		}
+	}()
+
+	return h, obj.UnmarshalBinary(data[HeaderSize:])
+}
@@ -0,0 +1,104 @@
+package rlwe
+
+import (
+	"sort"
+)
+
+// KeyRequest aggregates the Galois elements required by several planned homomorphic operations, so that a
+// single RotationKeySet containing exactly one key per distinct automorphism can be generated for all of them.
+// The rotations of the planned operations are given by the RotationsFor* methods of the scheme parameters.
+// Equivalent rotations (e.g. by k and k+N/2) and the identity are merged. The Galois elements are then passed to
+// the GenRotationKeys method of the key generator of the scheme:
+//
+//	kr := rlwe.NewKeyRequest(params.Parameters)
+//	kr.AddRotations(params.RotationsForInnerSumLog(batch, n)...)
+//	kr.AddRotations(params.RotationsForLinearTransform(lt)...)
+//	kr.AddConjugation()
+//	rtks := kgen.GenRotationKeys(kr.GaloisElements(), sk)
+type KeyRequest struct {
+	params Parameters
+	galEls map[uint64]bool
+}
+
+// NewKeyRequest creates a new empty KeyRequest for the given parameters.
+func NewKeyRequest(params Parameters) *KeyRequest {
+	return &KeyRequest{params: params, galEls: make(map[uint64]bool)}
+}
+
+// AddRotations adds the Galois elements of the left rotations of the slots by k positions for all the given k.
+// A negative k is a right rotation. It returns the receiver to allow chaining.
+func (kr *KeyRequest) AddRotations(ks ...int) *KeyRequest {
+	for _, k := range ks {
+		kr.AddGaloisElements(kr.params.GaloisElementForColumnRotationBy(k))
+	}
+	return kr
+}
+
+// AddSlotRotations adds the Galois elements of the given SlotRotations. It returns the receiver to allow chaining.
+func (kr *KeyRequest) AddSlotRotations(rots ...SlotRotation) *KeyRequest {
+	return kr.AddGaloisElements(GaloisElementsForSlotRotations(kr.params, rots)...)
+}
+
+// AddConjugation adds the Galois element of the row rotation, i.e. the swap of the rows of BFV or the complex
+// conjugation of the slots of CKKS. It returns the receiver to allow chaining.
+func (kr *KeyRequest) AddConjugation() *KeyRequest {
+	return kr.AddGaloisElements(kr.params.GaloisElementForRowRotation())
+}
+
+// AddRowInnerSum adds the Galois elements required by the InnerSum operation of the Evaluators (see
+// Parameters.GaloisElementsForRowInnerSum). It returns the receiver to allow chaining.
+func (kr *KeyRequest) AddRowInnerSum() *KeyRequest {
+	return kr.AddGaloisElements(kr.params.GaloisElementsForRowInnerSum()...)
+}
+
+// AddGaloisElements adds the given Galois elements. The identity is ignored. It returns the receiver to allow
+// chaining.
+func (kr *KeyRequest) AddGaloisElements(galEls ...uint64) *KeyRequest {
+	mask := uint64(kr.params.N()<<1) - 1
+	for _, galEl := range galEls {
+		if galEl&mask != 1 {
+			kr.galEls[galEl&mask] = true
+		}
+	}
+	return kr
+}
+
+// Merge adds the Galois elements of other to the receiver. It returns the receiver to allow chaining.
+func (kr *KeyRequest) Merge(other *KeyRequest) *KeyRequest {
+	for galEl := range other.galEls {
+		kr.galEls[galEl] = true
+	}
+	return kr
+}
+
+// Len returns the number of distinct Galois elements of the request, i.e. the number of keys it requires.
+func (kr *KeyRequest) Len() int {
+	return len(kr.galEls)
+}
+
+// Contains returns true if the request contains the Galois element.
+func (kr *KeyRequest) Contains(galEl uint64) bool {
+	return kr.galEls[galEl&(uint64(kr.params.N()<<1)-1)]
+}
+
+// GaloisElements returns the distinct Galois elements of the request in increasing order.
+func (kr *KeyRequest) GaloisElements() (galEls []uint64) {
+	galEls = make([]uint64, 0, len(kr.galEls))
+	for galEl := range kr.galEls {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+	return
+}
+
+// Missing returns the Galois elements of the request for which rtks does not contain a key, in increasing order.
+func (kr *KeyRequest) Missing(rtks *RotationKeySet) (galEls []uint64) {
+	for _, galEl := range kr.GaloisElements() {
+		if rtks == nil {
+			galEls = append(galEls, galEl)
+		} else if _, ok := rtks.Keys[galEl]; !ok {
+			galEls = append(galEls, galEl)
+		}
+	}
+	return
+}
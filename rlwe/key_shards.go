@@ -0,0 +1,347 @@
+package rlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// KeyShardKind is the kind of the switching key a shard belongs to.
+type KeyShardKind uint8
+
+const (
+	// RelinearizationKeyShard is the kind of the shards of a RelinearizationKey.
+	RelinearizationKeyShard = KeyShardKind(iota)
+	// RotationKeyShard is the kind of the shards of the keys of a RotationKeySet.
+	RotationKeyShard
+)
+
+// KeyShardID identifies a shard of a sharded EvaluationKey: the decomposition digit Digit of the switching key
+// of the given kind and index. The index is the position in RelinearizationKey.Keys for a RelinearizationKeyShard
+// and the Galois element for a RotationKeyShard.
+type KeyShardID struct {
+	Kind  KeyShardKind
+	Index uint64
+	Digit int
+}
+
+// String returns the name of the shard, e.g. "rlk-0-1" or "rtk-25-0", which can be used as the name of the
+// object storing it.
+func (id KeyShardID) String() string {
+	switch id.Kind {
+	case RelinearizationKeyShard:
+		return fmt.Sprintf("rlk-%d-%d", id.Index, id.Digit)
+	case RotationKeyShard:
+		return fmt.Sprintf("rtk-%d-%d", id.Index, id.Digit)
+	default:
+		return fmt.Sprintf("unknown%d-%d-%d", id.Kind, id.Index, id.Digit)
+	}
+}
+
+// KeyShardManifestEntry describes a sharded switching key: its kind, its index, its number of decomposition
+// digits (one shard per digit) and the size in bytes of each of its shards.
+type KeyShardManifestEntry struct {
+	Kind      KeyShardKind
+	Index     uint64
+	Digits    int
+	ShardSize int
+}
+
+// KeyShardManifest is the index of a sharded EvaluationKey. Each switching key of the EvaluationKey is split
+// into one independently loadable shard per decomposition digit, storing the two polynomials of the digit. An
+// evaluation server can therefore fetch the manifest and then only the shards of the keys required by a job
+// (e.g. the Galois elements of a KeyRequest) instead of the entire key set.
+type KeyShardManifest struct {
+	Entries []KeyShardManifestEntry
+}
+
+// KeyShardLoader is a function returning the data of the shard with the given identifier, e.g. by downloading
+// the object named id.String() from an object storage.
+type KeyShardLoader func(id KeyShardID) ([]byte, error)
+
+// MarshalShards splits the EvaluationKey into shards, one per decomposition digit of each of its switching keys,
+// and returns their index along with the data of each shard.
+func (evk EvaluationKey) MarshalShards() (manifest *KeyShardManifest, shards map[KeyShardID][]byte, err error) {
+
+	manifest = new(KeyShardManifest)
+	shards = make(map[KeyShardID][]byte)
+
+	addSwitchingKey := func(kind KeyShardKind, index uint64, swk *SwitchingKey) error {
+
+		entry := KeyShardManifestEntry{Kind: kind, Index: index, Digits: len(swk.Value)}
+
+		for j := range swk.Value {
+
+			var data []byte
+			if data, err = marshalKeyShard(swk.Value[j]); err != nil {
+				return err
+			}
+
+			if j == 0 {
+				entry.ShardSize = len(data)
+			} else if len(data) != entry.ShardSize {
+				return fmt.Errorf("cannot MarshalShards: the digits of the switching key %s have different sizes", KeyShardID{kind, index, j})
+			}
+
+			shards[KeyShardID{Kind: kind, Index: index, Digit: j}] = data
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+
+		return nil
+	}
+
+	if evk.Rlk != nil {
+		for i, swk := range evk.Rlk.Keys {
+			if err = addSwitchingKey(RelinearizationKeyShard, uint64(i), swk); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if evk.Rtks != nil {
+
+		galEls := make([]uint64, 0, len(evk.Rtks.Keys))
+		for galEl := range evk.Rtks.Keys {
+			galEls = append(galEls, galEl)
+		}
+		sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+		for _, galEl := range galEls {
+			if err = addSwitchingKey(RotationKeyShard, galEl, evk.Rtks.Keys[galEl]); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return manifest, shards, nil
+}
+
+// GaloisElements returns the Galois elements of the rotation keys indexed by the manifest, in increasing order.
+func (manifest *KeyShardManifest) GaloisElements() (galEls []uint64) {
+	for _, entry := range manifest.Entries {
+		if entry.Kind == RotationKeyShard {
+			galEls = append(galEls, entry.Index)
+		}
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+	return
+}
+
+// ShardIDs returns the identifiers of the shards of the relinearization key, if withRelinearizationKey is true,
+// and of the rotation keys for the given Galois elements. It returns an error if one of the keys is not
+// indexed by the manifest.
+func (manifest *KeyShardManifest) ShardIDs(withRelinearizationKey bool, galEls []uint64) (ids []KeyShardID, err error) {
+
+	var entries []KeyShardManifestEntry
+
+	if withRelinearizationKey {
+		if entries = manifest.entries(RelinearizationKeyShard); len(entries) == 0 {
+			return nil, errors.New("cannot ShardIDs: the manifest does not index a relinearization key")
+		}
+	}
+
+	for _, galEl := range galEls {
+		entry, ok := manifest.entry(RotationKeyShard, galEl)
+		if !ok {
+			return nil, fmt.Errorf("cannot ShardIDs: the manifest does not index a rotation key for the Galois element %d", galEl)
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, entry := range entries {
+		for j := 0; j < entry.Digits; j++ {
+			ids = append(ids, KeyShardID{Kind: entry.Kind, Index: entry.Index, Digit: j})
+		}
+	}
+
+	return ids, nil
+}
+
+// LoadRelinearizationKey loads the shards of the relinearization key with load and returns the reassembled key.
+func (manifest *KeyShardManifest) LoadRelinearizationKey(load KeyShardLoader) (rlk *RelinearizationKey, err error) {
+
+	entries := manifest.entries(RelinearizationKeyShard)
+	if len(entries) == 0 {
+		return nil, errors.New("cannot LoadRelinearizationKey: the manifest does not index a relinearization key")
+	}
+
+	rlk = &RelinearizationKey{Keys: make([]*SwitchingKey, len(entries))}
+	for _, entry := range entries {
+		if entry.Index >= uint64(len(entries)) {
+			return nil, fmt.Errorf("cannot LoadRelinearizationKey: invalid relinearization key index %d", entry.Index)
+		}
+		if rlk.Keys[entry.Index] != nil {
+			return nil, fmt.Errorf("cannot LoadRelinearizationKey: duplicate relinearization key index %d", entry.Index)
+		}
+		if rlk.Keys[entry.Index], err = loadSwitchingKey(entry, load); err != nil {
+			return nil, err
+		}
+	}
+
+	return rlk, nil
+}
+
+// LoadRotationKeys loads the shards of the rotation keys for the given Galois elements with load and returns
+// the reassembled RotationKeySet. The shards of the other rotation keys are not loaded.
+func (manifest *KeyShardManifest) LoadRotationKeys(galEls []uint64, load KeyShardLoader) (rtks *RotationKeySet, err error) {
+
+	rtks = &RotationKeySet{Keys: make(map[uint64]*SwitchingKey, len(galEls))}
+
+	for _, galEl := range galEls {
+
+		entry, ok := manifest.entry(RotationKeyShard, galEl)
+		if !ok {
+			return nil, fmt.Errorf("cannot LoadRotationKeys: the manifest does not index a rotation key for the Galois element %d", galEl)
+		}
+
+		if rtks.Keys[galEl], err = loadSwitchingKey(entry, load); err != nil {
+			return nil, err
+		}
+	}
+
+	return rtks, nil
+}
+
+// MarshalBinary encodes the KeyShardManifest in a byte slice.
+func (manifest *KeyShardManifest) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, 4+len(manifest.Entries)*14)
+
+	binary.BigEndian.PutUint32(data, uint32(len(manifest.Entries)))
+
+	pointer := 4
+	for _, entry := range manifest.Entries {
+
+		if entry.Digits > 0xFF {
+			return nil, fmt.Errorf("cannot MarshalBinary: the switching key %s has too many digits", KeyShardID{entry.Kind, entry.Index, 0})
+		}
+
+		data[pointer] = uint8(entry.Kind)
+		binary.BigEndian.PutUint64(data[pointer+1:], entry.Index)
+		data[pointer+9] = uint8(entry.Digits)
+		binary.BigEndian.PutUint32(data[pointer+10:], uint32(entry.ShardSize))
+		pointer += 14
+	}
+
+	return data[:pointer], nil
+}
+
+// UnmarshalBinary decodes a previously marshaled KeyShardManifest in the target KeyShardManifest.
+func (manifest *KeyShardManifest) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 4 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	nbEntries := int(binary.BigEndian.Uint32(data))
+
+	if len(data) != 4+nbEntries*14 {
+		return errors.New("cannot UnmarshalBinary: invalid data length")
+	}
+
+	entries := make([]KeyShardManifestEntry, nbEntries)
+	indexed := make(map[KeyShardID]bool, nbEntries)
+
+	pointer := 4
+	for i := range entries {
+		entries[i] = KeyShardManifestEntry{
+			Kind:      KeyShardKind(data[pointer]),
+			Index:     binary.BigEndian.Uint64(data[pointer+1:]),
+			Digits:    int(data[pointer+9]),
+			ShardSize: int(binary.BigEndian.Uint32(data[pointer+10:])),
+		}
+		pointer += 14
+
+		id := KeyShardID{Kind: entries[i].Kind, Index: entries[i].Index}
+		if indexed[id] {
+			return fmt.Errorf("cannot UnmarshalBinary: duplicate entry for the switching key %s", id)
+		}
+		indexed[id] = true
+	}
+
+	manifest.Entries = entries
+
+	return nil
+}
+
+func (manifest *KeyShardManifest) entry(kind KeyShardKind, index uint64) (KeyShardManifestEntry, bool) {
+	for _, entry := range manifest.Entries {
+		if entry.Kind == kind && entry.Index == index {
+			return entry, true
+		}
+	}
+	return KeyShardManifestEntry{}, false
+}
+
+func (manifest *KeyShardManifest) entries(kind KeyShardKind) (entries []KeyShardManifestEntry) {
+	for _, entry := range manifest.Entries {
+		if entry.Kind == kind {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+func loadSwitchingKey(entry KeyShardManifestEntry, load KeyShardLoader) (swk *SwitchingKey, err error) {
+
+	swk = &SwitchingKey{Value: make([][2]*ring.Poly, entry.Digits)}
+
+	for j := range swk.Value {
+
+		id := KeyShardID{Kind: entry.Kind, Index: entry.Index, Digit: j}
+
+		var data []byte
+		if data, err = load(id); err != nil {
+			return nil, err
+		}
+
+		if len(data) != entry.ShardSize {
+			return nil, fmt.Errorf("cannot load the shard %s: invalid size %d, expected %d", id, len(data), entry.ShardSize)
+		}
+
+		if swk.Value[j], err = unmarshalKeyShard(data); err != nil {
+			return nil, err
+		}
+	}
+
+	return swk, nil
+}
+
+func marshalKeyShard(digit [2]*ring.Poly) (data []byte, err error) {
+
+	data = make([]byte, digit[0].GetDataLen(true)+digit[1].GetDataLen(true))
+
+	var pointer int
+	for _, pol := range digit {
+		var inc int
+		if inc, err = pol.WriteTo(data[pointer : pointer+pol.GetDataLen(true)]); err != nil {
+			return nil, err
+		}
+		pointer += inc
+	}
+
+	return data, nil
+}
+
+func unmarshalKeyShard(data []byte) (digit [2]*ring.Poly, err error) {
+
+	var pointer int
+	for i := range digit {
+		digit[i] = new(ring.Poly)
+		var inc int
+		if inc, err = digit[i].DecodePolyNew(data[pointer:]); err != nil {
+			return digit, err
+		}
+		pointer += inc
+	}
+
+	if pointer != len(data) {
+		return digit, errors.New("cannot unmarshal the shard: invalid data length")
+	}
+
+	return digit, nil
+}
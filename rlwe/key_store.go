@@ -0,0 +1,187 @@
+package rlwe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const rotationKeyFilePrefix = "rtk-"
+
+// RotationKeyStore is a RotationKeyProvider backed by a directory storing one serialized rotation key per file, for
+// the sets of rotation keys too large to be held in memory (e.g., the bootstrapping keys). The keys are loaded from
+// the disk when they are requested, and the most recently used ones are kept in a cache of bounded size.
+// A RotationKeyStore is safe for concurrent use.
+type RotationKeyStore struct {
+	dir       string
+	cacheSize int
+
+	mu    sync.Mutex
+	cache map[uint64]*SwitchingKey
+	lru   []uint64 // Galois elements of the cached keys, from the least to the most recently used
+}
+
+// NewRotationKeyStore creates a new RotationKeyStore storing the rotation keys in the directory dir, which is created
+// if it does not exist, and caching at most cacheSize keys in memory (0 disables the cache).
+func NewRotationKeyStore(dir string, cacheSize int) (*RotationKeyStore, error) {
+
+	if cacheSize < 0 {
+		return nil, fmt.Errorf("cannot NewRotationKeyStore: negative cache size")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("cannot NewRotationKeyStore: %w", err)
+	}
+
+	return &RotationKeyStore{dir: dir, cacheSize: cacheSize, cache: make(map[uint64]*SwitchingKey)}, nil
+}
+
+// Dir returns the directory of the store.
+func (s *RotationKeyStore) Dir() string {
+	return s.dir
+}
+
+func (s *RotationKeyStore) path(galEl uint64) string {
+	return filepath.Join(s.dir, rotationKeyFilePrefix+strconv.FormatUint(galEl, 10))
+}
+
+// Store writes the rotation key swk for the Galois element galEl on the disk, replacing the previous key for galEl.
+// The key is first written in a temporary file, so that an interrupted Store does not leave a corrupted key.
+func (s *RotationKeyStore) Store(galEl uint64, swk *SwitchingKey) (err error) {
+
+	var data []byte
+	if data, err = swk.MarshalBinary(); err != nil {
+		return err
+	}
+
+	var tmp *os.File
+	if tmp, err = ioutil.TempFile(s.dir, ".tmp-"); err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), s.path(galEl)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	s.mu.Lock()
+	s.evict(galEl)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Has returns true if the store contains a rotation key for the Galois element galEl. Unlike GetRotationKey, it does
+// not load the key.
+func (s *RotationKeyStore) Has(galEl uint64) bool {
+	_, err := os.Stat(s.path(galEl))
+	return err == nil
+}
+
+// GaloisElements returns the sorted Galois elements of the rotation keys in the store.
+func (s *RotationKeyStore) GaloisElements() (galEls []uint64, err error) {
+
+	var files []os.FileInfo
+	if files, err = ioutil.ReadDir(s.dir); err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasPrefix(f.Name(), rotationKeyFilePrefix) {
+			continue
+		}
+		galEl, err := strconv.ParseUint(strings.TrimPrefix(f.Name(), rotationKeyFilePrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		galEls = append(galEls, galEl)
+	}
+
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	return galEls, nil
+}
+
+// MissingGaloisElements returns the Galois elements of galEls for which the store does not contain a rotation key.
+func (s *RotationKeyStore) MissingGaloisElements(galEls []uint64) (missing []uint64) {
+	for _, galEl := range galEls {
+		if !s.Has(galEl) {
+			missing = append(missing, galEl)
+		}
+	}
+	return
+}
+
+// GetRotationKey returns the rotation key for the Galois element galEl, loading it from the disk if it is not in the
+// cache. The second output is false if the store does not contain the key or if the key cannot be read.
+func (s *RotationKeyStore) GetRotationKey(galEl uint64) (*SwitchingKey, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if swk, ok := s.cache[galEl]; ok {
+		s.touch(galEl)
+		return swk, true
+	}
+
+	data, err := ioutil.ReadFile(s.path(galEl))
+	if err != nil {
+		return nil, false
+	}
+
+	swk := new(SwitchingKey)
+	if err = swk.UnmarshalBinary(data); err != nil {
+		return nil, false
+	}
+
+	if s.cacheSize > 0 {
+		if len(s.lru) == s.cacheSize {
+			s.evict(s.lru[0])
+		}
+		s.cache[galEl] = swk
+		s.lru = append(s.lru, galEl)
+	}
+
+	return swk, true
+}
+
+// touch moves galEl, which must be in the cache, to the end of the list of the most recently used keys.
+func (s *RotationKeyStore) touch(galEl uint64) {
+	for i, el := range s.lru {
+		if el == galEl {
+			copy(s.lru[i:], s.lru[i+1:])
+			s.lru[len(s.lru)-1] = galEl
+			return
+		}
+	}
+}
+
+// evict removes the key of galEl from the cache, if present.
+func (s *RotationKeyStore) evict(galEl uint64) {
+	if _, ok := s.cache[galEl]; !ok {
+		return
+	}
+	delete(s.cache, galEl)
+	for i, el := range s.lru {
+		if el == galEl {
+			s.lru = append(s.lru[:i], s.lru[i+1:]...)
+			return
+		}
+	}
+}
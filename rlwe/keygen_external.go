@@ -0,0 +1,188 @@
+package rlwe
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// SecretTransform identifies a function of the secret key s used in the generation of a switching key:
+// the power s^Power, to which the automorphism X -> X^GaloisElement is applied.
+type SecretTransform struct {
+	Power         int
+	GaloisElement uint64
+}
+
+// SecretIdentity is the SecretTransform of the secret key itself.
+var SecretIdentity = SecretTransform{Power: 1, GaloisElement: 1}
+
+// SecretKeyOperator is the interface of the secret-dependent operations of the key generation. It enables the
+// secret key to be held outside of the process, e.g. in an HSM or an enclave, while an ExternalKeyGenerator
+// constructs the public and evaluation key material: an implementation only receives public masks and only
+// returns RLWE samples, which do not reveal the secret key.
+//
+// All the polynomials are in the ring QP of the parameters and in the NTT domain. The error of each sample is
+// sampled by the implementation, with the standard deviation of the parameters.
+type SecretKeyOperator interface {
+	// EncryptZero writes on b the encryption of zero with the mask a: b = -a*s + e.
+	EncryptZero(a, b *ring.Poly)
+
+	// EncryptGadget writes on b the encryption of the i-th component of the gadget decomposition of in(s),
+	// with the mask a and under the key out(s): b = -a*out(s) + e + P*in(s) mod the moduli Q[i*#P:(i+1)*#P]
+	// (and e elsewhere). b, a and in(s) are in the Montgomery domain, as the values of a SwitchingKey.
+	EncryptGadget(in, out SecretTransform, i int, a, b *ring.Poly)
+}
+
+// ExternalKeyGenerator generates the public and evaluation keys of a secret key held by a SecretKeyOperator.
+// The public masks of the keys are sampled from a user-provided PRNG.
+type ExternalKeyGenerator struct {
+	params         Parameters
+	op             SecretKeyOperator
+	uniformSampler *ring.UniformSampler
+}
+
+// NewExternalKeyGenerator creates a new ExternalKeyGenerator for the secret key held by op, sampling the public
+// masks of the keys from prng.
+func NewExternalKeyGenerator(params Parameters, op SecretKeyOperator, prng utils.PRNG) *ExternalKeyGenerator {
+	return &ExternalKeyGenerator{
+		params:         params,
+		op:             op,
+		uniformSampler: ring.NewUniformSampler(prng, params.RingQP()),
+	}
+}
+
+// GenPublicKey generates the public key of the secret key.
+func (keygen *ExternalKeyGenerator) GenPublicKey() (pk *PublicKey) {
+	pk = NewPublicKey(keygen.params)
+	keygen.uniformSampler.Read(pk.Value[1])
+	keygen.op.EncryptZero(pk.Value[1], pk.Value[0])
+	return
+}
+
+// GenSwitchingKey generates a switching key from in(s) to out(s).
+func (keygen *ExternalKeyGenerator) GenSwitchingKey(in, out SecretTransform) (swk *SwitchingKey) {
+
+	if keygen.params.PCount() == 0 {
+		panic("cannot GenSwitchingKey: modulus P is empty")
+	}
+
+	swk = NewSwitchingKey(keygen.params)
+	for i := range swk.Value {
+		// a is uniform and is therefore considered already in the NTT and Montgomery domain
+		keygen.uniformSampler.Read(swk.Value[i][1])
+		keygen.op.EncryptGadget(in, out, i, swk.Value[i][1], swk.Value[i][0])
+	}
+
+	return
+}
+
+// GenRelinearizationKey generates the relinearization key of the secret key for ciphertexts of degree up
+// to maxDegree+1.
+func (keygen *ExternalKeyGenerator) GenRelinearizationKey(maxDegree int) (rlk *RelinearizationKey) {
+	rlk = &RelinearizationKey{Keys: make([]*SwitchingKey, maxDegree)}
+	for i := range rlk.Keys {
+		rlk.Keys[i] = keygen.GenSwitchingKey(SecretTransform{Power: i + 2, GaloisElement: 1}, SecretIdentity)
+	}
+	return
+}
+
+// GenRotationKeys generates a RotationKeySet of the secret key for the given Galois elements.
+func (keygen *ExternalKeyGenerator) GenRotationKeys(galEls []uint64) (rks *RotationKeySet) {
+	rks = &RotationKeySet{Keys: make(map[uint64]*SwitchingKey, len(galEls))}
+	for _, galEl := range galEls {
+		rks.Keys[galEl] = keygen.GenSwitchingKey(SecretIdentity, SecretTransform{Power: 1, GaloisElement: keygen.params.InverseGaloisElement(galEl)})
+	}
+	return
+}
+
+// secretKeyOperator is the reference SecretKeyOperator, holding the secret key in memory.
+type secretKeyOperator struct {
+	params          Parameters
+	ringQP          *ring.Ring
+	pBigInt         *big.Int
+	sk              *SecretKey
+	gaussianSampler *ring.GaussianSampler
+	poolIn, poolOut *ring.Poly
+}
+
+// NewSecretKeyOperator creates the reference SecretKeyOperator, which holds sk in memory and samples the errors
+// from prng. It is meant for testing and as a model for the implementations of SecretKeyOperator.
+func NewSecretKeyOperator(params Parameters, sk *SecretKey, prng utils.PRNG) SecretKeyOperator {
+	ringQP := params.RingQP()
+	return &secretKeyOperator{
+		params:          params,
+		ringQP:          ringQP,
+		pBigInt:         params.PBigInt(),
+		sk:              sk,
+		gaussianSampler: ring.NewGaussianSampler(prng, ringQP, params.Sigma(), int(6*params.Sigma())),
+		poolIn:          ringQP.NewPoly(),
+		poolOut:         ringQP.NewPoly(),
+	}
+}
+
+func (op *secretKeyOperator) EncryptZero(a, b *ring.Poly) {
+	op.gaussianSampler.Read(b)
+	op.ringQP.NTT(b, b)
+	op.ringQP.MulCoeffsMontgomeryAndSub(op.sk.Value, a, b)
+}
+
+func (op *secretKeyOperator) EncryptGadget(in, out SecretTransform, i int, a, b *ring.Poly) {
+
+	ringQP := op.ringQP
+
+	op.transform(in, op.poolIn)
+	op.transform(out, op.poolOut)
+
+	// P * in(s)
+	ringQP.MulScalarBigint(op.poolIn, op.pBigInt, op.poolIn)
+
+	// e, in the NTT and Montgomery domain
+	op.gaussianSampler.Read(b)
+	ringQP.NTTLazy(b, b)
+	ringQP.MForm(b, b)
+
+	alpha := op.params.PCount()
+	for j := 0; j < alpha; j++ {
+
+		index := i*alpha + j
+
+		// It handles the case where #Pi does not divide #Qi
+		if index >= op.params.QCount() {
+			break
+		}
+
+		qi := ringQP.Modulus[index]
+		p0tmp := op.poolIn.Coeffs[index]
+		p1tmp := b.Coeffs[index]
+
+		for w := 0; w < ringQP.N; w++ {
+			p1tmp[w] = ring.CRed(p1tmp[w]+p0tmp[w], qi)
+		}
+	}
+
+	ringQP.MulCoeffsMontgomeryAndSub(a, op.poolOut, b)
+
+	op.poolIn.Zero()
+	op.poolOut.Zero()
+}
+
+// transform writes t(s) on pOut, in the NTT and Montgomery domain.
+func (op *secretKeyOperator) transform(t SecretTransform, pOut *ring.Poly) {
+
+	if t.Power < 1 {
+		panic("cannot EncryptGadget: the power of a SecretTransform must be positive")
+	}
+
+	ringQP := op.ringQP
+
+	ringQP.Copy(op.sk.Value, pOut)
+	for i := 1; i < t.Power; i++ {
+		ringQP.MulCoeffsMontgomery(pOut, op.sk.Value, pOut)
+	}
+
+	if t.GaloisElement != 1 {
+		index := ring.PermuteNTTIndex(t.GaloisElement, uint64(ringQP.N))
+		ring.PermuteNTTWithIndexLvl(op.params.QPCount()-1, pOut.CopyNew(), index, pOut)
+	}
+}
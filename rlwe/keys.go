@@ -2,6 +2,9 @@ package rlwe
 
 import (
 	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/ldsec/lattigo/v2/ring"
 )
@@ -75,13 +78,47 @@ func NewRotationKeySet(params Parameters, galoisElement []uint64) (rotKey *Rotat
 	return
 }
 
+// RotationKeyProvider is an interface for the objects providing the Evaluators with the rotation keys. It enables
+// applications to generate the keys on demand, to cache them on disk or to fetch them over the network instead of
+// storing all the rotation keys in memory. The RotationKeySet type is the default, in-memory, RotationKeyProvider.
+type RotationKeyProvider interface {
+	// GetRotationKey returns the rotation key for the given galois element. The second argument is true iff
+	// the key could be provided.
+	GetRotationKey(galoisEl uint64) (*SwitchingKey, bool)
+}
+
+// RotationKeyProviderFunc is an adapter to use an ordinary function as a RotationKeyProvider.
+type RotationKeyProviderFunc func(galoisEl uint64) (*SwitchingKey, bool)
+
+// GetRotationKey calls f(galoisEl).
+func (f RotationKeyProviderFunc) GetRotationKey(galoisEl uint64) (*SwitchingKey, bool) {
+	return f(galoisEl)
+}
+
 // GetRotationKey return the rotation key for the given galois element or nil if such key is not in the set. The
 // second argument is true  iff the first one is non-nil.
 func (rtks *RotationKeySet) GetRotationKey(galoisEl uint64) (*SwitchingKey, bool) {
+	if rtks == nil {
+		return nil, false
+	}
 	rotKey, inSet := rtks.Keys[galoisEl]
 	return rotKey, inSet
 }
 
+// HasRotationKeys returns true if rtkp provides the rotation keys for all the given Galois elements. Note that
+// providers generating or loading the keys on demand are queried for each key.
+func HasRotationKeys(rtkp RotationKeyProvider, galEls ...uint64) bool {
+	if rtkp == nil {
+		return false
+	}
+	for _, galEl := range galEls {
+		if _, ok := rtkp.GetRotationKey(galEl); !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // NewSwitchingKey returns a new public switching key with pre-allocated zero-value
 func NewSwitchingKey(params Parameters) *SwitchingKey {
 	ringDegree := params.N()
@@ -500,3 +537,80 @@ func (rtks *RotationKeySet) UnmarshalBinary(data []byte) (err error) {
 
 	return nil
 }
+
+// Describe returns a human-readable description of the relinearization key, listing its relinearizable degrees,
+// decomposition size and size in bytes.
+func (rlk *RelinearizationKey) Describe() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "RelinearizationKey: %d switching key(s), %d bytes\n", len(rlk.Keys), rlk.GetDataLen(true))
+	for i, swk := range rlk.Keys {
+		fmt.Fprintf(&sb, "  degree %d -> %d: %s\n", i+2, i+1, swk.describe())
+	}
+	return sb.String()
+}
+
+// Describe returns a human-readable description of the rotation key set, listing for each key its Galois element
+// and the corresponding slot rotation, as well as the sizes in bytes.
+func (rtks *RotationKeySet) Describe() string {
+
+	galEls := make([]uint64, 0, len(rtks.Keys))
+	for galEl := range rtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "RotationKeySet: %d key(s), %d bytes\n", len(rtks.Keys), rtks.GetDataLen(true))
+	for _, galEl := range galEls {
+		swk := rtks.Keys[galEl]
+		fmt.Fprintf(&sb, "  galEl=%-8d %-32s %s\n", galEl, describeGaloisElement(galEl, swk.Value[0][0].Degree()), swk.describe())
+	}
+	return sb.String()
+}
+
+// Describe returns a human-readable description of the evaluation key.
+func (evk EvaluationKey) Describe() string {
+	var sb strings.Builder
+	if evk.Rlk != nil {
+		sb.WriteString(evk.Rlk.Describe())
+	} else {
+		sb.WriteString("RelinearizationKey: none\n")
+	}
+	if evk.Rtks != nil {
+		sb.WriteString(evk.Rtks.Describe())
+	} else {
+		sb.WriteString("RotationKeySet: none\n")
+	}
+	return sb.String()
+}
+
+func (swk *SwitchingKey) describe() string {
+	if len(swk.Value) == 0 {
+		return "(empty)"
+	}
+	return fmt.Sprintf("(beta=%d, N=%d, #QP=%d, %d bytes)", len(swk.Value), swk.Value[0][0].Degree(), swk.Value[0][0].LenModuli(), swk.GetDataLen(true))
+}
+
+// describeGaloisElement returns the slot rotation induced by the automorphism X -> X^galEl,
+// i.e. the k such that galEl = (+/-) GaloisGen^k mod 2N.
+func describeGaloisElement(galEl uint64, N int) string {
+	twoN := uint64(N << 1)
+	mask := twoN - 1
+
+	if galEl == twoN-1 {
+		return "row rotation (conjugation)"
+	}
+
+	pow := uint64(1)
+	for k := 0; k < N>>1; k++ {
+		if pow == galEl {
+			return fmt.Sprintf("column rotation by %d (left)", k)
+		}
+		if (twoN-pow)&mask == galEl {
+			return fmt.Sprintf("column rotation by %d (left) + row", k)
+		}
+		pow = (pow * GaloisGen) & mask
+	}
+
+	return "unknown automorphism"
+}
@@ -0,0 +1,9 @@
+//go:build lattigo_lowmem || wasm
+// +build lattigo_lowmem wasm
+
+package rlwe
+
+// LowMemory is true when the package is built with the lattigo_lowmem build tag or for GOARCH=wasm. It is the default
+// value of Options.LowMemory, with which the evaluators of the schemes allocate their large memory pools on their first
+// use instead of in their constructor.
+const LowMemory = true
@@ -0,0 +1,19 @@
+package rlwe
+
+// Options are the options of the constructors of the Evaluators of the schemes (e.g. bfv.NewEvaluatorWithOptions and
+// ckks.NewEvaluatorWithOptions).
+type Options struct {
+	// LowMemory makes the Evaluator allocate its large memory pools (tensoring, key-switching, hoisting and
+	// scale-up) on their first use instead of in its constructor and ShallowCopy, e.g. for browsers (GOARCH=wasm)
+	// and mobile devices, where the Evaluators that use only a few operations should not pay for all of them.
+	// The Encryptors always allocate their memory pools on their first use.
+	//
+	// LowMemory does not change the representation of the polynomials, which keep their 64-bit limbs.
+	LowMemory bool
+}
+
+// DefaultOptions returns the Options of the constructors without options, whose LowMemory is set by the
+// lattigo_lowmem build tag (see LowMemory).
+func DefaultOptions() Options {
+	return Options{LowMemory: LowMemory}
+}
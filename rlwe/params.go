@@ -6,8 +6,10 @@ import (
 	"math"
 	"math/big"
 	"math/bits"
+	"strings"
 
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/security"
 	"github.com/ldsec/lattigo/v2/utils"
 )
 
@@ -239,6 +241,18 @@ func (p Parameters) RingQP() *ring.Ring {
 	return ringQP
 }
 
+// OperationCounts returns the number of NTTs, basis extensions and key-switching operations performed,
+// process-wide, on the rings R_q, R_p and R_qp of the parameters. The operations are only counted if the
+// ring package is built with the lattigo_profiling build tag (see ring.ProfilingEnabled). Parameters sharing
+// the same moduli Q share the same counts.
+func (p Parameters) OperationCounts() ring.OperationCounts {
+	fingerprints := []uint64{ring.Fingerprint(p.N(), p.qi)}
+	if len(p.pi) != 0 {
+		fingerprints = append(fingerprints, ring.Fingerprint(p.N(), p.pi), ring.Fingerprint(p.N(), append(p.qi[:len(p.qi):len(p.qi)], p.pi...)))
+	}
+	return ring.GetOperationCountsFor(fingerprints...)
+}
+
 // GaloisElementForColumnRotationBy returns the galois element for plaintext
 // column rotations by k position to the left. Providing a negative k is
 // equivalent to a right rotation.
@@ -274,6 +288,91 @@ func (p Parameters) InverseGaloisElement(galEl uint64) uint64 {
 	return ring.ModExp(galEl, twoN-1, uint64(twoN))
 }
 
+// EstimatedSecurity returns an estimate of the classical security level of the parameters in bits (128, 192 or 256),
+// based on the Homomorphic Encryption Standard tables for a uniform ternary secret. It returns 0 if the parameters
+// do not reach 128 bits of security or if logN is not covered by the tables.
+func (p Parameters) EstimatedSecurity() int {
+	logQP := p.LogQP()
+	for i := len(security.Levels) - 1; i >= 0; i-- {
+		if bound, err := security.MaxLogQP(p.logN, security.Ternary, security.Classical, security.Levels[i]); err == nil && logQP <= bound {
+			return security.Levels[i]
+		}
+	}
+	return 0
+}
+
+// SecurityEstimate returns the estimated classical and quantum security level of the parameters, in bits, for
+// a uniform ternary secret (see security.EstimateSecurity). Returns an error if logN is not covered by the tables.
+func (p Parameters) SecurityEstimate() (security.Estimate, error) {
+	var logQP float64
+	for _, qi := range append(p.Q(), p.pi...) {
+		logQP += math.Log2(float64(qi))
+	}
+	return security.EstimateSecurity(p.logN, logQP, security.Ternary, p.sigma)
+}
+
+// Validate checks that the parameters are valid and, if minSecurity is positive, that their estimated
+// classical security level (see SecurityEstimate) is at least minSecurity bits.
+func (p Parameters) Validate(minSecurity float64) error {
+
+	if err := checkSizeParams(p.logN, len(p.qi), len(p.pi)); err != nil {
+		return err
+	}
+
+	if err := CheckModuli(p.qi, p.pi, p.logN); err != nil {
+		return err
+	}
+
+	if p.sigma <= 0 {
+		return fmt.Errorf("sigma=%v is not positive", p.sigma)
+	}
+
+	if minSecurity > 0 {
+
+		estimate, err := p.SecurityEstimate()
+		if err != nil {
+			return fmt.Errorf("cannot estimate the security: %w", err)
+		}
+
+		if estimate.Classical < minSecurity {
+			return fmt.Errorf("estimated security level %.1f bits is smaller than %v bits", estimate.Classical, minSecurity)
+		}
+	}
+
+	return nil
+}
+
+// String returns a single-line summary of the parameters.
+func (p Parameters) String() string {
+	return fmt.Sprintf("logN=%d, logQP=%d, #Q=%d, #P=%d, sigma=%v", p.logN, p.LogQP(), len(p.qi), len(p.pi), p.sigma)
+}
+
+// Describe returns a multi-line human-readable description of the parameters, listing the bit-size of each prime
+// and the estimated security level.
+func (p Parameters) Describe() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-10s: %d (N=%d)\n", "logN", p.logN, p.N())
+	fmt.Fprintf(&sb, "%-10s: %d (logQ=%d, logP=%d)\n", "logQP", p.LogQP(), p.QBigInt().BitLen(), p.PBigInt().BitLen())
+	fmt.Fprintf(&sb, "%-10s: %v (%d moduli)\n", "logQi", logModuli(p.qi), len(p.qi))
+	fmt.Fprintf(&sb, "%-10s: %v (%d moduli)\n", "logPi", logModuli(p.pi), len(p.pi))
+	fmt.Fprintf(&sb, "%-10s: %d\n", "beta", p.Beta())
+	fmt.Fprintf(&sb, "%-10s: %v\n", "sigma", p.sigma)
+	if lambda := p.EstimatedSecurity(); lambda != 0 {
+		fmt.Fprintf(&sb, "%-10s: ~%d bits (classical, HE standard)\n", "security", lambda)
+	} else {
+		fmt.Fprintf(&sb, "%-10s: < 128 bits or unknown (classical, HE standard)\n", "security")
+	}
+	return sb.String()
+}
+
+func logModuli(moduli []uint64) (logModuli []float64) {
+	logModuli = make([]float64, len(moduli))
+	for i, qi := range moduli {
+		logModuli[i] = math.Round(math.Log2(float64(qi))*100) / 100
+	}
+	return
+}
+
 // Equals checks two Parameter structs for equality.
 func (p Parameters) Equals(other Parameters) bool {
 	res := p.logN == other.logN
@@ -350,11 +449,16 @@ func (p Parameters) MarshalJSON() ([]byte, error) {
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
+// The fields of the scheme-specific parameters (e.g., T or Scale) are ignored, so that the generic parameters can be read
+// from the representation of any scheme's parameters. It returns an error if Sigma is not positive.
 func (p *Parameters) UnmarshalJSON(data []byte) (err error) {
 	var params ParametersLiteral
 	if err = json.Unmarshal(data, &params); err != nil {
 		return err
 	}
+	if params.Sigma <= 0 {
+		return fmt.Errorf("invalid parameters: Sigma=%v must be positive", params.Sigma)
+	}
 	*p, err = NewParametersFromLiteral(params)
 	return
 }
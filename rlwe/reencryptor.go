@@ -0,0 +1,274 @@
+package rlwe
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ReEncryptor re-encrypts ciphertexts from a secret key skIn to a secret key skOut with a switching key from skIn
+// to skOut (see GenReEncryptionKey), without decrypting them. It enables, for example, long-lived services to rotate
+// their secret key, or to delegate the decryption of stored ciphertexts to another party (proxy re-encryption).
+// The ReEncryptor works on the generic Element type and supports ciphertexts of degree 1, at any level, in and out of
+// the NTT domain. The switching key is kept in the NTT domain, and only the decomposition of the ciphertexts is
+// transformed, so that the cost of the key-switching is amortized over the re-encrypted ciphertexts.
+// The switching key is decomposed with the RNSGadget, unless the ReEncryptor is created with
+// NewReEncryptorWithGadget, and the key-switch is computed by a KeySwitcher.
+// A ReEncryptor is not safe for concurrent use: use ShallowCopy to obtain a ReEncryptor per goroutine.
+type ReEncryptor struct {
+	params Parameters
+	swk    *SwitchingKey
+	ks     *KeySwitcher
+
+	ringQ *ring.Ring
+
+	poolQ [4]*ring.Poly
+}
+
+// NewReEncryptor creates a new ReEncryptor re-encrypting the ciphertexts with the switching key swk of the
+// RNSGadget.
+func NewReEncryptor(params Parameters, swk *SwitchingKey) *ReEncryptor {
+
+	if params.PCount() == 0 {
+		panic("cannot NewReEncryptor: modulus P is empty")
+	}
+
+	return NewReEncryptorWithGadget(params, NewRNSGadget(params), swk)
+}
+
+// NewReEncryptorWithGadget creates a new ReEncryptor re-encrypting the ciphertexts with the switching key swk of
+// the gadget decomposition gadget (see GenSwitchingKeyWithGadget).
+func NewReEncryptorWithGadget(params Parameters, gadget Gadget, swk *SwitchingKey) *ReEncryptor {
+
+	if digits := gadget.Digits(params.QCount() - 1); len(swk.Value) < digits {
+		panic(fmt.Sprintf("cannot NewReEncryptorWithGadget: the switching key has %d components but the gadget has %d digits", len(swk.Value), digits))
+	}
+
+	re := &ReEncryptor{
+		params: params,
+		swk:    swk,
+		ks:     NewKeySwitcher(params, gadget),
+		ringQ:  params.RingQ(),
+	}
+
+	re.allocatePools()
+
+	return re
+}
+
+func (re *ReEncryptor) allocatePools() {
+	for i := range re.poolQ {
+		re.poolQ[i] = re.ringQ.NewPoly()
+	}
+}
+
+// ShallowCopy creates a shallow copy of this ReEncryptor in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// ReEncryptor can be used concurrently.
+func (re *ReEncryptor) ShallowCopy() *ReEncryptor {
+
+	reCopy := &ReEncryptor{
+		params: re.params,
+		swk:    re.swk,
+		ks:     re.ks.ShallowCopy(),
+		ringQ:  re.ringQ,
+	}
+
+	reCopy.allocatePools()
+
+	return reCopy
+}
+
+// ReEncryptNew re-encrypts ctIn and returns the result on a newly created element.
+func (re *ReEncryptor) ReEncryptNew(ctIn *Element) (ctOut *Element) {
+	ctOut = NewElementAtLevel(re.params, 1, ctIn.Level())
+	re.ReEncrypt(ctIn, ctOut)
+	return
+}
+
+// ReEncryptCiphertextNew re-encrypts the Ciphertext ctIn and returns the result, with the MetaData of ctIn, in a
+// newly created Ciphertext.
+func (re *ReEncryptor) ReEncryptCiphertextNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	return &Ciphertext{Element: re.ReEncryptNew(ctIn.Element), MetaData: ctIn.MetaData}
+}
+
+// ReEncrypt re-encrypts ctIn and returns the result on ctOut, in the same domain (NTT or not) as ctIn.
+// ctIn must be of degree 1 and ctOut can be ctIn.
+func (re *ReEncryptor) ReEncrypt(ctIn, ctOut *Element) {
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot ReEncrypt: input and output must be of degree 1")
+	}
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	ringQ := re.ringQ
+
+	c1NTT, c1 := re.poolQ[2], re.poolQ[3]
+
+	if ctIn.IsNTT {
+		ringQ.CopyLvl(level, ctIn.Value[1], c1NTT)
+		ringQ.InvNTTLvl(level, c1NTT, c1)
+	} else {
+		ringQ.CopyLvl(level, ctIn.Value[1], c1)
+		ringQ.NTTLvl(level, c1, c1NTT)
+	}
+
+	re.ks.SwitchKeys(level, c1NTT, c1, re.swk, re.poolQ[0], re.poolQ[1])
+
+	if !ctIn.IsNTT {
+		ringQ.InvNTTLvl(level, re.poolQ[0], re.poolQ[0])
+		ringQ.InvNTTLvl(level, re.poolQ[1], re.poolQ[1])
+	}
+
+	ringQ.AddLvl(level, ctIn.Value[0], re.poolQ[0], ctOut.Value[0])
+	ringQ.CopyLvl(level, re.poolQ[1], ctOut.Value[1])
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:level+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:level+1]
+	ctOut.IsNTT = ctIn.IsNTT
+}
+
+// ReEncryptMany re-encrypts each ctsIn[i] and returns the result on ctsOut[i], using up to runtime.NumCPU()
+// concurrent shallow copies of the ReEncryptor. ctsOut can be ctsIn.
+func (re *ReEncryptor) ReEncryptMany(ctsIn, ctsOut []*Element) {
+
+	if len(ctsIn) != len(ctsOut) {
+		panic("cannot ReEncryptMany: ctsIn and ctsOut must have the same length")
+	}
+
+	workers := utils.MinInt(runtime.NumCPU(), len(ctsIn))
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+
+		reWorker := re
+		if w > 0 {
+			reWorker = re.ShallowCopy()
+		}
+
+		go func(w int, reWorker *ReEncryptor) {
+			defer wg.Done()
+			for i := w; i < len(ctsIn); i += workers {
+				reWorker.ReEncrypt(ctsIn[i], ctsOut[i])
+			}
+		}(w, reWorker)
+	}
+	wg.Wait()
+}
+
+// gadgetProductNoModDown computes the products of the decomposition of cx by gadget with the gadget ciphertext swk in
+// the NTT domain, and writes (or adds, if accumulate is true) them on outQ and outP (in basis QP, or in basis Q if the
+// gadget is not scaled by P, in which case outP and c2QiP are not used). cxNTT and cx are cx in and out of the NTT
+// domain, and c2QiQ and c2QiP are buffers.
+func gadgetProductNoModDown(params Parameters, ringQ, ringP *ring.Ring, gadget Gadget, level int, cxNTT, cx *ring.Poly, swk *SwitchingKey, c2QiQ, c2QiP *ring.Poly, outQ, outP [2]*ring.Poly, accumulate bool) {
+	gadgetProductDigitsNoModDown(params, ringQ, ringP, gadget, level, func(i int) (*ring.Poly, *ring.Poly) {
+		gadget.Decompose(level, i, cxNTT, cx, c2QiQ, c2QiP)
+		return c2QiQ, c2QiP
+	}, swk, outQ, outP, accumulate)
+}
+
+// gadgetProductDigitsNoModDown is gadgetProductNoModDown for the digits returned by digit, e.g. the digits of a hoisted
+// decomposition.
+func gadgetProductDigitsNoModDown(params Parameters, ringQ, ringP *ring.Ring, gadget Gadget, level int, digit func(i int) (c2QiQ, c2QiP *ring.Poly), swk *SwitchingKey, outQ, outP [2]*ring.Poly, accumulate bool) {
+
+	withP := gadget.WithP()
+
+	pool2Q, pool3Q := outQ[0], outQ[1]
+	pool2P, pool3P := outP[0], outP[1]
+
+	swk0Q, swk1Q := new(ring.Poly), new(ring.Poly)
+	swk0P, swk1P := new(ring.Poly), new(ring.Poly)
+
+	QiOverF := params.QiOverflowMargin(level) >> 1
+	PiOverF := 1
+	if withP {
+		PiOverF = params.PiOverflowMargin() >> 1
+	}
+
+	var reduce int
+	for i := 0; i < gadget.Digits(level); i++ {
+
+		c2QiQ, c2QiP := digit(i)
+
+		swk0Q.Coeffs = swk.Value[i][0].Coeffs[:level+1]
+		swk1Q.Coeffs = swk.Value[i][1].Coeffs[:level+1]
+
+		if i == 0 && !accumulate {
+			ringQ.MulCoeffsMontgomeryConstantLvl(level, swk0Q, c2QiQ, pool2Q)
+			ringQ.MulCoeffsMontgomeryConstantLvl(level, swk1Q, c2QiQ, pool3Q)
+		} else {
+			ringQ.MulCoeffsMontgomeryConstantAndAddNoModLvl(level, swk0Q, c2QiQ, pool2Q)
+			ringQ.MulCoeffsMontgomeryConstantAndAddNoModLvl(level, swk1Q, c2QiQ, pool3Q)
+		}
+
+		if withP {
+
+			swk0P.Coeffs = swk.Value[i][0].Coeffs[len(ringQ.Modulus):]
+			swk1P.Coeffs = swk.Value[i][1].Coeffs[len(ringQ.Modulus):]
+
+			if i == 0 && !accumulate {
+				ringP.MulCoeffsMontgomeryConstant(swk0P, c2QiP, pool2P)
+				ringP.MulCoeffsMontgomeryConstant(swk1P, c2QiP, pool3P)
+			} else {
+				ringP.MulCoeffsMontgomeryConstantAndAddNoMod(swk0P, c2QiP, pool2P)
+				ringP.MulCoeffsMontgomeryConstantAndAddNoMod(swk1P, c2QiP, pool3P)
+			}
+		}
+
+		if reduce%QiOverF == QiOverF-1 {
+			ringQ.ReduceLvl(level, pool2Q, pool2Q)
+			ringQ.ReduceLvl(level, pool3Q, pool3Q)
+		}
+
+		if withP && reduce%PiOverF == PiOverF-1 {
+			ringP.Reduce(pool2P, pool2P)
+			ringP.Reduce(pool3P, pool3P)
+		}
+
+		reduce++
+	}
+
+	if reduce%QiOverF != 0 {
+		ringQ.ReduceLvl(level, pool2Q, pool2Q)
+		ringQ.ReduceLvl(level, pool3Q, pool3Q)
+	}
+
+	if withP && reduce%PiOverF != 0 {
+		ringP.Reduce(pool2P, pool2P)
+		ringP.Reduce(pool3P, pool3P)
+	}
+}
+
+// GenReEncryptionKey generates a switching key from skIn to skOut, with which a ReEncryptor re-encrypts the
+// ciphertexts encrypted under skIn into ciphertexts encrypted under skOut.
+func GenReEncryptionKey(params Parameters, skIn, skOut *SecretKey) (swk *SwitchingKey) {
+
+	if params.PCount() == 0 {
+		panic("cannot GenReEncryptionKey: modulus P is empty")
+	}
+
+	return GenSwitchingKeyWithGadget(params, NewRNSGadget(params), skIn, skOut)
+}
+
+// GenKeyRotation generates a fresh ternary secret key to replace sk, along with the switching key from sk to
+// the new secret key, with which a ReEncryptor rotates the ciphertexts encrypted under sk.
+func GenKeyRotation(params Parameters, sk *SecretKey) (skNew *SecretKey, swk *SwitchingKey) {
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	ringQP := params.RingQP()
+
+	skNew = new(SecretKey)
+	skNew.Value = ring.NewTernarySampler(prng, ringQP, 1.0/3, true).ReadNew()
+	ringQP.NTT(skNew.Value, skNew.Value)
+
+	return skNew, GenReEncryptionKey(params, sk, skNew)
+}
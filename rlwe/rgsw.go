@@ -0,0 +1,331 @@
+package rlwe
+
+import (
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RGSWCiphertext is an RGSW ciphertext of a plaintext polynomial m. It is the pair of gadget ciphertexts of m and of
+// m*s, in the RNS decomposition and in the format of a SwitchingKey: the i-th component of Value[0] is an RLWE
+// encryption of P*m, and the i-th component of Value[1] an RLWE encryption of P*m*s, modulo the moduli
+// Q[i*#P:(i+1)*#P] (and of zero modulo the other moduli).
+type RGSWCiphertext struct {
+	Value [2]*SwitchingKey
+}
+
+// NewRGSWCiphertext returns a new RGSWCiphertext with pre-allocated zero-value.
+func NewRGSWCiphertext(params Parameters) *RGSWCiphertext {
+	return &RGSWCiphertext{Value: [2]*SwitchingKey{NewSwitchingKey(params), NewSwitchingKey(params)}}
+}
+
+// Equals checks two RGSWCiphertexts for equality.
+func (ct *RGSWCiphertext) Equals(other *RGSWCiphertext) bool {
+	if ct == other {
+		return true
+	}
+	if (ct == nil) != (other == nil) {
+		return false
+	}
+	return ct.Value[0].Equals(other.Value[0]) && ct.Value[1].Equals(other.Value[1])
+}
+
+// CopyNew creates a deep copy of the receiver RGSWCiphertext and returns it.
+func (ct *RGSWCiphertext) CopyNew() *RGSWCiphertext {
+	return &RGSWCiphertext{Value: [2]*SwitchingKey{ct.Value[0].CopyNew(), ct.Value[1].CopyNew()}}
+}
+
+// GetDataLen returns the length in bytes of the target RGSWCiphertext.
+func (ct *RGSWCiphertext) GetDataLen(WithMetadata bool) (dataLen int) {
+	return ct.Value[0].GetDataLen(WithMetadata) + ct.Value[1].GetDataLen(WithMetadata)
+}
+
+// MarshalBinary encodes an RGSWCiphertext in a byte slice.
+func (ct *RGSWCiphertext) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, ct.GetDataLen(true))
+
+	var pointer int
+	if pointer, err = ct.Value[0].encode(0, data); err != nil {
+		return nil, err
+	}
+
+	if _, err = ct.Value[1].encode(pointer, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled RGSWCiphertext in the target RGSWCiphertext.
+func (ct *RGSWCiphertext) UnmarshalBinary(data []byte) (err error) {
+
+	ct.Value[0], ct.Value[1] = new(SwitchingKey), new(SwitchingKey)
+
+	var pointer int
+	if pointer, err = ct.Value[0].decode(data); err != nil {
+		return err
+	}
+
+	if _, err = ct.Value[1].decode(data[pointer:]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RGSWEncryptor encrypts plaintext polynomials in RGSWCiphertexts under a secret key.
+type RGSWEncryptor struct {
+	params          Parameters
+	ringQ           *ring.Ring
+	ringQP          *ring.Ring
+	sk              *SecretKey
+	gaussianSampler *ring.GaussianSampler
+	uniformSampler  *ring.UniformSampler
+	poolQ           *ring.Poly
+}
+
+// NewRGSWEncryptor creates a new RGSWEncryptor encrypting under the secret key sk.
+func NewRGSWEncryptor(params Parameters, sk *SecretKey) *RGSWEncryptor {
+
+	if params.PCount() == 0 {
+		panic("cannot NewRGSWEncryptor: modulus P is empty")
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	ringQP := params.RingQP()
+
+	return &RGSWEncryptor{
+		params:          params,
+		ringQ:           params.RingQ(),
+		ringQP:          ringQP,
+		sk:              sk,
+		gaussianSampler: ring.NewGaussianSampler(prng, ringQP, params.Sigma(), int(6*params.Sigma())),
+		uniformSampler:  ring.NewUniformSampler(prng, ringQP),
+		poolQ:           params.RingQ().NewPoly(),
+	}
+}
+
+// EncryptNew encrypts pt and returns the result on a newly created RGSWCiphertext.
+func (enc *RGSWEncryptor) EncryptNew(pt *ring.Poly) (ct *RGSWCiphertext) {
+	ct = NewRGSWCiphertext(enc.params)
+	enc.Encrypt(pt, ct)
+	return
+}
+
+// Encrypt encrypts pt and writes the result on ct. pt is a polynomial of R_Q in the coefficient domain, with
+// typically small coefficients, e.g. a bit or a monomial.
+func (enc *RGSWEncryptor) Encrypt(pt *ring.Poly, ct *RGSWCiphertext) {
+
+	if pt.Level() != enc.params.QCount()-1 {
+		panic("cannot Encrypt: pt must be at the maximum level of R_Q")
+	}
+
+	ringQ, ringQP := enc.ringQ, enc.ringQP
+
+	// P * m, in the NTT and Montgomery domain
+	pm := enc.poolQ
+	ringQ.NTT(pt, pm)
+	ringQ.MForm(pm, pm)
+	ringQ.MulScalarBigint(pm, enc.params.PBigInt(), pm)
+
+	for j := range ct.Value {
+		for i := range ct.Value[j].Value {
+
+			b, a := ct.Value[j].Value[i][0], ct.Value[j].Value[i][1]
+
+			// e, in the NTT and Montgomery domain
+			enc.gaussianSampler.Read(b)
+			ringQP.NTTLazy(b, b)
+			ringQP.MForm(b, b)
+
+			// a, which is uniform and is therefore considered already in the NTT and Montgomery domain
+			enc.uniformSampler.Read(a)
+
+			// e - a * s
+			ringQP.MulCoeffsMontgomeryAndSub(a, enc.sk.Value, b)
+
+			// P * m is added on b for the encryptions of P*m, and on a for the encryptions of P*m*s
+			target := b
+			if j == 1 {
+				target = a
+			}
+
+			alpha := enc.params.PCount()
+			for k := 0; k < alpha; k++ {
+
+				index := i*alpha + k
+
+				// It handles the case where #Pi does not divide #Qi
+				if index >= enc.params.QCount() {
+					break
+				}
+
+				qi := ringQP.Modulus[index]
+				p0tmp := pm.Coeffs[index]
+				p1tmp := target.Coeffs[index]
+
+				for w := 0; w < ringQP.N; w++ {
+					p1tmp[w] = ring.CRed(p1tmp[w]+p0tmp[w], qi)
+				}
+			}
+		}
+	}
+}
+
+// RGSWEvaluator evaluates the external products RGSW x RLWE, and the CMux gates based on them. It works on the
+// generic Element type and supports ciphertexts of degree 1, at any level, in and out of the NTT domain.
+// An RGSWEvaluator is not safe for concurrent use: use ShallowCopy to obtain an RGSWEvaluator per goroutine.
+type RGSWEvaluator struct {
+	params Parameters
+
+	ringQ         *ring.Ring
+	ringP         *ring.Ring
+	baseconverter *ring.FastBasisExtender
+	gadget        *RNSGadget
+
+	poolQ [7]*ring.Poly
+	poolP [3]*ring.Poly
+}
+
+// NewRGSWEvaluator creates a new RGSWEvaluator.
+func NewRGSWEvaluator(params Parameters) *RGSWEvaluator {
+
+	if params.PCount() == 0 {
+		panic("cannot NewRGSWEvaluator: modulus P is empty")
+	}
+
+	eval := &RGSWEvaluator{
+		params: params,
+		ringQ:  params.RingQ(),
+		ringP:  params.RingP(),
+	}
+
+	eval.baseconverter = ring.NewFastBasisExtender(eval.ringQ, eval.ringP)
+	eval.gadget = NewRNSGadget(params)
+	eval.allocatePools()
+
+	return eval
+}
+
+func (eval *RGSWEvaluator) allocatePools() {
+
+	for i := range eval.poolQ {
+		eval.poolQ[i] = eval.ringQ.NewPoly()
+	}
+
+	for i := range eval.poolP {
+		eval.poolP[i] = eval.ringP.NewPoly()
+	}
+}
+
+// ShallowCopy creates a shallow copy of this RGSWEvaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RGSWEvaluator can be used concurrently.
+func (eval *RGSWEvaluator) ShallowCopy() *RGSWEvaluator {
+
+	evalCopy := &RGSWEvaluator{
+		params:        eval.params,
+		ringQ:         eval.ringQ,
+		ringP:         eval.ringP,
+		baseconverter: eval.baseconverter.ShallowCopy(),
+		gadget:        eval.gadget,
+	}
+
+	evalCopy.allocatePools()
+
+	return evalCopy
+}
+
+// ExternalProductNew computes the external product of ctIn with rgsw and returns the result on a newly created
+// element.
+func (eval *RGSWEvaluator) ExternalProductNew(ctIn *Element, rgsw *RGSWCiphertext) (ctOut *Element) {
+	ctOut = NewElementAtLevel(eval.params, 1, ctIn.Level())
+	eval.ExternalProduct(ctIn, rgsw, ctOut)
+	return
+}
+
+// ExternalProduct computes the external product of ctIn, an RLWE encryption of mu, with rgsw, an RGSW encryption
+// of m under the same secret key, and writes on ctOut an RLWE encryption of m*mu, in the same domain (NTT or not)
+// as ctIn. The product adds to the error of ctIn an error proportional to m, so that m is typically small, e.g.
+// a bit or a monomial. ctIn must be of degree 1 and ctOut can be ctIn.
+func (eval *RGSWEvaluator) ExternalProduct(ctIn *Element, rgsw *RGSWCiphertext, ctOut *Element) {
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot ExternalProduct: input and output must be of degree 1")
+	}
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	ringQ := eval.ringQ
+
+	accQ := [2]*ring.Poly{eval.poolQ[0], eval.poolQ[1]}
+	accP := [2]*ring.Poly{eval.poolP[0], eval.poolP[1]}
+
+	// sum_i d_i(c0) * RLWE(P*m*g_i) + d_i(c1) * RLWE(P*m*s*g_i) = RLWE(P*m*(c0 + c1*s))
+	for j := range rgsw.Value {
+
+		cNTT, c := eval.poolQ[2], eval.poolQ[3]
+
+		if ctIn.IsNTT {
+			ringQ.CopyLvl(level, ctIn.Value[j], cNTT)
+			ringQ.InvNTTLvl(level, cNTT, c)
+		} else {
+			ringQ.CopyLvl(level, ctIn.Value[j], c)
+			ringQ.NTTLvl(level, c, cNTT)
+		}
+
+		gadgetProductNoModDown(eval.params, ringQ, eval.ringP, eval.gadget, level, cNTT, c, rgsw.Value[j],
+			eval.poolQ[4], eval.poolP[2], accQ, accP, j == 1)
+	}
+
+	for j := range accQ {
+
+		eval.baseconverter.ModDownSplitNTTPQ(level, accQ[j], accP[j], accQ[j])
+
+		if !ctIn.IsNTT {
+			ringQ.InvNTTLvl(level, accQ[j], accQ[j])
+		}
+
+		ringQ.CopyLvl(level, accQ[j], ctOut.Value[j])
+		ctOut.Value[j].Coeffs = ctOut.Value[j].Coeffs[:level+1]
+	}
+
+	ctOut.IsNTT = ctIn.IsNTT
+}
+
+// CMux evaluates the controlled multiplexer ct0 + rgsw x (ct1 - ct0) and writes the result on ctOut: for rgsw an
+// RGSW encryption of a bit b, ctOut is an RLWE encryption of the plaintext of ct1 if b = 1 and of the plaintext of
+// ct0 if b = 0. ct0 and ct1 must be of degree 1 and in the same domain (NTT or not), and ctOut can be ct0 or ct1.
+func (eval *RGSWEvaluator) CMux(rgsw *RGSWCiphertext, ct0, ct1, ctOut *Element) {
+
+	if ct0.Degree() != 1 || ct1.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot CMux: inputs and output must be of degree 1")
+	}
+
+	if ct0.IsNTT != ct1.IsNTT {
+		panic("cannot CMux: inputs must be in the same domain")
+	}
+
+	level := utils.MinInt(utils.MinInt(ct0.Level(), ct1.Level()), ctOut.Level())
+
+	ringQ := eval.ringQ
+
+	diff := &Element{Value: []*ring.Poly{new(ring.Poly), new(ring.Poly)}, IsNTT: ct0.IsNTT}
+	for j := range diff.Value {
+		diff.Value[j].Coeffs = eval.poolQ[5+j].Coeffs[:level+1]
+		ringQ.SubLvl(level, ct1.Value[j], ct0.Value[j], diff.Value[j])
+	}
+
+	eval.ExternalProduct(diff, rgsw, diff)
+
+	for j := range diff.Value {
+		ringQ.AddLvl(level, ct0.Value[j], diff.Value[j], ctOut.Value[j])
+		ctOut.Value[j].Coeffs = ctOut.Value[j].Coeffs[:level+1]
+	}
+
+	ctOut.IsNTT = ct0.IsNTT
+}
@@ -0,0 +1,239 @@
+package rlwe
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RingSwitchingKeys are the key bridges between the secret key of a large ring of degree N and the secret key of
+// a small ring of degree n < N. Both are switching keys of the large parameters.
+type RingSwitchingKeys struct {
+	// LargeToSmall switches from the large secret key to the small secret key embedded in the large ring.
+	LargeToSmall *SwitchingKey
+	// SmallToLarge switches from the small secret key embedded in the large ring to the large secret key.
+	SmallToLarge *SwitchingKey
+}
+
+// CheckRingSwitching returns an error if the ciphertexts cannot be switched between the rings of the large and
+// small parameters: the ring degree of small must be smaller than the one of large, the moduli Q of small must be
+// the first moduli Q of large, and the modulus P of large must not be empty.
+func CheckRingSwitching(large, small Parameters) error {
+
+	if small.LogN() >= large.LogN() {
+		return fmt.Errorf("small LogN=%d is not smaller than large LogN=%d", small.LogN(), large.LogN())
+	}
+
+	if small.QCount() > large.QCount() {
+		return fmt.Errorf("small has more moduli Q than large")
+	}
+
+	for i, qi := range small.Q() {
+		if large.Q()[i] != qi {
+			return fmt.Errorf("small Q[%d] does not match large Q[%d]", i, i)
+		}
+	}
+
+	if large.PCount() == 0 {
+		return fmt.Errorf("large modulus P is empty")
+	}
+
+	return nil
+}
+
+// GenRingSwitchingKeys generates the key bridges between the secret key skLarge of the large parameters and the
+// secret key skSmall of the small parameters.
+func GenRingSwitchingKeys(large, small Parameters, skLarge, skSmall *SecretKey) *RingSwitchingKeys {
+
+	skEmbedded := EmbedSecretKey(large, small, skSmall)
+
+	return &RingSwitchingKeys{
+		LargeToSmall: GenReEncryptionKey(large, skLarge, skEmbedded),
+		SmallToLarge: GenReEncryptionKey(large, skEmbedded, skLarge),
+	}
+}
+
+// EmbedSecretKey returns skSmall(X^(N/n)), the secret key skSmall of the small parameters embedded in the ring of
+// the large parameters.
+func EmbedSecretKey(large, small Parameters, skSmall *SecretKey) (skEmbedded *SecretKey) {
+
+	ringQPSmall := small.RingQP()
+	ringQPLarge := large.RingQP()
+
+	// Recovers the small (centered) coefficients of the secret from its first modulus
+	sk := ringQPSmall.NewPoly()
+	ringQPSmall.InvNTT(skSmall.Value, sk)
+	ringQPSmall.InvMForm(sk, sk)
+
+	q0 := ringQPSmall.Modulus[0]
+	gap := large.N() / small.N()
+
+	coeffs := make([]int64, large.N())
+	for j, c := range sk.Coeffs[0] {
+		if c >= q0>>1 {
+			coeffs[j*gap] = -int64(q0 - c)
+		} else {
+			coeffs[j*gap] = int64(c)
+		}
+	}
+
+	skEmbedded = NewSecretKey(large)
+	ringQPLarge.SetCoefficientsInt64(coeffs, skEmbedded.Value)
+	ringQPLarge.MForm(skEmbedded.Value, skEmbedded.Value)
+	ringQPLarge.NTT(skEmbedded.Value, skEmbedded.Value)
+
+	return
+}
+
+// RingSwitcher switches ciphertexts of degree 1 between the ring of degree N of the large parameters and the ring
+// of degree n of the small parameters, with their RingSwitchingKeys.
+//
+// Switching down maps the plaintext m(X) of the large ring to the plaintext of the small ring made of its
+// coefficients of index multiple of N/n, so that the plaintexts m(Y^(N/n)) of the subring are preserved, and
+// switching up maps the plaintext m(Y) of the small ring to m(X^(N/n)). The ciphertexts can be in or out of the
+// NTT domain, and the output is in the same domain as the input. A RingSwitcher is not safe for concurrent use.
+type RingSwitcher struct {
+	large Parameters
+	small Parameters
+
+	reLargeToSmall *ReEncryptor
+	reSmallToLarge *ReEncryptor
+
+	ringQLarge *ring.Ring
+	ringQSmall *ring.Ring
+	poolQLarge *ring.Poly
+	poolQSmall *ring.Poly
+	poolLarge  *Element
+}
+
+// NewRingSwitcher creates a new RingSwitcher between the large and small parameters with the key bridges keys.
+// It panics if the ciphertexts cannot be switched between the two rings (see CheckRingSwitching).
+func NewRingSwitcher(large, small Parameters, keys *RingSwitchingKeys) *RingSwitcher {
+
+	if err := CheckRingSwitching(large, small); err != nil {
+		panic(fmt.Sprintf("cannot NewRingSwitcher: %s", err))
+	}
+
+	return &RingSwitcher{
+		large:          large,
+		small:          small,
+		reLargeToSmall: NewReEncryptor(large, keys.LargeToSmall),
+		reSmallToLarge: NewReEncryptor(large, keys.SmallToLarge),
+		ringQLarge:     large.RingQ(),
+		ringQSmall:     small.RingQ(),
+		poolQLarge:     large.RingQ().NewPoly(),
+		poolQSmall:     small.RingQ().NewPoly(),
+		poolLarge:      NewElementAtLevel(large, 1, small.QCount()-1),
+	}
+}
+
+// MaxLevel returns the maximum level of the ciphertexts that can be switched between the two rings.
+func (rs *RingSwitcher) MaxLevel() int {
+	return rs.small.QCount() - 1
+}
+
+// SwitchDown switches ctLarge, a ciphertext of the large parameters, to the small ring and returns the result in
+// ctSmall, a ciphertext of the small parameters. The output is at the minimum level of ctLarge and ctSmall, and
+// the moduli of ctLarge above this level are simply dropped: with a scheme whose plaintext scaling depends on the
+// modulus, such as BFV, ctLarge must first be switched to the level of the output.
+func (rs *RingSwitcher) SwitchDown(ctLarge, ctSmall *Element) {
+
+	if ctLarge.Degree() != 1 || ctSmall.Degree() != 1 {
+		panic("cannot SwitchDown: input and output must be of degree 1")
+	}
+
+	level := utils.MinInt(ctLarge.Level(), ctSmall.Level())
+
+	ctTmp := rs.poolLarge
+	ctTmp.Value[0].Coeffs = ctTmp.Value[0].Coeffs[:level+1]
+	ctTmp.Value[1].Coeffs = ctTmp.Value[1].Coeffs[:level+1]
+
+	rs.reLargeToSmall.ReEncrypt(ctLarge, ctTmp)
+
+	gap := rs.large.N() / rs.small.N()
+
+	for i := range ctTmp.Value {
+
+		polLarge := ctTmp.Value[i]
+		if ctLarge.IsNTT {
+			rs.ringQLarge.InvNTTLvl(level, polLarge, rs.poolQLarge)
+			polLarge = rs.poolQLarge
+		}
+
+		for x := 0; x < level+1; x++ {
+			coeffsLarge, coeffsSmall := polLarge.Coeffs[x], ctSmall.Value[i].Coeffs[x]
+			for j := range coeffsSmall {
+				coeffsSmall[j] = coeffsLarge[j*gap]
+			}
+		}
+
+		if ctLarge.IsNTT {
+			rs.ringQSmall.NTTLvl(level, ctSmall.Value[i], ctSmall.Value[i])
+		}
+
+		ctSmall.Value[i].Coeffs = ctSmall.Value[i].Coeffs[:level+1]
+	}
+
+	ctSmall.IsNTT = ctLarge.IsNTT
+}
+
+// SwitchDownNew switches ctLarge, a ciphertext of the large parameters, to the small ring and returns the result
+// in a new ciphertext of the small parameters (see SwitchDown).
+func (rs *RingSwitcher) SwitchDownNew(ctLarge *Element) (ctSmall *Element) {
+	ctSmall = NewElementAtLevel(rs.small, 1, utils.MinInt(ctLarge.Level(), rs.MaxLevel()))
+	rs.SwitchDown(ctLarge, ctSmall)
+	return
+}
+
+// SwitchUp switches ctSmall, a ciphertext of the small parameters, to the large ring and returns the result in
+// ctLarge, a ciphertext of the large parameters. The output is at the minimum level of ctSmall and ctLarge.
+func (rs *RingSwitcher) SwitchUp(ctSmall, ctLarge *Element) {
+
+	if ctSmall.Degree() != 1 || ctLarge.Degree() != 1 {
+		panic("cannot SwitchUp: input and output must be of degree 1")
+	}
+
+	level := utils.MinInt(ctSmall.Level(), ctLarge.Level())
+
+	ctTmp := rs.poolLarge
+	ctTmp.Value[0].Coeffs = ctTmp.Value[0].Coeffs[:level+1]
+	ctTmp.Value[1].Coeffs = ctTmp.Value[1].Coeffs[:level+1]
+
+	gap := rs.large.N() / rs.small.N()
+
+	for i := range ctSmall.Value {
+
+		polSmall := ctSmall.Value[i]
+		if ctSmall.IsNTT {
+			rs.ringQSmall.InvNTTLvl(level, polSmall, rs.poolQSmall)
+			polSmall = rs.poolQSmall
+		}
+
+		for x := 0; x < level+1; x++ {
+			coeffsLarge, coeffsSmall := ctTmp.Value[i].Coeffs[x], polSmall.Coeffs[x]
+			for j := range coeffsLarge {
+				coeffsLarge[j] = 0
+			}
+			for j := range coeffsSmall {
+				coeffsLarge[j*gap] = coeffsSmall[j]
+			}
+		}
+
+		if ctSmall.IsNTT {
+			rs.ringQLarge.NTTLvl(level, ctTmp.Value[i], ctTmp.Value[i])
+		}
+	}
+
+	ctTmp.IsNTT = ctSmall.IsNTT
+
+	rs.reSmallToLarge.ReEncrypt(ctTmp, ctLarge)
+}
+
+// SwitchUpNew switches ctSmall, a ciphertext of the small parameters, to the large ring and returns the result
+// in a new ciphertext of the large parameters, at the level of ctSmall (see SwitchUp).
+func (rs *RingSwitcher) SwitchUpNew(ctSmall *Element) (ctLarge *Element) {
+	ctLarge = NewElementAtLevel(rs.large, 1, ctSmall.Level())
+	rs.SwitchUp(ctSmall, ctLarge)
+	return
+}
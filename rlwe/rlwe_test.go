@@ -0,0 +1,358 @@
+package rlwe
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// maxNoiseLog2 is the bound on the log2 of the noise of the key-switched ciphertexts of the tests.
+const maxNoiseLog2 = 32
+
+var testParamsLiteral = []ParametersLiteral{
+	{LogN: 11, LogQ: []int{55, 45, 45}, LogP: []int{61}, Sigma: DefaultSigma},
+	{LogN: 12, LogQ: []int{55, 45, 45, 45}, LogP: []int{55, 55}, Sigma: DefaultSigma},
+}
+
+type testContext struct {
+	params         Parameters
+	ringQ          *ring.Ring
+	ringQP         *ring.Ring
+	prng           utils.PRNG
+	gaussian       *ring.GaussianSampler
+	uniformSampler *ring.UniformSampler
+	sk             *SecretKey
+}
+
+func testString(opname string, params Parameters) string {
+	return fmt.Sprintf("%slogN=%d/logQP=%d/Qi=%d/Pi=%d", opname, params.LogN(), params.LogQP(), params.QCount(), params.PCount())
+}
+
+func newTestContext(params Parameters) (tc *testContext, err error) {
+
+	tc = &testContext{params: params, ringQ: params.RingQ(), ringQP: params.RingQP()}
+
+	if tc.prng, err = utils.NewPRNG(); err != nil {
+		return nil, err
+	}
+
+	tc.gaussian = ring.NewGaussianSampler(tc.prng, tc.ringQ, params.Sigma(), int(6*params.Sigma()))
+	tc.uniformSampler = ring.NewUniformSampler(tc.prng, tc.ringQ)
+	tc.sk = tc.genSecretKey()
+
+	return tc, nil
+}
+
+// genSecretKey returns a ternary secret key in the NTT and Montgomery domain.
+func (tc *testContext) genSecretKey() (sk *SecretKey) {
+	sk = &SecretKey{Value: ring.NewTernarySampler(tc.prng, tc.ringQP, 1.0/3, true).ReadNew()}
+	tc.ringQP.NTT(sk.Value, sk.Value)
+	return
+}
+
+// encryptZeroNew returns a fresh encryption of zero under sk at the given level, in the NTT domain.
+func (tc *testContext) encryptZeroNew(sk *SecretKey, level int) (ct *Element) {
+
+	ct = NewElementAtLevel(tc.params, 1, level)
+	ct.IsNTT = true
+
+	tc.gaussian.ReadLvl(level, ct.Value[0])
+	tc.ringQ.NTTLvl(level, ct.Value[0], ct.Value[0])
+	tc.uniformSampler.Readlvl(level, ct.Value[1])
+	tc.ringQ.MulCoeffsMontgomeryAndSubLvl(level, ct.Value[1], sk.Value, ct.Value[0])
+
+	return
+}
+
+// noiseLog2 returns the log2 of the largest coefficient, in absolute value, of the phase of the encryption of zero ct
+// under sk.
+func (tc *testContext) noiseLog2(ct *Element, sk *SecretKey) int {
+
+	level := ct.Level()
+	ringQ := tc.ringQ
+
+	phase := ringQ.NewPolyLvl(level)
+
+	if ct.IsNTT {
+		ringQ.MulCoeffsMontgomeryLvl(level, ct.Value[1], sk.Value, phase)
+		ringQ.AddLvl(level, phase, ct.Value[0], phase)
+		ringQ.InvNTTLvl(level, phase, phase)
+	} else {
+		ringQ.NTTLvl(level, ct.Value[1], phase)
+		ringQ.MulCoeffsMontgomeryLvl(level, phase, sk.Value, phase)
+		ringQ.InvNTTLvl(level, phase, phase)
+		ringQ.AddLvl(level, phase, ct.Value[0], phase)
+	}
+
+	var max uint64
+	q0 := ringQ.Modulus[0]
+	for _, c := range phase.Coeffs[0] {
+		if c > q0>>1 {
+			c = q0 - c
+		}
+		if c > max {
+			max = c
+		}
+	}
+
+	return bits.Len64(max)
+}
+
+func TestRLWE(t *testing.T) {
+
+	for _, paramsLiteral := range testParamsLiteral {
+
+		params, err := NewParametersFromLiteral(paramsLiteral)
+		require.NoError(t, err)
+
+		tc, err := newTestContext(params)
+		require.NoError(t, err)
+
+		testElement(tc, t)
+		testReEncryptor(tc, t)
+		testKeySwitcher(tc, t)
+		testKeyShards(tc, t)
+	}
+}
+
+func testElement(tc *testContext, t *testing.T) {
+
+	t.Run(testString("Element/NTTBatch/", tc.params), func(t *testing.T) {
+
+		level := tc.params.QCount() - 1
+
+		el := NewElementAtLevel(tc.params, 2, level)
+		for i := range el.Value {
+			tc.uniformSampler.Readlvl(level, el.Value[i])
+		}
+
+		want := el.CopyNew()
+		want.NTT(tc.ringQ, want)
+		require.True(t, want.IsNTT)
+
+		el.NTTBatch(tc.ringQ)
+		require.True(t, el.IsNTT)
+		for i := range el.Value {
+			require.True(t, tc.ringQ.EqualLvl(level, want.Value[i], el.Value[i]))
+		}
+
+		want.InvNTT(tc.ringQ, want)
+		el.InvNTTBatch(tc.ringQ)
+		require.False(t, el.IsNTT)
+		for i := range el.Value {
+			require.True(t, tc.ringQ.EqualLvl(level, want.Value[i], el.Value[i]))
+		}
+	})
+}
+
+func testReEncryptor(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	t.Run(testString("ReEncryptor/", params), func(t *testing.T) {
+
+		skNew, swk := GenKeyRotation(params, tc.sk)
+		reEncryptor := NewReEncryptor(params, swk)
+
+		elements := make([]*Element, 3)
+		for i := range elements {
+			elements[i] = tc.encryptZeroNew(tc.sk, params.QCount()-1)
+		}
+
+		// Re-encryption below the maximum level and out of the NTT domain
+		elements[1] = tc.encryptZeroNew(tc.sk, params.QCount()-2)
+		elements[2].InvNTT(tc.ringQ, elements[2])
+
+		reEncryptor.ReEncryptMany(elements, elements)
+
+		for _, el := range elements {
+			require.LessOrEqual(t, tc.noiseLog2(el, skNew), maxNoiseLog2)
+			require.Greater(t, tc.noiseLog2(el, tc.sk), maxNoiseLog2)
+		}
+
+		require.False(t, elements[2].IsNTT)
+		require.Equal(t, params.QCount()-2, elements[1].Level())
+
+		skOut := tc.genSecretKey()
+		reEncryptor = NewReEncryptor(params, GenReEncryptionKey(params, tc.sk, skOut))
+		require.LessOrEqual(t, tc.noiseLog2(reEncryptor.ReEncryptNew(tc.encryptZeroNew(tc.sk, params.QCount()-1)), skOut), maxNoiseLog2)
+	})
+
+	t.Run(testString("ReEncryptor/Gadget/", params), func(t *testing.T) {
+
+		skOut := tc.genSecretKey()
+
+		for _, gadget := range []Gadget{
+			NewRNSGadget(params),
+			NewBitGadget(params, 16),
+			NewHybridGadget(params, 30),
+		} {
+			swk := GenSwitchingKeyWithGadget(params, gadget, tc.sk, skOut)
+			require.Len(t, swk.Value, gadget.Digits(params.QCount()-1))
+
+			reEncryptor := NewReEncryptorWithGadget(params, gadget, swk)
+			ct := reEncryptor.ReEncryptNew(tc.encryptZeroNew(tc.sk, params.QCount()-1))
+			require.LessOrEqual(t, tc.noiseLog2(ct, skOut), maxNoiseLog2)
+		}
+
+		require.Panics(t, func() { NewBitGadget(params, 0) })
+		require.Panics(t, func() {
+			NewReEncryptorWithGadget(params, NewBitGadget(params, 16), GenSwitchingKeyWithGadget(params, NewRNSGadget(params), tc.sk, skOut))
+		})
+
+		// The bit decomposition does not need the modulus P
+		paramsNoP, err := NewParameters(params.LogN(), params.Q(), nil, params.Sigma())
+		require.NoError(t, err)
+
+		tcNoP, err := newTestContext(paramsNoP)
+		require.NoError(t, err)
+		skOut = tcNoP.genSecretKey()
+
+		gadget := NewBitGadget(paramsNoP, 8)
+		reEncryptor := NewReEncryptorWithGadget(paramsNoP, gadget, GenSwitchingKeyWithGadget(paramsNoP, gadget, tcNoP.sk, skOut))
+		ct := reEncryptor.ReEncryptNew(tcNoP.encryptZeroNew(tcNoP.sk, paramsNoP.QCount()-1))
+		require.LessOrEqual(t, tcNoP.noiseLog2(ct, skOut), maxNoiseLog2)
+	})
+}
+
+func testKeySwitcher(tc *testContext, t *testing.T) {
+
+	params := tc.params
+	ringQ, ringP := tc.ringQ, params.RingP()
+	baseconverter := ring.NewFastBasisExtender(ringQ, ringP)
+
+	t.Run(testString("KeySwitcher/", params), func(t *testing.T) {
+
+		skOut := tc.genSecretKey()
+		level := params.QCount() - 1
+
+		for _, gadget := range []Gadget{
+			NewRNSGadget(params),
+			NewBitGadget(params, 8),
+			NewHybridGadget(params, 20),
+		} {
+			ks := NewKeySwitcher(params, gadget)
+			require.Equal(t, gadget, ks.Gadget())
+
+			swk := GenSwitchingKeyWithGadget(params, gadget, tc.sk, skOut)
+
+			ct := tc.encryptZeroNew(tc.sk, level)
+			c1 := ringQ.NewPoly()
+			ringQ.InvNTTLvl(level, ct.Value[1], c1)
+
+			// SwitchKeys
+			res := NewElementAtLevel(params, 1, level)
+			res.IsNTT = true
+			ks.ShallowCopy().SwitchKeys(level, ct.Value[1], c1, swk, res.Value[0], res.Value[1])
+			ringQ.AddLvl(level, res.Value[0], ct.Value[0], res.Value[0])
+			require.LessOrEqual(t, tc.noiseLog2(res, skOut), maxNoiseLog2)
+
+			// SwitchKeysNoModDown followed by the division by P gives the result of SwitchKeys
+			p0Q, p1Q, p0P, p1P := ringQ.NewPoly(), ringQ.NewPoly(), ringP.NewPoly(), ringP.NewPoly()
+			ks.SwitchKeysNoModDown(level, ct.Value[1], c1, swk, p0Q, p1Q, p0P, p1P)
+			baseconverter.ModDownSplitNTTPQ(level, p0Q, p0P, p0Q)
+			baseconverter.ModDownSplitNTTPQ(level, p1Q, p1P, p1Q)
+			ringQ.AddLvl(level, p0Q, ct.Value[0], p0Q)
+			require.True(t, ringQ.EqualLvl(level, res.Value[0], p0Q))
+			require.True(t, ringQ.EqualLvl(level, res.Value[1], p1Q))
+
+			// The hoisted key-switch of the decomposition gives the same result as SwitchKeysNoModDown
+			digits := gadget.Digits(level)
+			c2QiQ, c2QiP := make([]*ring.Poly, digits), make([]*ring.Poly, digits)
+			for i := range c2QiQ {
+				c2QiQ[i], c2QiP[i] = ringQ.NewPoly(), ringP.NewPoly()
+			}
+			ks.Decompose(level, ct.Value[1], c1, c2QiQ, c2QiP)
+
+			h0Q, h1Q, h0P, h1P := ringQ.NewPoly(), ringQ.NewPoly(), ringP.NewPoly(), ringP.NewPoly()
+			ks.SwitchKeysNoModDown(level, ct.Value[1], c1, swk, p0Q, p1Q, p0P, p1P)
+			ks.SwitchKeysHoistedNoModDown(level, c2QiQ, c2QiP, swk, h0Q, h1Q, h0P, h1P)
+			require.True(t, ringQ.EqualLvl(level, p0Q, h0Q))
+			require.True(t, ringQ.EqualLvl(level, p1Q, h1Q))
+			require.True(t, ringP.Equal(p0P, h0P))
+			require.True(t, ringP.Equal(p1P, h1P))
+		}
+	})
+}
+
+func testKeyShards(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	t.Run(testString("KeyShards/", params), func(t *testing.T) {
+
+		gadget := NewRNSGadget(params)
+
+		galEls := []uint64{params.GaloisElementForRowRotation()}
+		for _, n := range []int{1, -1, 63} {
+			galEls = append(galEls, params.GaloisElementForColumnRotationBy(n))
+		}
+
+		evk := EvaluationKey{
+			Rlk:  GenRelinearizationKeyWithGadget(params, gadget, tc.sk, 2),
+			Rtks: GenRotationKeysWithGadget(params, gadget, galEls, tc.sk),
+		}
+
+		manifest, shards, err := evk.MarshalShards()
+		require.NoError(t, err)
+		var nbShards int
+		for _, swk := range evk.Rlk.Keys {
+			nbShards += len(swk.Value)
+		}
+		for _, swk := range evk.Rtks.Keys {
+			nbShards += len(swk.Value)
+		}
+		require.Len(t, shards, nbShards)
+
+		data, err := manifest.MarshalBinary()
+		require.NoError(t, err)
+
+		resManifest := new(KeyShardManifest)
+		require.NoError(t, resManifest.UnmarshalBinary(data))
+		require.Equal(t, manifest, resManifest)
+
+		// Loads only the shards of the relinearization key and of two rotation keys
+		loaded := make(map[KeyShardID]bool)
+		load := func(id KeyShardID) ([]byte, error) {
+			loaded[id] = true
+			return shards[id], nil
+		}
+
+		ids, err := resManifest.ShardIDs(true, galEls[:2])
+		require.NoError(t, err)
+
+		rlk, err := resManifest.LoadRelinearizationKey(load)
+		require.NoError(t, err)
+		require.True(t, rlk.Equals(evk.Rlk))
+
+		rtks, err := resManifest.LoadRotationKeys(galEls[:2], load)
+		require.NoError(t, err)
+		require.Len(t, rtks.Keys, 2)
+		for _, galEl := range galEls[:2] {
+			require.True(t, rtks.Keys[galEl].Equals(evk.Rtks.Keys[galEl]))
+		}
+
+		require.Len(t, loaded, len(ids))
+		for _, id := range ids {
+			require.True(t, loaded[id])
+		}
+
+		_, err = resManifest.LoadRotationKeys([]uint64{params.GaloisElementForColumnRotationBy(2)}, load)
+		require.Error(t, err)
+
+		// Duplicate entries of a switching key are rejected
+		duplicate := &KeyShardManifest{Entries: append([]KeyShardManifestEntry{}, manifest.Entries...)}
+		duplicate.Entries[1].Index = duplicate.Entries[0].Index
+		_, err = duplicate.LoadRelinearizationKey(load)
+		require.Error(t, err)
+
+		data, err = duplicate.MarshalBinary()
+		require.NoError(t, err)
+		require.Error(t, new(KeyShardManifest).UnmarshalBinary(data))
+	})
+}
@@ -0,0 +1,77 @@
+package rlwe
+
+// Rotation is the unified description of the rotations accepted by the RotateBy methods of the BFV and CKKS
+// Evaluators. It is implemented by the SlotRotation and CoeffShift types, which fix the direction conventions:
+//
+//   - SlotRotation(k) rotates the slots by k positions to the left, i.e. the slot i of the output stores the slot
+//     i+k of the input. A negative k rotates to the right. In BFV, the slots are the columns of the 2 x N/2 plaintext
+//     matrix (both rows are rotated). In CKKS, the slots are the entries of the message vector. This is the
+//     convention of the Rotate, RotateColumns and GenRotationKeysForRotations methods.
+//
+//   - CoeffShift(k) multiplies the plaintext polynomial by X^k, i.e. the coefficient i+k of the output stores the
+//     coefficient i of the input, with a sign change for the coefficients wrapping around X^N = -1. A negative k
+//     shifts the coefficients toward the lower degrees. It does not require any key.
+//
+// The swap of the two rows of BFV (RotateRows) and the conjugation of CKKS (Conjugate) are not rotations.
+type Rotation interface {
+	isRotation()
+}
+
+// SlotRotation is a rotation of the slots by k positions to the left. See Rotation for the conventions.
+type SlotRotation int
+
+// RotateLeft returns the SlotRotation rotating the slots by k positions to the left.
+func RotateLeft(k int) SlotRotation {
+	return SlotRotation(k)
+}
+
+// RotateRight returns the SlotRotation rotating the slots by k positions to the right.
+func RotateRight(k int) SlotRotation {
+	return SlotRotation(-k)
+}
+
+// GaloisElement returns the Galois element of the automorphism realizing the rotation. A rotation key
+// for this Galois element is required to evaluate the rotation.
+func (rot SlotRotation) GaloisElement(params Parameters) uint64 {
+	return params.GaloisElementForColumnRotationBy(int(rot))
+}
+
+// Normalized returns the equivalent left rotation by k positions with 0 <= k < N/2.
+func (rot SlotRotation) Normalized(params Parameters) SlotRotation {
+	return SlotRotation(int(rot) & (params.N()>>1 - 1))
+}
+
+func (rot SlotRotation) isRotation() {}
+
+// SlotRotationFromGaloisElement returns the normalized SlotRotation realized by the automorphism X -> X^galEl.
+// The second output is false if the automorphism is not a slot rotation (e.g. the row rotation of BFV).
+func SlotRotationFromGaloisElement(params Parameters, galEl uint64) (rot SlotRotation, ok bool) {
+	mask := uint64(params.N()<<1) - 1
+	pow := uint64(1)
+	for k := 0; k < params.N()>>1; k++ {
+		if pow == galEl&mask {
+			return SlotRotation(k), true
+		}
+		pow = (pow * GaloisGen) & mask
+	}
+	return 0, false
+}
+
+// GaloisElementsForSlotRotations returns the Galois elements of the automorphisms realizing the given rotations.
+func GaloisElementsForSlotRotations(params Parameters, rots []SlotRotation) (galEls []uint64) {
+	galEls = make([]uint64, len(rots))
+	for i, rot := range rots {
+		galEls[i] = rot.GaloisElement(params)
+	}
+	return
+}
+
+// CoeffShift is the multiplication of the plaintext polynomial by X^k. See Rotation for the conventions.
+type CoeffShift int
+
+// MonomialDegree returns the equivalent degree 0 <= d < 2N of the monomial X^k, given that X^(2N) = 1.
+func (shift CoeffShift) MonomialDegree(params Parameters) int {
+	return int(shift) & (params.N()<<1 - 1)
+}
+
+func (shift CoeffShift) isRotation() {}
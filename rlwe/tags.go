@@ -0,0 +1,115 @@
+package rlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// Tags are key-value pairs attached to a Ciphertext or a Plaintext through its MetaData, e.g. to track the provenance
+// of a ciphertext (feature name, batch id) through a pipeline without side tables keyed by pointer. The tags are not
+// encrypted and are not authenticated.
+type Tags map[string]string
+
+// CopyNew returns a copy of the tags, or nil if they are empty.
+func (t Tags) CopyNew() Tags {
+	if len(t) == 0 {
+		return nil
+	}
+	tCopy := make(Tags, len(t))
+	for k, v := range t {
+		tCopy[k] = v
+	}
+	return tCopy
+}
+
+// Equals returns true if the two sets of tags are equal, nil being equal to empty tags.
+func (t Tags) Equals(other Tags) bool {
+	if len(t) != len(other) {
+		return false
+	}
+	for k, v := range t {
+		if w, ok := other[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDataLen returns the length in bytes of the encoding of the tags.
+func (t Tags) GetDataLen() (dataLen int) {
+	dataLen = 4
+	for k, v := range t {
+		dataLen += 8 + len(k) + len(v)
+	}
+	return
+}
+
+// WriteTo writes the tags on data, sorted by key, and returns the number of bytes written:
+// 4 byte : number of tags
+// for each tag, 4 byte : length of the key, the key, 4 byte : length of the value, the value
+// Returns an error if data is shorter than GetDataLen.
+func (t Tags) WriteTo(data []byte) (pointer int, err error) {
+
+	if len(data) < t.GetDataLen() {
+		return 0, errors.New("too small bytearray for the tags")
+	}
+
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	binary.LittleEndian.PutUint32(data, uint32(len(keys)))
+	pointer = 4
+
+	for _, k := range keys {
+		for _, s := range []string{k, t[k]} {
+			binary.LittleEndian.PutUint32(data[pointer:], uint32(len(s)))
+			pointer += 4
+			pointer += copy(data[pointer:], s)
+		}
+	}
+
+	return
+}
+
+// Decode decodes tags written by WriteTo on the receiver and returns the number of bytes read.
+func (t *Tags) Decode(data []byte) (pointer int, err error) {
+
+	if len(data) < 4 {
+		return 0, errors.New("too small bytearray for the tags")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data))
+	pointer = 4
+
+	tags := make(Tags)
+	for i := 0; i < n; i++ {
+
+		var kv [2]string
+		for j := range kv {
+
+			if len(data[pointer:]) < 4 {
+				return 0, errors.New("too small bytearray for the tags")
+			}
+
+			l := int(binary.LittleEndian.Uint32(data[pointer:]))
+			pointer += 4
+
+			if l > len(data[pointer:]) {
+				return 0, errors.New("too small bytearray for the tags")
+			}
+
+			kv[j] = string(data[pointer : pointer+l])
+			pointer += l
+		}
+
+		tags[kv[0]] = kv[1]
+	}
+
+	*t = tags.CopyNew()
+
+	return pointer, nil
+}
@@ -0,0 +1,210 @@
+// Package security implements an estimator of the security level of RLWE parameters, based on the
+// cost-model tables of the Homomorphic Encryption Standard (homomorphicencryption.org).
+package security
+
+import (
+	"fmt"
+	"math"
+)
+
+// SecretDistribution is the distribution of the RLWE secret.
+type SecretDistribution int
+
+const (
+	// Ternary is a secret with coefficients uniformly distributed in {-1, 0, 1}.
+	Ternary SecretDistribution = iota
+	// Uniform is a secret with coefficients uniformly distributed modulo QP.
+	Uniform
+	// Gaussian is a secret with coefficients distributed as the error.
+	Gaussian
+)
+
+// Model is the attacker model of a security estimate.
+type Model int
+
+const (
+	// Classical is the cost model of a classical attacker.
+	Classical Model = iota
+	// Quantum is the cost model of a quantum attacker.
+	Quantum
+)
+
+// ReferenceSigma is the standard deviation of the error distribution for which the tables are computed.
+const ReferenceSigma = 3.2
+
+// MinLogN and MaxLogN are the smallest and the largest log2 of the ring degree covered by the tables.
+const (
+	MinLogN = 10
+	MaxLogN = 16
+)
+
+// Levels are the security levels, in bits, of the columns of the tables.
+var Levels = [3]int{128, 192, 256}
+
+// maxLogQP stores, for each secret distribution, attacker model and logN, the largest logQP
+// ensuring 128, 192 and 256 bits of security with an error of standard deviation ReferenceSigma,
+// according to the Homomorphic Encryption Standard (the values for logN=16 are extrapolated).
+var maxLogQP = map[SecretDistribution][2]map[int][3]int{
+	Ternary: {
+		Classical: {
+			10: {27, 19, 14},
+			11: {54, 37, 29},
+			12: {109, 75, 58},
+			13: {218, 152, 118},
+			14: {438, 305, 237},
+			15: {881, 611, 476},
+			16: {1761, 1221, 952},
+		},
+		Quantum: {
+			10: {25, 17, 13},
+			11: {51, 35, 27},
+			12: {101, 70, 54},
+			13: {202, 141, 109},
+			14: {411, 284, 220},
+			15: {827, 571, 443},
+			16: {1653, 1141, 886},
+		},
+	},
+	Uniform: {
+		Classical: {
+			10: {29, 21, 16},
+			11: {56, 39, 31},
+			12: {111, 77, 60},
+			13: {220, 154, 120},
+			14: {440, 307, 239},
+			15: {880, 612, 478},
+			16: {1760, 1224, 956},
+		},
+		Quantum: {
+			10: {27, 19, 15},
+			11: {53, 37, 29},
+			12: {103, 72, 56},
+			13: {206, 143, 111},
+			14: {413, 286, 222},
+			15: {829, 573, 445},
+			16: {1658, 1146, 890},
+		},
+	},
+	Gaussian: {
+		Classical: {
+			10: {29, 21, 16},
+			11: {56, 39, 31},
+			12: {111, 77, 60},
+			13: {220, 154, 120},
+			14: {440, 307, 239},
+			15: {883, 613, 478},
+			16: {1766, 1226, 956},
+		},
+		Quantum: {
+			10: {27, 19, 15},
+			11: {53, 37, 29},
+			12: {103, 72, 56},
+			13: {206, 143, 111},
+			14: {413, 286, 222},
+			15: {829, 573, 445},
+			16: {1658, 1146, 890},
+		},
+	},
+}
+
+// Estimate is an estimate of the security level of a set of parameters, in bits.
+type Estimate struct {
+	Classical float64
+	Quantum   float64
+}
+
+// String returns a single-line summary of the estimate.
+func (e Estimate) String() string {
+	return fmt.Sprintf("~%.1f bits (classical), ~%.1f bits (quantum)", e.Classical, e.Quantum)
+}
+
+// MaxLogQP returns the largest logQP ensuring lambda bits of security, for lambda in Levels, a ring degree 2^logN,
+// the given secret distribution and an error of standard deviation ReferenceSigma.
+func MaxLogQP(logN int, secret SecretDistribution, model Model, lambda int) (int, error) {
+
+	bounds, err := getBounds(logN, secret, model)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range Levels {
+		if Levels[i] == lambda {
+			return bounds[i], nil
+		}
+	}
+
+	return 0, fmt.Errorf("security level %d is not in the tables", lambda)
+}
+
+// EstimateSecurity returns the estimated classical and quantum security level of RLWE parameters with ring degree
+// 2^logN, modulus of logQP bits, the given secret distribution and an error of standard deviation sigma.
+//
+// The security level is interpolated between the entries of the tables assuming that it is linear in 1/logQP,
+// and extrapolated below 128 bits. A standard deviation different from ReferenceSigma is accounted for by
+// shifting logQP by log2(sigma/ReferenceSigma), since the hardness depends on the ratio between the modulus and the
+// error. Returns an error if logN is not covered by the tables or if the inputs are not positive.
+func EstimateSecurity(logN int, logQP float64, secret SecretDistribution, sigma float64) (e Estimate, err error) {
+
+	if logQP <= 0 {
+		return e, fmt.Errorf("invalid logQP: %f", logQP)
+	}
+
+	if sigma <= 0 {
+		return e, fmt.Errorf("invalid sigma: %f", sigma)
+	}
+
+	logQP -= math.Log2(sigma / ReferenceSigma)
+
+	if e.Classical, err = estimate(logN, logQP, secret, Classical); err != nil {
+		return Estimate{}, err
+	}
+
+	if e.Quantum, err = estimate(logN, logQP, secret, Quantum); err != nil {
+		return Estimate{}, err
+	}
+
+	return
+}
+
+func estimate(logN int, logQP float64, secret SecretDistribution, model Model) (lambda float64, err error) {
+
+	bounds, err := getBounds(logN, secret, model)
+	if err != nil {
+		return 0, err
+	}
+
+	if logQP <= 0 {
+		return math.Inf(1), nil
+	}
+
+	// Selects the segment of the table containing logQP, or the closest one for the extrapolation
+	i := 0
+	if logQP < float64(bounds[1]) {
+		i = 1
+	}
+
+	x0, x1 := 1/float64(bounds[i]), 1/float64(bounds[i+1])
+	y0, y1 := float64(Levels[i]), float64(Levels[i+1])
+
+	lambda = y0 + (y1-y0)*(1/logQP-x0)/(x1-x0)
+
+	return math.Max(lambda, 0), nil
+}
+
+func getBounds(logN int, secret SecretDistribution, model Model) (bounds [3]int, err error) {
+
+	tables, ok := maxLogQP[secret]
+	if !ok {
+		return bounds, fmt.Errorf("invalid secret distribution: %d", secret)
+	}
+
+	if model != Classical && model != Quantum {
+		return bounds, fmt.Errorf("invalid model: %d", model)
+	}
+
+	if bounds, ok = tables[model][logN]; !ok {
+		return bounds, fmt.Errorf("logN=%d is not covered by the tables (%d <= logN <= %d)", logN, MinLogN, MaxLogN)
+	}
+
+	return
+}
@@ -0,0 +1,101 @@
+package security
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxLogQP(t *testing.T) {
+
+	logQP, err := MaxLogQP(15, Ternary, Classical, 128)
+	require.NoError(t, err)
+	require.Equal(t, 881, logQP)
+
+	_, err = MaxLogQP(15, Ternary, Classical, 100)
+	require.Error(t, err)
+
+	_, err = MaxLogQP(9, Ternary, Classical, 128)
+	require.Error(t, err)
+
+	_, err = MaxLogQP(15, SecretDistribution(3), Classical, 128)
+	require.Error(t, err)
+
+	_, err = MaxLogQP(15, Ternary, Model(2), 128)
+	require.Error(t, err)
+}
+
+func TestEstimateSecurity(t *testing.T) {
+
+	t.Run("Tables", func(t *testing.T) {
+		for _, secret := range []SecretDistribution{Ternary, Uniform, Gaussian} {
+			for logN := MinLogN; logN <= MaxLogN; logN++ {
+				for _, model := range []Model{Classical, Quantum} {
+
+					// The estimate matches the tables and is decreasing with logQP
+					prev := math.Inf(1)
+					for _, lambda := range []int{256, 192, 128} {
+
+						logQP, err := MaxLogQP(logN, secret, model, lambda)
+						require.NoError(t, err)
+
+						e, err := EstimateSecurity(logN, float64(logQP), secret, ReferenceSigma)
+						require.NoError(t, err)
+
+						have := e.Classical
+						if model == Quantum {
+							have = e.Quantum
+						}
+
+						require.InDelta(t, float64(lambda), have, 1e-9)
+						require.Less(t, have, prev)
+						prev = have
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Interpolation", func(t *testing.T) {
+
+		e, err := EstimateSecurity(15, 700, Ternary, ReferenceSigma)
+		require.NoError(t, err)
+		require.Greater(t, e.Classical, 128.0)
+		require.Less(t, e.Classical, 192.0)
+		require.Less(t, e.Quantum, e.Classical)
+
+		// Extrapolation below 128 bits
+		e, err = EstimateSecurity(15, 1200, Ternary, ReferenceSigma)
+		require.NoError(t, err)
+		require.Less(t, e.Classical, 128.0)
+		require.Greater(t, e.Classical, 0.0)
+	})
+
+	t.Run("Sigma", func(t *testing.T) {
+
+		e0, err := EstimateSecurity(14, 438, Ternary, ReferenceSigma)
+		require.NoError(t, err)
+
+		// A larger error increases the security
+		e1, err := EstimateSecurity(14, 438, Ternary, 2*ReferenceSigma)
+		require.NoError(t, err)
+		require.Greater(t, e1.Classical, e0.Classical)
+
+		e2, err := EstimateSecurity(14, 437, Ternary, ReferenceSigma)
+		require.NoError(t, err)
+		require.InDelta(t, e2.Classical, e1.Classical, 1e-9)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+
+		_, err := EstimateSecurity(17, 438, Ternary, ReferenceSigma)
+		require.Error(t, err)
+
+		_, err = EstimateSecurity(14, 0, Ternary, ReferenceSigma)
+		require.Error(t, err)
+
+		_, err = EstimateSecurity(14, 438, Ternary, 0)
+		require.Error(t, err)
+	})
+}
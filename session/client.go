@@ -0,0 +1,217 @@
+// Package session implements a high-level API for the client-server usage of the CKKS scheme, in which a client
+// encrypts its data, sends the ciphertexts to a server that evaluates a circuit on them, and decrypts the results.
+//
+// A Client holds the secret key and exposes the encoding, encryption, decryption and decoding as methods working
+// on slices of bytes. Its EvaluationKey method returns the marshaled bundle of the parameters and of the public
+// evaluation keys, from which a Server is created with NewServer: the server needs nothing else to evaluate
+// circuits on the ciphertexts of the client. All the objects are marshaled with their rlwe.Header (see
+// ckks.MarshalVersioned), so that the objects of another scheme, parameters or format version are rejected with an
+// error instead of being decoded on garbage.
+package session
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Client is the party of a session holding the secret key. A Client is not safe for concurrent use.
+type Client struct {
+	params    ckks.Parameters
+	sk        *rlwe.SecretKey
+	pk        *rlwe.PublicKey
+	keygen    ckks.KeyGenerator
+	encoder   ckks.Encoder
+	encryptor ckks.Encryptor
+	decryptor ckks.Decryptor
+}
+
+// NewClient creates a new Client with a fresh secret key for the parameters params.
+func NewClient(params ckks.Parameters) *Client {
+	return NewClientFromSecretKey(params, ckks.NewKeyGenerator(params).GenSecretKey())
+}
+
+// NewClientFromSecretKey creates a new Client with the secret key sk, e.g. to resume a previous session.
+func NewClientFromSecretKey(params ckks.Parameters, sk *rlwe.SecretKey) *Client {
+	keygen := ckks.NewKeyGenerator(params)
+	pk := keygen.GenPublicKey(sk)
+	return &Client{
+		params:    params,
+		sk:        sk,
+		pk:        pk,
+		keygen:    keygen,
+		encoder:   ckks.NewEncoder(params),
+		encryptor: ckks.NewEncryptorFromPk(params, pk),
+		decryptor: ckks.NewDecryptor(params, sk),
+	}
+}
+
+// Parameters returns the parameters of the session.
+func (c *Client) Parameters() ckks.Parameters {
+	return c.params
+}
+
+// SecretKey returns the secret key of the client.
+func (c *Client) SecretKey() *rlwe.SecretKey {
+	return c.sk
+}
+
+// PublicKey returns the marshaled public key of the client, with which other parties can encrypt for it (see
+// NewEncryptor).
+func (c *Client) PublicKey() ([]byte, error) {
+	return ckks.MarshalVersioned(c.params, c.pk)
+}
+
+// EvaluationKey generates the relinearization key and the rotation keys for the rotations by the given numbers of
+// slots to the left, and the conjugation key if conjugate is true, and returns them marshaled along with the
+// parameters, in the format expected by NewServer.
+func (c *Client) EvaluationKey(rotations []int, conjugate bool) (data []byte, err error) {
+
+	rlk := c.keygen.GenRelinearizationKey(c.sk)
+
+	var rtks *rlwe.RotationKeySet
+	if len(rotations) > 0 || conjugate {
+		rtks = c.keygen.GenRotationKeysForRotations(rotations, conjugate, c.sk)
+	}
+
+	return marshalEvaluationKey(c.params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks})
+}
+
+// Encrypt encodes the values on the slots of a plaintext at the maximum level and at the default scale of the
+// parameters, encrypts it with the public key and returns the marshaled ciphertext. The slots beyond the values
+// are set to zero.
+func (c *Client) Encrypt(values []complex128) ([]byte, error) {
+	ct, err := encrypt(c.params, c.encoder, c.encryptor, values)
+	if err != nil {
+		return nil, err
+	}
+	return ckks.MarshalVersioned(c.params, ct)
+}
+
+// Decrypt unmarshals and decrypts the ciphertext data, and returns the decoded values of its slots.
+func (c *Client) Decrypt(data []byte) (values []complex128, err error) {
+
+	ct := new(ckks.Ciphertext)
+	if _, err = ckks.UnmarshalVersioned(c.params, data, ct); err != nil {
+		return nil, fmt.Errorf("cannot Decrypt: %w", err)
+	}
+
+	return c.encoder.Decode(c.decryptor.DecryptNew(ct), c.params.LogSlots()), nil
+}
+
+// Encryptor encrypts values with the public key of a client, for the parties other than the client contributing
+// data to a session. An Encryptor is not safe for concurrent use.
+type Encryptor struct {
+	params    ckks.Parameters
+	encoder   ckks.Encoder
+	encryptor ckks.Encryptor
+}
+
+// NewEncryptor creates a new Encryptor for the parameters params and the marshaled public key publicKey (see
+// Client.PublicKey).
+func NewEncryptor(params ckks.Parameters, publicKey []byte) (*Encryptor, error) {
+
+	pk := new(rlwe.PublicKey)
+	if _, err := ckks.UnmarshalVersioned(params, publicKey, pk); err != nil {
+		return nil, fmt.Errorf("cannot NewEncryptor: %w", err)
+	}
+
+	return &Encryptor{params: params, encoder: ckks.NewEncoder(params), encryptor: ckks.NewEncryptorFromPk(params, pk)}, nil
+}
+
+// Encrypt encodes and encrypts the values and returns the marshaled ciphertext (see Client.Encrypt).
+func (e *Encryptor) Encrypt(values []complex128) ([]byte, error) {
+	ct, err := encrypt(e.params, e.encoder, e.encryptor, values)
+	if err != nil {
+		return nil, err
+	}
+	return ckks.MarshalVersioned(e.params, ct)
+}
+
+func encrypt(params ckks.Parameters, encoder ckks.Encoder, encryptor ckks.Encryptor, values []complex128) (*ckks.Ciphertext, error) {
+
+	if len(values) > params.Slots() {
+		return nil, fmt.Errorf("cannot Encrypt: the number of values (%d) is larger than the number of slots (%d)", len(values), params.Slots())
+	}
+
+	slots := make([]complex128, params.Slots())
+	copy(slots, values)
+
+	return encryptor.EncryptNew(encoder.EncodeNTTAtLvlNew(params.MaxLevel(), slots, params.LogSlots())), nil
+}
+
+// marshalEvaluationKey encodes the parameters and the evaluation key as a sequence of length-prefixed objects:
+// the parameters, the relinearization key and the rotation keys, whose length is zero if there are none.
+func marshalEvaluationKey(params ckks.Parameters, evk rlwe.EvaluationKey) (data []byte, err error) {
+
+	objects := make([][]byte, 3)
+
+	if objects[0], err = params.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	if objects[1], err = ckks.MarshalVersioned(params, evk.Rlk); err != nil {
+		return nil, err
+	}
+
+	if evk.Rtks != nil {
+		if objects[2], err = ckks.MarshalVersioned(params, evk.Rtks); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, obj := range objects {
+		var length [8]byte
+		binary.LittleEndian.PutUint64(length[:], uint64(len(obj)))
+		data = append(data, length[:]...)
+		data = append(data, obj...)
+	}
+
+	return data, nil
+}
+
+// unmarshalEvaluationKey decodes data, previously marshaled with marshalEvaluationKey.
+func unmarshalEvaluationKey(data []byte) (params ckks.Parameters, evk rlwe.EvaluationKey, err error) {
+
+	objects := make([][]byte, 3)
+	for i := range objects {
+
+		if len(data) < 8 {
+			return params, evk, errors.New("truncated evaluation key")
+		}
+
+		length := binary.LittleEndian.Uint64(data)
+		data = data[8:]
+
+		if uint64(len(data)) < length {
+			return params, evk, errors.New("truncated evaluation key")
+		}
+
+		objects[i], data = data[:length], data[length:]
+	}
+
+	if len(data) != 0 {
+		return params, evk, errors.New("trailing data after the evaluation key")
+	}
+
+	if err = params.UnmarshalBinary(objects[0]); err != nil {
+		return params, evk, err
+	}
+
+	evk.Rlk = new(rlwe.RelinearizationKey)
+	if _, err = ckks.UnmarshalVersioned(params, objects[1], evk.Rlk); err != nil {
+		return params, evk, err
+	}
+
+	if len(objects[2]) != 0 {
+		evk.Rtks = new(rlwe.RotationKeySet)
+		if _, err = ckks.UnmarshalVersioned(params, objects[2], evk.Rtks); err != nil {
+			return params, evk, err
+		}
+	}
+
+	return params, evk, nil
+}
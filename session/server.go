@@ -0,0 +1,85 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+)
+
+// Circuit is a homomorphic circuit evaluated by a Server on the ciphertexts of a client.
+type Circuit func(eval ckks.Evaluator, inputs []*ckks.Ciphertext) (outputs []*ckks.Ciphertext, err error)
+
+// Server is the party of a session evaluating circuits on the ciphertexts of a client with its evaluation key.
+// A Server is not safe for concurrent use: each goroutine must use its own copy (see ShallowCopy).
+type Server struct {
+	params ckks.Parameters
+	eval   ckks.Evaluator
+}
+
+// NewServer creates a new Server from the marshaled evaluation key of a client (see Client.EvaluationKey).
+func NewServer(evaluationKey []byte) (*Server, error) {
+
+	params, evk, err := unmarshalEvaluationKey(evaluationKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot NewServer: %w", err)
+	}
+
+	return &Server{params: params, eval: ckks.NewEvaluator(params, evk)}, nil
+}
+
+// ShallowCopy creates a shallow copy of this Server in which the parameters and the evaluation key are shared with
+// the receiver and the temporary buffers of the evaluator are reallocated.
+func (s *Server) ShallowCopy() *Server {
+	return &Server{params: s.params, eval: s.eval.ShallowCopy()}
+}
+
+// Parameters returns the parameters of the session.
+func (s *Server) Parameters() ckks.Parameters {
+	return s.params
+}
+
+// Evaluator returns the evaluator of the server.
+func (s *Server) Evaluator() ckks.Evaluator {
+	return s.eval
+}
+
+// UnmarshalCiphertext decodes a ciphertext marshaled by the client. Returns an error if data is not a ciphertext
+// of the parameters of the session.
+func (s *Server) UnmarshalCiphertext(data []byte) (ct *ckks.Ciphertext, err error) {
+	ct = new(ckks.Ciphertext)
+	if _, err = ckks.UnmarshalVersioned(s.params, data, ct); err != nil {
+		return nil, fmt.Errorf("cannot UnmarshalCiphertext: %w", err)
+	}
+	return ct, nil
+}
+
+// MarshalCiphertext encodes a ciphertext for the client.
+func (s *Server) MarshalCiphertext(ct *ckks.Ciphertext) ([]byte, error) {
+	return ckks.MarshalVersioned(s.params, ct)
+}
+
+// Evaluate unmarshals the ciphertexts inputs, evaluates the circuit on them and returns its marshaled outputs.
+// Returns an error if an input cannot be unmarshaled, or the error returned by the circuit.
+func (s *Server) Evaluate(circuit Circuit, inputs [][]byte) (outputs [][]byte, err error) {
+
+	cts := make([]*ckks.Ciphertext, len(inputs))
+	for i, data := range inputs {
+		if cts[i], err = s.UnmarshalCiphertext(data); err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: input %d: %w", i, err)
+		}
+	}
+
+	var results []*ckks.Ciphertext
+	if results, err = circuit(s.eval, cts); err != nil {
+		return nil, fmt.Errorf("cannot Evaluate: %w", err)
+	}
+
+	outputs = make([][]byte, len(results))
+	for i, ct := range results {
+		if outputs[i], err = s.MarshalCiphertext(ct); err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: output %d: %w", i, err)
+		}
+	}
+
+	return outputs, nil
+}
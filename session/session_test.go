@@ -0,0 +1,137 @@
+package session
+
+import (
+	"errors"
+	"math/cmplx"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+
+	params, err := ckks.NewParametersFromLiteral(ckks.PN13QP218)
+	require.NoError(t, err)
+
+	client := NewClient(params)
+
+	evk, err := client.EvaluationKey([]int{1}, false)
+	require.NoError(t, err)
+
+	server, err := NewServer(evk)
+	require.NoError(t, err)
+	require.True(t, server.Parameters().Equals(params))
+
+	values0 := make([]complex128, params.Slots())
+	values1 := make([]complex128, params.Slots())
+	for i := range values0 {
+		values0[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		values1[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+	}
+
+	requireClose := func(want, have []complex128) {
+		require.Len(t, have, len(want))
+		for i := range want {
+			require.Less(t, cmplx.Abs(want[i]-have[i]), 1e-3)
+		}
+	}
+
+	t.Run("Evaluate", func(t *testing.T) {
+
+		ct0, err := client.Encrypt(values0)
+		require.NoError(t, err)
+
+		// Other parties encrypt with the public key of the client
+		pk, err := client.PublicKey()
+		require.NoError(t, err)
+		encryptor, err := NewEncryptor(params, pk)
+		require.NoError(t, err)
+		ct1, err := encryptor.Encrypt(values1)
+		require.NoError(t, err)
+
+		// rotate(ct0 * ct1, 1) and ct0 + ct1
+		circuit := func(eval ckks.Evaluator, inputs []*ckks.Ciphertext) ([]*ckks.Ciphertext, error) {
+			prod := eval.MulRelinNew(inputs[0], inputs[1])
+			if err := eval.Rescale(prod, params.Scale(), prod); err != nil {
+				return nil, err
+			}
+			return []*ckks.Ciphertext{eval.RotateNew(prod, 1), eval.AddNew(inputs[0], inputs[1])}, nil
+		}
+
+		outputs, err := server.ShallowCopy().Evaluate(circuit, [][]byte{ct0, ct1})
+		require.NoError(t, err)
+		require.Len(t, outputs, 2)
+
+		want := make([]complex128, params.Slots())
+		for i := range want {
+			j := (i + 1) % len(want)
+			want[i] = values0[j] * values1[j]
+		}
+		have, err := client.Decrypt(outputs[0])
+		require.NoError(t, err)
+		requireClose(want, have)
+
+		for i := range want {
+			want[i] = values0[i] + values1[i]
+		}
+		have, err = client.Decrypt(outputs[1])
+		require.NoError(t, err)
+		requireClose(want, have)
+	})
+
+	t.Run("ShortInput", func(t *testing.T) {
+
+		ct, err := client.Encrypt(values0[:3])
+		require.NoError(t, err)
+
+		have, err := client.Decrypt(ct)
+		require.NoError(t, err)
+		requireClose(values0[:3], have[:3])
+		requireClose(make([]complex128, params.Slots()-3), have[3:])
+
+		_, err = client.Encrypt(make([]complex128, params.Slots()+1))
+		require.Error(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+
+		// The ciphertexts of another parameter set are rejected
+		other, err := ckks.NewParametersFromLiteral(ckks.PN14QP438)
+		require.NoError(t, err)
+		ct, err := NewClient(other).Encrypt(values0)
+		require.NoError(t, err)
+
+		_, err = server.Evaluate(func(eval ckks.Evaluator, inputs []*ckks.Ciphertext) ([]*ckks.Ciphertext, error) {
+			return inputs, nil
+		}, [][]byte{ct})
+		require.True(t, errors.Is(err, rlwe.ErrIncompatible))
+
+		_, err = client.Decrypt(ct)
+		require.True(t, errors.Is(err, rlwe.ErrIncompatible))
+
+		// The errors of the circuit are returned
+		errCircuit := errors.New("circuit error")
+		_, err = server.Evaluate(func(eval ckks.Evaluator, inputs []*ckks.Ciphertext) ([]*ckks.Ciphertext, error) {
+			return nil, errCircuit
+		}, nil)
+		require.True(t, errors.Is(err, errCircuit))
+
+		// Truncated evaluation keys are rejected
+		_, err = NewServer(evk[:len(evk)-1])
+		require.Error(t, err)
+	})
+
+	t.Run("ResumeClient", func(t *testing.T) {
+
+		ct, err := client.Encrypt(values0)
+		require.NoError(t, err)
+
+		resumed := NewClientFromSecretKey(params, client.SecretKey())
+		have, err := resumed.Decrypt(ct)
+		require.NoError(t, err)
+		requireClose(values0, have)
+	})
+}
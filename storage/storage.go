@@ -0,0 +1,376 @@
+// Package storage implements the encryption at rest of serialized keys and ciphertexts. The outputs of MarshalBinary
+// are sealed in an envelope: the data is encrypted and authenticated with an AEAD (AES-256-GCM or
+// ChaCha20-Poly1305) under a fresh random data key, which is itself wrapped by a KeyWrapper, either derived from a
+// user passphrase (see NewPassphraseKeyWrapper) or delegated to an external key management service.
+//
+// An envelope is laid out as
+//
+//	magic (4 bytes) | version (1 byte) | cipher (1 byte) | len(wrapped key) (2 bytes) | wrapped key | nonce | ciphertext
+//
+// where the header (everything before the nonce) is authenticated as associated data of the AEAD, so that any
+// modification of an envelope is detected when opening it.
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	envelopeMagic   = "LTGE"
+	envelopeVersion = 1
+	headerSize      = len(envelopeMagic) + 4
+
+	// dataKeySize is the size in bytes of the data keys, and of the keys derived from passphrases.
+	dataKeySize = 32
+)
+
+// Cipher is an AEAD used to encrypt the data of an envelope.
+type Cipher uint8
+
+const (
+	// AES256GCM is AES-256 in Galois/Counter Mode.
+	AES256GCM = Cipher(1)
+	// ChaCha20Poly1305 is the ChaCha20-Poly1305 AEAD of RFC 8439.
+	ChaCha20Poly1305 = Cipher(2)
+)
+
+// String returns the name of the cipher.
+func (c Cipher) String() string {
+	switch c {
+	case AES256GCM:
+		return "AES-256-GCM"
+	case ChaCha20Poly1305:
+		return "ChaCha20-Poly1305"
+	default:
+		return fmt.Sprintf("Cipher(%d)", uint8(c))
+	}
+}
+
+// newAEAD returns the AEAD of the cipher keyed with key.
+func (c Cipher) newAEAD(key []byte) (cipher.AEAD, error) {
+	switch c {
+	case AES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case ChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	default:
+		return nil, fmt.Errorf("unknown cipher %v", c)
+	}
+}
+
+// KeyWrapper is the interface of the key encryption mechanisms protecting the data keys of the envelopes. It can be
+// implemented on top of a key management service, whose wrapped keys are stored in the envelopes.
+type KeyWrapper interface {
+	// WrapKey encrypts the data key and returns the wrapped key.
+	WrapKey(dataKey []byte) (wrappedKey []byte, err error)
+	// UnwrapKey decrypts a wrapped key returned by WrapKey and returns the data key.
+	UnwrapKey(wrappedKey []byte) (dataKey []byte, err error)
+}
+
+// Envelope seals and opens serialized objects.
+type Envelope struct {
+	cipher  Cipher
+	wrapper KeyWrapper
+}
+
+// NewEnvelope creates a new Envelope encrypting the data with c under data keys wrapped by wrapper.
+func NewEnvelope(c Cipher, wrapper KeyWrapper) (*Envelope, error) {
+
+	if _, err := c.newAEAD(make([]byte, dataKeySize)); err != nil {
+		return nil, err
+	}
+
+	if wrapper == nil {
+		return nil, errors.New("the key wrapper cannot be nil")
+	}
+
+	return &Envelope{cipher: c, wrapper: wrapper}, nil
+}
+
+// Seal encrypts and authenticates data under a fresh data key and returns the envelope.
+func (env *Envelope) Seal(data []byte) (sealed []byte, err error) {
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err = rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	defer wipe(dataKey)
+
+	var wrappedKey []byte
+	if wrappedKey, err = env.wrapper.WrapKey(dataKey); err != nil {
+		return nil, fmt.Errorf("cannot Seal: %v", err)
+	}
+
+	if len(wrappedKey) > 0xFFFF {
+		return nil, fmt.Errorf("cannot Seal: the wrapped key is larger than %d bytes", 0xFFFF)
+	}
+
+	var aead cipher.AEAD
+	if aead, err = env.cipher.newAEAD(dataKey); err != nil {
+		return nil, err
+	}
+
+	sealed = make([]byte, headerSize+len(wrappedKey)+aead.NonceSize(), headerSize+len(wrappedKey)+aead.NonceSize()+len(data)+aead.Overhead())
+
+	copy(sealed, envelopeMagic)
+	ptr := len(envelopeMagic)
+	sealed[ptr] = envelopeVersion
+	sealed[ptr+1] = uint8(env.cipher)
+	binary.BigEndian.PutUint16(sealed[ptr+2:], uint16(len(wrappedKey)))
+	ptr += 4
+	ptr += copy(sealed[ptr:], wrappedKey)
+
+	nonce := sealed[ptr:]
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(sealed, nonce, data, sealed[:ptr]), nil
+}
+
+// Open authenticates and decrypts an envelope returned by Seal and returns the data. The cipher of the envelope is
+// read from its header, so that an Envelope can open the envelopes sealed with any cipher, as long as their data key
+// can be unwrapped by its KeyWrapper. Returns an error if the envelope is malformed or was modified.
+func (env *Envelope) Open(sealed []byte) (data []byte, err error) {
+
+	if len(sealed) < headerSize || string(sealed[:len(envelopeMagic)]) != envelopeMagic {
+		return nil, errors.New("cannot Open: not an envelope")
+	}
+
+	ptr := len(envelopeMagic)
+
+	if sealed[ptr] != envelopeVersion {
+		return nil, fmt.Errorf("cannot Open: unsupported envelope version %d", sealed[ptr])
+	}
+
+	c := Cipher(sealed[ptr+1])
+	wrappedKeyLen := int(binary.BigEndian.Uint16(sealed[ptr+2:]))
+	ptr += 4
+
+	if len(sealed) < ptr+wrappedKeyLen {
+		return nil, errors.New("cannot Open: truncated envelope")
+	}
+
+	var dataKey []byte
+	if dataKey, err = env.wrapper.UnwrapKey(sealed[ptr : ptr+wrappedKeyLen]); err != nil {
+		return nil, fmt.Errorf("cannot Open: %v", err)
+	}
+	defer wipe(dataKey)
+	ptr += wrappedKeyLen
+
+	var aead cipher.AEAD
+	if aead, err = c.newAEAD(dataKey); err != nil {
+		return nil, fmt.Errorf("cannot Open: %v", err)
+	}
+
+	if len(sealed) < ptr+aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("cannot Open: truncated envelope")
+	}
+
+	if data, err = aead.Open(nil, sealed[ptr:ptr+aead.NonceSize()], sealed[ptr+aead.NonceSize():], sealed[:ptr]); err != nil {
+		return nil, errors.New("cannot Open: message authentication failed")
+	}
+
+	return data, nil
+}
+
+// SealObject serializes obj with its MarshalBinary method and returns its envelope.
+func (env *Envelope) SealObject(obj encoding.BinaryMarshaler) (sealed []byte, err error) {
+
+	var data []byte
+	if data, err = obj.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	defer wipe(data)
+
+	return env.Seal(data)
+}
+
+// OpenObject opens the envelope and deserializes its data in obj with its UnmarshalBinary method.
+func (env *Envelope) OpenObject(sealed []byte, obj encoding.BinaryUnmarshaler) (err error) {
+
+	var data []byte
+	if data, err = env.Open(sealed); err != nil {
+		return err
+	}
+	defer wipe(data)
+
+	return obj.UnmarshalBinary(data)
+}
+
+// WriteFile seals obj and writes its envelope to the file at path, which is readable and writable only by the owner.
+// The file is replaced atomically, so that a previous file is never lost if the write fails.
+func (env *Envelope) WriteFile(path string, obj encoding.BinaryMarshaler) (err error) {
+
+	var sealed []byte
+	if sealed, err = env.SealObject(obj); err != nil {
+		return err
+	}
+
+	// The temporary file is created with the permissions 0600
+	var tmp *os.File
+	if tmp, err = ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp"); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadFile reads the envelope written at path by WriteFile and deserializes its data in obj.
+func (env *Envelope) ReadFile(path string, obj encoding.BinaryUnmarshaler) (err error) {
+
+	var sealed []byte
+	if sealed, err = ioutil.ReadFile(path); err != nil {
+		return err
+	}
+
+	return env.OpenObject(sealed, obj)
+}
+
+// PassphraseKeyWrapper is a KeyWrapper encrypting the data keys with AES-256-GCM under a key derived from a
+// passphrase with scrypt. Each wrapped key stores the cost and the random salt of its derivation.
+// Wrapped keys whose cost is larger than the cost of the PassphraseKeyWrapper are rejected without being derived.
+type PassphraseKeyWrapper struct {
+	passphrase []byte
+	logN       uint8
+}
+
+const (
+	// DefaultScryptLogN is the default log2 of the scrypt cost parameter N of the PassphraseKeyWrapper.
+	DefaultScryptLogN = 15
+	// MaxScryptLogN is the largest log2 of the scrypt cost parameter N of the PassphraseKeyWrapper, for which a
+	// derivation uses 1GiB of memory.
+	MaxScryptLogN = 20
+
+	scryptR        = 8
+	scryptP        = 1
+	scryptSaltSize = 16
+)
+
+// NewPassphraseKeyWrapper creates a new PassphraseKeyWrapper deriving its keys from passphrase with the default
+// scrypt cost.
+func NewPassphraseKeyWrapper(passphrase []byte) (*PassphraseKeyWrapper, error) {
+	return NewPassphraseKeyWrapperWithCost(passphrase, DefaultScryptLogN)
+}
+
+// NewPassphraseKeyWrapperWithCost creates a new PassphraseKeyWrapper deriving its keys from passphrase with the scrypt
+// cost parameter N = 2^logN, with 1 <= logN <= MaxScryptLogN. The keys are wrapped with this cost and unwrapped with
+// the cost stored in the wrapped key, which must not be larger.
+func NewPassphraseKeyWrapperWithCost(passphrase []byte, logN int) (*PassphraseKeyWrapper, error) {
+
+	if len(passphrase) == 0 {
+		return nil, errors.New("the passphrase cannot be empty")
+	}
+
+	if logN < 1 || logN > MaxScryptLogN {
+		return nil, fmt.Errorf("invalid scrypt cost 2^%d", logN)
+	}
+
+	return &PassphraseKeyWrapper{passphrase: append([]byte{}, passphrase...), logN: uint8(logN)}, nil
+}
+
+// WrapKey encrypts dataKey under a key derived from the passphrase with a fresh salt and returns the wrapped key.
+func (w *PassphraseKeyWrapper) WrapKey(dataKey []byte) (wrappedKey []byte, err error) {
+
+	wrappedKey = make([]byte, 1+scryptSaltSize)
+	wrappedKey[0] = w.logN
+
+	if _, err = rand.Read(wrappedKey[1:]); err != nil {
+		return nil, err
+	}
+
+	var aead cipher.AEAD
+	if aead, err = w.newAEAD(wrappedKey[0], wrappedKey[1:]); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	wrappedKey = append(wrappedKey, nonce...)
+
+	return aead.Seal(wrappedKey, nonce, dataKey, wrappedKey[:1+scryptSaltSize]), nil
+}
+
+// UnwrapKey decrypts a wrapped key returned by WrapKey and returns the data key. Returns an error if the passphrase
+// is wrong, if the wrapped key was modified or if its cost is larger than the cost of the wrapper.
+func (w *PassphraseKeyWrapper) UnwrapKey(wrappedKey []byte) (dataKey []byte, err error) {
+
+	if len(wrappedKey) < 1+scryptSaltSize || wrappedKey[0] < 1 || wrappedKey[0] > w.logN {
+		return nil, errors.New("invalid wrapped key")
+	}
+
+	var aead cipher.AEAD
+	if aead, err = w.newAEAD(wrappedKey[0], wrappedKey[1:1+scryptSaltSize]); err != nil {
+		return nil, err
+	}
+
+	ptr := 1 + scryptSaltSize
+
+	if len(wrappedKey) < ptr+aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("invalid wrapped key")
+	}
+
+	if dataKey, err = aead.Open(nil, wrappedKey[ptr:ptr+aead.NonceSize()], wrappedKey[ptr+aead.NonceSize():], wrappedKey[:ptr]); err != nil {
+		return nil, errors.New("wrong passphrase or modified wrapped key")
+	}
+
+	return dataKey, nil
+}
+
+// newAEAD returns the AES-256-GCM AEAD keyed with the key derived from the passphrase with the given cost and salt.
+func (w *PassphraseKeyWrapper) newAEAD(logN uint8, salt []byte) (cipher.AEAD, error) {
+
+	key, err := scrypt.Key(w.passphrase, salt, 1<<logN, scryptR, scryptP, dataKeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(key)
+
+	return AES256GCM.newAEAD(key)
+}
+
+// wipe overwrites b with zeros.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
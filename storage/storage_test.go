@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+// testScryptLogN is a low scrypt cost to keep the tests fast.
+const testScryptLogN = 10
+
+func testString(opname string, c Cipher) string {
+	return fmt.Sprintf("%s/cipher=%v", opname, c)
+}
+
+func TestStorage(t *testing.T) {
+
+	wrapper, err := NewPassphraseKeyWrapperWithCost([]byte("correct horse battery staple"), testScryptLogN)
+	require.NoError(t, err)
+
+	wrongWrapper, err := NewPassphraseKeyWrapperWithCost([]byte("wrong passphrase"), testScryptLogN)
+	require.NoError(t, err)
+
+	params, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+
+	sk := bfv.NewKeyGenerator(params).GenSecretKey()
+
+	for _, c := range []Cipher{AES256GCM, ChaCha20Poly1305} {
+
+		env, err := NewEnvelope(c, wrapper)
+		require.NoError(t, err)
+
+		t.Run(testString("Seal/Open", c), func(t *testing.T) {
+
+			data := []byte("some serialized key")
+
+			sealed, err := env.Seal(data)
+			require.NoError(t, err)
+			require.NotContains(t, string(sealed), string(data))
+
+			opened, err := env.Open(sealed)
+			require.NoError(t, err)
+			require.Equal(t, data, opened)
+
+			// Two envelopes of the same data differ
+			sealed2, err := env.Seal(data)
+			require.NoError(t, err)
+			require.NotEqual(t, sealed, sealed2)
+
+			// The cipher is read from the header
+			other, err := NewEnvelope(AES256GCM+ChaCha20Poly1305-c, wrapper)
+			require.NoError(t, err)
+			opened, err = other.Open(sealed)
+			require.NoError(t, err)
+			require.Equal(t, data, opened)
+		})
+
+		t.Run(testString("Open/Tampered", c), func(t *testing.T) {
+
+			sealed, err := env.Seal([]byte("some serialized key"))
+			require.NoError(t, err)
+
+			for _, i := range []int{len(envelopeMagic) + 1, headerSize, len(sealed) - 1} {
+				tampered := append([]byte{}, sealed...)
+				tampered[i] ^= 1
+				_, err = env.Open(tampered)
+				require.Error(t, err)
+			}
+
+			_, err = env.Open(sealed[:len(sealed)-1])
+			require.Error(t, err)
+
+			_, err = env.Open(sealed[:headerSize])
+			require.Error(t, err)
+
+			wrongEnv, err := NewEnvelope(c, wrongWrapper)
+			require.NoError(t, err)
+			_, err = wrongEnv.Open(sealed)
+			require.Error(t, err)
+		})
+
+		t.Run(testString("WriteFile/ReadFile", c), func(t *testing.T) {
+
+			dir, err := ioutil.TempDir("", "storage")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "sk.bin")
+
+			require.NoError(t, env.WriteFile(path, sk))
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+			skHave := rlwe.NewSecretKey(params.Parameters)
+			require.NoError(t, env.ReadFile(path, skHave))
+			require.True(t, params.RingQP().Equal(sk.Value, skHave.Value))
+		})
+	}
+
+	t.Run("NewEnvelope/Invalid", func(t *testing.T) {
+		_, err := NewEnvelope(Cipher(0), wrapper)
+		require.Error(t, err)
+
+		_, err = NewEnvelope(AES256GCM, nil)
+		require.Error(t, err)
+
+		_, err = NewPassphraseKeyWrapper(nil)
+		require.Error(t, err)
+
+		_, err = NewPassphraseKeyWrapperWithCost([]byte("passphrase"), MaxScryptLogN+1)
+		require.Error(t, err)
+	})
+
+	t.Run("UnwrapKey/Cost", func(t *testing.T) {
+
+		wrappedKey, err := wrapper.WrapKey(make([]byte, 32))
+		require.NoError(t, err)
+
+		// A wrapped key cannot request a larger cost than the one of the wrapper
+		wrappedKey[0] = 30
+		_, err = wrapper.UnwrapKey(wrappedKey)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// UnmarshalJSONStrict decodes the JSON value data into v like json.Unmarshal, but returns an error if data has
+// fields that do not match the fields of v, or trailing data after the value.
+func UnmarshalJSONStrict(data []byte, v interface{}) (err error) {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err = dec.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err = dec.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+
+	return nil
+}
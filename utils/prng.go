@@ -1,9 +1,9 @@
 package utils
 
 import (
-	"crypto/rand"
 	"errors"
 
+	"github.com/ldsec/lattigo/v2/internal/randsource"
 	"golang.org/x/crypto/blake2b"
 )
 
@@ -34,13 +34,14 @@ func NewKeyedPRNG(key []byte) (*KeyedPRNG, error) {
 	return prng, err
 }
 
-// NewPRNG creates KeyedPRNG keyed from rand.Read for instances were no key should be provided by the user
+// NewPRNG creates KeyedPRNG keyed from rand.Read for instances were no key should be provided by the user.
+// In the deterministic mode of the tests of the library, the key is instead drawn from a seeded PRNG.
 func NewPRNG() (*KeyedPRNG, error) {
 	var err error
 	prng := new(KeyedPRNG)
 	prng.clock = 0
 	randomBytes := make([]byte, 64)
-	if _, err := rand.Read(randomBytes); err != nil {
+	if _, err := randsource.Read(randomBytes); err != nil {
 		panic("crypto rand error")
 	}
 	prng.xof, err = blake2b.NewXOF(blake2b.OutputLengthUnknown, randomBytes)
@@ -3,6 +3,7 @@ package utils
 import (
 	"testing"
 
+	"github.com/ldsec/lattigo/v2/internal/randsource"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,4 +33,32 @@ func Test_PRNG(t *testing.T) {
 		require.Equal(t, sum0, sum1)
 	})
 
+	t.Run("DeterministicPRNG", func(t *testing.T) {
+
+		defer randsource.SetSeed(nil)
+
+		draw := func() (sum []byte, u uint64, f float64) {
+			prng, _ := NewPRNG()
+			sum = make([]byte, 64)
+			prng.Clock(sum)
+			return sum, RandUint64(), RandFloat64(-1, 1)
+		}
+
+		randsource.SetSeed([]byte("seed"))
+		require.True(t, randsource.IsDeterministic())
+		sum0, u0, f0 := draw()
+
+		randsource.SetSeed([]byte("seed"))
+		sum1, u1, f1 := draw()
+
+		require.Equal(t, sum0, sum1)
+		require.Equal(t, u0, u1)
+		require.Equal(t, f0, f1)
+
+		randsource.SetSeed(nil)
+		require.False(t, randsource.IsDeterministic())
+		sum2, _, _ := draw()
+
+		require.NotEqual(t, sum0, sum2)
+	})
 }
@@ -1,15 +1,16 @@
 package utils
 
 import (
-	"crypto/rand"
 	"encoding/binary"
 	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/internal/randsource"
 )
 
 // RandUint64 return a random value between 0 and 0xFFFFFFFFFFFFFFFF
 func RandUint64() uint64 {
 	b := []byte{0, 0, 0, 0, 0, 0, 0, 0}
-	if _, err := rand.Read(b); err != nil {
+	if _, err := randsource.Read(b); err != nil {
 		panic(err)
 	}
 	return binary.BigEndian.Uint64(b)
@@ -18,7 +19,7 @@ func RandUint64() uint64 {
 // RandFloat64 returns a random float between min and max
 func RandFloat64(min, max float64) float64 {
 	b := []byte{0, 0, 0, 0, 0, 0, 0, 0}
-	if _, err := rand.Read(b); err != nil {
+	if _, err := randsource.Read(b); err != nil {
 		panic(err)
 	}
 	f := float64(binary.BigEndian.Uint64(b)) / 1.8446744073709552e+19